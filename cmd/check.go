@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/check"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// runCheckOnly 非交互式执行全部预检并打印汇总报告，存在错误时返回非零退出码
+func runCheckOnly(cfg *config.Config) error {
+	fmt.Println("🔍 Rainbond 安装预检")
+	fmt.Println(strings.Repeat("=", 60))
+
+	checker := check.NewBasicChecker(cfg)
+	checker.SetDiskProbe(checkDisk)
+	report := checker.RunCheckOnly()
+
+	for _, result := range report.Hosts {
+		icon := "\033[32m✓\033[0m"
+		if result.Status != "通过" {
+			icon = "\033[31m✗\033[0m"
+		}
+		fmt.Printf("%s %-15s 角色: %-20s 系统: %s %s, 内核: %s, CPU: %d 核, 内存: %d GB\n",
+			icon, result.IP, strings.Join(result.Role, ","), result.OS, result.Arch, result.Kernel, result.CPUCores, result.MemoryGB)
+	}
+	fmt.Println()
+
+	if len(report.Errors) > 0 {
+		fmt.Printf("\033[31m错误 (%d):\033[0m\n", len(report.Errors))
+		for i, e := range report.Errors {
+			fmt.Printf("  %d. %s\n", i+1, e)
+		}
+		fmt.Println()
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Printf("\033[33m警告 (%d):\033[0m\n", len(report.Warnings))
+		for i, w := range report.Warnings {
+			fmt.Printf("  %d. %s\n", i+1, w)
+		}
+		fmt.Println()
+	}
+
+	if !report.Ready() {
+		return fmt.Errorf("预检未通过: %d 个错误, %d 个警告", len(report.Errors), len(report.Warnings))
+	}
+	fmt.Printf("\033[32m✅ 预检通过，环境已就绪 (%d 个警告)\033[0m\n", len(report.Warnings))
+	return nil
+}
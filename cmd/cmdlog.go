@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// commandLogStages --log-commands 可选的阶段，与 roi up 的单阶段参数同名
+var commandLogStages = []string{"check", "dns", "lvm", "optimize", "cluster-compat", "rke2", "mysql", "rainbond"}
+
+// validateLogCommands 检查 --log-commands 的阶段名称
+func validateLogCommands() error {
+	for _, stage := range logCommands {
+		if stage != "all" && !containsStage(commandLogStages, stage) {
+			return fmt.Errorf("--log-commands 不支持的阶段 '%s'，可选值: all, %s", stage, strings.Join(commandLogStages, ", "))
+		}
+	}
+	return nil
+}
+
+// commandLogEnabled 判断是否记录该阶段执行的远程命令
+func commandLogEnabled(stage string) bool {
+	return containsStage(logCommands, "all") || containsStage(logCommands, stage)
+}
+
+// registerCommandSecrets 登记配置中的敏感值，命令日志中替换为 ****
+func registerCommandSecrets(cfg *config.Config) {
+	ssh.RegisterSecrets(cfg.SecretValues()...)
+	ssh.RegisterSecrets(rke2.RKE2DefaultToken)
+}
+
+// setStageCommandLog 进入阶段时按 --log-commands 开启或关闭远程命令日志，命令以Debug级别写入日志文件
+func setStageCommandLog(stage string, appLogger *logger.Logger) {
+	if commandLogEnabled(stage) {
+		appLogger.InfoToFileOnly("记录%s阶段执行的远程命令（敏感值已脱敏）", stage)
+		ssh.SetCommandLogger(appLogger)
+		return
+	}
+	ssh.SetCommandLogger(nil)
+}
+
+// singleStage 返回 roi up 单阶段运行时的阶段名称，与执行顺序一致；完整安装时返回空
+func singleStage() string {
+	switch {
+	case checkFlag:
+		return "check"
+	case lvmFlag:
+		return "lvm"
+	case dnsFlag:
+		return "dns"
+	case rke2Flag:
+		return "rke2"
+	case optimizeFlag:
+		return "optimize"
+	case mysqlFlag:
+		return "mysql"
+	case rainbondFlag:
+		return "rainbond"
+	}
+	return ""
+}
+
+// startSingleStageCommandLog 单阶段运行时没有安装日志，需要记录命令时单独创建日志文件，返回关闭函数
+func startSingleStageCommandLog(stage string) (func(), error) {
+	if !commandLogEnabled(stage) {
+		return func() {}, nil
+	}
+	cmdLogger, err := logger.NewLogger(logger.WARN, logger.DEBUG)
+	if err != nil {
+		return nil, fmt.Errorf("初始化日志记录器失败: %w", err)
+	}
+	ssh.SetCommandLogger(cmdLogger)
+	fmt.Printf("\033[36m[INFO]\033[0m 远程命令记录到日志文件（敏感值已脱敏）: %s\n", cmdLogger.GetLogFilePath())
+	return func() {
+		ssh.SetCommandLogger(nil)
+		cmdLogger.Close()
+	}, nil
+}
+
+func containsStage(stages []string, stage string) bool {
+	for _, s := range stages {
+		if s == stage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/doctor"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose a failed or partially installed cluster",
+	Long: `Inspect a live (possibly broken) cluster and report probable root causes:
+  - SSH to each node and summarize rke2 service state and recent errors
+  - Query the Kubernetes API for not-Ready nodes and non-Running pods
+    in kube-system and the Rainbond namespace
+  - Check the Rainbond console endpoint
+  - Flag common issues: taints blocking scheduling, image pull failures,
+    database connection errors
+
+Usage examples:
+  roi doctor
+  roi doctor --config config.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runDoctor(cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
+
+func runDoctor(cfg *config.Config) error {
+	fmt.Println("🩺 Rainbond 集群诊断")
+	fmt.Println(strings.Repeat("=", 60))
+
+	issues, err := doctor.NewDoctor(cfg).Run()
+	if err != nil {
+		return fmt.Errorf("诊断失败: %w", err)
+	}
+
+	if len(issues) == 0 {
+		fmt.Println("\033[32m✅ 未发现问题，集群运行正常\033[0m")
+		return nil
+	}
+
+	fmt.Printf("发现 %d 个问题（按优先级排序）:\n\n", len(issues))
+	for i, issue := range issues {
+		color := "\033[36m"
+		switch issue.Severity {
+		case doctor.SeverityCritical:
+			color = "\033[31m"
+		case doctor.SeverityWarning:
+			color = "\033[33m"
+		}
+		fmt.Printf("%d. %s[%s]\033[0m %s: %s\n", i+1, color, issue.Severity, issue.Target, issue.Summary)
+		if issue.Detail != "" {
+			for _, line := range strings.Split(issue.Detail, "\n") {
+				fmt.Printf("     %s\n", line)
+			}
+		}
+		if issue.Suggestion != "" {
+			fmt.Printf("   💡 %s\n", issue.Suggestion)
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	restoreSnapshot string
+	restoreForce    bool
+)
+
+var etcdRestoreCmd = &cobra.Command{
+	Use:   "etcd-restore",
+	Short: "Restore the cluster's etcd from a snapshot (destructive)",
+	Long: `Restore etcd from a snapshot with the RKE2 cluster-reset procedure:
+  1. Verify every server node is reachable and the snapshot exists on the
+     bootstrap (first etcd) node
+  2. Stop rke2-server on all server nodes
+  3. Run "rke2 server --cluster-reset --cluster-reset-restore-path=<snapshot>"
+     on the bootstrap node, then start rke2-server there
+  4. On every other server node, move the old etcd data directory aside
+     (<data-dir>/server/db.roi-restore-<timestamp>) and start
+     rke2-server so it rejoins the restored cluster, one node at a time
+  5. Wait until the API server and all nodes are healthy
+
+All cluster state written after the snapshot was taken is lost. The command
+prints the plan and asks for confirmation before stopping anything. For
+unattended runs the confirmation is skipped only when both --assume-yes and
+--force are given; --assume-yes alone is refused.
+
+--snapshot accepts a snapshot file name from "roi etcd-snapshot ls" (looked up
+in <data-dir>/server/db/snapshots on the bootstrap node, data-dir defaults to
+/var/lib/rancher/rke2 and is set with rke2.data_dir) or an
+absolute path on the bootstrap node.
+
+Usage examples:
+  roi etcd-restore --snapshot roi-snapshot-node1-1700000000`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runEtcdRestore(cfg)
+	},
+}
+
+func init() {
+	etcdRestoreCmd.Flags().StringVar(&restoreSnapshot, "snapshot", "", "Snapshot file name or absolute path on the bootstrap node (required)")
+	etcdRestoreCmd.MarkFlagRequired("snapshot")
+	etcdRestoreCmd.Flags().BoolVar(&restoreForce, "force", false, "Together with --assume-yes, restore without the interactive confirmation")
+	rootCmd.AddCommand(etcdRestoreCmd)
+}
+
+func runEtcdRestore(cfg *config.Config) error {
+	installer := rke2.NewRKE2Installer(cfg)
+	bootstrap, others := installer.RestorePlan()
+	if bootstrap == nil {
+		return fmt.Errorf("配置中未找到etcd节点")
+	}
+
+	fmt.Println("⚠️  etcd快照恢复")
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("快照: %s\n", installer.ResolveSnapshotPath(restoreSnapshot))
+	fmt.Printf("bootstrap节点（执行cluster-reset）: %s\n", bootstrap.IP)
+	for _, host := range others {
+		fmt.Printf("重新加入的server节点（旧etcd数据将被移走备份）: %s\n", host.IP)
+	}
+	fmt.Println()
+	fmt.Println("恢复期间所有server节点的rke2-server将被停止，集群不可用；快照之后写入的集群状态将全部丢失。")
+	if err := confirmEtcdRestore(); err != nil {
+		return err
+	}
+
+	appLogger, err := logger.NewLogger(logger.INFO, logger.DEBUG)
+	if err != nil {
+		return fmt.Errorf("初始化日志记录器失败: %w", err)
+	}
+	defer appLogger.Close()
+
+	if err := rke2.NewRKE2InstallerWithLogger(cfg, appLogger).RestoreEtcd(restoreSnapshot); err != nil {
+		appLogger.Error("etcd恢复失败: %v", err)
+		return fmt.Errorf("etcd恢复失败: %w，详细日志文件: %s", err, appLogger.GetLogFilePath())
+	}
+
+	fmt.Printf("\033[32m✅ etcd已从快照恢复，集群健康检查通过\033[0m，详细日志文件: %s\n", appLogger.GetLogFilePath())
+	return nil
+}
+
+// confirmEtcdRestore 确认执行恢复：交互式输入restore，或同时指定 --assume-yes 和 --force 跳过确认
+func confirmEtcdRestore() error {
+	if assumeYes {
+		if !restoreForce {
+			return fmt.Errorf("etcd恢复是破坏性操作，--assume-yes 不会自动确认；无人值守执行需同时指定 --force")
+		}
+		fmt.Println("已指定 --assume-yes 和 --force，跳过确认")
+		return nil
+	}
+
+	fmt.Printf("确认恢复请输入 restore: ")
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("无法读取用户输入: %w", err)
+	}
+	if strings.TrimSpace(response) != "restore" {
+		return fmt.Errorf("用户取消恢复")
+	}
+	return nil
+}
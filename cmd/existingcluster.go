@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/events"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+)
+
+// applyExistingCluster 将 --existing-cluster 指定的kubeconfig写入配置，优先于配置文件中的 existing_cluster.kubeconfig
+func applyExistingCluster(cfg *config.Config) error {
+	if existingCluster != "" {
+		cfg.ExistingCluster.Kubeconfig = existingCluster
+		if err := cfg.CheckKubeconfig(); err != nil {
+			return err
+		}
+	}
+	if cfg.IsExistingCluster() && rke2Flag {
+		return fmt.Errorf("已指定已有集群（kubeconfig: %s），不能再执行 --rke2 安装", cfg.KubeconfigPath())
+	}
+	return nil
+}
+
+// runClusterCompat 单独执行MySQL或Rainbond阶段前检查已有集群的兼容性并打印结果
+func runClusterCompat(cfg *config.Config) error {
+	results, err := cluster.NewCompatChecker(cfg).Run()
+	printCompatResults(results)
+	return err
+}
+
+func runClusterCompatWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
+	logger.Info("集群兼容性检查: 检查已有Kubernetes集群 %s", cfg.KubeconfigPath())
+	stepProgress.UpdateStepProgress("检查已有Kubernetes集群...")
+	_, err := cluster.NewCompatCheckerWithLogger(cfg, logger).Run()
+	return err
+}
+
+func printCompatResults(results []cluster.Result) {
+	fmt.Println("🔍 已有集群兼容性检查")
+	fmt.Println(strings.Repeat("=", 60))
+	for _, result := range results {
+		mark := "✅"
+		switch result.Status {
+		case cluster.StatusWarn:
+			mark = "⚠️ "
+		case cluster.StatusFail:
+			mark = "❌"
+		}
+		fmt.Printf("%s %-16s %s\n", mark, result.Name, result.Detail)
+	}
+	fmt.Println(strings.Repeat("=", 60))
+}
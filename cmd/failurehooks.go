@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/hooks"
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// newHookSummary 创建钩子使用的安装摘要
+func newHookSummary(cfg *config.Config, status string, stages []installStage, startedAt time.Time, appLogger *logger.Logger) *hooks.Summary {
+	summary := &hooks.Summary{
+		Status:      status,
+		ClusterName: cfg.ClusterName,
+		StartedAt:   startedAt.Format(time.RFC3339),
+		FinishedAt:  time.Now().Format(time.RFC3339),
+		DurationSec: int64(time.Since(startedAt).Seconds()),
+		LogFile:     appLogger.GetLogFilePath(),
+	}
+	for _, host := range cfg.Hosts {
+		summary.Hosts = append(summary.Hosts, host.IP)
+	}
+	for _, stage := range stages {
+		summary.Stages = append(summary.Stages, stage.name)
+	}
+	summary.Transfers = installTransfers
+	return summary
+}
+
+// installTransfers RKE2阶段的离线资源传输统计，写入钩子的安装摘要
+var installTransfers []hooks.TransferSummary
+
+// recordTransferStats 记录RKE2阶段的离线资源传输统计
+func recordTransferStats(stats []rke2.TransferStats) {
+	installTransfers = nil
+	for _, s := range stats {
+		installTransfers = append(installTransfers, hooks.TransferSummary{
+			Host:         s.Host,
+			Files:        s.Files,
+			Bytes:        s.Bytes,
+			SkippedFiles: s.SkippedFiles,
+			SkippedBytes: s.SkippedBytes,
+			ElapsedSec:   s.Elapsed.Seconds(),
+			MBps:         s.MBps(),
+		})
+	}
+}
+
+// runFailureHooks 阶段失败时执行 hooks.on_failure，尽力而为：钩子本身失败只输出和记录日志，调用方始终返回原始错误
+func runFailureHooks(cfg *config.Config, stages []installStage, stage string, stageErr error, startedAt time.Time, appLogger *logger.Logger) {
+	if cfg.Hooks.OnFailure.IsEmpty() {
+		return
+	}
+	summary := newHookSummary(cfg, "failure", stages, startedAt, appLogger)
+	summary.FailedStage = stage
+	summary.FailedHosts = failedHosts(cfg, stageErr)
+	summary.Error = stageErr.Error()
+	if err := runHooks("失败钩子", cfg.Hooks.OnFailure, summary, appLogger); err != nil {
+		appLogger.Error("%v", err)
+		fmt.Printf("\033[33m[WARN]\033[0m %v，安装失败原因见上方错误信息\n", err)
+	}
+}
+
+// failedHosts 确定失败涉及的主机：--continue-on-error 汇总的失败主机，否则为错误信息中出现的主机地址
+func failedHosts(cfg *config.Config, err error) []string {
+	var hostErrs ssh.HostErrors
+	if errors.As(err, &hostErrs) {
+		hosts := make([]string, 0, len(hostErrs))
+		for _, hostErr := range hostErrs {
+			hosts = append(hosts, hostErr.Host)
+		}
+		return hosts
+	}
+
+	var hosts []string
+	message := err.Error()
+	for _, host := range cfg.Hosts {
+		// 按完整地址匹配，避免 10.0.0.1 匹配到 10.0.0.12
+		pattern := `(^|[^0-9A-Fa-f.])` + regexp.QuoteMeta(host.IP) + `($|[^0-9A-Fa-f])`
+		if regexp.MustCompile(pattern).MatchString(message) {
+			hosts = append(hosts, host.IP)
+		}
+	}
+	return hosts
+}
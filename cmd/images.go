@@ -0,0 +1,270 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/images"
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+	"github.com/spf13/cobra"
+)
+
+var (
+	imagesOutput       string
+	imagesTarballs     []string
+	imagesFile         string
+	imagesVerifySample int
+	imagesOnlyNew      bool
+)
+
+var imagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Report which expected offline images are missing on each node",
+	Long: `List the images in RKE2's containerd on every node (crictl, falling back to ctr)
+and compare them against the expected images, reporting missing images per node.
+
+The expected image list is read from the offline image tarballs in the current
+directory (rke2-images-linux.tar and rainbond-offline-images.tar) and, optionally,
+from a plain list file with one image per line (e.g. images extracted from the
+Rainbond chart with "helm template ... | grep image:").
+
+Exits non-zero when any node is missing images or cannot be inspected.
+
+Usage examples:
+  roi images
+  roi images -o json
+  roi images --image-tarball rainbond-offline-images.tar
+  roi images --images-file rainbond-images.txt`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runImages(cfg)
+	},
+}
+
+func init() {
+	imagesCmd.Flags().StringVarP(&imagesOutput, "output", "o", "table", "Output format: table, json")
+	imagesCmd.Flags().StringArrayVar(&imagesTarballs, "image-tarball", nil, "Offline image tarball to read expected images from (can be repeated, default: rke2-images-linux.tar and rainbond-offline-images.tar if present)")
+	imagesCmd.Flags().StringVar(&imagesFile, "images-file", "", "File listing additional expected images, one per line")
+	imagesLoadCmd.Flags().StringArrayVar(&imagesTarballs, "image-tarball", nil, "Offline image tarball to load (can be repeated, default: rke2-images-linux.tar and rainbond-offline-images.tar if present)")
+	imagesLoadCmd.Flags().IntVar(&imagesVerifySample, "verify-sample", 20, "Number of images sampled evenly from the tarballs and checked on every node after import (0 checks all)")
+	imagesLoadCmd.Flags().BoolVar(&imagesOnlyNew, "only-new-images", false, "Only transfer and import images that are missing or changed on each node (compared by image ID via crictl)")
+	imagesCmd.AddCommand(imagesLoadCmd)
+	rootCmd.AddCommand(imagesCmd)
+}
+
+var imagesLoadCmd = &cobra.Command{
+	Use:   "load",
+	Short: "Load offline image tarballs into containerd on all nodes in parallel",
+	Long: `Transfer the offline image tarballs to every node and import them into RKE2's
+running containerd (ctr -n k8s.io images import). RKE2 only imports the tarballs
+in <data-dir>/agent/images (default /var/lib/rancher/rke2, see rke2.data_dir)
+when it starts, so use this to load new or
+updated images into an already running cluster without restarting RKE2.
+
+Nodes are processed in parallel, bounded by --concurrency. Tarballs already
+present on a node with the same checksum are not transferred again. After the
+import a representative sample of the images is checked on each node. Every
+node reports its progress and result; the command exits non-zero when any node
+fails.
+
+With --only-new-images the image IDs in the tarballs are compared with the
+images already in each node's containerd (crictl images), and only the missing
+or changed images are packed into a smaller tarball, transferred and imported;
+tarballs whose images are all current are skipped. A stale copy of the tarball
+in the RKE2 images directory is removed so a restart does not re-import the old
+images. Tarballs without a docker manifest.json (OCI layout), nodes without
+crictl, and tarballs where every image changed fall back to the full import,
+which remains the default.
+
+Usage examples:
+  roi images load
+  roi images load --concurrency 8
+  roi images load --only-new-images
+  roi images load --image-tarball rainbond-offline-images.tar --verify-sample 0`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runImagesLoad(cfg)
+	},
+}
+
+// imagesReport images命令的JSON输出
+type imagesReport struct {
+	Expected int                 `json:"expected"`
+	Nodes    []images.NodeReport `json:"nodes"`
+}
+
+func runImages(cfg *config.Config) error {
+	if imagesOutput != "table" && imagesOutput != "json" {
+		return fmt.Errorf("不支持的输出格式 '%s'，可选值: table, json", imagesOutput)
+	}
+
+	expected, err := loadExpectedImages()
+	if err != nil {
+		return err
+	}
+
+	inventory := images.NewInventory(cfg, expected)
+	report := imagesReport{Expected: len(inventory.Expected()), Nodes: inventory.Run()}
+
+	failed := 0
+	for _, node := range report.Nodes {
+		if node.Error != "" || len(node.Missing) > 0 {
+			failed++
+		}
+	}
+
+	if imagesOutput == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化镜像清点结果为JSON失败: %w", err)
+		}
+		fmt.Println(string(data))
+	} else {
+		printImagesTable(report)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d 个节点缺少镜像或清点失败", failed)
+	}
+	return nil
+}
+
+func runImagesLoad(cfg *config.Config) error {
+	tarballs := defaultTarballs()
+	if len(tarballs) == 0 {
+		return fmt.Errorf("未找到离线镜像包：当前目录下没有 %s，请通过 --image-tarball 指定",
+			strings.Join(images.DefaultTarballs, "、"))
+	}
+	var expected []string
+	for _, path := range tarballs {
+		refs, err := images.ReadTarballImages(path)
+		if err != nil {
+			return err
+		}
+		expected = append(expected, refs...)
+	}
+	sample := images.SampleImages(images.NewInventory(cfg, expected).Expected(), imagesVerifySample)
+
+	appLogger, err := logger.NewProgressLogger()
+	if err != nil {
+		return fmt.Errorf("初始化日志记录器失败: %w", err)
+	}
+	defer appLogger.Close()
+
+	fmt.Printf("📦 向 %d 个节点加载 %d 个镜像包（并发 %d），导入后抽样校验 %d 个镜像\n",
+		len(cfg.Hosts), len(tarballs), ssh.Concurrency(), len(sample))
+	var mu sync.Mutex
+	progress := func(host, message string) {
+		mu.Lock()
+		defer mu.Unlock()
+		fmt.Printf("  [%s] %s\n", host, message)
+	}
+
+	installer := rke2.NewRKE2InstallerWithLogger(cfg, appLogger)
+	installer.SetOnlyNewImages(imagesOnlyNew)
+	results := installer.LoadImages(tarballs, sample, 0, progress)
+
+	failed := 0
+	fmt.Println()
+	for _, result := range results {
+		if result.Err == nil {
+			fmt.Printf("\033[32m ✓ %s (%s)\033[0m\n", result.Host, result.Duration.Round(time.Second))
+			continue
+		}
+		failed++
+		fmt.Printf("\033[31m ✗ %s: %v\033[0m\n", result.Host, result.Err)
+		for _, ref := range result.Missing {
+			fmt.Printf("    - %s\n", ref)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d 个节点加载镜像失败，详细日志: %s", failed, appLogger.GetLogFilePath())
+	}
+	return nil
+}
+
+// defaultTarballs 返回 --image-tarball 指定的镜像包，未指定时使用当前目录下存在的默认镜像包
+func defaultTarballs() []string {
+	if len(imagesTarballs) > 0 {
+		return imagesTarballs
+	}
+	var tarballs []string
+	for _, path := range images.DefaultTarballs {
+		if _, err := os.Stat(path); err == nil {
+			tarballs = append(tarballs, path)
+		}
+	}
+	return tarballs
+}
+
+// loadExpectedImages 从离线镜像包和镜像清单文件汇总期望镜像
+func loadExpectedImages() ([]string, error) {
+	tarballs := defaultTarballs()
+
+	var expected []string
+	for _, path := range tarballs {
+		refs, err := images.ReadTarballImages(path)
+		if err != nil {
+			return nil, err
+		}
+		expected = append(expected, refs...)
+	}
+	if imagesFile != "" {
+		refs, err := images.ReadImageList(imagesFile)
+		if err != nil {
+			return nil, err
+		}
+		expected = append(expected, refs...)
+	}
+
+	if len(expected) == 0 {
+		return nil, fmt.Errorf("未找到期望镜像列表：当前目录下没有 %s，请通过 --image-tarball 或 --images-file 指定",
+			strings.Join(images.DefaultTarballs, "、"))
+	}
+	return expected, nil
+}
+
+func printImagesTable(report imagesReport) {
+	fmt.Println("📦 节点镜像清点")
+	fmt.Println(strings.Repeat("=", 60))
+	fmt.Printf("期望镜像: %d 个\n\n", report.Expected)
+
+	fmt.Printf("%-18s %-8s %-12s %-8s %s\n", "主机", "工具", "期望镜像", "镜像总数", "状态")
+	for _, node := range report.Nodes {
+		status := "\033[32m完整\033[0m"
+		switch {
+		case node.Error != "":
+			status = "\033[31m清点失败\033[0m"
+		case len(node.Missing) > 0:
+			status = fmt.Sprintf("\033[31m缺少 %d 个\033[0m", len(node.Missing))
+		}
+		fmt.Printf("%-18s %-8s %-12s %-8d %s\n", node.Host, node.Tool,
+			fmt.Sprintf("%d/%d", node.Present, report.Expected), node.Total, status)
+	}
+
+	for _, node := range report.Nodes {
+		if node.Error == "" && len(node.Missing) == 0 {
+			continue
+		}
+		fmt.Printf("\n主机 %s:\n", node.Host)
+		if node.Error != "" {
+			fmt.Printf("  \033[31m✗\033[0m %s\n", node.Error)
+		}
+		for _, ref := range node.Missing {
+			fmt.Printf("  - %s\n", ref)
+		}
+	}
+}
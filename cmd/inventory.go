@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/check"
+	"github.com/rainbond/rainbond-offline-installer/internal/lvm"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	inventoryOutput string
+	inventoryFile   string
+)
+
+var inventoryCmd = &cobra.Command{
+	Use:   "inventory",
+	Short: "Export discovered host facts as JSON or YAML",
+	Long: `Collect the facts of every host over SSH and write them to a file for
+documentation or a CMDB:
+  - roles, Rainbond roles and node name from the configuration
+  - OS, architecture, kernel, CPU cores, memory and root partition usage
+  - LVM layout: volume group, PV devices, logical volumes and mounts
+
+Inventory is read-only: it never prompts, installs packages (lvm2 is not
+auto-installed even with lvm.auto_install_tools) or changes the hosts.
+Facts that cannot be collected are listed in the host's "errors" field.
+
+Usage examples:
+  roi inventory
+  roi inventory -o yaml
+  roi inventory --file cluster-inventory.json
+  roi inventory --file - | jq '.hosts[].kernel'`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runInventory(cfg)
+	},
+}
+
+func init() {
+	inventoryCmd.Flags().StringVarP(&inventoryOutput, "output", "o", "json", "Output format: json, yaml")
+	inventoryCmd.Flags().StringVar(&inventoryFile, "file", "", "File to write the inventory to, '-' for stdout (default: roi-inventory.json or roi-inventory.yaml)")
+	rootCmd.AddCommand(inventoryCmd)
+}
+
+// inventoryReport inventory命令的输出
+type inventoryReport struct {
+	GeneratedAt string          `json:"generated_at" yaml:"generated_at"`
+	ClusterName string          `json:"cluster_name,omitempty" yaml:"cluster_name,omitempty"`
+	Hosts       []inventoryHost `json:"hosts" yaml:"hosts"`
+}
+
+// inventoryHost 单个主机的配置角色和采集到的系统信息
+type inventoryHost struct {
+	IP          string         `json:"ip" yaml:"ip"`
+	InternalIP  string         `json:"internal_ip,omitempty" yaml:"internal_ip,omitempty"`
+	NodeName    string         `json:"node_name" yaml:"node_name"`
+	Roles       []string       `json:"roles" yaml:"roles"`
+	RbdRoles    []string       `json:"rbd_roles,omitempty" yaml:"rbd_roles,omitempty"`
+	MySQLMaster bool           `json:"mysql_master,omitempty" yaml:"mysql_master,omitempty"`
+	MySQLSlave  bool           `json:"mysql_slave,omitempty" yaml:"mysql_slave,omitempty"`
+	OS          string         `json:"os" yaml:"os"`
+	Arch        string         `json:"arch" yaml:"arch"`
+	Kernel      string         `json:"kernel" yaml:"kernel"`
+	CPUCores    int            `json:"cpu_cores" yaml:"cpu_cores"`
+	MemoryGB    int            `json:"memory_gb" yaml:"memory_gb"`
+	RootSpace   string         `json:"root_space" yaml:"root_space"` // 可用/总量
+	RootUsage   string         `json:"root_usage" yaml:"root_usage"`
+	LVM         *lvm.LVMStatus `json:"lvm,omitempty" yaml:"lvm,omitempty"`
+	Errors      []string       `json:"errors,omitempty" yaml:"errors,omitempty"`
+}
+
+// runInventory 只读收集各主机信息并写入清单文件，不提示、不修改主机
+func runInventory(cfg *config.Config) error {
+	format := strings.ToLower(strings.TrimSpace(inventoryOutput))
+	if format == "yml" {
+		format = "yaml"
+	}
+	if format != "json" && format != "yaml" {
+		return fmt.Errorf("不支持的输出格式 '%s'，可选值: json, yaml", inventoryOutput)
+	}
+
+	// 进度信息输出到stderr，--file - 时stdout只包含清单内容
+	fmt.Fprintf(os.Stderr, "🔍 正在收集 %d 个主机的系统信息...\n", len(cfg.Hosts))
+	facts, problems := check.NewBasicChecker(cfg).CollectFacts()
+
+	var lvmStatus map[string]*lvm.LVMStatus
+	for _, host := range cfg.Hosts {
+		if host.LVMConfig != nil {
+			fmt.Fprintln(os.Stderr, "🔍 正在收集LVM状态...")
+			lvmStatus = lvm.NewLVM(cfg).CollectStatus()
+			break
+		}
+	}
+
+	report := inventoryReport{
+		GeneratedAt: time.Now().Format(time.RFC3339),
+		ClusterName: cfg.ClusterName,
+	}
+	for i, host := range cfg.Hosts {
+		fact := facts[i]
+		entry := inventoryHost{
+			IP:          host.IP,
+			InternalIP:  host.InternalIP,
+			NodeName:    cfg.GetNodeName(host),
+			Roles:       host.Role,
+			RbdRoles:    host.RbdRole,
+			MySQLMaster: host.MySQLMaster,
+			MySQLSlave:  host.MySQLSlave,
+			OS:          fact.OS,
+			Arch:        fact.Arch,
+			Kernel:      fact.Kernel,
+			CPUCores:    fact.CPUCores,
+			MemoryGB:    fact.MemoryGB,
+			RootSpace:   fact.RootSpace,
+			RootUsage:   fact.RootUsage,
+			Errors:      problems[host.IP],
+		}
+		if host.LVMConfig != nil {
+			entry.LVM = lvmStatus[host.IP]
+		}
+		report.Hosts = append(report.Hosts, entry)
+	}
+
+	var data []byte
+	var err error
+	if format == "json" {
+		data, err = json.MarshalIndent(report, "", "  ")
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(report)
+	}
+	if err != nil {
+		return fmt.Errorf("序列化主机清单失败: %w", err)
+	}
+
+	path := inventoryFile
+	if path == "" {
+		path = "roi-inventory." + format
+	}
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("写入主机清单文件 %s 失败: %w", path, err)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ 主机清单已写入 %s（%d 个主机", path, len(report.Hosts))
+	if len(problems) > 0 {
+		fmt.Fprintf(os.Stderr, "，其中 %d 个主机有未能收集的信息", len(problems))
+	}
+	fmt.Fprintln(os.Stderr, "）")
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/check"
+	"github.com/rainbond/rainbond-offline-installer/internal/optimize"
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+	"github.com/spf13/cobra"
+)
+
+var (
+	joinServer       string
+	joinToken        string
+	joinHosts        []string
+	joinUser         string
+	joinPassword     string
+	joinSSHKey       string
+	joinKubeconfig   string
+	joinSkipCheck    bool
+	joinSkipOptimize bool
+)
+
+var joinCmd = &cobra.Command{
+	Use:   "join",
+	Short: "Join worker nodes to an existing RKE2 cluster as agents",
+	Long: `Install RKE2 agents on new worker hosts and join them to an existing cluster,
+given only the address of a server node and the cluster token. The full original
+config is not needed and no server node is modified.
+
+Worker hosts come either from --host flags (with --user and --password or
+--ssh-key) or from a slim config file passed with --config that lists only the
+new hosts; every host must have the worker role. For each host roi runs the
+system check and optimization, transfers and verifies the offline artifacts
+of the current directory, installs rke2-agent pointing at --server and waits
+for the node to become Ready. Readiness is verified through --kubeconfig when
+the file exists, otherwise through the kubelet health endpoint on each host.
+
+The token is the content of /var/lib/rancher/rke2/server/node-token on a
+server node (clusters installed by roi use the built-in default token).
+
+Usage examples:
+  roi join --server 192.168.1.10 --token <token> --host 192.168.1.21 --host 192.168.1.22 --ssh-key ~/.ssh/id_rsa
+  roi join --server 192.168.1.10 --token <token> --config workers.yaml
+  roi join --server 192.168.1.10 --token <token> --config workers.yaml --skip-optimize`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if joinServer == "" || joinToken == "" {
+			return fmt.Errorf("必须通过 --server 和 --token 指定要加入的集群")
+		}
+		if strings.Contains(joinServer, "://") || strings.Contains(strings.Trim(joinServer, "[]"), "/") {
+			return fmt.Errorf("--server 只需填写server节点地址，如 192.168.1.10")
+		}
+		cfg, err := loadJoinConfig()
+		if err != nil {
+			return err
+		}
+		return runJoin(cfg)
+	},
+}
+
+func init() {
+	joinCmd.Flags().StringVar(&joinServer, "server", "", "address of an existing server node to register with (port 9345)")
+	joinCmd.Flags().StringVar(&joinToken, "token", "", "cluster join token (server node-token)")
+	joinCmd.Flags().StringSliceVar(&joinHosts, "host", nil, "worker host IP to join, repeatable; used instead of --config")
+	joinCmd.Flags().StringVar(&joinUser, "user", "root", "SSH user for --host workers")
+	joinCmd.Flags().StringVar(&joinPassword, "password", "", "SSH password for --host workers")
+	joinCmd.Flags().StringVar(&joinSSHKey, "ssh-key", "", "SSH private key for --host workers")
+	joinCmd.Flags().StringVar(&joinKubeconfig, "kubeconfig", config.DefaultKubeconfigPath, "kubeconfig used to verify that joined nodes are Ready")
+	joinCmd.Flags().BoolVar(&joinSkipCheck, "skip-check", false, "skip the system check on the workers")
+	joinCmd.Flags().BoolVar(&joinSkipOptimize, "skip-optimize", false, "skip system optimization on the workers")
+	rootCmd.AddCommand(joinCmd)
+}
+
+// loadJoinConfig 使用 --host 参数构造只包含worker主机的配置，未指定时加载 --config 指定的精简配置
+func loadJoinConfig() (*config.Config, error) {
+	if len(joinHosts) == 0 {
+		if cfgFile == "" {
+			return nil, fmt.Errorf("请通过 --host 或 --config 指定要加入集群的worker主机")
+		}
+		cfg, _, err := loadConfigFromFlags()
+		return cfg, err
+	}
+	if cfgFile != "" {
+		return nil, fmt.Errorf("--host 与 --config 不能同时使用")
+	}
+
+	cfg := &config.Config{}
+	for _, ip := range joinHosts {
+		cfg.Hosts = append(cfg.Hosts, config.Host{
+			IP:       strings.TrimSpace(ip),
+			User:     joinUser,
+			Password: joinPassword,
+			SSHKey:   joinSSHKey,
+			Role:     []string{"worker"},
+		})
+	}
+	if err := config.FinalizeConfig(cfg); err != nil {
+		return nil, err
+	}
+	ssh.SetTimeouts(time.Duration(cfg.SSH.ConnectTimeout)*time.Second, time.Duration(cfg.SSH.CommandTimeout)*time.Second)
+	return cfg, nil
+}
+
+func runJoin(cfg *config.Config) error {
+	if err := checkSSHPrerequisites(cfg.Hosts); err != nil {
+		return err
+	}
+
+	appLogger, err := logger.NewLogger(logger.INFO, logger.DEBUG)
+	if err != nil {
+		return fmt.Errorf("初始化日志记录器失败: %w", err)
+	}
+	defer appLogger.Close()
+
+	if !joinSkipCheck {
+		checker := check.NewBasicCheckerWithLogger(cfg, appLogger)
+		checker.SetAssumeYes(assumeYes)
+		if err := checker.Run(); err != nil {
+			appLogger.Error("系统检查失败: %v", err)
+			return fmt.Errorf("系统检查失败: %w", err)
+		}
+	}
+
+	if !joinSkipOptimize {
+		optimizer := optimize.NewSystemOptimizerWithLogger(cfg, appLogger)
+		if err := optimizer.SetProfile(optimizeProfile); err != nil {
+			return err
+		}
+		if err := optimizer.Run(); err != nil {
+			appLogger.Error("系统优化失败: %v", err)
+			return fmt.Errorf("系统优化失败: %w", err)
+		}
+	}
+
+	installer := rke2.NewRKE2InstallerWithLogger(cfg, appLogger)
+	installer.SetJoinTarget(joinServer, joinToken)
+	installer.SetCheckPorts(true)
+	if err := installer.JoinAgents(joinKubeconfig); err != nil {
+		appLogger.Error("加入集群失败: %v", err)
+		return fmt.Errorf("加入集群失败: %w", err)
+	}
+
+	for _, host := range cfg.Hosts {
+		fmt.Printf("\033[32m ✓ %s: 已加入集群并就绪\033[0m\n", host.IP)
+	}
+	fmt.Printf("\033[32m✅ %d 个worker节点已加入集群 %s\033[0m，详细日志文件: %s\n", len(cfg.Hosts), joinServer, appLogger.GetLogFilePath())
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	kubeconfigServer string
+	kubeconfigOutput string
+	kubeconfigStdout bool
+)
+
+var kubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: "Re-fetch the cluster kubeconfig from a server node",
+	Long: `Fetch /etc/rancher/rke2/rke2.yaml from the first server node over SSH (falling
+back to the other server nodes), rewrite its API address and save it with mode
+0600 to ./kubeconfig, then verify that it can reach the API server.
+
+Use this to recover when the local kubeconfig written during the RKE2 stage was
+lost, or after the control-plane address changed. --server sets the address
+written into the kubeconfig (for example a new IP or a VIP/load balancer); by
+default the address of the node it was fetched from is used. With --stdout the
+kubeconfig is printed instead of written and log output goes only to the log
+file, so stdout can be redirected.
+
+Usage examples:
+  roi kubeconfig
+  roi kubeconfig --server 192.168.1.100
+  roi kubeconfig --stdout > ~/.kube/config`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		if cfg.IsExistingCluster() {
+			return fmt.Errorf("已有集群模式直接使用 existing_cluster.kubeconfig（%s），无需重新获取", cfg.ExistingCluster.Kubeconfig)
+		}
+		return runKubeconfig(cfg)
+	},
+}
+
+func init() {
+	kubeconfigCmd.Flags().StringVar(&kubeconfigServer, "server", "", "API server address written into the kubeconfig (default: the server node it is fetched from)")
+	kubeconfigCmd.Flags().StringVarP(&kubeconfigOutput, "output", "o", config.DefaultKubeconfigPath, "file to write the kubeconfig to")
+	kubeconfigCmd.Flags().BoolVar(&kubeconfigStdout, "stdout", false, "print the kubeconfig to stdout instead of writing a file")
+	rootCmd.AddCommand(kubeconfigCmd)
+}
+
+func runKubeconfig(cfg *config.Config) error {
+	appLogger, err := logger.NewLogger(logger.INFO, logger.DEBUG)
+	if err != nil {
+		return fmt.Errorf("初始化日志记录器失败: %w", err)
+	}
+	defer appLogger.Close()
+	if kubeconfigStdout {
+		// 标准输出只保留kubeconfig内容，便于重定向
+		appLogger.SuppressConsole()
+	}
+
+	data, source, err := rke2.NewRKE2InstallerWithLogger(cfg, appLogger).FetchKubeconfig(kubeconfigServer)
+	if err != nil {
+		return err
+	}
+
+	if kubeconfigStdout {
+		os.Stdout.Write(data)
+	} else {
+		if err := rke2.SaveKubeconfig(data, kubeconfigOutput); err != nil {
+			return err
+		}
+		fmt.Printf("\033[32m ✓ kubeconfig: 已从节点 %s 获取并保存到 %s\033[0m\n", source, kubeconfigOutput)
+	}
+
+	nodes, err := rke2.VerifyKubeconfig(data)
+	if err != nil {
+		appLogger.Error("kubeconfig连接验证失败: %v", err)
+		return fmt.Errorf("kubeconfig连接验证失败，请检查API地址是否可达（可通过 --server 指定）: %w", err)
+	}
+	appLogger.Info("kubeconfig连接验证通过，集群共 %d 个节点", nodes)
+	if !kubeconfigStdout {
+		fmt.Printf("\033[32m ✓ 连接验证: API Server可访问，集群共 %d 个节点\033[0m\n", nodes)
+	}
+	return nil
+}
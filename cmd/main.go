@@ -3,16 +3,23 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
 	"github.com/rainbond/rainbond-offline-installer/internal/check"
+	"github.com/rainbond/rainbond-offline-installer/internal/dns"
+	"github.com/rainbond/rainbond-offline-installer/internal/hooks"
 	"github.com/rainbond/rainbond-offline-installer/internal/lvm"
 	"github.com/rainbond/rainbond-offline-installer/internal/mysql"
 	"github.com/rainbond/rainbond-offline-installer/internal/optimize"
 	"github.com/rainbond/rainbond-offline-installer/internal/rainbond"
 	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/internal/state"
 	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/events"
 	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
 	"github.com/rainbond/rainbond-offline-installer/pkg/progress"
 	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
@@ -21,17 +28,46 @@ import (
 )
 
 var (
-	cfgFile string
-	verbose bool
+	cfgFile     string
+	verbose     bool
+	sshBackend  string
+	concurrency int
+	assumeYes   bool
 )
 
 var (
-	checkFlag    bool
-	lvmFlag      bool
-	optimizeFlag bool
-	rke2Flag     bool
-	mysqlFlag    bool
-	rainbondFlag bool
+	checkFlag       bool
+	checkOnly       bool
+	checkDisk       bool
+	lvmFlag         bool
+	lvmPlan         bool
+	dnsFlag         bool
+	optimizeFlag    bool
+	rke2Flag        bool
+	mysqlFlag       bool
+	rainbondFlag    bool
+	setValues       []string
+	chartRef        string
+	chartSHA256     string
+	logCommands     []string
+	recreateFlag    bool
+	cleanResidue    bool
+	checkPorts      bool
+	detectNodeIP    bool
+	outputFormat    string
+	waitReady       bool
+	waitTimeout     time.Duration
+	tuiFlag         bool
+	eventsFile      string
+	optimizeProfile string
+	strictFlag      bool
+	forceFlag       bool
+	continueOnError bool
+	existingCluster string
+	externalVerify  bool
+	optimizeCheck   bool
+	optimizeReboot  bool
+	reportFile      string
 )
 
 var (
@@ -51,10 +87,14 @@ The tool automates the entire deployment process including:
 - Base component installation (MySQL, Keepalived)
 - Kubernetes (RKE2) deployment
 - Rainbond cluster installation`,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		if verbose {
 			fmt.Println("Verbose mode enabled")
 		}
+		if err := ssh.SetConcurrency(concurrency); err != nil {
+			return err
+		}
+		return ssh.SetBackend(sshBackend)
 	},
 	CompletionOptions: cobra.CompletionOptions{
 		DisableDefaultCmd: true,
@@ -66,34 +106,127 @@ var upCmd = &cobra.Command{
 	Short: "Set up or update a Rainbond cluster with optional operations",
 	Long: `Set up or update a Rainbond cluster with support for various operations:
   --check         Check system environment and requirements only
+  --check-only    Run all prechecks non-interactively and exit with readiness status
   --lvm           Show LVM status and create LVM configuration only
+  --dns           Write configured /etc/hosts entries and nameservers to every node only
   --rke2          Install and configure RKE2 Kubernetes cluster only
   --mysql         Install and configure MySQL master-slave cluster only
   --rainbond      Install and configure Rainbond only
   --optimize      Optimize system for containerized environments only
 
 默认行为（不使用任何flags时）：
-  roi up     # 依次执行: 系统检查 -> LVM配置 -> 系统优化 -> RKE2安装 -> MySQL安装 -> Rainbond安装
+  roi up     # 依次执行: 系统检查 -> DNS配置 -> LVM配置 -> 系统优化 -> RKE2安装 -> MySQL安装 -> Rainbond安装
 
 单独执行某个阶段：
   roi up --check           # 仅执行系统检查
+  roi up --check-only      # 非交互式执行全部预检，输出汇总报告（适用于CI流水线）
+  roi up --check --check-disk  # 系统检查时检测etcd节点磁盘fsync延迟
   roi up --lvm             # 仅执行LVM配置
+  roi up --lvm -o json     # 仅执行LVM配置，以JSON格式输出LVM状态（支持 table、json、yaml）
+  roi up --lvm --plan      # 仅打印每个主机的LVM变更计划（初始化的设备、卷组组成、逻辑卷大小、挂载点、fstab行），标出破坏性操作，不执行
+  roi up --dns             # 仅将 dns 配置写入各节点的 /etc/hosts 和 /etc/resolv.conf
   roi up --rke2            # 仅执行RKE2 Kubernetes安装
+  roi up --rke2 --check-ports  # 安装RKE2，节点加入前检查到第一个server的9345/6443端口是否放行
+  roi up --rke2 --detect-node-ip  # 安装前通过默认路由探测各节点地址，未配置internal_ip时自动确定node-ip，ip/internal_ip不符时告警
   roi up --mysql           # 仅执行MySQL主从集群安装
+  roi up --mysql --external-verify  # 只读验证已部署的MySQL：Pod就绪、可连接、console/region数据库及字符集、主从复制
   roi up --rainbond        # 仅执行Rainbond安装
-  roi up --optimize        # 仅执行系统优化`,
+  roi up --log-commands rke2,mysql  # 将RKE2和MySQL阶段执行的远程命令（已脱敏）记录到日志文件
+  roi up --rainbond --chart oci://registry.example.com/charts/rainbond:6.1.0  # 使用指定来源和版本的chart
+  roi up --optimize        # 仅执行系统优化
+  roi up --optimize --profile high-throughput  # 使用指定调优档位执行系统优化
+  roi up --optimize --validate  # 只检查各主机与优化目标的差异（防火墙、SELinux、swap、内核参数、系统限制），不做修改
+  roi up --optimize --reboot    # 系统优化后重启需要重启的主机，集群已运行时先逐个驱逐节点
+
+安装到已有的Kubernetes集群（跳过RKE2安装，MySQL和Rainbond阶段使用指定的kubeconfig，安装前检查集群版本、节点、StorageClass和节点标签）：
+  roi up --existing-cluster ~/.kube/config
+  roi up --rainbond --existing-cluster ~/.kube/config
+
+严格模式（完整安装时每个阶段完成后执行验证关卡，未通过则停止并报告失败的关卡）：
+  roi up --strict          # RKE2后要求所有节点Ready，MySQL后要求服务可访问，Rainbond后等待所有组件就绪
+
+完整安装结束时输出各阶段警告的汇总（涉及主机、修复建议、是否已确认），并写入日志文件：
+  roi up --report-file roi-report.json  # 同时将警告汇总保存为JSON
+
+再次运行时只处理新增或变更的主机（系统检查和系统优化阶段按 ./roi-state.json 中记录的主机配置摘要跳过未变更且上次成功的主机）：
+  roi up --check --force   # 忽略状态文件，重新检查全部主机
+
+一次看到所有主机的问题（系统检查、LVM、系统优化阶段某个主机失败时继续处理其余主机，结束时汇总失败主机并返回非零退出码）：
+  roi up --continue-on-error
+
+无人值守执行（自动确认交互提示，如系统检查发现警告后的继续确认；破坏性操作仍需各自的 --force/--recreate）：
+  roi up -y                # 等同于 roi up --assume-yes
+
+覆盖Rainbond values（与 helm --set 语法一致，最后合并）：
+  roi up --rainbond --set Cluster.gatewayIngressIPs=1.2.3.4 --set Component.rbd_app_ui.enable=true`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configFile := cfgFile
-		if configFile == "" {
-			configFile = viper.ConfigFileUsed()
-			if configFile == "" {
-				return fmt.Errorf("config file not found. Please specify with --config flag or create ./config.yaml")
+		cfg, _, err := loadConfigFromFlags()
+		if err != nil {
+			return err
+		}
+
+		if lvmPlan && !lvmFlag {
+			return fmt.Errorf("--plan 需要与 --lvm 一起使用")
+		}
+
+		if externalVerify && !mysqlFlag {
+			return fmt.Errorf("--external-verify 需要与 --mysql 一起使用")
+		}
+
+		if optimizeCheck && !optimizeFlag {
+			return fmt.Errorf("--validate 需要与 --optimize 一起使用")
+		}
+
+		if err := rainbond.ValidateChartChecksum(chartSHA256); err != nil {
+			return err
+		}
+
+		if err := validateLogCommands(); err != nil {
+			return err
+		}
+
+		if optimizeReboot && (!optimizeFlag || optimizeCheck) {
+			return fmt.Errorf("--reboot 需要与 --optimize 一起使用，且不能与 --validate 同时使用")
+		}
+
+		if err := applyExistingCluster(cfg); err != nil {
+			return err
+		}
+
+		// 提前校验 --set 覆盖项，避免在安装Rainbond时才发现格式错误
+		if err := config.ApplySetValues(map[string]interface{}{}, setValues); err != nil {
+			return err
+		}
+
+		// 密码认证依赖sshpass，ssh_key需存在且可读，缺失时立即失败，避免安装中途出现难以理解的错误
+		if err := checkSSHPrerequisites(cfg.Hosts); err != nil {
+			return err
+		}
+
+		// 非交互式预检汇总所有问题，不在单项失败时提前退出
+		if checkOnly {
+			return runCheckOnly(cfg)
+		}
+
+		if len(logCommands) > 0 {
+			registerCommandSecrets(cfg)
+		}
+		if stage := singleStage(); stage != "" {
+			stopCommandLog, err := startSingleStageCommandLog(stage)
+			if err != nil {
+				return err
 			}
+			defer stopCommandLog()
 		}
 
-		cfg, err := config.LoadConfig(configFile)
-		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+		// 按node_name_strategy解析节点名称，确保各阶段使用一致的节点名称
+		if err := rke2.NewRKE2Installer(cfg).ResolveNodeNames(); err != nil {
+			return fmt.Errorf("failed to resolve node names: %w", err)
+		}
+
+		// 在任何变更操作之前确认所有主机具备root权限
+		if err := check.NewBasicChecker(cfg).CheckPrivileges(); err != nil {
+			return err
 		}
 
 		// Execute specific operations based on flags
@@ -105,6 +238,10 @@ var upCmd = &cobra.Command{
 			return runLVM(cfg)
 		}
 
+		if dnsFlag {
+			return runDNS(cfg)
+		}
+
 		if rke2Flag {
 			return runRKE2(cfg)
 		}
@@ -113,6 +250,12 @@ var upCmd = &cobra.Command{
 			return runOptimize(cfg)
 		}
 
+		if (mysqlFlag || rainbondFlag) && cfg.IsExistingCluster() {
+			if err := runClusterCompat(cfg); err != nil {
+				return err
+			}
+		}
+
 		if mysqlFlag {
 			return runMySQL(cfg)
 		}
@@ -130,9 +273,33 @@ var upCmd = &cobra.Command{
 			return fmt.Errorf("初始化日志记录器失败: %w", err)
 		}
 		defer appLogger.Close()
+		defer ssh.SetCommandLogger(nil)
+
+		// 按配置预先确定需要执行的阶段，确保进度显示的总步骤数准确
+		stages, skipped := planInstallStages(cfg)
+		for _, skip := range skipped {
+			fmt.Printf("\033[36m[INFO]\033[0m %s\n", skip)
+			appLogger.InfoToFileOnly("%s", skip)
+		}
+		if notice := controlPlaneOnlyNotice(cfg); notice != "" {
+			appLogger.InfoToFileOnly("%s", notice)
+		}
 
 		// 初始化步骤进度显示器，集成logger
-		stepProgress := progress.NewStepProgressWithLogger(6, appLogger)
+		stepProgress := progress.NewStepProgressWithLogger(len(stages), appLogger)
+
+		// 各阶段通过事件总线发布结构化事件，由控制台、日志文件和可选的JSON事件流分别订阅
+		bus := events.NewBus()
+		bus.Subscribe(events.NewLogSubscriber(appLogger))
+		bus.Subscribe(stepProgress)
+		if eventsFile != "" {
+			f, err := os.Create(eventsFile)
+			if err != nil {
+				return fmt.Errorf("创建事件输出文件失败: %w", err)
+			}
+			defer f.Close()
+			bus.Subscribe(events.NewJSONSubscriber(f))
+		}
 
 		// 设置主机IP列表
 		var hostIPs []string
@@ -141,84 +308,55 @@ var upCmd = &cobra.Command{
 		}
 		stepProgress.SetHostIPs(hostIPs)
 
-		// 阶段1: 系统检查
-		stepProgress.StartStep("系统检查")
-		appLogger.Info("开始系统检查阶段")
-		if err := runCheckWithLogger(cfg, appLogger, stepProgress); err != nil {
-			appLogger.Error("系统检查阶段失败: %v", err)
-			stepProgress.FailStep(err.Error())
-			return fmt.Errorf("系统检查阶段失败: %w", err)
-		}
-		stepProgress.CompleteStep()
-		appLogger.Info("系统检查阶段完成")
-
-		// 阶段2: LVM配置
-		stepProgress.StartStep("LVM配置")
-		appLogger.Info("开始LVM配置阶段")
-		stepProgress.UpdateStepProgress("配置LVM逻辑卷...")
-		time.Sleep(500 * time.Millisecond) // 让spinner有时间显示
-		if err := runLVMWithLogger(cfg, appLogger, stepProgress); err != nil {
-			appLogger.Error("LVM配置阶段失败: %v", err)
-			stepProgress.FailStep(err.Error())
-			return fmt.Errorf("LVM配置阶段失败: %w", err)
-		}
-		stepProgress.CompleteStep()
-		appLogger.Info("LVM配置阶段完成")
-
-		// 阶段3: 系统优化
-		stepProgress.StartStep("系统优化")
-		appLogger.Info("开始系统优化阶段")
-		stepProgress.UpdateStepProgress("优化系统配置...")
-		time.Sleep(500 * time.Millisecond) // 让spinner有时间显示
-		if err := runOptimizeWithLogger(cfg, appLogger, stepProgress); err != nil {
-			appLogger.Error("系统优化阶段失败: %v", err)
-			stepProgress.FailStep(err.Error())
-			return fmt.Errorf("系统优化阶段失败: %w", err)
-		}
-		stepProgress.CompleteStep()
-		appLogger.Info("系统优化阶段完成")
-
-		// 阶段4: RKE2安装
-		stepProgress.StartStep("RKE2安装")
-		appLogger.Info("开始RKE2安装阶段")
-		stepProgress.UpdateStepProgress("安装RKE2 Kubernetes集群...")
-		time.Sleep(500 * time.Millisecond) // 让spinner有时间显示
-		if err := runRKE2WithLogger(cfg, appLogger, stepProgress); err != nil {
-			appLogger.Error("RKE2安装阶段失败: %v", err)
-			stepProgress.FailStep(err.Error())
-			return fmt.Errorf("RKE2安装阶段失败: %w", err)
-		}
-		stepProgress.CompleteStep()
-		appLogger.Info("RKE2安装阶段完成")
-
-		// 阶段5: MySQL安装
-		stepProgress.StartStep("MySQL安装")
-		appLogger.Info("开始MySQL安装阶段")
-		stepProgress.UpdateStepProgress("安装MySQL数据库...")
-		time.Sleep(500 * time.Millisecond) // 让spinner有时间显示
-		if err := runMySQLWithLogger(cfg, appLogger, stepProgress); err != nil {
-			appLogger.Error("MySQL安装阶段失败: %v", err)
-			stepProgress.FailStep(err.Error())
-			return fmt.Errorf("MySQL安装阶段失败: %w", err)
-		}
-		stepProgress.CompleteStep()
-		appLogger.Info("MySQL安装阶段完成")
-
-		// 阶段6: Rainbond安装
-		stepProgress.StartStep("Rainbond安装")
-		appLogger.Info("开始Rainbond安装阶段")
-		stepProgress.UpdateStepProgress("安装Rainbond平台...")
-		time.Sleep(500 * time.Millisecond) // 让spinner有时间显示
-		if err := runRainbondWithLogger(cfg, appLogger, stepProgress); err != nil {
-			appLogger.Error("Rainbond安装阶段失败: %v", err)
-			stepProgress.FailStep(err.Error())
-			return fmt.Errorf("Rainbond安装阶段失败: %w", err)
-		}
-		stepProgress.CompleteStep()
-		appLogger.Info("Rainbond安装阶段完成")
+		// 可选的多节点仪表盘，非终端环境自动回退到逐行进度输出
+		if tuiFlag {
+			stageNames := make([]string, 0, len(stages))
+			for _, stage := range stages {
+				stageNames = append(stageNames, stage.name)
+			}
+			if !stepProgress.EnableDashboard(stageNames) {
+				fmt.Println("\033[36m[INFO]\033[0m 标准输出不是终端，--tui 已禁用，使用普通进度显示")
+			}
+		}
+
+		// 汇总各阶段的警告，安装结束时统一输出修复建议
+		installWarnings = advisory.NewReport()
+		recordWarnings("配置检查", configLintWarnings(cfg), "")
+
+		startedAt := time.Now()
+		for i, stage := range stages {
+			bus.StartStep(stage.name)
+			appLogger.Info("开始%s阶段", stage.name)
+			if i > 0 {
+				bus.UpdateStepProgress(stage.progressMessage)
+				time.Sleep(500 * time.Millisecond) // 让spinner有时间显示
+			}
+			setStageCommandLog(stage.key, appLogger)
+			if err := stage.run(cfg, appLogger, bus); err != nil {
+				appLogger.Error("%s阶段失败: %v", stage.name, err)
+				bus.FailStep(err.Error())
+				finishWarningReport(appLogger)
+				runFailureHooks(cfg, stages, stage.name, err, startedAt, appLogger)
+				return fmt.Errorf("%s阶段失败: %w", stage.name, err)
+			}
+			// 严格模式下阶段返回成功后还需通过验证关卡，避免在半就绪的环境上继续后续阶段
+			if strictFlag && stage.verify != nil {
+				bus.UpdateStepProgress(fmt.Sprintf("验证: %s...", stage.gate))
+				if err := stage.verify(cfg, appLogger); err != nil {
+					appLogger.Error("%s阶段验证关卡「%s」未通过: %v", stage.name, stage.gate, err)
+					bus.FailStep(err.Error())
+					finishWarningReport(appLogger)
+					runFailureHooks(cfg, stages, stage.name, fmt.Errorf("验证关卡「%s」未通过: %w", stage.gate, err), startedAt, appLogger)
+					return fmt.Errorf("严格模式: %s阶段验证关卡「%s」未通过，已停止后续阶段: %w", stage.name, stage.gate, err)
+				}
+				appLogger.Info("%s阶段验证关卡「%s」通过", stage.name, stage.gate)
+			}
+			bus.CompleteStep()
+			appLogger.Info("%s阶段完成", stage.name)
+		}
 
 		// 完成所有步骤，重新启用控制台输出
-		stepProgress.Finish()
+		bus.Finish()
 
 		// 显示安装成功总结
 		fmt.Println("=====================================================")
@@ -232,118 +370,373 @@ var upCmd = &cobra.Command{
 			accessIP = "<未配置主机IP>"
 		}
 
-		fmt.Printf("\033[32m 访问地址: http://%s:7070 \033[0m\n", accessIP)
+		if cfg.ClusterName != "" {
+			fmt.Printf("\033[32m 集群名称: %s \033[0m\n", cfg.ClusterName)
+		}
+		fmt.Printf("\033[32m 访问地址: http://%s:7070 \033[0m\n", config.URLHost(accessIP))
 		fmt.Println("")
 		fmt.Printf("详细日志文件: %s\n", appLogger.GetLogFilePath())
 		fmt.Println("\033[32m 🙏 感谢使用 Rainbond！ 🙏\033[0m")
 		fmt.Println("=====================================================")
-		return nil
+		finishWarningReport(appLogger)
+
+		if cfg.Hooks.PostInstall.IsEmpty() {
+			return nil
+		}
+		summary := newHookSummary(cfg, "success", stages, startedAt, appLogger)
+		summary.AccessURL = fmt.Sprintf("http://%s:7070", config.URLHost(accessIP))
+		return runHooks("安装后钩子", cfg.Hooks.PostInstall, summary, appLogger)
 	},
 }
 
+// runHooks 执行钩子并输出每个钩子的结果，失败只在 fail_on_error 时作为错误返回
+func runHooks(title string, hook config.HookConfig, summary *hooks.Summary, appLogger *logger.Logger) error {
+	fmt.Printf("\n执行%s...\n", title)
+	var failed []string
+	for _, result := range hooks.NewExecutorWithLogger(hook, appLogger).Run(summary) {
+		if result.Err != nil {
+			appLogger.Error("%s %s 失败: %v", title, result.Name, result.Err)
+			fmt.Printf("\033[31m ✗ %s (%s): %v\033[0m\n", result.Name, result.Duration.Round(time.Millisecond), result.Err)
+			failed = append(failed, result.Name)
+			continue
+		}
+		appLogger.InfoToFileOnly("%s %s 完成，耗时 %s", title, result.Name, result.Duration)
+		fmt.Printf("\033[32m ✓ %s (%s)\033[0m\n", result.Name, result.Duration.Round(time.Millisecond))
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+	if hook.FailOnError {
+		return fmt.Errorf("%s失败: %s", title, strings.Join(failed, ", "))
+	}
+	fmt.Printf("\033[33m[WARN]\033[0m %d 个%s失败，未设置 fail_on_error，不影响安装结果\n", len(failed), title)
+	return nil
+}
+
+// loadConfigFromFlags 根据 --config 或默认搜索路径加载配置文件
+func loadConfigFromFlags() (*config.Config, string, error) {
+	configFile := cfgFile
+	if configFile == "" {
+		configFile = viper.ConfigFileUsed()
+		if configFile == "" {
+			return nil, "", fmt.Errorf("config file not found. Please specify with --config flag or create ./config.yaml")
+		}
+	}
+
+	cfg, err := config.LoadConfig(configFile)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+	ssh.SetTimeouts(time.Duration(cfg.SSH.ConnectTimeout)*time.Second, time.Duration(cfg.SSH.CommandTimeout)*time.Second)
+	logger.SetClusterName(cfg.ClusterName)
+	return cfg, configFile, nil
+}
+
 func runCheck(cfg *config.Config) error {
+	hosts := planStageHosts(cfg, state.StageCheck)
+	fmt.Println(hosts.summary())
 	checker := check.NewBasicChecker(cfg)
-	return checker.Run()
+	checker.SetDiskProbe(checkDisk)
+	checker.SetAssumeYes(assumeYes)
+	checker.SetSkipHosts(hosts.skipped)
+	checker.SetContinueOnError(continueOnError)
+	err := checker.Run()
+	hosts.record(err)
+	return err
 }
 
 func runLVM(cfg *config.Config) error {
 	lvmManager := lvm.NewLVM(cfg)
+	if err := lvmManager.SetOutputFormat(outputFormat); err != nil {
+		return err
+	}
+	if lvmPlan {
+		return lvmManager.ShowPlan()
+	}
+	lvmManager.SetContinueOnError(continueOnError)
 	return lvmManager.ShowAndCreate()
 }
 
+func runDNS(cfg *config.Config) error {
+	return dns.NewConfigurator(cfg).Run()
+}
+
 func runRKE2(cfg *config.Config) error {
+	controlPlaneOnlyNotice(cfg)
 	rke2Installer := rke2.NewRKE2Installer(cfg)
+	rke2Installer.SetCleanResidue(cleanResidue)
+	rke2Installer.SetCheckPorts(checkPorts)
+	rke2Installer.SetDetectNodeIP(detectNodeIP)
 	return rke2Installer.Run()
 }
 
 func runOptimize(cfg *config.Config) error {
 	optimizer := optimize.NewSystemOptimizer(cfg)
-	return optimizer.Run()
+	if err := optimizer.SetProfile(optimizeProfile); err != nil {
+		return err
+	}
+	if optimizeCheck {
+		return runOptimizeValidate(optimizer)
+	}
+	hosts := planStageHosts(cfg, state.StageOptimize)
+	fmt.Println(hosts.summary())
+	optimizer.SetSkipHosts(hosts.skipped)
+	optimizer.SetContinueOnError(continueOnError)
+	err := optimizer.Run()
+	if err == nil && optimizeReboot {
+		_, err = rebootOptimizedHosts(cfg, rke2.NewRKE2Installer(cfg), optimizer.Warnings())
+	}
+	hosts.record(err)
+	return err
 }
 
 func runMySQL(cfg *config.Config) error {
+	if externalVerify {
+		return runMySQLVerify(cfg)
+	}
 	mysqlInstaller := mysql.NewMySQLInstaller(cfg)
+	mysqlInstaller.SetRecreate(recreateFlag)
 	return mysqlInstaller.Run()
 }
 
 func runRainbond(cfg *config.Config) error {
 	rainbondInstaller := rainbond.NewRainbondInstaller(cfg)
+	rainbondInstaller.SetValueOverrides(setValues)
+	rainbondInstaller.SetChartPath(chartRef)
+	if err := rainbondInstaller.SetChartChecksum(chartSHA256); err != nil {
+		return err
+	}
+	rainbondInstaller.SetWaitReady(waitReady, waitTimeout)
 	return rainbondInstaller.Run()
 }
 
+// controlPlaneOnlyNotice 集群没有纯worker节点时在安装开始前醒目提示，返回提示内容以便写入日志
+func controlPlaneOnlyNotice(cfg *config.Config) string {
+	if cfg.HasWorkerNodes() {
+		return ""
+	}
+	notice := check.ControlPlaneOnlyNotice(len(cfg.Hosts))
+	fmt.Printf("\033[33m[WARN]\033[0m %s\n", notice)
+	return notice
+}
+
+// installStage 完整安装流程中的一个阶段
+type installStage struct {
+	key             string // 阶段标识，用于 --log-commands
+	name            string
+	progressMessage string
+	run             func(*config.Config, *logger.Logger, *events.Bus) error
+	gate            string                                     // 严格模式下验证关卡的说明
+	verify          func(*config.Config, *logger.Logger) error // 严格模式下阶段成功后执行的验证，为nil时不验证
+}
+
+// planInstallStages 根据配置确定完整安装需要执行的阶段，返回执行的阶段和被跳过阶段的说明
+func planInstallStages(cfg *config.Config) ([]installStage, []string) {
+	var stages []installStage
+	var skipped []string
+
+	stages = append(stages, installStage{key: "check", name: "系统检查", progressMessage: "检测系统环境...", run: runCheckWithLogger})
+	if cfg.HasDNSConfig() {
+		stages = append(stages, installStage{key: "dns", name: "DNS配置", progressMessage: "写入hosts和DNS配置...", run: runDNSWithLogger})
+	} else {
+		skipped = append(skipped, "DNS配置: 未配置 dns，跳过")
+	}
+	if hasLVMConfig(cfg) {
+		stages = append(stages, installStage{key: "lvm", name: "LVM配置", progressMessage: "配置LVM逻辑卷...", run: runLVMWithLogger})
+	} else {
+		skipped = append(skipped, "LVM配置: 未找到 LVM 配置，跳过")
+	}
+	stages = append(stages, installStage{key: "optimize", name: "系统优化", progressMessage: "优化系统配置...", run: runOptimizeWithLogger})
+	if cfg.IsExistingCluster() {
+		stages = append(stages, installStage{key: "cluster-compat", name: "集群兼容性检查", progressMessage: "检查已有Kubernetes集群...", run: runClusterCompatWithLogger})
+		skipped = append(skipped, fmt.Sprintf("RKE2安装: 使用已有集群（kubeconfig: %s），跳过", cfg.KubeconfigPath()))
+	} else {
+		stages = append(stages, installStage{key: "rke2", name: "RKE2安装", progressMessage: "安装RKE2 Kubernetes集群...", run: runRKE2WithLogger,
+			gate: "所有节点Ready", verify: verifyRKE2WithLogger})
+	}
+	if hasMySQLConfig(cfg) {
+		stages = append(stages, installStage{key: "mysql", name: "MySQL安装", progressMessage: "安装MySQL数据库...", run: runMySQLWithLogger,
+			gate: "MySQL可访问", verify: verifyMySQLWithLogger})
+	} else {
+		skipped = append(skipped, "MySQL安装: 未找到 MySQL 配置或 MySQL 节点，跳过")
+	}
+	stages = append(stages, installStage{key: "rainbond", name: "Rainbond安装", progressMessage: "安装Rainbond平台...", run: runRainbondWithLogger})
+
+	return stages, skipped
+}
+
+// hasLVMConfig 检查是否有主机配置了LVM
+func hasLVMConfig(cfg *config.Config) bool {
+	for _, host := range cfg.Hosts {
+		if host.LVMConfig != nil && len(host.LVMConfig.PVDevices) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// runMySQLVerify 只读验证已部署的MySQL并输出每项检查结果，不执行任何部署或初始化
+func runMySQLVerify(cfg *config.Config) error {
+	fmt.Println("\033[36m[INFO]\033[0m 验证MySQL（只读，不修改部署）...")
+	report, err := mysql.NewMySQLInstaller(cfg).VerifyOnly()
+	if err != nil {
+		return err
+	}
+	for _, check := range report.Checks {
+		if check.OK {
+			fmt.Printf("\033[32m ✓ %s: %s\033[0m\n", check.Name, check.Detail)
+		} else {
+			fmt.Printf("\033[31m ✗ %s: %s\033[0m\n", check.Name, check.Detail)
+		}
+	}
+	if failed := report.Failed(); len(failed) > 0 {
+		return fmt.Errorf("MySQL验证未通过: %d 项检查失败", len(failed))
+	}
+	fmt.Println("\033[32m[SUCCESS]\033[0m MySQL验证通过")
+	return nil
+}
+
+// runOptimizeValidate 输出各主机每个优化项是否合规，不做任何修改，也不记录优化状态
+func runOptimizeValidate(optimizer *optimize.SystemOptimizer) error {
+	fmt.Println("\033[36m[INFO]\033[0m 检查系统优化合规性（只读，不修改主机）...")
+	deviating := 0
+	for _, host := range optimizer.Validate() {
+		fmt.Printf("主机 %s:\n", host.Host)
+		if host.Error != "" {
+			fmt.Printf("\033[31m ✗ 检查失败: %s\033[0m\n", host.Error)
+			deviating++
+			continue
+		}
+		for _, item := range host.Items {
+			if item.Compliant {
+				fmt.Printf("\033[32m ✓ %s: %s\033[0m\n", item.Name, item.Detail)
+			} else {
+				fmt.Printf("\033[31m ✗ %s: %s\033[0m\n", item.Name, item.Detail)
+			}
+		}
+		if len(host.Deviations()) > 0 {
+			deviating++
+		}
+	}
+	if deviating > 0 {
+		return fmt.Errorf("%d 个主机存在需要优化的项，执行 roi up --optimize 进行优化", deviating)
+	}
+	fmt.Println("\033[32m[SUCCESS]\033[0m 所有主机均符合系统优化要求")
+	return nil
+}
+
+// hasMySQLConfig 检查是否启用了MySQL或配置了MySQL节点
+func hasMySQLConfig(cfg *config.Config) bool {
+	return cfg.MySQL.Enabled || cfg.IsMySQLEnabled()
+}
+
 // 带有日志记录器的运行函数
-func runCheckWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *progress.StepProgress) error {
+func runCheckWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
 	logger.Info("系统检查: 开始环境检测")
 	stepProgress.UpdateStepProgress("检测系统环境...")
+	hosts := planStageHosts(cfg, state.StageCheck)
+	logger.Info("系统检查: %s", hosts.summary())
 	checker := check.NewBasicCheckerWithLoggerAndProgress(cfg, logger, stepProgress)
-	return checker.Run()
+	checker.SetDiskProbe(checkDisk)
+	checker.SetAssumeYes(assumeYes)
+	checker.SetSkipHosts(hosts.skipped)
+	checker.SetContinueOnError(continueOnError)
+	err := checker.Run()
+	recordWarnings("系统检查", checker.Warnings(), checker.Acknowledgement())
+	hosts.record(err)
+	return err
 }
 
-func runLVMWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *progress.StepProgress) error {
+func runLVMWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
 	logger.Info("LVM配置: 检查并配置逻辑卷管理")
 	stepProgress.UpdateStepProgress("配置LVM逻辑卷...")
 
-	// 检查是否有LVM配置
-	hasLVMConfig := false
-	for _, host := range cfg.Hosts {
-		if host.LVMConfig != nil && len(host.LVMConfig.PVDevices) > 0 {
-			hasLVMConfig = true
-			break
-		}
-	}
-
-	if !hasLVMConfig {
+	if !hasLVMConfig(cfg) {
 		stepProgress.SkipStep("未找到 LVM 配置")
 		return nil
 	}
 
 	lvmManager := lvm.NewLVMWithLogger(cfg, logger)
+	lvmManager.SetContinueOnError(continueOnError)
 	return lvmManager.ShowAndCreate()
 }
 
-func runRKE2WithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *progress.StepProgress) error {
+func runDNSWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
+	logger.Info("DNS配置: 写入节点hosts映射和DNS服务器")
+	stepProgress.UpdateStepProgress("写入hosts和DNS配置...")
+	return dns.NewConfiguratorWithLoggerAndProgress(cfg, logger, stepProgress).Run()
+}
+
+func runRKE2WithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
 	logger.Info("RKE2安装: 开始Kubernetes集群部署")
 	stepProgress.UpdateStepProgress("安装RKE2 Kubernetes集群...")
 	rke2Installer := rke2.NewRKE2InstallerWithLoggerAndProgress(cfg, logger, stepProgress)
-	return rke2Installer.Run()
+	rke2Installer.SetCleanResidue(cleanResidue)
+	rke2Installer.SetCheckPorts(checkPorts)
+	rke2Installer.SetDetectNodeIP(detectNodeIP)
+	err := rke2Installer.Run()
+	recordWarnings("RKE2安装", rke2Installer.Warnings(), "")
+	recordTransferStats(rke2Installer.TransferStats())
+	return err
+}
+
+// verifyRKE2WithLogger 严格模式验证关卡：配置中的所有主机都已注册为节点且处于Ready状态
+func verifyRKE2WithLogger(cfg *config.Config, logger *logger.Logger) error {
+	return rke2.NewRKE2InstallerWithLogger(cfg, logger).VerifyClusterReady()
 }
 
-func runOptimizeWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *progress.StepProgress) error {
+func runOptimizeWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
 	logger.Info("系统优化: 优化容器环境配置")
 	stepProgress.UpdateStepProgress("优化系统配置...")
 	optimizer := optimize.NewSystemOptimizerWithLoggerAndProgress(cfg, logger, stepProgress)
-	return optimizer.Run()
+	if err := optimizer.SetProfile(optimizeProfile); err != nil {
+		return err
+	}
+	hosts := planStageHosts(cfg, state.StageOptimize)
+	logger.Info("系统优化: %s", hosts.summary())
+	optimizer.SetSkipHosts(hosts.skipped)
+	optimizer.SetContinueOnError(continueOnError)
+	err := optimizer.Run()
+	warnings := optimizer.Warnings()
+	if err == nil && optimizeReboot {
+		warnings, err = rebootOptimizedHosts(cfg, rke2.NewRKE2InstallerWithLogger(cfg, logger), warnings)
+	}
+	recordWarnings("系统优化", warnings, "")
+	hosts.record(err)
+	return err
 }
 
-func runMySQLWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *progress.StepProgress) error {
+func runMySQLWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
 	logger.Info("MySQL安装: 部署MySQL主从集群")
 	stepProgress.UpdateStepProgress("安装MySQL数据库...")
 
-	// 检查是否有MySQL配置或MySQL节点
-	hasMySQLConfig := cfg.MySQL.Enabled
-	if !hasMySQLConfig {
-		// 检查是否有MySQL主节点或从节点
-		for _, host := range cfg.Hosts {
-			if host.MySQLMaster || host.MySQLSlave {
-				hasMySQLConfig = true
-				break
-			}
-		}
-	}
-
-	if !hasMySQLConfig {
+	if !hasMySQLConfig(cfg) {
 		stepProgress.SkipStep("未找到 MySQL 配置或 MySQL 节点")
 		return nil
 	}
 
 	mysqlInstaller := mysql.NewMySQLInstallerWithLoggerAndProgress(cfg, logger, stepProgress)
+	mysqlInstaller.SetRecreate(recreateFlag)
 	return mysqlInstaller.Run()
 }
 
-func runRainbondWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *progress.StepProgress) error {
+// verifyMySQLWithLogger 严格模式验证关卡：MySQL Master就绪且服务端口可从集群节点访问
+func verifyMySQLWithLogger(cfg *config.Config, logger *logger.Logger) error {
+	return mysql.NewMySQLInstallerWithLogger(cfg, logger).VerifyReachable()
+}
+
+func runRainbondWithLogger(cfg *config.Config, logger *logger.Logger, stepProgress *events.Bus) error {
 	logger.Info("Rainbond安装: 部署Rainbond应用管理平台")
 	stepProgress.UpdateStepProgress("安装Rainbond平台...")
 	rainbondInstaller := rainbond.NewRainbondInstallerWithLoggerAndProgress(cfg, logger, stepProgress)
+	rainbondInstaller.SetValueOverrides(setValues)
+	rainbondInstaller.SetChartPath(chartRef)
+	if err := rainbondInstaller.SetChartChecksum(chartSHA256); err != nil {
+		return err
+	}
+	// 严格模式下Rainbond阶段以所有组件就绪作为验证关卡
+	rainbondInstaller.SetWaitReady(waitReady || strictFlag, waitTimeout)
 	return rainbondInstaller.Run()
 }
 
@@ -383,17 +776,9 @@ Note:
 - Without --unified-password: You'll be prompted for each host individually
 - You'll need to manually update your config file with the SSH key path after setup`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		configFile := cfgFile
-		if configFile == "" {
-			configFile = viper.ConfigFileUsed()
-			if configFile == "" {
-				return fmt.Errorf("config file not found. Please specify with --config flag or create ./config.yaml")
-			}
-		}
-
-		cfg, err := config.LoadConfig(configFile)
+		cfg, configFile, err := loadConfigFromFlags()
 		if err != nil {
-			return fmt.Errorf("failed to load config: %w", err)
+			return err
 		}
 
 		return runSSHSetup(cfg, configFile)
@@ -461,7 +846,11 @@ func runSSHSetup(cfg *config.Config, configFile string) error {
 	fmt.Println("\n🎉 SSH免密配置完成！")
 	fmt.Printf("📋 私钥路径: %s\n", keyPair.PrivateKeyPath)
 	fmt.Printf("📋 公钥路径: %s\n", keyPair.PublicKeyPath)
-	fmt.Println("\n💡 提示: 请在配置文件中手动设置 ssh_key 字段为私钥路径")
+	if configFile == config.StdinConfigPath {
+		fmt.Println("\n💡 提示: 配置来自标准输入，无法写回，请在生成配置的流水线中将 ssh_key 设置为私钥路径")
+	} else {
+		fmt.Println("\n💡 提示: 请在配置文件中手动设置 ssh_key 字段为私钥路径")
+	}
 	
 	return nil
 }
@@ -473,15 +862,44 @@ func Execute() error {
 func init() {
 	cobra.OnInitialize(initConfig)
 
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default search: ./config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file, or - to read YAML from stdin (default search: ./config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&sshBackend, "ssh-backend", "exec", "remote execution backend: exec (system ssh/scp/sshpass) or native (built-in Go SSH client)")
+	rootCmd.PersistentFlags().IntVar(&concurrency, "concurrency", ssh.DefaultConcurrency, "number of hosts processed in parallel by parallelized stages (check, optimize) and max concurrent native SSH dials")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "assume-yes", "y", false, "automatically answer yes to interactive confirmations for unattended runs; destructive commands additionally require their own --force")
 
 	upCmd.Flags().BoolVar(&checkFlag, "check", false, "Check system environment and requirements")
+	upCmd.Flags().BoolVar(&checkOnly, "check-only", false, "Run all prechecks without prompting, print a report and exit non-zero if the environment is not ready")
+	upCmd.Flags().BoolVar(&forceFlag, "force", false, "Re-run check and optimize on all hosts instead of skipping hosts whose config is unchanged since their last successful run (tracked in ./roi-state.json)")
+	upCmd.Flags().BoolVar(&continueOnError, "continue-on-error", false, "In the per-host stages (check, LVM, optimize), keep processing the remaining hosts when one fails and report all failed hosts at the end; RKE2, MySQL and Rainbond still stop at the first failure")
+	upCmd.Flags().BoolVar(&checkDisk, "check-disk", false, "During prechecks, probe disk fsync latency on etcd nodes (fio, falling back to dd) and warn when it exceeds etcd's 10ms recommendation")
 	upCmd.Flags().BoolVar(&lvmFlag, "lvm", false, "Show LVM status and create LVM configuration")
+	upCmd.Flags().BoolVar(&lvmPlan, "plan", false, "With --lvm, print the per-host LVM change plan (devices initialized, VG composition, LV sizes, mountpoints, fstab lines) and flag destructive operations without executing anything")
+	upCmd.Flags().BoolVar(&dnsFlag, "dns", false, "Write the dns.hosts entries to /etc/hosts and dns.nameservers to /etc/resolv.conf on every node")
+	upCmd.Flags().StringVar(&existingCluster, "existing-cluster", "", "Install MySQL and Rainbond into an existing Kubernetes cluster using this kubeconfig: skip the RKE2 stage and run a compatibility precheck (overrides existing_cluster.kubeconfig)")
 	upCmd.Flags().BoolVar(&rke2Flag, "rke2", false, "Install and configure RKE2 Kubernetes cluster")
 	upCmd.Flags().BoolVar(&mysqlFlag, "mysql", false, "Install and configure MySQL master-slave cluster")
 	upCmd.Flags().BoolVar(&rainbondFlag, "rainbond", false, "Install and configure Rainbond")
 	upCmd.Flags().BoolVar(&optimizeFlag, "optimize", false, "Optimize system for containerized environments")
+	upCmd.Flags().StringVar(&optimizeProfile, "profile", "", "System tuning profile for --optimize: balanced, high-throughput, low-memory (overrides optimize.profile)")
+	upCmd.Flags().BoolVar(&optimizeReboot, "reboot", false, "With --optimize, reboot hosts that need a reboot for changes to take effect (e.g. SELinux); when the cluster is up, nodes are cordoned and drained first, workers before control nodes, one at a time")
+	upCmd.Flags().BoolVar(&optimizeCheck, "validate", false, "With --optimize, only report per host which items (firewalld, UFW, SELinux, swap, sysctl, limits) comply and which would be changed, without modifying anything")
+	upCmd.Flags().BoolVar(&externalVerify, "external-verify", false, "With --mysql, only verify the deployed MySQL without changing it: pods Ready, master/slave reachable, console/region databases exist with utf8mb4, replication running (uses a throwaway client pod)")
+	upCmd.Flags().BoolVar(&recreateFlag, "recreate", false, "Wipe existing MySQL data directories before deploying MySQL (destructive)")
+	upCmd.Flags().BoolVar(&checkPorts, "check-ports", false, "Before each node joins the cluster, verify it can reach ports 9345/6443 on the first server")
+	upCmd.Flags().BoolVar(&detectNodeIP, "detect-node-ip", false, "Before installing RKE2, detect each node's default-route interface and address over SSH (ip route get 1.1.1.1): use it as node-ip for hosts without internal_ip and warn when the configured ip/internal_ip is not an address the node actually has")
+	upCmd.Flags().BoolVar(&cleanResidue, "clean-residue", false, "Run rke2-uninstall.sh on nodes with a partial RKE2 install before reinstalling (destructive)")
+	upCmd.Flags().StringVarP(&outputFormat, "output", "o", "table", "LVM status output format with --lvm: table, json, yaml")
+	upCmd.Flags().BoolVar(&tuiFlag, "tui", false, "Show a live per-node, per-stage dashboard during the full installation (ignored when stdout is not a terminal)")
+	upCmd.Flags().StringVar(&reportFile, "report-file", "", "During the full installation, also write the consolidated end-of-run warning report (affected hosts, remediation, whether acknowledged) as JSON to this file")
+	upCmd.Flags().StringVar(&eventsFile, "events-file", "", "Write structured stage/node events as JSON Lines to this file during the full installation")
+	upCmd.Flags().BoolVar(&waitReady, "wait-ready", false, "After the Rainbond Helm install, wait until all pods in the Rainbond namespace are Running and Ready")
+	upCmd.Flags().DurationVar(&waitTimeout, "wait-timeout", 30*time.Minute, "Maximum time to wait for Rainbond components with --wait-ready")
+	upCmd.Flags().BoolVar(&strictFlag, "strict", false, "During the full installation, verify each stage before continuing (all nodes Ready after RKE2, MySQL reachable after MySQL, all Rainbond components Ready) and stop at the first failed gate")
+	upCmd.Flags().StringVar(&chartRef, "chart", rainbond.DefaultChartPath, "Rainbond chart to install: a local .tgz or chart directory, oci://<registry>/<repo>/<chart>[:<version>], or an http(s):// URL (remote charts are downloaded to ./charts)")
+	upCmd.Flags().StringVar(&chartSHA256, "chart-sha256", "", "Expected sha256 of the Rainbond chart package, verified before install")
+	upCmd.Flags().StringSliceVar(&logCommands, "log-commands", nil, "Log every remote command of the given stages to the log file with passwords and tokens redacted as ****: all, check, dns, lvm, optimize, cluster-compat, rke2, mysql, rainbond (comma separated)")
+	upCmd.Flags().StringArrayVar(&setValues, "set", nil, "Override Rainbond values on the command line (can be repeated, e.g. --set Cluster.gatewayIngressIPs=1.2.3.4)")
 
 	sshSetupCmd.Flags().BoolVar(&sshUnifiedPassword, "unified-password", false, "All hosts use the same password")
 	sshSetupCmd.Flags().BoolVar(&sshForceGenerate, "force-generate", false, "Force generate new SSH key pair")
@@ -492,6 +910,10 @@ func init() {
 }
 
 func initConfig() {
+	// 从标准输入读取的配置由 loadConfigFromFlags 加载，标准输入只能读取一次
+	if cfgFile == config.StdinConfigPath {
+		return
+	}
 	if cfgFile != "" {
 		viper.SetConfigFile(cfgFile)
 	} else {
@@ -510,7 +932,18 @@ func initConfig() {
 }
 
 func main() {
-	if err := Execute(); err != nil {
+	// 中断时关闭native后端的SSH连接池，避免遗留远程连接
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		ssh.CloseAll()
+		os.Exit(130)
+	}()
+
+	err := Execute()
+	ssh.CloseAll()
+	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
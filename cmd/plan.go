@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var planCmd = &cobra.Command{
+	Use:   "plan",
+	Short: "Summarize the intended deployment without touching any host",
+	Long: `Print an overview of what 'roi up' would deploy from the configuration:
+  - the installation stages that would run and those that would be skipped
+  - each host's node name, host group, Kubernetes roles, Rainbond roles, MySQL role,
+    node labels and taints
+  - etcd/master/worker counts and the control-plane taint strategy
+  - the LVM layout per host (volume group, PV devices, logical volumes, mounts)
+  - whether MySQL (master/slaves) and Rainbond will be deployed and the
+    generated gateway nodes and ingress IPs
+  - topology warnings (etcd, MySQL, Rainbond roles)
+  - configuration suggestions: legal but discouraged layouts such as no dedicated
+    workers on a large cluster, a single etcd member behind several masters,
+    rbd-gateway on every node, a MySQL master on an etcd-only node, or LVM
+    configured on only some hosts with the same roles
+
+Plan only evaluates the configuration: it never connects to the hosts, so
+facts that need SSH (e.g. hostnames under node_name_strategy: hostname) are
+shown as placeholders.
+
+Usage examples:
+  roi plan
+  roi plan --config cluster.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, _, err := loadConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		printPlan(cfg)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(planCmd)
+}
+
+// printPlan 输出部署计划，只使用配置的分类和后处理结果，不连接任何主机
+func printPlan(cfg *config.Config) {
+	installer := rke2.NewRKE2Installer(cfg)
+
+	fmt.Println("=== 部署计划 ===")
+	if cfg.ClusterName != "" {
+		fmt.Printf("集群名称: %s\n", cfg.ClusterName)
+	}
+
+	stages, skipped := planInstallStages(cfg)
+	fmt.Println("\n安装阶段:")
+	for i, stage := range stages {
+		fmt.Printf("  %d. %s\n", i+1, stage.name)
+	}
+	for _, reason := range skipped {
+		fmt.Printf("  - %s\n", reason)
+	}
+
+	fmt.Printf("\n主机 (%d):\n", len(cfg.Hosts))
+	for _, host := range cfg.Hosts {
+		fmt.Printf("  %s\n", host.IP)
+		fmt.Printf("    节点名称: %s\n", planNodeName(cfg, host))
+		if host.Group != "" {
+			fmt.Printf("    主机组: %s\n", host.Group)
+		}
+		if host.InternalIP != "" && host.InternalIP != host.IP {
+			fmt.Printf("    内网IP: %s\n", host.InternalIP)
+		}
+		fmt.Printf("    Kubernetes角色: %s\n", strings.Join(host.Role, ", "))
+		if len(host.RbdRole) > 0 {
+			fmt.Printf("    Rainbond角色: %s\n", strings.Join(host.RbdRole, ", "))
+		}
+		switch {
+		case host.MySQLMaster:
+			fmt.Println("    MySQL: master")
+		case host.MySQLSlave:
+			fmt.Println("    MySQL: slave")
+		}
+		if len(host.NodeLabel) > 0 {
+			fmt.Printf("    节点标签: %s\n", strings.Join(host.NodeLabel, ", "))
+		}
+		if !cfg.IsExistingCluster() {
+			taints := installer.NodeTaints(host)
+			if len(taints) == 0 {
+				fmt.Println("    污点: 无")
+			} else {
+				fmt.Printf("    污点: %s\n", strings.Join(taints, ", "))
+			}
+		}
+		printPlanLVM(host)
+	}
+
+	fmt.Println("\nKubernetes集群:")
+	if cfg.IsExistingCluster() {
+		fmt.Printf("  使用已有集群（kubeconfig: %s），不安装RKE2\n", cfg.KubeconfigPath())
+	} else {
+		masters, workers := 0, len(cfg.GetWorkerHosts())
+		for _, host := range cfg.Hosts {
+			if hostHasRole(host, "master") || hostHasRole(host, "etcd") {
+				masters++
+			}
+		}
+		fmt.Printf("  etcd节点: %d，server节点（master/etcd）: %d，worker节点: %d\n", len(cfg.GetEtcdMemberHosts()), masters, workers)
+		if cfg.HasWorkerNodes() {
+			fmt.Println("  污点策略: 存在纯worker节点，未配置node-taint的控制平面节点使用 node-role.kubernetes.io/control-plane:NoSchedule")
+		} else {
+			fmt.Println("  污点策略: 没有纯worker节点，未配置node-taint的控制平面节点使用 node-role.kubernetes.io/control-plane:PreferNoSchedule")
+		}
+	}
+
+	fmt.Println("\nMySQL:")
+	if hasMySQLConfig(cfg) {
+		fmt.Printf("  部署主从集群，master: %s，slave: %s\n", planHostIPs(cfg.GetMySQLMasterHosts()), planHostIPs(cfg.GetMySQLSlaveHosts()))
+	} else {
+		fmt.Println("  不部署")
+	}
+
+	fmt.Println("\nRainbond:")
+	namespace := cfg.Rainbond.Namespace
+	if namespace == "" {
+		namespace = "rbd-system"
+	}
+	fmt.Printf("  部署到命名空间 %s\n", namespace)
+	fmt.Printf("  网关节点: %s\n", planHostIPs(cfg.GetRbdGatewayHosts()))
+	fmt.Printf("  chaos节点: %s\n", planHostIPs(cfg.GetRbdChaosHosts()))
+	if ips := cfg.GetGatewayIngressIPs(); len(ips) > 0 {
+		fmt.Printf("  网关入口IP: %s\n", strings.Join(ips, ", "))
+	} else {
+		fmt.Println("  网关入口IP: 未设置")
+	}
+
+	var warnings []string
+	warnings = append(warnings, cfg.EtcdTopologyWarnings()...)
+	warnings = append(warnings, cfg.MySQLTopologyWarnings()...)
+	warnings = append(warnings, cfg.RbdRoleWarnings()...)
+	fmt.Println("\n警告:")
+	if len(warnings) == 0 {
+		fmt.Println("  无")
+	}
+	for _, warning := range warnings {
+		fmt.Printf("\033[33m  ⚠ %s\033[0m\n", warning)
+	}
+
+	// 配置建议只作为提示，不影响安装
+	fmt.Println("\n配置建议:")
+	lints := cfg.LintWarnings()
+	if len(lints) == 0 {
+		fmt.Println("  无")
+	}
+	for _, lint := range lints {
+		fmt.Printf("\033[36m  ℹ %s\033[0m\n", lint)
+	}
+}
+
+// printPlanLVM 输出主机的LVM布局
+func printPlanLVM(host config.Host) {
+	if host.LVMConfig == nil || len(host.LVMConfig.PVDevices) == 0 {
+		return
+	}
+	fmt.Printf("    LVM: 卷组 %s（PV: %s）\n", host.LVMConfig.VGName, strings.Join(host.LVMConfig.PVDevices, ", "))
+	for _, lv := range host.LVMConfig.LVs {
+		fmt.Printf("      %s %s -> %s\n", lv.LVName, lv.Size, config.LVMountPoint(lv))
+	}
+}
+
+// planNodeName 获取主机的节点名称，hostname策略下需通过SSH获取的名称显示为占位符
+func planNodeName(cfg *config.Config, host config.Host) string {
+	if host.NodeName == "" && cfg.RKE2.NodeNameStrategy == config.NodeNameStrategyHostname {
+		return "<hostname -f>"
+	}
+	return cfg.GetNodeName(host)
+}
+
+func planHostIPs(hosts []config.Host) string {
+	if len(hosts) == 0 {
+		return "无"
+	}
+	var ips []string
+	for _, host := range hosts {
+		ips = append(ips, host.IP)
+	}
+	return strings.Join(ips, ", ")
+}
+
+func hostHasRole(host config.Host, role string) bool {
+	for _, r := range host.Role {
+		if strings.TrimSpace(strings.ToLower(r)) == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// rebootOptimizedHosts 重启系统优化报告需要重启的主机（--reboot），返回剩余未处理的警告
+// 集群已运行时由安装器逐个驱逐节点后重启，worker优先，控制节点逐个处理
+func rebootOptimizedHosts(cfg *config.Config, installer *rke2.RKE2Installer, warnings []advisory.Warning) ([]advisory.Warning, error) {
+	pending := make(map[string]bool)
+	var remaining []advisory.Warning
+	for _, w := range warnings {
+		if w.Category == advisory.CategoryRebootRequired {
+			pending[w.Host] = true
+			continue
+		}
+		remaining = append(remaining, w)
+	}
+	if len(pending) == 0 {
+		return warnings, nil
+	}
+
+	var hosts []config.Host
+	for _, host := range cfg.Hosts {
+		if pending[host.IP] {
+			hosts = append(hosts, host)
+		}
+	}
+	if err := installer.RebootHosts(hosts); err != nil {
+		return warnings, err
+	}
+	return remaining, nil
+}
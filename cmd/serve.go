@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/mysql"
+	"github.com/rainbond/rainbond-offline-installer/internal/rainbond"
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveListen   string
+	serveInterval time.Duration
+)
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve cluster health over HTTP for monitoring",
+	Long: `Run roi as a lightweight daemon that periodically collects cluster health
+and serves the latest result over HTTP:
+  - nodes: rke2 service state on every host and Kubernetes node Ready
+  - mysql: MySQL master pod Ready and service reachable (when mysql.enabled)
+  - rainbond: helm release state and pod readiness of every component
+  - gateway: Rainbond console reachable on the gateway node (port 7070)
+
+Endpoints:
+  GET /status   latest result as JSON
+  GET /healthz  200 when every check passed, 503 otherwise or before the
+                first collection finished
+
+roi serve is read-only and stateless: nothing is written to the hosts, the
+cluster or the local state file, and results are only kept in memory.
+
+Usage examples:
+  roi serve
+  roi serve --listen 127.0.0.1:9090 --interval 1m`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runServe(cfg)
+	},
+}
+
+func init() {
+	serveCmd.Flags().StringVar(&serveListen, "listen", ":8080", "Address the HTTP server listens on")
+	serveCmd.Flags().DurationVar(&serveInterval, "interval", 30*time.Second, "Interval between health collections")
+	rootCmd.AddCommand(serveCmd)
+}
+
+// healthCheck 单项健康检查结果
+type healthCheck struct {
+	Healthy bool        `json:"healthy"`
+	Error   string      `json:"error,omitempty"`
+	Detail  interface{} `json:"detail,omitempty"`
+}
+
+// healthReport 一轮健康检查的结果
+type healthReport struct {
+	CollectedAt string                  `json:"collected_at"`
+	DurationMS  int64                   `json:"duration_ms"`
+	Healthy     bool                    `json:"healthy"`
+	Checks      map[string]*healthCheck `json:"checks"`
+}
+
+// nodeHealth 单个主机的RKE2状态
+type nodeHealth struct {
+	IP      string `json:"ip"`
+	Status  string `json:"status"`
+	Running bool   `json:"running"`
+	Error   string `json:"error,omitempty"`
+}
+
+// healthServer 保存最近一次检查结果，仅存在于内存中
+type healthServer struct {
+	config *config.Config
+	mu     sync.RWMutex
+	latest *healthReport
+}
+
+func runServe(cfg *config.Config) error {
+	if serveInterval <= 0 {
+		return fmt.Errorf("--interval 必须大于0")
+	}
+
+	s := &healthServer{config: cfg}
+	go s.loop()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", s.handleStatus)
+	mux.HandleFunc("/healthz", s.handleHealthz)
+
+	fmt.Printf("🩺 健康检查服务已启动: http://%s （检查间隔: %s）\n", serveListen, serveInterval)
+	server := &http.Server{
+		Addr:              serveListen,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("HTTP服务启动失败: %w", err)
+	}
+	return nil
+}
+
+// loop 按间隔执行检查，单轮检查超过间隔时取消，避免多轮检查重叠
+func (s *healthServer) loop() {
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), serveInterval)
+		report := s.collect(ctx)
+		cancel()
+
+		s.mu.Lock()
+		s.latest = report
+		s.mu.Unlock()
+
+		time.Sleep(serveInterval)
+	}
+}
+
+func (s *healthServer) collect(ctx context.Context) *healthReport {
+	start := time.Now()
+	checks := map[string]*healthCheck{
+		"gateway": checkGatewayHealth(ctx, s.config),
+	}
+	if !s.config.IsExistingCluster() {
+		checks["nodes"] = checkNodesHealth(ctx, s.config)
+	}
+	if s.config.MySQL.Enabled {
+		checks["mysql"] = checkMySQLHealth(s.config)
+	}
+	checks["rainbond"] = checkRainbondHealth(s.config)
+
+	report := &healthReport{
+		CollectedAt: start.Format(time.RFC3339),
+		DurationMS:  time.Since(start).Milliseconds(),
+		Healthy:     true,
+		Checks:      checks,
+	}
+	for _, check := range checks {
+		report.Healthy = report.Healthy && check.Healthy
+	}
+	return report
+}
+
+func (s *healthServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	report := s.latest
+	s.mu.RUnlock()
+
+	if report == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "首次检查尚未完成"})
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
+func (s *healthServer) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	s.mu.RLock()
+	report := s.latest
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if report == nil || !report.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintln(w, "unhealthy")
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+// checkNodesHealth 所有主机rke2服务运行且对应节点Ready
+func checkNodesHealth(ctx context.Context, cfg *config.Config) *healthCheck {
+	results, err := rke2.NewStatusChecker(cfg).Check(ctx)
+	check := &healthCheck{Healthy: err == nil}
+	if err != nil {
+		check.Error = err.Error()
+	}
+
+	nodes := make([]nodeHealth, 0, len(results))
+	for _, status := range results {
+		nodes = append(nodes, nodeHealth{IP: status.IP, Status: status.Status, Running: status.Running, Error: status.Error})
+		if status.Status != "运行中" {
+			check.Healthy = false
+		}
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].IP < nodes[j].IP })
+	check.Detail = nodes
+	return check
+}
+
+func checkMySQLHealth(cfg *config.Config) *healthCheck {
+	if err := mysql.NewMySQLInstaller(cfg).VerifyReachable(); err != nil {
+		return &healthCheck{Error: err.Error()}
+	}
+	return &healthCheck{Healthy: true}
+}
+
+func checkRainbondHealth(cfg *config.Config) *healthCheck {
+	status, err := rainbond.NewRainbondInstaller(cfg).CollectStatus()
+	if err != nil {
+		return &healthCheck{Error: err.Error()}
+	}
+	return &healthCheck{Healthy: status.Healthy(), Detail: status}
+}
+
+// checkGatewayHealth 与 roi doctor 相同，访问网关节点上的Rainbond控制台
+func checkGatewayHealth(ctx context.Context, cfg *config.Config) *healthCheck {
+	gatewayIP := ""
+	if gatewayHosts := cfg.GetRbdGatewayHosts(); len(gatewayHosts) > 0 {
+		gatewayIP = gatewayHosts[0].IP
+	} else if len(cfg.Hosts) > 0 {
+		gatewayIP = cfg.Hosts[0].IP
+	}
+	if gatewayIP == "" {
+		return &healthCheck{Error: "未找到网关节点"}
+	}
+
+	url := fmt.Sprintf("http://%s:7070", config.URLHost(gatewayIP))
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return &healthCheck{Error: err.Error(), Detail: url}
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return &healthCheck{Error: err.Error(), Detail: url}
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return &healthCheck{Error: fmt.Sprintf("控制台返回 %d", resp.StatusCode), Detail: url}
+	}
+	return &healthCheck{Healthy: true, Detail: url}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/smoke"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var (
+	smokeImage        string
+	smokeIngress      bool
+	smokeIngressClass string
+	smokeTimeout      time.Duration
+	smokeKeep         bool
+)
+
+var smokeTestCmd = &cobra.Command{
+	Use:   "smoke-test",
+	Short: "Deploy a test workload and verify the cluster can run and route it",
+	Long: `Prove the cluster works end-to-end beyond component health:
+  1. Create the roi-smoke-test namespace with a one-replica HTTP Deployment
+     and a NodePort Service (and, with --ingress, an Ingress)
+  2. Wait for the test pod to become Ready
+  3. Request the Service ClusterIP from the first control node over SSH
+  4. Request the NodePort on the gateway IP from this machine
+  5. With --ingress, request the Ingress host through the Rainbond gateway
+  6. Delete the roi-smoke-test namespace
+
+Each step is reported with pass/fail and timing. Exits non-zero when any step
+fails. The test image must serve HTTP on port 80 and be available to the
+nodes; in offline environments import it first or point --image at an image
+in the private registry.
+
+Usage examples:
+  roi smoke-test
+  roi smoke-test --ingress
+  roi smoke-test --image 10.10.152.36:5000/library/nginx:alpine --keep`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runSmokeTest(cfg)
+	},
+}
+
+func init() {
+	smokeTestCmd.Flags().StringVar(&smokeImage, "image", smoke.DefaultImage, "HTTP server image for the test workload (must serve port 80)")
+	smokeTestCmd.Flags().BoolVar(&smokeIngress, "ingress", false, "Also create an Ingress and reach it through the Rainbond gateway")
+	smokeTestCmd.Flags().StringVar(&smokeIngressClass, "ingress-class", smoke.DefaultIngressClass, "IngressClass served by the Rainbond gateway")
+	smokeTestCmd.Flags().DurationVar(&smokeTimeout, "timeout", smoke.DefaultTimeout, "Timeout for the workload to become Ready and reachable")
+	smokeTestCmd.Flags().BoolVar(&smokeKeep, "keep", false, "Keep the test resources after the run for troubleshooting")
+	rootCmd.AddCommand(smokeTestCmd)
+}
+
+func runSmokeTest(cfg *config.Config) error {
+	appLogger, err := logger.NewLogger(logger.INFO, logger.DEBUG)
+	if err != nil {
+		return fmt.Errorf("初始化日志记录器失败: %w", err)
+	}
+	defer appLogger.Close()
+
+	fmt.Println("🧪 集群冒烟测试")
+	fmt.Println(strings.Repeat("=", 60))
+
+	tester := smoke.NewTesterWithLogger(cfg, appLogger)
+	tester.SetImage(smokeImage)
+	tester.SetIngress(smokeIngress, smokeIngressClass)
+	tester.SetTimeout(smokeTimeout)
+	tester.SetKeep(smokeKeep)
+
+	start := time.Now()
+	results, runErr := tester.Run()
+
+	fmt.Println()
+	for _, result := range results {
+		mark := "\033[32m✓\033[0m"
+		if !result.Passed {
+			mark = "\033[31m✗\033[0m"
+		}
+		fmt.Printf("%s %-28s %8s  %s\n", mark, result.Name, result.Duration.Round(time.Millisecond), result.Detail)
+	}
+	fmt.Println()
+
+	elapsed := time.Since(start).Round(time.Second)
+	if runErr != nil {
+		return fmt.Errorf("冒烟测试未通过（耗时 %s）: %w，详细日志文件: %s", elapsed, runErr, appLogger.GetLogFilePath())
+	}
+	fmt.Printf("\033[32m✅ 冒烟测试通过，集群可以运行并访问工作负载\033[0m（耗时 %s），详细日志文件: %s\n", elapsed, appLogger.GetLogFilePath())
+	return nil
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// loadSSHConfigFromFlags 加载配置文件并检查SSH前提，所有需要连接主机的命令都通过它加载配置
+func loadSSHConfigFromFlags() (*config.Config, error) {
+	cfg, _, err := loadConfigFromFlags()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSSHPrerequisites(cfg.Hosts); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// checkSSHPrerequisites 在任何远程操作之前检查SSH认证的本地前提：密码认证所需的sshpass和配置的ssh_key文件，
+// 不满足时立即失败；私钥权限过宽等问题只输出警告，警告写到标准错误，不影响 --stdout、-o json 等输出
+func checkSSHPrerequisites(hosts []config.Host) error {
+	if err := ssh.CheckSSHPassAvailable(hosts); err != nil {
+		return err
+	}
+	warnings, err := ssh.CheckSSHKeys(hosts)
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "\033[33m[WARN]\033[0m %s\n", warning)
+	}
+	return err
+}
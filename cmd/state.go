@@ -0,0 +1,112 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/state"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// stageHosts 记录阶段中需要处理和跳过的主机，阶段成功后将处理过的主机写入状态文件
+type stageHosts struct {
+	stage     string
+	state     *state.State
+	hash      func(config.Host) string
+	processed []config.Host
+	skipped   []config.Host
+}
+
+// planStageHosts 根据状态文件划分阶段需要处理的主机：未指定 --force 时跳过配置未变更且上次成功的主机
+// 状态文件读取失败时处理全部主机，不影响安装
+func planStageHosts(cfg *config.Config, stage string) *stageHosts {
+	plan := &stageHosts{stage: stage, hash: stageHostHash(cfg, stage), processed: cfg.Hosts}
+	st, err := state.Load(state.DefaultPath)
+	if err != nil {
+		fmt.Printf("\033[33m[WARN]\033[0m %v，本次处理全部主机\n", err)
+		return plan
+	}
+	plan.state = st
+	if !forceFlag {
+		plan.processed, plan.skipped = st.Partition(stage, cfg.Hosts, plan.hash)
+	}
+	return plan
+}
+
+// summary 返回处理和跳过主机的说明
+func (p *stageHosts) summary() string {
+	if len(p.skipped) == 0 {
+		return fmt.Sprintf("%s阶段处理全部 %d 个主机", stageDisplayName(p.stage), len(p.processed))
+	}
+	return fmt.Sprintf("%s阶段处理 %d 个新增或变更的主机 [%s]，跳过 %d 个配置未变更且上次成功的主机 [%s]（--force 处理全部主机）",
+		stageDisplayName(p.stage), len(p.processed), hostIPs(p.processed), len(p.skipped), hostIPs(p.skipped))
+}
+
+// record 根据阶段结果记录成功处理的主机：阶段成功时记录全部处理过的主机，
+// --continue-on-error 下部分失败时只记录成功的主机；写入失败只提示，不影响安装
+func (p *stageHosts) record(err error) {
+	if p.state == nil || len(p.processed) == 0 {
+		return
+	}
+	succeeded := p.processed
+	if err != nil {
+		failures, ok := err.(ssh.HostErrors)
+		if !ok {
+			return
+		}
+		succeeded = nil
+		for _, host := range p.processed {
+			if !failures.Failed(host.IP) {
+				succeeded = append(succeeded, host)
+			}
+		}
+	}
+	p.state.MarkVerified(p.stage, succeeded, p.hash)
+	if err := p.state.Save(); err != nil {
+		fmt.Printf("\033[33m[WARN]\033[0m %v\n", err)
+	}
+}
+
+// stageHostHash 返回阶段的主机配置摘要函数，optimize阶段还包含生效的调优档位、自定义模板内容和node_timezone
+func stageHostHash(cfg *config.Config, stage string) func(config.Host) string {
+	var extra interface{}
+	if stage == state.StageOptimize {
+		templates := make(map[string]string)
+		for _, path := range []string{cfg.Optimize.SysctlTemplate, cfg.Optimize.LimitsTemplate} {
+			if path == "" {
+				continue
+			}
+			content, _ := os.ReadFile(path)
+			templates[path] = string(content)
+		}
+		extra = struct {
+			Profile   string
+			Optimize  config.OptimizeConfig
+			Templates map[string]string
+			Timezone  string `json:",omitempty"` // 未配置时不改变已有的摘要
+		}{optimizeProfile, cfg.Optimize, templates, cfg.NodeTimezone}
+	}
+	return func(host config.Host) string {
+		return state.HostHash(host, extra)
+	}
+}
+
+func stageDisplayName(stage string) string {
+	switch stage {
+	case state.StageCheck:
+		return "系统检查"
+	case state.StageOptimize:
+		return "系统优化"
+	}
+	return stage
+}
+
+func hostIPs(hosts []config.Host) string {
+	ips := make([]string, len(hosts))
+	for i, host := range hosts {
+		ips[i] = host.IP
+	}
+	return strings.Join(ips, ", ")
+}
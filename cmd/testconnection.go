@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var testConnectionCmd = &cobra.Command{
+	Use:   "test-connection",
+	Short: "Test SSH, scp and rsync to every host",
+	Long: `Run a small round-trip against every host before a long install:
+  - SSH: execute a command
+  - scp: copy a small temp file and verify its content
+  - rsync: copy the same file with rsync (used for large offline artifacts)
+
+rsync failures are reported as warnings because the installer falls back to scp.
+
+Usage examples:
+  roi test-connection
+  roi test-connection --ssh-backend native`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runTestConnection(cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(testConnectionCmd)
+}
+
+func runTestConnection(cfg *config.Config) error {
+	fmt.Println("🔌 测试主机连接")
+	fmt.Println(strings.Repeat("=", 60))
+
+	results, err := rke2.NewRKE2Installer(cfg).TestConnections()
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, result := range results {
+		fmt.Printf("主机 %s\n", result.IP)
+		fmt.Printf("  SSH   : %s\n", transportStatus(result.SSH, ""))
+		fmt.Printf("  scp   : %s\n", transportStatus(result.SCP, ""))
+		fmt.Printf("  rsync : %s\n", transportStatus(result.Rsync, result.RsyncSkipped))
+		if result.SSH != nil || result.SCP != nil {
+			failed++
+		}
+	}
+	fmt.Println(strings.Repeat("=", 60))
+
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个主机连接测试失败", failed, len(results))
+	}
+	fmt.Printf("\033[32m✅ 所有 %d 个主机连接测试通过\033[0m\n", len(results))
+	return nil
+}
+
+// transportStatus 格式化单个传输方式的测试结果
+func transportStatus(err error, skipped string) string {
+	if skipped != "" {
+		return fmt.Sprintf("\033[36m- 跳过 (%s)\033[0m", skipped)
+	}
+	if err != nil {
+		return fmt.Sprintf("\033[31m✗ %v\033[0m", err)
+	}
+	return "\033[32m✓ 成功\033[0m"
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/rke2"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+	"github.com/spf13/cobra"
+)
+
+var upgradeRKE2Cmd = &cobra.Command{
+	Use:   "upgrade-rke2",
+	Short: "Upgrade an existing RKE2 cluster in place with new offline artifacts",
+	Long: `Upgrade every node of an existing RKE2 cluster to the version contained in the
+offline artifacts of the current directory (rke2-install.sh, rke2.linux*.tar.gz,
+sha256sum*.txt, rke2-images-linux.tar).
+
+Nodes are upgraded one at a time: the first server, the remaining servers, then
+agents. Each node is drained, its binaries and images are replaced, the service
+is restarted and the node must become Ready on the new version before it is
+uncordoned. Cluster health (all nodes Ready, API server /readyz including etcd)
+is verified before moving on, so at most one etcd member is down at a time.
+Nodes already running the target version are skipped, so an interrupted
+upgrade can be resumed by running the command again.
+
+Usage examples:
+  roi upgrade-rke2
+  roi upgrade-rke2 --config config.yaml`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadSSHConfigFromFlags()
+		if err != nil {
+			return err
+		}
+		return runUpgradeRKE2(cfg)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(upgradeRKE2Cmd)
+}
+
+func runUpgradeRKE2(cfg *config.Config) error {
+	appLogger, err := logger.NewLogger(logger.INFO, logger.DEBUG)
+	if err != nil {
+		return fmt.Errorf("初始化日志记录器失败: %w", err)
+	}
+	defer appLogger.Close()
+
+	if err := rke2.NewRKE2InstallerWithLogger(cfg, appLogger).Upgrade(); err != nil {
+		appLogger.Error("RKE2升级失败: %v", err)
+		return fmt.Errorf("RKE2升级失败: %w", err)
+	}
+
+	fmt.Printf("\033[32m✅ RKE2集群升级完成\033[0m，详细日志文件: %s\n", appLogger.GetLogFilePath())
+	return nil
+}
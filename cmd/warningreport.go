@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/logger"
+)
+
+// installWarnings 完整安装过程中各阶段记录的警告，只在完整安装时创建
+var installWarnings *advisory.Report
+
+// recordWarnings 将阶段的警告记录到汇总报告，非完整安装时忽略
+func recordWarnings(stage string, warnings []advisory.Warning, acknowledgement string) {
+	if installWarnings == nil {
+		return
+	}
+	installWarnings.Add(stage, warnings, acknowledgement)
+}
+
+// configLintWarnings 将配置建议转换为警告，记录到安装结束时的汇总报告中
+func configLintWarnings(cfg *config.Config) []advisory.Warning {
+	var warnings []advisory.Warning
+	for _, message := range cfg.LintWarnings() {
+		warnings = append(warnings, advisory.Warning{Category: advisory.CategoryConfigLint, Message: message})
+	}
+	return warnings
+}
+
+// finishWarningReport 在安装结束（成功或失败）时输出警告汇总，写入日志文件，指定 --report-file 时同时写入JSON
+func finishWarningReport(appLogger *logger.Logger) {
+	if installWarnings == nil {
+		return
+	}
+	installWarnings.Print(os.Stdout)
+	installWarnings.Log(appLogger)
+	if reportFile == "" {
+		return
+	}
+	if err := installWarnings.WriteJSON(reportFile); err != nil {
+		appLogger.Error("%v", err)
+		fmt.Printf("\033[33m[WARN]\033[0m %v\n", err)
+		return
+	}
+	fmt.Printf("警告汇总报告: %s\n", reportFile)
+}
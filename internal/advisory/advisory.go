@@ -0,0 +1,286 @@
+package advisory
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 警告类型，汇总报告按类型合并同类警告并给出修复建议
+const (
+	CategoryKernel           = "kernel"
+	CategoryMemory           = "memory"
+	CategoryRootDisk         = "root_disk"
+	CategoryPacketLoss       = "packet_loss"
+	CategoryEtcdTopology     = "etcd_topology"
+	CategoryEtcdDisk         = "etcd_disk"
+	CategoryMySQLTopology    = "mysql_topology"
+	CategoryRbdRole          = "rbd_role"
+	CategoryGatewayIngress   = "gateway_ingress"
+	CategoryControlPlaneOnly = "control_plane_only"
+	CategoryNodeIP           = "node_ip"
+	CategoryRebootRequired   = "reboot_required"
+	CategoryTimezone         = "timezone"
+	CategoryInodes           = "inodes"
+	CategoryConfigLint       = "config_lint"
+)
+
+// Warning 安装过程中发现的不影响继续安装的问题
+type Warning struct {
+	Category string // 警告类型，见 Category* 常量
+	Host     string // 涉及的主机，集群级警告为空
+	Message  string
+}
+
+// SortByHosts 按主机在配置中的顺序稳定排序警告，集群级警告（Host为空）排在最后
+// 并行执行时警告按各主机完成的先后记录，排序后输出不受执行顺序影响
+func SortByHosts(warnings []Warning, hosts []string) []Warning {
+	order := make(map[string]int, len(hosts))
+	for i, host := range hosts {
+		order[host] = i
+	}
+	rank := func(w Warning) int {
+		if i, ok := order[w.Host]; ok {
+			return i
+		}
+		return len(hosts)
+	}
+	sorted := append([]Warning(nil), warnings...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank(sorted[i]) < rank(sorted[j])
+	})
+	return sorted
+}
+
+// guidance 警告类型的标题、修复建议和参考链接
+type guidance struct {
+	title       string
+	remediation string
+	link        string
+}
+
+var catalog = map[string]guidance{
+	CategoryKernel: {
+		title:       "内核版本过低",
+		remediation: "升级到4.x及以上内核（如CentOS 7通过ELRepo安装kernel-lt）后重启，或更换为受支持的操作系统版本",
+	},
+	CategoryMemory: {
+		title:       "内存不足",
+		remediation: "将节点内存扩容到至少4GB，或将Rainbond组件调度到内存充足的节点",
+	},
+	CategoryRootDisk: {
+		title:       "根分区可用空间不足",
+		remediation: "清理或扩容根分区；也可在主机lvm配置中添加lv_containerd，将容器存储放到独立磁盘",
+	},
+	CategoryInodes: {
+		title:       "文件系统空闲inode不足",
+		remediation: "清理大量小文件或扩容；容器存储建议使用独立的XFS逻辑卷（lv_containerd），ext4需在格式化时通过 mkfs.ext4 -i 调小每inode字节数",
+	},
+	CategoryPacketLoss: {
+		title:       "节点间网络丢包",
+		remediation: "检查网卡、交换机和MTU配置，以及安全组/防火墙是否限速；丢包会导致etcd选主和跨节点访问超时",
+	},
+	CategoryEtcdTopology: {
+		title:       "etcd节点数量不合理",
+		remediation: "使用奇数个etcd节点（1、3或5个），调整主机的etcd角色后重新安装",
+		link:        "https://etcd.io/docs/v3.5/faq/#why-an-odd-number-of-cluster-members",
+	},
+	CategoryEtcdDisk: {
+		title:       "etcd磁盘fsync延迟过高",
+		remediation: "将etcd数据目录（rke2.data_dir）放到SSD等低延迟磁盘，避免与其他高IO负载共用磁盘",
+		link:        "https://etcd.io/docs/v3.5/op-guide/hardware/",
+	},
+	CategoryMySQLTopology: {
+		title:       "MySQL主从部署在同一节点",
+		remediation: "为mysql_master和mysql_slave角色选择不同的主机，使主从复制具备容灾能力",
+	},
+	CategoryRbdRole: {
+		title:       "Rainbond组件分配在纯etcd节点",
+		remediation: "将rbd_gateway、rbd_chaos等rbd_role分配到worker节点，避免与etcd争用CPU和磁盘IO",
+	},
+	CategoryGatewayIngress: {
+		title:       "网关入口IP未绑定在网关节点",
+		remediation: "确认rainbond.gateway_ingress_ips为rbd-gateway节点的地址，或确保NAT/VIP流量能转发到网关节点",
+	},
+	CategoryControlPlaneOnly: {
+		title:       "集群没有worker节点",
+		remediation: "添加role为worker的节点以隔离业务负载，或确保控制平面节点的CPU和内存满足建议配置",
+	},
+	CategoryNodeIP: {
+		title:       "节点地址与配置不一致",
+		remediation: "在配置中为对应主机填写网卡上实际存在的internal_ip，确保节点间通过该地址通信",
+	},
+	CategoryTimezone: {
+		title:       "节点时区或语言环境不一致",
+		remediation: "在配置中设置node_timezone（如Asia/Shanghai）由系统优化阶段统一设置，或在各节点执行 timedatectl set-timezone；语言环境通过 localectl set-locale 统一",
+	},
+	CategoryRebootRequired: {
+		title:       "需要重启节点",
+		remediation: "在业务低峰期逐个重启列出的节点，使SELinux等配置在重启后保持禁用状态",
+	},
+	CategoryConfigLint: {
+		title:       "配置不符合运维最佳实践",
+		remediation: "按提示调整主机的role、rbd_role、MySQL角色或LVM配置；这些配置合法但容易在规模增长或节点故障时出现问题，可执行 roi plan 查看",
+	},
+}
+
+// Item 汇总报告中同一阶段、同一类型的警告
+type Item struct {
+	Stage           string   `json:"stage"`
+	Category        string   `json:"category"`
+	Title           string   `json:"title"`
+	Hosts           []string `json:"hosts,omitempty"`
+	Details         []string `json:"details"`
+	Remediation     string   `json:"remediation,omitempty"`
+	Link            string   `json:"link,omitempty"`
+	Acknowledged    bool     `json:"acknowledged"`
+	Acknowledgement string   `json:"acknowledgement,omitempty"` // 确认方式：交互确认、--assume-yes
+}
+
+// Report 跨阶段汇总的警告报告，可在并行执行的阶段中调用
+type Report struct {
+	mu    sync.Mutex
+	items []*Item
+}
+
+// NewReport 创建空的警告报告
+func NewReport() *Report {
+	return &Report{}
+}
+
+// Add 记录一个阶段的警告，acknowledgement 为用户确认这些警告的方式，为空表示未经确认
+func (r *Report) Add(stage string, warnings []Warning, acknowledgement string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, w := range warnings {
+		item := r.find(stage, w.Category)
+		if item == nil {
+			g, ok := catalog[w.Category]
+			if !ok {
+				g = guidance{title: w.Category}
+			}
+			item = &Item{
+				Stage:           stage,
+				Category:        w.Category,
+				Title:           g.title,
+				Remediation:     g.remediation,
+				Link:            g.link,
+				Acknowledged:    acknowledgement != "",
+				Acknowledgement: acknowledgement,
+			}
+			r.items = append(r.items, item)
+		}
+		if w.Host != "" && !contains(item.Hosts, w.Host) {
+			item.Hosts = append(item.Hosts, w.Host)
+		}
+		item.Details = append(item.Details, w.Message)
+	}
+}
+
+func (r *Report) find(stage, category string) *Item {
+	for _, item := range r.items {
+		if item.Stage == stage && item.Category == category {
+			return item
+		}
+	}
+	return nil
+}
+
+// Items 按记录顺序返回汇总后的警告
+func (r *Report) Items() []Item {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	items := make([]Item, 0, len(r.items))
+	for _, item := range r.items {
+		items = append(items, *item)
+	}
+	return items
+}
+
+// Print 输出汇总报告，没有警告时不输出
+func (r *Report) Print(w io.Writer) {
+	items := r.Items()
+	if len(items) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n\033[33m⚠️  安装警告汇总 (%d 类)\033[0m\n", len(items))
+	for i, item := range items {
+		fmt.Fprintf(w, "%d. [%s] %s\n", i+1, item.Stage, item.Title)
+		if len(item.Hosts) > 0 {
+			fmt.Fprintf(w, "   涉及主机: %s\n", strings.Join(item.Hosts, ", "))
+		}
+		for _, detail := range item.Details {
+			fmt.Fprintf(w, "   - %s\n", detail)
+		}
+		if item.Remediation != "" {
+			fmt.Fprintf(w, "   建议: %s\n", item.Remediation)
+		}
+		if item.Link != "" {
+			fmt.Fprintf(w, "   参考: %s\n", item.Link)
+		}
+		fmt.Fprintf(w, "   确认: %s\n", item.acknowledgementText())
+	}
+}
+
+// Logger 写入日志文件使用的日志接口
+type Logger interface {
+	InfoToFileOnly(format string, v ...interface{})
+}
+
+// Log 将汇总报告写入日志文件
+func (r *Report) Log(logger Logger) {
+	items := r.Items()
+	if len(items) == 0 {
+		logger.InfoToFileOnly("安装警告汇总: 无")
+		return
+	}
+	logger.InfoToFileOnly("安装警告汇总: %d 类", len(items))
+	for _, item := range items {
+		logger.InfoToFileOnly("[%s] %s 主机: %s 确认: %s", item.Stage, item.Title, strings.Join(item.Hosts, ","), item.acknowledgementText())
+		for _, detail := range item.Details {
+			logger.InfoToFileOnly("  - %s", detail)
+		}
+		if item.Remediation != "" {
+			logger.InfoToFileOnly("  建议: %s", item.Remediation)
+		}
+		if item.Link != "" {
+			logger.InfoToFileOnly("  参考: %s", item.Link)
+		}
+	}
+}
+
+// WriteJSON 将汇总报告以JSON格式写入文件
+func (r *Report) WriteJSON(path string) error {
+	data, err := json.MarshalIndent(struct {
+		GeneratedAt string `json:"generated_at"`
+		Warnings    []Item `json:"warnings"`
+	}{time.Now().Format(time.RFC3339), r.Items()}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化警告报告失败: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("写入警告报告 %s 失败: %w", path, err)
+	}
+	return nil
+}
+
+func (i Item) acknowledgementText() string {
+	if !i.Acknowledged {
+		return "未经确认"
+	}
+	return "已确认（" + i.Acknowledgement + "）"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
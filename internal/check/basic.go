@@ -3,12 +3,16 @@ package check
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
 
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
 	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
 )
 
 // Logger 定义日志接口
@@ -33,8 +37,17 @@ type BasicChecker struct {
 	config       *config.Config
 	logger       Logger
 	stepProgress StepProgress
-	results      map[string]*BasicCheckResult
-	warnings     []string
+	// results 创建时为每个主机初始化，此后不再增删键；并行检查时各主机只修改自己的结果（配置校验保证主机IP唯一），
+	// 汇总读取在 ssh.ForEachHost 返回之后进行，因此无需加锁
+	results         map[string]*BasicCheckResult
+	warnings        []advisory.Warning // 各检查项和并行检查的主机共同写入，只能通过 addWarning 追加
+	warningsMu      sync.Mutex         // 保护 warnings
+	acknowledgement string             // 用户确认警告的方式，未确认时为空
+	diskProbe       bool               // 是否检测etcd节点磁盘fsync延迟
+	assumeYes       bool               // 发现警告时自动确认继续，不等待用户输入
+	skipHosts       map[string]bool    // 配置未变更且上次检查通过的主机，本次跳过
+	continueOnError bool               // 某个主机检查失败时继续检查其余主机，最后汇总报告
+	runner          ssh.Runner         // 非nil时远程命令通过 Runner 执行
 }
 
 type BasicCheckResult struct {
@@ -47,6 +60,9 @@ type BasicCheckResult struct {
 	MemoryGB  int
 	RootSpace string
 	RootUsage string
+	Inodes    string // 根分区和容器存储所在文件系统的空闲inode比例
+	Timezone  string // 时区名称和UTC偏移，如 Asia/Shanghai (UTC+0800)
+	Locale    string // 系统语言环境LANG
 	Status    string
 }
 
@@ -76,7 +92,26 @@ func NewBasicCheckerWithLoggerAndProgress(cfg *config.Config, logger Logger, ste
 		logger:       logger,
 		stepProgress: stepProgress,
 		results:      results,
-		warnings:     make([]string, 0),
+		warnings:     make([]advisory.Warning, 0),
+	}
+}
+
+// SetAssumeYes 设置发现警告时是否自动确认继续安装，用于无人值守执行
+func (c *BasicChecker) SetAssumeYes(assumeYes bool) {
+	c.assumeYes = assumeYes
+}
+
+// SetContinueOnError 设置某个主机检查失败时是否继续检查其余主机，结束后返回汇总所有失败主机的 ssh.HostErrors
+func (c *BasicChecker) SetContinueOnError(continueOnError bool) {
+	c.continueOnError = continueOnError
+}
+
+// SetSkipHosts 设置本次跳过检查的主机（配置未变更且上次检查通过），结果中标记为跳过
+func (c *BasicChecker) SetSkipHosts(hosts []config.Host) {
+	c.skipHosts = make(map[string]bool)
+	for _, host := range hosts {
+		c.skipHosts[host.IP] = true
+		c.results[host.IP].Status = "跳过"
 	}
 }
 
@@ -85,8 +120,15 @@ func (c *BasicChecker) Run() error {
 		c.logger.Info("正在检查系统基础环境...")
 	}
 
-	// 对每个节点进行逐个检查
+	var hosts []config.Host
 	for _, host := range c.config.Hosts {
+		if !c.skipHosts[host.IP] {
+			hosts = append(hosts, host)
+		}
+	}
+
+	// 按全局并发数并行检查各节点
+	errs := ssh.ForEachHost(hosts, func(host config.Host) error {
 		// 开始处理当前节点
 		if c.stepProgress != nil {
 			c.stepProgress.StartNodeProcessing(host.IP)
@@ -97,28 +139,104 @@ func (c *BasicChecker) Run() error {
 			if c.logger != nil {
 				c.logger.Error("节点 %s 检查失败: %v", host.IP, err)
 			}
-			return fmt.Errorf("节点 %s 检查失败: %w", host.IP, err)
+			return err
 		}
 
 		// 完成当前节点的检查
 		if c.stepProgress != nil {
 			c.stepProgress.CompleteNodeStep(host.IP)
 		}
+		return nil
+	})
+	failures := ssh.CollectHostErrors(hosts, errs)
+	if len(failures) > 0 && !c.continueOnError {
+		return fmt.Errorf("节点 %s 检查失败: %w", failures[0].Host, failures[0].Err)
 	}
 
 	// 更新所有成功的主机状态
-	for _, host := range c.config.Hosts {
+	for _, host := range hosts {
 		if c.results[host.IP].Status != "失败" {
 			c.results[host.IP].Status = "通过"
 		}
 	}
 
+	// 检查etcd拓扑，偶数个etcd节点时提示
+	c.collectClusterWarnings()
+
+	if len(failures) > 0 {
+		c.printResultsTable()
+		fmt.Printf("\n✗ %d 个主机检查失败:\n", len(failures))
+		for i, failure := range failures {
+			fmt.Printf("  %d. %s: %v\n", i+1, failure.Host, failure.Err)
+		}
+		fmt.Println()
+		return failures
+	}
+
 	if c.logger != nil {
 		c.logger.Info("所有基础系统检查都已成功完成！")
 	}
 	return c.printResultsTableAndConfirm()
 }
 
+// addWarning 记录警告，可在并行检查中调用
+func (c *BasicChecker) addWarning(category, host, message string) {
+	c.warningsMu.Lock()
+	defer c.warningsMu.Unlock()
+	c.warnings = append(c.warnings, advisory.Warning{Category: category, Host: host, Message: message})
+}
+
+// addClusterWarnings 记录不属于单个主机的警告
+func (c *BasicChecker) addClusterWarnings(category string, messages []string) {
+	for _, message := range messages {
+		c.addWarning(category, "", message)
+	}
+}
+
+// collectClusterWarnings 在各主机检查完成后记录集群拓扑、网关和etcd磁盘等集群级警告
+func (c *BasicChecker) collectClusterWarnings() {
+	c.addClusterWarnings(advisory.CategoryEtcdTopology, c.config.EtcdTopologyWarnings())
+	c.addClusterWarnings(advisory.CategoryMySQLTopology, c.config.MySQLTopologyWarnings())
+	c.addClusterWarnings(advisory.CategoryRbdRole, c.config.RbdRoleWarnings())
+	c.addClusterWarnings(advisory.CategoryGatewayIngress, c.gatewayIngressWarnings())
+	c.addClusterWarnings(advisory.CategoryControlPlaneOnly, c.controlPlaneOnlyWarnings())
+	c.addClusterWarnings(advisory.CategoryTimezone, c.timezoneWarnings())
+	for _, warning := range c.etcdDiskWarnings() {
+		c.addWarning(advisory.CategoryEtcdDisk, warning.Host, warning.Message)
+	}
+}
+
+// Warnings 返回检查中记录的所有警告
+func (c *BasicChecker) Warnings() []advisory.Warning {
+	c.warningsMu.Lock()
+	defer c.warningsMu.Unlock()
+	return advisory.SortByHosts(c.warnings, c.hostIPs())
+}
+
+// hostIPs 按配置顺序返回主机IP
+func (c *BasicChecker) hostIPs() []string {
+	ips := make([]string, 0, len(c.config.Hosts))
+	for _, host := range c.config.Hosts {
+		ips = append(ips, host.IP)
+	}
+	return ips
+}
+
+// Acknowledgement 返回用户确认警告的方式（交互确认或 --assume-yes），未确认或没有警告时为空
+func (c *BasicChecker) Acknowledgement() string {
+	return c.acknowledgement
+}
+
+// warningMessages 返回所有警告的描述
+func (c *BasicChecker) warningMessages() []string {
+	warnings := c.Warnings()
+	messages := make([]string, 0, len(warnings))
+	for _, warning := range warnings {
+		messages = append(messages, warning.Message)
+	}
+	return messages
+}
+
 // checkSingleHost 对单个主机进行所有检查
 func (c *BasicChecker) checkSingleHost(host config.Host) error {
 	checks := []struct {
@@ -133,6 +251,9 @@ func (c *BasicChecker) checkSingleHost(host config.Host) error {
 		{"CPU", c.checkSingleHostCPU},
 		{"内存", c.checkSingleHostMemory},
 		{"根分区", c.checkSingleHostRootPartition},
+		{"inode", c.checkSingleHostInodes},
+		{"时区", c.checkSingleHostTimezone},
+		{"网关端口", c.checkSingleHostGatewayPorts},
 	}
 
 	for _, check := range checks {
@@ -186,7 +307,7 @@ func (c *BasicChecker) checkOS() error {
 				strings.Contains(lower, "no route to host") ||
 				strings.Contains(lower, "connection refused") ||
 				strings.Contains(lower, "could not resolve hostname") ||
-				strings.Contains(lower, "port 22") ||
+				strings.Contains(lower, fmt.Sprintf("port %d", host.SSHPort())) ||
 				strings.Contains(lower, "unable to authenticate") {
 				return fmt.Errorf("host[%d] %s: SSH 连接失败（可能未配置免密、密码错误或未安装 sshpass）: %s", i, host.IP, strings.TrimSpace(string(output)))
 			}
@@ -281,7 +402,7 @@ func (c *BasicChecker) checkKernel() error {
 			}
 		} else {
 			warning := fmt.Sprintf("主机 %s 内核版本过低: %s (最少需要 4.x)", host.IP, kernel)
-			c.warnings = append(c.warnings, warning)
+			c.addWarning(advisory.CategoryKernel, host.IP, warning)
 			if c.logger != nil {
 				c.logger.Warn("主机 %s: 内核版本 %s 低于最低要求 (4.x)", host.IP, kernel)
 			}
@@ -364,7 +485,7 @@ func (c *BasicChecker) checkMemory() error {
 
 		if memGB < 4 {
 			warning := fmt.Sprintf("主机 %s 内存不足: %d GB (最少需要 4 GB)", host.IP, memGB)
-			c.warnings = append(c.warnings, warning)
+			c.addWarning(advisory.CategoryMemory, host.IP, warning)
 			if c.logger != nil {
 				c.logger.Warn("主机 %s 内存不足: %dGB (建议最少4GB)", host.IP, memGB)
 			}
@@ -403,7 +524,7 @@ func (c *BasicChecker) checkRootPartition() error {
 			availSpaceGB, err := strconv.Atoi(availSizeStr)
 			if err == nil && availSpaceGB < 50 {
 				warning := fmt.Sprintf("主机 %s 根分区可用空间不足: %d GB (最少需要 50 GB)", host.IP, availSpaceGB)
-				c.warnings = append(c.warnings, warning)
+				c.addWarning(advisory.CategoryRootDisk, host.IP, warning)
 				if c.logger != nil {
 					c.logger.Warn("主机 %s 根分区空间不足: %dGB 可用 (建议最少50GB)", host.IP, availSpaceGB)
 				}
@@ -450,7 +571,7 @@ func (c *BasicChecker) checkSSHConnectivity() error {
 				strings.Contains(lower, "no route to host") ||
 				strings.Contains(lower, "connection refused") ||
 				strings.Contains(lower, "could not resolve hostname") ||
-				strings.Contains(lower, "port 22") ||
+				strings.Contains(lower, fmt.Sprintf("port %d", host.SSHPort())) ||
 				strings.Contains(lower, "unable to authenticate") {
 				c.results[host.IP].Status = "失败"
 				return fmt.Errorf("host[%d] %s: SSH 连接失败（可能未配置免密、密码错误或未安装 sshpass）: %s", i, host.IP, strings.TrimSpace(string(output)))
@@ -492,7 +613,7 @@ func (c *BasicChecker) checkInterHostConnectivity() error {
 			}
 
 			// 使用SSH在源主机上执行ping命令到目标主机
-			pingCmd := fmt.Sprintf("ping -c 4 -W 3 %s", targetHost.IP)
+			pingCmd := pingCommand(targetHost.IP)
 			sshCmd := c.buildSSHCommand(sourceHost, pingCmd)
 			output, err := sshCmd.CombinedOutput()
 
@@ -517,7 +638,7 @@ func (c *BasicChecker) checkInterHostConnectivity() error {
 				for _, line := range lines {
 					if strings.Contains(line, "packet loss") && !strings.Contains(line, "0% packet loss") && !strings.Contains(line, "0.0% packet loss") {
 						warning := fmt.Sprintf("主机 %s 到 %s 有丢包: %s", sourceHost.IP, targetHost.IP, strings.TrimSpace(line))
-						c.warnings = append(c.warnings, warning)
+						c.addWarning(advisory.CategoryPacketLoss, sourceHost.IP, warning)
 						if c.logger != nil {
 							c.logger.Warn("检测到从 %s 到 %s 的丢包: %s", sourceHost.IP, targetHost.IP, strings.TrimSpace(line))
 						}
@@ -535,38 +656,34 @@ func (c *BasicChecker) checkInterHostConnectivity() error {
 	return nil
 }
 
-// buildSSHCommand 构建 SSH 命令
-func (c *BasicChecker) buildSSHCommand(host config.Host, command string) *exec.Cmd {
+// SetRunner 替换执行远程检查命令的 Runner，测试中可返回预设的 os-release、nproc、df 等输出
+func (c *BasicChecker) SetRunner(runner ssh.Runner) {
+	c.runner = runner
+}
+
+// buildSSHCommand 构建在被检查主机上执行的命令，设置了 Runner 时交由 Runner 执行
+func (c *BasicChecker) buildSSHCommand(host config.Host, command string) *ssh.Command {
+	if c.runner != nil {
+		return ssh.NewRunnerCommand(c.runner, host, command)
+	}
+	return c.sshCommand(host, command)
+}
+
+// sshCommand 构建系统ssh命令，连接失败时ssh的输出用于判断失败原因（免密、密码或网络）
+func (c *BasicChecker) sshCommand(host config.Host, command string) *ssh.Command {
 	var sshCmd *exec.Cmd
+	command = ssh.WrapBecome(host, command)
 
 	if host.Password != "" {
-		// 检查 sshpass 是否可用
-		if _, err := exec.LookPath("sshpass"); err != nil {
-			if c.logger != nil {
-				c.logger.Warn("未找到 sshpass。请为主机 %s 安装 sshpass 或使用 SSH 密钥认证", host.IP)
-			}
-			if c.logger != nil {
-				c.logger.Warn("安装 sshpass: 'brew install hudochenkov/sshpass/sshpass' (macOS) 或 'apt-get install sshpass' (Ubuntu)")
-			}
-			// 尝试使用 expect 或提示用户
-			sshCmd = exec.Command("ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "BatchMode=yes",
-				"-o", "LogLevel=ERROR",
-				"-o", "ConnectTimeout=5",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		} else {
-			// 使用密码登录 (需要 sshpass)
-			sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "LogLevel=ERROR",
-				"-o", "ConnectTimeout=5",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		}
+		// 使用密码登录 (sshpass 已在启动时检查)
+		sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", "LogLevel=ERROR",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
+			fmt.Sprintf("%s@%s", host.User, host.IP),
+			command)
 	} else if host.SSHKey != "" {
 		// 使用 SSH 密钥登录
 		sshCmd = exec.Command("ssh",
@@ -575,7 +692,8 @@ func (c *BasicChecker) buildSSHCommand(host config.Host, command string) *exec.C
 			"-o", "UserKnownHostsFile=/dev/null",
 			"-o", "BatchMode=yes",
 			"-o", "LogLevel=ERROR",
-			"-o", "ConnectTimeout=5",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	} else {
@@ -585,16 +703,60 @@ func (c *BasicChecker) buildSSHCommand(host config.Host, command string) *exec.C
 			"-o", "UserKnownHostsFile=/dev/null",
 			"-o", "BatchMode=yes",
 			"-o", "LogLevel=ERROR",
-			"-o", "ConnectTimeout=5",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	}
 
-	return sshCmd
+	return ssh.NewCommand(host, command, sshCmd)
 }
 
 // printResultsTableAndConfirm 打印基础检测结果表格并确认是否继续
 func (c *BasicChecker) printResultsTableAndConfirm() error {
+	c.printResultsTable()
+
+	// 显示警告信息并询问用户是否继续
+	if len(c.warnings) > 0 {
+		fmt.Printf("\n⚠️  发现以下问题:\n")
+		for i, warning := range c.warningMessages() {
+			fmt.Printf("  %d. %s\n", i+1, warning)
+		}
+		fmt.Printf("\n这些问题可能导致安装失败或运行不稳定。\n")
+		if c.assumeYes {
+			fmt.Printf("已指定 --assume-yes，忽略以上问题继续安装...\n\n")
+			c.acknowledgement = "--assume-yes"
+			if c.logger != nil {
+				c.logger.Warn("已指定 --assume-yes，忽略 %d 个检查警告继续安装", len(c.warnings))
+			}
+			return nil
+		}
+		fmt.Printf("是否继续安装? (y/N): ")
+
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return fmt.Errorf("标准输入已关闭（如通过 --config - 读取配置），无法交互确认，请使用 --assume-yes")
+		}
+		if err != nil {
+			return fmt.Errorf("无法读取用户输入: %w", err)
+		}
+
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			return fmt.Errorf("用户取消安装")
+		}
+
+		fmt.Printf("继续安装...\n")
+		c.acknowledgement = "交互确认"
+	}
+
+	fmt.Println()
+	return nil
+}
+
+// printResultsTable 打印基础检测结果表格
+func (c *BasicChecker) printResultsTable() {
 	if c.logger != nil {
 		c.logger.Info("\n" + strings.Repeat("=", 80))
 		c.logger.Info("                    基础系统检查结果")
@@ -604,11 +766,14 @@ func (c *BasicChecker) printResultsTableAndConfirm() error {
 	// 统计信息
 	passed := 0
 	failed := 0
+	skipped := 0
 	for _, result := range c.results {
 		if result.Status == "通过" {
 			passed++
 		} else if result.Status == "失败" {
 			failed++
+		} else if result.Status == "跳过" {
+			skipped++
 		}
 	}
 
@@ -653,6 +818,9 @@ func (c *BasicChecker) printResultsTableAndConfirm() error {
 			statusIcon = "✗"
 		} else if result.Status == "检查中..." {
 			statusIcon = "⏳"
+		} else if result.Status == "跳过" {
+			statusIcon = "⏭"
+			statusStr = "跳过（配置未变更，上次检查已通过）"
 		}
 
 		// 打印主机信息块
@@ -666,6 +834,9 @@ func (c *BasicChecker) printResultsTableAndConfirm() error {
 			c.logger.Info("│  CPU         : %s", cpuStr)
 			c.logger.Info("│  内存        : %s", memStr)
 			c.logger.Info("│  根分区      : %s", rootInfo)
+			c.logger.Info("│  空闲inode   : %s", valueOrUnknown(result.Inodes))
+			c.logger.Info("│  时区        : %s", valueOrUnknown(result.Timezone))
+			c.logger.Info("│  语言环境    : %s", valueOrUnknown(result.Locale))
 			c.logger.Info("└" + strings.Repeat("─", 50))
 		}
 	}
@@ -673,34 +844,11 @@ func (c *BasicChecker) printResultsTableAndConfirm() error {
 	if c.logger != nil {
 		c.logger.Info("\n" + strings.Repeat("=", 80))
 		c.logger.Info("检查总结: %d 个主机通过检查, %d 个主机检查失败", passed, failed)
-		c.logger.Info(strings.Repeat("=", 80))
-	}
-
-	// 显示警告信息并询问用户是否继续
-	if len(c.warnings) > 0 {
-		fmt.Printf("\n⚠️  发现以下问题:\n")
-		for i, warning := range c.warnings {
-			fmt.Printf("  %d. %s\n", i+1, warning)
-		}
-		fmt.Printf("\n这些问题可能导致安装失败或运行不稳定。\n")
-		fmt.Printf("是否继续安装? (y/N): ")
-
-		reader := bufio.NewReader(os.Stdin)
-		response, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("无法读取用户输入: %w", err)
+		if skipped > 0 {
+			c.logger.Info("另有 %d 个主机配置未变更且上次检查已通过，本次跳过（使用 --force 重新检查全部主机）", skipped)
 		}
-
-		response = strings.TrimSpace(strings.ToLower(response))
-		if response != "y" && response != "yes" {
-			return fmt.Errorf("用户取消安装")
-		}
-
-		fmt.Printf("继续安装...\n")
+		c.logger.Info(strings.Repeat("=", 80))
 	}
-
-	fmt.Println()
-	return nil
 }
 
 // checkSingleHostConnectivity 检查单个主机连通性
@@ -731,7 +879,7 @@ func (c *BasicChecker) checkSingleHostSSH(host config.Host) error {
 			strings.Contains(lower, "no route to host") ||
 			strings.Contains(lower, "connection refused") ||
 			strings.Contains(lower, "could not resolve hostname") ||
-			strings.Contains(lower, "port 22") ||
+			strings.Contains(lower, fmt.Sprintf("port %d", host.SSHPort())) ||
 			strings.Contains(lower, "unable to authenticate") {
 			c.results[host.IP].Status = "失败"
 			return fmt.Errorf("SSH 连接失败（可能未配置免密、密码错误或未安装 sshpass）: %s", strings.TrimSpace(string(output)))
@@ -762,7 +910,7 @@ func (c *BasicChecker) checkSingleHostOS(host config.Host) error {
 			strings.Contains(lower, "no route to host") ||
 			strings.Contains(lower, "connection refused") ||
 			strings.Contains(lower, "could not resolve hostname") ||
-			strings.Contains(lower, "port 22") ||
+			strings.Contains(lower, fmt.Sprintf("port %d", host.SSHPort())) ||
 			strings.Contains(lower, "unable to authenticate") {
 			return fmt.Errorf("SSH 连接失败（可能未配置免密、密码错误或未安装 sshpass）: %s", strings.TrimSpace(string(output)))
 		}
@@ -844,7 +992,7 @@ func (c *BasicChecker) checkSingleHostKernel(host config.Host) error {
 		}
 	} else {
 		warning := fmt.Sprintf("主机 %s 内核版本过低: %s (最少需要 4.x)", host.IP, kernel)
-		c.warnings = append(c.warnings, warning)
+		c.addWarning(advisory.CategoryKernel, host.IP, warning)
 		if c.logger != nil {
 			c.logger.Warn("主机 %s: 内核版本 %s 低于最低要求 (4.x)", host.IP, kernel)
 		}
@@ -903,7 +1051,7 @@ func (c *BasicChecker) checkSingleHostMemory(host config.Host) error {
 
 	if memGB < 4 {
 		warning := fmt.Sprintf("主机 %s 内存不足: %d GB (最少需要 4 GB)", host.IP, memGB)
-		c.warnings = append(c.warnings, warning)
+		c.addWarning(advisory.CategoryMemory, host.IP, warning)
 		if c.logger != nil {
 			c.logger.Warn("主机 %s 内存不足: %dGB (建议最少4GB)", host.IP, memGB)
 		}
@@ -936,7 +1084,7 @@ func (c *BasicChecker) checkSingleHostRootPartition(host config.Host) error {
 		availSpaceGB, err := strconv.Atoi(availSizeStr)
 		if err == nil && availSpaceGB < 50 {
 			warning := fmt.Sprintf("主机 %s 根分区可用空间不足: %d GB (最少需要 50 GB)", host.IP, availSpaceGB)
-			c.warnings = append(c.warnings, warning)
+			c.addWarning(advisory.CategoryRootDisk, host.IP, warning)
 			if c.logger != nil {
 				c.logger.Warn("主机 %s 根分区空间不足: %dGB 可用 (建议最少50GB)", host.IP, availSpaceGB)
 			}
@@ -952,6 +1100,14 @@ func (c *BasicChecker) checkSingleHostRootPartition(host config.Host) error {
 }
 
 // checkInterHostConnectivityForHost 检查从特定主机到其他主机的连通性
+// pingCommand 返回ping目标主机的命令，IPv6地址使用 ping -6
+func pingCommand(ip string) string {
+	if config.IsIPv6(ip) {
+		return fmt.Sprintf("ping -6 -c 4 -W 3 %s", ip)
+	}
+	return fmt.Sprintf("ping -c 4 -W 3 %s", ip)
+}
+
 func (c *BasicChecker) checkInterHostConnectivityForHost(sourceHost config.Host) error {
 	if c.logger != nil {
 		c.logger.Debug("检查从主机 %s 的主机间连通性...", sourceHost.IP)
@@ -974,7 +1130,7 @@ func (c *BasicChecker) checkInterHostConnectivityForHost(sourceHost config.Host)
 		}
 
 		// 使用SSH在源主机上执行ping命令到目标主机
-		pingCmd := fmt.Sprintf("ping -c 4 -W 3 %s", targetHost.IP)
+		pingCmd := pingCommand(targetHost.IP)
 		sshCmd := c.buildSSHCommand(sourceHost, pingCmd)
 		output, err := sshCmd.CombinedOutput()
 
@@ -996,7 +1152,7 @@ func (c *BasicChecker) checkInterHostConnectivityForHost(sourceHost config.Host)
 			for _, line := range lines {
 				if strings.Contains(line, "packet loss") && !strings.Contains(line, "0% packet loss") && !strings.Contains(line, "0.0% packet loss") {
 					warning := fmt.Sprintf("主机 %s 到 %s 有丢包: %s", sourceHost.IP, targetHost.IP, strings.TrimSpace(line))
-					c.warnings = append(c.warnings, warning)
+					c.addWarning(advisory.CategoryPacketLoss, sourceHost.IP, warning)
 					if c.logger != nil {
 						c.logger.Warn("检测到从 %s 到 %s 的丢包: %s", sourceHost.IP, targetHost.IP, strings.TrimSpace(line))
 					}
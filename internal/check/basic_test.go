@@ -0,0 +1,217 @@
+package check
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// remoteOutput 假 Runner 对一条远程命令的输出和退出码
+type remoteOutput struct {
+	stdout string
+	code   int
+}
+
+// commandRunner 按完整命令返回固定输出，未列出的命令以退出码127结束
+type commandRunner map[string]remoteOutput
+
+func (r commandRunner) Run(ctx context.Context, host config.Host, command string) (string, string, int, error) {
+	output, ok := r[command]
+	if !ok {
+		return "", "command not found", 127, nil
+	}
+	return output.stdout, "", output.code, nil
+}
+
+func TestCheckSingleHostParsesRemoteOutput(t *testing.T) {
+	const (
+		osRelease = "cat /etc/os-release"
+		uname     = "uname -m"
+		kernel    = "uname -r"
+		nproc     = "nproc"
+		memory    = "free -m | grep '^Mem:' | awk '{print $2}'"
+		rootDisk  = "df -BG / | tail -1"
+	)
+	checkOS := (*BasicChecker).checkSingleHostOS
+	checkArch := (*BasicChecker).checkSingleHostArch
+	checkKernel := (*BasicChecker).checkSingleHostKernel
+	checkCPU := (*BasicChecker).checkSingleHostCPU
+	checkMemory := (*BasicChecker).checkSingleHostMemory
+	checkRoot := (*BasicChecker).checkSingleHostRootPartition
+
+	tests := []struct {
+		name     string
+		check    func(*BasicChecker, config.Host) error
+		command  string
+		output   remoteOutput
+		field    func(*BasicCheckResult) string
+		want     string
+		wantErr  bool
+		failed   bool // 结果状态被标记为"失败"
+		warnings int
+	}{
+		{
+			name:    "ubuntu",
+			check:   checkOS,
+			command: osRelease,
+			output:  remoteOutput{stdout: "NAME=\"Ubuntu\"\nVERSION_ID=\"22.04\"\nID=ubuntu\n"},
+			field:   func(r *BasicCheckResult) string { return r.OS },
+			want:    "ubuntu",
+		},
+		{
+			name:    "openeuler",
+			check:   checkOS,
+			command: osRelease,
+			output:  remoteOutput{stdout: "NAME=\"openEuler\"\nVERSION=\"22.03 (LTS-SP3)\"\nID=\"openEuler\"\n"},
+			field:   func(r *BasicCheckResult) string { return r.OS },
+			want:    "openeuler",
+		},
+		{
+			name:    "unsupported distribution",
+			check:   checkOS,
+			command: osRelease,
+			output:  remoteOutput{stdout: "NAME=\"Debian GNU/Linux\"\nID=debian\n"},
+			field:   func(r *BasicCheckResult) string { return r.OS },
+			want:    "未知",
+			wantErr: true,
+			failed:  true,
+		},
+		{
+			name:    "os-release unreadable",
+			check:   checkOS,
+			command: osRelease,
+			output:  remoteOutput{stdout: "cat: /etc/os-release: No such file or directory", code: 1},
+			field:   func(r *BasicCheckResult) string { return r.OS },
+			want:    "未知",
+			wantErr: true,
+		},
+		{
+			name:    "x86_64",
+			check:   checkArch,
+			command: uname,
+			output:  remoteOutput{stdout: "x86_64\n"},
+			field:   func(r *BasicCheckResult) string { return r.Arch },
+			want:    "x86_64",
+		},
+		{
+			name:    "aarch64",
+			check:   checkArch,
+			command: uname,
+			output:  remoteOutput{stdout: "aarch64\n"},
+			field:   func(r *BasicCheckResult) string { return r.Arch },
+			want:    "未知",
+			wantErr: true,
+			failed:  true,
+		},
+		{
+			name:    "supported kernel",
+			check:   checkKernel,
+			command: kernel,
+			output:  remoteOutput{stdout: "5.15.0-91-generic\n"},
+			field:   func(r *BasicCheckResult) string { return r.Kernel },
+			want:    "5.15.0-91-generic",
+		},
+		{
+			name:     "old kernel",
+			check:    checkKernel,
+			command:  kernel,
+			output:   remoteOutput{stdout: "3.10.0-1160.el7.x86_64\n"},
+			field:    func(r *BasicCheckResult) string { return r.Kernel },
+			want:     "3.10.0-1160.el7.x86_64",
+			warnings: 1,
+		},
+		{
+			name:    "cpu cores",
+			check:   checkCPU,
+			command: nproc,
+			output:  remoteOutput{stdout: "8\n"},
+			field:   func(r *BasicCheckResult) string { return strconv.Itoa(r.CPUCores) },
+			want:    "8",
+		},
+		{
+			name:    "single cpu",
+			check:   checkCPU,
+			command: nproc,
+			output:  remoteOutput{stdout: "1\n"},
+			field:   func(r *BasicCheckResult) string { return strconv.Itoa(r.CPUCores) },
+			want:    "0",
+			wantErr: true,
+			failed:  true,
+		},
+		{
+			name:    "unparsable cpu count",
+			check:   checkCPU,
+			command: nproc,
+			output:  remoteOutput{stdout: "nproc: command not found\n"},
+			field:   func(r *BasicCheckResult) string { return strconv.Itoa(r.CPUCores) },
+			want:    "0",
+			wantErr: true,
+		},
+		{
+			name:    "memory",
+			check:   checkMemory,
+			command: memory,
+			output:  remoteOutput{stdout: "15884\n"},
+			field:   func(r *BasicCheckResult) string { return strconv.Itoa(r.MemoryGB) },
+			want:    "15",
+		},
+		{
+			name:     "low memory",
+			check:    checkMemory,
+			command:  memory,
+			output:   remoteOutput{stdout: "3072\n"},
+			field:    func(r *BasicCheckResult) string { return strconv.Itoa(r.MemoryGB) },
+			want:     "3",
+			warnings: 1,
+		},
+		{
+			name:    "root partition",
+			check:   checkRoot,
+			command: rootDisk,
+			output:  remoteOutput{stdout: "/dev/mapper/rl-root  200G   37G  164G  19% /\n"},
+			field:   func(r *BasicCheckResult) string { return r.RootSpace + " " + r.RootUsage },
+			want:    "164G/200G 19%",
+		},
+		{
+			name:     "small root partition",
+			check:    checkRoot,
+			command:  rootDisk,
+			output:   remoteOutput{stdout: "/dev/sda1  40G  12G  28G  30% /\n"},
+			field:    func(r *BasicCheckResult) string { return r.RootSpace + " " + r.RootUsage },
+			want:     "28G/40G 30%",
+			warnings: 1,
+		},
+		{
+			name:    "truncated df output",
+			check:   checkRoot,
+			command: rootDisk,
+			output:  remoteOutput{stdout: "/dev/sda1 40G\n"},
+			field:   func(r *BasicCheckResult) string { return r.RootSpace + " " + r.RootUsage },
+			want:    "未知 未知",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := config.Host{IP: "10.0.0.1", Role: []string{"master"}}
+			checker := NewBasicChecker(&config.Config{Hosts: []config.Host{host}})
+			checker.SetRunner(commandRunner{tt.command: tt.output})
+
+			err := tt.check(checker, host)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			result := checker.results[host.IP]
+			if got := tt.field(result); got != tt.want {
+				t.Errorf("parsed result = %q, want %q", got, tt.want)
+			}
+			if failed := result.Status == "失败"; failed != tt.failed {
+				t.Errorf("Status = %q, want failed %v", result.Status, tt.failed)
+			}
+			if got := len(checker.Warnings()); got != tt.warnings {
+				t.Errorf("got %d warnings, want %d: %v", got, tt.warnings, checker.Warnings())
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// etcdFsyncThreshold etcd官方建议的WAL fdatasync 99分位延迟上限
+const etcdFsyncThreshold = 10 * time.Millisecond
+
+// ddProbeCount dd回退方案的同步写入次数
+const ddProbeCount = 500
+
+// etcdProbeDirs 探测目录，优先使用RKE2数据目录下etcd数据所在的文件系统，安装前目录不存在时依次回退到上级目录
+func etcdProbeDirs(dataDir string) string {
+	return strings.Join([]string{path.Join(dataDir, "server"), dataDir, path.Dir(dataDir), path.Dir(path.Dir(dataDir))}, " ")
+}
+
+// etcdDiskProbeScript 在目标目录上测量fdatasync延迟
+// 优先使用fio（与etcd官方建议的测试参数一致，输出99分位延迟），否则使用dd的dsync写入估算平均延迟
+const etcdDiskProbeScript = `
+	dir=""
+	for d in %s; do
+		if [ -d "$d" ]; then dir="$d"; break; fi
+	done
+	[ -n "$dir" ] || { echo "none"; exit 0; }
+	probe="$dir/.roi-fsync-probe"
+	if command -v fio >/dev/null 2>&1; then
+		echo "fio $dir"
+		fio --name=roi-fsync-probe --filename="$probe" --rw=write --ioengine=sync --fdatasync=1 --size=22m --bs=2300 --output-format=json 2>/dev/null
+		rm -f "$probe"
+	elif command -v dd >/dev/null 2>&1; then
+		echo "dd $dir"
+		LC_ALL=C dd if=/dev/zero of="$probe" bs=2300 count=%d oflag=dsync 2>&1 | tail -1
+		rm -f "$probe"
+	else
+		echo "none"
+	fi
+`
+
+// ddElapsedPattern 解析dd输出中的耗时，如 "1150000 bytes (1.2 MB, 1.1 MiB) copied, 2.345 s, 490 kB/s"
+var ddElapsedPattern = regexp.MustCompile(`copied, ([0-9.]+) s`)
+
+// SetDiskProbe 设置是否在etcd节点上执行磁盘fsync延迟检测
+func (c *BasicChecker) SetDiskProbe(probe bool) {
+	c.diskProbe = probe
+}
+
+// etcdDiskWarnings 在etcd节点上测量磁盘fsync延迟，超过etcd建议阈值时给出警告
+// 磁盘延迟过高会导致etcd心跳超时、频繁选主，是安装后集群不稳定的常见原因
+func (c *BasicChecker) etcdDiskWarnings() []advisory.Warning {
+	if !c.diskProbe {
+		return nil
+	}
+
+	hosts := c.config.GetEtcdMemberHosts()
+	var mu sync.Mutex
+	byHost := make(map[string]string)
+	ssh.ForEachHost(hosts, func(host config.Host) error {
+		warning, err := c.probeEtcdDisk(host)
+		if err != nil {
+			warning = fmt.Sprintf("节点 %s 磁盘fsync延迟检测失败，已跳过: %v", host.IP, err)
+		}
+		mu.Lock()
+		byHost[host.IP] = warning
+		mu.Unlock()
+		return nil
+	})
+
+	// 按主机顺序输出，结果不受并行执行顺序影响
+	var warnings []advisory.Warning
+	for _, host := range hosts {
+		if warning := byHost[host.IP]; warning != "" {
+			warnings = append(warnings, advisory.Warning{Category: advisory.CategoryEtcdDisk, Host: host.IP, Message: warning})
+		}
+	}
+	return warnings
+}
+
+// probeEtcdDisk 对单个节点执行fsync延迟检测，延迟正常时返回空字符串
+func (c *BasicChecker) probeEtcdDisk(host config.Host) (string, error) {
+	if c.logger != nil {
+		c.logger.Info("主机 %s: 检测etcd磁盘fsync延迟...", host.IP)
+	}
+
+	output, err := c.buildSSHCommand(host, fmt.Sprintf(etcdDiskProbeScript, etcdProbeDirs(c.config.RKE2DataDir()), ddProbeCount)).Output()
+	if err != nil {
+		return "", err
+	}
+
+	header, body, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	fields := strings.Fields(header)
+	if len(fields) == 0 || fields[0] == "none" {
+		return fmt.Sprintf("节点 %s 未找到fio或dd，跳过etcd磁盘fsync延迟检测", host.IP), nil
+	}
+	tool, dir := fields[0], ""
+	if len(fields) > 1 {
+		dir = fields[1]
+	}
+
+	var latency time.Duration
+	var metric string
+	switch tool {
+	case "fio":
+		latency, err = parseFioFsyncP99(body)
+		metric = "fdatasync 99分位延迟"
+	case "dd":
+		latency, err = parseDdSyncLatency(body, ddProbeCount)
+		metric = "dsync写入平均延迟"
+	default:
+		err = fmt.Errorf("无法识别的检测输出: %s", header)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if c.logger != nil {
+		c.logger.Info("主机 %s: %s 上的%s为 %s（%s）", host.IP, dir, metric, latency, tool)
+	}
+	if latency > etcdFsyncThreshold {
+		return fmt.Sprintf("节点 %s 的磁盘%s为 %s（%s 测量 %s），超过etcd建议的 %s，可能导致etcd不稳定，建议为etcd使用SSD或低延迟磁盘",
+			host.IP, metric, latency.Round(time.Microsecond), tool, dir, etcdFsyncThreshold), nil
+	}
+	return "", nil
+}
+
+// parseFioFsyncP99 从fio的JSON输出中解析fdatasync延迟的99分位值
+func parseFioFsyncP99(output string) (time.Duration, error) {
+	var result struct {
+		Jobs []struct {
+			Sync struct {
+				LatNs struct {
+					Percentile map[string]float64 `json:"percentile"`
+				} `json:"lat_ns"`
+			} `json:"sync"`
+		} `json:"jobs"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0, fmt.Errorf("解析fio输出失败: %w", err)
+	}
+	if len(result.Jobs) == 0 {
+		return 0, fmt.Errorf("fio输出中没有测试结果")
+	}
+	p99, ok := result.Jobs[0].Sync.LatNs.Percentile["99.000000"]
+	if !ok {
+		return 0, fmt.Errorf("fio输出中没有fdatasync延迟统计，fio版本可能过旧")
+	}
+	return time.Duration(p99), nil
+}
+
+// parseDdSyncLatency 根据dd总耗时计算每次同步写入的平均延迟
+func parseDdSyncLatency(output string, count int) (time.Duration, error) {
+	matches := ddElapsedPattern.FindStringSubmatch(output)
+	if matches == nil {
+		return 0, fmt.Errorf("无法解析dd输出: %s", strings.TrimSpace(output))
+	}
+	seconds, err := strconv.ParseFloat(matches[1], 64)
+	if err != nil {
+		return 0, fmt.Errorf("无法解析dd耗时 %s: %w", matches[1], err)
+	}
+	return time.Duration(seconds / float64(count) * float64(time.Second)), nil
+}
@@ -0,0 +1,43 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+)
+
+// gatewayIngressWarnings 检查网关入口IP是否绑定在rbd-gateway节点上
+// 公网NAT地址或尚未漂移到节点上的VIP不会出现在网卡上，因此只作为警告提示
+func (c *BasicChecker) gatewayIngressWarnings() []string {
+	ingressIPs := c.config.GetGatewayIngressIPs()
+	gatewayHosts := c.config.GetRbdGatewayHosts()
+	if len(ingressIPs) == 0 || len(gatewayHosts) == 0 {
+		return nil
+	}
+
+	// 收集每个gateway节点上绑定的所有地址
+	bound := make(map[string][]string)
+	for _, host := range gatewayHosts {
+		output, err := c.buildSSHCommand(host, "ip -o addr show | awk '{print $4}' | cut -d/ -f1").Output()
+		if err != nil {
+			if c.logger != nil {
+				c.logger.Debug("主机 %s: 获取网卡地址失败: %v", host.IP, err)
+			}
+			continue
+		}
+		for _, addr := range strings.Fields(string(output)) {
+			bound[addr] = append(bound[addr], host.IP)
+		}
+	}
+
+	var warnings []string
+	for _, ip := range ingressIPs {
+		if nodes, ok := bound[ip]; ok {
+			if c.logger != nil {
+				c.logger.Debug("网关入口IP %s 绑定在节点 %s 上", ip, strings.Join(nodes, ", "))
+			}
+			continue
+		}
+		warnings = append(warnings, fmt.Sprintf("网关入口IP %s 未绑定在任何 rbd-gateway 节点上，如为公网NAT地址或keepalived VIP请确认流量可转发到网关节点", ip))
+	}
+	return warnings
+}
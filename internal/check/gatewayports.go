@@ -0,0 +1,141 @@
+package check
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// listenersCmd 列出所有TCP监听端口及进程，没有ss时使用netstat
+const listenersCmd = "ss -Hltnp 2>/dev/null || netstat -ltnp 2>/dev/null | tail -n +3"
+
+var (
+	// ssProcessPattern ss输出中的进程信息：users:(("nginx",pid=1234,fd=6))
+	ssProcessPattern = regexp.MustCompile(`\("([^"]+)",pid=(\d+)`)
+	// netstatProcessPattern netstat输出中的进程信息：1234/nginx
+	netstatProcessPattern = regexp.MustCompile(`^(\d+)/(.+)$`)
+)
+
+// portListener 占用端口的监听进程
+type portListener struct {
+	Port    int
+	Process string
+	PID     string
+}
+
+// parseListeners 解析 ss -Hltnp 或 netstat -ltnp 的输出，返回监听在指定端口上的进程
+func parseListeners(output string, ports []int) []portListener {
+	wanted := make(map[int]bool, len(ports))
+	for _, port := range ports {
+		wanted[port] = true
+	}
+
+	var listeners []portListener
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		// ss: State Recv-Q Send-Q Local Peer Process；netstat: Proto Recv-Q Send-Q Local Foreign State PID/Program
+		local := fields[3]
+		idx := strings.LastIndex(local, ":")
+		if idx < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(local[idx+1:])
+		if err != nil || !wanted[port] {
+			continue
+		}
+
+		listener := portListener{Port: port, Process: "未知进程"}
+		last := fields[len(fields)-1]
+		if m := ssProcessPattern.FindStringSubmatch(line); m != nil {
+			listener.Process, listener.PID = m[1], m[2]
+		} else if m := netstatProcessPattern.FindStringSubmatch(last); m != nil {
+			listener.PID, listener.Process = m[1], m[2]
+		}
+
+		key := fmt.Sprintf("%d/%s", port, listener.PID)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		listeners = append(listeners, listener)
+	}
+	return listeners
+}
+
+// podPIDsScript 输出属于Kubernetes Pod的进程PID，重复安装时网关自身占用的端口不视为冲突
+func podPIDsScript(pids []string) string {
+	return fmt.Sprintf(`for p in %s; do grep -q kubepods /proc/$p/cgroup 2>/dev/null && echo $p; done; true`, strings.Join(pids, " "))
+}
+
+// checkSingleHostGatewayPorts 检查rbd-gateway节点上网关需要绑定的端口是否已被其他进程占用
+// 端口被占用时网关在安装完成后才会因无法绑定端口而反复重启，因此在安装前失败
+func (c *BasicChecker) checkSingleHostGatewayPorts(host config.Host) error {
+	if !hasRbdRole(host, "rbd-gateway") {
+		return nil
+	}
+
+	ports := c.config.Check.GatewayPortList()
+	output, err := c.buildSSHCommand(host, listenersCmd).Output()
+	if err != nil {
+		if c.logger != nil {
+			c.logger.Debug("主机 %s: 获取监听端口失败: %v", host.IP, err)
+		}
+		return nil
+	}
+	listeners := parseListeners(string(output), ports)
+	if len(listeners) == 0 {
+		return nil
+	}
+
+	// 排除Kubernetes Pod中的进程（如已部署的rbd-gateway）
+	podPIDs := make(map[string]bool)
+	var pids []string
+	for _, listener := range listeners {
+		if listener.PID != "" {
+			pids = append(pids, listener.PID)
+		}
+	}
+	if len(pids) > 0 {
+		if output, err := c.buildSSHCommand(host, podPIDsScript(pids)).Output(); err == nil {
+			for _, pid := range strings.Fields(string(output)) {
+				podPIDs[pid] = true
+			}
+		}
+	}
+
+	var conflicts []string
+	for _, listener := range listeners {
+		if podPIDs[listener.PID] {
+			if c.logger != nil {
+				c.logger.Debug("主机 %s: 端口 %d 由Pod进程 %s (pid %s) 监听，视为已部署的网关", host.IP, listener.Port, listener.Process, listener.PID)
+			}
+			continue
+		}
+		if listener.PID != "" {
+			conflicts = append(conflicts, fmt.Sprintf("%d (%s, pid %s)", listener.Port, listener.Process, listener.PID))
+		} else {
+			conflicts = append(conflicts, fmt.Sprintf("%d (%s)", listener.Port, listener.Process))
+		}
+	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("rbd-gateway节点的网关端口已被占用: %s，请停止占用端口的服务或调整 check.gateway_ports 与chart配置", strings.Join(conflicts, ", "))
+	}
+	return nil
+}
+
+// hasRbdRole 检查主机是否配置了指定的Rainbond角色
+func hasRbdRole(host config.Host, role string) bool {
+	for _, r := range host.RbdRole {
+		if strings.TrimSpace(strings.ToLower(r)) == role {
+			return true
+		}
+	}
+	return false
+}
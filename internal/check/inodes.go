@@ -0,0 +1,95 @@
+package check
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// inodeUsage 一个文件系统的inode使用情况
+type inodeUsage struct {
+	Mount string
+	Total int64
+	Free  int64
+}
+
+// freePercent 空闲inode比例
+func (u inodeUsage) freePercent() int {
+	return int(u.Free * 100 / u.Total)
+}
+
+// inodePaths 需要检查inode的路径：根分区、RKE2数据目录、containerd数据目录和容器存储逻辑卷挂载点
+func (c *BasicChecker) inodePaths(host config.Host) []string {
+	paths := []string{"/", c.config.RKE2DataDir(), c.config.RKE2ContainerdPath()}
+	if lv := c.config.ContainerdLV(host); lv != nil {
+		paths = append(paths, config.LVMountPoint(*lv))
+	}
+	return paths
+}
+
+// inodeScript 对每个路径在其最近的已存在上级目录上执行 df -i，安装前数据目录可能尚未创建
+func inodeScript(paths []string) string {
+	return fmt.Sprintf(`for p in %s; do
+	d=$p
+	while [ ! -e "$d" ]; do d=$(dirname "$d"); done
+	df -iP "$d" | tail -1
+done`, strings.Join(paths, " "))
+}
+
+// parseInodeUsage 解析 df -iP 输出，按挂载点去重；不报告inode数量的文件系统（如btrfs）被忽略
+func parseInodeUsage(output string) []inodeUsage {
+	var usages []inodeUsage
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+		total, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || total == 0 {
+			continue
+		}
+		free, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		mount := fields[5]
+		if seen[mount] {
+			continue
+		}
+		seen[mount] = true
+		usages = append(usages, inodeUsage{Mount: mount, Total: total, Free: free})
+	}
+	return usages
+}
+
+// checkSingleHostInodes 检查根分区和容器存储所在文件系统的空闲inode，低于阈值时警告
+// 大量小文件的镜像层会耗尽inode，此时即使磁盘空间充足也无法写入
+func (c *BasicChecker) checkSingleHostInodes(host config.Host) error {
+	output, err := c.buildSSHCommand(host, inodeScript(c.inodePaths(host))).Output()
+	if err != nil {
+		if c.logger != nil {
+			c.logger.Debug("主机 %s: 获取inode使用情况失败: %v", host.IP, err)
+		}
+		return nil
+	}
+
+	threshold := c.config.Check.MinFreeInodesPercent()
+	var facts []string
+	for _, usage := range parseInodeUsage(string(output)) {
+		percent := usage.freePercent()
+		facts = append(facts, fmt.Sprintf("%s 空闲 %d%%", usage.Mount, percent))
+		if percent < threshold {
+			warning := fmt.Sprintf("主机 %s 文件系统 %s 空闲inode不足: %d/%d (%d%%，最少需要 %d%%)", host.IP, usage.Mount, usage.Free, usage.Total, percent, threshold)
+			c.addWarning(advisory.CategoryInodes, host.IP, warning)
+			if c.logger != nil {
+				c.logger.Warn("%s", warning)
+			}
+		}
+	}
+	c.results[host.IP].Inodes = strings.Join(facts, ", ")
+	return nil
+}
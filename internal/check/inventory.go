@@ -0,0 +1,64 @@
+package check
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// factCheck 清单导出时收集的单项系统信息
+type factCheck struct {
+	name string
+	fn   func(config.Host) error
+}
+
+// CollectFacts 只读收集各主机的系统信息（操作系统、架构、内核、CPU、内存、根分区），用于清单导出
+// 与 RunCheckOnly 不同，单项不满足安装要求时继续收集其余信息，返回每个主机的结果以及未能收集或不满足要求的项
+func (c *BasicChecker) CollectFacts() ([]*BasicCheckResult, map[string][]string) {
+	var mu sync.Mutex
+	problems := make(map[string][]string)
+
+	ssh.ForEachHost(c.config.Hosts, func(host config.Host) error {
+		hostProblems := c.collectHostFacts(host)
+		mu.Lock()
+		problems[host.IP] = hostProblems
+		mu.Unlock()
+		return nil
+	})
+
+	hosts := make([]*BasicCheckResult, 0, len(c.config.Hosts))
+	for _, host := range c.config.Hosts {
+		if len(problems[host.IP]) == 0 {
+			c.results[host.IP].Status = "通过"
+			delete(problems, host.IP)
+		}
+		hosts = append(hosts, c.results[host.IP])
+	}
+	return hosts, problems
+}
+
+// collectHostFacts SSH不可用时直接返回，其余各项独立收集
+func (c *BasicChecker) collectHostFacts(host config.Host) []string {
+	if err := c.checkSingleHostSSH(host); err != nil {
+		return []string{fmt.Sprintf("SSH连接: %v", err)}
+	}
+
+	facts := []factCheck{
+		{"操作系统", c.checkSingleHostOS},
+		{"系统架构", c.checkSingleHostArch},
+		{"内核版本", c.checkSingleHostKernel},
+		{"CPU", c.checkSingleHostCPU},
+		{"内存", c.checkSingleHostMemory},
+		{"根分区", c.checkSingleHostRootPartition},
+	}
+
+	var problems []string
+	for _, fact := range facts {
+		if err := fact.fn(host); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", fact.name, err))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,95 @@
+package check
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// PrivilegeResult 主机提权检查结果
+type PrivilegeResult struct {
+	IP      string
+	User    string
+	IsRoot  bool
+	CanSudo bool // 是否支持免密 sudo
+	Error   string
+}
+
+// CheckPrivileges 检查每个主机的SSH用户能否以root身份执行命令
+// 在任何变更操作之前执行，一次性报告所有缺少权限的主机
+func (c *BasicChecker) CheckPrivileges() error {
+	if c.logger != nil {
+		c.logger.Info("检查各主机的root权限...")
+	}
+
+	if failed := c.privilegeFailures(); len(failed) > 0 {
+		return fmt.Errorf("以下主机的SSH用户缺少root权限:\n  - %s", strings.Join(failed, "\n  - "))
+	}
+	return nil
+}
+
+// privilegeFailures 返回所有缺少root权限的主机描述
+func (c *BasicChecker) privilegeFailures() []string {
+	// 各主机的权限检查互不依赖，按全局并发数并行执行
+	errs := ssh.ForEachHost(c.config.Hosts, func(host config.Host) error {
+		result := c.checkHostPrivilege(host)
+		if result.Error != "" {
+			return errors.New(result.Error)
+		}
+		if c.logger != nil {
+			c.logger.Debug("主机 %s: 用户 %s 具备root权限", host.IP, host.User)
+		}
+		return nil
+	})
+
+	var failed []string
+	for i, host := range c.config.Hosts {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Sprintf("%s (%s@%s): %v", host.IP, host.User, host.IP, errs[i]))
+		}
+	}
+	return failed
+}
+
+// checkHostPrivilege 检查单个主机是否为root用户，以及是否支持免密sudo
+func (c *BasicChecker) checkHostPrivilege(host config.Host) PrivilegeResult {
+	result := PrivilegeResult{IP: host.IP, User: host.User}
+
+	// 配置了become时命令会被包装为sudo执行，直接确认提权后的用户为root
+	if host.Become {
+		output, err := c.buildSSHCommand(host, "id -u").CombinedOutput()
+		if err != nil {
+			result.Error = fmt.Sprintf("sudo提权失败，请检查become_password或sudoers配置: %s", strings.TrimSpace(string(output)))
+			return result
+		}
+		result.IsRoot = strings.TrimSpace(string(output)) == "0"
+		result.CanSudo = true
+		if !result.IsRoot && (host.BecomeUser == "" || host.BecomeUser == "root") {
+			result.Error = "sudo提权后仍不是root用户"
+		}
+		return result
+	}
+
+	output, err := c.buildSSHCommand(host, "id -u; sudo -n true >/dev/null 2>&1 && echo SUDO_OK || echo SUDO_NO").Output()
+	if err != nil {
+		result.Error = fmt.Sprintf("SSH执行失败: %v", err)
+		return result
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	result.IsRoot = len(lines) > 0 && strings.TrimSpace(lines[0]) == "0"
+	result.CanSudo = strings.Contains(string(output), "SUDO_OK")
+
+	if result.IsRoot {
+		return result
+	}
+	if result.CanSudo {
+		result.Error = "非root用户，支持免密sudo，请在主机配置中设置 become: true"
+	} else {
+		result.Error = "非root用户且不支持免密sudo，请使用root用户，或设置 become: true 并配置 become_password"
+	}
+	return result
+}
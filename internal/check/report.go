@@ -0,0 +1,44 @@
+package check
+
+import (
+	"fmt"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// Report 非交互式预检的汇总结果
+type Report struct {
+	Hosts    []*BasicCheckResult
+	Errors   []string // 阻止安装的问题
+	Warnings []string // 可能导致安装失败或运行不稳定的问题
+}
+
+// Ready 没有错误时认为环境已就绪，警告不影响结果
+func (r *Report) Ready() bool {
+	return len(r.Errors) == 0
+}
+
+// RunCheckOnly 执行全部预检项并汇总所有错误和警告，从不提示确认
+// 与 Run 不同，单个主机检查失败后继续检查其余主机，便于一次性报告全部问题
+func (c *BasicChecker) RunCheckOnly() *Report {
+	report := &Report{}
+
+	errs := ssh.ForEachHost(c.config.Hosts, c.checkSingleHost)
+	for i, host := range c.config.Hosts {
+		if errs[i] != nil {
+			report.Errors = append(report.Errors, fmt.Sprintf("节点 %s: %v", host.IP, errs[i]))
+		} else {
+			c.results[host.IP].Status = "通过"
+		}
+		report.Hosts = append(report.Hosts, c.results[host.IP])
+	}
+
+	for _, failure := range c.privilegeFailures() {
+		report.Errors = append(report.Errors, "缺少root权限: "+failure)
+	}
+
+	c.collectClusterWarnings()
+	report.Warnings = append(report.Warnings, c.warningMessages()...)
+
+	return report
+}
@@ -0,0 +1,92 @@
+package check
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// timezoneScript 输出节点的时区名称、UTC偏移和系统语言环境，各占一行，无法确定时输出unknown
+// 没有timedatectl的精简系统通过 /etc/localtime 链接推断时区名称
+const timezoneScript = `tz=$(timedatectl show -p Timezone --value 2>/dev/null)
+[ -n "$tz" ] || tz=$(readlink -f /etc/localtime 2>/dev/null | sed -n 's#.*/zoneinfo/##p')
+echo "${tz:-unknown}"
+date +%z
+lang=$(sed -n 's/^LANG=//p' /etc/locale.conf /etc/default/locale 2>/dev/null | head -1 | tr -d '"')
+echo "${lang:-unknown}"`
+
+// checkSingleHostTimezone 采集节点的时区和语言环境，只用于展示和一致性检查，采集失败不影响检查结果
+func (c *BasicChecker) checkSingleHostTimezone(host config.Host) error {
+	output, err := c.buildSSHCommand(host, timezoneScript).Output()
+	if err != nil {
+		if c.logger != nil {
+			c.logger.Debug("主机 %s: 获取时区失败: %v", host.IP, err)
+		}
+		return nil
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	if len(lines) < 3 {
+		return nil
+	}
+	result := c.results[host.IP]
+	result.Timezone = fmt.Sprintf("%s (UTC%s)", strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1]))
+	result.Locale = strings.TrimSpace(lines[2])
+	return nil
+}
+
+// timezoneWarnings 各节点时区或语言环境不一致时给出警告；配置了node_timezone时由系统优化阶段统一设置，不再警告时区差异
+func (c *BasicChecker) timezoneWarnings() []string {
+	var warnings []string
+	if c.config.NodeTimezone != "" {
+		if c.logger != nil {
+			c.logger.Info("已配置 node_timezone，系统优化阶段将各节点时区统一设置为 %s", c.config.NodeTimezone)
+		}
+	} else if groups := c.groupHostsBy(func(r *BasicCheckResult) string { return r.Timezone }); len(groups) > 1 {
+		warnings = append(warnings, fmt.Sprintf("各节点时区不一致: %s，日志时间难以对照，可配置 node_timezone 统一设置", groups))
+	}
+	if groups := c.groupHostsBy(func(r *BasicCheckResult) string { return r.Locale }); len(groups) > 1 {
+		warnings = append(warnings, fmt.Sprintf("各节点语言环境(LANG)不一致: %s", groups))
+	}
+	return warnings
+}
+
+// hostGroups 按取值分组的主机列表
+type hostGroups map[string][]string
+
+func (g hostGroups) String() string {
+	values := make([]string, 0, len(g))
+	for value := range g {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	parts := make([]string, 0, len(values))
+	for _, value := range values {
+		parts = append(parts, fmt.Sprintf("%s [%s]", value, strings.Join(g[value], ", ")))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// groupHostsBy 按 key 对已采集到取值的主机分组，未采集到的主机不参与比较
+func (c *BasicChecker) groupHostsBy(key func(*BasicCheckResult) string) hostGroups {
+	groups := make(hostGroups)
+	for _, host := range c.config.Hosts {
+		result := c.results[host.IP]
+		if result == nil {
+			continue
+		}
+		if value := key(result); value != "" {
+			groups[value] = append(groups[value], host.IP)
+		}
+	}
+	return groups
+}
+
+// valueOrUnknown 未采集到的取值显示为未知
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "未知"
+	}
+	return value
+}
@@ -0,0 +1,47 @@
+package check
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 没有worker节点时，每个控制平面节点在运行etcd/apiserver之外还要承载Rainbond和业务负载，建议的单节点最低配置
+const (
+	controlPlaneOnlyMinCPU      = 4
+	controlPlaneOnlyMinMemoryGB = 8
+)
+
+// ControlPlaneOnlyNotice 集群没有纯worker节点时的说明，用于安装开始时的提示和预检警告
+func ControlPlaneOnlyNotice(hostCount int) string {
+	return fmt.Sprintf("集群没有纯worker节点，%d 个节点均为控制平面节点（master/etcd）：控制平面节点将使用 node-role.kubernetes.io/control-plane:PreferNoSchedule 污点，Rainbond组件和业务负载都会运行在控制平面节点上，与etcd、API Server争用CPU、内存和磁盘IO", hostCount)
+}
+
+// controlPlaneOnlyWarnings 集群没有纯worker节点时给出可见的警告，并检查控制平面节点的总容量是否足够运行Rainbond
+func (c *BasicChecker) controlPlaneOnlyWarnings() []string {
+	if c.config.HasWorkerNodes() {
+		return nil
+	}
+
+	totalCPU, totalMemGB := 0, 0
+	var undersized []string
+	for _, host := range c.config.Hosts {
+		result := c.results[host.IP]
+		if result == nil {
+			continue
+		}
+		totalCPU += result.CPUCores
+		totalMemGB += result.MemoryGB
+		if result.CPUCores > 0 && result.MemoryGB > 0 &&
+			(result.CPUCores < controlPlaneOnlyMinCPU || result.MemoryGB < controlPlaneOnlyMinMemoryGB) {
+			undersized = append(undersized, fmt.Sprintf("%s(%d核/%dGB)", host.IP, result.CPUCores, result.MemoryGB))
+		}
+	}
+
+	warnings := []string{fmt.Sprintf("%s；当前总容量 %d 核 / %d GB，如需隔离业务负载请添加 role 为 worker 的节点",
+		ControlPlaneOnlyNotice(len(c.config.Hosts)), totalCPU, totalMemGB)}
+	if len(undersized) > 0 {
+		warnings = append(warnings, fmt.Sprintf("没有worker节点时建议每个节点至少 %d 核 / %d GB，以下节点低于建议配置: %s",
+			controlPlaneOnlyMinCPU, controlPlaneOnlyMinMemoryGB, strings.Join(undersized, ", ")))
+	}
+	return warnings
+}
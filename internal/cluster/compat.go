@@ -0,0 +1,280 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// MinKubernetesVersion 安装Rainbond要求的最低Kubernetes版本
+const MinKubernetesVersion = "1.24.0"
+
+// defaultStorageClassAnnotation 标记默认StorageClass的注解
+const defaultStorageClassAnnotation = "storageclass.kubernetes.io/is-default-class"
+
+// 检查项结果状态
+const (
+	StatusPass = "通过"
+	StatusWarn = "警告"
+	StatusFail = "失败"
+)
+
+// Logger 定义日志接口
+type Logger interface {
+	Debug(format string, v ...interface{})
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Result 单个兼容性检查项的结果
+type Result struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// CompatChecker 检查已有Kubernetes集群是否满足安装MySQL和Rainbond的要求：
+// 集群版本、可用节点、配置中的主机与集群节点的对应关系、StorageClass和roi依赖的节点标签
+type CompatChecker struct {
+	config     *config.Config
+	logger     Logger
+	kubeClient kubernetes.Interface
+	results    []Result
+}
+
+func NewCompatChecker(cfg *config.Config) *CompatChecker {
+	return NewCompatCheckerWithLogger(cfg, nil)
+}
+
+func NewCompatCheckerWithLogger(cfg *config.Config, logger Logger) *CompatChecker {
+	return &CompatChecker{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Run 执行全部检查项，任一项失败时返回错误；警告项不阻止安装
+func (c *CompatChecker) Run() ([]Result, error) {
+	c.results = nil
+
+	serverVersion, err := c.connect()
+	if err != nil {
+		c.add("集群连接", StatusFail, err.Error())
+		return c.results, fmt.Errorf("无法连接已有集群: %w", err)
+	}
+	c.add("集群连接", StatusPass, fmt.Sprintf("%s（kubeconfig: %s）", serverVersion, c.config.KubeconfigPath()))
+
+	c.checkVersion(serverVersion)
+
+	nodes, err := c.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		c.add("可用节点", StatusFail, fmt.Sprintf("获取节点列表失败: %v", err))
+	} else {
+		c.checkNodes(nodes.Items)
+		c.checkHosts(nodes.Items)
+		c.checkLabels(nodes.Items)
+	}
+
+	c.checkStorageClass()
+
+	var failed []string
+	for _, result := range c.results {
+		if result.Status == StatusFail {
+			failed = append(failed, result.Name)
+		}
+	}
+	if len(failed) > 0 {
+		return c.results, fmt.Errorf("已有集群未通过兼容性检查: %s", strings.Join(failed, ", "))
+	}
+	return c.results, nil
+}
+
+// connect 使用已有集群的kubeconfig创建客户端，返回API服务器版本
+func (c *CompatChecker) connect() (string, error) {
+	if err := c.config.CheckKubeconfig(); err != nil {
+		return "", err
+	}
+	restConfig, err := clientcmd.BuildConfigFromFlags("", c.config.KubeconfigPath())
+	if err != nil {
+		return "", fmt.Errorf("构建Kubernetes配置失败: %w", err)
+	}
+	restConfig.Timeout = 10 * time.Second
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("创建Kubernetes客户端失败: %w", err)
+	}
+	info, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("连接Kubernetes API失败: %w", err)
+	}
+
+	c.kubeClient = clientset
+	return info.GitVersion, nil
+}
+
+func (c *CompatChecker) checkVersion(serverVersion string) {
+	current, err := version.ParseGeneric(serverVersion)
+	if err != nil {
+		c.add("Kubernetes版本", StatusWarn, fmt.Sprintf("无法解析版本 %s: %v", serverVersion, err))
+		return
+	}
+	if !current.AtLeast(version.MustParseGeneric(MinKubernetesVersion)) {
+		c.add("Kubernetes版本", StatusFail, fmt.Sprintf("%s 低于要求的最低版本 %s", serverVersion, MinKubernetesVersion))
+		return
+	}
+	c.add("Kubernetes版本", StatusPass, fmt.Sprintf("%s >= %s", serverVersion, MinKubernetesVersion))
+}
+
+func (c *CompatChecker) checkNodes(nodes []corev1.Node) {
+	ready := 0
+	for _, node := range nodes {
+		if nodeReady(node) {
+			ready++
+		}
+	}
+	if ready == 0 {
+		c.add("可用节点", StatusFail, fmt.Sprintf("集群中 %d 个节点均未就绪", len(nodes)))
+		return
+	}
+	c.add("可用节点", StatusPass, fmt.Sprintf("%d/%d 个节点就绪", ready, len(nodes)))
+}
+
+// checkHosts 将配置中的主机与集群节点对应：MySQL按节点名称绑定，找不到节点或名称不一致的MySQL主机会导致部署失败
+func (c *CompatChecker) checkHosts(nodes []corev1.Node) {
+	var failures, warnings []string
+	for _, host := range c.config.Hosts {
+		name := c.config.GetNodeName(host)
+		mysqlHost := host.MySQLMaster || host.MySQLSlave
+		node := findNode(nodes, name, host)
+		switch {
+		case node == nil:
+			message := fmt.Sprintf("主机 %s 在集群中没有对应的节点（节点名 %s）", host.IP, name)
+			if mysqlHost {
+				failures = append(failures, message)
+			} else {
+				warnings = append(warnings, message)
+			}
+		case node.Name != name:
+			message := fmt.Sprintf("主机 %s 对应的节点名为 %s，请在配置中设置 node_name: %s", host.IP, node.Name, node.Name)
+			if mysqlHost {
+				failures = append(failures, message)
+			} else {
+				warnings = append(warnings, message)
+			}
+		case !nodeReady(*node):
+			warnings = append(warnings, fmt.Sprintf("主机 %s 对应的节点 %s 未就绪", host.IP, node.Name))
+		}
+	}
+
+	switch {
+	case len(failures) > 0:
+		c.add("主机与节点对应", StatusFail, strings.Join(append(failures, warnings...), "; "))
+	case len(warnings) > 0:
+		c.add("主机与节点对应", StatusWarn, strings.Join(warnings, "; "))
+	default:
+		c.add("主机与节点对应", StatusPass, fmt.Sprintf("%d 个主机均有对应的就绪节点", len(c.config.Hosts)))
+	}
+}
+
+// checkLabels 检查RKE2安装时会自动添加、而已有集群需要自行添加的节点标签
+func (c *CompatChecker) checkLabels(nodes []corev1.Node) {
+	labels := c.config.ClusterLabels()
+	if len(labels) == 0 {
+		c.add("节点标签", StatusPass, "未配置 cluster_name，无需额外的节点标签")
+		return
+	}
+
+	var missing []string
+	for _, host := range c.config.Hosts {
+		node := findNode(nodes, c.config.GetNodeName(host), host)
+		if node == nil {
+			continue
+		}
+		for key, value := range labels {
+			if node.Labels[key] != value {
+				missing = append(missing, fmt.Sprintf("kubectl label node %s %s=%s --overwrite", node.Name, key, value))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		c.add("节点标签", StatusWarn, "以下节点缺少集群名称标签，可执行: "+strings.Join(missing, "; "))
+		return
+	}
+	c.add("节点标签", StatusPass, "所有节点均已包含集群名称标签")
+}
+
+func (c *CompatChecker) checkStorageClass() {
+	classes, err := c.kubeClient.StorageV1().StorageClasses().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		c.add("StorageClass", StatusWarn, fmt.Sprintf("获取StorageClass失败: %v", err))
+		return
+	}
+	if len(classes.Items) == 0 {
+		c.add("StorageClass", StatusWarn, "集群中没有StorageClass，需要持久卷的组件和应用将无法创建PVC")
+		return
+	}
+
+	var names []string
+	for _, class := range classes.Items {
+		if class.Annotations[defaultStorageClassAnnotation] == "true" {
+			c.add("StorageClass", StatusPass, fmt.Sprintf("默认StorageClass: %s", class.Name))
+			return
+		}
+		names = append(names, class.Name)
+	}
+	c.add("StorageClass", StatusWarn, fmt.Sprintf("没有默认StorageClass（已有: %s），未指定storageClassName的PVC将无法绑定", strings.Join(names, ", ")))
+}
+
+func (c *CompatChecker) add(name, status, detail string) {
+	c.results = append(c.results, Result{Name: name, Status: status, Detail: detail})
+	if c.logger == nil {
+		return
+	}
+	switch status {
+	case StatusFail:
+		c.logger.Error("兼容性检查 %s: %s", name, detail)
+	case StatusWarn:
+		c.logger.Warn("兼容性检查 %s: %s", name, detail)
+	default:
+		c.logger.Info("兼容性检查 %s: %s", name, detail)
+	}
+}
+
+// findNode 按节点名称查找主机对应的节点，找不到时按节点地址匹配主机IP或内网IP
+func findNode(nodes []corev1.Node, name string, host config.Host) *corev1.Node {
+	for i := range nodes {
+		if nodes[i].Name == name {
+			return &nodes[i]
+		}
+	}
+	for i := range nodes {
+		for _, addr := range nodes[i].Status.Addresses {
+			if addr.Type != corev1.NodeInternalIP && addr.Type != corev1.NodeExternalIP {
+				continue
+			}
+			if addr.Address == host.IP || (host.InternalIP != "" && addr.Address == host.InternalIP) {
+				return &nodes[i]
+			}
+		}
+	}
+	return nil
+}
+
+func nodeReady(node corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
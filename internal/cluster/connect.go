@@ -0,0 +1,38 @@
+package cluster
+
+import (
+	"fmt"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// 首次连接Kubernetes API的重试参数：RKE2刚安装完成时API服务器可能仍在启动
+const (
+	connectAttempts       = 6
+	connectInitialBackoff = 2 * time.Second
+	connectMaxBackoff     = 15 * time.Second
+)
+
+// WaitForAPI 以指数退避重试访问Kubernetes API，直到成功或达到重试次数（约45秒），返回最后一次的错误
+func WaitForAPI(client kubernetes.Interface, logger Logger) error {
+	backoff := connectInitialBackoff
+	var err error
+	for attempt := 1; attempt <= connectAttempts; attempt++ {
+		if _, err = client.Discovery().ServerVersion(); err == nil {
+			return nil
+		}
+		if attempt == connectAttempts {
+			break
+		}
+		if logger != nil {
+			logger.Warn("Kubernetes API暂不可访问（第 %d/%d 次）: %v，%s 后重试", attempt, connectAttempts, err, backoff)
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > connectMaxBackoff {
+			backoff = connectMaxBackoff
+		}
+	}
+	return fmt.Errorf("重试 %d 次后Kubernetes API仍不可访问: %w", connectAttempts, err)
+}
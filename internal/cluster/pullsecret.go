@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// EnsureImagePullSecret 确保 image_pull.secret_name 在命名空间中可用：
+// 提供了仓库凭据时创建或更新 kubernetes.io/dockerconfigjson 类型的Secret，否则检查Secret已存在
+func EnsureImagePullSecret(ctx context.Context, client kubernetes.Interface, namespace string, pull config.ImagePullConfig) error {
+	if !pull.HasPullSecret() {
+		return nil
+	}
+
+	secrets := client.CoreV1().Secrets(namespace)
+	existing, err := secrets.Get(ctx, pull.SecretName, metav1.GetOptions{})
+	notFound := apierrors.IsNotFound(err)
+	if err != nil && !notFound {
+		return fmt.Errorf("获取imagePullSecret %s/%s 失败: %w", namespace, pull.SecretName, err)
+	}
+
+	if !pull.CreatesPullSecret() {
+		if notFound {
+			return fmt.Errorf("imagePullSecret %s 在命名空间 %s 中不存在，请先创建，或在 image_pull 中配置 registry/username/password 由roi创建", pull.SecretName, namespace)
+		}
+		if existing.Type != corev1.SecretTypeDockerConfigJson && existing.Type != corev1.SecretTypeDockercfg {
+			return fmt.Errorf("imagePullSecret %s/%s 的类型为 %s，不能用于拉取镜像", namespace, pull.SecretName, existing.Type)
+		}
+		return nil
+	}
+
+	dockerConfig, err := dockerConfigJSON(pull)
+	if err != nil {
+		return err
+	}
+	if notFound {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: pull.SecretName, Namespace: namespace},
+			Type:       corev1.SecretTypeDockerConfigJson,
+			Data:       map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+		}
+		if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("创建imagePullSecret %s/%s 失败: %w", namespace, pull.SecretName, err)
+		}
+		return nil
+	}
+
+	if existing.Type != corev1.SecretTypeDockerConfigJson {
+		return fmt.Errorf("命名空间 %s 中已存在类型为 %s 的Secret %s，无法更新为imagePullSecret", namespace, existing.Type, pull.SecretName)
+	}
+	existing.Data = map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig}
+	if _, err := secrets.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("更新imagePullSecret %s/%s 失败: %w", namespace, pull.SecretName, err)
+	}
+	return nil
+}
+
+// dockerConfigJSON 生成 .dockerconfigjson 内容
+func dockerConfigJSON(pull config.ImagePullConfig) ([]byte, error) {
+	auth := base64.StdEncoding.EncodeToString([]byte(pull.Username + ":" + pull.Password))
+	data, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{
+			pull.Registry: map[string]string{
+				"username": pull.Username,
+				"password": pull.Password,
+				"auth":     auth,
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("生成imagePullSecret内容失败: %w", err)
+	}
+	return data, nil
+}
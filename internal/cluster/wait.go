@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// waitProgressInterval 等待期间输出一次Info级别进度的间隔，每次轮询的状态只记录到Debug日志
+const waitProgressInterval = time.Minute
+
+// ErrWaitTimeout 等待条件超时，调用方可通过 errors.Is 判断后补充诊断信息
+var ErrWaitTimeout = errors.New("超时")
+
+// Condition 轮询检查的条件，返回true表示条件已满足
+// 返回的错误视为暂时状态（如API暂不可访问、节点未就绪的原因），记录后继续等待，超时时作为诊断信息附加到错误中
+type Condition func(ctx context.Context) (bool, error)
+
+// WaitFor 每隔 interval 检查一次 condition，直到满足、超时或 ctx 取消
+// 首次检查立即执行；超时返回包装了 ErrWaitTimeout 和最后一次状态的错误，ctx 取消时返回包装了 ctx.Err() 的错误
+func WaitFor(ctx context.Context, logger Logger, interval, timeout time.Duration, description string, condition Condition) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	lastProgress := start
+	var lastErr error
+	for {
+		done, err := condition(ctx)
+		if done {
+			return nil
+		}
+		lastErr = err
+		if logger != nil {
+			if err != nil {
+				logger.Debug("等待%s: %v（已等待 %s）", description, err, time.Since(start).Round(time.Second))
+			}
+			if time.Since(lastProgress) >= waitProgressInterval {
+				logger.Info("仍在等待%s（已等待 %s，超时 %s）", description, time.Since(start).Round(time.Second), timeout)
+				lastProgress = time.Now()
+			}
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				if lastErr != nil {
+					return fmt.Errorf("等待%s%w（%s），最后状态: %w", description, ErrWaitTimeout, timeout, lastErr)
+				}
+				return fmt.Errorf("等待%s%w（%s）", description, ErrWaitTimeout, timeout)
+			}
+			return fmt.Errorf("等待%s已取消: %w", description, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package dns
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// Logger 定义日志接口
+type Logger interface {
+	Debug(format string, v ...interface{})
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// StepProgress 进度接口
+type StepProgress interface {
+	StartNodeProcessing(nodeIP string)
+	CompleteNodeStep(nodeIP string)
+}
+
+const (
+	hostsFile   = "/etc/hosts"
+	resolvFile  = "/etc/resolv.conf"
+	hostsBlock  = "hosts"
+	resolvBlock = "nameservers"
+)
+
+// Configurator 将 dns 配置中的hosts映射和DNS服务器写入各节点
+type Configurator struct {
+	config       *config.Config
+	logger       Logger
+	stepProgress StepProgress
+}
+
+func NewConfigurator(cfg *config.Config) *Configurator {
+	return NewConfiguratorWithLogger(cfg, nil)
+}
+
+func NewConfiguratorWithLogger(cfg *config.Config, logger Logger) *Configurator {
+	return NewConfiguratorWithLoggerAndProgress(cfg, logger, nil)
+}
+
+func NewConfiguratorWithLoggerAndProgress(cfg *config.Config, logger Logger, stepProgress StepProgress) *Configurator {
+	return &Configurator{
+		config:       cfg,
+		logger:       logger,
+		stepProgress: stepProgress,
+	}
+}
+
+// Run 在所有节点上写入 /etc/hosts 和 /etc/resolv.conf 中由roi管理的区块，重复执行时替换区块而不是追加
+func (c *Configurator) Run() error {
+	if !c.config.HasDNSConfig() {
+		if c.logger != nil {
+			c.logger.Info("未配置dns，跳过名称解析配置")
+		}
+		return nil
+	}
+
+	errs := ssh.ForEachHost(c.config.Hosts, func(host config.Host) error {
+		if c.stepProgress != nil {
+			c.stepProgress.StartNodeProcessing(host.IP)
+		}
+		if err := c.configureHost(host); err != nil {
+			if c.logger != nil {
+				c.logger.Error("主机 %s: 名称解析配置失败: %v", host.IP, err)
+			}
+			return err
+		}
+		if c.stepProgress != nil {
+			c.stepProgress.CompleteNodeStep(host.IP)
+		}
+		return nil
+	})
+	if failures := ssh.CollectHostErrors(c.config.Hosts, errs); len(failures) > 0 {
+		return fmt.Errorf("节点 %s 名称解析配置失败: %w", failures[0].Host, failures[0].Err)
+	}
+
+	if c.logger != nil {
+		c.logger.Info("所有节点名称解析配置完成")
+	}
+	return nil
+}
+
+// configureHost 写入单个节点的hosts映射和DNS服务器
+func (c *Configurator) configureHost(host config.Host) error {
+	if len(c.config.DNS.Hosts) > 0 {
+		if err := c.writeBlock(host, hostsFile, hostsBlock, hostsContent(c.config.DNS.Hosts), false); err != nil {
+			return err
+		}
+	}
+	if len(c.config.DNS.Nameservers) > 0 {
+		// nameserver按出现顺序使用，区块写在文件开头使配置的DNS服务器优先
+		if err := c.writeBlock(host, resolvFile, resolvBlock, resolvContent(c.config.DNS.Nameservers), true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBlock 在节点文件中写入受管理区块并记录结果
+func (c *Configurator) writeBlock(host config.Host, path, name, content string, prepend bool) error {
+	changed, err := ssh.WriteManagedBlock(host, ssh.NewHostCommand, ssh.ManagedBlock{
+		Path:    path,
+		Name:    name,
+		Content: content,
+		Prepend: prepend,
+	})
+	if err != nil {
+		return err
+	}
+	if c.logger != nil {
+		if changed {
+			c.logger.Info("主机 %s: 已更新 %s（原文件备份为 %s.roi-bak）", host.IP, path, path)
+		} else {
+			c.logger.Info("主机 %s: %s 无需变更", host.IP, path)
+		}
+	}
+	return nil
+}
+
+func hostsContent(entries []config.HostsEntry) string {
+	lines := make([]string, len(entries))
+	for i, entry := range entries {
+		lines[i] = fmt.Sprintf("%s\t%s", entry.IP, strings.Join(entry.Hostnames, " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func resolvContent(nameservers []string) string {
+	lines := make([]string, len(nameservers))
+	for i, nameserver := range nameservers {
+		lines[i] = "nameserver " + nameserver
+	}
+	return strings.Join(lines, "\n")
+}
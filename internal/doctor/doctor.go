@@ -0,0 +1,379 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// Logger 定义日志接口
+type Logger interface {
+	Debug(format string, v ...interface{})
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Severity 问题严重程度，数值越小优先级越高
+type Severity int
+
+const (
+	SeverityCritical Severity = iota // 集群无法正常工作
+	SeverityWarning                  // 部分功能受影响
+	SeverityInfo                     // 提示信息
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityCritical:
+		return "严重"
+	case SeverityWarning:
+		return "警告"
+	default:
+		return "提示"
+	}
+}
+
+// Issue 诊断发现的问题
+type Issue struct {
+	Severity   Severity
+	Target     string // 主机IP、节点名或Pod名称
+	Summary    string
+	Detail     string
+	Suggestion string
+}
+
+// Doctor 诊断已安装（可能失败或部分完成）的集群
+type Doctor struct {
+	config     *config.Config
+	logger     Logger
+	kubeClient kubernetes.Interface
+	issues     []Issue
+}
+
+// 需要检查Pod状态的命名空间
+var diagnosedNamespaces = []string{"kube-system"}
+
+func NewDoctor(cfg *config.Config) *Doctor {
+	return NewDoctorWithLogger(cfg, nil)
+}
+
+func NewDoctorWithLogger(cfg *config.Config, logger Logger) *Doctor {
+	return &Doctor{
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Run 执行诊断，返回按优先级排序的问题列表
+func (d *Doctor) Run() ([]Issue, error) {
+	d.issues = nil
+
+	for _, host := range d.config.Hosts {
+		d.diagnoseHost(host)
+	}
+
+	if err := d.initializeKubeClient(); err != nil {
+		remedy := "确认 ./kubeconfig 存在且第一个server节点的 rke2-server 服务正常运行，可执行 roi up --rke2 重新获取"
+		if d.config.IsExistingCluster() {
+			remedy = fmt.Sprintf("确认已有集群的kubeconfig %s 有效且API服务器可从本机访问", d.config.KubeconfigPath())
+		}
+		d.addIssue(SeverityCritical, "kubernetes", "无法连接Kubernetes API", err.Error(), remedy)
+	} else {
+		d.diagnoseNodes()
+		namespaces := append([]string{}, diagnosedNamespaces...)
+		namespaces = append(namespaces, d.config.Rainbond.Namespace)
+		for _, namespace := range namespaces {
+			d.diagnosePods(namespace)
+		}
+	}
+
+	d.diagnoseGateway()
+
+	sort.SliceStable(d.issues, func(i, j int) bool {
+		return d.issues[i].Severity < d.issues[j].Severity
+	})
+	return d.issues, nil
+}
+
+func (d *Doctor) addIssue(severity Severity, target, summary, detail, suggestion string) {
+	d.issues = append(d.issues, Issue{
+		Severity:   severity,
+		Target:     target,
+		Summary:    summary,
+		Detail:     detail,
+		Suggestion: suggestion,
+	})
+}
+
+// diagnoseHost 检查主机SSH连通性、RKE2服务状态和最近的错误日志，已有集群不由RKE2管理，只检查SSH连通性
+func (d *Doctor) diagnoseHost(host config.Host) {
+	if d.logger != nil {
+		d.logger.Info("诊断主机 %s...", host.IP)
+	}
+
+	if output, err := ssh.NewHostCommand(host, "echo ok").CombinedOutput(); err != nil {
+		d.addIssue(SeverityCritical, host.IP, "SSH连接失败", strings.TrimSpace(string(output)),
+			"检查主机网络、SSH服务以及配置文件中的用户名/密码/密钥")
+		return
+	}
+	if d.config.IsExistingCluster() {
+		return
+	}
+
+	service := "rke2-agent"
+	if isServerHost(host) {
+		service = "rke2-server"
+	}
+
+	output, _ := ssh.NewHostCommand(host, fmt.Sprintf("systemctl is-active %s 2>/dev/null", service)).Output()
+	state := strings.TrimSpace(string(output))
+	if state == "active" {
+		return
+	}
+	if state == "" {
+		state = "unknown"
+	}
+
+	// 提取服务最近的错误日志
+	logCmd := fmt.Sprintf("journalctl -u %s --no-pager -n 300 2>/dev/null | grep -iE 'error|fatal|failed' | tail -5", service)
+	logOutput, _ := ssh.NewHostCommand(host, logCmd).Output()
+	recentErrors := strings.TrimSpace(string(logOutput))
+
+	suggestion := fmt.Sprintf("登录主机执行 journalctl -u %s -f 查看详细日志", service)
+	if strings.Contains(recentErrors, "etcd") {
+		suggestion = "etcd 异常，检查etcd节点间 2379/2380 端口连通性及磁盘性能；" + suggestion
+	} else if strings.Contains(recentErrors, "9345") || strings.Contains(recentErrors, "connection refused") {
+		suggestion = "无法连接到第一个server节点，检查 9345/6443 端口连通性；" + suggestion
+	}
+
+	d.addIssue(SeverityCritical, host.IP, fmt.Sprintf("%s 服务状态为 %s", service, state), recentErrors, suggestion)
+}
+
+// diagnoseNodes 检查未就绪的节点以及阻止调度的污点
+func (d *Doctor) diagnoseNodes() {
+	nodes, err := d.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		d.addIssue(SeverityCritical, "kubernetes", "获取节点列表失败", err.Error(), "检查Kubernetes API服务是否正常")
+		return
+	}
+
+	schedulable := 0
+	for _, node := range nodes.Items {
+		ready := false
+		var reason string
+		for _, condition := range node.Status.Conditions {
+			if condition.Type == corev1.NodeReady {
+				ready = condition.Status == corev1.ConditionTrue
+				reason = condition.Message
+			}
+		}
+		if !ready {
+			d.addIssue(SeverityCritical, node.Name, "节点未就绪", reason,
+				"检查该节点 rke2 服务和CNI组件（kube-system 中的 canal/calico Pod）状态")
+		}
+
+		blocking := false
+		for _, taint := range node.Spec.Taints {
+			if taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute {
+				blocking = true
+				break
+			}
+		}
+		if ready && !blocking && !node.Spec.Unschedulable {
+			schedulable++
+		}
+	}
+
+	if len(nodes.Items) > 0 && schedulable == 0 {
+		d.addIssue(SeverityCritical, "kubernetes", "没有可调度业务负载的节点",
+			"所有节点都带有 NoSchedule/NoExecute 污点、处于 cordon 状态或未就绪",
+			"添加worker节点，或移除控制面节点上的污点（kubectl taint nodes <node> <key>-）")
+	}
+}
+
+// diagnosePods 检查命名空间下未正常运行的Pod，识别镜像拉取失败和数据库连接错误
+func (d *Doctor) diagnosePods(namespace string) {
+	if namespace == "" {
+		return
+	}
+
+	pods, err := d.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		d.addIssue(SeverityWarning, namespace, "获取Pod列表失败", err.Error(), "")
+		return
+	}
+
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		target := fmt.Sprintf("%s/%s", namespace, pod.Name)
+
+		if pod.Status.Phase == corev1.PodPending {
+			detail := ""
+			for _, condition := range pod.Status.Conditions {
+				if condition.Type == corev1.PodScheduled && condition.Status != corev1.ConditionTrue {
+					detail = condition.Message
+				}
+			}
+			suggestion := "检查节点资源与污点配置"
+			if strings.Contains(detail, "taint") {
+				suggestion = "Pod被节点污点阻止调度，检查 node-taint 配置或添加worker节点"
+			}
+			if detail != "" || !hasContainerWaiting(pod) {
+				d.addIssue(SeverityWarning, target, "Pod处于Pending状态", detail, suggestion)
+				continue
+			}
+		}
+
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Waiting != nil {
+				d.diagnoseWaitingContainer(namespace, pod, status)
+			} else if !status.Ready && pod.Status.Phase == corev1.PodRunning {
+				d.addIssue(SeverityInfo, target, fmt.Sprintf("容器 %s 未就绪", status.Name), "",
+					fmt.Sprintf("执行 kubectl -n %s describe pod %s 查看探针状态", namespace, pod.Name))
+			}
+		}
+	}
+}
+
+func hasContainerWaiting(pod corev1.Pod) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// diagnoseWaitingContainer 根据容器等待原因给出诊断
+func (d *Doctor) diagnoseWaitingContainer(namespace string, pod corev1.Pod, status corev1.ContainerStatus) {
+	target := fmt.Sprintf("%s/%s", namespace, pod.Name)
+	reason := status.State.Waiting.Reason
+	message := status.State.Waiting.Message
+
+	switch reason {
+	case "ErrImagePull", "ImagePullBackOff", "InvalidImageName":
+		d.addIssue(SeverityCritical, target, fmt.Sprintf("镜像拉取失败: %s", status.Image), message,
+			"确认离线镜像已导入，或检查 rke2.registry_config 中的镜像仓库地址与认证信息")
+	case "CrashLoopBackOff":
+		logs := d.getContainerLogs(namespace, pod.Name, status.Name)
+		if isDatabaseError(logs) {
+			d.addIssue(SeverityCritical, target, "组件无法连接数据库", lastLines(logs, 3),
+				"检查MySQL Pod状态及 rainbond.values 中 regionDatabase/uiDatabase 配置")
+			return
+		}
+		d.addIssue(SeverityWarning, target, fmt.Sprintf("容器 %s 反复重启", status.Name), lastLines(logs, 3),
+			fmt.Sprintf("执行 kubectl -n %s logs %s -c %s --previous 查看日志", namespace, pod.Name, status.Name))
+	case "ContainerCreating", "PodInitializing":
+		d.addIssue(SeverityInfo, target, fmt.Sprintf("容器 %s 正在创建", status.Name), message, "稍后重新执行 roi doctor")
+	default:
+		d.addIssue(SeverityWarning, target, fmt.Sprintf("容器 %s 处于 %s 状态", status.Name, reason), message,
+			fmt.Sprintf("执行 kubectl -n %s describe pod %s 查看事件", namespace, pod.Name))
+	}
+}
+
+// getContainerLogs 获取容器上一次运行的日志
+func (d *Doctor) getContainerLogs(namespace, podName, container string) string {
+	tailLines := int64(50)
+	options := &corev1.PodLogOptions{Container: container, Previous: true, TailLines: &tailLines}
+	data, err := d.kubeClient.CoreV1().Pods(namespace).GetLogs(podName, options).DoRaw(context.TODO())
+	if err != nil {
+		options.Previous = false
+		data, _ = d.kubeClient.CoreV1().Pods(namespace).GetLogs(podName, options).DoRaw(context.TODO())
+	}
+	return string(data)
+}
+
+func isDatabaseError(logs string) bool {
+	lower := strings.ToLower(logs)
+	for _, keyword := range []string{"access denied for user", "can't connect to mysql", "connect to database", "dial tcp", "unknown database"} {
+		if strings.Contains(lower, keyword) && (strings.Contains(lower, "mysql") || strings.Contains(lower, "database") || strings.Contains(lower, "3306")) {
+			return true
+		}
+	}
+	return false
+}
+
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// diagnoseGateway 检查Rainbond控制台访问地址
+func (d *Doctor) diagnoseGateway() {
+	gatewayHosts := d.config.GetRbdGatewayHosts()
+	gatewayIP := ""
+	if len(gatewayHosts) > 0 {
+		gatewayIP = gatewayHosts[0].IP
+	} else if len(d.config.Hosts) > 0 {
+		gatewayIP = d.config.Hosts[0].IP
+	}
+	if gatewayIP == "" {
+		return
+	}
+
+	url := fmt.Sprintf("http://%s:7070", config.URLHost(gatewayIP))
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		d.addIssue(SeverityWarning, gatewayIP, "Rainbond控制台无法访问", err.Error(),
+			fmt.Sprintf("检查 %s 命名空间中 rbd-gateway 与 rbd-app-ui 的Pod状态，以及防火墙是否放行 7070 端口", d.config.Rainbond.Namespace))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		d.addIssue(SeverityWarning, gatewayIP, fmt.Sprintf("Rainbond控制台返回 %d", resp.StatusCode), url,
+			"检查 rbd-app-ui 日志")
+	}
+}
+
+// initializeKubeClient 使用RKE2安装时保存的本地kubeconfig（或已有集群的kubeconfig）创建客户端
+func (d *Doctor) initializeKubeClient() error {
+	kubeConfigPath := d.config.KubeconfigPath()
+	if err := d.config.CheckKubeconfig(); err != nil {
+		return err
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	if err != nil {
+		return fmt.Errorf("构建Kubernetes配置失败: %w", err)
+	}
+	restConfig.Timeout = 10 * time.Second
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("创建Kubernetes客户端失败: %w", err)
+	}
+	if _, err := clientset.Discovery().ServerVersion(); err != nil {
+		return fmt.Errorf("连接Kubernetes API失败: %w", err)
+	}
+
+	d.kubeClient = clientset
+	return nil
+}
+
+// isServerHost 判断主机是否运行rke2-server（etcd或master角色）
+func isServerHost(host config.Host) bool {
+	for _, role := range host.Role {
+		role = strings.TrimSpace(strings.ToLower(role))
+		if role == "etcd" || role == "master" || role == "control" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// Logger 定义日志接口
+type Logger interface {
+	Debug(format string, v ...interface{})
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// Summary 安装摘要，作为钩子命令的标准输入和Webhook的请求体
+type Summary struct {
+	Status      string            `json:"status"` // success、failure
+	ClusterName string            `json:"cluster_name,omitempty"`
+	AccessURL   string            `json:"access_url,omitempty"`
+	Hosts       []string          `json:"hosts"`
+	Stages      []string          `json:"stages"`
+	StartedAt   string            `json:"started_at"`
+	FinishedAt  string            `json:"finished_at"`
+	DurationSec int64             `json:"duration_seconds"`
+	LogFile     string            `json:"log_file,omitempty"`
+	FailedStage string            `json:"failed_stage,omitempty"` // 失败的阶段，仅失败时设置
+	FailedHosts []string          `json:"failed_hosts,omitempty"` // 失败涉及的主机，无法确定时为空
+	Error       string            `json:"error,omitempty"`        // 失败的错误信息
+	Transfers   []TransferSummary `json:"transfers,omitempty"`    // RKE2阶段各节点的离线资源传输统计
+}
+
+// TransferSummary 单个节点的离线资源传输统计
+type TransferSummary struct {
+	Host         string  `json:"host"`
+	Files        int     `json:"files"`
+	Bytes        int64   `json:"bytes"`
+	SkippedFiles int     `json:"skipped_files"` // 远程已存在且校验一致而跳过的文件
+	SkippedBytes int64   `json:"skipped_bytes"`
+	ElapsedSec   float64 `json:"elapsed_seconds"`
+	MBps         float64 `json:"mb_per_second"` // 实际传输期间的平均速率
+}
+
+// Result 单个钩子的执行结果
+type Result struct {
+	Name     string
+	Duration time.Duration
+	Err      error
+}
+
+// Executor 执行一组钩子：依次运行本地命令，再POST Webhook，单个钩子失败不影响其余钩子
+type Executor struct {
+	hook   config.HookConfig
+	logger Logger
+	client *http.Client
+}
+
+func NewExecutor(hook config.HookConfig) *Executor {
+	return NewExecutorWithLogger(hook, nil)
+}
+
+func NewExecutorWithLogger(hook config.HookConfig, logger Logger) *Executor {
+	return &Executor{
+		hook:   hook,
+		logger: logger,
+		client: &http.Client{},
+	}
+}
+
+// Run 执行所有钩子并返回每个钩子的结果
+func (e *Executor) Run(summary *Summary) []Result {
+	payload, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return []Result{{Name: "安装摘要", Err: fmt.Errorf("序列化安装摘要失败: %w", err)}}
+	}
+
+	var results []Result
+	for _, command := range e.hook.Commands {
+		start := time.Now()
+		err := e.runCommand(command, summary, payload)
+		results = append(results, Result{Name: command, Duration: time.Since(start), Err: err})
+	}
+	if e.hook.Webhook != "" {
+		start := time.Now()
+		err := e.postWebhook(payload)
+		results = append(results, Result{Name: "webhook " + e.hook.Webhook, Duration: time.Since(start), Err: err})
+	}
+	return results
+}
+
+// runCommand 通过 sh -c 执行本地命令，标准输入为安装摘要JSON，环境变量中提供常用字段
+func (e *Executor) runCommand(command string, summary *Summary, payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.hook.TimeoutDuration())
+	defer cancel()
+
+	if e.logger != nil {
+		e.logger.Info("执行钩子命令: %s", command)
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(payload)
+	// 超时后命令的子进程可能仍持有输出管道，最多再等待5秒
+	cmd.WaitDelay = 5 * time.Second
+	cmd.Env = append(os.Environ(),
+		"ROI_STATUS="+summary.Status,
+		"ROI_CLUSTER_NAME="+summary.ClusterName,
+		"ROI_ACCESS_URL="+summary.AccessURL,
+		"ROI_LOG_FILE="+summary.LogFile,
+		"ROI_FAILED_STAGE="+summary.FailedStage,
+		"ROI_FAILED_HOSTS="+strings.Join(summary.FailedHosts, ","),
+		"ROI_ERROR="+summary.Error,
+	)
+	output, err := cmd.CombinedOutput()
+	if e.logger != nil && len(output) > 0 {
+		e.logger.Debug("钩子命令输出:\n%s", strings.TrimSpace(string(output)))
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("执行超时（%s）", e.hook.TimeoutDuration())
+	}
+	if err != nil {
+		return fmt.Errorf("%w, 输出: %s", err, lastLines(string(output), 5))
+	}
+	return nil
+}
+
+// postWebhook 将安装摘要JSON POST到Webhook，非2xx响应视为失败
+func (e *Executor) postWebhook(payload []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), e.hook.TimeoutDuration())
+	defer cancel()
+
+	if e.logger != nil {
+		e.logger.Info("发送安装摘要到Webhook: %s", e.hook.Webhook)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.hook.Webhook, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("创建Webhook请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求Webhook失败: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("Webhook返回 %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,160 @@
+package images
+
+import (
+	"archive/tar"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// DefaultTarballs 安装时传输到各节点并由RKE2自动导入的离线镜像包
+var DefaultTarballs = []string{"rke2-images-linux.tar", "rainbond-offline-images.tar"}
+
+// ReadTarballImages 读取离线镜像包（docker save 或 OCI 格式）中包含的镜像名称，不解压镜像层
+func ReadTarballImages(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开镜像包 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	var refs []string
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取镜像包 %s 失败: %w", path, err)
+		}
+
+		switch strings.TrimPrefix(header.Name, "./") {
+		case "manifest.json":
+			var manifest []struct {
+				RepoTags []string `json:"RepoTags"`
+			}
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return nil, fmt.Errorf("解析镜像包 %s 的manifest.json失败: %w", path, err)
+			}
+			for _, entry := range manifest {
+				refs = append(refs, entry.RepoTags...)
+			}
+			return uniqueNormalized(refs), nil
+		case "index.json":
+			var index struct {
+				Manifests []struct {
+					Annotations map[string]string `json:"annotations"`
+				} `json:"manifests"`
+			}
+			if err := json.NewDecoder(tr).Decode(&index); err != nil {
+				return nil, fmt.Errorf("解析镜像包 %s 的index.json失败: %w", path, err)
+			}
+			for _, m := range index.Manifests {
+				if name := m.Annotations["io.containerd.image.name"]; name != "" {
+					refs = append(refs, name)
+				} else if name := m.Annotations["org.opencontainers.image.ref.name"]; strings.Contains(name, "/") {
+					refs = append(refs, name)
+				}
+			}
+		}
+	}
+
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("镜像包 %s 中未找到镜像名称（缺少manifest.json或index.json）", path)
+	}
+	return uniqueNormalized(refs), nil
+}
+
+// ReadImageList 读取镜像清单文件，每行一个镜像，忽略空行和#注释
+// 兼容 helm template 输出中的 "image: xxx" 行，便于直接使用从Chart提取的镜像列表
+func ReadImageList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开镜像清单 %s 失败: %w", path, err)
+	}
+	defer f.Close()
+
+	var refs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "- "), "image:"))
+		line = strings.Trim(line, `"'`)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		refs = append(refs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("读取镜像清单 %s 失败: %w", path, err)
+	}
+	return uniqueNormalized(refs), nil
+}
+
+// NormalizeImage 将镜像名称补全为containerd使用的完整形式，如 nginx -> docker.io/library/nginx:latest
+func NormalizeImage(ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return ref
+	}
+
+	name, digest, hasDigest := strings.Cut(ref, "@")
+	domain, remainder := "docker.io", name
+	if i := strings.Index(name, "/"); i >= 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			domain, remainder = first, name[i+1:]
+		}
+	}
+	if domain == "index.docker.io" {
+		domain = "docker.io"
+	}
+	if domain == "docker.io" && !strings.Contains(remainder, "/") {
+		remainder = "library/" + remainder
+	}
+
+	// 最后一个路径分量中的冒号才是tag分隔符，域名中的端口不算
+	lastSlash := strings.LastIndex(remainder, "/")
+	if !hasDigest && !strings.Contains(remainder[lastSlash+1:], ":") {
+		remainder += ":latest"
+	}
+
+	normalized := domain + "/" + remainder
+	if hasDigest {
+		normalized += "@" + digest
+	}
+	return normalized
+}
+
+// SampleImages 从镜像列表中等间隔抽取最多n个镜像，作为导入后校验的代表性镜像
+func SampleImages(refs []string, n int) []string {
+	if n <= 0 || len(refs) <= n {
+		return refs
+	}
+	sample := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		sample = append(sample, refs[i*len(refs)/n])
+	}
+	return sample
+}
+
+// uniqueNormalized 规范化镜像名称并去重排序
+func uniqueNormalized(refs []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, ref := range refs {
+		ref = NormalizeImage(ref)
+		if ref == "" || seen[ref] {
+			continue
+		}
+		seen[ref] = true
+		result = append(result, ref)
+	}
+	sort.Strings(result)
+	return result
+}
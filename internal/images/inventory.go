@@ -0,0 +1,215 @@
+package images
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// Logger 定义日志接口
+type Logger interface {
+	Debug(format string, v ...interface{})
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// listImagesScript 列出RKE2内置containerd中k8s.io命名空间的镜像
+// 优先使用crictl输出JSON，没有crictl时回退到ctr逐行输出镜像引用
+const listImagesScript = `
+	bin=%s
+	sock=/run/k3s/containerd/containerd.sock
+	if [ -x "$bin/crictl" ]; then
+		echo crictl
+		"$bin/crictl" --runtime-endpoint "unix://$sock" images -o json
+	elif [ -x "$bin/ctr" ]; then
+		echo ctr
+		"$bin/ctr" --address "$sock" -n k8s.io images ls -q
+	else
+		echo none
+	fi
+`
+
+// NodeReport 单个节点的镜像清点结果
+type NodeReport struct {
+	Host    string   `json:"host"`
+	Tool    string   `json:"tool,omitempty"`    // 使用的清点工具：crictl 或 ctr
+	Present int      `json:"present"`           // 节点上已存在的期望镜像数量
+	Total   int      `json:"total"`             // 节点上的镜像总数
+	Missing []string `json:"missing,omitempty"` // 节点上缺失的期望镜像
+	Error   string   `json:"error,omitempty"`
+}
+
+// Inventory 清点各节点containerd中的镜像，并与离线镜像包中的期望镜像对比
+type Inventory struct {
+	config   *config.Config
+	logger   Logger
+	expected []string
+}
+
+func NewInventory(cfg *config.Config, expected []string) *Inventory {
+	return NewInventoryWithLogger(cfg, expected, nil)
+}
+
+func NewInventoryWithLogger(cfg *config.Config, expected []string, logger Logger) *Inventory {
+	return &Inventory{
+		config:   cfg,
+		logger:   logger,
+		expected: uniqueNormalized(expected),
+	}
+}
+
+// Run 并行清点所有节点的镜像，结果按配置中的主机顺序返回
+func (inv *Inventory) Run() []NodeReport {
+	var mu sync.Mutex
+	byHost := make(map[string]NodeReport)
+	ssh.ForEachHost(inv.config.Hosts, func(host config.Host) error {
+		report, err := inv.inspectHost(host)
+		if err != nil {
+			report.Error = err.Error()
+		}
+		mu.Lock()
+		byHost[host.IP] = report
+		mu.Unlock()
+		return nil
+	})
+
+	reports := make([]NodeReport, len(inv.config.Hosts))
+	for i, host := range inv.config.Hosts {
+		reports[i] = byHost[host.IP]
+		reports[i].Host = host.IP
+	}
+	return reports
+}
+
+// Inspect 清点单个节点的镜像，用于导入镜像后只校验该节点
+func (inv *Inventory) Inspect(host config.Host) (NodeReport, error) {
+	return inv.inspectHost(host)
+}
+
+// inspectHost 获取单个节点的镜像列表并计算缺失的期望镜像
+func (inv *Inventory) inspectHost(host config.Host) (NodeReport, error) {
+	report := NodeReport{Host: host.IP}
+	if inv.logger != nil {
+		inv.logger.Info("主机 %s: 清点containerd镜像...", host.IP)
+	}
+
+	output, err := ssh.NewHostCommand(host, fmt.Sprintf(listImagesScript, inv.config.RKE2BinDir())).Output()
+	if err != nil {
+		return report, fmt.Errorf("获取镜像列表失败: %w", err)
+	}
+
+	tool, body, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	report.Tool = strings.TrimSpace(tool)
+
+	var present []string
+	switch report.Tool {
+	case "crictl":
+		present, err = parseCrictlImages(body)
+	case "ctr":
+		present = parseCtrImages(body)
+	case "none":
+		return report, fmt.Errorf("未找到crictl或ctr，RKE2可能尚未安装")
+	default:
+		err = fmt.Errorf("无法识别的输出: %s", report.Tool)
+	}
+	if err != nil {
+		return report, err
+	}
+
+	available := make(map[string]bool, len(present))
+	for _, ref := range present {
+		available[NormalizeImage(ref)] = true
+	}
+	report.Total = len(available)
+	for _, ref := range inv.expected {
+		if available[ref] {
+			report.Present++
+		} else {
+			report.Missing = append(report.Missing, ref)
+		}
+	}
+
+	if inv.logger != nil {
+		inv.logger.Info("主机 %s: 共 %d 个镜像，期望镜像 %d/%d 已存在", host.IP, report.Total, report.Present, len(inv.expected))
+	}
+	return report, nil
+}
+
+// parseCrictlImages 解析 crictl images -o json 的输出
+func parseCrictlImages(output string) ([]string, error) {
+	var result struct {
+		Images []struct {
+			RepoTags    []string `json:"repoTags"`
+			RepoDigests []string `json:"repoDigests"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return nil, fmt.Errorf("解析crictl输出失败: %w", err)
+	}
+
+	var refs []string
+	for _, image := range result.Images {
+		refs = append(refs, image.RepoTags...)
+		refs = append(refs, image.RepoDigests...)
+	}
+	return refs, nil
+}
+
+// ImageIDs 获取节点上各镜像的ID，按规范化的镜像名称索引，用于与离线镜像包对比找出缺失或已变化的镜像；
+// 只支持crictl，ctr的输出不包含镜像ID
+func (inv *Inventory) ImageIDs(host config.Host) (map[string]string, error) {
+	output, err := ssh.NewHostCommand(host, fmt.Sprintf(listImagesScript, inv.config.RKE2BinDir())).Output()
+	if err != nil {
+		return nil, fmt.Errorf("获取镜像列表失败: %w", err)
+	}
+
+	tool, body, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	if tool = strings.TrimSpace(tool); tool != "crictl" {
+		return nil, fmt.Errorf("节点上没有crictl（%s），无法获取镜像ID", tool)
+	}
+	return parseCrictlImageIDs(body)
+}
+
+// parseCrictlImageIDs 解析 crictl images -o json 输出中各镜像名称对应的镜像ID
+func parseCrictlImageIDs(output string) (map[string]string, error) {
+	var result struct {
+		Images []struct {
+			ID       string   `json:"id"`
+			RepoTags []string `json:"repoTags"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return nil, fmt.Errorf("解析crictl输出失败: %w", err)
+	}
+
+	ids := make(map[string]string)
+	for _, image := range result.Images {
+		for _, ref := range image.RepoTags {
+			ids[NormalizeImage(ref)] = image.ID
+		}
+	}
+	return ids, nil
+}
+
+// parseCtrImages 解析 ctr images ls -q 的输出，忽略仅以摘要标识的条目
+func parseCtrImages(output string) []string {
+	var refs []string
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "sha256:") {
+			continue
+		}
+		refs = append(refs, line)
+	}
+	return refs
+}
+
+// Expected 返回参与对比的期望镜像列表
+func (inv *Inventory) Expected() []string {
+	return inv.expected
+}
@@ -0,0 +1,174 @@
+package images
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// ErrNoDockerManifest 镜像包不是 docker save 格式（没有manifest.json），无法按镜像增量导入
+var ErrNoDockerManifest = errors.New("镜像包中没有manifest.json")
+
+// dockerManifestEntry docker save 格式 manifest.json 中的单个镜像
+type dockerManifestEntry struct {
+	Config   string   `json:"Config"`
+	RepoTags []string `json:"RepoTags"`
+	Layers   []string `json:"Layers"`
+}
+
+// ReadTarballImageIDs 读取 docker save 格式镜像包中各镜像的ID（镜像配置的摘要，与 crictl images 的 id 一致），
+// 按规范化的镜像名称索引；OCI格式的镜像包返回 ErrNoDockerManifest
+func ReadTarballImageIDs(path string) (map[string]string, error) {
+	manifest, _, err := readDockerManifest(path)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[string]string)
+	for _, entry := range manifest {
+		id := configDigest(entry.Config)
+		for _, ref := range entry.RepoTags {
+			ids[NormalizeImage(ref)] = id
+		}
+	}
+	return ids, nil
+}
+
+// WriteImageSubset 从 docker save 格式的镜像包中提取 refs 中的镜像，写入新的镜像包 dst，只包含这些镜像的配置和镜像层
+func WriteImageSubset(src, dst string, refs []string) error {
+	manifest, links, err := readDockerManifest(src)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		wanted[NormalizeImage(ref)] = true
+	}
+	var selected []dockerManifestEntry
+	files := make(map[string]bool)
+	for _, entry := range manifest {
+		match := false
+		for _, ref := range entry.RepoTags {
+			if wanted[NormalizeImage(ref)] {
+				match = true
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		selected = append(selected, entry)
+		files[cleanTarPath(entry.Config)] = true
+		for _, layer := range entry.Layers {
+			files[cleanTarPath(layer)] = true
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("镜像包 %s 中未找到需要导入的镜像", src)
+	}
+	// 旧版 docker save 以符号链接复用相同的镜像层，链接目标也需写入
+	for name := range files {
+		for target, ok := links[name]; ok && !files[target]; target, ok = links[target] {
+			files[target] = true
+		}
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("打开镜像包 %s 失败: %w", src, err)
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("创建镜像包 %s 失败: %w", dst, err)
+	}
+	defer out.Close()
+
+	tr := tar.NewReader(in)
+	tw := tar.NewWriter(out)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("读取镜像包 %s 失败: %w", src, err)
+		}
+		if !files[cleanTarPath(header.Name)] {
+			continue
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("写入镜像包 %s 失败: %w", dst, err)
+		}
+		if _, err := io.Copy(tw, tr); err != nil {
+			return fmt.Errorf("写入镜像包 %s 失败: %w", dst, err)
+		}
+	}
+
+	data, err := json.Marshal(selected)
+	if err != nil {
+		return fmt.Errorf("生成manifest.json失败: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+		return fmt.Errorf("写入镜像包 %s 失败: %w", dst, err)
+	}
+	if _, err := tw.Write(data); err != nil {
+		return fmt.Errorf("写入镜像包 %s 失败: %w", dst, err)
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("写入镜像包 %s 失败: %w", dst, err)
+	}
+	return out.Close()
+}
+
+// readDockerManifest 读取镜像包的manifest.json，同时返回包内符号链接到目标路径的映射
+func readDockerManifest(tarball string) ([]dockerManifestEntry, map[string]string, error) {
+	f, err := os.Open(tarball)
+	if err != nil {
+		return nil, nil, fmt.Errorf("打开镜像包 %s 失败: %w", tarball, err)
+	}
+	defer f.Close()
+
+	var manifest []dockerManifestEntry
+	found := false
+	links := make(map[string]string)
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("读取镜像包 %s 失败: %w", tarball, err)
+		}
+		name := cleanTarPath(header.Name)
+		switch {
+		case header.Typeflag == tar.TypeSymlink:
+			links[name] = cleanTarPath(path.Join(path.Dir(name), header.Linkname))
+		case name == "manifest.json":
+			if err := json.NewDecoder(tr).Decode(&manifest); err != nil {
+				return nil, nil, fmt.Errorf("解析镜像包 %s 的manifest.json失败: %w", tarball, err)
+			}
+			found = true
+		}
+	}
+	if !found {
+		return nil, nil, fmt.Errorf("%w: %s", ErrNoDockerManifest, tarball)
+	}
+	return manifest, links, nil
+}
+
+// configDigest 将manifest.json中的镜像配置路径转换为镜像ID，兼容 <hex>.json 和 blobs/sha256/<hex> 两种格式
+func configDigest(config string) string {
+	name := strings.TrimSuffix(path.Base(config), ".json")
+	return "sha256:" + name
+}
+
+func cleanTarPath(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
@@ -0,0 +1,33 @@
+package lvm
+
+import (
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// CollectStatus 只读收集各主机的LVM状态，用于清单导出：不自动安装lvm2，
+// 单个主机设备缺失或状态收集失败时记录在该主机的 device_info 中，继续收集其余主机
+func (l *LVM) CollectStatus() map[string]*LVMStatus {
+	results := make(map[string]*LVMStatus)
+	for _, host := range l.config.Hosts {
+		results[host.IP] = &LVMStatus{
+			IP:     host.IP,
+			Role:   host.Role,
+			Status: "Unknown",
+		}
+	}
+
+	ssh.ForEachHost(l.config.Hosts, func(host config.Host) error {
+		// 按单个主机复用 checkCurrentStatus，关闭自动安装使其只检查LVM工具是否存在
+		cfg := *l.config
+		cfg.Hosts = []config.Host{host}
+		cfg.LVM.AutoInstallTools = false
+		single := &LVM{config: &cfg, logger: l.logger, runner: l.runner}
+
+		if err := single.checkCurrentStatus(results); err != nil {
+			results[host.IP].DeviceInfo = err.Error()
+		}
+		return nil
+	})
+	return results
+}
@@ -0,0 +1,150 @@
+package lvm
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// hostRunner 按主机IP和完整命令返回固定输出，未列出的命令以退出码5结束（与LVM命令找不到对象时一致）
+type hostRunner map[string]map[string]string
+
+func (r hostRunner) Run(ctx context.Context, host config.Host, command string) (string, string, int, error) {
+	stdout, ok := r[host.IP][command]
+	if !ok {
+		return "", "not found", 5, nil
+	}
+	return stdout, "", 0, nil
+}
+
+// lvmHostOutputs 返回vg_rainbond中lv_containerd、lv_data均已创建并挂载的主机上各命令的输出
+func lvmHostOutputs() map[string]string {
+	return map[string]string{
+		"which lvm":        "/usr/sbin/lvm\n",
+		"test -e /dev/sdb": "",
+		"lsblk -b -d -n -o SIZE /dev/sdb 2>/dev/null | head -1":           "214748364800\n",
+		"vgs vg_rainbond --noheadings --nosuffix --units g":               "  vg_rainbond   1   2   0 wz--n- 199.99 49.99\n",
+		"vgs vg_rainbond --noheadings --units g --nosuffix":               "  vg_rainbond   1   2   0 wz--n- 199.99 49.99\n",
+		"lvs vg_rainbond/lv_containerd --noheadings --nosuffix --units g": "  lv_containerd vg_rainbond -wi-ao---- 100.00\n",
+		"lvs vg_rainbond/lv_containerd --noheadings --units g --nosuffix": "  lv_containerd vg_rainbond -wi-ao---- 100.00\n",
+		"lvs vg_rainbond/lv_data --noheadings --nosuffix --units g":       "  lv_data vg_rainbond -wi-ao---- 50.00\n",
+		"lvs vg_rainbond/lv_data --noheadings --units g --nosuffix":       "  lv_data vg_rainbond -wi-ao---- 50.00\n",
+		"df -h /var/lib/containerd 2>/dev/null": "Filesystem                          Size  Used Avail Use% Mounted on\n" +
+			"/dev/mapper/vg_rainbond-lv_containerd  98G   12G   82G  13% /var/lib/containerd\n",
+		"df -h /data 2>/dev/null": "Filesystem                    Size  Used Avail Use% Mounted on\n" +
+			"/dev/mapper/vg_rainbond-lv_data  49G  1.0G   46G   3% /data\n",
+	}
+}
+
+func TestCollectStatus(t *testing.T) {
+	lvmConfig := &config.LVMConfig{
+		PVDevices: []string{"/dev/sdb"},
+		LVs: []config.LogicalVolume{
+			{LVName: config.ContainerdLVName, Size: "100G"},
+			{LVName: "lv_data", Size: "50G", MountPoint: "/data"},
+		},
+	}
+	cfg := &config.Config{LVM: config.LVMSettings{AutoInstallTools: true}}
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"} {
+		cfg.Hosts = append(cfg.Hosts, config.Host{IP: ip, Role: []string{"worker"}, LVMConfig: lvmConfig})
+	}
+	cfg.Hosts = append(cfg.Hosts, config.Host{IP: "10.0.0.6", Role: []string{"worker"}})
+
+	runner := hostRunner{
+		// 卷组和逻辑卷均已创建
+		"10.0.0.1": lvmHostOutputs(),
+		// lv_data 尚未创建
+		"10.0.0.2": lvmHostOutputs(),
+		// 卷组尚未创建
+		"10.0.0.3": lvmHostOutputs(),
+		// PV设备不存在
+		"10.0.0.4": lvmHostOutputs(),
+		// 未安装lvm2，清单导出不自动安装
+		"10.0.0.5": lvmHostOutputs(),
+	}
+	delete(runner["10.0.0.2"], "lvs vg_rainbond/lv_data --noheadings --nosuffix --units g")
+	delete(runner["10.0.0.2"], "lvs vg_rainbond/lv_data --noheadings --units g --nosuffix")
+	delete(runner["10.0.0.2"], "df -h /data 2>/dev/null")
+	delete(runner["10.0.0.3"], "vgs vg_rainbond --noheadings --nosuffix --units g")
+	delete(runner["10.0.0.4"], "test -e /dev/sdb")
+	delete(runner["10.0.0.5"], "which lvm")
+
+	l := NewLVM(cfg)
+	l.SetRunner(runner)
+	results := l.CollectStatus()
+
+	containerdLV := LVInfo{Name: config.ContainerdLVName, Size: "100.00G", Used: "100.00G", MountPoint: "/var/lib/containerd", Status: "Active"}
+	containerdMount := MountInfo{
+		Device: "/dev/vg_rainbond/lv_containerd", MountPoint: "/var/lib/containerd",
+		Size: "98G", Used: "12G", Available: "82G", Usage: "13%",
+	}
+	tests := []struct {
+		ip            string
+		status        string
+		deviceInfo    string
+		vgSize        string
+		vgUsed        string
+		lvDetails     []LVInfo
+		mountInfo     []MountInfo
+		deviceInfoHas string
+	}{
+		{
+			ip:         "10.0.0.1",
+			status:     "Ready",
+			deviceInfo: "1 devices found",
+			vgSize:     "199.99G",
+			vgUsed:     "150.00G",
+			lvDetails: []LVInfo{
+				containerdLV,
+				{Name: "lv_data", Size: "50.00G", Used: "50.00G", MountPoint: "/data", Status: "Active"},
+			},
+			mountInfo: []MountInfo{
+				containerdMount,
+				{Device: "/dev/vg_rainbond/lv_data", MountPoint: "/data", Size: "49G", Used: "1.0G", Available: "46G", Usage: "3%"},
+			},
+		},
+		{
+			ip:         "10.0.0.2",
+			status:     "Partial",
+			deviceInfo: "1 devices found",
+			vgSize:     "199.99G",
+			vgUsed:     "150.00G",
+			lvDetails:  []LVInfo{containerdLV},
+			mountInfo:  []MountInfo{containerdMount},
+		},
+		{ip: "10.0.0.3", status: "Not Created", deviceInfo: "1 devices found"},
+		{ip: "10.0.0.4", status: "Failed", deviceInfoHas: "LVM device /dev/sdb not found"},
+		{ip: "10.0.0.5", status: "Failed", deviceInfoHas: "LVM tools not found"},
+		{ip: "10.0.0.6", status: "Unknown", deviceInfo: "No LVM Config"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got := results[tt.ip]
+			if got == nil {
+				t.Fatalf("no result for %s", tt.ip)
+			}
+			if got.Status != tt.status {
+				t.Errorf("Status = %q, want %q", got.Status, tt.status)
+			}
+			if tt.deviceInfoHas != "" {
+				if !strings.Contains(got.DeviceInfo, tt.deviceInfoHas) {
+					t.Errorf("DeviceInfo = %q, want it to contain %q", got.DeviceInfo, tt.deviceInfoHas)
+				}
+			} else if got.DeviceInfo != tt.deviceInfo {
+				t.Errorf("DeviceInfo = %q, want %q", got.DeviceInfo, tt.deviceInfo)
+			}
+			if got.VGSize != tt.vgSize || got.VGUsed != tt.vgUsed {
+				t.Errorf("VGSize/VGUsed = %q/%q, want %q/%q", got.VGSize, got.VGUsed, tt.vgSize, tt.vgUsed)
+			}
+			if !reflect.DeepEqual(got.LVDetails, tt.lvDetails) {
+				t.Errorf("LVDetails = %+v, want %+v", got.LVDetails, tt.lvDetails)
+			}
+			if !reflect.DeepEqual(got.MountInfo, tt.mountInfo) {
+				t.Errorf("MountInfo = %+v, want %+v", got.MountInfo, tt.mountInfo)
+			}
+		})
+	}
+}
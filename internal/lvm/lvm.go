@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
 )
 
 // Logger 定义日志接口
@@ -18,40 +19,43 @@ type Logger interface {
 }
 
 type LVM struct {
-	config *config.Config
-	logger Logger
+	config          *config.Config
+	logger          Logger
+	outputFormat    string     // 状态输出格式：table、json、yaml
+	continueOnError bool       // 某个主机LVM配置失败时继续处理其余主机，最后汇总报告
+	runner          ssh.Runner // 非nil时远程命令通过 Runner 执行
 }
 
 type LVMStatus struct {
-	IP         string
-	Role       []string
-	VGName     string
-	PVDevices  []string
-	LVs        []string
-	Status     string
-	DeviceInfo string
+	IP         string   `json:"ip" yaml:"ip"`
+	Role       []string `json:"role" yaml:"role"`
+	VGName     string   `json:"vg_name" yaml:"vg_name"`
+	PVDevices  []string `json:"pv_devices" yaml:"pv_devices"`
+	LVs        []string `json:"lvs" yaml:"lvs"`
+	Status     string   `json:"status" yaml:"status"`
+	DeviceInfo string   `json:"device_info" yaml:"device_info"`
 	// 新增字段
-	VGSize    string      // 卷组总大小
-	VGUsed    string      // 卷组已用空间
-	LVDetails []LVInfo    // 逻辑卷详细信息
-	MountInfo []MountInfo // 挂载信息
+	VGSize    string      `json:"vg_size" yaml:"vg_size"`       // 卷组总大小
+	VGUsed    string      `json:"vg_used" yaml:"vg_used"`       // 卷组已用空间
+	LVDetails []LVInfo    `json:"lv_details" yaml:"lv_details"` // 逻辑卷详细信息
+	MountInfo []MountInfo `json:"mount_info" yaml:"mount_info"` // 挂载信息
 }
 
 type LVInfo struct {
-	Name       string
-	Size       string
-	Used       string
-	MountPoint string
-	Status     string
+	Name       string `json:"name" yaml:"name"`
+	Size       string `json:"size" yaml:"size"`
+	Used       string `json:"used" yaml:"used"`
+	MountPoint string `json:"mount_point" yaml:"mount_point"`
+	Status     string `json:"status" yaml:"status"`
 }
 
 type MountInfo struct {
-	Device     string
-	MountPoint string
-	Size       string
-	Used       string
-	Available  string
-	Usage      string
+	Device     string `json:"device" yaml:"device"`
+	MountPoint string `json:"mount_point" yaml:"mount_point"`
+	Size       string `json:"size" yaml:"size"`
+	Used       string `json:"used" yaml:"used"`
+	Available  string `json:"available" yaml:"available"`
+	Usage      string `json:"usage" yaml:"usage"`
 }
 
 func NewLVM(cfg *config.Config) *LVM {
@@ -60,8 +64,9 @@ func NewLVM(cfg *config.Config) *LVM {
 
 func NewLVMWithLogger(cfg *config.Config, logger Logger) *LVM {
 	return &LVM{
-		config: cfg,
-		logger: logger,
+		config:       cfg,
+		logger:       logger,
+		outputFormat: OutputTable,
 	}
 }
 
@@ -99,10 +104,9 @@ func (l *LVM) Show() error {
 
 		if l.logger != nil { l.logger.Info("Checking LVM tools for host %s...", host.IP) }
 
-		sshCmd := l.buildSSHCommand(host, "which lvm")
-		if err := sshCmd.Run(); err != nil {
+		if err := l.ensureLVMTools(host); err != nil {
 			results[host.IP].Status = "Failed"
-			return fmt.Errorf("host[%d] %s: LVM tools not found, please install lvm2 package", i, host.IP)
+			return fmt.Errorf("host[%d] %s: %w", i, host.IP, err)
 		}
 	}
 
@@ -132,15 +136,19 @@ func (l *LVM) Show() error {
 		deviceList := []string{}
 		for _, device := range host.LVMConfig.PVDevices {
 			// 通过 SSH 检查远程设备
-			sshCmd := l.buildSSHCommand(host, fmt.Sprintf("test -e %s", device))
-			if err := sshCmd.Run(); err != nil {
+			exists, err := l.pathExists(host, device)
+			if err != nil {
+				results[host.IP].Status = "Failed"
+				return fmt.Errorf("host[%d] %s: failed to check LVM device %s: %w", i, host.IP, device, err)
+			}
+			if !exists {
 				results[host.IP].Status = "Failed"
 				return fmt.Errorf("host[%d] %s: LVM device %s not found", i, host.IP, device)
 			}
 
 			// 检查设备大小
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("lsblk -b -d -n -o SIZE %s 2>/dev/null | head -1", device))
-			_, err := sshCmd.Output()
+			sshCmd := l.buildSSHCommand(host, fmt.Sprintf("lsblk -b -d -n -o SIZE %s 2>/dev/null | head -1", device))
+			_, err = sshCmd.Output()
 			if err == nil {
 				deviceList = append(deviceList, fmt.Sprintf("%s", device))
 			} else {
@@ -262,8 +270,7 @@ func (l *LVM) Show() error {
 		}
 	}
 
-	l.printResultsTable(results)
-	return nil
+	return l.printResults(results, l.printResultsTable)
 }
 
 // Create 创建 LVM 配置
@@ -290,11 +297,11 @@ func (l *LVM) Create() error {
 		if l.logger != nil { l.logger.Info("Creating LVM configuration for host %s...", host.IP) }
 
 		// 检查 LVM 工具
-		sshCmd := l.buildSSHCommand(host, "which lvm")
-		if err := sshCmd.Run(); err != nil {
-			return fmt.Errorf("host[%d] %s: LVM tools not found, please install lvm2 package", i, host.IP)
+		if err := l.ensureLVMTools(host); err != nil {
+			return fmt.Errorf("host[%d] %s: %w", i, host.IP, err)
 		}
 
+		var sshCmd *ssh.Command
 		vgName := host.LVMConfig.VGName
 		if vgName == "" {
 			vgName = "vg_rainbond"
@@ -302,8 +309,11 @@ func (l *LVM) Create() error {
 
 		// 检查设备是否存在
 		for _, device := range host.LVMConfig.PVDevices {
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("test -e %s", device))
-			if err := sshCmd.Run(); err != nil {
+			exists, err := l.pathExists(host, device)
+			if err != nil {
+				return fmt.Errorf("host[%d] %s: failed to check LVM device %s: %w", i, host.IP, device, err)
+			}
+			if !exists {
 				return fmt.Errorf("host[%d] %s: LVM device %s not found", i, host.IP, device)
 			}
 		}
@@ -335,6 +345,7 @@ func (l *LVM) Create() error {
 		}
 
 		// 格式化并挂载逻辑卷
+		var fstabEntries []string
 		for _, lv := range host.LVMConfig.LVs {
 			if l.logger != nil { l.logger.Info("Host %s: Formatting logical volume %s", host.IP, lv.LVName) }
 
@@ -351,20 +362,17 @@ func (l *LVM) Create() error {
 
 			// 挂载逻辑卷
 			if l.logger != nil { l.logger.Info("Host %s: Mounting %s to %s", host.IP, lv.LVName, mountPoint) }
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mount /dev/%s/%s %s", vgName, lv.LVName, mountPoint))
+			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mount -o %s /dev/%s/%s %s", config.LVMountOptions(lv), vgName, lv.LVName, mountPoint))
 			if err := sshCmd.Run(); err != nil {
 				if l.logger != nil { l.logger.Warn("Host %s: Logical volume %s may already be mounted", host.IP, lv.LVName) }
 			}
 
-			// 添加到 /etc/fstab（避免重复添加）
-			if l.logger != nil { l.logger.Info("Host %s: Adding %s to /etc/fstab", host.IP, lv.LVName) }
-			fstabEntry := fmt.Sprintf("/dev/%s/%s %s xfs defaults 0 0", vgName, lv.LVName, mountPoint)
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("grep -q '%s' /etc/fstab || echo '%s' >> /etc/fstab", fstabEntry, fstabEntry))
-			if err := sshCmd.Run(); err != nil {
-				if l.logger != nil { l.logger.Warn("Host %s: Failed to add %s to /etc/fstab", host.IP, lv.LVName) }
-			}
+			fstabEntries = append(fstabEntries, fmt.Sprintf("/dev/%s/%s %s xfs %s 0 0", vgName, lv.LVName, mountPoint, config.LVMountOptions(lv)))
 		}
 
+		// 添加到 /etc/fstab
+		l.writeFstabBlock(host, fstabEntries)
+
 		if l.logger != nil { l.logger.Info("Host %s: LVM configuration completed", host.IP) }
 	}
 
@@ -372,6 +380,11 @@ func (l *LVM) Create() error {
 	return nil
 }
 
+// SetContinueOnError 设置某个主机LVM配置失败时是否继续处理其余主机，结束后返回汇总所有失败主机的 ssh.HostErrors
+func (l *LVM) SetContinueOnError(continueOnError bool) {
+	l.continueOnError = continueOnError
+}
+
 // ShowAndCreate 合并显示状态和创建配置功能
 func (l *LVM) ShowAndCreate() error {
 	if l.logger != nil { l.logger.Info("显示LVM状态并创建配置...") }
@@ -404,15 +417,22 @@ func (l *LVM) ShowAndCreate() error {
 
 	// 然后创建配置
 	if l.logger != nil { l.logger.Info("=== 创建LVM配置 ===") }
-	if err := l.createLVMConfiguration(); err != nil {
-		return err
+	createErr := l.createLVMConfiguration()
+	failures, partial := createErr.(ssh.HostErrors)
+	if createErr != nil && !partial {
+		return createErr
 	}
 
-	// 最后显示创建后的状态
+	// 最后显示创建后的状态，部分主机失败时也显示，便于一次看到所有主机的结果
 	if l.logger != nil { l.logger.Info("=== 最终LVM状态 ===") }
 	l.checkCurrentStatus(results)
 
-	l.printVerticalResultsTable(results)
+	if err := l.printResults(results, l.printVerticalResultsTable); err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return failures
+	}
 	return nil
 }
 
@@ -426,10 +446,9 @@ func (l *LVM) checkCurrentStatus(results map[string]*LVMStatus) error {
 
 		if l.logger != nil { l.logger.Info("Checking LVM tools for host %s...", host.IP) }
 
-		sshCmd := l.buildSSHCommand(host, "which lvm")
-		if err := sshCmd.Run(); err != nil {
+		if err := l.ensureLVMTools(host); err != nil {
 			results[host.IP].Status = "Failed"
-			return fmt.Errorf("host[%d] %s: LVM tools not found, please install lvm2 package", i, host.IP)
+			return fmt.Errorf("host[%d] %s: %w", i, host.IP, err)
 		}
 	}
 
@@ -598,189 +617,220 @@ func (l *LVM) checkCurrentStatus(results map[string]*LVMStatus) error {
 
 // createLVMConfiguration 创建 LVM 配置
 func (l *LVM) createLVMConfiguration() error {
+	var failures ssh.HostErrors
 	for i, host := range l.config.Hosts {
 		if host.LVMConfig == nil {
 			continue
 		}
+		if err := l.createHostLVM(i, host); err != nil {
+			if !l.continueOnError {
+				return err
+			}
+			if l.logger != nil { l.logger.Error("主机 %s: LVM配置失败，继续处理其余主机: %v", host.IP, err) }
+			failures = append(failures, ssh.HostError{Host: host.IP, Err: err})
+		}
+	}
 
-		if l.logger != nil { l.logger.Info("主机 %s: 开始创建LVM配置...", host.IP) }
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
 
-		// 检查 LVM 工具
-		sshCmd := l.buildSSHCommand(host, "which lvm")
-		if err := sshCmd.Run(); err != nil {
-			return fmt.Errorf("主机[%d] %s: 未找到LVM工具，请安装lvm2软件包", i, host.IP)
-		}
+// createHostLVM 在单个主机上创建物理卷、卷组和逻辑卷，并格式化挂载
+func (l *LVM) createHostLVM(i int, host config.Host) error {
+	if l.logger != nil { l.logger.Info("主机 %s: 开始创建LVM配置...", host.IP) }
 
-		vgName := host.LVMConfig.VGName
-		if vgName == "" {
-			vgName = "vg_rainbond"
-		}
+	// 检查 LVM 工具
+	if err := l.ensureLVMTools(host); err != nil {
+		return fmt.Errorf("主机[%d] %s: %w", i, host.IP, err)
+	}
 
-		// 检查设备是否存在
-		for _, device := range host.LVMConfig.PVDevices {
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("test -e %s", device))
-			if err := sshCmd.Run(); err != nil {
-				return fmt.Errorf("主机[%d] %s: LVM设备 %s 不存在", i, host.IP, device)
-			}
+	var sshCmd *ssh.Command
+	vgName := host.LVMConfig.VGName
+	if vgName == "" {
+		vgName = "vg_rainbond"
+	}
+
+	// 检查设备是否存在
+	for _, device := range host.LVMConfig.PVDevices {
+		exists, err := l.pathExists(host, device)
+		if err != nil {
+			return fmt.Errorf("主机[%d] %s: 检查LVM设备 %s 失败: %w", i, host.IP, device, err)
+		}
+		if !exists {
+			return fmt.Errorf("主机[%d] %s: LVM设备 %s 不存在", i, host.IP, device)
 		}
+	}
 
-		// 创建物理卷
-		for _, device := range host.LVMConfig.PVDevices {
-			// 先检查物理卷是否已存在
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("pvs %s --noheadings 2>/dev/null", device))
-			if err := sshCmd.Run(); err == nil {
-				if l.logger != nil { l.logger.Info("主机 %s: 物理卷 %s 已存在，跳过创建", host.IP, device) }
-				continue
-			}
+	// 创建物理卷
+	for _, device := range host.LVMConfig.PVDevices {
+		// 先检查物理卷是否已存在
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("pvs %s --noheadings 2>/dev/null", device))
+		if err := sshCmd.Run(); err == nil {
+			if l.logger != nil { l.logger.Info("主机 %s: 物理卷 %s 已存在，跳过创建", host.IP, device) }
+			continue
+		}
 
-			if l.logger != nil { l.logger.Info("主机 %s: 创建物理卷 %s", host.IP, device) }
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("pvcreate %s", device))
-			output, err := sshCmd.CombinedOutput()
-			if err != nil {
-				if strings.Contains(string(output), "already a physical volume") {
-					if l.logger != nil { l.logger.Info("主机 %s: 物理卷 %s 已存在", host.IP, device) }
-				} else {
-					return fmt.Errorf("主机[%d] %s: 创建物理卷 %s 失败: %v - %s", 
-						i, host.IP, device, err, strings.TrimSpace(string(output)))
-				}
+		if l.logger != nil { l.logger.Info("主机 %s: 创建物理卷 %s", host.IP, device) }
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("pvcreate %s", device))
+		output, err := sshCmd.CombinedOutput()
+		if err != nil {
+			if strings.Contains(string(output), "already a physical volume") {
+				if l.logger != nil { l.logger.Info("主机 %s: 物理卷 %s 已存在", host.IP, device) }
 			} else {
-				if l.logger != nil { l.logger.Info("主机 %s: 成功创建物理卷 %s", host.IP, device) }
+				return fmt.Errorf("主机[%d] %s: 创建物理卷 %s 失败: %v - %s", 
+					i, host.IP, device, err, strings.TrimSpace(string(output)))
 			}
+		} else {
+			if l.logger != nil { l.logger.Info("主机 %s: 成功创建物理卷 %s", host.IP, device) }
 		}
+	}
 
-		// 创建卷组
-		// 先检查卷组是否已存在
-		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("vgs %s --noheadings 2>/dev/null", vgName))
-		if err := sshCmd.Run(); err == nil {
-			if l.logger != nil { l.logger.Info("主机 %s: 卷组 %s 已存在，跳过创建", host.IP, vgName) }
-		} else {
-			if l.logger != nil { l.logger.Info("主机 %s: 创建卷组 %s", host.IP, vgName) }
-			deviceList := strings.Join(host.LVMConfig.PVDevices, " ")
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("vgcreate %s %s", vgName, deviceList))
-			output, err := sshCmd.CombinedOutput()
-			if err != nil {
-				if strings.Contains(string(output), "already exists") {
-					if l.logger != nil { l.logger.Info("主机 %s: 卷组 %s 已存在", host.IP, vgName) }
-				} else {
-					return fmt.Errorf("主机[%d] %s: 创建卷组 %s 失败: %v - %s", 
-						i, host.IP, vgName, err, strings.TrimSpace(string(output)))
-				}
+	// 创建卷组
+	// 先检查卷组是否已存在
+	sshCmd = l.buildSSHCommand(host, fmt.Sprintf("vgs %s --noheadings 2>/dev/null", vgName))
+	if err := sshCmd.Run(); err == nil {
+		if l.logger != nil { l.logger.Info("主机 %s: 卷组 %s 已存在，跳过创建", host.IP, vgName) }
+	} else {
+		if l.logger != nil { l.logger.Info("主机 %s: 创建卷组 %s", host.IP, vgName) }
+		deviceList := strings.Join(host.LVMConfig.PVDevices, " ")
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("vgcreate %s %s", vgName, deviceList))
+		output, err := sshCmd.CombinedOutput()
+		if err != nil {
+			if strings.Contains(string(output), "already exists") {
+				if l.logger != nil { l.logger.Info("主机 %s: 卷组 %s 已存在", host.IP, vgName) }
 			} else {
-				if l.logger != nil { l.logger.Info("主机 %s: 成功创建卷组 %s", host.IP, vgName) }
+				return fmt.Errorf("主机[%d] %s: 创建卷组 %s 失败: %v - %s", 
+					i, host.IP, vgName, err, strings.TrimSpace(string(output)))
 			}
+		} else {
+			if l.logger != nil { l.logger.Info("主机 %s: 成功创建卷组 %s", host.IP, vgName) }
 		}
+	}
 
-		// 创建逻辑卷
-		for _, lv := range host.LVMConfig.LVs {
-			// 先检查逻辑卷是否已存在
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("lvs %s/%s --noheadings 2>/dev/null", vgName, lv.LVName))
-			if err := sshCmd.Run(); err == nil {
-				if l.logger != nil { l.logger.Info("主机 %s: 逻辑卷 %s 已存在，跳过创建", host.IP, lv.LVName) }
-				continue
-			}
+	// 创建逻辑卷
+	for _, lv := range host.LVMConfig.LVs {
+		// 先检查逻辑卷是否已存在
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("lvs %s/%s --noheadings 2>/dev/null", vgName, lv.LVName))
+		if err := sshCmd.Run(); err == nil {
+			if l.logger != nil { l.logger.Info("主机 %s: 逻辑卷 %s 已存在，跳过创建", host.IP, lv.LVName) }
+			continue
+		}
 
-			if l.logger != nil { l.logger.Info("主机 %s: 创建逻辑卷 %s，大小 %s", host.IP, lv.LVName, lv.Size) }
-			
-			// 获取可用空间信息
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("vgs %s --noheadings --units g --nosuffix -o vg_free", vgName))
-			freeOutput, err := sshCmd.Output()
-			if err != nil {
-				return fmt.Errorf("主机[%d] %s: 无法获取卷组 %s 的可用空间信息: %v", i, host.IP, vgName, err)
-			}
-			
-			freeSpaceStr := strings.TrimSpace(string(freeOutput))
-			if l.logger != nil { l.logger.Info("主机 %s: 卷组 %s 可用空间: %s GB", host.IP, vgName, freeSpaceStr) }
-			
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("lvcreate -n %s -L %s %s", lv.LVName, lv.Size, vgName))
-			output, err := sshCmd.CombinedOutput()
-			if err != nil {
-				if strings.Contains(string(output), "not enough free space") || 
-				   strings.Contains(string(output), "insufficient free space") {
-					return fmt.Errorf("主机[%d] %s: 创建逻辑卷 %s 失败 - 空间不足。请求大小: %s，可用空间: %s GB。请调整配置文件中的逻辑卷大小", 
-						i, host.IP, lv.LVName, lv.Size, freeSpaceStr)
-				} else if strings.Contains(string(output), "already exists") {
-					if l.logger != nil { l.logger.Info("主机 %s: 逻辑卷 %s 已存在", host.IP, lv.LVName) }
-				} else {
-					return fmt.Errorf("主机[%d] %s: 创建逻辑卷 %s 失败: %v - %s", 
-						i, host.IP, lv.LVName, err, strings.TrimSpace(string(output)))
-				}
+		if l.logger != nil { l.logger.Info("主机 %s: 创建逻辑卷 %s，大小 %s", host.IP, lv.LVName, lv.Size) }
+		
+		// 获取可用空间信息
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("vgs %s --noheadings --units g --nosuffix -o vg_free", vgName))
+		freeOutput, err := sshCmd.Output()
+		if err != nil {
+			return fmt.Errorf("主机[%d] %s: 无法获取卷组 %s 的可用空间信息: %v", i, host.IP, vgName, err)
+		}
+		
+		freeSpaceStr := strings.TrimSpace(string(freeOutput))
+		if l.logger != nil { l.logger.Info("主机 %s: 卷组 %s 可用空间: %s GB", host.IP, vgName, freeSpaceStr) }
+		
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("lvcreate -n %s -L %s %s", lv.LVName, lv.Size, vgName))
+		output, err := sshCmd.CombinedOutput()
+		if err != nil {
+			if strings.Contains(string(output), "not enough free space") || 
+			   strings.Contains(string(output), "insufficient free space") {
+				return fmt.Errorf("主机[%d] %s: 创建逻辑卷 %s 失败 - 空间不足。请求大小: %s，可用空间: %s GB。请调整配置文件中的逻辑卷大小", 
+					i, host.IP, lv.LVName, lv.Size, freeSpaceStr)
+			} else if strings.Contains(string(output), "already exists") {
+				if l.logger != nil { l.logger.Info("主机 %s: 逻辑卷 %s 已存在", host.IP, lv.LVName) }
 			} else {
-				if l.logger != nil { l.logger.Info("主机 %s: 成功创建逻辑卷 %s", host.IP, lv.LVName) }
+				return fmt.Errorf("主机[%d] %s: 创建逻辑卷 %s 失败: %v - %s", 
+					i, host.IP, lv.LVName, err, strings.TrimSpace(string(output)))
 			}
+		} else {
+			if l.logger != nil { l.logger.Info("主机 %s: 成功创建逻辑卷 %s", host.IP, lv.LVName) }
 		}
+	}
 
-		// 格式化并挂载逻辑卷
-		for _, lv := range host.LVMConfig.LVs {
-			devicePath := fmt.Sprintf("/dev/%s/%s", vgName, lv.LVName)
-			mountPoint := l.getMountPoint(lv.LVName, &lv)
+	// 格式化并挂载逻辑卷
+	var fstabEntries []string
+	for _, lv := range host.LVMConfig.LVs {
+		devicePath := fmt.Sprintf("/dev/%s/%s", vgName, lv.LVName)
+		mountPoint := l.getMountPoint(lv.LVName, &lv)
 
-			// 检查逻辑卷是否存在
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("test -e %s", devicePath))
-			if err := sshCmd.Run(); err != nil {
-				if l.logger != nil { l.logger.Warn("主机 %s: 逻辑卷设备 %s 不存在，跳过格式化和挂载", host.IP, devicePath) }
-				continue
-			}
+		// 检查逻辑卷是否存在
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("test -e %s", devicePath))
+		if err := sshCmd.Run(); err != nil {
+			if l.logger != nil { l.logger.Warn("主机 %s: 逻辑卷设备 %s 不存在，跳过格式化和挂载", host.IP, devicePath) }
+			continue
+		}
 
-			// 检查文件系统是否已存在
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("blkid %s", devicePath))
-			output, err := sshCmd.Output()
-			if err != nil || !strings.Contains(string(output), "xfs") {
-				if l.logger != nil { l.logger.Info("主机 %s: 格式化逻辑卷 %s 为XFS文件系统", host.IP, lv.LVName) }
-				sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mkfs.xfs -f %s", devicePath))
-				output, err := sshCmd.CombinedOutput()
-				if err != nil {
-					if l.logger != nil { l.logger.Warn("主机 %s: 格式化逻辑卷 %s 失败: %v - %s", 
-						host.IP, lv.LVName, err, strings.TrimSpace(string(output))) }
-				} else {
-					if l.logger != nil { l.logger.Info("主机 %s: 成功格式化逻辑卷 %s", host.IP, lv.LVName) }
-				}
+		// 检查文件系统是否已存在
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("blkid %s", devicePath))
+		output, err := sshCmd.Output()
+		if err != nil || !strings.Contains(string(output), "xfs") {
+			if l.logger != nil { l.logger.Info("主机 %s: 格式化逻辑卷 %s 为XFS文件系统", host.IP, lv.LVName) }
+			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mkfs.xfs -f %s", devicePath))
+			output, err := sshCmd.CombinedOutput()
+			if err != nil {
+				if l.logger != nil { l.logger.Warn("主机 %s: 格式化逻辑卷 %s 失败: %v - %s", 
+					host.IP, lv.LVName, err, strings.TrimSpace(string(output))) }
 			} else {
-				if l.logger != nil { l.logger.Info("主机 %s: 逻辑卷 %s 已格式化为XFS，跳过格式化", host.IP, lv.LVName) }
+				if l.logger != nil { l.logger.Info("主机 %s: 成功格式化逻辑卷 %s", host.IP, lv.LVName) }
 			}
+		} else {
+			if l.logger != nil { l.logger.Info("主机 %s: 逻辑卷 %s 已格式化为XFS，跳过格式化", host.IP, lv.LVName) }
+		}
 
-			// 创建挂载点
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mkdir -p %s", mountPoint))
-			sshCmd.Run() // 忽略错误，目录可能已存在
-
-			// 检查是否已挂载
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mountpoint -q %s", mountPoint))
-			if err := sshCmd.Run(); err == nil {
-				if l.logger != nil { l.logger.Info("主机 %s: 挂载点 %s 已被挂载，跳过挂载", host.IP, mountPoint) }
-			} else {
-				// 挂载逻辑卷
-				if l.logger != nil { l.logger.Info("主机 %s: 挂载逻辑卷 %s 到 %s", host.IP, lv.LVName, mountPoint) }
-				sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mount %s %s", devicePath, mountPoint))
-				output, err := sshCmd.CombinedOutput()
-				if err != nil {
-					if l.logger != nil { l.logger.Warn("主机 %s: 挂载逻辑卷 %s 失败: %v - %s", 
-						host.IP, lv.LVName, err, strings.TrimSpace(string(output))) }
-				} else {
-					if l.logger != nil { l.logger.Info("主机 %s: 成功挂载逻辑卷 %s", host.IP, lv.LVName) }
-				}
-			}
+		// 创建挂载点
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mkdir -p %s", mountPoint))
+		sshCmd.Run() // 忽略错误，目录可能已存在
 
-			// 添加到 /etc/fstab（避免重复添加）
-			fstabEntry := fmt.Sprintf("%s %s xfs defaults 0 0", devicePath, mountPoint)
-			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("grep -q '%s' /etc/fstab", fstabEntry))
-			if err := sshCmd.Run(); err != nil {
-				if l.logger != nil { l.logger.Info("主机 %s: 添加 %s 到 /etc/fstab", host.IP, lv.LVName) }
-				sshCmd = l.buildSSHCommand(host, fmt.Sprintf("echo '%s' >> /etc/fstab", fstabEntry))
-				if err := sshCmd.Run(); err != nil {
-					if l.logger != nil { l.logger.Warn("主机 %s: 添加 %s 到 /etc/fstab 失败", host.IP, lv.LVName) }
-				} else {
-					if l.logger != nil { l.logger.Info("主机 %s: 成功添加 %s 到 /etc/fstab", host.IP, lv.LVName) }
-				}
+		// 检查是否已挂载
+		sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mountpoint -q %s", mountPoint))
+		if err := sshCmd.Run(); err == nil {
+			if l.logger != nil { l.logger.Info("主机 %s: 挂载点 %s 已被挂载，跳过挂载", host.IP, mountPoint) }
+		} else {
+			// 挂载逻辑卷
+			if l.logger != nil { l.logger.Info("主机 %s: 挂载逻辑卷 %s 到 %s", host.IP, lv.LVName, mountPoint) }
+			sshCmd = l.buildSSHCommand(host, fmt.Sprintf("mount -o %s %s %s", config.LVMountOptions(lv), devicePath, mountPoint))
+			output, err := sshCmd.CombinedOutput()
+			if err != nil {
+				if l.logger != nil { l.logger.Warn("主机 %s: 挂载逻辑卷 %s 失败: %v - %s", 
+					host.IP, lv.LVName, err, strings.TrimSpace(string(output))) }
 			} else {
-				if l.logger != nil { l.logger.Info("主机 %s: %s 已存在于 /etc/fstab 中", host.IP, lv.LVName) }
+				if l.logger != nil { l.logger.Info("主机 %s: 成功挂载逻辑卷 %s", host.IP, lv.LVName) }
 			}
 		}
 
-		if l.logger != nil { l.logger.Info("主机 %s: LVM配置完成", host.IP) }
+		fstabEntries = append(fstabEntries, fmt.Sprintf("%s %s xfs %s 0 0", devicePath, mountPoint, config.LVMountOptions(lv)))
 	}
 
+	// 添加到 /etc/fstab
+	l.writeFstabBlock(host, fstabEntries)
+
+	if l.logger != nil { l.logger.Info("主机 %s: LVM配置完成", host.IP) }
+
 	return nil
 }
 
+// writeFstabBlock 将逻辑卷挂载写入 /etc/fstab 中由roi管理的区块，重复执行时替换区块；
+// 同时清理旧版本直接追加的相同挂载行，写入失败只告警，不影响已完成的挂载
+func (l *LVM) writeFstabBlock(host config.Host, entries []string) {
+	if len(entries) == 0 {
+		return
+	}
+	changed, err := ssh.WriteManagedBlock(host, l.buildSSHCommand, ssh.ManagedBlock{
+		Path:    "/etc/fstab",
+		Name:    "lvm",
+		Content: strings.Join(entries, "\n"),
+		Dedupe:  true,
+	})
+	if err != nil {
+		if l.logger != nil { l.logger.Warn("主机 %s: 写入 /etc/fstab 失败: %v", host.IP, err) }
+	} else if changed {
+		if l.logger != nil { l.logger.Info("主机 %s: 已更新 /etc/fstab 中的逻辑卷挂载（原文件备份为 /etc/fstab.roi-bak）", host.IP) }
+	} else {
+		if l.logger != nil { l.logger.Info("主机 %s: /etc/fstab 中的逻辑卷挂载无需变更", host.IP) }
+	}
+}
+
 // printVerticalResultsTable 打印纵向 LVM 状态表格
 func (l *LVM) printVerticalResultsTable(results map[string]*LVMStatus) {
 	if l.logger != nil { l.logger.Info("\n" + strings.Repeat("=", 80)) }
@@ -864,7 +914,7 @@ func (l *LVM) printVerticalResultsTable(results map[string]*LVMStatus) {
 			}
 		}
 
-		if l.logger != nil { l.logger.Info(fmt.Sprintf("└" + strings.Repeat("─", 50))) }
+		if l.logger != nil { l.logger.Info("└" + strings.Repeat("─", 50)) }
 	}
 
 	if l.logger != nil { l.logger.Info("\n" + strings.Repeat("=", 80)) }
@@ -874,61 +924,76 @@ func (l *LVM) printVerticalResultsTable(results map[string]*LVMStatus) {
 	if l.logger != nil { l.logger.Info("") }
 }
 
-// buildSSHCommand 构建 SSH 命令
-func (l *LVM) buildSSHCommand(host config.Host, command string) *exec.Cmd {
+// SetRunner 替换执行LVM命令的 Runner，测试中可模拟 vgs、lvs、df 的输出来构造卷组和逻辑卷状态
+func (l *LVM) SetRunner(runner ssh.Runner) {
+	l.runner = runner
+}
+
+// buildSSHCommand 构建在主机上执行的LVM命令，设置了 Runner 时交由 Runner 执行
+func (l *LVM) buildSSHCommand(host config.Host, command string) *ssh.Command {
+	if l.runner != nil {
+		return ssh.NewRunnerCommand(l.runner, host, command)
+	}
+	return l.sshCommand(host, command)
+}
+
+// pathExists 通过 test -e 检查远程路径是否存在，退出码1表示不存在，命令未能执行时返回错误
+func (l *LVM) pathExists(host config.Host, path string) (bool, error) {
+	err := l.buildSSHCommand(host, fmt.Sprintf("test -e %s", path)).Run()
+	if err == nil {
+		return true, nil
+	}
+	if ssh.HasExitCode(err, 1) {
+		return false, nil
+	}
+	return false, err
+}
+
+// sshCommand 构建系统ssh命令，创建PV/VG/LV和格式化需要root权限，非root用户依赖become配置
+func (l *LVM) sshCommand(host config.Host, command string) *ssh.Command {
 	var sshCmd *exec.Cmd
+	command = ssh.WrapBecome(host, command)
 
 	if host.Password != "" {
-		// 检查 sshpass 是否可用
-		if _, err := exec.LookPath("sshpass"); err != nil {
-			if l.logger != nil { l.logger.Warn("sshpass not found. Please install sshpass or use SSH key authentication for host %s", host.IP) }
-			sshCmd = exec.Command("ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "BatchMode=no",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		} else {
-			sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		}
+		// sshpass 已在启动时检查
+		sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
+			fmt.Sprintf("%s@%s", host.User, host.IP),
+			command)
 	} else if host.SSHKey != "" {
 		sshCmd = exec.Command("ssh",
 			"-i", host.SSHKey,
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	} else {
 		sshCmd = exec.Command("ssh",
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	}
 
-	return sshCmd
+	return ssh.NewCommand(host, command, sshCmd)
 }
 
 // getMountPoint 根据逻辑卷名称获取挂载点
 func (l *LVM) getMountPoint(lvName string, configLV *config.LogicalVolume) string {
 	// 如果配置中指定了挂载点，使用配置的
-	if configLV != nil && configLV.MountPoint != "" {
-		return configLV.MountPoint
+	if configLV != nil {
+		return config.LVMountPoint(*configLV)
 	}
 
 	// 否则使用默认挂载点
-	switch lvName {
-	case "lv_docker":
-		return "/var/lib/docker"
-	case "lv_containerd":
-		return "/var/lib/containerd"
-	default:
-		return fmt.Sprintf("/mnt/%s", lvName)
-	}
+	return config.LVMountPoint(config.LogicalVolume{LVName: lvName})
 }
 
 // printResultsTable 打印 LVM 状态表格
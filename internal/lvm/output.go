@@ -0,0 +1,52 @@
+package lvm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 状态输出格式
+const (
+	OutputTable = "table" // 人类可读的表格（默认）
+	OutputJSON  = "json"
+	OutputYAML  = "yaml"
+)
+
+// SetOutputFormat 设置LVM状态的输出格式：table、json、yaml
+func (l *LVM) SetOutputFormat(format string) error {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "", OutputTable:
+		l.outputFormat = OutputTable
+	case OutputJSON:
+		l.outputFormat = OutputJSON
+	case OutputYAML, "yml":
+		l.outputFormat = OutputYAML
+	default:
+		return fmt.Errorf("不支持的输出格式 '%s'，可选值: table, json, yaml", format)
+	}
+	return nil
+}
+
+// printResults 按输出格式打印LVM状态，table格式使用传入的表格打印函数
+func (l *LVM) printResults(results map[string]*LVMStatus, printTable func(map[string]*LVMStatus)) error {
+	switch l.outputFormat {
+	case OutputJSON:
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化LVM状态为JSON失败: %w", err)
+		}
+		fmt.Println(string(data))
+	case OutputYAML:
+		data, err := yaml.Marshal(results)
+		if err != nil {
+			return fmt.Errorf("序列化LVM状态为YAML失败: %w", err)
+		}
+		fmt.Print(string(data))
+	default:
+		printTable(results)
+	}
+	return nil
+}
@@ -0,0 +1,371 @@
+package lvm
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// PlanAction LVM变更计划中的一项操作
+type PlanAction struct {
+	Operation   string `json:"operation" yaml:"operation"`                 // pvcreate、vgcreate、lvcreate、mkfs、mount、fstab
+	Target      string `json:"target" yaml:"target"`                       // 设备、卷组、逻辑卷或挂载点
+	Command     string `json:"command,omitempty" yaml:"command,omitempty"` // 执行时运行的命令，跳过的操作为空
+	Detail      string `json:"detail" yaml:"detail"`
+	Skip        bool   `json:"skip" yaml:"skip"`               // 当前状态已满足，执行时跳过
+	Destructive bool   `json:"destructive" yaml:"destructive"` // 会覆盖设备或文件系统上已有的数据
+}
+
+// HostPlan 单个主机的LVM变更计划
+type HostPlan struct {
+	IP       string       `json:"ip" yaml:"ip"`
+	VGName   string       `json:"vg_name" yaml:"vg_name"`
+	Actions  []PlanAction `json:"actions" yaml:"actions"`
+	Warnings []string     `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+}
+
+// Changes 返回计划中需要执行的操作数和其中具有破坏性的操作数
+func (p HostPlan) Changes() (changes, destructive int) {
+	for _, action := range p.Actions {
+		if action.Skip {
+			continue
+		}
+		changes++
+		if action.Destructive {
+			destructive++
+		}
+	}
+	return changes, destructive
+}
+
+// Plan 根据配置和节点当前的LVM状态计算每个主机将执行的操作，只执行只读命令，不做任何修改
+// 计划与 createHostLVM 的执行逻辑一致：已存在的物理卷、卷组、逻辑卷和挂载都会被跳过
+func (l *LVM) Plan() ([]HostPlan, error) {
+	var plans []HostPlan
+	for _, host := range l.config.Hosts {
+		if host.LVMConfig == nil || len(host.LVMConfig.PVDevices) == 0 {
+			continue
+		}
+		if l.logger != nil {
+			l.logger.Info("主机 %s: 计算LVM变更计划...", host.IP)
+		}
+		plans = append(plans, l.planHost(host))
+	}
+	if len(plans) == 0 {
+		return nil, fmt.Errorf("no LVM configuration found in any host")
+	}
+	return plans, nil
+}
+
+// ShowPlan 打印每个主机的LVM变更计划，不执行任何操作
+func (l *LVM) ShowPlan() error {
+	plans, err := l.Plan()
+	if err != nil {
+		return err
+	}
+
+	switch l.outputFormat {
+	case OutputJSON:
+		data, err := json.MarshalIndent(plans, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化LVM变更计划为JSON失败: %w", err)
+		}
+		fmt.Println(string(data))
+	case OutputYAML:
+		data, err := yaml.Marshal(plans)
+		if err != nil {
+			return fmt.Errorf("序列化LVM变更计划为YAML失败: %w", err)
+		}
+		fmt.Print(string(data))
+	default:
+		printPlans(plans)
+	}
+	return nil
+}
+
+func printPlans(plans []HostPlan) {
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Println("                        LVM 变更计划（未执行任何操作）")
+	fmt.Println(strings.Repeat("=", 80))
+
+	totalChanges, totalDestructive := 0, 0
+	for _, plan := range plans {
+		changes, destructive := plan.Changes()
+		totalChanges += changes
+		totalDestructive += destructive
+
+		fmt.Printf("\n主机 %s（卷组 %s）: %d 项变更", plan.IP, plan.VGName, changes)
+		if destructive > 0 {
+			fmt.Printf("，\033[31m%d 项具有破坏性\033[0m", destructive)
+		}
+		fmt.Println()
+		for _, action := range plan.Actions {
+			action.Detail = strings.ReplaceAll(action.Detail, "\n", "\n      ")
+			switch {
+			case action.Skip:
+				fmt.Printf("  \033[90m= %-8s %s: %s\033[0m\n", action.Operation, action.Target, action.Detail)
+			case action.Destructive:
+				fmt.Printf("  \033[31m! %-8s %s: %s\033[0m\n", action.Operation, action.Target, action.Detail)
+			default:
+				fmt.Printf("  \033[32m+ %-8s %s: %s\033[0m\n", action.Operation, action.Target, action.Detail)
+			}
+			if action.Command != "" && !action.Skip {
+				fmt.Printf("      $ %s\n", action.Command)
+			}
+		}
+		for _, warning := range plan.Warnings {
+			fmt.Printf("  \033[33m⚠ %s\033[0m\n", warning)
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(strings.Repeat("=", 80))
+	fmt.Printf("共 %d 个主机，%d 项变更", len(plans), totalChanges)
+	if totalDestructive > 0 {
+		fmt.Printf("，\033[31m%d 项具有破坏性（会覆盖已有数据），执行前请确认\033[0m", totalDestructive)
+	}
+	fmt.Println()
+	fmt.Println("图例: + 将执行  ! 将执行且覆盖已有数据  = 已满足，跳过")
+	fmt.Println(strings.Repeat("=", 80))
+}
+
+// planHost 探测单个主机的设备、卷组、逻辑卷、挂载和fstab状态，生成变更计划
+func (l *LVM) planHost(host config.Host) HostPlan {
+	vgName := host.LVMConfig.VGName
+	if vgName == "" {
+		vgName = "vg_rainbond"
+	}
+	plan := HostPlan{IP: host.IP, VGName: vgName}
+
+	lvmTools := l.buildSSHCommand(host, "which lvm").Run() == nil
+	if !lvmTools {
+		if l.config.LVM.AutoInstallTools {
+			plan.Warnings = append(plan.Warnings, "未找到LVM工具，执行时将自动安装lvm2；以下计划按尚无任何物理卷和卷组计算")
+		} else {
+			plan.Warnings = append(plan.Warnings, "未找到LVM工具且未开启 lvm.auto_install_tools，执行时将失败")
+		}
+	}
+
+	// 物理卷
+	var deviceBytes int64
+	for _, device := range host.LVMConfig.PVDevices {
+		exists, err := l.pathExists(host, device)
+		if err != nil {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("检查设备 %s 失败: %v", device, err))
+			continue
+		}
+		if !exists {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("设备 %s 不存在，执行时将失败", device))
+			continue
+		}
+		size := l.probeOutput(host, fmt.Sprintf("lsblk -b -d -n -o SIZE %s 2>/dev/null | head -1", device))
+		if bytes, err := strconv.ParseInt(size, 10, 64); err == nil {
+			deviceBytes += bytes
+			size = formatGiB(float64(bytes) / (1 << 30))
+		}
+
+		if lvmTools {
+			if out, err := l.buildSSHCommand(host, fmt.Sprintf("pvs %s --noheadings -o vg_name 2>/dev/null", device)).Output(); err == nil {
+				pvVG := strings.TrimSpace(string(out))
+				plan.Actions = append(plan.Actions, PlanAction{Operation: "pvcreate", Target: device, Skip: true,
+					Detail: fmt.Sprintf("已是物理卷（%s）", describeVG(pvVG))})
+				if pvVG != "" && pvVG != vgName {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf("设备 %s 已属于卷组 %s，不会加入卷组 %s", device, pvVG, vgName))
+				}
+				continue
+			}
+		}
+
+		action := PlanAction{Operation: "pvcreate", Target: device, Command: fmt.Sprintf("pvcreate %s", device),
+			Detail: fmt.Sprintf("初始化为物理卷，大小 %s", size)}
+		if signatures := l.deviceSignatures(host, device); signatures != "" {
+			action.Destructive = true
+			action.Detail += fmt.Sprintf("，设备上已有 %s，数据将被覆盖", signatures)
+		}
+		plan.Actions = append(plan.Actions, action)
+	}
+
+	// 卷组
+	vgFreeGiB := float64(deviceBytes) / (1 << 30)
+	vgExists := false
+	if lvmTools {
+		if out, err := l.buildSSHCommand(host, fmt.Sprintf("vgs %s --noheadings --units g --nosuffix -o vg_size,vg_free 2>/dev/null", vgName)).Output(); err == nil {
+			vgExists = true
+			fields := strings.Fields(string(out))
+			members := strings.Fields(l.probeOutput(host, fmt.Sprintf("pvs --noheadings -o pv_name -S vg_name=%s 2>/dev/null", vgName)))
+			detail := fmt.Sprintf("已存在，成员 %s", strings.Join(members, " "))
+			if len(fields) >= 2 {
+				vgFreeGiB, _ = strconv.ParseFloat(fields[1], 64)
+				detail += fmt.Sprintf("，总大小 %sG，可用 %sG", fields[0], fields[1])
+			}
+			plan.Actions = append(plan.Actions, PlanAction{Operation: "vgcreate", Target: vgName, Skip: true, Detail: detail})
+			for _, device := range host.LVMConfig.PVDevices {
+				if !containsString(members, device) {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf("设备 %s 不在已有卷组 %s 中，执行时不会自动扩展卷组（需手动 vgextend）", device, vgName))
+				}
+			}
+		}
+	}
+	if !vgExists {
+		deviceList := strings.Join(host.LVMConfig.PVDevices, " ")
+		plan.Actions = append(plan.Actions, PlanAction{Operation: "vgcreate", Target: vgName,
+			Command: fmt.Sprintf("vgcreate %s %s", vgName, deviceList),
+			Detail:  fmt.Sprintf("由 %s 组成，约 %s", deviceList, formatGiB(vgFreeGiB))})
+	}
+
+	// 逻辑卷、文件系统和挂载
+	var fstabEntries []string
+	for _, lv := range host.LVMConfig.LVs {
+		devicePath := fmt.Sprintf("/dev/%s/%s", vgName, lv.LVName)
+		mountPoint := l.getMountPoint(lv.LVName, &lv)
+		fstabEntries = append(fstabEntries, fmt.Sprintf("%s %s xfs %s 0 0", devicePath, mountPoint, config.LVMountOptions(lv)))
+
+		lvExists := false
+		if vgExists {
+			if out, err := l.buildSSHCommand(host, fmt.Sprintf("lvs %s/%s --noheadings --units g --nosuffix -o lv_size 2>/dev/null", vgName, lv.LVName)).Output(); err == nil {
+				lvExists = true
+				current := strings.TrimSpace(string(out))
+				detail := fmt.Sprintf("已存在，大小 %sG", current)
+				if want, ok := lvSizeGiB(lv.Size); ok {
+					if have, err := strconv.ParseFloat(current, 64); err == nil && !sizeClose(have, want) {
+						detail += fmt.Sprintf("（配置为 %s，执行时不会调整大小）", lv.Size)
+					}
+				}
+				plan.Actions = append(plan.Actions, PlanAction{Operation: "lvcreate", Target: lv.LVName, Skip: true, Detail: detail})
+			}
+		}
+		if !lvExists {
+			action := PlanAction{Operation: "lvcreate", Target: lv.LVName,
+				Command: fmt.Sprintf("lvcreate -n %s -L %s %s", lv.LVName, lv.Size, vgName),
+				Detail:  fmt.Sprintf("大小 %s", lv.Size)}
+			if want, ok := lvSizeGiB(lv.Size); ok {
+				if want > vgFreeGiB+0.01 {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf("逻辑卷 %s 需要 %s，卷组剩余约 %s，执行时将因空间不足失败", lv.LVName, lv.Size, formatGiB(vgFreeGiB)))
+				}
+				vgFreeGiB -= want
+			}
+			plan.Actions = append(plan.Actions, action)
+		}
+
+		// 执行时对非XFS的逻辑卷使用 mkfs.xfs -f，已有其他文件系统会被覆盖
+		fsType := ""
+		if lvExists {
+			fsType = l.probeOutput(host, fmt.Sprintf("blkid -o value -s TYPE %s 2>/dev/null", devicePath))
+		}
+		switch fsType {
+		case "xfs":
+			plan.Actions = append(plan.Actions, PlanAction{Operation: "mkfs", Target: devicePath, Skip: true, Detail: "已格式化为XFS"})
+		case "":
+			plan.Actions = append(plan.Actions, PlanAction{Operation: "mkfs", Target: devicePath,
+				Command: fmt.Sprintf("mkfs.xfs -f %s", devicePath), Detail: "格式化为XFS"})
+		default:
+			plan.Actions = append(plan.Actions, PlanAction{Operation: "mkfs", Target: devicePath, Destructive: true,
+				Command: fmt.Sprintf("mkfs.xfs -f %s", devicePath),
+				Detail:  fmt.Sprintf("已有 %s 文件系统，将被重新格式化为XFS，数据将丢失", fsType)})
+		}
+
+		if l.buildSSHCommand(host, fmt.Sprintf("mountpoint -q %s", mountPoint)).Run() == nil {
+			plan.Actions = append(plan.Actions, PlanAction{Operation: "mount", Target: mountPoint, Skip: true, Detail: "已挂载"})
+		} else {
+			action := PlanAction{Operation: "mount", Target: mountPoint,
+				Command: fmt.Sprintf("mkdir -p %s && mount -o %s %s %s", mountPoint, config.LVMountOptions(lv), devicePath, mountPoint),
+				Detail:  fmt.Sprintf("挂载 %s", devicePath)}
+			if entries := l.probeOutput(host, fmt.Sprintf("ls -A %s 2>/dev/null | wc -l", mountPoint)); entries != "" && entries != "0" {
+				action.Detail += fmt.Sprintf("，目录中已有 %s 个文件，挂载后将被遮盖", entries)
+			}
+			plan.Actions = append(plan.Actions, action)
+		}
+	}
+
+	// fstab 中由roi管理的区块
+	if len(fstabEntries) > 0 {
+		want := strings.Join(fstabEntries, "\n")
+		current := l.probeOutput(host, "awk '$0 == \"# BEGIN roi lvm\" {in_block = 1; next} $0 == \"# END roi lvm\" {in_block = 0; next} in_block' /etc/fstab 2>/dev/null")
+		if current == want {
+			plan.Actions = append(plan.Actions, PlanAction{Operation: "fstab", Target: "/etc/fstab", Skip: true, Detail: "roi lvm 区块无需变更"})
+		} else {
+			plan.Actions = append(plan.Actions, PlanAction{Operation: "fstab", Target: "/etc/fstab",
+				Detail: "写入 roi lvm 区块（原文件备份为 /etc/fstab.roi-bak）:\n" + want})
+		}
+	}
+
+	return plan
+}
+
+// deviceSignatures 返回设备上已有的文件系统、分区表或分区，为空表示设备是空白的
+func (l *LVM) deviceSignatures(host config.Host, device string) string {
+	var found []string
+	if fsType := l.probeOutput(host, fmt.Sprintf("blkid -p -o value -s TYPE %s 2>/dev/null", device)); fsType != "" {
+		found = append(found, fsType+" 签名")
+	}
+	if ptType := l.probeOutput(host, fmt.Sprintf("blkid -p -o value -s PTTYPE %s 2>/dev/null", device)); ptType != "" {
+		found = append(found, ptType+" 分区表")
+	}
+	if parts := l.probeOutput(host, fmt.Sprintf("lsblk -n -o NAME %s 2>/dev/null | tail -n +2 | wc -l", device)); parts != "" && parts != "0" {
+		found = append(found, parts+" 个分区")
+	}
+	return strings.Join(found, "、")
+}
+
+// probeOutput 执行只读探测命令，失败时返回空字符串
+func (l *LVM) probeOutput(host config.Host, command string) string {
+	out, err := l.buildSSHCommand(host, command).Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+func describeVG(vgName string) string {
+	if vgName == "" {
+		return "未加入卷组"
+	}
+	return "属于卷组 " + vgName
+}
+
+// lvSizeGiB 将规范化后的逻辑卷大小转换为GiB，未带单位时按lvcreate默认的MiB计算
+func lvSizeGiB(size string) (float64, bool) {
+	units := map[byte]float64{
+		'B': 1.0 / (1 << 30), 'S': 512.0 / (1 << 30), 'K': 1.0 / (1 << 20), 'M': 1.0 / (1 << 10),
+		'G': 1, 'T': 1 << 10, 'P': 1 << 20, 'E': 1 << 30,
+	}
+	multiplier := units['M']
+	number := size
+	if n := len(size); n > 0 {
+		if unit, ok := units[size[n-1]]; ok {
+			multiplier = unit
+			number = size[:n-1]
+		}
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, false
+	}
+	return value * multiplier, true
+}
+
+// sizeClose 判断两个GiB大小是否一致，允许lvs输出四舍五入和extent对齐造成的误差
+func sizeClose(a, b float64) bool {
+	diff := a - b
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= 0.01*b+0.01
+}
+
+func formatGiB(gib float64) string {
+	return fmt.Sprintf("%.2fG", gib)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
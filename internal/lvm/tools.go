@@ -0,0 +1,39 @@
+package lvm
+
+import (
+	"fmt"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/pkgmgr"
+)
+
+// ensureLVMTools 检查LVM工具是否可用，开启 lvm.auto_install_tools 时通过包管理器安装lvm2
+func (l *LVM) ensureLVMTools(host config.Host) error {
+	if err := l.buildSSHCommand(host, "which lvm").Run(); err == nil {
+		return nil
+	}
+
+	if !l.config.LVM.AutoInstallTools {
+		return fmt.Errorf("LVM tools not found, please install lvm2 package or set lvm.auto_install_tools: true")
+	}
+
+	if l.logger != nil {
+		l.logger.Info("主机 %s: 未找到LVM工具，自动安装lvm2", host.IP)
+	}
+
+	packages := pkgmgr.New(pkgmgr.RunnerFunc(func(h config.Host, command string) ([]byte, error) {
+		return l.buildSSHCommand(h, command).CombinedOutput()
+	}))
+	if err := packages.InstallPackages(host, "lvm2"); err != nil {
+		return fmt.Errorf("LVM tools not found and automatic lvm2 installation failed (no package repository access?): %w", err)
+	}
+
+	if err := l.buildSSHCommand(host, "which lvm").Run(); err != nil {
+		return fmt.Errorf("lvm2 installed but lvm command is still not available")
+	}
+
+	if l.logger != nil {
+		l.logger.Info("主机 %s: lvm2安装完成", host.IP)
+	}
+	return nil
+}
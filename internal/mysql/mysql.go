@@ -2,16 +2,23 @@ package mysql
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
-	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+	"github.com/rainbond/rainbond-offline-installer/internal/templates"
 	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -22,300 +29,6 @@ import (
 	"k8s.io/client-go/tools/clientcmd"
 )
 
-const mysqlMasterYAML = `---
-# MySQL Master Service
-apiVersion: v1
-kind: Service
-metadata:
-  name: mysql-master
-  namespace: rbd-system
-  labels:
-    app: mysql-master
-spec:
-  type: ClusterIP
-  ports:
-    - port: 3306
-      targetPort: 3306
-      protocol: TCP
-  selector:
-    app: mysql-master
-
----
-# MySQL Master StatefulSet
-apiVersion: apps/v1
-kind: StatefulSet
-metadata:
-  name: mysql-master
-  namespace: rbd-system
-  labels:
-    app: mysql-master
-spec:
-  serviceName: mysql-master
-  replicas: 1
-  selector:
-    matchLabels:
-      app: mysql-master
-  template:
-    metadata:
-      labels:
-        app: mysql-master
-    spec:
-      nodeName: "%s"
-      containers:
-      - name: mysql
-        image: registry.cn-hangzhou.aliyuncs.com/goodrain/mysql:8.0.34-bitnami
-        ports:
-        - containerPort: 3306
-        env:
-        - name: MYSQL_ROOT_PASSWORD
-          value: "%s"
-        - name: MYSQL_REPLICATION_MODE
-          value: "master"
-        - name: MYSQL_REPLICATION_USER
-          value: "%s"
-        - name: MYSQL_REPLICATION_PASSWORD
-          value: "%s"
-        - name: MYSQL_AUTHENTICATION_PLUGIN
-          value: "mysql_native_password"
-        volumeMounts:
-        - name: mysql-data
-          mountPath: /bitnami/mysql/data
-        resources:
-          requests:
-            memory: "1Gi"
-            cpu: "500m"
-          limits:
-            memory: "2Gi"
-            cpu: "1000m"
-        livenessProbe:
-          exec:
-            command:
-            - mysqladmin
-            - ping
-            - -h
-            - localhost
-          initialDelaySeconds: 30
-          periodSeconds: 10
-          timeoutSeconds: 5
-        readinessProbe:
-          exec:
-            command:
-            - mysqladmin
-            - ping
-            - -h
-            - localhost
-          initialDelaySeconds: 5
-          periodSeconds: 5
-          timeoutSeconds: 1
-      volumes:
-      - name: mysql-data
-        hostPath:
-          path: %s/master
-          type: DirectoryOrCreate
-`
-
-const mysqlSlaveYAML = `---
-# MySQL Slave Service
-apiVersion: v1
-kind: Service
-metadata:
-  name: mysql-slave
-  namespace: rbd-system
-  labels:
-    app: mysql-slave
-spec:
-  type: ClusterIP
-  ports:
-    - port: 3306
-      targetPort: 3306
-      protocol: TCP
-  selector:
-    app: mysql-slave
-
----
-# MySQL Slave StatefulSet
-apiVersion: apps/v1
-kind: StatefulSet
-metadata:
-  name: mysql-slave
-  namespace: rbd-system
-  labels:
-    app: mysql-slave
-spec:
-  serviceName: mysql-slave
-  replicas: 1
-  selector:
-    matchLabels:
-      app: mysql-slave
-  template:
-    metadata:
-      labels:
-        app: mysql-slave
-    spec:
-      nodeName: "%s"
-      containers:
-      - name: mysql
-        image: registry.cn-hangzhou.aliyuncs.com/goodrain/mysql:8.0.34-bitnami
-        ports:
-        - containerPort: 3306
-        env:
-        - name: MYSQL_MASTER_HOST
-          value: "mysql-master-0.mysql-master.rbd-system.svc.cluster.local"
-        - name: MYSQL_MASTER_ROOT_PASSWORD
-          value: "%s"
-        - name: MYSQL_MASTER_PORT_NUMBER
-          value: "3306"
-        - name: MYSQL_REPLICATION_MODE
-          value: "slave"
-        - name: MYSQL_REPLICATION_USER
-          value: "%s"
-        - name: MYSQL_REPLICATION_PASSWORD
-          value: "%s"
-        - name: MYSQL_AUTHENTICATION_PLUGIN
-          value: "mysql_native_password"
-        volumeMounts:
-        - name: mysql-data
-          mountPath: /bitnami/mysql/data
-        resources:
-          requests:
-            memory: "1Gi"
-            cpu: "500m"
-          limits:
-            memory: "2Gi"
-            cpu: "1000m"
-        livenessProbe:
-          exec:
-            command:
-            - mysqladmin
-            - ping
-            - -h
-            - localhost
-          initialDelaySeconds: 60
-          periodSeconds: 10
-          timeoutSeconds: 5
-        readinessProbe:
-          exec:
-            command:
-            - mysqladmin
-            - ping
-            - -h
-            - localhost
-          initialDelaySeconds: 30
-          periodSeconds: 5
-          timeoutSeconds: 1
-      volumes:
-      - name: mysql-data
-        hostPath:
-          path: %s/slave
-          type: DirectoryOrCreate
-`
-
-const mysqlInitYAML = `---
-# MySQL Database Initialization Job
-apiVersion: batch/v1
-kind: Job
-metadata:
-  name: mysql-init-databases
-  namespace: rbd-system
-  labels:
-    app: mysql-init
-spec:
-  ttlSecondsAfterFinished: 60
-  template:
-    metadata:
-      labels:
-        app: mysql-init
-    spec:
-      restartPolicy: OnFailure
-      containers:
-      - name: mysql-init
-        image: registry.cn-hangzhou.aliyuncs.com/goodrain/mysql:8.0.34-bitnami
-        command:
-        - /bin/bash
-        - -c
-        - |
-          echo "等待MySQL Master就绪..."
-          until mysql -h mysql-master-0.mysql-master.rbd-system.svc.cluster.local -u root -p%s -e "SELECT 1" >/dev/null 2>&1; do
-            echo "等待MySQL Master启动... ($(date))"
-            sleep 5
-          done
-          
-          echo "MySQL Master已就绪，开始创建数据库..."
-          
-          # 创建console数据库
-          mysql -h mysql-master-0.mysql-master.rbd-system.svc.cluster.local -u root -p%s -e "CREATE DATABASE IF NOT EXISTS console CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;"
-          if [ $? -eq 0 ]; then
-            echo "console数据库创建成功"
-          else
-            echo "console数据库创建失败"
-            exit 1
-          fi
-          
-          # 创建region数据库  
-          mysql -h mysql-master-0.mysql-master.rbd-system.svc.cluster.local -u root -p%s -e "CREATE DATABASE IF NOT EXISTS region CHARACTER SET utf8mb4 COLLATE utf8mb4_unicode_ci;"
-          if [ $? -eq 0 ]; then
-            echo "region数据库创建成功"
-          else
-            echo "region数据库创建失败"
-            exit 1
-          fi
-          
-          
-          echo "数据库初始化完成"
-          
-          # 验证主从同步状态
-          echo "验证主从同步状态..."
-          sleep 10
-          
-          echo "=== Master状态 ==="
-          mysql -h mysql-master-0.mysql-master.rbd-system.svc.cluster.local -u root -p%s -e "SHOW MASTER STATUS\G"
-          
-          echo "=== 显示所有数据库 ==="
-          mysql -h mysql-master-0.mysql-master.rbd-system.svc.cluster.local -u root -p%s -e "SHOW DATABASES;"
-          
-          # 检查是否有Slave节点并验证主从同步
-          echo "检查Slave节点可用性..."
-          
-          # 尝试连接Slave节点来检测是否存在
-          SLAVE_CONNECTED=false
-          for i in {1..6}; do
-            if mysql -h mysql-slave-0.mysql-slave.rbd-system.svc.cluster.local -u root -p%s -e "SELECT 1" >/dev/null 2>&1; then
-              echo "检测到Slave节点，开始验证数据同步..."
-              SLAVE_CONNECTED=true
-              break
-            fi
-            echo "尝试连接Slave节点... ($i/6)"
-            sleep 5
-          done
-          
-          if [ "$SLAVE_CONNECTED" = "true" ]; then
-            # 在Master上创建测试表来验证同步
-            echo "=== 验证数据同步 ==="
-            mysql -h mysql-master-0.mysql-master.rbd-system.svc.cluster.local -u root -p%s -e "
-              USE console;
-              CREATE TABLE IF NOT EXISTS sync_test (id INT PRIMARY KEY, test_time TIMESTAMP DEFAULT CURRENT_TIMESTAMP);
-              INSERT INTO sync_test (id) VALUES (1) ON DUPLICATE KEY UPDATE test_time = CURRENT_TIMESTAMP;
-            "
-            
-            # 等待同步传播
-            sleep 3
-            
-            # 验证数据同步
-            if mysql -h mysql-slave-0.mysql-slave.rbd-system.svc.cluster.local -u root -p%s -e "SELECT * FROM console.sync_test WHERE id=1" >/dev/null 2>&1; then
-              echo "✓ 数据同步验证成功: 测试数据已同步到Slave"
-            else
-              echo "✗ 警告: 数据同步验证失败"
-            fi
-            
-            # 清理测试表
-            mysql -h mysql-master-0.mysql-master.rbd-system.svc.cluster.local -u root -p%s -e "DROP TABLE IF EXISTS console.sync_test" >/dev/null 2>&1
-          else
-            echo "未检测到Slave节点或Slave节点未就绪，跳过主从同步验证"
-          fi
-          
-          echo "MySQL集群初始化和验证完成!"
-`
-
 // Logger 定义日志接口
 type Logger interface {
 	Debug(format string, v ...interface{})
@@ -334,12 +47,23 @@ type StepProgress interface {
 	CompleteNodeStep(nodeIP string)
 }
 
+// defaultMySQLStorageSize 未配置storage_size时要求的数据目录最小可用空间，StorageClass模式下为PVC的容量
+const defaultMySQLStorageSize = "10Gi"
+
+// 等待MySQL Pod就绪的轮询参数
+const (
+	podsReadyTimeout  = 10 * time.Minute
+	podsReadyInterval = 10 * time.Second
+)
+
 type MySQLInstaller struct {
 	config       *config.Config
 	logger       Logger
 	stepProgress StepProgress
 	kubeConfig   *rest.Config
 	kubeClient   kubernetes.Interface
+	recreate     bool       // 是否清空已有数据目录后重新部署
+	runner       ssh.Runner // 非nil时远程命令通过 Runner 执行
 }
 
 func NewMySQLInstaller(cfg *config.Config) *MySQLInstaller {
@@ -356,23 +80,40 @@ func NewMySQLInstallerWithLoggerAndProgress(cfg *config.Config, logger Logger, s
 		logger:       logger,
 		stepProgress: stepProgress,
 	}
-	// 初始化Kubernetes客户端
-	if err := m.initializeKubeClient(); err != nil {
+	// 尝试初始化Kubernetes客户端，失败时（如RKE2尚未安装）在首次使用时由 ensureKubeClient 重试
+	if err := m.initializeKubeClient(false); err != nil {
 		if logger != nil {
-			logger.Error("初始化Kubernetes客户端失败: %v", err)
+			logger.Debug("初始化Kubernetes客户端失败，将在使用时重试: %v", err)
 		}
 	}
 	return m
 }
 
-// 初始化Kubernetes客户端
-func (m *MySQLInstaller) initializeKubeClient() error {
-	// 优先使用本地kubeconfig文件
-	localKubeConfigPath := "./kubeconfig"
+// SetRecreate 设置是否清空已有的MySQL数据目录（破坏性操作，仅在 --recreate 时启用）
+func (m *MySQLInstaller) SetRecreate(recreate bool) {
+	m.recreate = recreate
+}
+
+// ensureKubeClient 确保Kubernetes客户端可用，未初始化时带退避重试连接API
+// 连接失败时返回包含原因的错误，调用方不会拿到nil客户端
+func (m *MySQLInstaller) ensureKubeClient() error {
+	if m.kubeClient != nil {
+		return nil
+	}
+	if err := m.initializeKubeClient(true); err != nil {
+		return fmt.Errorf("Kubernetes客户端未就绪: %w", err)
+	}
+	return nil
+}
+
+// 初始化Kubernetes客户端，wait为true时API暂不可访问会带退避重试，连接测试通过后才设置客户端
+func (m *MySQLInstaller) initializeKubeClient(wait bool) error {
+	// 使用RKE2安装时保存的本地kubeconfig，或已有集群指定的kubeconfig
+	localKubeConfigPath := m.config.KubeconfigPath()
 
-	// 检查本地kubeconfig是否存在
-	if _, err := os.Stat(localKubeConfigPath); err != nil {
-		return fmt.Errorf("本地kubeconfig文件不存在: %s，请先运行RKE2安装", localKubeConfigPath)
+	// 检查kubeconfig是否存在
+	if err := m.config.CheckKubeconfig(); err != nil {
+		return err
 	}
 
 	if m.logger != nil {
@@ -395,24 +136,30 @@ func (m *MySQLInstaller) initializeKubeClient() error {
 	if err != nil {
 		return fmt.Errorf("创建Kubernetes客户端失败: %w", err)
 	}
-	m.kubeClient = clientset
+
+	if wait {
+		if err := cluster.WaitForAPI(clientset, m.logger); err != nil {
+			return err
+		}
+	}
 
 	// 测试连接
-	if err := m.testKubernetesConnection(); err != nil {
+	if err := m.testKubernetesConnection(clientset); err != nil {
 		return fmt.Errorf("测试Kubernetes连接失败: %w", err)
 	}
+	m.kubeClient = clientset
 
 	return nil
 }
 
 // testKubernetesConnection 测试Kubernetes集群连接
-func (m *MySQLInstaller) testKubernetesConnection() error {
+func (m *MySQLInstaller) testKubernetesConnection(client kubernetes.Interface) error {
 	if m.logger != nil {
 		m.logger.Debug("测试Kubernetes集群连接...")
 	}
 
 	// 尝试获取节点列表来测试连接
-	nodes, err := m.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
 	if err != nil {
 		return fmt.Errorf("无法连接到Kubernetes集群: %w", err)
 	}
@@ -458,9 +205,15 @@ func (m *MySQLInstaller) Run() error {
 		return m.verifyDeployment()
 	}
 
-	// 创建数据存储目录
-	if err := m.createDataDirectories(); err != nil {
-		return fmt.Errorf("创建数据目录失败: %w", err)
+	for _, warning := range m.config.MySQLTopologyWarnings() {
+		if m.logger != nil {
+			m.logger.Warn("%s", warning)
+		}
+	}
+
+	// 准备数据存储：hostPath目录或StorageClass
+	if err := m.prepareStorage(); err != nil {
+		return fmt.Errorf("准备MySQL数据存储失败: %w", err)
 	}
 
 	// 创建命名空间
@@ -468,6 +221,10 @@ func (m *MySQLInstaller) Run() error {
 		return fmt.Errorf("创建命名空间失败: %w", err)
 	}
 
+	if err := m.ensureImagePullSecret(); err != nil {
+		return err
+	}
+
 	// 部署MySQL Master
 	if m.logger != nil {
 		m.logger.Info("=== 部署MySQL Master ===")
@@ -520,6 +277,8 @@ func (m *MySQLInstaller) setDefaults() {
 	if m.config.MySQL.DataPath == "" {
 		m.config.MySQL.DataPath = "/opt/rainbond/mysql"
 	}
+	// 默认密码同样需要在命令日志中脱敏
+	ssh.RegisterSecrets(m.config.MySQL.RootPassword, m.config.MySQL.ReplPassword)
 }
 
 func (m *MySQLInstaller) checkKubernetesReady() error {
@@ -528,10 +287,8 @@ func (m *MySQLInstaller) checkKubernetesReady() error {
 	}
 
 	// 确保Kubernetes客户端已初始化
-	if m.kubeClient == nil {
-		if err := m.initializeKubeClient(); err != nil {
-			return fmt.Errorf("初始化Kubernetes客户端失败: %w", err)
-		}
+	if err := m.ensureKubeClient(); err != nil {
+		return err
 	}
 
 	// 使用Kubernetes API获取节点状态
@@ -566,10 +323,8 @@ func (m *MySQLInstaller) checkExistingDeployment() (bool, error) {
 	}
 
 	// 确保Kubernetes客户端已初始化
-	if m.kubeClient == nil {
-		if err := m.initializeKubeClient(); err != nil {
-			return false, fmt.Errorf("初始化Kubernetes客户端失败: %w", err)
-		}
+	if err := m.ensureKubeClient(); err != nil {
+		return false, err
 	}
 
 	// 使用Kubernetes API检查StatefulSet是否存在
@@ -582,59 +337,145 @@ func (m *MySQLInstaller) createDataDirectories() error {
 		m.logger.Info("创建MySQL数据存储目录...")
 	}
 
-	// 在MySQL Master节点上创建master数据目录
-	masterHost := m.getMasterHost()
-	if masterHost != nil {
-		masterPath := fmt.Sprintf("%s/master", m.config.MySQL.DataPath)
-		// 清理可能存在的目录，创建新目录，设置权限为1001:1001 (bitnami mysql user)
-		cmd := m.buildSSHCommand(*masterHost, fmt.Sprintf(
-			"rm -rf %s && mkdir -p %s && chown -R 1001:1001 %s && chmod -R 755 %s",
-			masterPath, masterPath, masterPath, masterPath))
-
-		if err := cmd.Run(); err != nil {
-			if m.logger != nil {
-				m.logger.Warn("主机 %s: 创建Master数据目录失败: %v", masterHost.IP, err)
-			}
-		} else {
+	if err := config.ValidateMySQLDataPath(m.config.MySQL.DataPath); err != nil {
+		return err
+	}
+
+	dataDirs := []struct {
+		host *config.Host
+		name string
+	}{
+		{m.getMasterHost(), "master"},
+		{m.getSlaveHost(), "slave"},
+	}
+
+	for _, dir := range dataDirs {
+		if dir.host == nil {
+			continue
+		}
+		path := fmt.Sprintf("%s/%s", filepath.Clean(m.config.MySQL.DataPath), dir.name)
+
+		if err := m.checkDataPathSpace(*dir.host, path); err != nil {
+			return err
+		}
+
+		// 默认保留已有数据，仅在 --recreate 时清空目录
+		command := fmt.Sprintf("mkdir -p %s && chown -R 1001:1001 %s && chmod -R 755 %s", path, path, path)
+		if m.recreate {
 			if m.logger != nil {
-				m.logger.Info("主机 %s: Master数据目录创建成功", masterHost.IP)
+				m.logger.Warn("主机 %s: --recreate 已启用，将清空数据目录 %s", dir.host.IP, path)
 			}
+			command = fmt.Sprintf("rm -rf %s && %s", path, command)
+		}
+
+		// 设置权限为1001:1001 (bitnami mysql user)
+		if output, err := m.buildSSHCommand(*dir.host, command).CombinedOutput(); err != nil {
+			return fmt.Errorf("主机 %s: 创建%s数据目录 %s 失败: %w, 输出: %s", dir.host.IP, dir.name, path, err, string(output))
+		}
+		if m.logger != nil {
+			m.logger.Info("主机 %s: %s数据目录 %s 准备完成", dir.host.IP, dir.name, path)
 		}
 	}
 
-	// 在MySQL Slave节点上创建slave数据目录
-	slaveHost := m.getSlaveHost()
-	if slaveHost != nil {
-		slavePath := fmt.Sprintf("%s/slave", m.config.MySQL.DataPath)
-		// 清理可能存在的目录，创建新目录，设置权限为1001:1001 (bitnami mysql user)
-		cmd := m.buildSSHCommand(*slaveHost, fmt.Sprintf(
-			"rm -rf %s && mkdir -p %s && chown -R 1001:1001 %s && chmod -R 755 %s",
-			slavePath, slavePath, slavePath, slavePath))
-
-		if err := cmd.Run(); err != nil {
-			if m.logger != nil {
-				m.logger.Warn("主机 %s: 创建Slave数据目录失败: %v", slaveHost.IP, err)
-			}
-		} else {
-			if m.logger != nil {
-				m.logger.Info("主机 %s: Slave数据目录创建成功", slaveHost.IP)
-			}
+	return nil
+}
+
+// checkDataPathSpace 检查数据目录所在文件系统的可用空间是否满足存储大小要求
+// 目录中已有的数据计入存储大小：重新部署时保留的数据不需要额外空间，--recreate 清空后空间被释放，
+// 因此按可用空间加上目录当前占用与存储大小比较
+func (m *MySQLInstaller) checkDataPathSpace(host config.Host, path string) error {
+	required := resource.MustParse(defaultMySQLStorageSize)
+	if m.config.MySQL.StorageSize != "" {
+		quantity, err := resource.ParseQuantity(m.config.MySQL.StorageSize)
+		if err != nil {
+			return fmt.Errorf("无效的mysql.storage_size '%s': %w", m.config.MySQL.StorageSize, err)
 		}
+		required = quantity
 	}
 
+	// 输出两行：可用空间和目录当前占用（KB）；目录可能尚不存在，向上查找第一个已存在的目录
+	command := fmt.Sprintf(`p=%s
+used=0
+if [ -d "$p" ]; then used=$(du -sk "$p" 2>/dev/null | awk '{print $1}'); fi
+while [ ! -d "$p" ]; do p=$(dirname "$p"); done
+df -Pk "$p" | tail -1 | awk '{print $4}'
+echo "${used:-0}"`, path)
+	output, err := m.buildSSHCommand(host, command).Output()
+	if err != nil {
+		return fmt.Errorf("主机 %s: 获取 %s 可用空间失败: %w", host.IP, path, err)
+	}
+
+	fields := strings.Fields(string(output))
+	if len(fields) != 2 {
+		return fmt.Errorf("主机 %s: 解析可用空间失败: %s", host.IP, strings.TrimSpace(string(output)))
+	}
+	availableKB, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("主机 %s: 解析可用空间失败: %s", host.IP, strings.TrimSpace(string(output)))
+	}
+	usedKB, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return fmt.Errorf("主机 %s: 解析 %s 已用空间失败: %s", host.IP, path, strings.TrimSpace(string(output)))
+	}
+
+	if (availableKB+usedKB)*1024 < required.Value() {
+		return fmt.Errorf("主机 %s: %s 所在文件系统可用空间 %.1fGi（目录已占用 %.1fGi）小于MySQL存储需求 %s",
+			host.IP, path, float64(availableKB)/1024/1024, float64(usedKB)/1024/1024, required.String())
+	}
+	if m.logger != nil {
+		m.logger.Debug("主机 %s: %s 可用空间 %.1fGi，目录已占用 %.1fGi，满足需求 %s",
+			host.IP, path, float64(availableKB)/1024/1024, float64(usedKB)/1024/1024, required.String())
+	}
 	return nil
 }
 
+// mysqlManifestData MySQL清单模板参数
+type mysqlManifestData struct {
+	NodeName          string // 直接绑定的节点名称
+	NodeSelectorKey   string // node_selector调度方式下的节点标签键，为空时使用nodeName绑定
+	NodeSelectorValue string // node_selector调度方式下的节点标签值
+	RootPassword      string
+	ReplUser          string
+	ReplPassword      string
+	DataPath          string // hostPath根目录
+	StorageClass      string // mysql.storage_class，非空时使用volumeClaimTemplates代替hostPath
+	StorageSize       string // PVC请求的容量
+	InitSQL           string // 额外初始化SQL，base64编码，仅初始化Job使用
+	SkipSyncTest      bool   // 跳过主从同步验证，仅初始化Job使用
+	ImagePullPolicy   string // image_pull.policy，为空时不设置
+	ImagePullSecret   string // image_pull.secret_name，为空时不设置
+}
+
+// manifestData 生成MySQL清单模板参数，role为master或slave
+func (m *MySQLInstaller) manifestData(nodeName, role string) mysqlManifestData {
+	data := mysqlManifestData{
+		NodeName:        nodeName,
+		RootPassword:    m.config.MySQL.RootPassword,
+		ReplUser:        m.config.MySQL.ReplUser,
+		ReplPassword:    m.config.MySQL.ReplPassword,
+		DataPath:        m.config.MySQL.DataPath,
+		ImagePullPolicy: m.config.ImagePull.Policy,
+		ImagePullSecret: m.config.ImagePull.SecretName,
+		StorageClass:    m.config.MySQL.StorageClass,
+		StorageSize:     m.config.MySQL.StorageSize,
+	}
+	if data.StorageSize == "" {
+		data.StorageSize = defaultMySQLStorageSize
+	}
+	if m.useNodeSelector() && role != "" {
+		data.NodeSelectorKey = mysqlRoleLabel
+		data.NodeSelectorValue = role
+	}
+	return data
+}
+
 func (m *MySQLInstaller) deployMaster() error {
 	masterHost := m.getMasterHost()
 	if masterHost == nil {
 		return fmt.Errorf("没有找到配置为MySQL Master的节点")
 	}
 
-	masterNodeName := masterHost.NodeName
-	if masterNodeName == "" {
-		masterNodeName = masterHost.IP
-	}
+	masterNodeName := m.config.GetNodeName(*masterHost)
 
 	// 生成MySQL Master YAML
 	if m.logger != nil {
@@ -642,13 +483,16 @@ func (m *MySQLInstaller) deployMaster() error {
 			masterNodeName, m.config.MySQL.RootPassword, m.config.MySQL.ReplUser, m.config.MySQL.ReplPassword, m.config.MySQL.DataPath)
 	}
 
-	yamlContent := fmt.Sprintf(mysqlMasterYAML,
-		masterNodeName,              // nodeName for direct binding
-		m.config.MySQL.RootPassword, // MYSQL_ROOT_PASSWORD
-		m.config.MySQL.ReplUser,     // MYSQL_REPLICATION_USER
-		m.config.MySQL.ReplPassword, // MYSQL_REPLICATION_PASSWORD
-		m.config.MySQL.DataPath,     // hostPath
-	)
+	if m.useNodeSelector() {
+		if err := m.labelMySQLNode(masterNodeName, "master"); err != nil {
+			return err
+		}
+	}
+
+	yamlContent, err := templates.Render(templates.MySQLMaster, "", m.manifestData(masterNodeName, "master"))
+	if err != nil {
+		return fmt.Errorf("生成MySQL Master YAML失败: %w", err)
+	}
 
 	if m.logger != nil {
 		m.logger.Debug("生成的MySQL Master YAML长度: %d", len(yamlContent))
@@ -664,10 +508,7 @@ func (m *MySQLInstaller) deploySlave() error {
 		return fmt.Errorf("没有找到配置为MySQL Slave的节点")
 	}
 
-	slaveNodeName := slaveHost.NodeName
-	if slaveNodeName == "" {
-		slaveNodeName = slaveHost.IP
-	}
+	slaveNodeName := m.config.GetNodeName(*slaveHost)
 
 	// 生成MySQL Slave YAML
 	if m.logger != nil {
@@ -675,13 +516,16 @@ func (m *MySQLInstaller) deploySlave() error {
 			slaveNodeName, m.config.MySQL.RootPassword, m.config.MySQL.ReplUser, m.config.MySQL.ReplPassword, m.config.MySQL.DataPath)
 	}
 
-	yamlContent := fmt.Sprintf(mysqlSlaveYAML,
-		slaveNodeName,               // nodeName for direct binding
-		m.config.MySQL.RootPassword, // MYSQL_MASTER_ROOT_PASSWORD
-		m.config.MySQL.ReplUser,     // MYSQL_REPLICATION_USER
-		m.config.MySQL.ReplPassword, // MYSQL_REPLICATION_PASSWORD
-		m.config.MySQL.DataPath,     // hostPath
-	)
+	if m.useNodeSelector() {
+		if err := m.labelMySQLNode(slaveNodeName, "slave"); err != nil {
+			return err
+		}
+	}
+
+	yamlContent, err := templates.Render(templates.MySQLSlave, "", m.manifestData(slaveNodeName, "slave"))
+	if err != nil {
+		return fmt.Errorf("生成MySQL Slave YAML失败: %w", err)
+	}
 
 	if m.logger != nil {
 		m.logger.Debug("生成的MySQL Slave YAML长度: %d", len(yamlContent))
@@ -691,6 +535,14 @@ func (m *MySQLInstaller) deploySlave() error {
 	return m.applyYAMLOnFirstNode(yamlContent, "MySQL Slave")
 }
 
+// skipSyncTestReason 跳过主从同步验证的原因
+func skipSyncTestReason(configured bool) string {
+	if configured {
+		return "mysql.skip_sync_test"
+	}
+	return "未配置Slave节点"
+}
+
 func (m *MySQLInstaller) waitForDeployment() error {
 	if m.logger != nil {
 		m.logger.Info("等待MySQL部署就绪...")
@@ -727,17 +579,27 @@ func (m *MySQLInstaller) initializeDatabases() error {
 	namespace := "rbd-system"
 
 	// 生成MySQL初始化Job YAML
-	yamlContent := fmt.Sprintf(mysqlInitYAML,
-		m.config.MySQL.RootPassword, // password for waiting connection
-		m.config.MySQL.RootPassword, // password for console database
-		m.config.MySQL.RootPassword, // password for region database
-		m.config.MySQL.RootPassword, // password for master status
-		m.config.MySQL.RootPassword, // password for show databases
-		m.config.MySQL.RootPassword, // password for waiting slave
-		m.config.MySQL.RootPassword, // password for creating test table
-		m.config.MySQL.RootPassword, // password for data sync verification
-		m.config.MySQL.RootPassword, // password for cleanup test table
-	)
+	data := m.manifestData("", "")
+	initSQL, err := m.config.MySQL.InitSQLScript()
+	if err != nil {
+		return err
+	}
+	if initSQL != "" {
+		if m.logger != nil {
+			m.logger.Info("初始化Job将执行额外初始化SQL（%d 字节）", len(initSQL))
+		}
+		data.InitSQL = base64.StdEncoding.EncodeToString([]byte(initSQL))
+	}
+	// 只有Master时没有可验证的复制，跳过探测Slave和同步测试
+	data.SkipSyncTest = m.config.MySQL.SkipSyncTest || !m.hasSlaveNode()
+	if data.SkipSyncTest && m.logger != nil {
+		m.logger.Info("初始化Job跳过主从同步验证（%s）", skipSyncTestReason(m.config.MySQL.SkipSyncTest))
+	}
+
+	yamlContent, err := templates.Render(templates.MySQLInit, "", data)
+	if err != nil {
+		return fmt.Errorf("生成MySQL初始化Job YAML失败: %w", err)
+	}
 
 	// 使用Kubernetes API创建资源
 	if err := m.applyYAMLOnFirstNode(yamlContent, "MySQL 初始化Job"); err != nil {
@@ -859,40 +721,53 @@ func (m *MySQLInstaller) verifyDeployment() error {
 	return nil
 }
 
-func (m *MySQLInstaller) buildSSHCommand(host config.Host, command string) *exec.Cmd {
+// SetRunner 替换在主机上准备hostPath数据目录时使用的 Runner，MySQL资源本身通过Kubernetes API创建，不受影响
+func (m *MySQLInstaller) SetRunner(runner ssh.Runner) {
+	m.runner = runner
+}
+
+// buildSSHCommand 构建在数据目录所在主机上执行的命令，设置了 Runner 时交由 Runner 执行
+func (m *MySQLInstaller) buildSSHCommand(host config.Host, command string) *ssh.Command {
+	if m.runner != nil {
+		return ssh.NewRunnerCommand(m.runner, host, command)
+	}
+	return m.sshCommand(host, command)
+}
+
+// sshCommand 构建系统ssh命令，只用于数据目录的创建、授权和空间检查
+func (m *MySQLInstaller) sshCommand(host config.Host, command string) *ssh.Command {
 	var sshCmd *exec.Cmd
+	command = ssh.WrapBecome(host, command)
 
 	if host.Password != "" {
-		if _, err := exec.LookPath("sshpass"); err == nil {
-			sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		} else {
-			sshCmd = exec.Command("ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "BatchMode=no",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		}
+		// sshpass 已在启动时检查
+		sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
+			fmt.Sprintf("%s@%s", host.User, host.IP),
+			command)
 	} else if host.SSHKey != "" {
 		sshCmd = exec.Command("ssh",
 			"-i", host.SSHKey,
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	} else {
 		sshCmd = exec.Command("ssh",
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	}
 
-	return sshCmd
+	return ssh.NewCommand(host, command, sshCmd)
 }
 
 func (m *MySQLInstaller) getMasterHost() *config.Host {
@@ -923,10 +798,8 @@ func (m *MySQLInstaller) createNamespace() error {
 	}
 
 	// 确保Kubernetes客户端已初始化
-	if m.kubeClient == nil {
-		if err := m.initializeKubeClient(); err != nil {
-			return fmt.Errorf("初始化Kubernetes客户端失败: %w", err)
-		}
+	if err := m.ensureKubeClient(); err != nil {
+		return err
 	}
 
 	// 使用Kubernetes API检查命名空间是否已存在
@@ -941,7 +814,8 @@ func (m *MySQLInstaller) createNamespace() error {
 	// 创建命名空间
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: "rbd-system",
+			Name:   "rbd-system",
+			Labels: m.config.ClusterLabels(),
 		},
 	}
 
@@ -962,16 +836,8 @@ func (m *MySQLInstaller) applyYAMLOnFirstNode(yamlContent, component string) err
 	}
 
 	// 确保Kubernetes客户端已初始化
-	if m.kubeClient == nil {
-		if m.logger != nil {
-			m.logger.Debug("Kubernetes客户端未初始化，开始初始化...")
-		}
-		if err := m.initializeKubeClient(); err != nil {
-			return fmt.Errorf("初始化Kubernetes客户端失败: %w", err)
-		}
-		if m.logger != nil {
-			m.logger.Debug("Kubernetes客户端初始化成功")
-		}
+	if err := m.ensureKubeClient(); err != nil {
+		return err
 	}
 
 	// 确保rbd-system命名空间存在
@@ -1012,7 +878,8 @@ func (m *MySQLInstaller) ensureNamespace(namespace string) error {
 
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: namespace,
+			Name:   namespace,
+			Labels: m.config.ClusterLabels(),
 		},
 	}
 
@@ -1126,35 +993,37 @@ func (m *MySQLInstaller) createKubernetesResource(obj runtime.Object, gvk *schem
 // waitForPodsReady 等待指定标签的Pod就绪
 func (m *MySQLInstaller) waitForPodsReady(labelSelector string, componentName string) error {
 	// 确保Kubernetes客户端已初始化
-	if m.kubeClient == nil {
-		if err := m.initializeKubeClient(); err != nil {
-			return fmt.Errorf("初始化Kubernetes客户端失败: %w", err)
-		}
+	if err := m.ensureKubeClient(); err != nil {
+		return err
 	}
 
-	for i := 0; i < 60; i++ { // 最多等待10分钟
-		pods, err := m.kubeClient.CoreV1().Pods("rbd-system").List(context.TODO(), metav1.ListOptions{
-			LabelSelector: labelSelector,
-			FieldSelector: "status.phase=Running",
-		})
-
-		if err == nil && len(pods.Items) > 0 {
-			if m.logger != nil {
-				m.logger.Info("%s已就绪", componentName)
+	err := cluster.WaitFor(context.Background(), m.logger, podsReadyInterval, podsReadyTimeout, componentName+"就绪",
+		func(ctx context.Context) (bool, error) {
+			pods, err := m.kubeClient.CoreV1().Pods("rbd-system").List(ctx, metav1.ListOptions{
+				LabelSelector: labelSelector,
+				FieldSelector: "status.phase=Running",
+			})
+			if err != nil {
+				return false, fmt.Errorf("获取Pod列表失败: %w", err)
 			}
-			return nil
-		}
-
-		if i == 59 {
-			return fmt.Errorf("等待%s就绪超时", componentName)
-		}
-
-		time.Sleep(10 * time.Second)
-		if m.logger != nil {
-			m.logger.Debug("等待%s就绪... (%d/60)", componentName, i+1)
+			if len(pods.Items) == 0 {
+				return false, fmt.Errorf("没有Running状态的Pod")
+			}
+			return true, nil
+		})
+	if errors.Is(err, cluster.ErrWaitTimeout) {
+		// 超时时补充Pod无法调度的原因，便于定位节点或存储问题
+		if reason := m.unschedulableReason(labelSelector); reason != "" {
+			return fmt.Errorf("等待%s就绪超时: %s", componentName, reason)
 		}
 	}
+	if err != nil {
+		return err
+	}
 
+	if m.logger != nil {
+		m.logger.Info("%s已就绪", componentName)
+	}
 	return nil
 }
 
@@ -0,0 +1,26 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+)
+
+// mysqlNamespace MySQL清单部署的命名空间
+const mysqlNamespace = "rbd-system"
+
+// ensureImagePullSecret 配置了 image_pull.secret_name 时，在MySQL命名空间中创建或检查imagePullSecret
+func (m *MySQLInstaller) ensureImagePullSecret() error {
+	pull := m.config.ImagePull
+	if !pull.HasPullSecret() {
+		return nil
+	}
+	if m.logger != nil {
+		if pull.CreatesPullSecret() {
+			m.logger.Info("创建或更新imagePullSecret %s/%s（仓库: %s）", mysqlNamespace, pull.SecretName, pull.Registry)
+		} else {
+			m.logger.Info("检查imagePullSecret %s/%s", mysqlNamespace, pull.SecretName)
+		}
+	}
+	return cluster.EnsureImagePullSecret(context.TODO(), m.kubeClient, mysqlNamespace, pull)
+}
@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// mysqlRoleLabel node_selector调度方式下标记MySQL目标节点的标签，值为master或slave
+const mysqlRoleLabel = "rainbond.io/mysql-role"
+
+// useNodeSelector 是否通过节点标签和nodeSelector调度MySQL Pod
+func (m *MySQLInstaller) useNodeSelector() bool {
+	return m.config.MySQL.UsesNodeSelector()
+}
+
+// labelMySQLNode 为MySQL目标节点添加角色标签，nodeSelector据此将Pod调度到该节点
+func (m *MySQLInstaller) labelMySQLNode(nodeName, role string) error {
+	if err := m.ensureKubeClient(); err != nil {
+		return err
+	}
+
+	if _, err := m.kubeClient.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("MySQL %s 目标节点 %s 不存在: %w", role, nodeName, err)
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"labels": map[string]string{mysqlRoleLabel: role}},
+	})
+	if err != nil {
+		return fmt.Errorf("生成节点标签补丁失败: %w", err)
+	}
+	if _, err := m.kubeClient.CoreV1().Nodes().Patch(context.TODO(), nodeName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("为节点 %s 添加标签 %s=%s 失败: %w", nodeName, mysqlRoleLabel, role, err)
+	}
+
+	if m.logger != nil {
+		m.logger.Info("已为节点 %s 添加标签 %s=%s", nodeName, mysqlRoleLabel, role)
+	}
+	return nil
+}
+
+// unschedulableReason 返回匹配标签的Pod无法调度的原因，Pod均已调度时返回空字符串
+func (m *MySQLInstaller) unschedulableReason(labelSelector string) string {
+	pods, err := m.kubeClient.CoreV1().Pods("rbd-system").List(context.TODO(), metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return ""
+	}
+
+	var reasons []string
+	for _, pod := range pods.Items {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+				reasons = append(reasons, fmt.Sprintf("Pod %s 无法调度: %s", pod.Name, condition.Message))
+			}
+		}
+	}
+	return strings.Join(reasons, "; ")
+}
@@ -0,0 +1,67 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// mysqlDataVolume StatefulSet中数据卷和volumeClaimTemplates的名称，PVC名为 <volume>-<statefulset>-0
+const mysqlDataVolume = "mysql-data"
+
+// useStorageClass 是否通过StorageClass动态创建PVC存储数据，否则使用节点上的hostPath
+func (m *MySQLInstaller) useStorageClass() bool {
+	return m.config.MySQL.StorageClass != ""
+}
+
+// prepareStorage hostPath模式下在节点上创建数据目录，StorageClass模式下检查StorageClass存在
+func (m *MySQLInstaller) prepareStorage() error {
+	if !m.useStorageClass() {
+		return m.createDataDirectories()
+	}
+
+	if err := m.checkStorageClass(); err != nil {
+		return err
+	}
+	if m.recreate {
+		return m.deleteDataClaims()
+	}
+	return nil
+}
+
+// checkStorageClass 部署前确认StorageClass存在，避免PVC一直处于Pending
+func (m *MySQLInstaller) checkStorageClass() error {
+	name := m.config.MySQL.StorageClass
+	sc, err := m.kubeClient.StorageV1().StorageClasses().Get(context.TODO(), name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("StorageClass %s 不存在，请先部署对应的存储插件或修改 mysql.storage_class", name)
+	}
+	if err != nil {
+		return fmt.Errorf("检查StorageClass %s 失败: %w", name, err)
+	}
+
+	if m.logger != nil {
+		m.logger.Info("使用StorageClass %s（provisioner: %s）存储MySQL数据", sc.Name, sc.Provisioner)
+	}
+	return nil
+}
+
+// deleteDataClaims --recreate 时删除上次部署遗留的PVC，StatefulSet删除后PVC会保留
+func (m *MySQLInstaller) deleteDataClaims() error {
+	claims := []string{mysqlDataVolume + "-mysql-master-0", mysqlDataVolume + "-mysql-slave-0"}
+	for _, claim := range claims {
+		err := m.kubeClient.CoreV1().PersistentVolumeClaims(mysqlNamespace).Delete(context.TODO(), claim, metav1.DeleteOptions{})
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("删除PVC %s 失败: %w", claim, err)
+		}
+		if m.logger != nil {
+			m.logger.Warn("--recreate 已启用，已删除PVC %s/%s", mysqlNamespace, claim)
+		}
+	}
+	return nil
+}
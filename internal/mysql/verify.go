@@ -0,0 +1,66 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VerifyReachable 严格模式下的阶段验证：MySQL Master Pod就绪，且能从集群节点通过Service地址连接到3306端口
+// Rainbond安装依赖MySQL可用，Pod已创建但未就绪或Service不通时不继续安装
+func (m *MySQLInstaller) VerifyReachable() error {
+	if !m.config.MySQL.Enabled {
+		return nil
+	}
+	if err := m.ensureKubeClient(); err != nil {
+		return err
+	}
+
+	namespace := "rbd-system"
+	pods, err := m.kubeClient.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
+		LabelSelector: "app=mysql-master",
+	})
+	if err != nil {
+		return fmt.Errorf("检查MySQL Master状态失败: %w", err)
+	}
+	if len(pods.Items) == 0 {
+		return fmt.Errorf("未找到MySQL Master Pod")
+	}
+	for _, pod := range pods.Items {
+		if !isPodReady(&pod) {
+			return fmt.Errorf("MySQL Master Pod %s 未就绪，状态: %s", pod.Name, pod.Status.Phase)
+		}
+	}
+
+	svc, err := m.kubeClient.CoreV1().Services(namespace).Get(context.TODO(), "mysql-master", metav1.GetOptions{})
+	if err != nil {
+		return fmt.Errorf("获取MySQL服务失败: %w", err)
+	}
+
+	host := m.getMasterHost()
+	if host == nil {
+		return fmt.Errorf("未找到MySQL Master节点")
+	}
+	checkCmd := fmt.Sprintf("timeout 5 bash -c '</dev/tcp/%s/3306'", svc.Spec.ClusterIP)
+	if output, err := m.buildSSHCommand(*host, checkCmd).CombinedOutput(); err != nil {
+		return fmt.Errorf("从节点 %s 无法连接MySQL服务 %s:3306: %w, 输出: %s", host.IP, svc.Spec.ClusterIP, err, strings.TrimSpace(string(output)))
+	}
+
+	if m.logger != nil {
+		m.logger.Info("MySQL验证通过: 服务 %s:3306 可访问", svc.Spec.ClusterIP)
+	}
+	return nil
+}
+
+// isPodReady 判断Pod的Ready条件是否为True
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
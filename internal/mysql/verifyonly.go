@@ -0,0 +1,268 @@
+package mysql
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// mysqlClientImage 只读检查Pod使用的镜像，与初始化Job一致
+	mysqlClientImage = "registry.cn-hangzhou.aliyuncs.com/goodrain/mysql:8.0.34-bitnami"
+	// verifyPodTimeout 等待只读检查Pod结束的最长时间
+	verifyPodTimeout = 2 * time.Minute
+	// requiredCharset console/region数据库要求的字符集，与初始化Job创建时一致
+	requiredCharset = "utf8mb4"
+)
+
+// requiredDatabases Rainbond依赖的数据库
+var requiredDatabases = []string{"console", "region"}
+
+// verifyScript 只读检查脚本：连接Master和Slave，输出数据库字符集和复制状态，每行一个结果供解析，不做任何修改
+const verifyScript = `
+master=mysql-master-0.mysql-master.rbd-system.svc.cluster.local
+slave=mysql-slave-0.mysql-slave.rbd-system.svc.cluster.local
+if ! err=$(mysql -h $master -u root -N -B -e "SELECT 1" 2>&1 >/dev/null); then
+  echo "CONNECT master FAIL $(echo "$err" | head -1)"
+  exit 0
+fi
+echo "CONNECT master OK"
+mysql -h $master -u root -N -B -e "SELECT SCHEMA_NAME, DEFAULT_CHARACTER_SET_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME IN ('console','region')" | sed 's/^/SCHEMA /'
+if [ "$CHECK_SLAVE" = "true" ]; then
+  if ! err=$(mysql -h $slave -u root -N -B -e "SELECT 1" 2>&1 >/dev/null); then
+    echo "CONNECT slave FAIL $(echo "$err" | head -1)"
+    exit 0
+  fi
+  echo "CONNECT slave OK"
+  mysql -h $slave -u root -e "SHOW REPLICA STATUS\G" | sed -n 's/^ *\(Replica_IO_Running\|Replica_SQL_Running\|Seconds_Behind_Source\|Last_IO_Error\|Last_SQL_Error\): */REPLICA \1 /p'
+fi
+`
+
+// VerifyCheck 只读验证中的单项检查结果
+type VerifyCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// VerifyReport MySQL只读验证报告
+type VerifyReport struct {
+	Checks []VerifyCheck
+}
+
+// Failed 返回未通过的检查项
+func (r *VerifyReport) Failed() []VerifyCheck {
+	var failed []VerifyCheck
+	for _, check := range r.Checks {
+		if !check.OK {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+func (r *VerifyReport) add(name string, ok bool, format string, v ...interface{}) {
+	r.Checks = append(r.Checks, VerifyCheck{Name: name, OK: ok, Detail: fmt.Sprintf(format, v...)})
+}
+
+// VerifyOnly 只读验证已部署的MySQL：Pod就绪、可连接、console/region数据库存在且字符集为utf8mb4，
+// 配置了Slave时检查复制线程和延迟。不部署、不初始化，只创建一个用完即删的客户端Pod执行查询
+func (m *MySQLInstaller) VerifyOnly() (*VerifyReport, error) {
+	if err := m.ensureKubeClient(); err != nil {
+		return nil, err
+	}
+
+	report := &VerifyReport{}
+	masterReady := m.checkPodsReady(report, "app=mysql-master", "MySQL Master Pod")
+	if m.hasSlaveNode() {
+		m.checkPodsReady(report, "app=mysql-slave", "MySQL Slave Pod")
+	}
+	if !masterReady {
+		return report, nil
+	}
+
+	output, err := m.runVerifyPod()
+	if err != nil {
+		report.add("MySQL查询", false, "%v", err)
+		return report, nil
+	}
+	m.parseVerifyOutput(report, output)
+	return report, nil
+}
+
+// checkPodsReady 检查标签选择器匹配的Pod全部就绪，并记录到报告
+func (m *MySQLInstaller) checkPodsReady(report *VerifyReport, labelSelector, name string) bool {
+	pods, err := m.kubeClient.CoreV1().Pods(mysqlNamespace).List(context.TODO(), metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		report.add(name, false, "查询失败: %v", err)
+		return false
+	}
+	if len(pods.Items) == 0 {
+		report.add(name, false, "命名空间 %s 中未找到 %s 的Pod", mysqlNamespace, labelSelector)
+		return false
+	}
+	for _, pod := range pods.Items {
+		if !isPodReady(&pod) {
+			report.add(name, false, "%s 未就绪，状态: %s", pod.Name, pod.Status.Phase)
+			return false
+		}
+	}
+	report.add(name, true, "%d 个Pod就绪", len(pods.Items))
+	return true
+}
+
+// runVerifyPod 创建一次性客户端Pod执行只读检查脚本，返回其日志，结束后删除Pod
+func (m *MySQLInstaller) runVerifyPod() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), verifyPodTimeout)
+	defer cancel()
+
+	container := corev1.Container{
+		Name:    "mysql-verify",
+		Image:   mysqlClientImage,
+		Command: []string{"/bin/bash", "-c", verifyScript},
+		Env: []corev1.EnvVar{
+			{Name: "MYSQL_PWD", Value: m.config.MySQL.RootPassword},
+			{Name: "CHECK_SLAVE", Value: fmt.Sprintf("%t", m.hasSlaveNode())},
+		},
+	}
+	if policy := m.config.ImagePull.Policy; policy != "" {
+		container.ImagePullPolicy = corev1.PullPolicy(policy)
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "mysql-verify-",
+			Namespace:    mysqlNamespace,
+			Labels:       map[string]string{"app": "mysql-verify"},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			Containers:    []corev1.Container{container},
+		},
+	}
+	if secret := m.config.ImagePull.SecretName; secret != "" {
+		pod.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: secret}}
+	}
+
+	pods := m.kubeClient.CoreV1().Pods(mysqlNamespace)
+	created, err := pods.Create(ctx, pod, metav1.CreateOptions{})
+	if err != nil {
+		return "", fmt.Errorf("创建MySQL检查Pod失败: %w", err)
+	}
+	defer func() {
+		var grace int64
+		if err := pods.Delete(context.Background(), created.Name, metav1.DeleteOptions{GracePeriodSeconds: &grace}); err != nil && m.logger != nil {
+			m.logger.Warn("删除MySQL检查Pod %s 失败: %v", created.Name, err)
+		}
+	}()
+	if m.logger != nil {
+		m.logger.Info("创建临时Pod %s/%s 执行只读检查", mysqlNamespace, created.Name)
+	}
+
+	for {
+		current, err := pods.Get(ctx, created.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", fmt.Errorf("获取MySQL检查Pod状态失败: %w", err)
+		}
+		if current.Status.Phase == corev1.PodSucceeded || current.Status.Phase == corev1.PodFailed {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("MySQL检查Pod %s 在 %s 内未完成，状态: %s", created.Name, verifyPodTimeout, current.Status.Phase)
+		case <-time.After(2 * time.Second):
+		}
+	}
+
+	logs, err := pods.GetLogs(created.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("获取MySQL检查Pod日志失败: %w", err)
+	}
+	return string(logs), nil
+}
+
+// parseVerifyOutput 解析检查脚本的输出并记录到报告
+func (m *MySQLInstaller) parseVerifyOutput(report *VerifyReport, output string) {
+	charsets := make(map[string]string)
+	replica := make(map[string]string)
+	connected := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "CONNECT":
+			if len(fields) < 3 {
+				continue
+			}
+			name := "连接MySQL " + fields[1]
+			if fields[2] == "OK" {
+				report.add(name, true, "root用户连接成功")
+				connected[fields[1]] = true
+			} else {
+				report.add(name, false, "%s", strings.Join(fields[3:], " "))
+			}
+		case "SCHEMA":
+			if len(fields) >= 3 {
+				charsets[fields[1]] = fields[2]
+			}
+		case "REPLICA":
+			replica[fields[1]] = strings.Join(fields[2:], " ")
+		}
+	}
+
+	if !connected["master"] {
+		if !strings.Contains(output, "CONNECT master") {
+			report.add("MySQL查询", false, "检查Pod未输出预期结果: %s", lastLines(output, 5))
+		}
+		return
+	}
+
+	for _, db := range requiredDatabases {
+		charset, ok := charsets[db]
+		switch {
+		case !ok:
+			report.add("数据库 "+db, false, "不存在")
+		case charset != requiredCharset:
+			report.add("数据库 "+db, false, "字符集为 %s，要求 %s", charset, requiredCharset)
+		default:
+			report.add("数据库 "+db, true, "字符集 %s", charset)
+		}
+	}
+
+	if !m.hasSlaveNode() || !connected["slave"] {
+		return
+	}
+	if len(replica) == 0 {
+		report.add("主从复制", false, "Slave未配置复制（SHOW REPLICA STATUS 为空）")
+		return
+	}
+	io, sql := replica["Replica_IO_Running"], replica["Replica_SQL_Running"]
+	if io != "Yes" || sql != "Yes" {
+		detail := fmt.Sprintf("IO线程: %s, SQL线程: %s", io, sql)
+		for _, key := range []string{"Last_IO_Error", "Last_SQL_Error"} {
+			if replica[key] != "" {
+				detail += fmt.Sprintf(", %s: %s", key, replica[key])
+			}
+		}
+		report.add("主从复制", false, "%s", detail)
+		return
+	}
+	report.add("主从复制", true, "IO/SQL线程运行中，延迟 %s 秒", replica["Seconds_Behind_Source"])
+}
+
+// lastLines 返回文本的最后n行
+func lastLines(text string, n int) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return strings.Join(lines, "\n")
+}
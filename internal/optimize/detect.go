@@ -0,0 +1,108 @@
+package optimize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// serviceState 防火墙服务的当前状态
+type serviceState struct {
+	Installed bool
+	Enabled   bool // 开机自启
+	Active    bool // 正在运行
+	Rules     bool // UFW防火墙规则已启用（ufw status 为 active）
+}
+
+// selinuxState SELinux的当前状态和配置文件中的设置
+type selinuxState struct {
+	Installed bool
+	Current   string // getenforce 输出：Enforcing、Permissive、Disabled
+	Config    string // /etc/selinux/config 中的 SELINUX= 值
+}
+
+// swapState 当前激活的swap、fstab中的swap条目和未屏蔽的systemd swap单元
+type swapState struct {
+	Active  []string
+	Fstab   []fstabSwapEntry
+	Units   []string // 已加载且未屏蔽的 .swap 单元（fstab、systemd-gpt-auto-generator、zram等生成）
+	GPTAuto []string // 其中由 systemd-gpt-auto-generator 自动发现的GPT swap分区生成的单元
+}
+
+// Disabled 当前和重启后都不会启用swap
+func (s swapState) Disabled() bool {
+	return len(s.Active) == 0 && len(s.Fstab) == 0 && len(s.Units) == 0
+}
+
+// detectFirewalld 获取firewalld的安装、运行和开机自启状态
+func (o *SystemOptimizer) detectFirewalld(host config.Host) serviceState {
+	if err := o.buildSSHCommand(host, "command -v firewall-cmd").Run(); err != nil {
+		return serviceState{}
+	}
+	state := serviceState{Installed: true}
+	output, err := o.buildSSHCommand(host, "systemctl is-enabled firewalld").Output()
+	state.Enabled = err == nil && strings.TrimSpace(string(output)) == "enabled"
+	output, err = o.buildSSHCommand(host, "systemctl is-active firewalld").Output()
+	state.Active = err == nil && strings.TrimSpace(string(output)) == "active"
+	return state
+}
+
+// detectUFW 获取UFW的防火墙规则、服务运行和开机自启状态
+func (o *SystemOptimizer) detectUFW(host config.Host) serviceState {
+	if err := o.buildSSHCommand(host, "command -v ufw").Run(); err != nil {
+		return serviceState{}
+	}
+	state := serviceState{Installed: true}
+	if output, err := o.buildSSHCommand(host, "ufw status | head -1").Output(); err == nil {
+		state.Rules = strings.Contains(strings.TrimSpace(string(output)), "active")
+	}
+	output, err := o.buildSSHCommand(host, "systemctl is-active ufw 2>/dev/null").Output()
+	state.Active = err == nil && strings.TrimSpace(string(output)) == "active"
+	output, err = o.buildSSHCommand(host, "systemctl is-enabled ufw 2>/dev/null").Output()
+	state.Enabled = err == nil && strings.TrimSpace(string(output)) == "enabled"
+	return state
+}
+
+// detectSELinux 获取SELinux的当前模式和配置文件设置
+func (o *SystemOptimizer) detectSELinux(host config.Host) (selinuxState, error) {
+	if err := o.buildSSHCommand(host, "command -v getenforce").Run(); err != nil {
+		return selinuxState{}, nil
+	}
+	output, err := o.buildSSHCommand(host, "getenforce").Output()
+	if err != nil {
+		return selinuxState{}, fmt.Errorf("获取SELinux状态失败: %w", err)
+	}
+	configOutput, _ := o.buildSSHCommand(host, "grep '^SELINUX=' /etc/selinux/config 2>/dev/null | cut -d= -f2").Output()
+	return selinuxState{
+		Installed: true,
+		Current:   strings.TrimSpace(string(output)),
+		Config:    strings.TrimSpace(string(configOutput)),
+	}, nil
+}
+
+// Disabled SELinux当前和重启后都处于禁用状态
+func (s selinuxState) Disabled() bool {
+	return !s.Installed || (s.Current == "Disabled" && s.Config == "disabled")
+}
+
+// detectSwap 读取 /proc/swaps、/etc/fstab 中的swap和systemd swap单元
+func (o *SystemOptimizer) detectSwap(host config.Host) (swapState, error) {
+	procSwaps, err := o.buildSSHCommand(host, "cat /proc/swaps").Output()
+	if err != nil {
+		return swapState{}, fmt.Errorf("读取/proc/swaps失败: %w", err)
+	}
+	fstab, err := o.buildSSHCommand(host, "cat /etc/fstab").Output()
+	if err != nil {
+		return swapState{}, fmt.Errorf("读取/etc/fstab失败: %w", err)
+	}
+	// 没有systemd时无法列出单元，只处理fstab
+	units, _ := o.buildSSHCommand(host, swapUnitsCmd).Output()
+	gptAuto, _ := o.buildSSHCommand(host, gptAutoSwapUnitsCmd).Output()
+	return swapState{
+		Active:  parseActiveSwaps(string(procSwaps)),
+		Fstab:   findFstabSwapEntries(string(fstab)),
+		Units:   parseSwapUnits(string(units)),
+		GPTAuto: parseSwapUnits(string(gptAuto)),
+	}, nil
+}
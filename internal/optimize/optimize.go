@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"sync"
 
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
+	"github.com/rainbond/rainbond-offline-installer/internal/templates"
 	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
 )
 
 // Logger 定义日志接口
@@ -26,10 +30,21 @@ type StepProgress interface {
 	CompleteNodeStep(nodeIP string)
 }
 
+const (
+	sysctlFile = "/etc/sysctl.conf"
+	limitsFile = "/etc/security/limits.conf"
+)
+
 type SystemOptimizer struct {
-	config       *config.Config
-	logger       Logger
-	stepProgress StepProgress
+	config          *config.Config
+	logger          Logger
+	stepProgress    StepProgress
+	profile         string             // 命令行指定的调优档位，为空时使用配置文件设置
+	skipHosts       map[string]bool    // 配置未变更且上次优化成功的主机，本次跳过
+	continueOnError bool               // 某个主机优化失败时继续处理其余主机，最后汇总报告
+	runner          ssh.Runner         // 非nil时远程命令通过 Runner 执行
+	warnings        []advisory.Warning // 优化过程中发现的需要用户处理的问题，如需要重启
+	warningsMu      sync.Mutex         // 并行优化时保护 warnings
 }
 
 func NewSystemOptimizer(cfg *config.Config) *SystemOptimizer {
@@ -48,13 +63,37 @@ func NewSystemOptimizerWithLoggerAndProgress(cfg *config.Config, logger Logger,
 	}
 }
 
+// SetContinueOnError 设置某个主机优化失败时是否继续处理其余主机，结束后返回汇总所有失败主机的 ssh.HostErrors
+func (o *SystemOptimizer) SetContinueOnError(continueOnError bool) {
+	o.continueOnError = continueOnError
+}
+
+// SetSkipHosts 设置本次跳过优化的主机（配置未变更且上次优化成功）
+func (o *SystemOptimizer) SetSkipHosts(hosts []config.Host) {
+	o.skipHosts = make(map[string]bool)
+	for _, host := range hosts {
+		o.skipHosts[host.IP] = true
+	}
+}
+
 func (o *SystemOptimizer) Run() error {
 	if o.logger != nil {
 		o.logger.Info("开始系统优化...")
 	}
 
-	// 对每个节点进行逐个优化
+	var hosts []config.Host
 	for _, host := range o.config.Hosts {
+		if o.skipHosts[host.IP] {
+			if o.logger != nil {
+				o.logger.Info("主机 %s: 配置未变更且上次优化成功，跳过", host.IP)
+			}
+			continue
+		}
+		hosts = append(hosts, host)
+	}
+
+	// 各节点的优化互不依赖，按全局并发数并行执行
+	errs := ssh.ForEachHost(hosts, func(host config.Host) error {
 		// 开始处理当前节点
 		if o.stepProgress != nil {
 			o.stepProgress.StartNodeProcessing(host.IP)
@@ -65,13 +104,24 @@ func (o *SystemOptimizer) Run() error {
 			if o.logger != nil {
 				o.logger.Error("节点 %s 系统优化失败: %v", host.IP, err)
 			}
-			return fmt.Errorf("节点 %s 系统优化失败: %w", host.IP, err)
+			return err
 		}
 
 		// 完成当前节点的优化
 		if o.stepProgress != nil {
 			o.stepProgress.CompleteNodeStep(host.IP)
 		}
+		return nil
+	})
+	failures := ssh.CollectHostErrors(hosts, errs)
+	if len(failures) > 0 {
+		if o.continueOnError {
+			if o.logger != nil {
+				o.logger.Error("系统优化完成，%d/%d 个主机失败: %v", len(failures), len(hosts), failures)
+			}
+			return failures
+		}
+		return fmt.Errorf("节点 %s 系统优化失败: %w", failures[0].Host, failures[0].Err)
 	}
 
 	if o.logger != nil {
@@ -102,6 +152,7 @@ func (o *SystemOptimizer) optimizeSingleHost(host config.Host) error {
 		{"禁用交换分区", o.disableSwap},
 		{"优化内核参数", o.optimizeKernelParameters},
 		{"优化系统限制", o.optimizeSystemLimits},
+		{"设置时区", o.setTimezone},
 	}
 
 	for _, opt := range optimizeFuncs {
@@ -139,24 +190,15 @@ func (o *SystemOptimizer) disableFirewalld(host config.Host) error {
 		o.logger.Info("主机 %s: 禁用firewalld防火墙...", host.IP)
 	}
 
-	// 检查 firewalld 是否安装
-	sshCmd := o.buildSSHCommand(host, "command -v firewall-cmd")
-	if err := sshCmd.Run(); err != nil {
+	state := o.detectFirewalld(host)
+	if !state.Installed {
 		if o.logger != nil {
 			o.logger.Info("主机 %s: 系统未安装firewalld", host.IP)
 		}
 		return nil
 	}
-
-	// 检查 firewalld 是否启用
-	sshCmd = o.buildSSHCommand(host, "systemctl is-enabled firewalld")
-	output, err := sshCmd.Output()
-	isEnabled := err == nil && strings.TrimSpace(string(output)) == "enabled"
-
-	// 检查 firewalld 是否运行
-	sshCmd = o.buildSSHCommand(host, "systemctl is-active firewalld")
-	output, err = sshCmd.Output()
-	isActive := err == nil && strings.TrimSpace(string(output)) == "active"
+	isEnabled, isActive := state.Enabled, state.Active
+	var sshCmd *ssh.Command
 
 	if !isActive && !isEnabled {
 		if o.logger != nil {
@@ -201,33 +243,15 @@ func (o *SystemOptimizer) disableUFW(host config.Host) error {
 		o.logger.Info("主机 %s: 禁用UFW防火墙...", host.IP)
 	}
 
-	// 检查 UFW 是否安装
-	sshCmd := o.buildSSHCommand(host, "command -v ufw")
-	if err := sshCmd.Run(); err != nil {
+	state := o.detectUFW(host)
+	if !state.Installed {
 		if o.logger != nil {
 			o.logger.Info("主机 %s: 系统未安装UFW", host.IP)
 		}
 		return nil
 	}
-
-	// 检查 UFW 防火墙状态
-	sshCmd = o.buildSSHCommand(host, "ufw status | head -1")
-	output, err := sshCmd.Output()
-	var firewallActive bool
-	if err == nil {
-		status := strings.TrimSpace(string(output))
-		firewallActive = strings.Contains(status, "active")
-	}
-
-	// 检查 UFW 服务状态
-	sshCmd = o.buildSSHCommand(host, "systemctl is-active ufw 2>/dev/null")
-	output, err = sshCmd.Output()
-	serviceActive := err == nil && strings.TrimSpace(string(output)) == "active"
-
-	// 检查 UFW 服务是否启用
-	sshCmd = o.buildSSHCommand(host, "systemctl is-enabled ufw 2>/dev/null")
-	output, err = sshCmd.Output()
-	serviceEnabled := err == nil && strings.TrimSpace(string(output)) == "enabled"
+	firewallActive, serviceActive, serviceEnabled := state.Rules, state.Active, state.Enabled
+	var sshCmd *ssh.Command
 
 	// 如果防火墙和服务都已禁用，跳过操作
 	if !firewallActive && !serviceActive && !serviceEnabled {
@@ -296,31 +320,21 @@ func (o *SystemOptimizer) disableSELinux(host config.Host) error {
 		o.logger.Info("主机 %s: 禁用SELinux...", host.IP)
 	}
 
-	// 检查 SELinux 是否安装
-	sshCmd := o.buildSSHCommand(host, "command -v getenforce")
-	if err := sshCmd.Run(); err != nil {
+	state, err := o.detectSELinux(host)
+	if err != nil {
+		return err
+	}
+	if !state.Installed {
 		if o.logger != nil {
 			o.logger.Info("主机 %s: 系统未安装SELinux", host.IP)
 		}
 		return nil
 	}
-
-	// 获取当前 SELinux 状态
-	sshCmd = o.buildSSHCommand(host, "getenforce")
-	output, err := sshCmd.Output()
-	if err != nil {
-		return fmt.Errorf("获取SELinux状态失败: %w", err)
-	}
-
-	currentStatus := strings.TrimSpace(string(output))
-	
-	// 检查配置文件中的SELinux设置
-	sshCmd = o.buildSSHCommand(host, "grep '^SELINUX=' /etc/selinux/config 2>/dev/null | cut -d= -f2")
-	configOutput, _ := sshCmd.Output()
-	configStatus := strings.TrimSpace(string(configOutput))
+	currentStatus, configStatus := state.Current, state.Config
+	var sshCmd *ssh.Command
 
 	// 如果已经完全禁用，跳过操作
-	if currentStatus == "Disabled" && configStatus == "disabled" {
+	if state.Disabled() {
 		if o.logger != nil {
 			o.logger.Info("主机 %s: SELinux已完全禁用，跳过操作", host.IP)
 		}
@@ -360,250 +374,191 @@ func (o *SystemOptimizer) disableSELinux(host config.Host) error {
 		if o.logger != nil {
 			o.logger.Info("主机 %s: 成功永久禁用SELinux（需要重启生效）", host.IP)
 		}
+		o.addWarning(advisory.CategoryRebootRequired, host.IP,
+			fmt.Sprintf("主机 %s 已在配置文件中禁用SELinux（当前 %s），重启后完全生效", host.IP, currentStatus))
 	}
 
 	return nil
 }
 
-func (o *SystemOptimizer) disableSwap(host config.Host) error {
+func (o *SystemOptimizer) optimizeKernelParameters(host config.Host) error {
 	if o.logger != nil {
-		o.logger.Info("主机 %s: 禁用交换分区...", host.IP)
+		o.logger.Info("主机 %s: 优化内核参数...", host.IP)
 	}
 
-	// 检查是否有激活的交换分区
-	sshCmd := o.buildSSHCommand(host, "swapon -s | wc -l")
-	output, err := sshCmd.Output()
-	var swapActive bool
-	if err == nil {
-		lines := strings.TrimSpace(string(output))
-		swapActive = lines != "0" && lines != "1" // 标题行算1行，所以>1表示有swap
+	block, profile, err := o.sysctlBlock()
+	if err != nil {
+		return err
 	}
+	sysctlConfig := block.Content
 
-	// 检查 /etc/fstab 中是否有swap条目
-	sshCmd = o.buildSSHCommand(host, "grep -v '^#' /etc/fstab | grep -c swap || true")
-	output, err = sshCmd.Output()
-	var swapInFstab bool
-	if err == nil {
-		count := strings.TrimSpace(string(output))
-		swapInFstab = count != "0"
+	// 写入 /etc/sysctl.conf 中由roi管理的区块，保留文件中的其他参数；同时清理旧版本整体覆盖写入的重复行
+	if o.logger != nil {
+		o.logger.Info("主机 %s: 写入内核参数配置（档位: %s）", host.IP, profile.Name)
 	}
-
-	// 如果交换分区已完全禁用，跳过操作
-	if !swapActive && !swapInFstab {
+	changed, err := ssh.WriteManagedBlock(host, o.buildSSHCommand, block)
+	if err != nil {
+		return fmt.Errorf("写入内核参数配置失败: %w", err)
+	}
+	if !changed {
 		if o.logger != nil {
-			o.logger.Info("主机 %s: 交换分区已完全禁用，跳过操作", host.IP)
+			o.logger.Info("主机 %s: 内核参数已按 %s 档位优化，跳过操作", host.IP, profile.Name)
 		}
 		return nil
 	}
 
-	// 禁用当前激活的交换分区
-	if swapActive {
+	// 应用 sysctl 设置，部分参数可能不被当前内核支持，sysctl -p 会继续应用其余参数
+	if o.logger != nil {
+		o.logger.Info("主机 %s: 应用内核参数设置", host.IP)
+	}
+	sshCmd := o.buildSSHCommand(host, "sysctl -p 2>&1")
+	if output, err := sshCmd.CombinedOutput(); err != nil {
 		if o.logger != nil {
-			o.logger.Info("主机 %s: 关闭当前激活的交换分区", host.IP)
-		}
-		sshCmd = o.buildSSHCommand(host, "swapoff -a")
-		if err := sshCmd.Run(); err != nil {
-			if o.logger != nil {
-				o.logger.Warn("主机 %s: 关闭交换分区失败: %v", host.IP, err)
-			}
-		} else {
-			if o.logger != nil {
-				o.logger.Info("主机 %s: 成功关闭激活的交换分区", host.IP)
-			}
+			o.logger.Warn("主机 %s: 某些内核参数可能不被支持: %v", host.IP, err)
+			o.logger.Debug("主机 %s: sysctl -p 输出:\n%s", host.IP, strings.TrimSpace(string(output)))
 		}
 	}
 
-	// 注释掉 /etc/fstab 中的交换分区条目
-	if swapInFstab {
+	// 回读实际生效值，报告未生效的参数
+	rejected, err := o.verifySysctl(host, parseSysctlConfig(sysctlConfig))
+	if err != nil {
 		if o.logger != nil {
-			o.logger.Info("主机 %s: 注释/etc/fstab中的交换分区条目", host.IP)
-		}
-		sshCmd = o.buildSSHCommand(host, "sed -i '/swap/s/^/#/' /etc/fstab")
-		if err := sshCmd.Run(); err != nil {
-			return fmt.Errorf("注释/etc/fstab中的交换分区条目失败: %w", err)
+			o.logger.Warn("主机 %s: %v，无法确认内核参数是否生效", host.IP, err)
 		}
+	} else if len(rejected) > 0 {
 		if o.logger != nil {
-			o.logger.Info("主机 %s: 成功注释/etc/fstab中的交换分区条目", host.IP)
+			o.logger.Warn("主机 %s: %d 个内核参数未生效:\n  - %s", host.IP, len(rejected), strings.Join(rejected, "\n  - "))
 		}
+	} else if o.logger != nil {
+		o.logger.Info("主机 %s: 所有内核参数均已生效", host.IP)
 	}
 
+	if o.logger != nil {
+		o.logger.Info("主机 %s: 内核参数优化成功", host.IP)
+	}
 	return nil
 }
 
-
-func (o *SystemOptimizer) optimizeKernelParameters(host config.Host) error {
+func (o *SystemOptimizer) optimizeSystemLimits(host config.Host) error {
 	if o.logger != nil {
-		o.logger.Info("主机 %s: 优化内核参数...", host.IP)
+		o.logger.Info("主机 %s: 优化系统限制...", host.IP)
 	}
 
-	// 检查是否已经优化过（通过检查配置文件是否存在我们的标识）
-	sshCmd := o.buildSSHCommand(host, "grep -q 'Network bridge settings for container networking' /etc/sysctl.conf 2>/dev/null")
-	if err := sshCmd.Run(); err == nil {
-		if o.logger != nil {
-			o.logger.Info("主机 %s: 内核参数已优化，跳过操作", host.IP)
-		}
-		return nil
+	block, profile, err := o.limitsBlock()
+	if err != nil {
+		return err
 	}
 
-	// 创建优化的 sysctl 配置（兼容性更好的版本）
-	sysctlConfig := `# Network bridge settings for container networking
-net.bridge.bridge-nf-call-ip6tables=1
-net.bridge.bridge-nf-call-iptables=1
-net.ipv4.ip_forward=1
-net.ipv4.conf.all.forwarding=1
-
-# Neighbor table settings
-net.ipv4.neigh.default.gc_thresh1=4096
-net.ipv4.neigh.default.gc_thresh2=6144
-net.ipv4.neigh.default.gc_thresh3=8192
-
-# Performance monitoring
-kernel.perf_event_paranoid=-1
-
-# Sysctls for k8s node configuration
-net.core.rmem_max=16777216
-fs.inotify.max_user_watches=524288
-
-# File system limits
-fs.file-max=2097152
-fs.inotify.max_user_instances=8192
-fs.inotify.max_queued_events=16384
-vm.max_map_count=262144
-
-# Network performance tuning
-net.core.netdev_max_backlog=16384
-net.core.wmem_max=16777216
-net.core.somaxconn=32768
-net.ipv4.tcp_max_syn_backlog=8096
-
-# Disable IPv6 (if not needed)
-net.ipv6.conf.all.disable_ipv6=1
-net.ipv6.conf.default.disable_ipv6=1
-net.ipv6.conf.lo.disable_ipv6=1
-
-# Memory and debugging settings
-vm.swappiness=0
-
-# Security settings
-net.ipv4.conf.default.accept_source_route=0
-net.ipv4.conf.all.accept_source_route=0
-net.ipv4.conf.default.promote_secondaries=1
-net.ipv4.conf.all.promote_secondaries=1
-
-# Source route verification
-net.ipv4.conf.all.rp_filter=0
-net.ipv4.conf.default.rp_filter=0
-net.ipv4.conf.default.arp_announce=2
-net.ipv4.conf.lo.arp_announce=2
-net.ipv4.conf.all.arp_announce=2
-
-# TCP optimization
-net.ipv4.tcp_max_tw_buckets=5000
-net.ipv4.tcp_syncookies=1
-net.ipv4.tcp_fin_timeout=30
-net.ipv4.tcp_synack_retries=2`
-
-	// 写入 sysctl 配置
+	// 写入 /etc/security/limits.conf 中由roi管理的区块，保留文件中的其他限制
 	if o.logger != nil {
-		o.logger.Info("主机 %s: 写入内核参数配置文件", host.IP)
+		o.logger.Info("主机 %s: 写入系统限制配置", host.IP)
 	}
-	sshCmd = o.buildSSHCommand(host, fmt.Sprintf("cat > /etc/sysctl.conf << 'EOF'\n%s\nEOF", sysctlConfig))
-	if err := sshCmd.Run(); err != nil {
-		return fmt.Errorf("写入内核参数配置失败: %w", err)
-	}
-
-	// 应用 sysctl 设置（忽略错误，因为某些参数可能不支持）
-	if o.logger != nil {
-		o.logger.Info("主机 %s: 应用内核参数设置", host.IP)
+	changed, err := ssh.WriteManagedBlock(host, o.buildSSHCommand, block)
+	if err != nil {
+		return fmt.Errorf("写入系统限制配置失败: %w", err)
 	}
-	sshCmd = o.buildSSHCommand(host, "sysctl -p")
-	if err := sshCmd.Run(); err != nil {
+	if !changed {
 		if o.logger != nil {
-			o.logger.Warn("主机 %s: 某些内核参数可能不被支持: %v", host.IP, err)
+			o.logger.Info("主机 %s: 系统限制已按 %s 档位优化，跳过操作", host.IP, profile.Name)
 		}
-		// 尝试逐个应用参数，忽略不支持的参数
-		sshCmd = o.buildSSHCommand(host, "sysctl -p 2>&1 | grep -v 'No such file or directory' | grep -v 'Invalid argument' || true")
-		sshCmd.Run() // 忽略错误
+		return nil
 	}
 
 	if o.logger != nil {
-		o.logger.Info("主机 %s: 内核参数优化成功", host.IP)
+		o.logger.Info("主机 %s: 系统限制优化成功", host.IP)
 	}
 	return nil
 }
 
-func (o *SystemOptimizer) optimizeSystemLimits(host config.Host) error {
-	if o.logger != nil {
-		o.logger.Info("主机 %s: 优化系统限制...", host.IP)
+// sysctlBlock 按调优档位渲染 sysctl 配置区块，可通过 optimize.sysctl_template 替换模板
+func (o *SystemOptimizer) sysctlBlock() (ssh.ManagedBlock, Profile, error) {
+	profile, err := o.currentProfile()
+	if err != nil {
+		return ssh.ManagedBlock{}, profile, err
+	}
+	content, err := templates.Render(templates.Sysctl, o.config.Optimize.SysctlTemplate, profile)
+	if err != nil {
+		return ssh.ManagedBlock{}, profile, fmt.Errorf("生成内核参数配置失败: %w", err)
 	}
+	return ssh.ManagedBlock{Path: sysctlFile, Name: "sysctl", Content: content, Dedupe: true}, profile, nil
+}
 
-	// 检查是否已经优化过（通过检查配置文件是否包含我们的标识）
-	sshCmd := o.buildSSHCommand(host, "grep -q 'Increased file descriptor limits for containerized workloads' /etc/security/limits.conf 2>/dev/null")
-	if err := sshCmd.Run(); err == nil {
-		if o.logger != nil {
-			o.logger.Info("主机 %s: 系统限制已优化，跳过操作", host.IP)
-		}
-		return nil
+// limitsBlock 按调优档位渲染 limits 配置区块，可通过 optimize.limits_template 替换模板
+func (o *SystemOptimizer) limitsBlock() (ssh.ManagedBlock, Profile, error) {
+	profile, err := o.currentProfile()
+	if err != nil {
+		return ssh.ManagedBlock{}, profile, err
+	}
+	content, err := templates.Render(templates.Limits, o.config.Optimize.LimitsTemplate, profile)
+	if err != nil {
+		return ssh.ManagedBlock{}, profile, fmt.Errorf("生成系统限制配置失败: %w", err)
 	}
+	return ssh.ManagedBlock{Path: limitsFile, Name: "limits", Content: content, Dedupe: true}, profile, nil
+}
 
-	// 创建优化的 limits 配置
-	limitsConfig := `# Increased file descriptor limits for containerized workloads
-* soft nofile 1024000
-* hard nofile 1024000
-* soft nproc 1024000
-* hard nproc 1024000`
+// addWarning 记录需要用户处理的问题，可在并行优化中调用
+func (o *SystemOptimizer) addWarning(category, host, message string) {
+	o.warningsMu.Lock()
+	defer o.warningsMu.Unlock()
+	o.warnings = append(o.warnings, advisory.Warning{Category: category, Host: host, Message: message})
+}
 
-	// 写入 limits 配置
-	if o.logger != nil {
-		o.logger.Info("主机 %s: 写入系统限制配置文件", host.IP)
-	}
-	sshCmd = o.buildSSHCommand(host, fmt.Sprintf("cat > /etc/security/limits.conf << 'EOF'\n%s\nEOF", limitsConfig))
-	if err := sshCmd.Run(); err != nil {
-		return fmt.Errorf("写入系统限制配置失败: %w", err)
+// Warnings 返回优化过程中记录的警告
+func (o *SystemOptimizer) Warnings() []advisory.Warning {
+	o.warningsMu.Lock()
+	defer o.warningsMu.Unlock()
+	ips := make([]string, 0, len(o.config.Hosts))
+	for _, host := range o.config.Hosts {
+		ips = append(ips, host.IP)
 	}
+	return advisory.SortByHosts(o.warnings, ips)
+}
 
-	if o.logger != nil {
-		o.logger.Info("主机 %s: 系统限制优化成功", host.IP)
+// SetRunner 替换执行系统优化命令的 Runner，测试中可模拟防火墙、SELinux、swap 的检测结果；sysctl和limits配置块也经由它写入
+func (o *SystemOptimizer) SetRunner(runner ssh.Runner) {
+	o.runner = runner
+}
+
+// buildSSHCommand 构建在主机上执行的优化命令，设置了 Runner 时交由 Runner 执行，同时作为 ssh.WriteManagedBlock 的命令构造函数
+func (o *SystemOptimizer) buildSSHCommand(host config.Host, command string) *ssh.Command {
+	if o.runner != nil {
+		return ssh.NewRunnerCommand(o.runner, host, command)
 	}
-	return nil
+	return o.sshCommand(host, command)
 }
 
-func (o *SystemOptimizer) buildSSHCommand(host config.Host, command string) *exec.Cmd {
+// sshCommand 构建系统ssh命令，修改内核参数、关闭防火墙等操作要求root权限（见 checkRootUser）
+func (o *SystemOptimizer) sshCommand(host config.Host, command string) *ssh.Command {
 	var sshCmd *exec.Cmd
+	command = ssh.WrapBecome(host, command)
 
 	if host.Password != "" {
-		// 检查 sshpass 是否可用
-		if _, err := exec.LookPath("sshpass"); err != nil {
-			if o.logger != nil {
-				o.logger.Warn("未找到sshpass工具，请安装sshpass或为主机 %s 使用SSH密钥认证", host.IP)
-			}
-			sshCmd = exec.Command("ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "BatchMode=no",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		} else {
-			sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		}
+		// sshpass 已在启动时检查
+		sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
+			fmt.Sprintf("%s@%s", host.User, host.IP),
+			command)
 	} else if host.SSHKey != "" {
 		sshCmd = exec.Command("ssh",
 			"-i", host.SSHKey,
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	} else {
 		sshCmd = exec.Command("ssh",
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	}
 
-	return sshCmd
+	return ssh.NewCommand(host, command, sshCmd)
 }
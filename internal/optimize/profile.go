@@ -0,0 +1,133 @@
+package optimize
+
+import (
+	"fmt"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// Profile 系统调优档位，决定sysctl和limits模板中的参数取值
+//
+// 各档位相对 balanced 的差异：
+//   - high-throughput: 增大socket缓冲区（rmem_max/wmem_max 64M）、somaxconn/tcp_max_syn_backlog 65535、
+//     netdev_max_backlog 250000、tcp_max_tw_buckets 262144、邻居表阈值翻倍、fs.file-max 4194304，
+//     扩大本地端口范围（1024-65535）并开启 tcp_tw_reuse，适合网关和高并发节点
+//   - low-memory: 减小socket缓冲区（4M）、somaxconn 4096、tcp_max_syn_backlog 2048、netdev_max_backlog 4096、
+//     邻居表阈值减半、fs.file-max 1048576、inotify watches 262144 / instances 1024、nofile/nproc 655360，
+//     适合内存较小的边缘或测试节点
+type Profile struct {
+	Name                    string
+	RmemMax                 int
+	WmemMax                 int
+	Somaxconn               int
+	TCPMaxSynBacklog        int
+	NetdevMaxBacklog        int
+	TCPMaxTwBuckets         int
+	TCPTwReuse              bool
+	IPLocalPortRange        string // 为空时不修改系统默认值
+	GCThresh1               int
+	GCThresh2               int
+	GCThresh3               int
+	FileMax                 int
+	MaxMapCount             int
+	InotifyMaxUserWatches   int
+	InotifyMaxUserInstances int
+	NoFile                  int
+	NProc                   int
+	KeepIPv6                bool // 集群使用IPv6或双栈时不禁用IPv6，由配置推导而非档位决定
+}
+
+// profiles 内置调优档位
+var profiles = map[string]Profile{
+	config.OptimizeProfileBalanced: {
+		Name:                    config.OptimizeProfileBalanced,
+		RmemMax:                 16777216,
+		WmemMax:                 16777216,
+		Somaxconn:               32768,
+		TCPMaxSynBacklog:        8096,
+		NetdevMaxBacklog:        16384,
+		TCPMaxTwBuckets:         5000,
+		GCThresh1:               4096,
+		GCThresh2:               6144,
+		GCThresh3:               8192,
+		FileMax:                 2097152,
+		MaxMapCount:             262144,
+		InotifyMaxUserWatches:   524288,
+		InotifyMaxUserInstances: 8192,
+		NoFile:                  1024000,
+		NProc:                   1024000,
+	},
+	config.OptimizeProfileHighThroughput: {
+		Name:                    config.OptimizeProfileHighThroughput,
+		RmemMax:                 67108864,
+		WmemMax:                 67108864,
+		Somaxconn:               65535,
+		TCPMaxSynBacklog:        65535,
+		NetdevMaxBacklog:        250000,
+		TCPMaxTwBuckets:         262144,
+		TCPTwReuse:              true,
+		IPLocalPortRange:        "1024 65535",
+		GCThresh1:               8192,
+		GCThresh2:               16384,
+		GCThresh3:               32768,
+		FileMax:                 4194304,
+		MaxMapCount:             262144,
+		InotifyMaxUserWatches:   524288,
+		InotifyMaxUserInstances: 8192,
+		NoFile:                  1048576,
+		NProc:                   1048576,
+	},
+	config.OptimizeProfileLowMemory: {
+		Name:                    config.OptimizeProfileLowMemory,
+		RmemMax:                 4194304,
+		WmemMax:                 4194304,
+		Somaxconn:               4096,
+		TCPMaxSynBacklog:        2048,
+		NetdevMaxBacklog:        4096,
+		TCPMaxTwBuckets:         5000,
+		GCThresh1:               2048,
+		GCThresh2:               3072,
+		GCThresh3:               4096,
+		FileMax:                 1048576,
+		MaxMapCount:             262144,
+		InotifyMaxUserWatches:   262144,
+		InotifyMaxUserInstances: 1024,
+		NoFile:                  655360,
+		NProc:                   655360,
+	},
+}
+
+// SetProfile 设置调优档位，覆盖配置文件中的 optimize.profile，为空时保持配置文件设置
+func (o *SystemOptimizer) SetProfile(name string) error {
+	if err := config.ValidateOptimizeProfile(name); err != nil {
+		return err
+	}
+	o.profile = name
+	return nil
+}
+
+// currentProfile 获取生效的调优档位：命令行 > 配置文件 > balanced
+func (o *SystemOptimizer) currentProfile() (Profile, error) {
+	name := o.profile
+	if name == "" {
+		name = o.config.Optimize.Profile
+	}
+	profile, err := LookupProfile(name)
+	if err != nil {
+		return Profile{}, err
+	}
+	profile.KeepIPv6 = o.config.UsesIPv6()
+	return profile, nil
+}
+
+// LookupProfile 按名称获取内置调优档位，为空时返回默认的 balanced 档位
+func LookupProfile(name string) (Profile, error) {
+	if name == "" {
+		name = config.OptimizeProfileBalanced
+	}
+	profile, ok := profiles[name]
+	if !ok {
+		return Profile{}, fmt.Errorf("未知的调优档位: %s", name)
+	}
+	return profile, nil
+}
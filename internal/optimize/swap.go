@@ -0,0 +1,167 @@
+package optimize
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+const (
+	// swapCommentMarker 注释fstab中swap条目时添加的前缀，回滚时去掉该前缀即可恢复
+	swapCommentMarker = "#roi-swap# "
+	// swapStateFile 记录禁用swap时所做变更的文件，用于回滚
+	swapStateFile = "/var/lib/roi/swap-changes.log"
+	// swapUnitsCmd 列出已加载且未屏蔽的swap单元，输出每行一个单元名
+	swapUnitsCmd = "systemctl list-units --type=swap --all --no-legend --plain 2>/dev/null | awk '$2 != \"masked\" {print $1}'"
+	// gptAutoSwapUnitsCmd 列出systemd-gpt-auto-generator生成的swap单元，这类swap不在fstab中，重启后自动启用
+	gptAutoSwapUnitsCmd = "ls /run/systemd/generator.late 2>/dev/null | grep '\\.swap$'"
+)
+
+// fstabSwapEntry /etc/fstab 中的swap条目
+type fstabSwapEntry struct {
+	LineNumber int    // 从1开始的行号
+	Device     string // 第一列，设备、文件或UUID/LABEL
+	Line       string
+}
+
+// findFstabSwapEntries 查找fstab中文件系统类型为swap的有效条目
+// 只匹配第三列（文件系统类型），避免误改路径或标签中包含swap字样的其他条目
+func findFstabSwapEntries(fstab string) []fstabSwapEntry {
+	var entries []fstabSwapEntry
+	for i, line := range strings.Split(fstab, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		fields := strings.Fields(trimmed)
+		if len(fields) >= 3 && fields[2] == "swap" {
+			entries = append(entries, fstabSwapEntry{LineNumber: i + 1, Device: fields[0], Line: line})
+		}
+	}
+	return entries
+}
+
+// parseActiveSwaps 解析 /proc/swaps，返回当前激活的swap设备或文件
+func parseActiveSwaps(procSwaps string) []string {
+	var devices []string
+	for _, line := range strings.Split(strings.TrimSpace(procSwaps), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || fields[0] == "Filename" {
+			continue
+		}
+		devices = append(devices, fields[0])
+	}
+	return devices
+}
+
+// parseSwapUnits 解析每行一个的swap单元名
+func parseSwapUnits(output string) []string {
+	var units []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if unit := strings.TrimSpace(line); strings.HasSuffix(unit, ".swap") {
+			units = append(units, unit)
+		}
+	}
+	return units
+}
+
+func (o *SystemOptimizer) disableSwap(host config.Host) error {
+	if o.logger != nil {
+		o.logger.Info("主机 %s: 禁用交换分区...", host.IP)
+	}
+
+	state, err := o.detectSwap(host)
+	if err != nil {
+		return err
+	}
+	activeSwaps, fstabEntries := state.Active, state.Fstab
+
+	// 如果交换分区已完全禁用，跳过操作
+	if state.Disabled() {
+		if o.logger != nil {
+			o.logger.Info("主机 %s: 交换分区已完全禁用，跳过操作", host.IP)
+		}
+		return nil
+	}
+
+	var changes []string
+
+	// 不在fstab中的激活swap（swapfile、zram等）由其他机制启用，swapoff后重启仍可能恢复
+	inFstab := make(map[string]bool)
+	for _, entry := range fstabEntries {
+		inFstab[entry.Device] = true
+	}
+	for _, device := range activeSwaps {
+		changes = append(changes, fmt.Sprintf("swapoff %s", device))
+		if !inFstab[device] && len(state.Units) == 0 {
+			if o.logger != nil {
+				o.logger.Warn("主机 %s: 激活的swap %s 不在/etc/fstab中且没有对应的systemd swap单元，重启后可能被其他脚本重新启用，请手动检查", host.IP, device)
+			}
+		}
+	}
+
+	// 禁用当前激活的交换分区
+	if len(activeSwaps) > 0 {
+		if o.logger != nil {
+			o.logger.Info("主机 %s: 关闭当前激活的交换分区: %s", host.IP, strings.Join(activeSwaps, ", "))
+		}
+		if output, err := o.buildSSHCommand(host, "swapoff -a").CombinedOutput(); err != nil {
+			if o.logger != nil {
+				o.logger.Warn("主机 %s: 关闭交换分区失败: %v, 输出: %s", host.IP, err, strings.TrimSpace(string(output)))
+			}
+		}
+	}
+
+	// 按行号注释 /etc/fstab 中的swap条目，修改前备份
+	if len(fstabEntries) > 0 {
+		var sedArgs []string
+		for _, entry := range fstabEntries {
+			sedArgs = append(sedArgs, fmt.Sprintf("-e '%ds/^/%s/'", entry.LineNumber, swapCommentMarker))
+			changes = append(changes, fmt.Sprintf("fstab:%d %s", entry.LineNumber, strings.TrimSpace(entry.Line)))
+		}
+		commentCmd := fmt.Sprintf("cp -p /etc/fstab /etc/fstab.roi-%s.bak && sed -i %s /etc/fstab",
+			time.Now().Format("20060102150405"), strings.Join(sedArgs, " "))
+
+		if o.logger != nil {
+			o.logger.Info("主机 %s: 注释/etc/fstab中的 %d 个交换分区条目", host.IP, len(fstabEntries))
+		}
+		if output, err := o.buildSSHCommand(host, commentCmd).CombinedOutput(); err != nil {
+			return fmt.Errorf("注释/etc/fstab中的交换分区条目失败: %w, 输出: %s", err, string(output))
+		}
+	}
+
+	// 屏蔽swap单元和swap.target，防止systemd在启动时重新启用swap（GPT自动发现的swap分区、swapfile单元、zram等）
+	if len(state.Units) > 0 {
+		if len(state.GPTAuto) > 0 && o.logger != nil {
+			o.logger.Info("主机 %s: 检测到systemd-gpt-auto-generator自动启用的swap分区: %s", host.IP, strings.Join(state.GPTAuto, ", "))
+		}
+		units := append([]string{"swap.target"}, state.Units...)
+		quoted := make([]string, len(units))
+		for i, unit := range units {
+			quoted[i] = "'" + unit + "'"
+			changes = append(changes, "mask "+unit)
+		}
+		if o.logger != nil {
+			o.logger.Info("主机 %s: 屏蔽systemd swap单元: %s", host.IP, strings.Join(units, ", "))
+		}
+		if output, err := o.buildSSHCommand(host, "systemctl mask "+strings.Join(quoted, " ")).CombinedOutput(); err != nil {
+			return fmt.Errorf("屏蔽systemd swap单元失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	// 记录变更，回滚方式: sed -i 's/^#roi-swap# //' /etc/fstab && systemctl unmask <单元> && swapon -a
+	recordCmd := fmt.Sprintf("mkdir -p $(dirname %s) && cat >> %s << 'EOF'\n# %s\n%s\nEOF",
+		swapStateFile, swapStateFile, time.Now().Format(time.RFC3339), strings.Join(changes, "\n"))
+	if err := o.buildSSHCommand(host, recordCmd).Run(); err != nil {
+		if o.logger != nil {
+			o.logger.Warn("主机 %s: 记录swap变更失败: %v", host.IP, err)
+		}
+	}
+
+	if o.logger != nil {
+		o.logger.Info("主机 %s: 交换分区已禁用，变更记录在 %s，回滚: sed -i 's/^%s//' /etc/fstab && systemctl unmask 记录中的单元 && swapon -a", host.IP, swapStateFile, swapCommentMarker)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package optimize
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindFstabSwapEntries(t *testing.T) {
+	tests := []struct {
+		name  string
+		fstab string
+		want  []fstabSwapEntry
+	}{
+		{
+			name:  "empty",
+			fstab: "",
+			want:  nil,
+		},
+		{
+			name: "comments and blank lines",
+			fstab: `# /etc/fstab: static file system information.
+#
+# <file system> <mount point> <type> <options> <dump> <pass>
+#/dev/sda2 none swap sw 0 0
+
+/dev/sda1 / ext4 defaults 0 1
+`,
+			want: nil,
+		},
+		{
+			name: "device with none and swap mount points",
+			fstab: `/dev/mapper/centos-root /                       xfs     defaults        0 0
+/dev/mapper/centos-swap swap                    swap    defaults        0 0
+/dev/sdb2	none	swap	sw	0	0
+`,
+			want: []fstabSwapEntry{
+				{LineNumber: 2, Device: "/dev/mapper/centos-swap", Line: "/dev/mapper/centos-swap swap                    swap    defaults        0 0"},
+				{LineNumber: 3, Device: "/dev/sdb2", Line: "/dev/sdb2\tnone\tswap\tsw\t0\t0"},
+			},
+		},
+		{
+			name: "UUID and LABEL entries",
+			fstab: `UUID=3f1d6c2e-5b0a-4e0b-9a51-1b2c3d4e5f60 / ext4 errors=remount-ro 0 1
+UUID=8a7b6c5d-4e3f-2a1b-0c9d-8e7f6a5b4c3d none swap sw 0 0
+LABEL=SWAP swap swap defaults 0 0
+`,
+			want: []fstabSwapEntry{
+				{LineNumber: 2, Device: "UUID=8a7b6c5d-4e3f-2a1b-0c9d-8e7f6a5b4c3d", Line: "UUID=8a7b6c5d-4e3f-2a1b-0c9d-8e7f6a5b4c3d none swap sw 0 0"},
+				{LineNumber: 3, Device: "LABEL=SWAP", Line: "LABEL=SWAP swap swap defaults 0 0"},
+			},
+		},
+		{
+			name: "swap file with leading whitespace",
+			fstab: `/dev/sda1 / ext4 defaults 0 1
+   /swapfile none swap sw 0 0
+`,
+			want: []fstabSwapEntry{
+				{LineNumber: 2, Device: "/swapfile", Line: "   /swapfile none swap sw 0 0"},
+			},
+		},
+		{
+			name: "entries already commented by roi",
+			fstab: `/dev/sda1 / ext4 defaults 0 1
+#roi-swap# /dev/sda2 none swap sw 0 0
+#roi-swap# /swapfile none swap sw 0 0
+`,
+			want: nil,
+		},
+		{
+			name: "swap only in path or label",
+			fstab: `/dev/sdc1 /mnt/swap ext4 defaults 0 2
+LABEL=swapdata /data xfs defaults 0 0
+/swap.img /mnt/swap.img none bind 0 0
+`,
+			want: nil,
+		},
+		{
+			name:  "incomplete line",
+			fstab: "/dev/sda2 swap\n",
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := findFstabSwapEntries(tt.fstab); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findFstabSwapEntries() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseActiveSwaps(t *testing.T) {
+	tests := []struct {
+		name      string
+		procSwaps string
+		want      []string
+	}{
+		{
+			name:      "empty",
+			procSwaps: "",
+			want:      nil,
+		},
+		{
+			name:      "header only",
+			procSwaps: "Filename\t\t\t\tType\t\tSize\t\tUsed\t\tPriority\n",
+			want:      nil,
+		},
+		{
+			name: "partition, swap file and zram",
+			procSwaps: `Filename				Type		Size		Used		Priority
+/dev/sda2                               partition	2097148		0		-2
+/swapfile                               file		1048572		0		-3
+/dev/zram0                              partition	4194300		1024		100
+`,
+			want: []string{"/dev/sda2", "/swapfile", "/dev/zram0"},
+		},
+		{
+			name:      "without header",
+			procSwaps: "/dev/dm-1 partition 8388604 0 -2",
+			want:      []string{"/dev/dm-1"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseActiveSwaps(tt.procSwaps); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseActiveSwaps() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package optimize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// sysctlMissing 读取参数失败（内核不支持该参数）时的占位值
+const sysctlMissing = "<missing>"
+
+// sysctlParam 配置文件中请求设置的内核参数
+type sysctlParam struct {
+	Key   string
+	Value string
+}
+
+// parseSysctlConfig 解析sysctl配置内容中的 key=value 参数，忽略注释和空行
+func parseSysctlConfig(content string) []sysctlParam {
+	var params []sysctlParam
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		params = append(params, sysctlParam{
+			Key:   strings.TrimSpace(key),
+			Value: normalizeSysctlValue(value),
+		})
+	}
+	return params
+}
+
+// normalizeSysctlValue 统一空白字符，sysctl 读取多值参数时以制表符分隔
+func normalizeSysctlValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// readSysctlValues 一次SSH调用读取所有参数的实际生效值
+func (o *SystemOptimizer) readSysctlValues(host config.Host, params []sysctlParam) (map[string]string, error) {
+	var script strings.Builder
+	for _, p := range params {
+		fmt.Fprintf(&script, "printf '%%s=' '%s'; sysctl -n '%s' 2>/dev/null || echo '%s'\n", p.Key, p.Key, sysctlMissing)
+	}
+
+	output, err := o.buildSSHCommand(host, script.String()).Output()
+	if err != nil {
+		return nil, fmt.Errorf("读取内核参数失败: %w", err)
+	}
+
+	values := make(map[string]string, len(params))
+	for _, line := range strings.Split(string(output), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		values[strings.TrimSpace(key)] = normalizeSysctlValue(value)
+	}
+	return values, nil
+}
+
+// verifySysctl 对比请求值和实际生效值，返回未生效参数的描述
+func (o *SystemOptimizer) verifySysctl(host config.Host, params []sysctlParam) ([]string, error) {
+	values, err := o.readSysctlValues(host, params)
+	if err != nil {
+		return nil, err
+	}
+
+	var rejected []string
+	for _, p := range params {
+		actual, ok := values[p.Key]
+		switch {
+		case !ok || actual == sysctlMissing:
+			rejected = append(rejected, fmt.Sprintf("%s: 内核不支持该参数（请求值 %s）", p.Key, p.Value))
+		case actual != p.Value:
+			rejected = append(rejected, fmt.Sprintf("%s: 请求值 %s，实际值 %s", p.Key, p.Value, actual))
+		}
+	}
+	return rejected, nil
+}
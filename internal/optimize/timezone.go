@@ -0,0 +1,62 @@
+package optimize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// currentTimezone 获取节点当前的时区名称
+func (o *SystemOptimizer) currentTimezone(host config.Host) (string, error) {
+	output, err := o.buildSSHCommand(host, "timedatectl show -p Timezone --value").Output()
+	if err != nil {
+		return "", fmt.Errorf("获取时区失败（需要timedatectl）: %w", err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// setTimezone 配置了node_timezone时将节点时区设置为该值，未配置时不做修改
+func (o *SystemOptimizer) setTimezone(host config.Host) error {
+	timezone := o.config.NodeTimezone
+	if timezone == "" {
+		return nil
+	}
+
+	current, err := o.currentTimezone(host)
+	if err != nil {
+		return err
+	}
+	if current == timezone {
+		if o.logger != nil {
+			o.logger.Info("主机 %s: 时区已为 %s，跳过设置", host.IP, timezone)
+		}
+		return nil
+	}
+
+	if o.logger != nil {
+		o.logger.Info("主机 %s: 将时区从 %s 设置为 %s", host.IP, current, timezone)
+	}
+	if output, err := o.buildSSHCommand(host, fmt.Sprintf("timedatectl set-timezone '%s'", timezone)).CombinedOutput(); err != nil {
+		return fmt.Errorf("设置时区 %s 失败: %w, 输出: %s", timezone, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// validateTimezone 检查节点时区是否为node_timezone，未配置时不检查
+func (o *SystemOptimizer) validateTimezone(host config.Host, result *HostCompliance) {
+	timezone := o.config.NodeTimezone
+	if timezone == "" {
+		return
+	}
+	current, err := o.currentTimezone(host)
+	if err != nil {
+		result.add("时区", false, "%v", err)
+		return
+	}
+	if current != timezone {
+		result.add("时区", false, "当前 %s，将设置为 %s", current, timezone)
+		return
+	}
+	result.add("时区", true, "已为 %s", timezone)
+}
@@ -0,0 +1,187 @@
+package optimize
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// ComplianceItem 单个优化项的合规检查结果
+type ComplianceItem struct {
+	Name      string
+	Compliant bool
+	Detail    string
+}
+
+// HostCompliance 单个主机的合规检查结果，Error 非空表示检查本身失败
+type HostCompliance struct {
+	Host  string
+	Items []ComplianceItem
+	Error string
+}
+
+// Deviations 返回需要优化的项
+func (h HostCompliance) Deviations() []ComplianceItem {
+	var items []ComplianceItem
+	for _, item := range h.Items {
+		if !item.Compliant {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func (h *HostCompliance) add(name string, compliant bool, format string, v ...interface{}) {
+	h.Items = append(h.Items, ComplianceItem{Name: name, Compliant: compliant, Detail: fmt.Sprintf(format, v...)})
+}
+
+// Validate 只检查各主机的防火墙、SELinux、swap、内核参数、系统限制和时区与优化目标的差异，不做任何修改，
+// 结果按配置中的主机顺序返回
+func (o *SystemOptimizer) Validate() []HostCompliance {
+	hosts := o.config.Hosts
+	index := make(map[string]int, len(hosts))
+	for i, host := range hosts {
+		index[host.IP] = i
+	}
+	// 每个主机只写入自己的下标，无需加锁
+	results := make([]HostCompliance, len(hosts))
+	ssh.ForEachHost(hosts, func(host config.Host) error {
+		results[index[host.IP]] = o.validateHost(host)
+		return nil
+	})
+	return results
+}
+
+// validateHost 检查单个主机，单项检查失败时记为不合规并继续检查其余项
+func (o *SystemOptimizer) validateHost(host config.Host) HostCompliance {
+	result := HostCompliance{Host: host.IP}
+	if err := o.checkRootUser(host); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	firewalld := o.detectFirewalld(host)
+	switch {
+	case !firewalld.Installed:
+		result.add("firewalld", true, "未安装")
+	case !firewalld.Active && !firewalld.Enabled:
+		result.add("firewalld", true, "已停止且未开机自启")
+	default:
+		result.add("firewalld", false, "%s，将停止并禁用开机自启", serviceStateText(firewalld))
+	}
+
+	ufw := o.detectUFW(host)
+	switch {
+	case !ufw.Installed:
+		result.add("UFW", true, "未安装")
+	case !ufw.Rules && !ufw.Active && !ufw.Enabled:
+		result.add("UFW", true, "已停用")
+	default:
+		detail := serviceStateText(ufw)
+		if ufw.Rules {
+			detail = "防火墙规则已启用，" + detail
+		}
+		result.add("UFW", false, "%s，将禁用", detail)
+	}
+
+	if selinux, err := o.detectSELinux(host); err != nil {
+		result.add("SELinux", false, "%v", err)
+	} else if !selinux.Installed {
+		result.add("SELinux", true, "未安装")
+	} else if selinux.Disabled() {
+		result.add("SELinux", true, "已禁用")
+	} else {
+		result.add("SELinux", false, "当前 %s，配置文件 SELINUX=%s，将设为 Permissive 并在配置文件中禁用", selinux.Current, selinux.Config)
+	}
+
+	if swap, err := o.detectSwap(host); err != nil {
+		result.add("swap", false, "%v", err)
+	} else if swap.Disabled() {
+		result.add("swap", true, "未启用")
+	} else {
+		var details []string
+		if len(swap.Active) > 0 {
+			details = append(details, "已激活 "+strings.Join(swap.Active, ", "))
+		}
+		if len(swap.Fstab) > 0 {
+			details = append(details, fmt.Sprintf("/etc/fstab 中有 %d 个swap条目", len(swap.Fstab)))
+		}
+		if len(swap.Units) > 0 {
+			details = append(details, "systemd swap单元 "+strings.Join(swap.Units, ", ")+" 未屏蔽")
+		}
+		result.add("swap", false, "%s，将 swapoff、注释fstab条目并屏蔽swap单元", strings.Join(details, "，"))
+	}
+
+	o.validateSysctl(host, &result)
+	o.validateLimits(host, &result)
+	o.validateTimezone(host, &result)
+	return result
+}
+
+// validateSysctl 检查 /etc/sysctl.conf 中roi管理的区块是否为最新，以及参数是否已实际生效
+func (o *SystemOptimizer) validateSysctl(host config.Host, result *HostCompliance) {
+	block, profile, err := o.sysctlBlock()
+	if err != nil {
+		result.add("内核参数", false, "%v", err)
+		return
+	}
+	changed, err := ssh.CheckManagedBlock(host, o.buildSSHCommand, block)
+	if err != nil {
+		result.add("内核参数", false, "%v", err)
+		return
+	}
+	rejected, err := o.verifySysctl(host, parseSysctlConfig(block.Content))
+	if err != nil {
+		result.add("内核参数", false, "%v", err)
+		return
+	}
+
+	var details []string
+	if changed {
+		details = append(details, fmt.Sprintf("%s 需要更新（档位: %s）", sysctlFile, profile.Name))
+	}
+	if len(rejected) > 0 {
+		details = append(details, fmt.Sprintf("%d 个参数未生效: %s", len(rejected), strings.Join(rejected, "; ")))
+	}
+	if len(details) > 0 {
+		result.add("内核参数", false, "%s", strings.Join(details, "，"))
+		return
+	}
+	result.add("内核参数", true, "已按档位 %s 配置并生效", profile.Name)
+}
+
+// validateLimits 检查 /etc/security/limits.conf 中roi管理的区块是否为最新
+func (o *SystemOptimizer) validateLimits(host config.Host, result *HostCompliance) {
+	block, profile, err := o.limitsBlock()
+	if err != nil {
+		result.add("系统限制", false, "%v", err)
+		return
+	}
+	changed, err := ssh.CheckManagedBlock(host, o.buildSSHCommand, block)
+	if err != nil {
+		result.add("系统限制", false, "%v", err)
+		return
+	}
+	if changed {
+		result.add("系统限制", false, "%s 需要更新（档位: %s）", limitsFile, profile.Name)
+		return
+	}
+	result.add("系统限制", true, "已按档位 %s 配置", profile.Name)
+}
+
+// serviceStateText 描述服务的运行和开机自启状态
+func serviceStateText(state serviceState) string {
+	var parts []string
+	if state.Active {
+		parts = append(parts, "运行中")
+	}
+	if state.Enabled {
+		parts = append(parts, "开机自启")
+	}
+	if len(parts) == 0 {
+		return "已停止"
+	}
+	return strings.Join(parts, "、")
+}
@@ -0,0 +1,213 @@
+package rainbond
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/cli"
+	"helm.sh/helm/v3/pkg/registry"
+)
+
+const (
+	// DefaultChartPath 未指定 --chart 时使用的本地chart包
+	DefaultChartPath = "./rainbond.tgz"
+	// chartDownloadDir 远程chart下载到工作目录下的该目录，避免覆盖本地的 rainbond.tgz
+	chartDownloadDir     = "charts"
+	chartDownloadTimeout = 10 * time.Minute
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// ValidateChartChecksum 检查chart sha256格式，允许 sha256: 前缀，为空表示不校验
+func ValidateChartChecksum(sum string) error {
+	sum = strings.TrimPrefix(strings.TrimSpace(sum), "sha256:")
+	if sum != "" && !sha256Pattern.MatchString(sum) {
+		return fmt.Errorf("invalid chart sha256 '%s': must be 64 hex characters", sum)
+	}
+	return nil
+}
+
+// SetChartChecksum 设置chart包的sha256校验值，为空时不校验；远程chart下载后和本地chart安装前都会校验
+func (r *RainbondInstaller) SetChartChecksum(sum string) error {
+	if err := ValidateChartChecksum(sum); err != nil {
+		return err
+	}
+	r.chartSHA256 = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(sum), "sha256:"))
+	return nil
+}
+
+// prepareChart 解析chart来源：oci:// 通过Helm SDK拉取，http(s):// 直接下载到工作目录，其余视为本地chart包或目录
+// 获取后校验sha256（已设置时）并确认是可读取的Helm chart，之后 chartPath 指向本地文件
+func (r *RainbondInstaller) prepareChart() error {
+	source := r.chartPath
+	switch {
+	case strings.HasPrefix(source, "oci://"):
+		local, err := r.pullOCIChart(source)
+		if err != nil {
+			return err
+		}
+		r.chartPath = local
+	case strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://"):
+		local, err := r.downloadChart(source)
+		if err != nil {
+			return err
+		}
+		r.chartPath = local
+	}
+
+	if r.chartSHA256 != "" {
+		if err := verifyChartChecksum(r.chartPath, r.chartSHA256); err != nil {
+			if r.chartPath != source {
+				// 删除校验失败的下载文件，避免被误用
+				os.Remove(r.chartPath)
+			}
+			return err
+		}
+		if r.logger != nil {
+			r.logger.Info("chart包sha256校验通过: %s", r.chartPath)
+		}
+	} else if r.chartPath != source && r.logger != nil {
+		r.logger.Warn("未指定 --chart-sha256，跳过远程chart %s 的完整性校验", source)
+	}
+
+	chart, err := readChartMetadata(r.chartPath)
+	if err != nil {
+		return fmt.Errorf("chart %s 不是可读取的Helm chart包: %w", r.chartPath, err)
+	}
+	if chart.Name == "" || chart.Version == "" {
+		return fmt.Errorf("chart %s 的Chart.yaml缺少name或version，不是有效的Helm chart", r.chartPath)
+	}
+	if r.logger != nil {
+		r.logger.Info("使用chart %s %s（%s）", chart.Name, chart.Version, r.chartPath)
+	}
+	return nil
+}
+
+// pullOCIChart 通过Helm SDK从OCI仓库拉取chart，引用格式为 oci://<registry>/<repo>/<chart>[:<version>]
+// 仓库凭据从Helm的registry配置文件读取，未找到时回退到docker login保存的凭据
+func (r *RainbondInstaller) pullOCIChart(ref string) (string, error) {
+	if err := os.MkdirAll(chartDownloadDir, 0755); err != nil {
+		return "", fmt.Errorf("创建chart下载目录失败: %w", err)
+	}
+	dest, err := os.MkdirTemp(chartDownloadDir, "oci-")
+	if err != nil {
+		return "", fmt.Errorf("创建chart下载目录失败: %w", err)
+	}
+	defer os.RemoveAll(dest)
+
+	repo, version := ref, ""
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		repo, version = ref[:i], ref[i+1:]
+	}
+
+	settings := cli.New()
+	registryClient, err := registry.NewClient(registry.ClientOptCredentialsFile(settings.RegistryConfig))
+	if err != nil {
+		return "", fmt.Errorf("创建OCI仓库客户端失败: %w", err)
+	}
+	pull := action.NewPullWithOpts(action.WithConfig(&action.Configuration{RegistryClient: registryClient}))
+	pull.Settings = settings
+	pull.DestDir = dest
+	pull.Version = version
+
+	if r.logger != nil {
+		r.logger.Info("从OCI仓库拉取chart: %s", ref)
+	}
+	if output, err := pull.Run(repo); err != nil {
+		return "", fmt.Errorf("拉取chart %s 失败: %w, 输出: %s", ref, err, strings.TrimSpace(output))
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dest, "*.tgz"))
+	if len(matches) != 1 {
+		return "", fmt.Errorf("拉取chart %s 后未找到chart包", ref)
+	}
+	local := filepath.Join(chartDownloadDir, filepath.Base(matches[0]))
+	if err := os.Rename(matches[0], local); err != nil {
+		return "", fmt.Errorf("保存chart包失败: %w", err)
+	}
+	if r.logger != nil {
+		r.logger.Info("chart已拉取到 %s", local)
+	}
+	return local, nil
+}
+
+// downloadChart 通过HTTP(S)下载chart包到工作目录，先写入临时文件，下载完整后再重命名
+func (r *RainbondInstaller) downloadChart(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid chart url '%s': %w", rawURL, err)
+	}
+	name := path.Base(u.Path)
+	if name == "" || name == "." || name == "/" {
+		name = "rainbond.tgz"
+	}
+	if err := os.MkdirAll(chartDownloadDir, 0755); err != nil {
+		return "", fmt.Errorf("创建chart下载目录失败: %w", err)
+	}
+	local := filepath.Join(chartDownloadDir, name)
+
+	if r.logger != nil {
+		r.logger.Info("下载chart: %s", rawURL)
+	}
+	client := &http.Client{Timeout: chartDownloadTimeout}
+	resp, err := client.Get(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("下载chart %s 失败: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("下载chart %s 失败: HTTP %s", rawURL, resp.Status)
+	}
+
+	tmp, err := os.CreateTemp(chartDownloadDir, name+".*.part")
+	if err != nil {
+		return "", fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, resp.Body); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("下载chart %s 失败: %w", rawURL, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("保存chart包失败: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), local); err != nil {
+		return "", fmt.Errorf("保存chart包失败: %w", err)
+	}
+	if r.logger != nil {
+		r.logger.Info("chart已下载到 %s", local)
+	}
+	return local, nil
+}
+
+// verifyChartChecksum 校验chart包的sha256，chart为目录时无法校验
+func verifyChartChecksum(chartPath, expected string) error {
+	file, err := os.Open(chartPath)
+	if err != nil {
+		return fmt.Errorf("打开chart包失败: %w", err)
+	}
+	defer file.Close()
+	if info, err := file.Stat(); err == nil && info.IsDir() {
+		return fmt.Errorf("chart %s 是目录，无法校验sha256", chartPath)
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("读取chart包失败: %w", err)
+	}
+	if actual := hex.EncodeToString(hash.Sum(nil)); actual != expected {
+		return fmt.Errorf("chart包 %s sha256校验失败: 期望 %s，实际 %s", chartPath, expected, actual)
+	}
+	return nil
+}
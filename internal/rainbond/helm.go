@@ -0,0 +1,217 @@
+package rainbond
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/release"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+)
+
+// helmDriver release信息的存储方式，与helm CLI默认一致，已有的release可继续用helm命令查看
+const helmDriver = "secret"
+
+// HelmClient Helm release操作接口
+// 默认由基于Helm SDK的 sdkHelmClient 实现，不依赖helm二进制，可通过 SetHelmClient 替换
+type HelmClient interface {
+	List(namespace, filter string) ([]string, error)
+	Install(release, chart, namespace, valuesFile string, timeout time.Duration) (string, error)
+	Upgrade(release, chart, namespace, valuesFile string, timeout time.Duration) (string, error)
+	Uninstall(release, namespace string) (string, error)
+	Status(release, namespace string) (*ReleaseStatus, error)
+}
+
+// ReleaseStatus Helm release状态
+type ReleaseStatus struct {
+	Name      string
+	Namespace string
+	Revision  int
+	Status    string // deployed, failed, pending-install 等
+}
+
+// sdkHelmClient 通过Helm SDK使用安装器获取的kubeconfig直接操作集群
+type sdkHelmClient struct {
+	installer *RainbondInstaller
+	newConfig func(namespace string) (*action.Configuration, error) // 非nil时替代 configuration，用于在测试中使用内存存储
+}
+
+// SetHelmClient 替换默认的Helm客户端实现
+func (r *RainbondInstaller) SetHelmClient(client HelmClient) {
+	r.helm = client
+}
+
+func (h *sdkHelmClient) List(namespace, filter string) ([]string, error) {
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	list := action.NewList(cfg)
+	list.Filter = filter
+	list.SetStateMask()
+	releases, err := list.Run()
+	if err != nil {
+		return nil, fmt.Errorf("helm list失败: %w", err)
+	}
+
+	var names []string
+	for _, rel := range releases {
+		names = append(names, rel.Name)
+	}
+	return names, nil
+}
+
+func (h *sdkHelmClient) Install(releaseName, chartPath, namespace, valuesFile string, timeout time.Duration) (string, error) {
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return "", err
+	}
+	ch, values, err := loadChart(chartPath, valuesFile)
+	if err != nil {
+		return "", err
+	}
+
+	install := action.NewInstall(cfg)
+	install.ReleaseName = releaseName
+	install.Namespace = namespace
+	install.CreateNamespace = true
+	install.Wait = true
+	install.Timeout = timeout
+	rel, err := install.Run(ch, values)
+	if err != nil {
+		return releaseSummary(rel), fmt.Errorf("helm install失败: %w", err)
+	}
+	return releaseSummary(rel), nil
+}
+
+func (h *sdkHelmClient) Upgrade(releaseName, chartPath, namespace, valuesFile string, timeout time.Duration) (string, error) {
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return "", err
+	}
+	ch, values, err := loadChart(chartPath, valuesFile)
+	if err != nil {
+		return "", err
+	}
+
+	upgrade := action.NewUpgrade(cfg)
+	upgrade.Namespace = namespace
+	upgrade.Wait = true
+	upgrade.Timeout = timeout
+	rel, err := upgrade.Run(releaseName, ch, values)
+	if err != nil {
+		return releaseSummary(rel), fmt.Errorf("helm upgrade失败: %w", err)
+	}
+	return releaseSummary(rel), nil
+}
+
+func (h *sdkHelmClient) Uninstall(releaseName, namespace string) (string, error) {
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := action.NewUninstall(cfg).Run(releaseName)
+	if err != nil {
+		return "", fmt.Errorf("helm uninstall失败: %w", err)
+	}
+	output := fmt.Sprintf("release \"%s\" uninstalled", releaseName)
+	if resp != nil && resp.Info != "" {
+		output += "\n" + resp.Info
+	}
+	return output, nil
+}
+
+func (h *sdkHelmClient) Status(releaseName, namespace string) (*ReleaseStatus, error) {
+	cfg, err := h.actionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := action.NewStatus(cfg).Run(releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("helm status失败: %w", err)
+	}
+	status := &ReleaseStatus{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+	}
+	if rel.Info != nil {
+		status.Status = rel.Info.Status.String()
+	}
+	return status, nil
+}
+
+// actionConfig 返回操作指定命名空间的Helm配置
+func (h *sdkHelmClient) actionConfig(namespace string) (*action.Configuration, error) {
+	if h.newConfig != nil {
+		return h.newConfig(namespace)
+	}
+	return h.configuration(namespace)
+}
+
+// configuration 创建操作指定命名空间的Helm配置，Kubernetes客户端尚未初始化时先获取kubeconfig
+func (h *sdkHelmClient) configuration(namespace string) (*action.Configuration, error) {
+	if h.installer.kubeConfigPath == "" {
+		if err := h.installer.ensureClients(); err != nil {
+			return nil, err
+		}
+	}
+
+	kubeConfig := h.installer.kubeConfigPath
+	flags := genericclioptions.NewConfigFlags(false)
+	flags.KubeConfig = &kubeConfig
+	flags.Namespace = &namespace
+
+	cfg := new(action.Configuration)
+	if err := cfg.Init(flags, namespace, helmDriver, h.debug); err != nil {
+		return nil, fmt.Errorf("初始化Helm配置失败: %w", err)
+	}
+	return cfg, nil
+}
+
+// debug 将Helm SDK的调试日志输出到安装器日志
+func (h *sdkHelmClient) debug(format string, v ...interface{}) {
+	if h.installer.logger != nil {
+		h.installer.logger.Debug("Helm: "+format, v...)
+	}
+}
+
+// loadChart 加载chart包或目录以及values文件，未指定values文件时使用chart默认值
+func loadChart(chartPath, valuesFile string) (*chart.Chart, map[string]interface{}, error) {
+	ch, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("加载chart %s 失败: %w", chartPath, err)
+	}
+	if valuesFile == "" {
+		return ch, map[string]interface{}{}, nil
+	}
+	values, err := chartutil.ReadValuesFile(valuesFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取values文件 %s 失败: %w", valuesFile, err)
+	}
+	return ch, values.AsMap(), nil
+}
+
+// releaseSummary 按helm install的输出格式汇总release信息，release为nil时返回空
+func releaseSummary(rel *release.Release) string {
+	if rel == nil {
+		return ""
+	}
+	var summary strings.Builder
+	fmt.Fprintf(&summary, "NAME: %s\nNAMESPACE: %s\n", rel.Name, rel.Namespace)
+	if rel.Info != nil {
+		fmt.Fprintf(&summary, "STATUS: %s\n", rel.Info.Status)
+	}
+	fmt.Fprintf(&summary, "REVISION: %d", rel.Version)
+	if rel.Info != nil && rel.Info.Notes != "" {
+		fmt.Fprintf(&summary, "\nNOTES:\n%s", strings.TrimSpace(rel.Info.Notes))
+	}
+	return summary.String()
+}
@@ -0,0 +1,141 @@
+package rainbond
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+)
+
+// memoryHelmClient 返回release保存在内存中、Kubernetes操作只打印不执行的Helm客户端
+func memoryHelmClient(t *testing.T) (*sdkHelmClient, *storage.Storage) {
+	t.Helper()
+	memory := driver.NewMemory()
+	releases := storage.Init(memory)
+	client := &sdkHelmClient{
+		installer: &RainbondInstaller{},
+		newConfig: func(namespace string) (*action.Configuration, error) {
+			memory.SetNamespace(namespace)
+			return &action.Configuration{
+				Releases:     releases,
+				KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+				Capabilities: chartutil.DefaultCapabilities,
+				Log:          t.Logf,
+			}, nil
+		},
+	}
+	return client, releases
+}
+
+// writeTestChart 在临时目录中创建只包含一个ConfigMap的chart，以及覆盖其中值的values文件
+func writeTestChart(t *testing.T) (chartDir, valuesFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	chartDir = filepath.Join(dir, "rainbond")
+	files := map[string]string{
+		"Chart.yaml":  "apiVersion: v2\nname: rainbond\nversion: 6.0.0\n",
+		"values.yaml": "cluster:\n  name: default\n",
+		"templates/configmap.yaml": "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: rbd-config\n" +
+			"data:\n  cluster: {{ .Values.cluster.name }}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(chartDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	valuesFile = filepath.Join(dir, "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte("cluster:\n  name: prod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return chartDir, valuesFile
+}
+
+func TestSDKHelmClientLifecycle(t *testing.T) {
+	client, releases := memoryHelmClient(t)
+	chartDir, valuesFile := writeTestChart(t)
+	const namespace = "rbd-system"
+
+	output, err := client.Install("rainbond", chartDir, namespace, valuesFile, time.Minute)
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if !strings.Contains(output, "STATUS: deployed") {
+		t.Errorf("Install() output = %q, want a deployed release summary", output)
+	}
+	rel, err := releases.Last("rainbond")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(rel.Manifest, "cluster: prod") {
+		t.Errorf("values file not applied, manifest:\n%s", rel.Manifest)
+	}
+
+	names, err := client.List(namespace, "rainbond")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if !reflect.DeepEqual(names, []string{"rainbond"}) {
+		t.Errorf("List() = %q, want [rainbond]", names)
+	}
+
+	// 重复安装返回的错误用于触发 installHelmChart 的清理重试
+	if _, err := client.Install("rainbond", chartDir, namespace, valuesFile, time.Minute); err == nil ||
+		!strings.Contains(err.Error(), "cannot re-use a name that is still in use") {
+		t.Errorf("second Install() error = %v, want a name-in-use error", err)
+	}
+
+	if _, err := client.Upgrade("rainbond", chartDir, namespace, "", time.Minute); err != nil {
+		t.Fatalf("Upgrade() error = %v", err)
+	}
+	status, err := client.Status("rainbond", namespace)
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	want := &ReleaseStatus{Name: "rainbond", Namespace: namespace, Revision: 2, Status: "deployed"}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("Status() = %+v, want %+v", status, want)
+	}
+
+	if _, err := client.Uninstall("rainbond", namespace); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	if names, err := client.List(namespace, "rainbond"); err != nil || len(names) != 0 {
+		t.Errorf("List() after Uninstall() = %q, %v, want no releases", names, err)
+	}
+}
+
+func TestSDKHelmClientListOtherNamespace(t *testing.T) {
+	client, _ := memoryHelmClient(t)
+	chartDir, _ := writeTestChart(t)
+
+	if _, err := client.Install("rainbond", chartDir, "rbd-system", "", time.Minute); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	names, err := client.List("default", "rainbond")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(names) != 0 {
+		t.Errorf("List() in another namespace = %q, want none", names)
+	}
+}
+
+func TestLoadChartMissingValuesFile(t *testing.T) {
+	chartDir, _ := writeTestChart(t)
+	if _, _, err := loadChart(chartDir, filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("loadChart() with a missing values file succeeded, want error")
+	}
+}
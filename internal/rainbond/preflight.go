@@ -0,0 +1,199 @@
+package rainbond
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/version"
+)
+
+// chartMetadata Chart.yaml中与版本兼容性相关的字段
+type chartMetadata struct {
+	APIVersion  string `yaml:"apiVersion"`
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version"`
+	KubeVersion string `yaml:"kubeVersion"`
+}
+
+var constraintPattern = regexp.MustCompile(`^(>=|<=|!=|=|>|<)?\s*v?([0-9][0-9A-Za-z.\-+]*)$`)
+
+// checkChartCompatibility 安装前检查集群Kubernetes版本与chart的kubeVersion约束是否兼容
+// 内置的Helm SDK为Helm 3，可安装 apiVersion v1 和 v2 的chart，无需检查helm版本
+// 无法读取chart或获取版本时只记录警告，交由helm install报告具体错误
+func (r *RainbondInstaller) checkChartCompatibility() error {
+	chart, err := readChartMetadata(r.chartPath)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Warn("读取chart元数据失败，跳过版本兼容性检查: %v", err)
+		}
+		return nil
+	}
+	if r.logger != nil {
+		r.logger.Debug("chart %s %s: apiVersion=%s kubeVersion=%q", chart.Name, chart.Version, chart.APIVersion, chart.KubeVersion)
+	}
+
+	return r.checkKubeVersion(chart)
+}
+
+// checkKubeVersion 检查集群Kubernetes版本是否满足chart的kubeVersion约束
+func (r *RainbondInstaller) checkKubeVersion(chart *chartMetadata) error {
+	if chart.KubeVersion == "" {
+		return nil
+	}
+	info, err := r.kubeClient.Discovery().ServerVersion()
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Warn("获取Kubernetes版本失败，跳过kubeVersion检查: %v", err)
+		}
+		return nil
+	}
+
+	ok, err := matchKubeVersion(chart.KubeVersion, info.GitVersion)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Warn("无法解析chart的kubeVersion约束 %q，跳过检查: %v", chart.KubeVersion, err)
+		}
+		return nil
+	}
+	if !ok {
+		return fmt.Errorf("Kubernetes版本 %s 不满足chart %s %s 的要求 kubeVersion: %q，请通过 --chart 使用与集群版本匹配的chart",
+			info.GitVersion, chart.Name, chart.Version, chart.KubeVersion)
+	}
+	if r.logger != nil {
+		r.logger.Info("Kubernetes版本 %s 满足chart要求 %s", info.GitVersion, chart.KubeVersion)
+	}
+	return nil
+}
+
+// readChartMetadata 从chart目录或.tgz包中读取Chart.yaml
+func readChartMetadata(chartPath string) (*chartMetadata, error) {
+	info, err := os.Stat(chartPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var data []byte
+	if info.IsDir() {
+		data, err = os.ReadFile(filepath.Join(chartPath, "Chart.yaml"))
+	} else {
+		data, err = readChartYAMLFromArchive(chartPath)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var chart chartMetadata
+	if err := yaml.Unmarshal(data, &chart); err != nil {
+		return nil, fmt.Errorf("解析Chart.yaml失败: %w", err)
+	}
+	return &chart, nil
+}
+
+// readChartYAMLFromArchive 读取chart包顶层目录中的Chart.yaml（<chart>/Chart.yaml），子chart中的忽略
+func readChartYAMLFromArchive(archivePath string) ([]byte, error) {
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("读取chart包失败: %w", err)
+	}
+	defer gz.Close()
+
+	reader := tar.NewReader(gz)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("读取chart包失败: %w", err)
+		}
+		parts := strings.Split(strings.TrimPrefix(header.Name, "./"), "/")
+		if len(parts) == 2 && parts[1] == "Chart.yaml" {
+			return io.ReadAll(reader)
+		}
+	}
+	return nil, fmt.Errorf("chart包 %s 中未找到Chart.yaml", archivePath)
+}
+
+// matchKubeVersion 判断Kubernetes版本是否满足Helm kubeVersion约束
+// 支持 ||（或）以及空格或逗号分隔的 >=、>、<=、<、=、!= 条件；版本中的预发布后缀（如 -0）和构建信息在比较时忽略
+func matchKubeVersion(constraint, kubeVersion string) (bool, error) {
+	current, err := version.ParseGeneric(kubeVersion)
+	if err != nil {
+		return false, err
+	}
+
+	for _, group := range strings.Split(constraint, "||") {
+		conditions := strings.Fields(strings.ReplaceAll(group, ",", " "))
+		// 合并被空格分开的运算符和版本号，如 ">= 1.21.0"
+		var merged []string
+		for i := 0; i < len(conditions); i++ {
+			if constraintPattern.MatchString(conditions[i]) || i+1 >= len(conditions) {
+				merged = append(merged, conditions[i])
+				continue
+			}
+			merged = append(merged, conditions[i]+conditions[i+1])
+			i++
+		}
+		if len(merged) == 0 {
+			continue
+		}
+
+		matched := true
+		for _, condition := range merged {
+			ok, err := matchCondition(condition, current)
+			if err != nil {
+				return false, err
+			}
+			matched = matched && ok
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func matchCondition(condition string, current *version.Version) (bool, error) {
+	m := constraintPattern.FindStringSubmatch(condition)
+	if m == nil {
+		return false, fmt.Errorf("不支持的条件 %q", condition)
+	}
+	target, err := version.ParseGeneric(m[2])
+	if err != nil {
+		return false, fmt.Errorf("无法解析版本 %q: %w", m[2], err)
+	}
+
+	cmp := 0
+	if current.LessThan(target) {
+		cmp = -1
+	} else if target.LessThan(current) {
+		cmp = 1
+	}
+	switch m[1] {
+	case ">=":
+		return cmp >= 0, nil
+	case ">":
+		return cmp > 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "!=":
+		return cmp != 0, nil
+	default:
+		return cmp == 0, nil
+	}
+}
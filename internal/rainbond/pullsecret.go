@@ -0,0 +1,66 @@
+package rainbond
+
+import (
+	"context"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+)
+
+// chart中镜像拉取的配置项，位于 values.Cluster 下
+const (
+	imagePullPolicyKey  = "imagePullPolicy"
+	imagePullSecretsKey = "imagePullSecrets"
+)
+
+// ensureImagePullSecret 配置了 image_pull.secret_name 时，在Rainbond命名空间中创建或检查imagePullSecret
+func (r *RainbondInstaller) ensureImagePullSecret() error {
+	pull := r.config.ImagePull
+	if !pull.HasPullSecret() {
+		return nil
+	}
+	namespace := r.config.Rainbond.Namespace
+	if namespace == "" {
+		namespace = "rbd-system"
+	}
+	if r.logger != nil {
+		if pull.CreatesPullSecret() {
+			r.logger.Info("创建或更新imagePullSecret %s/%s（仓库: %s）", namespace, pull.SecretName, pull.Registry)
+		} else {
+			r.logger.Info("检查imagePullSecret %s/%s", namespace, pull.SecretName)
+		}
+	}
+	return cluster.EnsureImagePullSecret(context.TODO(), r.kubeClient, namespace, pull)
+}
+
+// applyImagePull 将 image_pull 的拉取策略和imagePullSecret写入 values.Cluster，values中已显式配置时以values为准
+func (r *RainbondInstaller) applyImagePull(values map[string]interface{}) {
+	pull := r.config.ImagePull
+	if pull.Policy == "" && !pull.HasPullSecret() {
+		return
+	}
+
+	cluster, ok := values["Cluster"].(map[string]interface{})
+	if !ok {
+		cluster = make(map[string]interface{})
+		values["Cluster"] = cluster
+	}
+
+	if pull.Policy != "" {
+		if _, exists := cluster[imagePullPolicyKey]; exists {
+			if r.logger != nil {
+				r.logger.Warn("values.Cluster.%s 已配置，忽略 image_pull.policy (%s)", imagePullPolicyKey, pull.Policy)
+			}
+		} else {
+			cluster[imagePullPolicyKey] = pull.Policy
+		}
+	}
+	if pull.HasPullSecret() {
+		if _, exists := cluster[imagePullSecretsKey]; exists {
+			if r.logger != nil {
+				r.logger.Warn("values.Cluster.%s 已配置，忽略 image_pull.secret_name (%s)", imagePullSecretsKey, pull.SecretName)
+			}
+		} else {
+			cluster[imagePullSecretsKey] = []map[string]interface{}{{"name": pull.SecretName}}
+		}
+	}
+}
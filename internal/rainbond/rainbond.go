@@ -2,14 +2,18 @@ package rainbond
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
 	"github.com/rainbond/rainbond-offline-installer/pkg/config"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -35,13 +39,18 @@ type StepProgress interface {
 }
 
 type RainbondInstaller struct {
-	config         *config.Config
-	logger         Logger
-	stepProgress   StepProgress
-	chartPath      string
-	kubeConfig     *rest.Config
-	kubeClient     kubernetes.Interface
-	kubeConfigPath string
+	config           *config.Config
+	logger           Logger
+	stepProgress     StepProgress
+	chartPath        string
+	chartSHA256      string // chart包sha256，为空时不校验
+	kubeConfig       *rest.Config
+	kubeClient       kubernetes.Interface
+	kubeConfigPath   string
+	setValues        []string // 命令行 --set 覆盖项，最后合并
+	helm             HelmClient
+	waitReady        bool          // 安装完成后等待所有组件就绪
+	waitReadyTimeout time.Duration // 等待组件就绪的超时时间
 }
 
 func NewRainbondInstaller(cfg *config.Config) *RainbondInstaller {
@@ -57,23 +66,42 @@ func NewRainbondInstallerWithLoggerAndProgress(cfg *config.Config, logger Logger
 		config:       cfg,
 		logger:       logger,
 		stepProgress: stepProgress,
-		chartPath:    "./rainbond.tgz", // 使用tgz包
+		chartPath:    DefaultChartPath, // 使用tgz包
 	}
-	// 初始化Kubernetes客户端和Helm配置
-	if err := r.initializeClients(); err != nil {
+	r.helm = &sdkHelmClient{installer: r}
+	// 尝试初始化Kubernetes客户端和Helm配置，失败时（如RKE2尚未安装）在首次使用时由 ensureClients 重试
+	if err := r.initializeClients(false); err != nil {
 		if logger != nil {
-			logger.Error("初始化Kubernetes和Helm客户端失败: %v", err)
+			logger.Debug("初始化Kubernetes和Helm客户端失败，将在使用时重试: %v", err)
 		}
 	}
 	return r
 }
 
+// SetChartPath 设置chart来源：本地chart包或目录、oci://<registry>/<repo>/<chart>[:<version>] 或 http(s):// 地址
 func (r *RainbondInstaller) SetChartPath(path string) {
 	r.chartPath = path
 }
 
-// 初始化Kubernetes客户端
-func (r *RainbondInstaller) initializeClients() error {
+// SetValueOverrides 设置命令行 --set 覆盖项，在生成values时最后合并
+func (r *RainbondInstaller) SetValueOverrides(sets []string) {
+	r.setValues = sets
+}
+
+// ensureClients 确保Kubernetes客户端可用，未初始化时带退避重试连接API
+// 连接失败时返回包含原因的错误，调用方不会拿到nil客户端
+func (r *RainbondInstaller) ensureClients() error {
+	if r.kubeClient != nil {
+		return nil
+	}
+	if err := r.initializeClients(true); err != nil {
+		return fmt.Errorf("Kubernetes客户端未就绪: %w", err)
+	}
+	return nil
+}
+
+// 初始化Kubernetes客户端，wait为true时API暂不可访问会带退避重试，连接测试通过后才设置客户端
+func (r *RainbondInstaller) initializeClients(wait bool) error {
 	// 获取kubeconfig
 	kubeConfigPath, err := r.getKubeConfig()
 	if err != nil {
@@ -101,7 +129,12 @@ func (r *RainbondInstaller) initializeClients() error {
 	if err != nil {
 		return fmt.Errorf("创建Kubernetes客户端失败: %w", err)
 	}
-	r.kubeClient = clientset
+
+	if wait {
+		if err := cluster.WaitForAPI(clientset, r.logger); err != nil {
+			return err
+		}
+	}
 
 	// 测试连接
 	if r.logger != nil {
@@ -114,25 +147,25 @@ func (r *RainbondInstaller) initializeClients() error {
 	if r.logger != nil {
 		r.logger.Debug("成功连接到Kubernetes集群，发现 %d 个节点", len(nodes.Items))
 	}
+	r.kubeClient = clientset
 
 	return nil
 }
 
 // 获取kubeconfig文件路径
 func (r *RainbondInstaller) getKubeConfig() (string, error) {
-	// 优先使用RKE2模块保存的本地kubeconfig文件
-	localKubeConfigPath := "./kubeconfig"
-
-	// 检查本地kubeconfig是否存在
-	if _, err := os.Stat(localKubeConfigPath); err == nil {
-		if r.logger != nil {
+	// 使用RKE2模块保存的本地kubeconfig文件，已有集群使用指定的kubeconfig
+	if err := r.config.CheckKubeconfig(); err != nil {
+		return "", err
+	}
+	if r.logger != nil {
+		if r.config.IsExistingCluster() {
+			r.logger.Info("使用已有集群的kubeconfig文件: %s", r.config.KubeconfigPath())
+		} else {
 			r.logger.Info("使用RKE2安装时保存的本地kubeconfig文件")
 		}
-		return localKubeConfigPath, nil
 	}
-	
-	// 如果本地文件不存在，返回错误
-	return "", fmt.Errorf("本地kubeconfig文件不存在: %s，请先运行RKE2安装", localKubeConfigPath)
+	return r.config.KubeconfigPath(), nil
 }
 
 // 更新kubeconfig中的server地址
@@ -143,11 +176,8 @@ func (r *RainbondInstaller) updateKubeConfigServer(kubeconfigPath, serverIP stri
 		return err
 	}
 
-	// 替换server地址
-	content := strings.ReplaceAll(string(data), "https://127.0.0.1:6443", fmt.Sprintf("https://%s:6443", serverIP))
-
-	// 写回文件
-	return os.WriteFile(kubeconfigPath, []byte(content), 0644)
+	// 替换server地址并写回文件
+	return os.WriteFile(kubeconfigPath, config.KubeconfigForServer(data, serverIP), 0600)
 }
 
 // 执行命令的通用方法
@@ -165,16 +195,8 @@ func (r *RainbondInstaller) Run() error {
 	}
 
 	// 确保客户端已初始化
-	if r.kubeClient == nil {
-		if r.logger != nil {
-			r.logger.Debug("初始化Kubernetes和Helm客户端...")
-		}
-		if err := r.initializeClients(); err != nil {
-			return fmt.Errorf("初始化客户端失败: %w", err)
-		}
-		if r.logger != nil {
-			r.logger.Debug("客户端初始化成功")
-		}
+	if err := r.ensureClients(); err != nil {
+		return err
 	}
 
 	// 检查Kubernetes集群状态
@@ -189,14 +211,36 @@ func (r *RainbondInstaller) Run() error {
 		if r.logger != nil {
 			r.logger.Info("检测到Rainbond已存在，跳过安装")
 		}
+		if r.waitReady {
+			return r.waitForComponentsReady()
+		}
 		return nil
 	}
 
+	// 获取并校验chart，远程chart下载到工作目录
+	if err := r.prepareChart(); err != nil {
+		return err
+	}
+
+	// 检查helm和集群版本与chart是否兼容，避免helm install报出难以理解的错误
+	if err := r.checkChartCompatibility(); err != nil {
+		return err
+	}
+
 	// 创建命名空间
 	if err := r.createNamespace(); err != nil {
 		return fmt.Errorf("创建命名空间失败: %w", err)
 	}
 
+	if err := r.ensureImagePullSecret(); err != nil {
+		return err
+	}
+
+	// 检查私有镜像仓库可达
+	if err := r.checkImageRegistryReachable(); err != nil {
+		return err
+	}
+
 	// 生成values配置
 	values, err := r.generateValues()
 	if err != nil {
@@ -211,6 +255,12 @@ func (r *RainbondInstaller) Run() error {
 	if r.logger != nil {
 		r.logger.Info("🎉 Rainbond Helm安装完成!")
 	}
+
+	if r.waitReady {
+		if err := r.waitForComponentsReady(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -256,24 +306,22 @@ func (r *RainbondInstaller) checkExistingDeployment() (bool, error) {
 	}
 
 	if r.logger != nil {
-		r.logger.Debug("使用Helm CLI检查现有部署，目标命名空间: %s", namespace)
+		r.logger.Debug("使用Helm检查现有部署，目标命名空间: %s", namespace)
 	}
 
-	// 使用Helm CLI检查是否已安装
-	cmd := r.buildHelmCommand("list", "-n", namespace, "-f", "rainbond", "-q")
-	output, err := cmd.Output()
+	// 使用Helm检查是否已安装
+	releases, err := r.helm.List(namespace, "rainbond")
 	if err != nil {
 		if r.logger != nil {
-			r.logger.Debug("Helm list命令执行失败: %v", err)
+			r.logger.Debug("Helm list失败: %v", err)
 		}
 		// 如果是因为命名空间不存在等原因，认为没有部署
 		return false, nil
 	}
 
-	releases := strings.TrimSpace(string(output))
-	if releases != "" {
+	if len(releases) > 0 {
 		if r.logger != nil {
-			r.logger.Info("发现现有Rainbond部署: %s", releases)
+			r.logger.Info("发现现有Rainbond部署: %s", strings.Join(releases, ", "))
 		}
 		return true, nil
 	}
@@ -295,18 +343,20 @@ func (r *RainbondInstaller) createNamespace() error {
 	}
 
 	// 使用Kubernetes客户端检查命名空间是否已存在
-	_, err := r.kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
+	existing, err := r.kubeClient.CoreV1().Namespaces().Get(context.TODO(), namespace, metav1.GetOptions{})
 	if err == nil {
 		if r.logger != nil {
 			r.logger.Info("命名空间 %s 已存在，跳过创建", namespace)
 		}
+		r.labelNamespace(existing)
 		return nil
 	}
 
 	// 创建命名空间
 	ns := &corev1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
-			Name: namespace,
+			Name:   namespace,
+			Labels: r.config.ClusterLabels(),
 		},
 	}
 
@@ -321,6 +371,34 @@ func (r *RainbondInstaller) createNamespace() error {
 	return nil
 }
 
+// labelNamespace 为已存在的命名空间补充集群名称标签，失败时仅记录警告
+func (r *RainbondInstaller) labelNamespace(ns *corev1.Namespace) {
+	labels := r.config.ClusterLabels()
+	missing := false
+	for key, value := range labels {
+		if ns.Labels[key] != value {
+			missing = true
+		}
+	}
+	if !missing {
+		return
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{"metadata": map[string]interface{}{"labels": labels}})
+	if err == nil {
+		_, err = r.kubeClient.CoreV1().Namespaces().Patch(context.TODO(), ns.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	}
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Warn("为命名空间 %s 添加集群名称标签失败: %v", ns.Name, err)
+		}
+		return
+	}
+	if r.logger != nil {
+		r.logger.Info("已为命名空间 %s 添加集群名称标签 %s=%s", ns.Name, config.ClusterNameLabel, r.config.ClusterName)
+	}
+}
+
 func (r *RainbondInstaller) generateValues() (map[string]interface{}, error) {
 	if r.logger != nil {
 		r.logger.Info("生成Helm values配置...")
@@ -373,6 +451,20 @@ func (r *RainbondInstaller) generateValues() (map[string]interface{}, error) {
 		}
 	}
 
+	// 私有镜像仓库覆盖chart中的默认镜像仓库
+	r.applyImageRegistry(values)
+	r.applyImagePull(values)
+
+	// 最后合并命令行 --set 覆盖项
+	if len(r.setValues) > 0 {
+		if err := config.ApplySetValues(values, r.setValues); err != nil {
+			return nil, err
+		}
+		if r.logger != nil {
+			r.logger.Info("已合并 %d 个 --set 覆盖项", len(r.setValues))
+		}
+	}
+
 	if r.logger != nil {
 		r.logger.Info("Values配置生成完成")
 	}
@@ -397,70 +489,62 @@ func (r *RainbondInstaller) installHelmChart(values map[string]interface{}) erro
 	}
 
 	if r.logger != nil {
-		r.logger.Info("使用Helm CLI安装chart: %s 到命名空间: %s", releaseName, namespace)
-	}
-
-	// 构建Helm install命令 - 简化版本
-	args := []string{
-		"install", releaseName, r.chartPath,
-		"--namespace", namespace,
-		"--create-namespace", 
-		"--timeout", "20m",
-		"--wait",
+		r.logger.Info("使用Helm安装chart: %s 到命名空间: %s", releaseName, namespace)
 	}
 
-	// 如果有values配置，生成values文件并添加参数
+	// 如果有values配置，生成values文件
+	valuesFile := ""
 	if len(values) > 0 {
-		valuesFile, err := r.generateValuesFile(values)
+		var err error
+		valuesFile, err = r.generateValuesFile(values)
 		if err != nil {
 			return fmt.Errorf("生成values文件失败: %w", err)
 		}
-		
-		// 添加values参数
-		args = append(args, "--values", valuesFile)
-		
+
 		if r.logger != nil {
 			r.logger.Debug("使用values文件: %s", valuesFile)
 		}
 	}
 
-	// 执行Helm install命令
-	cmd := r.buildHelmCommand(args...)
-	if r.logger != nil {
-		r.logger.Debug("执行Helm命令: %s", strings.Join(append([]string{"helm"}, args...), " "))
+	// 执行Helm install
+	install := func() (string, error) {
+		return r.helm.Install(releaseName, r.chartPath, namespace, valuesFile, 20*time.Minute)
 	}
 
-	output, err := cmd.CombinedOutput()
+	output, err := install()
 	if err != nil {
 		if r.logger != nil {
 			r.logger.Error("Helm安装失败: %v", err)
-			r.logger.Error("Helm输出: %s", string(output))
+			r.logger.Error("Helm输出: %s", output)
 
 			// 如果是名称冲突错误，尝试清理并重试
-			if strings.Contains(string(output), "cannot re-use a name that is still in use") {
+			if strings.Contains(err.Error(), "cannot re-use a name that is still in use") {
 				r.logger.Info("检测到名称冲突，尝试清理现有release...")
 				if cleanErr := r.cleanupExistingRelease(releaseName, namespace); cleanErr != nil {
 					r.logger.Error("清理现有release失败: %v", cleanErr)
 				} else {
 					r.logger.Info("清理完成，重新尝试安装...")
-					retryOutput, retryErr := cmd.CombinedOutput()
+					retryOutput, retryErr := install()
 					if retryErr == nil {
 						r.logger.Info("重新安装成功")
-						r.logger.Info("Helm输出: %s", string(retryOutput))
+						r.logger.Info("Helm输出: %s", retryOutput)
 						return nil
 					} else {
 						r.logger.Error("重新安装仍然失败: %v", retryErr)
-						r.logger.Error("重试输出: %s", string(retryOutput))
+						r.logger.Error("重试输出: %s", retryOutput)
 					}
 				}
 			}
 		}
-		return fmt.Errorf("helm install失败: %w, 输出: %s", err, string(output))
+		return err
 	}
 
 	if r.logger != nil {
 		r.logger.Info("Rainbond Helm Chart安装成功")
-		r.logger.Info("Helm输出: %s", string(output))
+		r.logger.Info("Helm输出: %s", output)
+		if status, err := r.helm.Status(releaseName, namespace); err == nil {
+			r.logger.Info("Release %s 状态: %s (revision %d)", status.Name, status.Status, status.Revision)
+		}
 	}
 	return nil
 }
@@ -471,21 +555,20 @@ func (r *RainbondInstaller) cleanupExistingRelease(releaseName, namespace string
 		r.logger.Debug("尝试清理release: %s (命名空间: %s)", releaseName, namespace)
 	}
 
-	// 使用Helm CLI删除
-	cmd := r.buildHelmCommand("uninstall", releaseName, "-n", namespace)
-	output, err := cmd.CombinedOutput()
+	// 使用Helm删除
+	output, err := r.helm.Uninstall(releaseName, namespace)
 	if err != nil {
 		if r.logger != nil {
-			r.logger.Debug("Helm CLI删除失败: %v，输出: %s，尝试手动清理...", err, string(output))
+			r.logger.Debug("Helm删除失败: %v，尝试手动清理...", err)
 		}
 
-		// 如果Helm CLI失败，尝试手动清理Kubernetes资源
+		// 如果Helm删除失败，尝试手动清理Kubernetes资源
 		return r.manualCleanupResources(releaseName, namespace)
 	}
 
 	if r.logger != nil {
 		r.logger.Info("Helm release清理成功")
-		r.logger.Debug("清理输出: %s", string(output))
+		r.logger.Debug("清理输出: %s", output)
 	}
 	return nil
 }
@@ -523,32 +606,6 @@ func (r *RainbondInstaller) manualCleanupResources(releaseName, namespace string
 	return nil
 }
 
-// buildHelmCommand 构建Helm命令
-func (r *RainbondInstaller) buildHelmCommand(args ...string) *exec.Cmd {
-	var helmPath string
-	
-	// 优先使用当前目录下的helm二进制文件
-	if _, err := os.Stat("./helm"); err == nil {
-		helmPath = "./helm"
-		if r.logger != nil {
-			r.logger.Debug("使用当前目录下的helm二进制: %s", helmPath)
-		}
-	} else {
-		// 回退到系统PATH中的helm
-		helmPath = "helm"
-		if r.logger != nil {
-			r.logger.Debug("使用系统PATH中的helm")
-		}
-	}
-	
-	cmd := exec.Command(helmPath, args...)
-	// 设置KUBECONFIG环境变量
-	if r.kubeConfigPath != "" {
-		cmd.Env = append(os.Environ(), fmt.Sprintf("KUBECONFIG=%s", r.kubeConfigPath))
-	}
-	return cmd
-}
-
 // generateValuesFile 生成values文件
 func (r *RainbondInstaller) generateValuesFile(values map[string]interface{}) (string, error) {
 	valuesFileName := "./rainbond-values.yaml"
@@ -0,0 +1,193 @@
+package rainbond
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultWaitReadyTimeout = 30 * time.Minute
+	waitReadyInterval       = 10 * time.Second
+	// 连续多少次轮询全部就绪才认为安装完成，避免operator尚未创建后续组件时提前返回
+	waitReadyStableRounds = 3
+)
+
+// SetWaitReady 设置Helm安装完成后是否等待所有Rainbond组件就绪，timeout<=0时使用默认超时
+func (r *RainbondInstaller) SetWaitReady(wait bool, timeout time.Duration) {
+	r.waitReady = wait
+	if timeout <= 0 {
+		timeout = defaultWaitReadyTimeout
+	}
+	r.waitReadyTimeout = timeout
+}
+
+// componentStatus 单个组件的Pod就绪情况
+type componentStatus struct {
+	Name    string
+	Total   int
+	Ready   int
+	Reasons []string
+}
+
+// waitForComponentsReady 轮询命名空间内的Pod，直到所有组件Running且Ready或超时
+func (r *RainbondInstaller) waitForComponentsReady() error {
+	namespace := r.config.Rainbond.Namespace
+	if namespace == "" {
+		namespace = "rbd-system"
+	}
+	timeout := r.waitReadyTimeout
+	if timeout <= 0 {
+		timeout = defaultWaitReadyTimeout
+	}
+
+	if r.logger != nil {
+		r.logger.Info("等待Rainbond组件就绪（命名空间: %s，超时: %s）...", namespace, timeout)
+	}
+
+	deadline := time.Now().Add(timeout)
+	stable := 0
+	var lagging []componentStatus
+	for {
+		statuses, err := r.collectComponentStatus(namespace)
+		if err != nil {
+			if r.logger != nil {
+				r.logger.Debug("获取Rainbond组件状态失败: %v", err)
+			}
+			stable = 0
+		} else {
+			lagging = laggingComponents(statuses)
+			if len(statuses) > 0 && len(lagging) == 0 {
+				stable++
+				if stable >= waitReadyStableRounds {
+					if r.logger != nil {
+						r.logger.Info("所有Rainbond组件已就绪（共 %d 个组件）", len(statuses))
+					}
+					return nil
+				}
+			} else {
+				stable = 0
+				if r.logger != nil {
+					r.logger.Info("Rainbond组件就绪 %d/%d，等待中: %s",
+						len(statuses)-len(lagging), len(statuses), formatComponentNames(lagging))
+				}
+			}
+		}
+
+		if time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(waitReadyInterval)
+	}
+
+	if len(lagging) == 0 {
+		return fmt.Errorf("等待Rainbond组件就绪超时（%s），命名空间 %s 中未发现可用组件", timeout, namespace)
+	}
+	var details []string
+	for _, s := range lagging {
+		detail := fmt.Sprintf("%s (%d/%d 就绪)", s.Name, s.Ready, s.Total)
+		if len(s.Reasons) > 0 {
+			detail += ": " + strings.Join(s.Reasons, "; ")
+		}
+		details = append(details, detail)
+	}
+	return fmt.Errorf("等待Rainbond组件就绪超时（%s），以下组件未就绪:\n  - %s", timeout, strings.Join(details, "\n  - "))
+}
+
+// collectComponentStatus 按组件汇总命名空间内Pod的就绪情况，已完成的Job Pod不计入
+func (r *RainbondInstaller) collectComponentStatus(namespace string) ([]componentStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pods, err := r.kubeClient.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取Pod列表失败: %w", err)
+	}
+
+	byName := make(map[string]*componentStatus)
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded {
+			continue
+		}
+		name := podComponentName(pod)
+		s, ok := byName[name]
+		if !ok {
+			s = &componentStatus{Name: name}
+			byName[name] = s
+		}
+		s.Total++
+		if ready, reason := podReady(pod); ready {
+			s.Ready++
+		} else {
+			s.Reasons = append(s.Reasons, fmt.Sprintf("%s %s", pod.Name, reason))
+		}
+	}
+
+	statuses := make([]componentStatus, 0, len(byName))
+	for _, s := range byName {
+		statuses = append(statuses, *s)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses, nil
+}
+
+// podComponentName 获取Pod所属组件名，优先使用Rainbond组件的name标签
+func podComponentName(pod corev1.Pod) string {
+	for _, key := range []string{"name", "app.kubernetes.io/name", "app"} {
+		if v := pod.Labels[key]; v != "" {
+			return v
+		}
+	}
+	if len(pod.OwnerReferences) > 0 {
+		return pod.OwnerReferences[0].Name
+	}
+	return pod.Name
+}
+
+// podReady 判断Pod是否Running且所有容器Ready，未就绪时返回原因
+func podReady(pod corev1.Pod) (bool, string) {
+	if pod.Status.Phase != corev1.PodRunning {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+				return false, cs.State.Waiting.Reason
+			}
+		}
+		return false, string(pod.Status.Phase)
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Ready {
+			continue
+		}
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			return false, cs.State.Waiting.Reason
+		}
+		if cs.State.Terminated != nil && cs.State.Terminated.Reason != "" {
+			return false, cs.State.Terminated.Reason
+		}
+		return false, "容器未就绪"
+	}
+	return true, ""
+}
+
+func laggingComponents(statuses []componentStatus) []componentStatus {
+	var lagging []componentStatus
+	for _, s := range statuses {
+		if s.Ready < s.Total {
+			lagging = append(lagging, s)
+		}
+	}
+	return lagging
+}
+
+func formatComponentNames(statuses []componentStatus) string {
+	names := make([]string, 0, len(statuses))
+	for _, s := range statuses {
+		names = append(names, s.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,69 @@
+package rainbond
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// imageRepositoryKey chart中全局镜像仓库的配置项，位于 values.Cluster 下
+const imageRepositoryKey = "rainbondImageRepository"
+
+// applyImageRegistry 将 rainbond.image_registry 写入 values.Cluster.rainbondImageRepository，
+// 使chart中所有组件镜像从私有仓库拉取；values中已显式配置时以values为准
+func (r *RainbondInstaller) applyImageRegistry(values map[string]interface{}) {
+	registry := r.config.Rainbond.ImageRegistry
+	if registry == "" {
+		return
+	}
+
+	cluster, ok := values["Cluster"].(map[string]interface{})
+	if !ok {
+		cluster = make(map[string]interface{})
+		values["Cluster"] = cluster
+	}
+
+	if existing, ok := cluster[imageRepositoryKey].(string); ok && existing != "" {
+		if existing != registry && r.logger != nil {
+			r.logger.Warn("values.Cluster.%s (%s) 与 rainbond.image_registry (%s) 不一致，使用values中的配置",
+				imageRepositoryKey, existing, registry)
+		}
+		return
+	}
+
+	cluster[imageRepositoryKey] = registry
+	if r.logger != nil {
+		r.logger.Info("Rainbond组件镜像仓库: %s", registry)
+	}
+}
+
+// checkImageRegistryReachable 从集群控制节点检查镜像仓库端口可达，避免安装后组件因拉取镜像失败而无法启动
+func (r *RainbondInstaller) checkImageRegistryReachable() error {
+	registry := r.config.Rainbond.ImageRegistry
+	if registry == "" {
+		return nil
+	}
+
+	hosts := r.config.GetControlHosts()
+	if len(hosts) == 0 {
+		hosts = r.config.Hosts
+	}
+	if len(hosts) == 0 {
+		return nil
+	}
+	host := hosts[0]
+
+	address, port := config.RegistryAddress(registry)
+	if r.logger != nil {
+		r.logger.Info("检查镜像仓库 %s:%s 在主机 %s 上的可达性...", address, port, host.IP)
+	}
+
+	checkCmd := fmt.Sprintf("timeout 5 bash -c '</dev/tcp/%s/%s'", address, port)
+	if output, err := ssh.NewHostCommand(host, checkCmd).CombinedOutput(); err != nil {
+		return fmt.Errorf("主机 %s 无法连接镜像仓库 %s:%s，请检查 rainbond.image_registry 和网络: %w, 输出: %s",
+			host.IP, address, port, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package rainbond
+
+import "strings"
+
+// ComponentHealth 单个Rainbond组件的Pod就绪情况
+type ComponentHealth struct {
+	Name    string   `json:"name"`
+	Total   int      `json:"total"`
+	Ready   int      `json:"ready"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Status Rainbond Helm release和各组件的当前状态，供 roi serve 等只读场景使用
+type Status struct {
+	Release    string            `json:"release"`
+	Revision   int               `json:"revision"`
+	State      string            `json:"state"` // helm release状态，如 deployed、failed
+	Components []ComponentHealth `json:"components"`
+}
+
+// Healthy release为deployed且所有组件的Pod都已就绪
+func (s *Status) Healthy() bool {
+	if s.State != "deployed" || len(s.Components) == 0 {
+		return false
+	}
+	for _, c := range s.Components {
+		if c.Ready < c.Total {
+			return false
+		}
+	}
+	return true
+}
+
+// CollectStatus 只读查询Rainbond release状态和命名空间内各组件的Pod就绪情况
+func (r *RainbondInstaller) CollectStatus() (*Status, error) {
+	if err := r.ensureClients(); err != nil {
+		return nil, err
+	}
+
+	namespace := r.config.Rainbond.Namespace
+	if namespace == "" {
+		namespace = "rbd-system"
+	}
+
+	release, err := r.helm.Status("rainbond", namespace)
+	if err != nil {
+		return nil, err
+	}
+	statuses, err := r.collectComponentStatus(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{
+		Release:  release.Name,
+		Revision: release.Revision,
+		State:    strings.ToLower(release.Status),
+	}
+	for _, s := range statuses {
+		status.Components = append(status.Components, ComponentHealth{
+			Name:    s.Name,
+			Total:   s.Total,
+			Ready:   s.Ready,
+			Reasons: s.Reasons,
+		})
+	}
+	return status, nil
+}
@@ -0,0 +1,88 @@
+package rke2
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// TransportResult 单个主机各传输方式的测试结果，nil表示成功
+type TransportResult struct {
+	IP           string
+	SSH          error
+	SCP          error
+	Rsync        error
+	RsyncSkipped string // 跳过rsync测试的原因
+}
+
+// TestConnections 对每个主机执行SSH、scp、rsync往返测试，使用与安装过程相同的命令构建方式
+func (r *RKE2Installer) TestConnections() ([]TransportResult, error) {
+	content := fmt.Sprintf("roi-connection-test-%d", os.Getpid())
+	localFile, err := os.CreateTemp("", "roi-connection-test-*")
+	if err != nil {
+		return nil, fmt.Errorf("创建本地测试文件失败: %w", err)
+	}
+	defer os.Remove(localFile.Name())
+	if _, err := localFile.WriteString(content); err != nil {
+		localFile.Close()
+		return nil, fmt.Errorf("写入本地测试文件失败: %w", err)
+	}
+	localFile.Close()
+
+	var results []TransportResult
+	for _, host := range r.config.Hosts {
+		results = append(results, r.testHostConnection(host, localFile.Name(), content))
+	}
+	return results, nil
+}
+
+// testHostConnection 测试单个主机的SSH执行、scp和rsync传输
+func (r *RKE2Installer) testHostConnection(host config.Host, localPath, content string) TransportResult {
+	result := TransportResult{IP: host.IP}
+	remotePath := fmt.Sprintf("/tmp/.roi-connection-test-%d", os.Getpid())
+
+	output, err := r.buildSSHCommand(host, "echo roi-ok").CombinedOutput()
+	if err != nil {
+		result.SSH = fmt.Errorf("%w, 输出: %s", err, strings.TrimSpace(string(output)))
+		result.SCP = fmt.Errorf("SSH不可用，跳过")
+		result.RsyncSkipped = "SSH不可用"
+		return result
+	}
+	if !strings.Contains(string(output), "roi-ok") {
+		result.SSH = fmt.Errorf("命令输出异常: %s", strings.TrimSpace(string(output)))
+	}
+	defer r.buildSSHCommand(host, fmt.Sprintf("rm -f %s", remotePath)).Run()
+
+	if output, err := r.buildScpCommand(host, localPath, remotePath).CombinedOutput(); err != nil {
+		result.SCP = fmt.Errorf("%w, 输出: %s", err, strings.TrimSpace(string(output)))
+	} else {
+		result.SCP = r.verifyRemoteContent(host, remotePath, content)
+	}
+
+	if ssh.GetBackend() == ssh.BackendNative {
+		result.RsyncSkipped = "native后端不使用rsync"
+		return result
+	}
+	r.buildSSHCommand(host, fmt.Sprintf("rm -f %s", remotePath)).Run()
+	if err := r.transferFileWithRsync(host, localPath, remotePath, false); err != nil {
+		result.Rsync = err
+	} else {
+		result.Rsync = r.verifyRemoteContent(host, remotePath, content)
+	}
+	return result
+}
+
+// verifyRemoteContent 确认远程测试文件内容与本地一致
+func (r *RKE2Installer) verifyRemoteContent(host config.Host, remotePath, content string) error {
+	output, err := r.buildSSHCommand(host, fmt.Sprintf("cat %s", remotePath)).Output()
+	if err != nil {
+		return fmt.Errorf("读取远程测试文件失败: %w", err)
+	}
+	if strings.TrimSpace(string(output)) != content {
+		return fmt.Errorf("远程测试文件内容不一致")
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package rke2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// ensureContainerdStorage 确认容器存储逻辑卷已挂载到RKE2的containerd数据目录（位于rke2.data_dir下）
+// 逻辑卷挂载在其他路径时，通过bind mount挂载到RKE2的containerd目录并写入fstab
+func (r *RKE2Installer) ensureContainerdStorage(host config.Host) error {
+	lv := r.config.ContainerdLV(host)
+	if lv == nil {
+		return nil
+	}
+
+	mountPoint := config.LVMountPoint(*lv)
+	containerdDir := r.config.RKE2ContainerdPath()
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 检查容器存储逻辑卷 %s (%s)", host.IP, lv.LVName, mountPoint)
+	}
+
+	// 逻辑卷必须已由LVM阶段挂载
+	if err := r.buildSSHCommand(host, fmt.Sprintf("mountpoint -q %s", mountPoint)).Run(); err != nil {
+		return fmt.Errorf("容器存储逻辑卷 %s 未挂载到 %s，请先执行 roi up --lvm", lv.LVName, mountPoint)
+	}
+
+	if strings.TrimRight(mountPoint, "/") != containerdDir {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 将 %s 绑定挂载到RKE2 containerd目录 %s", host.IP, mountPoint, containerdDir)
+		}
+		bindCmd := fmt.Sprintf(`
+			set -e
+			mkdir -p %[2]s
+			if ! mountpoint -q %[2]s; then
+				mount --bind %[1]s %[2]s
+			fi
+		`, mountPoint, containerdDir)
+		if output, err := r.buildSSHCommand(host, bindCmd).CombinedOutput(); err != nil {
+			return fmt.Errorf("绑定挂载containerd数据目录失败: %w, 输出: %s", err, string(output))
+		}
+		// 绑定挂载写入fstab中由roi管理的区块，data_dir变化时整体替换旧条目；
+		// nofail: 逻辑卷缺失时不阻塞主机启动
+		if _, err := ssh.WriteManagedBlock(host, r.buildSSHCommand, ssh.ManagedBlock{
+			Path:    "/etc/fstab",
+			Name:    "containerd-bind",
+			Content: fmt.Sprintf("%s %s none bind,nofail 0 0", mountPoint, containerdDir),
+			Dedupe:  true,
+		}); err != nil {
+			return fmt.Errorf("写入containerd绑定挂载的fstab条目失败: %w", err)
+		}
+	}
+
+	// RKE2启动前再次确认containerd目录位于专用磁盘
+	if err := r.buildSSHCommand(host, fmt.Sprintf("mountpoint -q %s", containerdDir)).Run(); err != nil {
+		return fmt.Errorf("RKE2 containerd目录 %s 未挂载到容器存储逻辑卷", containerdDir)
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: containerd数据目录已使用逻辑卷 %s", host.IP, lv.LVName)
+	}
+	return nil
+}
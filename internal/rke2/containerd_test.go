@@ -0,0 +1,45 @@
+package rke2
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+func TestEnsureContainerdStorageWritesManagedFstabBlock(t *testing.T) {
+	host := config.Host{
+		IP:        "10.0.0.1",
+		Role:      []string{"worker"},
+		LVMConfig: &config.LVMConfig{LVs: []config.LogicalVolume{{LVName: config.ContainerdLVName, Size: "100G"}}},
+	}
+	cfg := &config.Config{
+		Hosts: []config.Host{host},
+		RKE2:  config.RKE2Config{DataDir: "/data/rke2"},
+	}
+	runner := &recordingRunner{}
+	installer := NewRKE2Installer(cfg)
+	installer.SetRunner(runner)
+
+	if err := installer.ensureContainerdStorage(host); err != nil {
+		t.Fatalf("ensureContainerdStorage() error = %v", err)
+	}
+
+	var block string
+	for _, command := range runner.commands[host.IP] {
+		if strings.Contains(command, "/etc/fstab") {
+			if strings.Contains(command, "sed -i") || strings.Contains(command, ">> /etc/fstab") {
+				t.Errorf("fstab edited in place:\n%s", command)
+			}
+			if strings.Contains(command, "# BEGIN roi containerd-bind") {
+				block = command
+			}
+		}
+	}
+	if block == "" {
+		t.Fatalf("no command writes the containerd-bind block, commands: %q", runner.commands[host.IP])
+	}
+	if want := "\n/var/lib/containerd /data/rke2/agent/containerd none bind,nofail 0 0\n"; !strings.Contains(block, want) {
+		t.Errorf("containerd-bind block does not contain %q:\n%s", want, block)
+	}
+}
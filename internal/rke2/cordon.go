@@ -0,0 +1,79 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// prepareCordon 重新执行安装时，第一个server已运行则连接已有集群，之后处理已加入集群的节点前先标记为不可调度；
+// 首次安装时API尚不存在，不进行cordon
+func (r *RKE2Installer) prepareCordon(status map[string]*RKE2Status, firstServer config.Host) {
+	r.cordonClient = nil
+	if s, ok := status[firstServer.IP]; !ok || !s.Running {
+		return
+	}
+
+	client, err := r.createKubernetesClient(firstServer)
+	if err == nil {
+		_, err = client.Discovery().ServerVersion()
+	}
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Warn("无法连接已有集群: %v，处理已加入的节点时不进行cordon", err)
+		}
+		return
+	}
+	r.cordonClient = client
+}
+
+// withNodeCordoned 在节点已加入集群时先cordon再执行fn，完成且节点就绪后恢复调度，避免Pod被调度到正在重新配置的节点上；
+// 节点尚未加入、已被手动cordon或未连接到集群时直接执行fn
+func (r *RKE2Installer) withNodeCordoned(host config.Host, fn func() error) error {
+	client := r.cordonClient
+	if client == nil {
+		return fn()
+	}
+	node, err := r.findKubernetesNode(client, host)
+	if err != nil {
+		return fn()
+	}
+	if node.Spec.Unschedulable {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 节点 %s 已处于不可调度状态，处理后保持不变", host.IP, node.Name)
+		}
+		return fn()
+	}
+
+	if err := r.cordonNode(client, node.Name); err != nil {
+		if r.logger != nil {
+			r.logger.Warn("主机 %s: %v，继续处理节点", host.IP, err)
+		}
+		return fn()
+	}
+	if err := fn(); err != nil {
+		return fmt.Errorf("%w，节点 %s 保持不可调度状态", err, node.Name)
+	}
+	if err := r.waitForNodeReadyByName(client, host, node.Name, time.Time{}); err != nil {
+		return fmt.Errorf("%w，节点 %s 保持不可调度状态", err, node.Name)
+	}
+	r.uncordonNode(client, node.Name)
+	return nil
+}
+
+// cordonNode 将节点标记为不可调度
+func (r *RKE2Installer) cordonNode(client kubernetes.Interface, nodeName string) error {
+	patch := []byte(`{"spec":{"unschedulable":true}}`)
+	if _, err := client.CoreV1().Nodes().Patch(context.TODO(), nodeName, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("标记节点 %s 为不可调度失败: %w", nodeName, err)
+	}
+	if r.logger != nil {
+		r.logger.Info("节点 %s 已标记为不可调度", nodeName)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package rke2
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// dataDirMountScript 输出数据目录所在挂载点和设备，目录不存在时输出 missing
+const dataDirMountScript = `
+	dir=%s
+	if [ ! -d "$dir" ]; then
+		echo missing
+		exit 0
+	fi
+	findmnt -n -o TARGET,SOURCE --target "$dir" 2>/dev/null || df -P "$dir" | awk 'NR==2 {print $6, $1}'
+`
+
+// checkDataDirs 配置了rke2.data_dir时，在安装前确认各节点上该目录已存在，并检查其挂载：
+// 配置了承载该目录的逻辑卷时必须已挂载到位，否则位于根文件系统时给出警告，etcd IO将与系统盘竞争
+func (r *RKE2Installer) checkDataDirs() error {
+	if r.config.RKE2.DataDir == "" {
+		return nil
+	}
+	dataDir := r.config.RKE2DataDir()
+	hosts := r.config.Hosts
+	errs := ssh.ForEachHost(hosts, func(host config.Host) error {
+		return r.checkDataDir(host, dataDir)
+	})
+	if failures := ssh.CollectHostErrors(hosts, errs); len(failures) > 0 {
+		return fmt.Errorf("节点 %s RKE2数据目录检查失败: %w", failures[0].Host, failures[0].Err)
+	}
+	return nil
+}
+
+// checkDataDir 检查单个节点上的RKE2数据目录
+func (r *RKE2Installer) checkDataDir(host config.Host, dataDir string) error {
+	output, err := r.buildSSHCommand(host, fmt.Sprintf(dataDirMountScript, dataDir)).Output()
+	if err != nil {
+		return fmt.Errorf("获取 %s 的挂载信息失败: %w", dataDir, err)
+	}
+	fields := strings.Fields(string(output))
+	if len(fields) == 0 {
+		return fmt.Errorf("无法识别 %s 的挂载信息", dataDir)
+	}
+	if fields[0] == "missing" {
+		return fmt.Errorf("RKE2数据目录 %s 不存在，请先创建并挂载独立磁盘（可在主机的lvm配置中添加挂载到该目录的逻辑卷后执行 roi up --lvm）", dataDir)
+	}
+	target, source := fields[0], ""
+	if len(fields) > 1 {
+		source = fields[1]
+	}
+
+	if lv := r.config.DataDirLV(host); lv != nil {
+		if mountPoint := path.Clean(config.LVMountPoint(*lv)); target != mountPoint {
+			return fmt.Errorf("逻辑卷 %s 应挂载到 %s，但 %s 当前位于挂载点 %s，请先执行 roi up --lvm", lv.LVName, mountPoint, dataDir, target)
+		}
+		if r.logger != nil {
+			r.logger.Info("主机 %s: RKE2数据目录 %s 位于逻辑卷 %s (%s)", host.IP, dataDir, lv.LVName, target)
+		}
+		return nil
+	}
+
+	if target == "/" {
+		if r.logger != nil {
+			r.logger.Warn("主机 %s: RKE2数据目录 %s 位于根文件系统，未使用独立挂载，etcd IO将与系统盘竞争", host.IP, dataDir)
+		}
+		return nil
+	}
+	if r.logger != nil {
+		r.logger.Info("主机 %s: RKE2数据目录 %s 位于挂载点 %s (%s)", host.IP, dataDir, target, source)
+	}
+	return nil
+}
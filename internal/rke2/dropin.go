@@ -0,0 +1,34 @@
+package rke2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// RKE2DropInDir RKE2配置片段目录，其中的文件按文件名顺序合并到主配置之后
+const RKE2DropInDir = RKE2ConfigDir + "/config.yaml.d"
+
+// createDropInConfigs 上传rke2.config_drop_ins中的用户配置片段，并删除已从配置中移除的旧片段
+func (r *RKE2Installer) createDropInConfigs(host config.Host) error {
+	var script strings.Builder
+	fmt.Fprintf(&script, "mkdir -p %s\nrm -f %s/%s*.yaml\n", RKE2DropInDir, RKE2DropInDir, config.DropInPrefix)
+
+	for _, dropIn := range r.config.RKE2.ConfigDropIns {
+		data, err := dropIn.Load()
+		if err != nil {
+			return err
+		}
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 上传RKE2配置片段 %s", host.IP, dropIn.FileName())
+		}
+		fmt.Fprintf(&script, "cat > %s/%s << 'ROI_DROPIN_EOF'\n%s\nROI_DROPIN_EOF\n",
+			RKE2DropInDir, dropIn.FileName(), strings.TrimRight(string(data), "\n"))
+	}
+
+	if output, err := r.buildSSHCommand(host, script.String()).CombinedOutput(); err != nil {
+		return fmt.Errorf("上传RKE2配置片段失败: %w, 输出: %s", err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package rke2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// etcdHealthInterval etcd健康检查的轮询间隔
+const etcdHealthInterval = 5 * time.Second
+
+// etcdMemberList etcd v3 gRPC网关 /v3/cluster/member/list 的响应
+type etcdMemberList struct {
+	Members []struct {
+		Name       string   `json:"name"`
+		PeerURLs   []string `json:"peerURLs"`
+		ClientURLs []string `json:"clientURLs"`
+	} `json:"members"`
+}
+
+// etcdCurlCmd 使用RKE2生成的etcd客户端证书访问本机etcd
+func (r *RKE2Installer) etcdCurlCmd(args string) string {
+	tlsDir := r.config.RKE2DataDir() + "/server/tls/etcd"
+	return fmt.Sprintf("curl -sf --max-time 5 --cacert %s/server-ca.crt --cert %s/server-client.crt --key %s/server-client.key %s",
+		tlsDir, tlsDir, tlsDir, args)
+}
+
+// waitForEtcdHealthy 在etcd节点上检查本机etcd健康，并等待集群中已启动的成员数达到expectedMembers，
+// 仅以node-token存在判断server就绪时，新成员可能尚未完成加入，此时继续加入下一个server容易导致etcd失去法定人数
+func (r *RKE2Installer) waitForEtcdHealthy(host config.Host, expectedMembers int) error {
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 等待etcd健康（期望成员数: %d）", host.IP, expectedMembers)
+	}
+
+	healthCmd := r.etcdCurlCmd("https://127.0.0.1:2379/health")
+	memberCmd := r.etcdCurlCmd("-X POST -d '{}' https://127.0.0.1:2379/v3/cluster/member/list")
+
+	err := cluster.WaitFor(context.Background(), r.logger, etcdHealthInterval, r.config.EtcdHealthTimeout(),
+		fmt.Sprintf("主机 %s etcd健康", host.IP),
+		func(ctx context.Context) (bool, error) {
+			output, err := r.buildSSHCommand(host, healthCmd).Output()
+			if err != nil {
+				return false, fmt.Errorf("etcd健康检查失败: %w", err)
+			}
+			var health struct {
+				Health string `json:"health"`
+			}
+			if err := json.Unmarshal(output, &health); err != nil || health.Health != "true" {
+				return false, fmt.Errorf("etcd不健康: %s", strings.TrimSpace(string(output)))
+			}
+
+			output, err = r.buildSSHCommand(host, memberCmd).Output()
+			if err != nil {
+				return false, fmt.Errorf("获取etcd成员列表失败: %w", err)
+			}
+			var list etcdMemberList
+			if err := json.Unmarshal(output, &list); err != nil {
+				return false, fmt.Errorf("解析etcd成员列表失败: %w", err)
+			}
+			// 未启动的成员没有name和clientURLs
+			started := 0
+			for _, member := range list.Members {
+				if member.Name != "" && len(member.ClientURLs) > 0 {
+					started++
+				}
+			}
+			if started < expectedMembers {
+				return false, fmt.Errorf("etcd已启动成员 %d/%d（共 %d 个成员）", started, expectedMembers, len(list.Members))
+			}
+			return true, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: etcd健康，已启动成员数达到 %d", host.IP, expectedMembers)
+	}
+	return nil
+}
+
+// waitBeforeNextServer 加入下一个server节点前等待配置的稳定时间
+func (r *RKE2Installer) waitBeforeNextServer() {
+	delay := r.config.ServerJoinDelay()
+	if delay <= 0 {
+		return
+	}
+	if r.logger != nil {
+		r.logger.Info("等待 %s 让集群稳定后再加入下一个server节点", delay)
+	}
+	time.Sleep(delay)
+}
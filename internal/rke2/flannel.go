@@ -0,0 +1,127 @@
+package rke2
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/templates"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// canalConfigFile canal网卡配置，RKE2 server启动时自动应用数据目录下manifests目录中的清单
+func (r *RKE2Installer) canalConfigFile() string {
+	return path.Join(r.config.RKE2DataDir(), "server/manifests/rke2-canal-config.yaml")
+}
+
+// canalConfigData canal网卡配置模板参数，所有主机网卡名称相同时使用Iface，否则使用IfaceRegex匹配各主机的网卡
+type canalConfigData struct {
+	Iface      string
+	IfaceRegex string
+}
+
+// resolveFlannelIfaces 确定各主机容器网络使用的网卡并确认网卡存在
+// 只要有主机配置了flannel_iface，未配置的主机也按internal_ip所在网卡确定，保证canal的网卡匹配覆盖所有节点
+func (r *RKE2Installer) resolveFlannelIfaces() error {
+	configured := false
+	for _, host := range r.config.Hosts {
+		if host.FlannelIface != "" {
+			configured = true
+			break
+		}
+	}
+	if !configured {
+		return nil
+	}
+
+	ifaces := make(map[string]string)
+	for _, host := range r.config.Hosts {
+		iface, err := r.resolveFlannelIface(host)
+		if err != nil {
+			return fmt.Errorf("主机 %s: %w", host.IP, err)
+		}
+		ifaces[host.IP] = iface
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 容器网络使用网卡 %s", host.IP, iface)
+		}
+	}
+	r.flannelIfaces = ifaces
+	return nil
+}
+
+// resolveFlannelIface 获取单个主机的容器网络网卡，显式配置的网卡需存在，auto或未配置时使用internal_ip所在网卡
+func (r *RKE2Installer) resolveFlannelIface(host config.Host) (string, error) {
+	if host.FlannelIface != "" && host.FlannelIface != config.FlannelIfaceAuto {
+		checkCmd := fmt.Sprintf("ip link show dev %s", host.FlannelIface)
+		if output, err := r.buildSSHCommand(host, checkCmd).CombinedOutput(); err != nil {
+			return "", fmt.Errorf("flannel_iface指定的网卡 %s 不存在: %w, 输出: %s", host.FlannelIface, err, strings.TrimSpace(string(output)))
+		}
+		return host.FlannelIface, nil
+	}
+
+	ip := r.getNodeInternalIP(host)
+	findCmd := fmt.Sprintf("ip -o addr show | awk '{split($4, a, \"/\"); if (a[1] == \"%s\") {print $2; exit}}'", ip)
+	output, err := r.buildSSHCommand(host, findCmd).Output()
+	if err != nil {
+		return "", fmt.Errorf("查找internal_ip %s 所在网卡失败: %w", ip, err)
+	}
+	iface := strings.TrimSpace(string(output))
+	if iface == "" {
+		return "", fmt.Errorf("未找到绑定internal_ip %s 的网卡，请通过flannel_iface显式指定", ip)
+	}
+	return iface, nil
+}
+
+// canalConfig 根据各主机的网卡生成canal网卡配置参数
+func (r *RKE2Installer) canalConfig() canalConfigData {
+	seen := make(map[string]bool)
+	var names []string
+	for _, iface := range r.flannelIfaces {
+		if !seen[iface] {
+			seen[iface] = true
+			names = append(names, iface)
+		}
+	}
+	if len(names) == 1 {
+		return canalConfigData{Iface: names[0]}
+	}
+
+	sort.Strings(names)
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	// 写入YAML双引号字符串，反斜杠需要转义
+	pattern := "^(" + strings.Join(quoted, "|") + ")$"
+	return canalConfigData{IfaceRegex: strings.ReplaceAll(pattern, `\`, `\\`)}
+}
+
+// createCanalConfig 在server节点上写入canal网卡配置
+func (r *RKE2Installer) createCanalConfig(host config.Host) error {
+	if len(r.flannelIfaces) == 0 {
+		return nil
+	}
+
+	content, err := templates.Render(templates.RKE2CanalConfig, "", r.canalConfig())
+	if err != nil {
+		return err
+	}
+
+	configFile := r.canalConfigFile()
+	createCmd := fmt.Sprintf(`
+		mkdir -p $(dirname %s)
+		cat > %s << 'EOF'
+%s
+EOF
+	`, configFile, configFile, content)
+	if output, err := r.buildSSHCommand(host, createCmd).CombinedOutput(); err != nil {
+		return fmt.Errorf("写入 %s 失败: %w, 输出: %s", configFile, err, strings.TrimSpace(string(output)))
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 已写入canal网卡配置 %s", host.IP, configFile)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/images"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// importImagesScript 将镜像包导入运行中的RKE2 containerd的k8s.io命名空间
+const importImagesScript = `
+	bin=%s
+	sock=/run/k3s/containerd/containerd.sock
+	if [ ! -x "$bin/ctr" ] || [ ! -S "$sock" ]; then
+		echo "未找到RKE2的ctr或containerd未运行，请先安装并启动RKE2" >&2
+		exit 1
+	fi
+	"$bin/ctr" --address "$sock" -n k8s.io images import %s
+`
+
+// ImageLoadProgress 镜像加载的节点进度回调，message为当前步骤
+type ImageLoadProgress func(host, message string)
+
+// ImageLoadResult 单个节点的镜像加载结果
+type ImageLoadResult struct {
+	Host     string
+	Duration time.Duration
+	Missing  []string // 导入后仍缺失的抽样镜像
+	Err      error
+}
+
+// LoadImages 以 concurrency 个并发（<1时使用全局并发数）将离线镜像包传输到所有节点并导入containerd，
+// 适用于RKE2已运行、不再从镜像目录自动导入的场景；导入后在每个节点上检查 sample 中的镜像都已存在
+// 通过 SetOnlyNewImages 开启增量导入时，只传输和导入节点上缺失或镜像ID已变化的镜像
+// 结果按配置中的主机顺序返回，单个节点失败不影响其余节点
+func (r *RKE2Installer) LoadImages(tarballs, sample []string, concurrency int, progress ImageLoadProgress) []ImageLoadResult {
+	hosts := r.config.Hosts
+	// 镜像包保存在RKE2启动时自动导入的目录，节点重启后RKE2仍可重新导入
+	imagesDir := r.config.RKE2ImagesDir()
+	report := func(host config.Host, format string, v ...interface{}) {
+		if progress != nil {
+			progress(host.IP, fmt.Sprintf(format, v...))
+		}
+	}
+
+	var incremental *imageSync
+	if r.onlyNewImages {
+		s, err := r.newImageSync(tarballs)
+		if err != nil {
+			if r.logger != nil {
+				r.logger.Warn("%v，将完整导入镜像包", err)
+			}
+		} else {
+			incremental = s
+			defer incremental.Close()
+		}
+	}
+
+	var mu sync.Mutex
+	missing := make(map[string][]string)
+	durations := make(map[string]time.Duration)
+	errs := ssh.ForEachHostContext(context.Background(), hosts, concurrency, func(_ context.Context, host config.Host) error {
+		start := time.Now()
+		defer func() {
+			mu.Lock()
+			durations[host.IP] = time.Since(start)
+			mu.Unlock()
+		}()
+
+		if err := r.buildSSHCommand(host, "mkdir -p "+imagesDir).Run(); err != nil {
+			return fmt.Errorf("创建镜像目录 %s 失败: %w", imagesDir, err)
+		}
+		var nodeIDs map[string]string
+		if incremental != nil {
+			report(host, "获取节点上的镜像ID")
+			nodeIDs = incremental.nodeImageIDs(host)
+		}
+		for i, tarball := range tarballs {
+			remotePath := imagesDir + "/" + filepath.Base(tarball)
+			if incremental != nil {
+				if changed, total, ok := incremental.changedImages(tarball, nodeIDs); ok {
+					if len(changed) == 0 {
+						report(host, "%s 中的 %d 个镜像均已是最新，跳过导入 (%d/%d)", filepath.Base(tarball), total, i+1, len(tarballs))
+					} else {
+						report(host, "导入 %s 中新增或变化的 %d/%d 个镜像 (%d/%d)", filepath.Base(tarball), len(changed), total, i+1, len(tarballs))
+						if err := incremental.importChanged(host, tarball, changed); err != nil {
+							return fmt.Errorf("增量导入 %s 失败: %w", filepath.Base(tarball), err)
+						}
+					}
+					if err := incremental.removeStaleTarball(host, tarball, remotePath); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			report(host, "传输 %s (%d/%d)", filepath.Base(tarball), i+1, len(tarballs))
+			if err := r.transferFileWithProgress(host, tarball, remotePath); err != nil {
+				return fmt.Errorf("传输 %s 失败: %w", tarball, err)
+			}
+			report(host, "导入 %s (%d/%d)", filepath.Base(tarball), i+1, len(tarballs))
+			output, err := r.buildSSHCommand(host, fmt.Sprintf(importImagesScript, r.config.RKE2BinDir(), remotePath)).CombinedOutput()
+			if err != nil {
+				return fmt.Errorf("导入 %s 失败: %w, 输出: %s", filepath.Base(tarball), err, strings.TrimSpace(string(output)))
+			}
+		}
+
+		if len(sample) == 0 {
+			return nil
+		}
+		report(host, "校验 %d 个抽样镜像", len(sample))
+		node, err := images.NewInventoryWithLogger(r.config, sample, r.logger).Inspect(host)
+		if err != nil {
+			return fmt.Errorf("校验镜像失败: %w", err)
+		}
+		if len(node.Missing) > 0 {
+			mu.Lock()
+			missing[host.IP] = node.Missing
+			mu.Unlock()
+			return fmt.Errorf("导入后仍缺少 %d 个抽样镜像", len(node.Missing))
+		}
+		return nil
+	})
+
+	results := make([]ImageLoadResult, len(hosts))
+	for i, host := range hosts {
+		results[i] = ImageLoadResult{Host: host.IP, Duration: durations[host.IP], Missing: missing[host.IP], Err: errs[i]}
+		if errs[i] == nil {
+			report(host, "完成 (%s)", durations[host.IP].Round(time.Second))
+		} else {
+			report(host, "失败: %v", errs[i])
+		}
+	}
+	return results
+}
@@ -0,0 +1,172 @@
+package rke2
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/images"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// imageSyncRemoteDir 增量导入时镜像子集在节点上的临时目录，导入后删除
+const imageSyncRemoteDir = "/tmp/rke2-artifacts"
+
+// SetOnlyNewImages 设置加载镜像时是否只导入节点上缺失或镜像ID已变化的镜像；
+// 无法对比的镜像包（如OCI格式）或节点（没有crictl）仍完整导入
+func (r *RKE2Installer) SetOnlyNewImages(onlyNew bool) {
+	r.onlyNewImages = onlyNew
+}
+
+// imageSync 增量导入时在所有节点间共享的镜像包信息和已生成的镜像子集
+type imageSync struct {
+	r         *RKE2Installer
+	bundleIDs map[string]map[string]string // 各镜像包中的镜像ID，按镜像包路径索引，不支持增量导入的镜像包不在其中
+	workDir   string                       // 本地生成镜像子集的临时目录
+	mu        sync.Mutex                   // 保护 subsets 和 localInfo，生成镜像子集时持有
+	subsets   map[string]string            // 已生成的镜像子集路径，按镜像包和镜像列表索引
+	localInfo map[string]*FileInfo         // 镜像包的本地文件信息，用于判断节点上的同名镜像包是否为旧版本
+}
+
+// newImageSync 读取各镜像包中的镜像ID，读取失败的镜像包在所有节点上完整导入
+func (r *RKE2Installer) newImageSync(tarballs []string) (*imageSync, error) {
+	workDir, err := os.MkdirTemp("", "roi-images-")
+	if err != nil {
+		return nil, fmt.Errorf("创建镜像子集临时目录失败: %w", err)
+	}
+	s := &imageSync{
+		r:         r,
+		bundleIDs: make(map[string]map[string]string),
+		workDir:   workDir,
+		subsets:   make(map[string]string),
+		localInfo: make(map[string]*FileInfo),
+	}
+	for _, tarball := range tarballs {
+		ids, err := images.ReadTarballImageIDs(tarball)
+		if err != nil {
+			if r.logger != nil {
+				r.logger.Warn("镜像包 %s 无法按镜像增量导入: %v，将完整导入", tarball, err)
+			}
+			continue
+		}
+		s.bundleIDs[tarball] = ids
+	}
+	return s, nil
+}
+
+// Close 删除本地生成的镜像子集
+func (s *imageSync) Close() {
+	os.RemoveAll(s.workDir)
+}
+
+// nodeImageIDs 获取节点上的镜像ID，失败时返回nil，该节点上的镜像包完整导入
+func (s *imageSync) nodeImageIDs(host config.Host) map[string]string {
+	ids, err := images.NewInventoryWithLogger(s.r.config, nil, s.r.logger).ImageIDs(host)
+	if err != nil {
+		if s.r.logger != nil {
+			s.r.logger.Warn("主机 %s: %v，将完整导入镜像包", host.IP, err)
+		}
+		return nil
+	}
+	return ids
+}
+
+// changedImages 返回镜像包中节点上缺失或镜像ID不一致的镜像，ok为false表示该镜像包需要完整导入
+func (s *imageSync) changedImages(tarball string, nodeIDs map[string]string) (changed []string, total int, ok bool) {
+	bundle, found := s.bundleIDs[tarball]
+	if !found || nodeIDs == nil {
+		return nil, 0, false
+	}
+	for ref, id := range bundle {
+		if nodeIDs[ref] != id {
+			changed = append(changed, ref)
+		}
+	}
+	sort.Strings(changed)
+	// 所有镜像都需要导入时直接使用原镜像包，节点上已有相同镜像包时无需重新传输
+	if len(changed) == len(bundle) {
+		return nil, len(bundle), false
+	}
+	return changed, len(bundle), true
+}
+
+// subset 生成只包含 refs 中镜像的镜像子集，相同的镜像列表在多个节点间复用
+func (s *imageSync) subset(tarball string, refs []string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := tarball + "\x00" + strings.Join(refs, ",")
+	if path, ok := s.subsets[key]; ok {
+		return path, nil
+	}
+	path := filepath.Join(s.workDir, fmt.Sprintf("%d-%s", len(s.subsets), filepath.Base(tarball)))
+	if err := images.WriteImageSubset(tarball, path, refs); err != nil {
+		return "", fmt.Errorf("生成镜像子集失败: %w", err)
+	}
+	s.subsets[key] = path
+	return path, nil
+}
+
+// importChanged 将镜像子集传输到节点并导入containerd，导入后删除节点上的临时文件
+func (s *imageSync) importChanged(host config.Host, tarball string, refs []string) error {
+	subset, err := s.subset(tarball, refs)
+	if err != nil {
+		return err
+	}
+	r := s.r
+	remotePath := imageSyncRemoteDir + "/roi-new-" + filepath.Base(tarball)
+	if err := r.buildSSHCommand(host, "mkdir -p "+imageSyncRemoteDir).Run(); err != nil {
+		return fmt.Errorf("创建临时目录 %s 失败: %w", imageSyncRemoteDir, err)
+	}
+	if err := r.transferFileWithProgress(host, subset, remotePath); err != nil {
+		return fmt.Errorf("传输镜像子集失败: %w", err)
+	}
+	output, err := r.buildSSHCommand(host, fmt.Sprintf(importImagesScript, r.config.RKE2BinDir(), remotePath)).CombinedOutput()
+	r.buildSSHCommand(host, "rm -f "+remotePath).Run()
+	if err != nil {
+		return fmt.Errorf("导入镜像子集失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// removeStaleTarball 删除节点镜像目录中与本地镜像包不一致的同名旧镜像包，
+// 避免RKE2重启时重新导入旧镜像覆盖增量导入的新镜像
+func (s *imageSync) removeStaleTarball(host config.Host, tarball, remotePath string) error {
+	r := s.r
+	remoteInfo, err := r.getRemoteFileInfo(host, remotePath)
+	if err != nil {
+		return nil
+	}
+	localInfo, err := s.tarballInfo(tarball)
+	if err != nil {
+		return err
+	}
+	if remoteInfo.size == localInfo.size && remoteInfo.md5 == localInfo.md5 {
+		return nil
+	}
+	if err := r.buildSSHCommand(host, "rm -f "+remotePath).Run(); err != nil {
+		return fmt.Errorf("删除旧版本镜像包 %s 失败: %w", remotePath, err)
+	}
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 已删除旧版本镜像包 %s，避免RKE2重启时导入旧镜像", host.IP, remotePath)
+	}
+	return nil
+}
+
+// tarballInfo 返回镜像包的本地文件信息，只计算一次
+func (s *imageSync) tarballInfo(tarball string) (*FileInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if info, ok := s.localInfo[tarball]; ok {
+		return info, nil
+	}
+	info, err := s.r.getLocalFileInfo(tarball)
+	if err != nil {
+		return nil, err
+	}
+	s.localInfo[tarball] = info
+	return info, nil
+}
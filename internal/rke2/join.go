@@ -0,0 +1,163 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	joinReadyTimeout  = 5 * time.Minute
+	joinPollInterval  = 10 * time.Second
+	kubeletHealthzCmd = "curl -sf --max-time 5 http://127.0.0.1:10248/healthz"
+)
+
+// SetJoinTarget 设置加入已有集群使用的server地址和token
+// 设置后agent节点向该地址注册，不再从配置中的server节点推导，配置中只需包含待加入的worker主机
+func (r *RKE2Installer) SetJoinTarget(server, token string) {
+	r.joinServer = server
+	r.joinToken = token
+}
+
+// JoinAgents 将配置中的worker主机作为agent加入已有集群：传输并校验离线资源、安装agent并等待节点Ready
+// 不修改server节点；kubeconfigPath 可访问时通过API确认节点Ready，否则退化为检查各节点kubelet健康状态
+func (r *RKE2Installer) JoinAgents(kubeconfigPath string) error {
+	if r.joinServer == "" || r.joinToken == "" {
+		return fmt.Errorf("加入已有集群需要指定server地址和token")
+	}
+	hosts := r.config.Hosts
+	if len(hosts) == 0 {
+		return fmt.Errorf("未找到需要加入集群的主机")
+	}
+	for _, host := range hosts {
+		roles := r.normalizeRoles(host.Role)
+		if r.hasRole(roles, "etcd") || r.hasRole(roles, "master") {
+			return fmt.Errorf("主机 %s 包含etcd/master角色，roi join 只支持加入worker节点", host.IP)
+		}
+	}
+
+	if r.logger != nil {
+		r.logger.Info("开始将 %d 个worker节点加入集群 %s", len(hosts), r.joinServer)
+	}
+
+	if err := r.ResolveNodeNames(); err != nil {
+		return err
+	}
+	if err := r.checkDataDirs(); err != nil {
+		return err
+	}
+	if err := r.transferOfflineResourcesToAllNodes(); err != nil {
+		return fmt.Errorf("传输离线资源失败: %w", err)
+	}
+	if err := r.validatePackageIntegrityOnAllNodes(); err != nil {
+		return fmt.Errorf("安装包完整性验证失败: %w", err)
+	}
+	if err := r.checkRegistriesReachable(); err != nil {
+		return err
+	}
+	r.checkNodeAddresses()
+	if err := r.resolveFlannelIfaces(); err != nil {
+		return err
+	}
+
+	for _, host := range hosts {
+		if r.stepProgress != nil {
+			r.stepProgress.StartNodeProcessing(host.IP)
+		}
+		if err := r.verifyJoinPorts(host); err != nil {
+			return err
+		}
+		if err := r.installRKE2OnAgent(host); err != nil {
+			return fmt.Errorf("节点 %s RKE2 agent安装失败: %w", host.IP, err)
+		}
+		if r.stepProgress != nil {
+			r.stepProgress.CompleteNodeStep(host.IP)
+		}
+	}
+
+	if err := r.waitForJoinedNodes(kubeconfigPath); err != nil {
+		return err
+	}
+
+	// 重新加入的节点沿用旧的注册信息，通过API按配置校正标签和污点
+	if client, err := joinKubeClient(kubeconfigPath); err == nil {
+		if err := r.reconcileNodeMetadata(client, hosts); err != nil && r.logger != nil {
+			r.logger.Warn("校正节点标签和污点失败: %v", err)
+		}
+	} else if r.logger != nil {
+		r.logger.Warn("无法访问集群，跳过校正节点标签和污点: %v", err)
+	}
+	return nil
+}
+
+// waitForJoinedNodes 等待所有加入的节点Ready，超时未就绪的节点作为错误返回
+func (r *RKE2Installer) waitForJoinedNodes(kubeconfigPath string) error {
+	client, err := joinKubeClient(kubeconfigPath)
+	if err != nil && r.logger != nil {
+		r.logger.Warn("无法通过kubeconfig访问集群，改为检查各节点kubelet健康状态: %v", err)
+	}
+
+	pending := append([]config.Host(nil), r.config.Hosts...)
+	err = cluster.WaitFor(context.Background(), r.logger, joinPollInterval, joinReadyTimeout, "加入的节点就绪",
+		func(ctx context.Context) (bool, error) {
+			var remaining []config.Host
+			for _, host := range pending {
+				if r.joinedNodeReady(ctx, client, host) {
+					if r.logger != nil {
+						r.logger.Info("主机 %s: 已加入集群并处于Ready状态", host.IP)
+					}
+					continue
+				}
+				remaining = append(remaining, host)
+			}
+			pending = remaining
+			if len(pending) > 0 {
+				return false, fmt.Errorf("%d 个节点尚未就绪", len(pending))
+			}
+			return true, nil
+		})
+	if err == nil {
+		return nil
+	}
+
+	var ips []string
+	for _, host := range pending {
+		ips = append(ips, host.IP)
+	}
+	return fmt.Errorf("节点 %s 在 %s 内未就绪，请在节点上检查 journalctl -u rke2-agent", strings.Join(ips, ", "), joinReadyTimeout)
+}
+
+// joinedNodeReady 有集群访问权限时按节点地址确认Ready状态，否则检查节点本地kubelet健康状态
+func (r *RKE2Installer) joinedNodeReady(ctx context.Context, client kubernetes.Interface, host config.Host) bool {
+	if client == nil {
+		return r.buildSSHCommand(host, kubeletHealthzCmd).Run() == nil
+	}
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Debug("获取节点列表失败: %v", err)
+		}
+		return false
+	}
+	return nodeReadyByIP(nodes.Items, r.getNodeIP(host))
+}
+
+// joinKubeClient 从本地kubeconfig创建客户端，文件不存在时返回错误
+func joinKubeClient(kubeconfigPath string) (kubernetes.Interface, error) {
+	if _, err := os.Stat(kubeconfigPath); err != nil {
+		return nil, fmt.Errorf("kubeconfig文件不可访问: %w", err)
+	}
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("加载kubeconfig失败: %w", err)
+	}
+	return kubernetes.NewForConfig(restConfig)
+}
@@ -0,0 +1,81 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// readKubeconfig 通过SSH读取server节点上RKE2生成的kubeconfig
+func (r *RKE2Installer) readKubeconfig(host config.Host) ([]byte, error) {
+	output, err := r.buildSSHCommand(host, "cat "+RKE2ConfigDir+"/rke2.yaml").Output()
+	if err != nil {
+		return nil, fmt.Errorf("从节点 %s 获取kubeconfig失败: %w", host.IP, err)
+	}
+	return output, nil
+}
+
+// FetchKubeconfig 从server节点重新获取kubeconfig，并将API地址改写为 server（为空时使用读取的节点地址）
+// 优先使用第一个server节点，不可达时依次尝试其余server节点
+func (r *RKE2Installer) FetchKubeconfig(server string) ([]byte, string, error) {
+	servers := r.getServerHosts()
+	if len(servers) == 0 {
+		return nil, "", fmt.Errorf("未找到server节点（etcd或master角色），无法获取kubeconfig")
+	}
+
+	var failed []string
+	for _, host := range servers {
+		data, err := r.readKubeconfig(host)
+		if err != nil {
+			if r.logger != nil {
+				r.logger.Warn("%v", err)
+			}
+			failed = append(failed, err.Error())
+			continue
+		}
+		address := server
+		if address == "" {
+			address = host.IP
+		}
+		if r.logger != nil {
+			r.logger.Info("已从节点 %s 获取kubeconfig，API地址: %s", host.IP, address)
+		}
+		return config.KubeconfigForServer(data, address), host.IP, nil
+	}
+	return nil, "", fmt.Errorf("所有server节点均无法获取kubeconfig: %s", strings.Join(failed, "; "))
+}
+
+// SaveKubeconfig 以0600权限写入kubeconfig，其中包含集群管理员证书
+func SaveKubeconfig(data []byte, path string) error {
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("保存kubeconfig到 %s 失败: %w", path, err)
+	}
+	// 文件已存在时 WriteFile 不修改权限
+	if err := os.Chmod(path, 0600); err != nil {
+		return fmt.Errorf("设置kubeconfig %s 权限失败: %w", path, err)
+	}
+	return nil
+}
+
+// VerifyKubeconfig 使用kubeconfig连接API Server并列出节点，返回节点数量
+func VerifyKubeconfig(data []byte) (int, error) {
+	restConfig, err := clientcmd.RESTConfigFromKubeConfig(data)
+	if err != nil {
+		return 0, fmt.Errorf("解析kubeconfig失败: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return 0, fmt.Errorf("创建Kubernetes客户端失败: %w", err)
+	}
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return 0, fmt.Errorf("连接API Server %s 失败: %w", restConfig.Host, err)
+	}
+	return len(nodes.Items), nil
+}
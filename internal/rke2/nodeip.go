@@ -0,0 +1,158 @@
+package rke2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// routeProbeTarget 用于查询默认路由的目标地址，ip route get 只查询路由表，不会发出数据包；
+// routeProbeTargetV6 用于通过IPv6地址连接的主机
+const (
+	routeProbeTarget   = "1.1.1.1"
+	routeProbeTargetV6 = "2606:4700:4700::1111"
+)
+
+// nodeAddresses 节点实际的网络地址：默认路由使用的网卡和源地址，以及所有全局地址
+type nodeAddresses struct {
+	Iface   string            // 默认路由网卡，离线环境没有默认路由时为空
+	Address string            // 默认路由的源地址
+	Local   map[string]string // 节点上的全局地址 -> 所在网卡
+}
+
+// SetDetectNodeIP 设置是否在安装前通过SSH探测各节点的默认路由网卡和地址：
+// 未配置internal_ip的主机使用默认路由地址作为node-ip，配置的ip/internal_ip与节点实际地址不符时告警
+func (r *RKE2Installer) SetDetectNodeIP(detect bool) {
+	r.detectNodeIP = detect
+}
+
+// Warnings 返回安装过程中记录的警告
+func (r *RKE2Installer) Warnings() []advisory.Warning {
+	r.warningsMu.Lock()
+	defer r.warningsMu.Unlock()
+	return append([]advisory.Warning(nil), r.warnings...)
+}
+
+// addWarning 记录不影响继续安装的问题，可在并行处理主机时调用
+func (r *RKE2Installer) addWarning(category, host, message string) {
+	r.warningsMu.Lock()
+	defer r.warningsMu.Unlock()
+	r.warnings = append(r.warnings, advisory.Warning{Category: category, Host: host, Message: message})
+}
+
+// checkNodeAddresses 探测各节点的实际地址并与配置中的ip/internal_ip对比，只告警不中断安装
+// 云主机NAT场景下公网IP不在任何网卡上，node-ip必须使用网卡上的内网地址，否则节点加入后不可达
+func (r *RKE2Installer) checkNodeAddresses() {
+	if !r.detectNodeIP {
+		return
+	}
+
+	r.detectedNodeIPs = make(map[string]string)
+	for _, host := range r.config.Hosts {
+		addrs, err := r.detectNodeAddresses(host)
+		if err != nil {
+			if r.logger != nil {
+				r.logger.Warn("主机 %s: 探测节点地址失败，跳过ip/internal_ip检查: %v", host.IP, err)
+			}
+			continue
+		}
+		for _, warning := range r.nodeAddressWarnings(host, addrs) {
+			if r.logger != nil {
+				r.logger.Warn("主机 %s: %s", host.IP, warning)
+			}
+			r.addWarning(advisory.CategoryNodeIP, host.IP, fmt.Sprintf("主机 %s: %s", host.IP, warning))
+		}
+	}
+}
+
+// nodeAddressWarnings 对比配置与节点实际地址，未配置internal_ip时记录探测到的默认路由地址
+func (r *RKE2Installer) nodeAddressWarnings(host config.Host, addrs nodeAddresses) []string {
+	var warnings []string
+
+	if host.InternalIP == "" {
+		if addrs.Address == "" {
+			return append(warnings, "未配置internal_ip且节点没有默认路由，无法自动确定node-ip，请在配置中填写internal_ip")
+		}
+		r.detectedNodeIPs[host.IP] = addrs.Address
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 未配置internal_ip，使用默认路由网卡 %s 的地址 %s 作为node-ip", host.IP, addrs.Iface, addrs.Address)
+		}
+		if host.IP != addrs.Address {
+			if _, local := addrs.Local[host.IP]; !local && r.logger != nil {
+				r.logger.Info("主机 %s: ip %s 不在节点网卡上，按NAT公网地址配置为node-external-ip", host.IP, host.IP)
+			}
+		}
+		return warnings
+	}
+
+	iface, local := addrs.Local[host.InternalIP]
+	switch {
+	case !local:
+		warning := fmt.Sprintf("internal_ip %s 不在节点的任何网卡上（节点地址: %s），RKE2的node-ip必须是本机地址，否则节点加入后不可达",
+			host.InternalIP, strings.Join(localAddressList(addrs), ", "))
+		if addrs.Address != "" {
+			warning += fmt.Sprintf("；默认路由网卡 %s 的地址为 %s", addrs.Iface, addrs.Address)
+		}
+		warnings = append(warnings, warning)
+	case addrs.Iface != "" && iface != addrs.Iface:
+		warnings = append(warnings, fmt.Sprintf("internal_ip %s 位于网卡 %s，而默认路由网卡为 %s（地址 %s），请确认节点间通过 %s 通信",
+			host.InternalIP, iface, addrs.Iface, addrs.Address, iface))
+	}
+
+	if host.IP == host.InternalIP {
+		return warnings
+	}
+	if _, local := addrs.Local[host.IP]; !local && r.logger != nil {
+		r.logger.Info("主机 %s: ip %s 不在节点网卡上，按NAT公网地址配置为node-external-ip", host.IP, host.IP)
+	}
+	return warnings
+}
+
+// detectNodeAddresses 通过 ip route get 和 ip addr 获取节点的默认路由网卡、源地址和全局地址
+func (r *RKE2Installer) detectNodeAddresses(host config.Host) (nodeAddresses, error) {
+	addrs := nodeAddresses{Local: make(map[string]string)}
+
+	// 离线环境可能没有默认路由，此时只检查网卡地址
+	target := routeProbeTarget
+	if config.IsIPv6(host.IP) {
+		target = routeProbeTargetV6
+	}
+	routeCmd := fmt.Sprintf("ip -o route get %s 2>/dev/null", target)
+	if output, err := r.buildSSHCommand(host, routeCmd).Output(); err == nil {
+		fields := strings.Fields(string(output))
+		for i := 0; i+1 < len(fields); i++ {
+			switch fields[i] {
+			case "dev":
+				addrs.Iface = fields[i+1]
+			case "src":
+				addrs.Address = fields[i+1]
+			}
+		}
+	}
+
+	output, err := r.buildSSHCommand(host, "ip -o addr show scope global").CombinedOutput()
+	if err != nil {
+		return addrs, fmt.Errorf("获取网卡地址失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 || (fields[2] != "inet" && fields[2] != "inet6") {
+			continue
+		}
+		address := strings.SplitN(fields[3], "/", 2)[0]
+		addrs.Local[address] = strings.TrimSuffix(fields[1], ":")
+	}
+	return addrs, nil
+}
+
+func localAddressList(addrs nodeAddresses) []string {
+	list := make([]string, 0, len(addrs.Local))
+	for address, iface := range addrs.Local {
+		list = append(list, fmt.Sprintf("%s(%s)", address, iface))
+	}
+	sort.Strings(list)
+	return list
+}
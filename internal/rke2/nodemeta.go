@@ -0,0 +1,242 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	workerRoleLabel = "node-role.kubernetes.io/worker"
+	// managedLabelsAnnotation/managedTaintsAnnotation 记录上次由roi应用的标签键和污点（key:effect），
+	// 配置中删除的条目据此从节点上移除，不影响其他组件添加的标签和污点
+	managedLabelsAnnotation = "rainbond.io/roi-managed-labels"
+	managedTaintsAnnotation = "rainbond.io/roi-managed-taints"
+)
+
+// applyNodeMetadata 通过Kubernetes API按配置校正所有节点的标签和污点
+func (r *RKE2Installer) applyNodeMetadata() error {
+	if err := r.ensureKubernetesClient(); err != nil {
+		return fmt.Errorf("创建Kubernetes客户端失败: %w", err)
+	}
+	return r.reconcileNodeMetadata(r.kubeClient, r.config.Hosts)
+}
+
+// NodeTaints 获取主机安装时使用的污点：配置了node-taint时使用配置，否则按集群组成推荐
+func (r *RKE2Installer) NodeTaints(host config.Host) []string {
+	return r.getRecommendedTaints(host)
+}
+
+// reconcileNodeMetadata 将配置的标签（集群标签、node-label、worker角色标签）和污点（node-taint或推荐污点）应用到已注册的节点
+// RKE2配置文件中的 node-label/node-taint 只在节点首次注册时生效，重装或重新加入的节点需要通过API校正
+func (r *RKE2Installer) reconcileNodeMetadata(client kubernetes.Interface, hosts []config.Host) error {
+	if r.logger != nil {
+		r.logger.Info("开始校正 %d 个节点的标签和污点...", len(hosts))
+	}
+
+	var failed []string
+	for _, host := range hosts {
+		labels, taints, err := r.desiredNodeMetadata(host)
+		if err != nil {
+			failed = append(failed, host.IP)
+			if r.logger != nil {
+				r.logger.Warn("主机 %s: %v", host.IP, err)
+			}
+			continue
+		}
+
+		node, err := r.findKubernetesNode(client, host)
+		if err != nil {
+			failed = append(failed, host.IP)
+			if r.logger != nil {
+				r.logger.Warn("主机 %s: %v，跳过校正标签和污点", host.IP, err)
+			}
+			continue
+		}
+
+		var changes []string
+		err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			current, err := client.CoreV1().Nodes().Get(context.TODO(), node.Name, metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			changes = mergeNodeMetadata(current, labels, taints)
+			if len(changes) == 0 {
+				return nil
+			}
+			_, err = client.CoreV1().Nodes().Update(context.TODO(), current, metav1.UpdateOptions{})
+			return err
+		})
+		if err != nil {
+			failed = append(failed, host.IP)
+			if r.logger != nil {
+				r.logger.Warn("更新节点 %s (%s) 的标签和污点失败: %v", host.IP, node.Name, err)
+			}
+			continue
+		}
+
+		if r.logger != nil {
+			if len(changes) == 0 {
+				r.logger.Debug("节点 %s (%s) 的标签和污点已符合配置", host.IP, node.Name)
+			} else {
+				r.logger.Info("节点 %s (%s) 已校正: %s", host.IP, node.Name, strings.Join(changes, ", "))
+			}
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d 个节点的标签和污点未能校正: %s", len(failed), strings.Join(failed, ", "))
+	}
+	if r.logger != nil {
+		r.logger.Info("节点标签和污点校正完成")
+	}
+	return nil
+}
+
+// desiredNodeMetadata 计算主机期望的标签和污点，与写入RKE2配置文件的内容一致
+func (r *RKE2Installer) desiredNodeMetadata(host config.Host) (map[string]string, []corev1.Taint, error) {
+	labels := make(map[string]string)
+	for key, value := range r.config.ClusterLabels() {
+		labels[key] = value
+	}
+	for _, label := range host.NodeLabel {
+		key, value, err := config.ParseNodeLabel(label)
+		if err != nil {
+			return nil, nil, err
+		}
+		labels[key] = value
+	}
+	if r.hasRole(r.normalizeRoles(host.Role), "worker") {
+		labels[workerRoleLabel] = "worker"
+	}
+
+	var taints []corev1.Taint
+	for _, taint := range r.getRecommendedTaints(host) {
+		key, value, effect, err := config.ParseNodeTaint(taint)
+		if err != nil {
+			return nil, nil, err
+		}
+		taints = append(taints, corev1.Taint{Key: key, Value: value, Effect: corev1.TaintEffect(effect)})
+	}
+	return labels, taints, nil
+}
+
+// mergeNodeMetadata 将期望的标签和污点合并到节点上，移除上次由roi应用但已不在配置中的条目，返回变更说明
+func mergeNodeMetadata(node *corev1.Node, labels map[string]string, taints []corev1.Taint) []string {
+	var changes []string
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+
+	for _, key := range splitManaged(node.Annotations[managedLabelsAnnotation]) {
+		if _, ok := labels[key]; !ok {
+			if _, exists := node.Labels[key]; exists {
+				delete(node.Labels, key)
+				changes = append(changes, "移除标签 "+key)
+			}
+		}
+	}
+	labelKeys := make([]string, 0, len(labels))
+	for key, value := range labels {
+		labelKeys = append(labelKeys, key)
+		if current, ok := node.Labels[key]; !ok || current != value {
+			node.Labels[key] = value
+			changes = append(changes, fmt.Sprintf("标签 %s=%s", key, value))
+		}
+	}
+
+	desired := make(map[string]corev1.Taint, len(taints))
+	taintKeys := make([]string, 0, len(taints))
+	for _, taint := range taints {
+		id := taintID(taint)
+		desired[id] = taint
+		taintKeys = append(taintKeys, id)
+	}
+	previous := make(map[string]bool)
+	for _, id := range splitManaged(node.Annotations[managedTaintsAnnotation]) {
+		previous[id] = true
+	}
+	var merged []corev1.Taint
+	applied := make(map[string]bool)
+	for _, taint := range node.Spec.Taints {
+		id := taintID(taint)
+		if want, ok := desired[id]; ok {
+			if applied[id] {
+				continue
+			}
+			applied[id] = true
+			if taint.Value != want.Value {
+				taint.Value = want.Value
+				changes = append(changes, "污点 "+formatTaint(want))
+			}
+			merged = append(merged, taint)
+			continue
+		}
+		if previous[id] {
+			changes = append(changes, "移除污点 "+formatTaint(taint))
+			continue
+		}
+		merged = append(merged, taint)
+	}
+	for _, taint := range taints {
+		if !applied[taintID(taint)] {
+			merged = append(merged, taint)
+			changes = append(changes, "污点 "+formatTaint(taint))
+		}
+	}
+	node.Spec.Taints = merged
+
+	// 记录本次应用的条目，下次校正时据此移除配置中已删除的标签和污点
+	labelsRecorded := setManaged(node.Annotations, managedLabelsAnnotation, labelKeys)
+	taintsRecorded := setManaged(node.Annotations, managedTaintsAnnotation, taintKeys)
+	if labelsRecorded || taintsRecorded {
+		if len(changes) == 0 {
+			changes = append(changes, "更新roi管理记录")
+		}
+	}
+	return changes
+}
+
+// setManaged 写入排序后的管理记录，返回记录是否变化
+func setManaged(annotations map[string]string, key string, values []string) bool {
+	sort.Strings(values)
+	value := strings.Join(values, ",")
+	if annotations[key] == value {
+		return false
+	}
+	if value == "" {
+		delete(annotations, key)
+		return true
+	}
+	annotations[key] = value
+	return true
+}
+
+func splitManaged(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// taintID 污点以 key:effect 区分，同一键可以有不同效果的多个污点
+func taintID(taint corev1.Taint) string {
+	return taint.Key + ":" + string(taint.Effect)
+}
+
+func formatTaint(taint corev1.Taint) string {
+	if taint.Value == "" {
+		return taintID(taint)
+	}
+	return fmt.Sprintf("%s=%s:%s", taint.Key, taint.Value, taint.Effect)
+}
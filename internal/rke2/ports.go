@@ -0,0 +1,55 @@
+package rke2
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// joinPorts 加入集群的节点需要访问第一个server的端口
+var joinPorts = []struct {
+	port int
+	name string
+}{
+	{9345, "RKE2 supervisor"},
+	{6443, "Kubernetes API"},
+}
+
+// SetCheckPorts 设置是否在节点加入集群前检查到第一个server的端口连通性
+func (r *RKE2Installer) SetCheckPorts(check bool) {
+	r.checkPorts = check
+}
+
+// verifyJoinPorts 从加入集群的节点通过SSH对第一个server的9345/6443端口发起TCP连接
+// 云安全组或firewalld/UFW之外的iptables规则拦截这些端口时，提前报告而不是等待加入超时
+func (r *RKE2Installer) verifyJoinPorts(host config.Host) error {
+	if !r.checkPorts {
+		return nil
+	}
+
+	server := r.getServerURL()
+	if server == "" || server == r.getNodeIP(host) {
+		return nil
+	}
+
+	var blocked []string
+	for _, p := range joinPorts {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 检查到server %s 的 %d 端口（%s）连通性", host.IP, server, p.port, p.name)
+		}
+		checkCmd := fmt.Sprintf("timeout 5 bash -c '</dev/tcp/%s/%d'", server, p.port)
+		if output, err := r.buildSSHCommand(host, checkCmd).CombinedOutput(); err != nil {
+			if r.logger != nil {
+				r.logger.Debug("主机 %s: 连接 %s:%d 失败: %v, 输出: %s", host.IP, server, p.port, err, strings.TrimSpace(string(output)))
+			}
+			blocked = append(blocked, fmt.Sprintf("端口 %d（%s）到server %s 被阻断", p.port, p.name, server))
+		}
+	}
+
+	if len(blocked) > 0 {
+		return fmt.Errorf("节点 %s 加入集群前端口检查失败: %s；请检查云安全组、iptables规则或其他防火墙是否放行这些端口",
+			host.IP, strings.Join(blocked, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,171 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	rebootTimeout      = 10 * time.Minute // 单个主机重启后等待SSH恢复和节点就绪的超时时间
+	rebootPollInterval = 10 * time.Second
+	bootIDCmd          = "cat /proc/sys/kernel/random/boot_id"
+	// rebootCmd 延迟重启，使SSH命令在连接断开前正常返回
+	rebootCmd = "nohup sh -c 'sleep 2; systemctl reboot' >/dev/null 2>&1 &"
+)
+
+// RebootHosts 重启需要重启才能使配置生效的主机（如SELinux），集群已运行时先驱逐节点：
+// 按worker、server的顺序逐个主机驱逐、重启、等待SSH恢复和节点Ready、恢复调度并确认集群健康，
+// 同一时间最多一个控制节点离线；集群尚未安装的主机直接重启
+func (r *RKE2Installer) RebootHosts(hosts []config.Host) error {
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	pending := make(map[string]bool, len(hosts))
+	for _, host := range hosts {
+		pending[host.IP] = true
+	}
+	var ordered []config.Host
+	for _, host := range append(r.getAgentHosts(), r.getServerHosts()...) {
+		if pending[host.IP] {
+			ordered = append(ordered, host)
+		}
+	}
+
+	for i, host := range ordered {
+		if r.logger != nil {
+			r.logger.Info("=== 重启主机 %d/%d: %s ===", i+1, len(ordered), host.IP)
+		}
+		if err := r.rebootHost(host); err != nil {
+			return fmt.Errorf("主机 %s 重启失败: %w", host.IP, err)
+		}
+	}
+
+	if r.logger != nil {
+		r.logger.Info("%d 个主机已重启完成", len(ordered))
+	}
+	return nil
+}
+
+// rebootHost 重启单个主机，主机已作为节点加入集群时在重启前后驱逐和恢复调度
+func (r *RKE2Installer) rebootHost(host config.Host) error {
+	controlHost := r.upgradeControlHost(host)
+	client, err := r.createKubernetesClient(controlHost)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 集群尚未运行（%v），直接重启", host.IP, err)
+		}
+		return r.rebootAndWait(host)
+	}
+	node, err := r.findKubernetesNode(client, host)
+	if err != nil {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 尚未加入集群，直接重启", host.IP)
+		}
+		return r.rebootAndWait(host)
+	}
+
+	if err := r.drainNode(controlHost, node.Name); err != nil {
+		r.uncordonNode(client, node.Name)
+		return err
+	}
+	// 快速重启时节点可能仍处于kubelet离线前上报的Ready状态，以重启时间为界等待重启后的心跳
+	rebootedAt := time.Now()
+	if err := r.rebootAndWait(host); err != nil {
+		return fmt.Errorf("%w，节点 %s 保持不可调度状态", err, node.Name)
+	}
+	if err := r.waitForNodeReadyByName(client, host, node.Name, rebootedAt); err != nil {
+		return err
+	}
+	r.uncordonNode(client, node.Name)
+	return r.waitForClusterHealthy(client)
+}
+
+// rebootAndWait 重启主机并等待SSH恢复，通过boot_id变化确认主机确实已重启
+func (r *RKE2Installer) rebootAndWait(host config.Host) error {
+	output, err := r.buildSSHCommand(host, bootIDCmd).Output()
+	if err != nil {
+		return fmt.Errorf("读取boot_id失败: %w", err)
+	}
+	bootID := strings.TrimSpace(string(output))
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 执行重启", host.IP)
+	}
+	if output, err := r.buildSSHCommand(host, rebootCmd).CombinedOutput(); err != nil {
+		return fmt.Errorf("执行重启命令失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+	// 等待重启命令生效，避免在主机关机前读到旧的boot_id
+	time.Sleep(rebootPollInterval)
+
+	err = cluster.WaitFor(context.Background(), r.logger, rebootPollInterval, rebootTimeout,
+		fmt.Sprintf("主机 %s 重启后SSH恢复", host.IP),
+		func(ctx context.Context) (bool, error) {
+			output, err := r.buildSSHCommand(host, bootIDCmd).Output()
+			if err != nil {
+				return false, fmt.Errorf("SSH暂不可用: %w", err)
+			}
+			if current := strings.TrimSpace(string(output)); current == "" || current == bootID {
+				return false, fmt.Errorf("主机尚未重启")
+			}
+			return true, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 已重启，SSH已恢复", host.IP)
+	}
+	return nil
+}
+
+// waitForNodeReadyByName 等待重启后的节点重新就绪，since 非零时要求Ready状态的心跳时间晚于该时间，
+// 避免在节点监控宽限期内把重启前上报的Ready状态当作kubelet已恢复
+func (r *RKE2Installer) waitForNodeReadyByName(client kubernetes.Interface, host config.Host, nodeName string, since time.Time) error {
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 等待节点 %s 就绪", host.IP, nodeName)
+	}
+
+	err := cluster.WaitFor(context.Background(), r.logger, rebootPollInterval, rebootTimeout,
+		fmt.Sprintf("节点 %s 重启后就绪", nodeName),
+		func(ctx context.Context) (bool, error) {
+			node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Errorf("暂时无法获取节点信息: %w", err)
+			}
+			if !isNodeReady(node) {
+				return false, fmt.Errorf("节点未就绪")
+			}
+			if heartbeat := nodeReadyHeartbeat(node); !since.IsZero() && !heartbeat.After(since) {
+				return false, fmt.Errorf("节点Ready状态仍为重启前上报（心跳时间 %s）", heartbeat.Format(time.RFC3339))
+			}
+			return true, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 节点 %s 已就绪", host.IP, nodeName)
+	}
+	return nil
+}
+
+// nodeReadyHeartbeat 返回节点Ready状态的最近心跳时间，没有Ready状态时返回零值
+func nodeReadyHeartbeat(node *corev1.Node) time.Time {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.LastHeartbeatTime.Time
+		}
+	}
+	return time.Time{}
+}
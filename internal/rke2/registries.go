@@ -0,0 +1,179 @@
+package rke2
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"gopkg.in/yaml.v3"
+)
+
+// registryCertsDir 私有镜像仓库CA证书在节点上的存放目录，每个仓库一个子目录
+const registryCertsDir = "/etc/rancher/rke2/certs"
+
+// defaultRegistryConfig 未配置任何镜像仓库时使用的Rainbond内置仓库配置
+// 内置仓库使用自签名证书，保留跳过TLS校验；需要严格校验时在rke2.registries中配置名为goodrain.me的仓库及其ca_file
+const defaultRegistryConfig = `mirrors:
+  "goodrain.me":
+    endpoint:
+      - "https://goodrain.me"
+configs:
+  "goodrain.me":
+    auth:
+      username: admin
+      password: admin1234
+    tls:
+      insecure_skip_verify: true`
+
+// registryCAPath 返回镜像仓库CA证书在节点上的路径
+func registryCAPath(registry config.RegistryConfig) string {
+	return path.Join(registryCertsDir, strings.ReplaceAll(registry.Name, ":", "_"), "ca.crt")
+}
+
+// registriesYAML 生成完整的registries.yaml内容：以registry_config为基础，合并rke2.registries中的仓库，
+// 同名仓库以rke2.registries为准；两者都未配置时使用Rainbond内置仓库配置
+func (r *RKE2Installer) registriesYAML() (string, error) {
+	base := r.config.RKE2.RegistryConfig
+	registries := r.config.RKE2.Registries
+	if base == "" && len(registries) == 0 {
+		return defaultRegistryConfig, nil
+	}
+	if len(registries) == 0 {
+		return strings.TrimSpace(base), nil
+	}
+
+	data := make(map[string]interface{})
+	if base != "" {
+		if err := yaml.Unmarshal([]byte(base), &data); err != nil {
+			return "", fmt.Errorf("解析rke2.registry_config失败: %w", err)
+		}
+	}
+	mirrors := yamlSection(data, "mirrors")
+	configs := yamlSection(data, "configs")
+
+	// rke2.registries未覆盖goodrain.me时保留Rainbond内置仓库，集群内组件依赖该仓库
+	if base == "" && !hasRegistry(registries, "goodrain.me") {
+		var defaults map[string]interface{}
+		if err := yaml.Unmarshal([]byte(defaultRegistryConfig), &defaults); err != nil {
+			return "", err
+		}
+		for key, value := range yamlSection(defaults, "mirrors") {
+			mirrors[key] = value
+		}
+		for key, value := range yamlSection(defaults, "configs") {
+			configs[key] = value
+		}
+	}
+
+	for _, registry := range registries {
+		mirrors[registry.Name] = map[string]interface{}{
+			"endpoint": []string{registry.RegistryEndpoint()},
+		}
+
+		entry := make(map[string]interface{})
+		if registry.Username != "" {
+			entry["auth"] = map[string]interface{}{
+				"username": registry.Username,
+				"password": registry.Password,
+			}
+		}
+		tls := make(map[string]interface{})
+		if registry.CAFile != "" {
+			tls["ca_file"] = registryCAPath(registry)
+		}
+		if registry.Insecure {
+			tls["insecure_skip_verify"] = true
+		}
+		if len(tls) > 0 {
+			entry["tls"] = tls
+		}
+		if len(entry) > 0 {
+			configs[registry.RegistryHost()] = entry
+		}
+	}
+
+	out, err := yaml.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("生成registries.yaml失败: %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// yamlSection 返回顶层的mirrors/configs段，不存在时创建
+func yamlSection(data map[string]interface{}, key string) map[string]interface{} {
+	section, ok := data[key].(map[string]interface{})
+	if !ok {
+		section = make(map[string]interface{})
+		data[key] = section
+	}
+	return section
+}
+
+func hasRegistry(registries []config.RegistryConfig, name string) bool {
+	for _, registry := range registries {
+		if registry.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadRegistryCAs 将镜像仓库的CA证书上传到节点，registries.yaml中的ca_file引用这些路径
+func (r *RKE2Installer) uploadRegistryCAs(host config.Host) error {
+	for _, registry := range r.config.RKE2.Registries {
+		if registry.CAFile == "" {
+			continue
+		}
+		remotePath := registryCAPath(registry)
+		mkdirCmd := fmt.Sprintf("mkdir -p %s", path.Dir(remotePath))
+		if output, err := r.buildSSHCommand(host, mkdirCmd).CombinedOutput(); err != nil {
+			return fmt.Errorf("创建证书目录 %s 失败: %w, 输出: %s", path.Dir(remotePath), err, strings.TrimSpace(string(output)))
+		}
+		if err := r.transferFileWithScp(host, registry.CAFile, remotePath, false); err != nil {
+			return fmt.Errorf("上传镜像仓库 %s 的CA证书失败: %w", registry.Name, err)
+		}
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 已上传镜像仓库 %s 的CA证书到 %s", host.IP, registry.Name, remotePath)
+		}
+	}
+	return nil
+}
+
+// checkRegistriesReachable 从第一个server节点检查rke2.registries中的仓库端口，至少一个可达才继续安装
+func (r *RKE2Installer) checkRegistriesReachable() error {
+	registries := r.config.RKE2.Registries
+	if len(registries) == 0 {
+		return nil
+	}
+	servers := r.getServerHosts()
+	if len(servers) == 0 {
+		return nil
+	}
+	host := servers[0]
+
+	var unreachable []string
+	for _, registry := range registries {
+		address, port := config.RegistryAddress(registry.RegistryHost())
+		if !strings.Contains(registry.RegistryHost(), ":") && strings.HasPrefix(registry.RegistryEndpoint(), "http://") {
+			port = "80"
+		}
+		checkCmd := fmt.Sprintf("timeout 5 bash -c '</dev/tcp/%s/%s'", address, port)
+		if err := r.buildSSHCommand(host, checkCmd).Run(); err != nil {
+			unreachable = append(unreachable, fmt.Sprintf("%s(%s:%s)", registry.Name, address, port))
+			if r.logger != nil {
+				r.logger.Warn("主机 %s 无法连接镜像仓库 %s (%s:%s)", host.IP, registry.Name, address, port)
+			}
+			continue
+		}
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 镜像仓库 %s (%s:%s) 可达", host.IP, registry.Name, address, port)
+		}
+	}
+
+	if len(unreachable) == len(registries) {
+		return fmt.Errorf("主机 %s 无法连接任何已配置的镜像仓库: %s，请检查 rke2.registries 和网络",
+			host.IP, strings.Join(unreachable, ", "))
+	}
+	return nil
+}
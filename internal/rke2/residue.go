@@ -0,0 +1,142 @@
+package rke2
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// StatusResidual 节点未完整安装但存在之前安装遗留的文件或服务
+const StatusResidual = "存在残留安装"
+
+// rke2ResiduePaths 判断残留安装的文件，不包含roi在安装前创建的目录和配置文件
+var rke2ResiduePaths = []string{
+	"/usr/local/bin/rke2",
+	"/usr/bin/rke2",
+	"/opt/rke2/bin/rke2",
+	"/etc/systemd/system/rke2-server.service",
+	"/etc/systemd/system/rke2-agent.service",
+	"/usr/local/lib/systemd/system/rke2-server.service",
+	"/usr/local/lib/systemd/system/rke2-agent.service",
+	"/usr/lib/systemd/system/rke2-server.service",
+	"/usr/lib/systemd/system/rke2-agent.service",
+	"/var/lib/kubelet",
+}
+
+// rke2DataResidue RKE2数据目录下判断残留安装的子目录
+var rke2DataResidue = []string{"server/db", "agent/containerd", "data"}
+
+// SetCleanResidue 设置是否在安装前通过rke2-uninstall.sh清理残留安装
+func (r *RKE2Installer) SetCleanResidue(clean bool) {
+	r.cleanResidue = clean
+}
+
+// detectRKE2Residue 列出节点上遗留的RKE2文件和失败的服务
+func (r *RKE2Installer) detectRKE2Residue(host config.Host) ([]string, error) {
+	output, err := r.buildSSHCommand(host, rke2ResidueScript(r.config.RKE2DataDir())).Output()
+	if err != nil {
+		return nil, fmt.Errorf("检查RKE2残留文件失败: %w", err)
+	}
+	return parseResidue(string(output)), nil
+}
+
+// rke2ResidueScript 输出遗留的RKE2文件和失败的服务，每行一项
+func rke2ResidueScript(dataDir string) string {
+	paths := append([]string{}, rke2ResiduePaths...)
+	for _, sub := range rke2DataResidue {
+		paths = append(paths, path.Join(dataDir, sub))
+	}
+	return fmt.Sprintf(`
+		for p in %s; do
+			[ -e "$p" ] && echo "文件: $p"
+		done
+		for s in rke2-server rke2-agent; do
+			if systemctl is-failed --quiet $s 2>/dev/null; then
+				echo "服务: $s (failed)"
+			fi
+		done
+		true
+	`, strings.Join(paths, " "))
+}
+
+func parseResidue(output string) []string {
+	var residue []string
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			residue = append(residue, line)
+		}
+	}
+	return residue
+}
+
+// handleResidualInstalls 处理存在残留安装的节点
+// 未指定 --clean-residue 时报告每个节点的残留项并停止安装，避免在残留文件上重装失败
+func (r *RKE2Installer) handleResidualInstalls(status map[string]*RKE2Status) error {
+	var residualHosts []config.Host
+	for _, host := range r.config.Hosts {
+		if s := status[host.IP]; s != nil && s.Status == StatusResidual {
+			residualHosts = append(residualHosts, host)
+		}
+	}
+	if len(residualHosts) == 0 {
+		return nil
+	}
+
+	if !r.cleanResidue {
+		var details []string
+		for _, host := range residualHosts {
+			details = append(details, fmt.Sprintf("%s:\n      %s", host.IP, strings.Join(status[host.IP].Residue, "\n      ")))
+		}
+		return fmt.Errorf("以下节点存在未完整安装的RKE2残留，直接重装可能失败:\n  - %s\n请确认后使用 --clean-residue 执行rke2-uninstall.sh清理残留并重新安装",
+			strings.Join(details, "\n  - "))
+	}
+
+	for _, host := range residualHosts {
+		if err := r.uninstallRKE2(host); err != nil {
+			return fmt.Errorf("节点 %s 清理RKE2残留失败: %w", host.IP, err)
+		}
+		status[host.IP].Status = "未安装"
+		status[host.IP].Residue = nil
+	}
+	return nil
+}
+
+// uninstallRKE2 执行RKE2卸载脚本清理残留文件
+func (r *RKE2Installer) uninstallRKE2(host config.Host) error {
+	if r.logger != nil {
+		r.logger.Warn("主机 %s: 清理RKE2残留安装 (rke2-uninstall.sh)", host.IP)
+	}
+
+	// rke2-uninstall.sh 通过 RKE2_DATA_DIR 清理自定义数据目录
+	uninstallCmd := fmt.Sprintf(`
+		export RKE2_DATA_DIR=%[1]s
+		for script in /usr/local/bin/rke2-uninstall.sh /usr/bin/rke2-uninstall.sh /opt/rke2/bin/rke2-uninstall.sh; do
+			if [ -x "$script" ]; then
+				echo "执行卸载脚本: $script"
+				"$script"
+				exit $?
+			fi
+		done
+		echo "未找到rke2-uninstall.sh，手动清理残留文件"
+		systemctl stop rke2-server rke2-agent >/dev/null 2>&1
+		systemctl disable rke2-server rke2-agent >/dev/null 2>&1
+		systemctl reset-failed rke2-server rke2-agent >/dev/null 2>&1
+		rm -f /usr/local/bin/rke2 /usr/bin/rke2 /opt/rke2/bin/rke2
+		rm -f /etc/systemd/system/rke2-*.service /usr/local/lib/systemd/system/rke2-*.service /usr/lib/systemd/system/rke2-*.service
+		rm -rf %[1]s/server %[1]s/agent/containerd %[1]s/data /var/lib/kubelet
+		systemctl daemon-reload
+	`, r.config.RKE2DataDir())
+
+	output, err := r.buildSSHCommand(host, uninstallCmd).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("卸载RKE2失败: %w, 输出: %s", err, string(output))
+	}
+
+	if r.logger != nil {
+		r.logger.Debug("主机 %s: RKE2卸载输出:\n%s", host.IP, string(output))
+		r.logger.Info("主机 %s: RKE2残留已清理", host.IP)
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+package rke2
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// clusterResetTimeout cluster-reset在前台运行，恢复完成后自动退出，超过该时间视为失败
+const clusterResetTimeout = 20 * time.Minute
+
+// ResolveSnapshotPath 将快照名称解析为bootstrap节点上的快照路径，绝对路径原样返回
+func (r *RKE2Installer) ResolveSnapshotPath(snapshot string) string {
+	if path.IsAbs(snapshot) {
+		return snapshot
+	}
+	return path.Join(r.etcdSnapshotDir(), snapshot)
+}
+
+// RestorePlan 返回恢复流程中各角色的节点：执行cluster-reset的bootstrap节点和需要重新加入的其余server节点
+func (r *RKE2Installer) RestorePlan() (*config.Host, []config.Host) {
+	bootstrap := r.getFirstEtcdHost()
+	if bootstrap == nil {
+		return nil, nil
+	}
+	var others []config.Host
+	for _, host := range r.getServerHosts() {
+		if host.IP != bootstrap.IP {
+			others = append(others, host)
+		}
+	}
+	return bootstrap, others
+}
+
+// RestoreEtcd 按RKE2的cluster-reset流程从快照恢复etcd：
+// 停止所有server节点的rke2-server，在bootstrap节点上以 --cluster-reset-restore-path 恢复并启动，
+// 再将其余server节点的旧etcd数据移走后逐个重新加入，最后确认集群健康
+func (r *RKE2Installer) RestoreEtcd(snapshot string) error {
+	bootstrap, others := r.RestorePlan()
+	if bootstrap == nil {
+		return fmt.Errorf("未找到etcd节点")
+	}
+	snapshotPath := r.ResolveSnapshotPath(snapshot)
+
+	// 任何节点不可达或快照不存在时，在停止服务之前就中止
+	if err := r.checkRestorePreconditions(*bootstrap, others, snapshotPath); err != nil {
+		return err
+	}
+
+	servers := append([]config.Host{*bootstrap}, others...)
+	for i, host := range servers {
+		if err := r.stopRKE2Server(host); err != nil {
+			return r.restartStoppedServers(servers[:i], err)
+		}
+	}
+
+	if err := r.clusterReset(*bootstrap, snapshotPath); err != nil {
+		return err
+	}
+	if err := r.startRestoredServer(*bootstrap, ""); err != nil {
+		return fmt.Errorf("bootstrap节点 %s 恢复后启动失败: %w", bootstrap.IP, err)
+	}
+
+	backupSuffix := time.Now().Format("20060102150405")
+	for _, host := range others {
+		if err := r.startRestoredServer(host, backupSuffix); err != nil {
+			return fmt.Errorf("server节点 %s 重新加入集群失败: %w", host.IP, err)
+		}
+	}
+
+	if r.logger != nil {
+		r.logger.Info("所有server节点已启动，等待集群恢复健康...")
+	}
+	r.kubeClient = nil
+	if err := r.ensureKubernetesClient(); err != nil {
+		return fmt.Errorf("连接Kubernetes集群失败: %w", err)
+	}
+	if err := r.waitForClusterHealthy(r.kubeClient); err != nil {
+		return err
+	}
+
+	if r.logger != nil {
+		r.logger.Info("etcd已从快照 %s 恢复，集群健康检查通过", snapshotPath)
+	}
+	return nil
+}
+
+// checkRestorePreconditions 确认所有server节点可通过SSH访问，且快照文件存在于bootstrap节点上
+func (r *RKE2Installer) checkRestorePreconditions(bootstrap config.Host, others []config.Host, snapshotPath string) error {
+	for _, host := range append([]config.Host{bootstrap}, others...) {
+		if output, err := r.buildSSHCommand(host, "echo ok").CombinedOutput(); err != nil {
+			return fmt.Errorf("server节点 %s SSH连接失败，恢复需要所有server节点可用: %w, 输出: %s", host.IP, err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	checkCmd := fmt.Sprintf("test -s %s", ssh.ShellQuote(snapshotPath))
+	if err := r.buildSSHCommand(bootstrap, checkCmd).Run(); err != nil {
+		return fmt.Errorf("bootstrap节点 %s 上不存在快照 %s，可通过 roi etcd-snapshot ls 查看可用快照", bootstrap.IP, snapshotPath)
+	}
+
+	if r.logger != nil {
+		r.logger.Info("恢复前检查通过: %d 个server节点可访问，快照 %s 存在于 %s", len(others)+1, snapshotPath, bootstrap.IP)
+	}
+	return nil
+}
+
+// stopRKE2Server 停止节点上的rke2-server服务并等待其完全退出
+func (r *RKE2Installer) stopRKE2Server(host config.Host) error {
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 停止rke2-server服务", host.IP)
+	}
+
+	stopCmd := `
+		systemctl stop rke2-server
+		for i in $(seq 1 30); do
+			systemctl is-active --quiet rke2-server || exit 0
+			sleep 2
+		done
+		echo "rke2-server仍在运行"
+		exit 1
+	`
+	if output, err := r.buildSSHCommand(host, stopCmd).CombinedOutput(); err != nil {
+		return fmt.Errorf("停止节点 %s 的rke2-server失败: %w, 输出: %s", host.IP, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// restartStoppedServers 某个节点停止失败时重新启动此前已停止的server节点，使集群回到恢复前的状态
+// 重新启动失败的节点在错误中列出，需要手动启动
+func (r *RKE2Installer) restartStoppedServers(stopped []config.Host, cause error) error {
+	if len(stopped) == 0 {
+		return cause
+	}
+	var restarted, failed []string
+	for _, host := range stopped {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 恢复中止，重新启动rke2-server服务", host.IP)
+		}
+		if output, err := r.buildSSHCommand(host, "systemctl start --no-block rke2-server").CombinedOutput(); err != nil {
+			if r.logger != nil {
+				r.logger.Error("主机 %s: 重新启动rke2-server失败: %v, 输出: %s", host.IP, err, strings.TrimSpace(string(output)))
+			}
+			failed = append(failed, host.IP)
+			continue
+		}
+		restarted = append(restarted, host.IP)
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%w；以下server节点的rke2-server已停止且重新启动失败: %s，请登录这些节点执行 systemctl start rke2-server 恢复",
+			cause, strings.Join(failed, ", "))
+	}
+	return fmt.Errorf("%w；已重新启动此前停止的server节点: %s", cause, strings.Join(restarted, ", "))
+}
+
+// clusterReset 在bootstrap节点上以快照重置etcd集群成员，命令完成恢复后自动退出
+func (r *RKE2Installer) clusterReset(host config.Host, snapshotPath string) error {
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 从快照 %s 执行cluster-reset，可能需要几分钟...", host.IP, snapshotPath)
+	}
+
+	// 完整输出保存在节点的日志文件中，只返回最后20行便于定位错误
+	resetCmd := fmt.Sprintf(`
+		%s timeout %d rke2 server --cluster-reset --cluster-reset-restore-path=%s > /tmp/roi-cluster-reset.log 2>&1
+		rc=$?
+		tail -20 /tmp/roi-cluster-reset.log
+		exit $rc
+	`, rke2PathEnv, int(clusterResetTimeout/time.Second), ssh.ShellQuote(snapshotPath))
+	output, err := r.buildSSHCommand(host, resetCmd).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("节点 %s 执行cluster-reset失败: %w, 输出: %s", host.IP, err, strings.TrimSpace(string(output)))
+	}
+
+	if r.logger != nil {
+		r.logger.Debug("主机 %s: cluster-reset输出: %s", host.IP, strings.TrimSpace(string(output)))
+		r.logger.Info("主机 %s: cluster-reset完成", host.IP)
+	}
+	return nil
+}
+
+// startRestoredServer 启动恢复后的server节点并等待就绪
+// backupSuffix不为空时，先将节点上旧的etcd数据目录重命名备份，使其以新成员身份加入恢复后的集群
+func (r *RKE2Installer) startRestoredServer(host config.Host, backupSuffix string) error {
+	if backupSuffix != "" {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 备份旧的etcd数据目录", host.IP)
+		}
+		moveCmd := fmt.Sprintf(`
+			db=%s/server/db
+			if [ -d "$db" ]; then
+				mv "$db" "$db.roi-restore-%s"
+			fi
+		`, r.config.RKE2DataDir(), backupSuffix)
+		if output, err := r.buildSSHCommand(host, moveCmd).CombinedOutput(); err != nil {
+			return fmt.Errorf("备份etcd数据目录失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+		}
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 启动rke2-server服务", host.IP)
+	}
+	if output, err := r.buildSSHCommand(host, "systemctl start --no-block rke2-server").CombinedOutput(); err != nil {
+		return fmt.Errorf("启动rke2-server失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+	return r.waitForServerReady(host)
+}
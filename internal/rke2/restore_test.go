@@ -0,0 +1,166 @@
+package rke2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// restoreStep 将恢复流程中的远程命令归类为步骤名，无法识别的命令返回空
+func restoreStep(command string) string {
+	switch {
+	case command == "echo ok":
+		return "precheck"
+	case strings.HasPrefix(command, "test -s "):
+		return "snapshot"
+	case strings.Contains(command, "systemctl stop rke2-server"):
+		return "stop"
+	case strings.Contains(command, "--cluster-reset"):
+		return "reset"
+	case strings.Contains(command, `mv "$db"`):
+		return "move-db"
+	case command == "systemctl start --no-block rke2-server":
+		return "start"
+	case strings.Contains(command, "systemctl is-active rke2-server"):
+		return "ready"
+	case command == "cat /etc/rancher/rke2/rke2.yaml":
+		return "kubeconfig"
+	}
+	return ""
+}
+
+// sequenceRunner 按执行顺序记录 "<IP> <步骤>"，fail 中列出的步骤以退出码1结束，其余命令成功
+type sequenceRunner struct {
+	fail map[string]bool
+
+	mu       sync.Mutex
+	steps    []string
+	commands []string
+}
+
+func (r *sequenceRunner) Run(ctx context.Context, host config.Host, command string) (string, string, int, error) {
+	step := restoreStep(command)
+	if step == "" {
+		return "", "", -1, errors.New("unexpected command: " + command)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry := host.IP + " " + step
+	r.steps = append(r.steps, entry)
+	r.commands = append(r.commands, command)
+	if r.fail[entry] {
+		return "", "failed", 1, nil
+	}
+	return "", "", 0, nil
+}
+
+func restoreTestConfig() *config.Config {
+	return &config.Config{Hosts: []config.Host{
+		{IP: "10.0.0.1", Role: []string{"etcd", "master"}},
+		{IP: "10.0.0.2", Role: []string{"etcd", "master"}},
+		{IP: "10.0.0.3", Role: []string{"etcd", "master"}},
+		{IP: "10.0.0.4", Role: []string{"worker"}},
+	}}
+}
+
+func TestRestoreEtcdOrder(t *testing.T) {
+	runner := &sequenceRunner{}
+	installer := NewRKE2Installer(restoreTestConfig())
+	installer.SetRunner(runner)
+
+	// kubeconfig为空，恢复流程在连接集群时结束
+	err := installer.RestoreEtcd("on-demand it's.zip")
+	if err == nil || !strings.Contains(err.Error(), "连接Kubernetes集群失败") {
+		t.Fatalf("RestoreEtcd() error = %v, want it to stop at the cluster connection", err)
+	}
+
+	want := []string{
+		"10.0.0.1 precheck", "10.0.0.2 precheck", "10.0.0.3 precheck",
+		"10.0.0.1 snapshot",
+		"10.0.0.1 stop", "10.0.0.2 stop", "10.0.0.3 stop",
+		"10.0.0.1 reset",
+		"10.0.0.1 start", "10.0.0.1 ready",
+		"10.0.0.2 move-db", "10.0.0.2 start", "10.0.0.2 ready",
+		"10.0.0.3 move-db", "10.0.0.3 start", "10.0.0.3 ready",
+		"10.0.0.1 kubeconfig",
+	}
+	if !reflect.DeepEqual(runner.steps, want) {
+		t.Errorf("steps = %q\nwant %q", runner.steps, want)
+	}
+
+	quoted := `'/var/lib/rancher/rke2/server/db/snapshots/on-demand it'"'"'s.zip'`
+	for i, step := range runner.steps {
+		if (step == "10.0.0.1 snapshot" || step == "10.0.0.1 reset") && !strings.Contains(runner.commands[i], quoted) {
+			t.Errorf("%s does not quote the snapshot path:\n%s", step, runner.commands[i])
+		}
+	}
+}
+
+func TestRestoreEtcdStopFailure(t *testing.T) {
+	tests := []struct {
+		name      string
+		fail      []string
+		wantSteps []string
+		wantErr   []string
+	}{
+		{
+			name: "restarts stopped servers",
+			fail: []string{"10.0.0.3 stop"},
+			wantSteps: []string{
+				"10.0.0.1 stop", "10.0.0.2 stop", "10.0.0.3 stop",
+				"10.0.0.1 start", "10.0.0.2 start",
+			},
+			wantErr: []string{"停止节点 10.0.0.3 的rke2-server失败", "已重新启动此前停止的server节点: 10.0.0.1, 10.0.0.2"},
+		},
+		{
+			name: "lists servers that failed to restart",
+			fail: []string{"10.0.0.3 stop", "10.0.0.2 start"},
+			wantSteps: []string{
+				"10.0.0.1 stop", "10.0.0.2 stop", "10.0.0.3 stop",
+				"10.0.0.1 start", "10.0.0.2 start",
+			},
+			wantErr: []string{"重新启动失败: 10.0.0.2", "systemctl start rke2-server"},
+		},
+		{
+			name:      "nothing stopped yet",
+			fail:      []string{"10.0.0.1 stop"},
+			wantSteps: []string{"10.0.0.1 stop"},
+			wantErr:   []string{"停止节点 10.0.0.1 的rke2-server失败"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &sequenceRunner{fail: make(map[string]bool)}
+			for _, step := range tt.fail {
+				runner.fail[step] = true
+			}
+			installer := NewRKE2Installer(restoreTestConfig())
+			installer.SetRunner(runner)
+
+			err := installer.RestoreEtcd("snapshot.zip")
+			if err == nil {
+				t.Fatal("RestoreEtcd() succeeded, want a stop failure")
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("error = %v, want it to contain %q", err, want)
+				}
+			}
+			// 预检命令之后只应有停止和重新启动，不执行cluster-reset
+			var steps []string
+			for _, step := range runner.steps {
+				if !strings.HasSuffix(step, " precheck") && !strings.HasSuffix(step, " snapshot") {
+					steps = append(steps, step)
+				}
+			}
+			if !reflect.DeepEqual(steps, tt.wantSteps) {
+				t.Errorf("steps = %q, want %q", steps, tt.wantSteps)
+			}
+		})
+	}
+}
@@ -2,14 +2,22 @@ package rke2
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/rainbond/rainbond-offline-installer/internal/advisory"
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+	"github.com/rainbond/rainbond-offline-installer/internal/templates"
 	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
 	"gopkg.in/yaml.v3"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -43,6 +51,13 @@ const (
 	RKE2CustomConfig = "/etc/rancher/rke2/config.yaml.d/00-rbd.yaml"
 )
 
+// 安装过程中等待server和节点就绪的轮询参数
+const (
+	serverReadyTimeout  = 10 * time.Minute
+	serverReadyInterval = 10 * time.Second
+	nodeReadyTimeout    = 5 * time.Minute
+)
+
 // FileArtifact 文件传输配置
 type FileArtifact struct {
 	localPath  string
@@ -51,10 +66,24 @@ type FileArtifact struct {
 }
 
 type RKE2Installer struct {
-	config       *config.Config
-	logger       Logger
-	stepProgress StepProgress
-	kubeClient   kubernetes.Interface // Kubernetes客户端
+	config          *config.Config
+	logger          Logger
+	stepProgress    StepProgress
+	kubeClient      kubernetes.Interface      // Kubernetes客户端
+	cleanResidue    bool                      // 是否清理残留安装后重装
+	checkPorts      bool                      // 是否在节点加入前检查到第一个server的端口连通性
+	flannelIfaces   map[string]string         // 各主机容器网络使用的网卡，按主机IP索引，未配置flannel_iface时为空
+	detectNodeIP    bool                      // 是否在安装前探测各节点的默认路由地址并检查ip/internal_ip
+	detectedNodeIPs map[string]string         // 未配置internal_ip的主机探测到的默认路由地址，按主机IP索引
+	runner          ssh.Runner                // 非nil时远程命令通过 Runner 执行
+	warnings        []advisory.Warning        // 安装过程中发现的不影响继续安装的问题，只能通过 addWarning 追加
+	warningsMu      sync.Mutex                // 保护 warnings
+	joinServer      string                    // 加入已有集群时的server地址，非空时替代配置中的第一个server
+	joinToken       string                    // 加入已有集群时使用的token
+	cordonClient    kubernetes.Interface      // 重新执行安装时已运行集群的客户端，非nil时处理已加入的节点前先cordon
+	transferStats   map[string]*TransferStats // 各主机的离线资源传输统计，按主机IP索引
+	transferMu      sync.Mutex                // 保护 transferStats
+	onlyNewImages   bool                      // 加载镜像时是否只导入节点上缺失或已变化的镜像
 }
 
 type RKE2Status struct {
@@ -65,6 +94,7 @@ type RKE2Status struct {
 	IsServer bool
 	IsAgent  bool
 	Error    string
+	Residue  []string // 未完整安装时遗留的文件和服务
 }
 
 func NewRKE2Installer(cfg *config.Config) *RKE2Installer {
@@ -109,6 +139,12 @@ func (r *RKE2Installer) Run() error {
 			len(etcdHosts), len(masterHosts), len(workerHosts))
 	}
 
+	for _, warning := range r.config.EtcdTopologyWarnings() {
+		if r.logger != nil {
+			r.logger.Warn("%s", warning)
+		}
+	}
+
 	// 调试信息：显示节点分类详情
 	if r.logger != nil {
 		r.logger.Debug("第一个etcd节点: %s (角色: %v)", firstEtcdHost.IP, firstEtcdHost.Role)
@@ -173,10 +209,10 @@ func (r *RKE2Installer) Run() error {
 			}
 		}
 
-		// 为worker节点添加标签
-		if err := r.addWorkerLabels(*firstEtcdHost); err != nil {
+		// 按配置校正节点标签和污点
+		if err := r.applyNodeMetadata(); err != nil {
 			if r.logger != nil {
-				r.logger.Warn("为worker节点添加标签失败: %v", err)
+				r.logger.Warn("校正节点标签和污点失败: %v", err)
 			}
 		}
 
@@ -202,6 +238,16 @@ func (r *RKE2Installer) Run() error {
 		r.logger.Info("检测到部分节点需要安装或启动: 运行中 %d/%d, 已安装 %d/%d", runningCount, len(hosts), installedCount, len(hosts))
 	}
 
+	// 自定义数据目录需在传输离线资源之前就绪，镜像包将写入其中
+	if err := r.checkDataDirs(); err != nil {
+		return err
+	}
+
+	// 清理残留安装需在传输离线资源之前完成，卸载脚本会删除RKE2数据目录
+	if err := r.handleResidualInstalls(status); err != nil {
+		return err
+	}
+
 	// 阶段2: 传输离线资源到所有节点
 	if r.logger != nil {
 		r.logger.Debug("=== 阶段2: 传输离线资源到所有节点 ===")
@@ -218,10 +264,24 @@ func (r *RKE2Installer) Run() error {
 		return fmt.Errorf("安装包完整性验证失败: %w", err)
 	}
 
+	// 确认至少一个私有镜像仓库可达，避免安装后节点无法拉取镜像
+	if err := r.checkRegistriesReachable(); err != nil {
+		return err
+	}
+
+	// 探测各节点的默认路由地址，检查配置的ip/internal_ip是否为节点实际地址
+	r.checkNodeAddresses()
+
+	// 确认各主机的容器网络网卡存在，server节点据此生成canal网卡配置
+	if err := r.resolveFlannelIfaces(); err != nil {
+		return err
+	}
+
 	// 阶段4: 顺序安装RKE2服务
 	if r.logger != nil {
 		r.logger.Debug("=== 阶段4: 安装RKE2服务 ===")
 	}
+	r.prepareCordon(status, *firstEtcdHost)
 
 	// 步骤1: 安装第一个etcd节点（必须包含etcd）
 	if r.stepProgress != nil {
@@ -230,7 +290,7 @@ func (r *RKE2Installer) Run() error {
 	if r.logger != nil {
 		r.logger.Info("开始安装第一个节点: %s (角色: %s)", firstEtcdHost.IP, firstEtcdHost.Role)
 	}
-	if err := r.installRKE2OnServer(*firstEtcdHost, true); err != nil {
+	if err := r.withNodeCordoned(*firstEtcdHost, func() error { return r.installRKE2OnServer(*firstEtcdHost, true) }); err != nil {
 		return fmt.Errorf("第一个节点 %s RKE2安装失败: %w", firstEtcdHost.IP, err)
 	}
 	if r.stepProgress != nil {
@@ -244,10 +304,20 @@ func (r *RKE2Installer) Run() error {
 	if err := r.waitForServerReady(*firstEtcdHost); err != nil {
 		return fmt.Errorf("等待第一个etcd节点 %s 就绪失败: %w", firstEtcdHost.IP, err)
 	}
+	if err := r.waitForEtcdHealthy(*firstEtcdHost, 1); err != nil {
+		return fmt.Errorf("第一个etcd节点 %s etcd未就绪: %w", firstEtcdHost.IP, err)
+	}
 	if r.logger != nil {
 		r.logger.Info("第一个节点已就绪，开始安装其他节点...")
 	}
 
+	// 运行etcd的节点，纯master节点（非第一个server）禁用etcd
+	etcdMembers := make(map[string]bool)
+	for _, host := range r.config.GetEtcdMemberHosts() {
+		etcdMembers[host.IP] = true
+	}
+	startedMembers := 1
+
 	// 步骤2: 安装其他etcd节点
 	if r.logger != nil {
 		r.logger.Debug("检查其他etcd节点，第一个节点是: %s", firstEtcdHost.IP)
@@ -268,9 +338,26 @@ func (r *RKE2Installer) Run() error {
 		if r.logger != nil {
 			r.logger.Info("安装etcd节点: %s (角色: %v)", etcdHost.IP, etcdHost.Role)
 		}
-		if err := r.installRKE2OnServer(etcdHost, false); err != nil {
+		r.waitBeforeNextServer()
+		if err := r.verifyJoinPorts(etcdHost); err != nil {
+			return err
+		}
+		if err := r.withNodeCordoned(etcdHost, func() error { return r.installRKE2OnServer(etcdHost, false) }); err != nil {
 			return fmt.Errorf("etcd节点 %s RKE2安装失败: %w", etcdHost.IP, err)
 		}
+
+		// 确认新server已就绪且etcd恢复健康后再加入下一个server，避免同时加入多个成员导致失去法定人数
+		if err := r.waitForServerReady(etcdHost); err != nil {
+			return fmt.Errorf("等待etcd节点 %s 就绪失败: %w", etcdHost.IP, err)
+		}
+		healthHost := *firstEtcdHost
+		if etcdMembers[etcdHost.IP] {
+			startedMembers++
+			healthHost = etcdHost
+		}
+		if err := r.waitForEtcdHealthy(healthHost, startedMembers); err != nil {
+			return fmt.Errorf("etcd节点 %s 加入后etcd未就绪: %w", etcdHost.IP, err)
+		}
 		if r.stepProgress != nil {
 			r.stepProgress.CompleteNodeStep(etcdHost.IP)
 		}
@@ -302,7 +389,10 @@ func (r *RKE2Installer) Run() error {
 		if r.logger != nil {
 			r.logger.Info("安装master节点: %s (角色: %s)", masterHost.IP, masterHost.Role)
 		}
-		if err := r.installRKE2OnServer(masterHost, false); err != nil {
+		if err := r.verifyJoinPorts(masterHost); err != nil {
+			return err
+		}
+		if err := r.withNodeCordoned(masterHost, func() error { return r.installRKE2OnServer(masterHost, false) }); err != nil {
 			return fmt.Errorf("master节点 %s RKE2安装失败: %w", masterHost.IP, err)
 		}
 		if r.stepProgress != nil {
@@ -329,7 +419,10 @@ func (r *RKE2Installer) Run() error {
 		if r.logger != nil {
 			r.logger.Info("安装worker节点 %d/%d: %s", i+1, len(workerHosts), workerHost.IP)
 		}
-		if err := r.installRKE2OnAgent(workerHost); err != nil {
+		if err := r.verifyJoinPorts(workerHost); err != nil {
+			return err
+		}
+		if err := r.withNodeCordoned(workerHost, func() error { return r.installRKE2OnAgent(workerHost) }); err != nil {
 			return fmt.Errorf("worker节点 %s RKE2安装失败: %w", workerHost.IP, err)
 		}
 		if r.stepProgress != nil {
@@ -436,11 +529,11 @@ func (r *RKE2Installer) Run() error {
 		r.logger.Info("RKE2集群安装完成! 已安装: %d/%d, 运行中: %d/%d", finalInstalledCount, len(hosts), finalRunningCount, len(hosts))
 	}
 
-	// 为worker节点添加标签
+	// 按配置校正节点标签和污点
 	if finalInstalledCount == len(hosts) {
-		if err := r.addWorkerLabels(*firstEtcdHost); err != nil {
+		if err := r.applyNodeMetadata(); err != nil {
 			if r.logger != nil {
-				r.logger.Warn("为worker节点添加标签失败: %v", err)
+				r.logger.Warn("校正节点标签和污点失败: %v", err)
 			}
 		}
 	}
@@ -615,6 +708,11 @@ func (r *RKE2Installer) installRKE2OnServer(host config.Host, isFirstServer bool
 		return fmt.Errorf("创建RKE2配置失败: %w", err)
 	}
 
+	// 确认容器存储逻辑卷已挂载到containerd数据目录
+	if err := r.ensureContainerdStorage(host); err != nil {
+		return fmt.Errorf("检查容器存储失败: %w", err)
+	}
+
 	// 步骤2: 执行RKE2安装脚本
 	if err := r.executeRKE2Install(host, "server"); err != nil {
 		return fmt.Errorf("执行RKE2安装失败: %w", err)
@@ -672,6 +770,11 @@ func (r *RKE2Installer) installRKE2OnAgent(host config.Host) error {
 		return fmt.Errorf("创建RKE2配置失败: %w", err)
 	}
 
+	// 确认容器存储逻辑卷已挂载到containerd数据目录
+	if err := r.ensureContainerdStorage(host); err != nil {
+		return fmt.Errorf("检查容器存储失败: %w", err)
+	}
+
 	// 步骤2: 执行RKE2安装脚本
 	if err := r.executeRKE2Install(host, "agent"); err != nil {
 		return fmt.Errorf("执行RKE2安装失败: %w", err)
@@ -700,13 +803,13 @@ func (r *RKE2Installer) createRKE2Directories(host config.Host) error {
 		mkdir -p %s/config.yaml.d
 		
 		# 创建RKE2数据目录
-		mkdir -p /var/lib/rancher/rke2
+		mkdir -p %s
 		
 		# 创建日志目录
 		mkdir -p /var/log/rke2
 		
 		echo "RKE2目录创建完成"
-	`, RKE2ConfigDir, RKE2ConfigDir)
+	`, RKE2ConfigDir, RKE2ConfigDir, r.config.RKE2DataDir())
 
 	sshCmd := r.buildSSHCommand(host, createDirsCmd)
 	output, err := sshCmd.CombinedOutput()
@@ -717,6 +820,18 @@ func (r *RKE2Installer) createRKE2Directories(host config.Host) error {
 	return nil
 }
 
+// installArtifacts 安装需要传输的RKE2离线资源，镜像包的目标路径位于RKE2数据目录下
+func (r *RKE2Installer) installArtifacts() []FileArtifact {
+	imagesDir := r.config.RKE2ImagesDir()
+	return []FileArtifact{
+		{"rke2-install.sh", "/tmp/rke2-artifacts/rke2-install.sh", true},
+		{"rke2.linux*.tar.gz", "/tmp/rke2-artifacts/rke2.linux*.tar.gz", true},
+		{"sha256sum*.txt", "/tmp/rke2-artifacts/sha256sum*.txt", true},
+		{"rke2-images-linux.tar", imagesDir + "/rke2-images-linux.tar", true},
+		{"rainbond-offline-images.tar", imagesDir + "/rainbond-offline-images.tar", true},
+	}
+}
+
 // transferRKE2Artifacts 传输RKE2离线资源文件
 func (r *RKE2Installer) transferRKE2Artifacts(host config.Host) error {
 	if r.logger != nil {
@@ -724,20 +839,14 @@ func (r *RKE2Installer) transferRKE2Artifacts(host config.Host) error {
 	}
 
 	// 定义需要传输的文件
-	artifacts := []FileArtifact{
-		{"rke2-install.sh", "/tmp/rke2-artifacts/rke2-install.sh", true},
-		{"rke2.linux*.tar.gz", "/tmp/rke2-artifacts/rke2.linux*.tar.gz", true},
-		{"sha256sum*.txt", "/tmp/rke2-artifacts/sha256sum*.txt", true},
-		{"rke2-images-linux.tar", "/var/lib/rancher/rke2/agent/images/rke2-images-linux.tar", true},
-		{"rainbond-offline-images.tar", "/var/lib/rancher/rke2/agent/images/rainbond-offline-images.tar", true},
-	}
+	artifacts := r.installArtifacts()
 
-	// 创建远程目录
-	createDirsCmd := `
+	// 创建远程目录，镜像包放在RKE2数据目录下的自动导入目录
+	createDirsCmd := fmt.Sprintf(`
 		mkdir -p /tmp/rke2-artifacts
-		mkdir -p /var/lib/rancher/rke2/agent/images
+		mkdir -p %s
 		echo "RKE2离线资源目录创建完成"
-	`
+	`, r.config.RKE2ImagesDir())
 
 	sshCmd := r.buildSSHCommand(host, createDirsCmd)
 	if err := sshCmd.Run(); err != nil {
@@ -961,52 +1070,183 @@ func (r *RKE2Installer) transferFileWithProgress(host config.Host, localPath, re
 		if r.logger != nil {
 			r.logger.Info("主机 %s: 远程文件已存在且完整，跳过传输", host.IP)
 		}
+		r.recordSkippedFile(host, localInfo.size)
 		return nil
 	}
 
 	if err == nil && remoteInfo.size > 0 {
 		if r.logger != nil {
-			r.logger.Info("主机 %s: 发现不完整的远程文件 (大小: %s, MD5: %s)，将重新传输",
+			r.logger.Info("主机 %s: 发现不完整的远程文件 (大小: %s, MD5: %s)，将续传或重新传输",
 				host.IP, remoteInfo.sizeHuman, remoteInfo.md5[:8]+"...")
 		}
 	}
 
-	// 传输文件
-	if err := r.transferFileWithScp(host, localPath, remotePath); err != nil {
-		return fmt.Errorf("文件传输失败: %w", err)
+	// become主机在整个重试过程中使用同一个临时目录，已传输的部分可以续传
+	staging := ""
+	if host.Become {
+		if staging, err = r.createStagingDir(host); err != nil {
+			return err
+		}
+		defer r.removeStagingDir(host, staging)
 	}
 
-	// 验证传输后的文件完整性
-	finalInfo, err := r.getRemoteFileInfo(host, remotePath)
+	// 传输中断（如网络抖动）时重试，已传输的部分通过rsync追加续传，不再整体重传
+	var lastErr error
+	for attempt := 1; attempt <= transferMaxAttempts; attempt++ {
+		resume := r.canResumeTransfer(host, localInfo, uploadPath(staging, remotePath))
+
+		started := time.Now()
+		if err := r.transferFile(host, localPath, remotePath, staging, resume); err != nil {
+			lastErr = fmt.Errorf("文件传输失败: %w", err)
+			if r.logger != nil {
+				r.logger.Warn("主机 %s: 第 %d/%d 次传输失败: %v", host.IP, attempt, transferMaxAttempts, err)
+			}
+			continue
+		}
+
+		// 验证传输后的文件完整性
+		finalInfo, err := r.getRemoteFileInfo(host, remotePath)
+		if err != nil {
+			lastErr = fmt.Errorf("验证传输后文件失败: %w", err)
+			continue
+		}
+
+		if finalInfo.size == localInfo.size && finalInfo.md5 == localInfo.md5 {
+			if r.logger != nil {
+				r.logger.Info("主机 %s: 文件传输成功并校验通过: %s", host.IP, localPath)
+			}
+			r.recordTransferredFile(host, localInfo.size, time.Since(started))
+			return nil
+		}
+
+		lastErr = fmt.Errorf("文件传输后校验失败: 预期大小=%d MD5=%s, 实际大小=%d MD5=%s",
+			localInfo.size, localInfo.md5, finalInfo.size, finalInfo.md5)
+		if r.logger != nil {
+			r.logger.Warn("主机 %s: 第 %d/%d 次传输%v，删除远程文件后完整重传", host.IP, attempt, transferMaxAttempts, lastErr)
+		}
+		// 校验失败说明已有内容不可信，删除后下次完整传输
+		r.buildSSHCommand(host, fmt.Sprintf("rm -f %s", remotePath)).Run()
+	}
+	return lastErr
+}
+
+// uploadPath 获取文件实际上传的远程路径，staging 非空时（become主机）先上传到该临时目录
+func uploadPath(staging, remotePath string) string {
+	if staging != "" {
+		return path.Join(staging, path.Base(remotePath))
+	}
+	return remotePath
+}
+
+// createStagingDir 以SSH登录用户身份通过 mktemp -d 创建become主机的上传临时目录，
+// 目录名不可预测且权限为0700，其他本地用户无法预先创建或替换为符号链接
+func (r *RKE2Installer) createStagingDir(host config.Host) (string, error) {
+	login := host
+	login.Become = false
+	output, err := r.buildSSHCommand(login, "mktemp -d /tmp/.roi-upload.XXXXXXXX").Output()
 	if err != nil {
-		return fmt.Errorf("验证传输后文件失败: %w", err)
+		return "", fmt.Errorf("创建上传临时目录失败: %w", err)
+	}
+	staging := strings.TrimSpace(string(output))
+	if !strings.HasPrefix(staging, "/tmp/.roi-upload.") {
+		return "", fmt.Errorf("创建上传临时目录失败: mktemp返回了意外的路径 %q", staging)
 	}
+	return staging, nil
+}
 
-	if finalInfo.size != localInfo.size || finalInfo.md5 != localInfo.md5 {
-		return fmt.Errorf("文件传输后校验失败: 预期大小=%d MD5=%s, 实际大小=%d MD5=%s",
-			localInfo.size, localInfo.md5, finalInfo.size, finalInfo.md5)
+// removeStagingDir 删除上传临时目录，失败时只记录警告
+func (r *RKE2Installer) removeStagingDir(host config.Host, staging string) {
+	if output, err := r.buildSSHCommand(host, fmt.Sprintf("rm -rf %s", staging)).CombinedOutput(); err != nil && r.logger != nil {
+		r.logger.Warn("主机 %s: 删除上传临时目录 %s 失败: %v, 输出: %s", host.IP, staging, err, strings.TrimSpace(string(output)))
 	}
+}
 
+// canResumeTransfer 判断能否在远程已有部分文件的基础上追加续传
+// 仅exec后端使用rsync，且远程文件需小于本地文件，否则只能完整重传
+func (r *RKE2Installer) canResumeTransfer(host config.Host, localInfo *FileInfo, uploadPath string) bool {
+	if ssh.GetBackend() == ssh.BackendNative {
+		return false
+	}
+	if _, err := exec.LookPath("rsync"); err != nil {
+		return false
+	}
+	// 只读取大小，避免对未传完的大文件计算MD5
+	output, err := r.buildSSHCommand(host, fmt.Sprintf("stat -c %%s %s 2>/dev/null", uploadPath)).Output()
+	if err != nil {
+		return false
+	}
+	partialSize, err := strconv.ParseInt(strings.TrimSpace(string(output)), 10, 64)
+	if err != nil || partialSize <= 0 || partialSize >= localInfo.size {
+		return false
+	}
 	if r.logger != nil {
-		r.logger.Info("主机 %s: 文件传输成功并校验通过: %s", host.IP, localPath)
+		r.logger.Info("主机 %s: 远程已传输 %d / %d 字节，从中断处续传", host.IP, partialSize, localInfo.size)
+	}
+	return true
+}
+
+// transferFileWithScp 使用scp或rsync传输文件，优先rsync以支持进度条，resume为true时追加续传
+// become主机为本次传输创建临时目录，完成后删除
+func (r *RKE2Installer) transferFileWithScp(host config.Host, localPath, remotePath string, resume bool) error {
+	if !host.Become {
+		return r.transferFile(host, localPath, remotePath, "", resume)
+	}
+	staging, err := r.createStagingDir(host)
+	if err != nil {
+		return err
+	}
+	defer r.removeStagingDir(host, staging)
+	return r.transferFile(host, localPath, remotePath, staging, resume)
+}
+
+// transferFile 传输文件到远程路径，staging 非空时先传输到该临时目录，确认目录和文件属于登录用户后再通过sudo移动
+func (r *RKE2Installer) transferFile(host config.Host, localPath, remotePath, staging string, resume bool) error {
+	if staging == "" {
+		return r.uploadFile(host, localPath, remotePath, resume)
+	}
+
+	// become主机的登录用户无权直接写入目标目录
+	tmpPath := uploadPath(staging, remotePath)
+	if err := r.uploadFile(host, localPath, tmpPath, resume); err != nil {
+		return err
+	}
+	// sudo设置的 SUDO_UID 为登录用户的uid
+	mvCmd := r.buildSSHCommand(host, fmt.Sprintf(`
+		if [ -z "$SUDO_UID" ] || [ -L %[1]s ] || [ ! -d %[1]s ] || [ "$(stat -c %%u %[1]s)" != "$SUDO_UID" ] ||
+			[ -L %[2]s ] || [ ! -f %[2]s ] || [ "$(stat -c %%u %[2]s)" != "$SUDO_UID" ]; then
+			echo "上传临时文件 %[2]s 不属于登录用户" >&2
+			exit 1
+		fi
+		mv -f %[2]s %[3]s
+	`, staging, tmpPath, remotePath))
+	if output, err := mvCmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("移动文件到 %s 失败: %w, 输出: %s", remotePath, err, string(output))
 	}
 	return nil
 }
 
-// transferFileWithScp 使用scp或rsync传输文件，优先rsync以支持进度条
-func (r *RKE2Installer) transferFileWithScp(host config.Host, localPath, remotePath string) error {
+// uploadFile 以SSH登录用户身份上传文件
+func (r *RKE2Installer) uploadFile(host config.Host, localPath, remotePath string, resume bool) error {
 	if r.logger != nil {
 		r.logger.Info("主机 %s: 开始传输 %s", host.IP, localPath)
 	}
 
-	// 首先尝试使用rsync (支持进度条)
-	if err := r.transferFileWithRsync(host, localPath, remotePath); err == nil {
-		return nil
-	}
+	// native后端不依赖外部二进制，直接通过SSH会话传输
+	if ssh.GetBackend() != ssh.BackendNative {
+		// 首先尝试使用rsync (支持进度条)
+		err := r.transferFileWithRsync(host, localPath, remotePath, resume)
+		if err == nil {
+			return nil
+		}
+		// 传输中断等错误交给重试，下次尝试通过rsync续传，不在本次改用scp整体重传
+		if !errors.Is(err, errRsyncUnavailable) {
+			return err
+		}
 
-	// rsync失败时回退到scp
-	if r.logger != nil {
-		r.logger.Info("主机 %s: rsync不可用，使用scp传输", host.IP)
+		// 本地或远程未安装rsync时回退到scp
+		if r.logger != nil {
+			r.logger.Info("主机 %s: %v，使用scp传输", host.IP, err)
+		}
 	}
 	scpCmd := r.buildScpCommand(host, localPath, remotePath)
 	output, err := scpCmd.CombinedOutput()
@@ -1020,11 +1260,11 @@ func (r *RKE2Installer) transferFileWithScp(host config.Host, localPath, remoteP
 	return nil
 }
 
-// transferFileWithRsync 使用rsync传输文件（支持进度条）
-func (r *RKE2Installer) transferFileWithRsync(host config.Host, localPath, remotePath string) error {
+// transferFileWithRsync 使用rsync传输文件（支持进度条），resume为true时使用 --append-verify 从已传输位置续传
+func (r *RKE2Installer) transferFileWithRsync(host config.Host, localPath, remotePath string, resume bool) error {
 	// 检查rsync是否可用
 	if _, err := exec.LookPath("rsync"); err != nil {
-		return fmt.Errorf("rsync不可用: %w", err)
+		return fmt.Errorf("本地%w", errRsyncUnavailable)
 	}
 
 	if r.logger != nil {
@@ -1032,7 +1272,7 @@ func (r *RKE2Installer) transferFileWithRsync(host config.Host, localPath, remot
 	}
 
 	var rsyncCmd *exec.Cmd
-	target := fmt.Sprintf("%s@%s:%s", host.User, host.IP, remotePath)
+	target := fmt.Sprintf("%s@%s:%s", host.User, config.URLHost(host.IP), remotePath)
 
 	// 构建rsync命令参数
 	baseArgs := []string{
@@ -1043,11 +1283,15 @@ func (r *RKE2Installer) transferFileWithRsync(host config.Host, localPath, remot
 		"--inplace",        // 就地更新文件
 		"--stats",          // 显示传输统计信息
 	}
+	if resume {
+		// 只传输远程文件之后的部分，完成后校验整个文件，校验失败时rsync会自动完整重传
+		baseArgs = append(baseArgs, "--append-verify")
+	}
 
 	// 根据认证方式构建SSH命令
 	if host.Password != "" {
 		if _, err := exec.LookPath("sshpass"); err == nil {
-			sshOpts := "ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+			sshOpts := "ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o " + ssh.ConnectTimeoutOption() + " -o " + ssh.PortOption(host)
 			rsyncCmd = exec.Command("sshpass", "-p", host.Password, "rsync")
 			args := append(baseArgs, "-e", sshOpts, localPath, target)
 			rsyncCmd.Args = append(rsyncCmd.Args, args...)
@@ -1055,11 +1299,11 @@ func (r *RKE2Installer) transferFileWithRsync(host config.Host, localPath, remot
 			return fmt.Errorf("需要sshpass工具来支持密码认证的rsync")
 		}
 	} else if host.SSHKey != "" {
-		sshOpts := fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null", host.SSHKey)
+		sshOpts := fmt.Sprintf("ssh -i %s -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o %s -o %s", host.SSHKey, ssh.ConnectTimeoutOption(), ssh.PortOption(host))
 		args := append(baseArgs, "-e", sshOpts, localPath, target)
 		rsyncCmd = exec.Command("rsync", args...)
 	} else {
-		sshOpts := "ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null"
+		sshOpts := "ssh -o StrictHostKeyChecking=no -o UserKnownHostsFile=/dev/null -o " + ssh.ConnectTimeoutOption() + " -o " + ssh.PortOption(host)
 		args := append(baseArgs, "-e", sshOpts, localPath, target)
 		rsyncCmd = exec.Command("rsync", args...)
 	}
@@ -1069,6 +1313,10 @@ func (r *RKE2Installer) transferFileWithRsync(host config.Host, localPath, remot
 	// rsyncCmd.Stderr = os.Stderr
 
 	if err := rsyncCmd.Run(); err != nil {
+		// 远程未安装rsync时rsync以127退出（remote command not found）
+		if code, ok := ssh.ExitCode(err); ok && code == 127 {
+			return fmt.Errorf("远程主机%w", errRsyncUnavailable)
+		}
 		return fmt.Errorf("rsync传输失败: %w", err)
 	}
 
@@ -1078,6 +1326,12 @@ func (r *RKE2Installer) transferFileWithRsync(host config.Host, localPath, remot
 	return nil
 }
 
+// transferMaxAttempts 单个文件传输的最大尝试次数
+const transferMaxAttempts = 3
+
+// errRsyncUnavailable 本地或远程主机未安装rsync，只有这种情况才回退到scp
+var errRsyncUnavailable = errors.New("未安装rsync")
+
 // FileInfo 文件信息结构体
 type FileInfo struct {
 	size      int64
@@ -1196,34 +1450,96 @@ func (r *RKE2Installer) getRemoteFileInfo(host config.Host, filePath string) (*F
 	}, nil
 }
 
-// getNodeConfigSection 生成节点配置部分
-func (r *RKE2Installer) getNodeConfigSection(host config.Host) string {
-	nodeName := r.getNodeName(host)
-
-	configLines := []string{
-		fmt.Sprintf("# 节点配置"),
-		fmt.Sprintf("node-name: %s", nodeName),
-	}
+// rke2ConfigData RKE2主配置模板参数
+type rke2ConfigData struct {
+	Description           string // 配置文件头部的节点类型描述
+	ServerURL             string // 加入集群时连接的server地址，第一个server节点为空
+	Token                 string
+	NodeName              string
+	NodeIP                string   // node-ip 使用internal_ip
+	NodeExternalIP        string   // 仅当ip和internal_ip不同时配置
+	ClusterCIDR           string   // Pod网段，双栈时为 IPv4,IPv6，仅server节点
+	ServiceCIDR           string   // Service网段，仅server节点
+	Taints                []string // 智能调度策略推荐的污点
+	NodeLabels            []string // 节点标签 key=value，如集群名称
+	DisableControlPlane   bool     // 专用etcd节点
+	DisableEtcd           bool     // 专用control-plane节点
+	EtcdSnapshotCron      string   // etcd定时快照cron表达式，仅etcd节点
+	EtcdSnapshotRetention int      // etcd定时快照保留数量，仅etcd节点
+	DataDir               string   // 自定义RKE2数据目录，为空时使用RKE2默认值
+	Host                  config.Host
+	Roles                 []string
+}
 
-	// node-ip 使用internal_ip（现在是必填字段）
-	configLines = append(configLines, fmt.Sprintf("node-ip: %s", host.InternalIP))
+// getConfigData 生成节点的RKE2主配置模板参数
+func (r *RKE2Installer) getConfigData(host config.Host, nodeType string, isFirstServer bool) rke2ConfigData {
+	roles := r.normalizeRoles(host.Role)
+	isEtcd := r.hasRole(roles, "etcd")
+	isMaster := r.hasRole(roles, "master")
 
-	// node-external-ip 使用ip，仅当ip和internal_ip不同时才配置
-	if host.IP != host.InternalIP {
-		configLines = append(configLines, fmt.Sprintf("node-external-ip: %s", host.IP))
+	data := rke2ConfigData{
+		Token:    RKE2DefaultToken,
+		NodeName: r.getNodeName(host),
+		NodeIP:   r.configuredNodeIP(host),
+		Taints:   r.getRecommendedTaints(host),
+		Host:     host,
+		Roles:    roles,
 	}
-
-	// node-taint 配置污点 - 智能调度策略
-	taints := r.getRecommendedTaints(host)
-	if len(taints) > 0 {
-		configLines = append(configLines, "# 节点污点配置 - 智能调度策略")
-		configLines = append(configLines, "node-taint:")
-		for _, taint := range taints {
-			configLines = append(configLines, fmt.Sprintf("  - \"%s\"", taint))
+	for key, value := range r.config.ClusterLabels() {
+		data.NodeLabels = append(data.NodeLabels, fmt.Sprintf("%s=%s", key, value))
+	}
+	data.NodeLabels = append(data.NodeLabels, host.NodeLabel...)
+	if host.IP != data.NodeIP {
+		data.NodeExternalIP = host.IP
+	}
+	if host.DualStackIP != "" {
+		// 双栈集群的node-ip为 主地址,另一地址族地址，顺序与cluster-cidr一致
+		primary := data.NodeIP
+		if primary == "" {
+			primary = host.IP
+		}
+		data.NodeIP = primary + "," + host.DualStackIP
+	}
+	if nodeType != "server" || !isFirstServer {
+		data.ServerURL = config.URLHost(r.getServerURL())
+	}
+	if r.joinToken != "" {
+		data.Token = r.joinToken
+	}
+	if r.config.RKE2.DataDir != "" {
+		data.DataDir = r.config.RKE2DataDir()
+	}
+	if nodeType == "server" {
+		data.ClusterCIDR = r.config.RKE2.ClusterCIDR
+		data.ServiceCIDR = r.config.RKE2.ServiceCIDR
+	}
+
+	switch {
+	case nodeType != "server":
+		data.Description = "worker节点"
+	case isEtcd && !isMaster:
+		data.Description = "etcd节点"
+		data.DisableControlPlane = true
+	case isMaster && !isEtcd:
+		data.Description = "master节点"
+		data.DisableEtcd = !isFirstServer
+	default:
+		data.Description = "混合节点 (master+etcd)"
+	}
+	if nodeType == "server" && !data.DisableEtcd {
+		data.EtcdSnapshotCron = strings.TrimSpace(r.config.RKE2.EtcdSnapshot.ScheduleCron)
+		data.EtcdSnapshotRetention = r.config.RKE2.EtcdSnapshot.Retention
+	}
+	if nodeType == "server" && isFirstServer {
+		// 第一个server节点必须包含etcd
+		if data.DisableControlPlane {
+			data.Description = "第一个etcd节点"
+		} else {
+			data.Description = "第一个master节点"
 		}
 	}
 
-	return strings.Join(configLines, "\n")
+	return data
 }
 
 // getRecommendedTaints 根据集群组成和节点角色推荐合适的污点配置
@@ -1304,69 +1620,9 @@ func (r *RKE2Installer) createRKE2Config(host config.Host, nodeType string, isFi
 		r.logger.Info("主机 %s: 创建RKE2配置文件 (类型: %s, 角色: %v)", host.IP, nodeType, host.Role)
 	}
 
-	var config string
-	serverURL := r.getServerURL()
-	roles := r.normalizeRoles(host.Role)
-	nodeConfig := r.getNodeConfigSection(host)
-
-	if nodeType == "server" {
-		if isFirstServer {
-			// 第一个server节点配置（必须包含etcd）
-			if r.hasRole(roles, "etcd") && !r.hasRole(roles, "master") {
-				// 专用etcd节点
-				config = fmt.Sprintf(`# RKE2 第一个etcd节点配置
-token: %s
-%s
-# 专用etcd节点配置
-disable-apiserver: true
-disable-controller-manager: true
-disable-scheduler: true
-`, RKE2DefaultToken, nodeConfig)
-			} else {
-				// master节点或master+etcd混合节点（包含所有control-plane组件和etcd）
-				config = fmt.Sprintf(`# RKE2 第一个master节点配置
-token: %s
-%s
-`, RKE2DefaultToken, nodeConfig)
-			}
-		} else {
-			// 其他server节点配置
-			if r.hasRole(roles, "etcd") && !r.hasRole(roles, "master") {
-				// 专用etcd节点
-				config = fmt.Sprintf(`# RKE2 etcd节点配置
-server: https://%s:9345
-token: %s
-%s
-# 专用etcd节点配置
-disable-apiserver: true
-disable-controller-manager: true
-disable-scheduler: true
-`, serverURL, RKE2DefaultToken, nodeConfig)
-			} else if r.hasRole(roles, "master") && !r.hasRole(roles, "etcd") {
-				// 专用control-plane节点
-				config = fmt.Sprintf(`# RKE2 master节点配置
-server: https://%s:9345
-token: %s
-%s
-# 专用control-plane节点配置
-disable-etcd: true
-`, serverURL, RKE2DefaultToken, nodeConfig)
-			} else if r.hasRole(roles, "master") && r.hasRole(roles, "etcd") {
-				// 混合节点（master+etcd）
-				config = fmt.Sprintf(`# RKE2 混合节点配置 (master+etcd)
-server: https://%s:9345
-token: %s
-%s
-`, serverURL, RKE2DefaultToken, nodeConfig)
-			}
-		}
-	} else {
-		// worker节点配置
-		config = fmt.Sprintf(`# RKE2 worker节点配置
-server: https://%s:9345
-token: %s
-%s
-`, serverURL, RKE2DefaultToken, nodeConfig)
+	config, err := templates.Render(templates.RKE2Config, r.config.RKE2.ConfigTemplate, r.getConfigData(host, nodeType, isFirstServer))
+	if err != nil {
+		return fmt.Errorf("生成RKE2主配置失败: %w", err)
 	}
 
 	// 创建主配置文件
@@ -1383,11 +1639,12 @@ EOF
 	}
 
 	// 创建Rainbond定制配置
-	rainbondConfig := `# Rainbond定制配置
-disable:
-- rke2-ingress-nginx
-system-default-registry: registry.cn-hangzhou.aliyuncs.com
-`
+	rainbondConfig, err := templates.Render(templates.RKE2CustomConfig, "", map[string]string{
+		"SystemDefaultRegistry": "registry.cn-hangzhou.aliyuncs.com",
+	})
+	if err != nil {
+		return fmt.Errorf("生成RKE2定制配置失败: %w", err)
+	}
 
 	createCustomConfigCmd := fmt.Sprintf(`
 		cat > %s << 'EOF'
@@ -1401,11 +1658,22 @@ EOF
 		return fmt.Errorf("创建RKE2定制配置文件失败: %w", err)
 	}
 
+	// 上传用户自定义配置片段
+	if err := r.createDropInConfigs(host); err != nil {
+		return err
+	}
+
 	// 创建镜像仓库配置
 	if err := r.createRegistryConfig(host); err != nil {
 		return fmt.Errorf("创建镜像仓库配置失败: %w", err)
 	}
 
+	if nodeType == "server" {
+		if err := r.createCanalConfig(host); err != nil {
+			return fmt.Errorf("创建canal网卡配置失败: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -1415,20 +1683,10 @@ func (r *RKE2Installer) createRegistryConfig(host config.Host) error {
 		r.logger.Info("主机 %s: 创建镜像仓库配置文件", host.IP)
 	}
 
-	// 使用配置文件中的registry_config，如果没有则使用默认值
-	registryConfig := r.config.RKE2.RegistryConfig
-	if registryConfig == "" {
-		registryConfig = `mirrors:
-  "goodrain.me":
-    endpoint:
-      - "https://goodrain.me"
-configs:
-  "goodrain.me":
-    auth:
-      username: admin
-      password: admin1234
-    tls:
-      insecure_skip_verify: true`
+	// 合并registry_config和rke2.registries，均未配置时使用Rainbond内置仓库配置
+	registryConfig, err := r.registriesYAML()
+	if err != nil {
+		return err
 	}
 
 	// 清理registry配置内容，移除可能导致YAML解析错误的字符
@@ -1442,6 +1700,10 @@ configs:
 		return fmt.Errorf("Registry配置YAML格式错误: %w", err)
 	}
 
+	if err := r.uploadRegistryCAs(host); err != nil {
+		return err
+	}
+
 	registryConfigPath := "/etc/rancher/rke2/registries.yaml"
 
 	// 验证YAML格式并生成文件
@@ -1472,8 +1734,11 @@ EOF
 	return nil
 }
 
-// getServerURL 获取server URL（第一个主机的IP）
+// getServerURL 获取server URL（第一个主机的IP），加入已有集群时为指定的server地址
 func (r *RKE2Installer) getServerURL() string {
+	if r.joinServer != "" {
+		return r.joinServer
+	}
 	serverHosts := r.getServerHosts()
 	if len(serverHosts) > 0 {
 		return r.getNodeIP(serverHosts[0])
@@ -1481,14 +1746,40 @@ func (r *RKE2Installer) getServerURL() string {
 	return ""
 }
 
-// getNodeName 获取节点名称，如果未指定则根据IP自动生成
+// getNodeName 获取节点名称，如果未指定则使用IP
 func (r *RKE2Installer) getNodeName(host config.Host) string {
-	if host.NodeName != "" {
-		return host.NodeName
+	return r.config.GetNodeName(host)
+}
+
+// ResolveNodeNames 按node_name_strategy解析节点名称，hostname策略下通过SSH获取各主机的hostname -f
+func (r *RKE2Installer) ResolveNodeNames() error {
+	if r.config.RKE2.NodeNameStrategy != config.NodeNameStrategyHostname {
+		return nil
 	}
 
-	// 直接使用主IP作为节点名称
-	return host.IP
+	nodeNames := make(map[string]string)
+	for _, host := range r.config.Hosts {
+		if host.NodeName != "" {
+			continue
+		}
+
+		output, err := r.buildSSHCommand(host, "hostname -f").Output()
+		if err != nil {
+			return fmt.Errorf("获取主机 %s 的hostname失败: %w", host.IP, err)
+		}
+
+		hostname := strings.ToLower(strings.TrimSpace(string(output)))
+		if hostname == "" {
+			return fmt.Errorf("主机 %s 的hostname为空", host.IP)
+		}
+		nodeNames[host.IP] = hostname
+
+		if r.logger != nil {
+			r.logger.Debug("主机 %s: 使用hostname %s 作为节点名称", host.IP, hostname)
+		}
+	}
+
+	return r.config.ApplyNodeNames(nodeNames)
 }
 
 // getNodeIP 获取节点IP（直接使用主IP）
@@ -1498,10 +1789,18 @@ func (r *RKE2Installer) getNodeIP(host config.Host) string {
 
 // getNodeInternalIP 获取节点内网IP（如果有内网IP配置则返回，否则返回主IP）
 func (r *RKE2Installer) getNodeInternalIP(host config.Host) string {
+	if nodeIP := r.configuredNodeIP(host); nodeIP != "" {
+		return nodeIP
+	}
+	return host.IP
+}
+
+// configuredNodeIP 返回写入RKE2配置的node-ip：配置的internal_ip，未配置时使用 --detect-node-ip 探测到的默认路由地址
+func (r *RKE2Installer) configuredNodeIP(host config.Host) string {
 	if host.InternalIP != "" {
 		return host.InternalIP
 	}
-	return host.IP
+	return r.detectedNodeIPs[host.IP]
 }
 
 // executeRKE2Install 执行RKE2安装脚本
@@ -1591,30 +1890,33 @@ func (r *RKE2Installer) waitForServerReady(host config.Host) error {
 		r.logger.Info("主机 %s: 等待RKE2 server就绪", host.IP)
 	}
 
-	for i := 0; i < 60; i++ { // 最多等待10分钟
-		checkCmd := `
-			if systemctl is-active rke2-server >/dev/null 2>&1; then
-				if [ -f /var/lib/rancher/rke2/server/node-token ]; then
-					echo "ready"
-					exit 0
-				fi
+	checkCmd := fmt.Sprintf(`
+		if systemctl is-active rke2-server >/dev/null 2>&1; then
+			if [ -f %s/server/node-token ]; then
+				echo "ready"
+				exit 0
 			fi
-			echo "not ready"
-			exit 1
-		`
-
-		sshCmd := r.buildSSHCommand(host, checkCmd)
-		if err := sshCmd.Run(); err == nil {
-			if r.logger != nil {
-				r.logger.Info("主机 %s: RKE2 server已就绪", host.IP)
+		fi
+		echo "not ready"
+		exit 1
+	`, r.config.RKE2DataDir())
+
+	err := cluster.WaitFor(context.Background(), r.logger, serverReadyInterval, serverReadyTimeout,
+		fmt.Sprintf("主机 %s RKE2 server就绪", host.IP),
+		func(ctx context.Context) (bool, error) {
+			if err := r.buildSSHCommand(host, checkCmd).Run(); err != nil {
+				return false, fmt.Errorf("rke2-server未运行或node-token尚未生成")
 			}
-			return nil
-		}
-
-		time.Sleep(10 * time.Second)
+			return true, nil
+		})
+	if err != nil {
+		return err
 	}
 
-	return fmt.Errorf("等待RKE2 server就绪超时")
+	if r.logger != nil {
+		r.logger.Info("主机 %s: RKE2 server已就绪", host.IP)
+	}
+	return nil
 }
 
 // waitForClusterReady 等待集群就绪
@@ -1623,178 +1925,50 @@ func (r *RKE2Installer) waitForClusterReady(firstServer config.Host) error {
 		r.logger.Info("等待Kubernetes集群就绪...")
 	}
 
-	for i := 0; i < 60; i++ { // 最多等待10分钟
-		// 确保Kubernetes客户端已创建
-		if err := r.ensureKubernetesClient(); err != nil {
-			if r.logger != nil {
-				r.logger.Debug("创建Kubernetes客户端失败: %v", err)
+	err := cluster.WaitFor(context.Background(), r.logger, serverReadyInterval, serverReadyTimeout, "Kubernetes集群就绪",
+		func(ctx context.Context) (bool, error) {
+			// 确保Kubernetes客户端已创建
+			if err := r.ensureKubernetesClient(); err != nil {
+				return false, fmt.Errorf("创建Kubernetes客户端失败: %w", err)
 			}
-			time.Sleep(10 * time.Second)
-			continue
-		}
 
-		// 获取所有节点
-		nodes, err := r.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			if r.logger != nil {
-				r.logger.Debug("获取节点列表失败: %v", err)
+			nodes, err := r.kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return false, fmt.Errorf("获取节点列表失败: %w", err)
 			}
-			time.Sleep(10 * time.Second)
-			continue
-		}
-
-		if len(nodes.Items) == 0 {
-			if r.logger != nil {
-				r.logger.Debug("未发现任何Kubernetes节点")
+			if len(nodes.Items) == 0 {
+				return false, fmt.Errorf("未发现任何Kubernetes节点")
 			}
-			time.Sleep(10 * time.Second)
-			continue
-		}
-
-		// 统计就绪节点数量
-		readyCount := 0
-		totalCount := len(nodes.Items)
 
-		for _, node := range nodes.Items {
-			for _, condition := range node.Status.Conditions {
-				if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
+			// 统计就绪节点数量
+			readyCount := 0
+			for i := range nodes.Items {
+				if isNodeReady(&nodes.Items[i]) {
 					readyCount++
-					break
 				}
 			}
-		}
-
-		if r.logger != nil {
-			r.logger.Info("集群节点状态: %d/%d 就绪", readyCount, totalCount)
-		}
-
-		// 检查是否所有节点都就绪
-		if readyCount == totalCount && totalCount > 0 {
 			if r.logger != nil {
-				r.logger.Info("Kubernetes集群已就绪")
+				r.logger.Info("集群节点状态: %d/%d 就绪", readyCount, len(nodes.Items))
 			}
-			return nil
-		}
-
-		time.Sleep(10 * time.Second)
-	}
-
-	return fmt.Errorf("等待Kubernetes集群就绪超时")
-}
-
-// checkRKE2Status 检查RKE2状态
-func (r *RKE2Installer) checkRKE2Status() map[string]*RKE2Status {
-	results := make(map[string]*RKE2Status)
-
-	for _, host := range r.config.Hosts {
-		roles := r.normalizeRoles(host.Role)
-		// 在RKE2中，如果节点有etcd或master角色，就是server节点
-		// 只有纯worker节点才是agent节点
-		isServer := r.hasRole(roles, "etcd") || r.hasRole(roles, "master")
-		isAgent := !isServer && r.hasRole(roles, "worker")
-
-		status := &RKE2Status{
-			IP:       host.IP,
-			Role:     host.Role,
-			IsServer: isServer,
-			IsAgent:  isAgent,
-			Status:   "未知",
-		}
-
-		// 检查RKE2是否安装（使用与checkRKE2Installed相同的逻辑）
-		installed, err := r.checkRKE2Installed(host)
-		if err != nil {
-			if r.logger != nil {
-				r.logger.Debug("主机 %s: 检查RKE2状态时出错: %v", host.IP, err)
-			}
-			status.Status = "检查失败"
-			results[host.IP] = status
-			continue
-		}
-
-		if !installed {
-			status.Status = "未安装"
-			results[host.IP] = status
-			continue
-		}
-
-		// 检查RKE2服务状态
-		var serviceName string
-		if status.IsServer {
-			serviceName = "rke2-server"
-		} else {
-			serviceName = "rke2-agent"
-		}
-
-		sshCmd := r.buildSSHCommand(host, fmt.Sprintf("systemctl is-active %s", serviceName))
-		if err := sshCmd.Run(); err == nil {
-			status.Running = true
-			// 进一步检查Kubernetes节点是否就绪
-			if r.checkKubernetesNodeReady(host) {
-				status.Status = "运行中"
-			} else {
-				status.Status = "服务运行中但节点未就绪"
+			if readyCount < len(nodes.Items) {
+				return false, fmt.Errorf("%d/%d 个节点就绪", readyCount, len(nodes.Items))
 			}
-		} else {
-			status.Running = false
-			status.Status = "已安装未运行"
-		}
-
-		results[host.IP] = status
-	}
-
-	return results
-}
-
-// checkKubernetesNodeReady 检查Kubernetes节点是否就绪
-func (r *RKE2Installer) checkKubernetesNodeReady(host config.Host) bool {
-	// 确保Kubernetes客户端已创建
-	if err := r.ensureKubernetesClient(); err != nil {
-		if r.logger != nil {
-			r.logger.Debug("创建Kubernetes客户端失败: %v", err)
-		}
-		return false
-	}
-
-	// 获取所有节点
-	nodes, err := r.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+			return true, nil
+		})
 	if err != nil {
-		if r.logger != nil {
-			r.logger.Debug("获取节点列表失败: %v", err)
-		}
-		return false
-	}
-
-	// 查找匹配的节点
-	hostIP := r.getNodeIP(host)
-	for _, node := range nodes.Items {
-		// 通过IP地址匹配节点
-		nodeMatched := false
-		for _, addr := range node.Status.Addresses {
-			if (addr.Type == corev1.NodeInternalIP || addr.Type == corev1.NodeExternalIP) && addr.Address == hostIP {
-				nodeMatched = true
-				break
-			}
-		}
-
-		if nodeMatched {
-			// 检查节点是否就绪
-			for _, condition := range node.Status.Conditions {
-				if condition.Type == corev1.NodeReady {
-					isReady := condition.Status == corev1.ConditionTrue
-					if r.logger != nil {
-						r.logger.Debug("节点 %s (%s) 的Kubernetes就绪状态: %v", hostIP, node.Name, isReady)
-					}
-					return isReady
-				}
-			}
-		}
+		return err
 	}
 
 	if r.logger != nil {
-		r.logger.Debug("未找到IP为 %s 的Kubernetes节点", hostIP)
+		r.logger.Info("Kubernetes集群已就绪")
 	}
-	return false
+	return nil
+}
+
+// checkRKE2Status 并行检查所有主机的RKE2状态，与安装器共用Kubernetes客户端
+func (r *RKE2Installer) checkRKE2Status() map[string]*RKE2Status {
+	results, _ := newStatusChecker(r).Check(context.Background())
+	return results
 }
 
 // printRKE2Status 打印RKE2状态到文件，不干扰控制台进度
@@ -1879,6 +2053,11 @@ func (r *RKE2Installer) printRKE2Status(status map[string]*RKE2Status) {
 				r.logger.Debug(fmt.Sprintf("│  错误信息      : %s", result.Error))
 			}
 		}
+		for _, item := range result.Residue {
+			if r.logger != nil {
+				r.logger.Debug("│  残留项        : %s", item)
+			}
+		}
 		if r.logger != nil {
 			r.logger.Debug("└" + strings.Repeat("─", 50))
 		}
@@ -1917,80 +2096,89 @@ func (r *RKE2Installer) printRKE2Status(status map[string]*RKE2Status) {
 	}
 }
 
-// 构建命令的辅助方法
-func (r *RKE2Installer) buildSSHCommand(host config.Host, command string) *exec.Cmd {
+// SetRunner 替换执行RKE2安装命令的 Runner，用于在测试中检查生成的配置文件和模拟主机返回；
+// 安装包通过scp/rsync上传，不经过 Runner
+func (r *RKE2Installer) SetRunner(runner ssh.Runner) {
+	r.runner = runner
+}
+
+// buildSSHCommand 构建在RKE2节点上执行的命令，设置了 Runner 时交由 Runner 执行
+func (r *RKE2Installer) buildSSHCommand(host config.Host, command string) *ssh.Command {
+	if r.runner != nil {
+		return ssh.NewRunnerCommand(r.runner, host, command)
+	}
+	return r.sshCommand(host, command)
+}
+
+// sshCommand 构建系统ssh命令，与 buildScpCommand 使用相同的认证参数
+func (r *RKE2Installer) sshCommand(host config.Host, command string) *ssh.Command {
 	var sshCmd *exec.Cmd
+	command = ssh.WrapBecome(host, command)
 
 	if host.Password != "" {
-		if _, err := exec.LookPath("sshpass"); err != nil {
-			if r.logger != nil {
-				r.logger.Warn("未找到sshpass工具，请安装sshpass或为主机 %s 使用SSH密钥认证", host.IP)
-			}
-			sshCmd = exec.Command("ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				"-o", "BatchMode=no",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		} else {
-			sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				fmt.Sprintf("%s@%s", host.User, host.IP),
-				command)
-		}
+		// sshpass 已在启动时检查
+		sshCmd = exec.Command("sshpass", "-p", host.Password, "ssh",
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
+			fmt.Sprintf("%s@%s", host.User, host.IP),
+			command)
 	} else if host.SSHKey != "" {
 		sshCmd = exec.Command("ssh",
 			"-i", host.SSHKey,
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	} else {
 		sshCmd = exec.Command("ssh",
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			fmt.Sprintf("%s@%s", host.User, host.IP),
 			command)
 	}
 
-	return sshCmd
+	return ssh.NewCommand(host, command, sshCmd)
 }
 
-func (r *RKE2Installer) buildScpCommand(host config.Host, source, dest string) *exec.Cmd {
+func (r *RKE2Installer) buildScpCommand(host config.Host, source, dest string) *ssh.Command {
 	var scpCmd *exec.Cmd
-	target := fmt.Sprintf("%s@%s:%s", host.User, host.IP, dest)
+	target := fmt.Sprintf("%s@%s:%s", host.User, config.URLHost(host.IP), dest)
 
 	if host.Password != "" {
-		if _, err := exec.LookPath("sshpass"); err == nil {
-			scpCmd = exec.Command("sshpass", "-p", host.Password, "scp",
-				"-C", // 启用压缩
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				source, target)
-		} else {
-			scpCmd = exec.Command("scp",
-				"-C", // 启用压缩
-				"-o", "StrictHostKeyChecking=no",
-				"-o", "UserKnownHostsFile=/dev/null",
-				source, target)
-		}
+		// sshpass 已在启动时检查
+		scpCmd = exec.Command("sshpass", "-p", host.Password, "scp",
+			"-C", // 启用压缩
+			"-o", "StrictHostKeyChecking=no",
+			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
+			source, target)
 	} else if host.SSHKey != "" {
 		scpCmd = exec.Command("scp",
 			"-C", // 启用压缩
 			"-i", host.SSHKey,
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			source, target)
 	} else {
 		scpCmd = exec.Command("scp",
 			"-C", // 启用压缩
 			"-o", "StrictHostKeyChecking=no",
 			"-o", "UserKnownHostsFile=/dev/null",
+			"-o", ssh.ConnectTimeoutOption(),
+			"-o", ssh.PortOption(host),
 			source, target)
 	}
 
-	return scpCmd
+	return ssh.NewCopyCommand(host, source, dest, scpCmd)
 }
 
 // configureKubectl 配置第一个server节点的kubectl
@@ -1999,7 +2187,9 @@ func (r *RKE2Installer) configureKubectl(host config.Host) error {
 		r.logger.Info("主机 %s: 配置kubectl访问", host.IP)
 	}
 
-	kubectlCmd := `
+	kubectlCmd := fmt.Sprintf(`
+		rke2_bin=%s
+
 		# 创建.kube目录
 		mkdir -p /root/.kube
 
@@ -2029,9 +2219,9 @@ func (r *RKE2Installer) configureKubectl(host config.Host) error {
 		echo "等待kubectl二进制文件生成..."
 		kubectl_timeout=180
 		while [ $kubectl_timeout -gt 0 ]; do
-			if [ -f /var/lib/rancher/rke2/bin/kubectl ]; then
+			if [ -f $rke2_bin/kubectl ]; then
 				echo "kubectl文件已生成，开始复制..."
-				cp /var/lib/rancher/rke2/bin/kubectl /usr/local/bin/kubectl
+				cp $rke2_bin/kubectl /usr/local/bin/kubectl
 				chmod +x /usr/local/bin/kubectl
 				
 				# 创建符号链接到 /usr/bin (兼容性)
@@ -2053,7 +2243,7 @@ func (r *RKE2Installer) configureKubectl(host config.Host) error {
 		# 验证kubectl配置
 		export KUBECONFIG=/root/.kube/config
 		echo "kubectl配置完成"
-	`
+	`, r.config.RKE2BinDir())
 
 	sshCmd := r.buildSSHCommand(host, kubectlCmd)
 
@@ -2092,80 +2282,46 @@ func (r *RKE2Installer) waitForNodeReady(host config.Host) error {
 		return nil
 	}
 
-	// 使用Kubernetes API等待节点就绪（最多等待5分钟）
-	timeout := 300 * time.Second
-	interval := 10 * time.Second
-	deadline := time.Now().Add(timeout)
-
 	if r.logger != nil {
 		r.logger.Info("主机 %s: 开始节点状态检查...", host.IP)
 	}
 
-	for time.Now().Before(deadline) {
-		// 获取所有节点
-		nodes, err := kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
-		if err != nil {
-			if r.logger != nil {
-				r.logger.Debug("主机 %s: 暂时无法获取节点信息，继续等待...", host.IP)
-			}
-			time.Sleep(interval)
-			continue
-		}
-
-		readyCount := 0
-		totalCount := len(nodes.Items)
-
-		// 检查节点就绪状态
-		for _, node := range nodes.Items {
-			for _, condition := range node.Status.Conditions {
-				if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
-					readyCount++
-					break
-				}
+	var nodes *corev1.NodeList
+	err = cluster.WaitFor(context.Background(), r.logger, serverReadyInterval, nodeReadyTimeout,
+		fmt.Sprintf("主机 %s 节点就绪", host.IP),
+		func(ctx context.Context) (bool, error) {
+			list, err := kubeClient.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+			if err != nil {
+				return false, fmt.Errorf("暂时无法获取节点信息: %w", err)
 			}
-		}
-
-		if r.logger != nil {
-			r.logger.Debug("主机 %s: 发现 %d/%d 个Ready节点", host.IP, readyCount, totalCount)
-		}
-
-		if readyCount > 0 {
-			if r.logger != nil {
-				r.logger.Info("主机 %s: 发现 %d 个Ready节点，集群基本就绪", host.IP, readyCount)
-				// 显示节点状态
-				for _, node := range nodes.Items {
-					var status string
-					for _, condition := range node.Status.Conditions {
-						if condition.Type == corev1.NodeReady {
-							if condition.Status == corev1.ConditionTrue {
-								status = "Ready"
-							} else {
-								status = "NotReady"
-							}
-							break
-						}
-					}
-					r.logger.Info("  节点 %s: %s", node.Name, status)
+			for i := range list.Items {
+				if isNodeReady(&list.Items[i]) {
+					nodes = list
+					return true, nil
 				}
-				r.logger.Info("主机 %s: 节点就绪检查完成", host.IP)
 			}
-			return nil
-		}
-
-		// 还没有Ready节点，继续等待
+			return false, fmt.Errorf("0/%d 个节点Ready", len(list.Items))
+		})
+	if err != nil {
+		// 超时但仍继续，因为这可能是正常的
 		if r.logger != nil {
-			remaining := time.Until(deadline).Seconds()
-			r.logger.Debug("主机 %s: 等待节点变为Ready状态... (剩余 %.0f 秒)", host.IP, remaining)
+			r.logger.Warn("主机 %s: 节点在%s内未完全就绪（%v），但这可能是正常的，继续安装流程...", host.IP, nodeReadyTimeout, err)
 		}
-
-		time.Sleep(interval)
+		return nil
 	}
 
-	// 超时但仍继续，因为这可能是正常的
 	if r.logger != nil {
-		r.logger.Warn("主机 %s: 节点在5分钟内未完全就绪，但这可能是正常的，继续安装流程...", host.IP)
+		r.logger.Info("主机 %s: 集群已有Ready节点，集群基本就绪", host.IP)
+		// 显示节点状态
+		for i := range nodes.Items {
+			status := "NotReady"
+			if isNodeReady(&nodes.Items[i]) {
+				status = "Ready"
+			}
+			r.logger.Info("  节点 %s: %s", nodes.Items[i].Name, status)
+		}
+		r.logger.Info("主机 %s: 节点就绪检查完成", host.IP)
 	}
-
 	return nil
 }
 
@@ -2175,64 +2331,7 @@ func (r *RKE2Installer) checkRKE2Installed(host config.Host) (bool, error) {
 		r.logger.Debug("主机 %s: 检查RKE2安装状态", host.IP)
 	}
 
-	checkCmd := `
-		# 检查RKE2是否完整安装的严格标准
-		echo "=== RKE2安装状态检查 ==="
-		
-		# 1. 检查systemd服务文件是否存在
-		server_service=false
-		agent_service=false
-		if [ -f /etc/systemd/system/rke2-server.service ] || [ -f /usr/lib/systemd/system/rke2-server.service ]; then
-			echo "rke2-server服务文件: 存在"
-			server_service=true
-		fi
-		
-		if [ -f /etc/systemd/system/rke2-agent.service ] || [ -f /usr/lib/systemd/system/rke2-agent.service ]; then
-			echo "rke2-agent服务文件: 存在"  
-			agent_service=true
-		fi
-		
-		# 2. 检查RKE2二进制文件
-		binary_exists=false
-		if [ -f /usr/local/bin/rke2 ] || [ -f /var/lib/rancher/rke2/bin/rke2 ]; then
-			echo "RKE2二进制文件: 存在"
-			binary_exists=true
-		fi
-		
-		# 3. 检查RKE2目录结构
-		dirs_exist=false
-		if [ -d /var/lib/rancher/rke2 ] && [ -d /etc/rancher/rke2 ]; then
-			echo "RKE2目录结构: 存在"
-			dirs_exist=true
-		fi
-		
-		# 4. 检查是否有正在运行的服务（可选，作为额外指标）
-		services_running=false
-		if systemctl is-active rke2-server >/dev/null 2>&1 || systemctl is-active rke2-agent >/dev/null 2>&1; then
-			echo "RKE2服务: 运行中"
-			services_running=true
-		fi
-		
-		# 安装判断：优先考虑服务运行状态，其次考虑文件完整性
-		if [ "$services_running" = "true" ]; then
-			echo "结果: RKE2已安装且正在运行"
-			exit 0
-		elif [ "$binary_exists" = "true" ] && [ "$dirs_exist" = "true" ] && ([ "$server_service" = "true" ] || [ "$agent_service" = "true" ]); then
-			echo "结果: RKE2已完整安装但服务未运行"
-			exit 0
-		else
-			echo "结果: RKE2未完整安装（可能存在残留文件）"
-			echo "详细检查:"
-			echo "  - 二进制文件: $binary_exists"
-			echo "  - 目录结构: $dirs_exist" 
-			echo "  - Server服务: $server_service"
-			echo "  - Agent服务: $agent_service"
-			echo "  - 服务运行: $services_running"
-			exit 1
-		fi
-	`
-
-	sshCmd := r.buildSSHCommand(host, checkCmd)
+	sshCmd := r.buildSSHCommand(host, rke2InstalledScript(r.config.RKE2DataDir()))
 	output, err := sshCmd.CombinedOutput()
 
 	// 显示检查输出到文件，不输出到控制台
@@ -2244,10 +2343,11 @@ func (r *RKE2Installer) checkRKE2Installed(host config.Host) (bool, error) {
 
 	if err != nil {
 		// 退出码为1表示未安装，这是正常情况
-		if exitError, ok := err.(*exec.ExitError); ok && exitError.ExitCode() == 1 {
+		var remoteErr *ssh.RemoteCommandError
+		if errors.As(err, &remoteErr) && remoteErr.Exited() && remoteErr.ExitCode == 1 {
 			return false, nil
 		}
-		// 其他错误
+		// 其他退出码或命令未能执行（连接失败、超时）
 		return false, fmt.Errorf("检查RKE2状态失败: %w", err)
 	}
 
@@ -2260,6 +2360,8 @@ func (r *RKE2Installer) transferOfflineResourcesToAllNodes() error {
 	if r.logger != nil {
 		r.logger.Info("开始传输离线资源到 %d 个节点", len(r.config.Hosts))
 	}
+	r.resetTransferStats()
+	transferStarted := time.Now()
 
 	// 顺序处理每个节点，确保每个节点完整传输所有文件后再处理下一个
 	for i, host := range r.config.Hosts {
@@ -2274,7 +2376,11 @@ func (r *RKE2Installer) transferOfflineResourcesToAllNodes() error {
 		}
 
 		// 2. 传输文件
-		if err := r.transferRKE2Artifacts(host); err != nil {
+		hostStarted := time.Now()
+		err := r.transferRKE2Artifacts(host)
+		r.updateTransferStats(host, func(s *TransferStats) { s.Elapsed = time.Since(hostStarted) })
+		if err != nil {
+			r.logTransferSummary(time.Since(transferStarted))
 			return fmt.Errorf("节点 %s 传输文件失败: %w", host.IP, err)
 		}
 
@@ -2287,6 +2393,7 @@ func (r *RKE2Installer) transferOfflineResourcesToAllNodes() error {
 	if r.logger != nil {
 		r.logger.Info("所有节点离线资源传输完成")
 	}
+	r.logTransferSummary(time.Since(transferStarted))
 	return nil
 }
 
@@ -2297,13 +2404,7 @@ func (r *RKE2Installer) validatePackageIntegrityOnAllNodes() error {
 	}
 
 	// 定义需要验证的文件
-	filesToValidate := []FileArtifact{
-		{"rke2-install.sh", "/tmp/rke2-artifacts/rke2-install.sh", true},
-		{"rke2.linux*.tar.gz", "/tmp/rke2-artifacts/rke2.linux*.tar.gz", true},
-		{"sha256sum*.txt", "/tmp/rke2-artifacts/sha256sum*.txt", true},
-		{"rke2-images-linux.tar", "/var/lib/rancher/rke2/agent/images/rke2-images-linux.tar", true},
-		{"rainbond-offline-images.tar", "/var/lib/rancher/rke2/agent/images/rainbond-offline-images.tar", true},
-	}
+	filesToValidate := r.installArtifacts()
 
 	// 获取本地文件信息
 	localFileInfos := make(map[string]*FileInfo)
@@ -2347,6 +2448,8 @@ func (r *RKE2Installer) getKubeConfig(controlNode config.Host) (*rest.Config, er
 		return nil, fmt.Errorf("从节点 %s 获取kubeconfig失败: %w", controlNode.IP, err)
 	}
 
+	serverHost := config.URLHost(controlNode.IP)
+
 	// 解析kubeconfig
 	config, err := clientcmd.Load(output)
 	if err != nil {
@@ -2355,7 +2458,7 @@ func (r *RKE2Installer) getKubeConfig(controlNode config.Host) (*rest.Config, er
 
 	// 修正server地址为控制节点的外网IP
 	if config.Clusters["default"] != nil {
-		config.Clusters["default"].Server = fmt.Sprintf("https://%s:6443", controlNode.IP)
+		config.Clusters["default"].Server = fmt.Sprintf("https://%s:6443", serverHost)
 	}
 
 	// 创建客户端配置
@@ -2371,22 +2474,13 @@ func (r *RKE2Installer) getKubeConfig(controlNode config.Host) (*rest.Config, er
 // saveKubeConfigToLocal 保存kubeconfig到本地文件
 func (r *RKE2Installer) saveKubeConfigToLocal(controlNode config.Host) error {
 	// 从控制节点获取kubeconfig内容
-	cmd := r.buildSSHCommand(controlNode, "cat /etc/rancher/rke2/rke2.yaml")
-	output, err := cmd.CombinedOutput()
+	output, err := r.readKubeconfig(controlNode)
 	if err != nil {
-		return fmt.Errorf("从节点 %s 获取kubeconfig失败: %w", controlNode.IP, err)
-	}
-
-	// 修正server地址为控制节点的外网IP
-	content := strings.ReplaceAll(string(output), "https://127.0.0.1:6443", fmt.Sprintf("https://%s:6443", controlNode.IP))
-
-	// 保存到本地文件
-	localKubeConfigPath := "./kubeconfig"
-	if err := os.WriteFile(localKubeConfigPath, []byte(content), 0600); err != nil {
-		return fmt.Errorf("保存kubeconfig到本地失败: %w", err)
+		return err
 	}
 
-	return nil
+	// 修正server地址为控制节点的外网IP，保存到本地文件
+	return SaveKubeconfig(config.KubeconfigForServer(output, controlNode.IP), config.DefaultKubeconfigPath)
 }
 
 // createKubernetesClient 创建Kubernetes客户端
@@ -2420,121 +2514,12 @@ func (r *RKE2Installer) ensureKubernetesClient() error {
 	if err != nil {
 		return err
 	}
-
-	r.kubeClient = client
-	return nil
-}
-
-// addWorkerLabels 为包含worker角色的节点添加 node-role.kubernetes.io/worker=worker 标签
-func (r *RKE2Installer) addWorkerLabels(controlNode config.Host) error {
-	if r.logger != nil {
-		r.logger.Info("开始为worker节点添加角色标签...")
-	}
-
-	// 确保Kubernetes客户端已创建
-	if err := r.ensureKubernetesClient(); err != nil {
-		return fmt.Errorf("创建Kubernetes客户端失败: %w", err)
-	}
-
-	// 获取包含worker角色的所有节点
-	var workerNodes []config.Host
-	for _, host := range r.config.Hosts {
-		roles := r.normalizeRoles(host.Role)
-		if r.hasRole(roles, "worker") {
-			workerNodes = append(workerNodes, host)
-		}
-	}
-
-	if len(workerNodes) == 0 {
-		if r.logger != nil {
-			r.logger.Info("未发现worker节点，跳过添加worker标签")
-		}
-		return nil
-	}
-
-	if r.logger != nil {
-		r.logger.Info("发现 %d 个worker节点需要添加标签", len(workerNodes))
-	}
-
-	// 获取所有Kubernetes节点
-	nodes, err := r.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
-	if err != nil {
-		return fmt.Errorf("获取Kubernetes节点列表失败: %w", err)
-	}
-
-	successCount := 0
-	for i, workerHost := range workerNodes {
-		if r.logger != nil {
-			r.logger.Info("为节点 %s 添加worker标签 (%d/%d)", workerHost.IP, i+1, len(workerNodes))
-		}
-
-		// 通过IP地址匹配Kubernetes节点
-		var targetNode *corev1.Node
-		workerIP := r.getNodeIP(workerHost)
-
-		for _, node := range nodes.Items {
-			for _, addr := range node.Status.Addresses {
-				if (addr.Type == corev1.NodeInternalIP || addr.Type == corev1.NodeExternalIP) && addr.Address == workerIP {
-					targetNode = &node
-					break
-				}
-			}
-			if targetNode != nil {
-				break
-			}
-		}
-
-		if targetNode == nil {
-			if r.logger != nil {
-				r.logger.Warn("无法找到IP为 %s 的Kubernetes节点，跳过添加标签", workerIP)
-			}
-			continue
-		}
-
-		if r.logger != nil {
-			r.logger.Info("找到节点 %s 对应的Kubernetes节点名: %s", workerHost.IP, targetNode.Name)
-		}
-
-		// 检查节点是否就绪
-		isReady := false
-		for _, condition := range targetNode.Status.Conditions {
-			if condition.Type == corev1.NodeReady && condition.Status == corev1.ConditionTrue {
-				isReady = true
-				break
-			}
-		}
-
-		if !isReady {
-			if r.logger != nil {
-				r.logger.Warn("节点 %s (%s) 未就绪，跳过添加标签", workerHost.IP, targetNode.Name)
-			}
-			continue
-		}
-
-		// 添加worker标签
-		if targetNode.Labels == nil {
-			targetNode.Labels = make(map[string]string)
-		}
-		targetNode.Labels["node-role.kubernetes.io/worker"] = "worker"
-
-		// 更新节点
-		_, err := r.kubeClient.CoreV1().Nodes().Update(context.TODO(), targetNode, metav1.UpdateOptions{})
-		if err != nil {
-			if r.logger != nil {
-				r.logger.Warn("为节点 %s (%s) 添加worker标签失败: %v", workerHost.IP, targetNode.Name, err)
-			}
-			continue
-		}
-
-		successCount++
-		if r.logger != nil {
-			r.logger.Info("节点 %s (%s) worker标签添加成功", workerHost.IP, targetNode.Name)
-		}
+	// RKE2刚启动时API服务器可能尚未就绪，带退避重试，连接成功后才缓存客户端
+	if err := cluster.WaitForAPI(client, r.logger); err != nil {
+		return err
 	}
 
-	if r.logger != nil {
-		r.logger.Info("worker节点标签添加完成: 成功 %d/%d", successCount, len(workerNodes))
-	}
+	r.kubeClient = client
 	return nil
 }
 
@@ -0,0 +1,276 @@
+package rke2
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// recordingRunner 记录执行过的远程命令，hostname -f 返回 hostnames 中的主机名，其余命令均成功
+type recordingRunner struct {
+	hostnames map[string]string
+
+	mu       sync.Mutex
+	commands map[string][]string
+}
+
+func (r *recordingRunner) Run(ctx context.Context, host config.Host, command string) (string, string, int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.commands == nil {
+		r.commands = make(map[string][]string)
+	}
+	r.commands[host.IP] = append(r.commands[host.IP], command)
+	if command == "hostname -f" {
+		return r.hostnames[host.IP], "", 0, nil
+	}
+	return "", "", 0, nil
+}
+
+// writtenConfig 从写入主配置文件的命令中取出配置内容
+func (r *recordingRunner) writtenConfig(t *testing.T, ip string) string {
+	t.Helper()
+	marker := "cat > " + RKE2ConfigFile + " << 'EOF'\n"
+	for _, command := range r.commands[ip] {
+		if start := strings.Index(command, marker); start >= 0 {
+			content := command[start+len(marker):]
+			return content[:strings.Index(content, "\nEOF")]
+		}
+	}
+	t.Fatalf("%s: no command writes %s", ip, RKE2ConfigFile)
+	return ""
+}
+
+func TestCreateRKE2Config(t *testing.T) {
+	controlPlaneTaint := `  - "node-role.kubernetes.io/control-plane:NoSchedule"`
+	controlPlaneOnly := &config.Config{
+		Hosts: []config.Host{{IP: "10.0.0.1", Role: []string{"etcd", "master"}}},
+	}
+	mixed := &config.Config{
+		Hosts: []config.Host{
+			{IP: "10.0.0.1", Role: []string{"etcd", "master"}},
+			{IP: "10.0.0.2", Role: []string{"etcd"}, InternalIP: "192.168.0.2"},
+			{IP: "10.0.0.3", Role: []string{"master"}, NodeName: "master-3"},
+			{IP: "10.0.0.4", Role: []string{"worker"}, NodeLabel: []string{"pool=build"}},
+			{IP: "10.0.0.5", Role: []string{"worker", "master"}, NodeTaint: []string{"dedicated=infra:NoExecute"}},
+		},
+		RKE2: config.RKE2Config{
+			ClusterCIDR: "10.42.0.0/16",
+			ServiceCIDR: "10.43.0.0/16",
+			EtcdSnapshot: config.EtcdSnapshotConfig{
+				ScheduleCron: "0 */6 * * *",
+				Retention:    10,
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		host     int
+		nodeType string
+		first    bool
+		want     []string
+		notWant  []string
+	}{
+		{
+			name:     "first server with workers",
+			cfg:      mixed,
+			host:     0,
+			nodeType: "server",
+			first:    true,
+			want: []string{
+				"# RKE2 第一个master节点配置",
+				"node-name: 10.0.0.1",
+				`cluster-cidr: "10.42.0.0/16"`,
+				controlPlaneTaint,
+				`etcd-snapshot-schedule-cron: "0 */6 * * *"`,
+			},
+			notWant: []string{"server: ", "disable-etcd", "disable-apiserver"},
+		},
+		{
+			name:     "first server without workers",
+			cfg:      controlPlaneOnly,
+			host:     0,
+			nodeType: "server",
+			first:    true,
+			want:     []string{`  - "node-role.kubernetes.io/control-plane:PreferNoSchedule"`},
+		},
+		{
+			name:     "dedicated etcd",
+			cfg:      mixed,
+			host:     1,
+			nodeType: "server",
+			want: []string{
+				"# RKE2 etcd节点配置",
+				"server: https://10.0.0.1:9345",
+				"node-ip: 192.168.0.2",
+				"node-external-ip: 10.0.0.2",
+				"disable-apiserver: true",
+				controlPlaneTaint,
+				"etcd-snapshot-retention: 10",
+			},
+			notWant: []string{"disable-etcd"},
+		},
+		{
+			name:     "dedicated master",
+			cfg:      mixed,
+			host:     2,
+			nodeType: "server",
+			want: []string{
+				"# RKE2 master节点配置",
+				"node-name: master-3",
+				"disable-etcd: true",
+				controlPlaneTaint,
+			},
+			notWant: []string{"disable-apiserver", "etcd-snapshot-schedule-cron"},
+		},
+		{
+			name:     "worker",
+			cfg:      mixed,
+			host:     3,
+			nodeType: "agent",
+			want: []string{
+				"# RKE2 worker节点配置",
+				"server: https://10.0.0.1:9345",
+				`  - "pool=build"`,
+			},
+			notWant: []string{"node-taint", "cluster-cidr", "disable-"},
+		},
+		{
+			name:     "configured taints replace recommended ones",
+			cfg:      mixed,
+			host:     4,
+			nodeType: "server",
+			want:     []string{`  - "dedicated=infra:NoExecute"`},
+			notWant:  []string{"control-plane:NoSchedule"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host := tt.cfg.Hosts[tt.host]
+			runner := &recordingRunner{}
+			installer := NewRKE2Installer(tt.cfg)
+			installer.SetRunner(runner)
+
+			if err := installer.createRKE2Config(host, tt.nodeType, tt.first); err != nil {
+				t.Fatalf("createRKE2Config() error = %v", err)
+			}
+			written := runner.writtenConfig(t, host.IP)
+			lines := strings.Split(written, "\n")
+			for _, want := range tt.want {
+				if !containsLine(lines, want) {
+					t.Errorf("config missing line %q:\n%s", want, written)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(written, notWant) {
+					t.Errorf("config contains %q:\n%s", notWant, written)
+				}
+			}
+		})
+	}
+}
+
+func TestResolveNodeNames(t *testing.T) {
+	cfg := &config.Config{
+		Hosts: []config.Host{
+			{IP: "10.0.0.1", Role: []string{"etcd", "master"}},
+			{IP: "10.0.0.2", Role: []string{"worker"}, NodeName: "worker-2"},
+		},
+		RKE2: config.RKE2Config{NodeNameStrategy: config.NodeNameStrategyHostname},
+	}
+	runner := &recordingRunner{hostnames: map[string]string{"10.0.0.1": "Node-1.Example.COM\n"}}
+	installer := NewRKE2Installer(cfg)
+	installer.SetRunner(runner)
+
+	if err := installer.ResolveNodeNames(); err != nil {
+		t.Fatalf("ResolveNodeNames() error = %v", err)
+	}
+	if got := cfg.Hosts[0].NodeName; got != "node-1.example.com" {
+		t.Errorf("NodeName = %q, want %q", got, "node-1.example.com")
+	}
+	// 显式配置了node_name的主机不查询hostname
+	if commands := runner.commands["10.0.0.2"]; len(commands) != 0 {
+		t.Errorf("queried hostname of a host with node_name: %q", commands)
+	}
+
+	if err := installer.createRKE2Config(cfg.Hosts[0], "server", true); err != nil {
+		t.Fatalf("createRKE2Config() error = %v", err)
+	}
+	if written := runner.writtenConfig(t, "10.0.0.1"); !containsLine(strings.Split(written, "\n"), "node-name: node-1.example.com") {
+		t.Errorf("config does not use the resolved hostname:\n%s", written)
+	}
+}
+
+func TestResolveNodeNamesEmptyHostname(t *testing.T) {
+	cfg := &config.Config{
+		Hosts: []config.Host{{IP: "10.0.0.1", Role: []string{"etcd", "master"}}},
+		RKE2:  config.RKE2Config{NodeNameStrategy: config.NodeNameStrategyHostname},
+	}
+	installer := NewRKE2Installer(cfg)
+	installer.SetRunner(&recordingRunner{hostnames: map[string]string{"10.0.0.1": " \n"}})
+
+	if err := installer.ResolveNodeNames(); err == nil || !strings.Contains(err.Error(), "hostname为空") {
+		t.Errorf("ResolveNodeNames() error = %v, want an empty hostname error", err)
+	}
+}
+
+func TestResolveNodeNamesDuplicate(t *testing.T) {
+	tests := []struct {
+		name      string
+		hosts     []config.Host
+		hostnames map[string]string
+		want      string
+	}{
+		{
+			name: "two hosts with the same hostname",
+			hosts: []config.Host{
+				{IP: "10.0.0.1", Role: []string{"etcd", "master"}},
+				{IP: "10.0.0.2", Role: []string{"worker"}},
+			},
+			hostnames: map[string]string{"10.0.0.1": "localhost.localdomain\n", "10.0.0.2": "LOCALHOST.localdomain\n"},
+			want:      "host[1] (10.0.0.2): duplicate node name 'localhost.localdomain', already used by host[0] (10.0.0.1)",
+		},
+		{
+			name: "hostname clashes with a configured node_name",
+			hosts: []config.Host{
+				{IP: "10.0.0.1", Role: []string{"etcd", "master"}, NodeName: "node-2"},
+				{IP: "10.0.0.2", Role: []string{"worker"}},
+			},
+			hostnames: map[string]string{"10.0.0.2": "node-2\n"},
+			want:      "host[1] (10.0.0.2): duplicate node name 'node-2', already used by host[0] (10.0.0.1)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Hosts: tt.hosts,
+				RKE2:  config.RKE2Config{NodeNameStrategy: config.NodeNameStrategyHostname},
+			}
+			installer := NewRKE2Installer(cfg)
+			installer.SetRunner(&recordingRunner{hostnames: tt.hostnames})
+
+			err := installer.ResolveNodeNames()
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("ResolveNodeNames() error = %v, want %q", err, tt.want)
+			}
+			// 名称冲突时不修改任何主机
+			if got := cfg.Hosts[1].NodeName; got != "" {
+				t.Errorf("NodeName of host[1] = %q after a failed resolve, want it unset", got)
+			}
+		})
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
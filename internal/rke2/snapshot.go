@@ -0,0 +1,131 @@
+package rke2
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+const (
+	// rke2PathEnv 兼容tarball安装（/usr/local/bin）和RPM安装（/usr/bin）以及 /opt/rke2/bin 的rke2二进制位置
+	rke2PathEnv = "PATH=$PATH:/usr/local/bin:/usr/bin:/opt/rke2/bin"
+	// DefaultSnapshotName 手动快照的默认名称前缀
+	DefaultSnapshotName = "roi-snapshot"
+)
+
+// etcdSnapshotDir RKE2的etcd快照目录，位于数据目录下
+func (r *RKE2Installer) etcdSnapshotDir() string {
+	return path.Join(r.config.RKE2DataDir(), "server/db/snapshots")
+}
+
+// snapshotNamePattern 快照名称前缀只允许小写字母、数字、-和.，避免拼接到远程命令时产生歧义
+var snapshotNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9.-]*[a-z0-9])?$`)
+
+// EtcdSnapshot 手动快照的结果
+type EtcdSnapshot struct {
+	Host      string // 执行快照的etcd节点
+	Path      string // 快照在节点上的路径
+	LocalPath string // 下载到本地的路径，未下载时为空
+}
+
+// SaveEtcdSnapshot 在第一个etcd节点上执行 rke2 etcd-snapshot save，downloadDir不为空时将快照复制到本地目录
+func (r *RKE2Installer) SaveEtcdSnapshot(name, downloadDir string) (*EtcdSnapshot, error) {
+	if name == "" {
+		name = DefaultSnapshotName
+	}
+	if !snapshotNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("快照名称 '%s' 无效，只能包含小写字母、数字、-和.", name)
+	}
+
+	host := r.getFirstEtcdHost()
+	if host == nil {
+		return nil, fmt.Errorf("未找到etcd节点")
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 创建etcd快照 %s...", host.IP, name)
+	}
+	saveCmd := fmt.Sprintf("%s rke2 etcd-snapshot save --name %s", rke2PathEnv, name)
+	if output, err := r.buildSSHCommand(*host, saveCmd).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("在节点 %s 上创建etcd快照失败: %w, 输出: %s", host.IP, err, strings.TrimSpace(string(output)))
+	}
+
+	// RKE2以 <name>-<节点名>-<时间戳> 命名快照，取该前缀下最新的文件
+	snapshotDir := r.etcdSnapshotDir()
+	findCmd := fmt.Sprintf("ls -1t %s/%s-* 2>/dev/null | head -1", snapshotDir, name)
+	output, err := r.buildSSHCommand(*host, findCmd).Output()
+	if err != nil {
+		return nil, fmt.Errorf("查找节点 %s 上的快照文件失败: %w", host.IP, err)
+	}
+	snapshotPath := strings.TrimSpace(string(output))
+	if snapshotPath == "" {
+		return nil, fmt.Errorf("快照已创建，但在节点 %s 的 %s 中未找到以 %s 开头的快照文件", host.IP, snapshotDir, name)
+	}
+
+	snapshot := &EtcdSnapshot{Host: host.IP, Path: snapshotPath}
+	if r.logger != nil {
+		r.logger.Info("主机 %s: etcd快照已保存到 %s", host.IP, snapshotPath)
+	}
+
+	if downloadDir != "" {
+		localPath, err := r.downloadSnapshot(*host, snapshotPath, downloadDir)
+		if err != nil {
+			return snapshot, err
+		}
+		snapshot.LocalPath = localPath
+	}
+	return snapshot, nil
+}
+
+// downloadSnapshot 通过SSH读取快照文件并保存到本地目录，按sha256校验完整性
+func (r *RKE2Installer) downloadSnapshot(host config.Host, remotePath, downloadDir string) (string, error) {
+	if err := os.MkdirAll(downloadDir, 0700); err != nil {
+		return "", fmt.Errorf("创建本地快照目录 %s 失败: %w", downloadDir, err)
+	}
+	localPath := filepath.Join(downloadDir, path.Base(remotePath))
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 下载快照 %s 到 %s", host.IP, remotePath, localPath)
+	}
+	content, err := r.buildSSHCommand(host, fmt.Sprintf("cat %s", remotePath)).Output()
+	if err != nil {
+		return "", fmt.Errorf("从节点 %s 读取快照 %s 失败: %w", host.IP, remotePath, err)
+	}
+
+	output, err := r.buildSSHCommand(host, fmt.Sprintf("sha256sum %s", remotePath)).Output()
+	if err != nil {
+		return "", fmt.Errorf("计算节点 %s 上快照的sha256失败: %w", host.IP, err)
+	}
+	fields := strings.Fields(string(output))
+	sum := sha256.Sum256(content)
+	if len(fields) == 0 || fields[0] != hex.EncodeToString(sum[:]) {
+		return "", fmt.Errorf("快照 %s 下载后校验失败，sha256与节点上的文件不一致", remotePath)
+	}
+
+	if err := os.WriteFile(localPath, content, 0600); err != nil {
+		return "", fmt.Errorf("保存快照到 %s 失败: %w", localPath, err)
+	}
+	return localPath, nil
+}
+
+// ListEtcdSnapshots 在第一个etcd节点上执行 rke2 etcd-snapshot ls，返回其原始输出
+func (r *RKE2Installer) ListEtcdSnapshots() (string, error) {
+	host := r.getFirstEtcdHost()
+	if host == nil {
+		return "", fmt.Errorf("未找到etcd节点")
+	}
+
+	listCmd := fmt.Sprintf("%s rke2 etcd-snapshot ls 2>/dev/null", rke2PathEnv)
+	output, err := r.buildSSHCommand(*host, listCmd).Output()
+	if err != nil {
+		return "", fmt.Errorf("在节点 %s 上列出etcd快照失败: %w", host.IP, err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
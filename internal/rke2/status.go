@@ -0,0 +1,281 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// rke2InstalledScript 检查RKE2是否完整安装，退出码0表示已安装，1表示未安装
+func rke2InstalledScript(dataDir string) string {
+	return fmt.Sprintf(`
+		data_dir=%s
+
+		# 检查RKE2是否完整安装的严格标准
+		echo "=== RKE2安装状态检查 ==="
+		
+		# 1. 检查systemd服务文件是否存在
+		server_service=false
+		agent_service=false
+		if [ -f /etc/systemd/system/rke2-server.service ] || [ -f /usr/lib/systemd/system/rke2-server.service ]; then
+			echo "rke2-server服务文件: 存在"
+			server_service=true
+		fi
+		
+		if [ -f /etc/systemd/system/rke2-agent.service ] || [ -f /usr/lib/systemd/system/rke2-agent.service ]; then
+			echo "rke2-agent服务文件: 存在"  
+			agent_service=true
+		fi
+		
+		# 2. 检查RKE2二进制文件
+		binary_exists=false
+		if [ -f /usr/local/bin/rke2 ] || [ -f $data_dir/bin/rke2 ]; then
+			echo "RKE2二进制文件: 存在"
+			binary_exists=true
+		fi
+		
+		# 3. 检查RKE2目录结构
+		dirs_exist=false
+		if [ -d $data_dir ] && [ -d /etc/rancher/rke2 ]; then
+			echo "RKE2目录结构: 存在"
+			dirs_exist=true
+		fi
+		
+		# 4. 检查是否有正在运行的服务（可选，作为额外指标）
+		services_running=false
+		if systemctl is-active rke2-server >/dev/null 2>&1 || systemctl is-active rke2-agent >/dev/null 2>&1; then
+			echo "RKE2服务: 运行中"
+			services_running=true
+		fi
+		
+		# 安装判断：优先考虑服务运行状态，其次考虑文件完整性
+		if [ "$services_running" = "true" ]; then
+			echo "结果: RKE2已安装且正在运行"
+			exit 0
+		elif [ "$binary_exists" = "true" ] && [ "$dirs_exist" = "true" ] && ([ "$server_service" = "true" ] || [ "$agent_service" = "true" ]); then
+			echo "结果: RKE2已完整安装但服务未运行"
+			exit 0
+		else
+			echo "结果: RKE2未完整安装（可能存在残留文件）"
+			echo "详细检查:"
+			echo "  - 二进制文件: $binary_exists"
+			echo "  - 目录结构: $dirs_exist" 
+			echo "  - Server服务: $server_service"
+			echo "  - Agent服务: $agent_service"
+			echo "  - 服务运行: $services_running"
+			exit 1
+		fi
+	`, dataDir)
+}
+
+// StatusChecker 并行检查各节点RKE2的安装和运行状态，可取消，独立于安装流程使用（如 roi status 和TUI）
+// 远程命令通过 ssh.Runner 执行，Kubernetes节点就绪状态通过 kubeClient 查询，两者均可替换
+type StatusChecker struct {
+	config      *config.Config
+	logger      Logger
+	runner      ssh.Runner
+	concurrency int
+	kubeClient  kubernetes.Interface
+	installer   *RKE2Installer // 未设置kubeClient时通过第一个控制节点的kubeconfig创建客户端
+}
+
+func NewStatusChecker(cfg *config.Config) *StatusChecker {
+	return NewStatusCheckerWithLogger(cfg, nil)
+}
+
+func NewStatusCheckerWithLogger(cfg *config.Config, logger Logger) *StatusChecker {
+	return newStatusChecker(NewRKE2InstallerWithLogger(cfg, logger))
+}
+
+// newStatusChecker 复用安装器的 Runner、SSH命令构造和Kubernetes客户端
+func newStatusChecker(installer *RKE2Installer) *StatusChecker {
+	runner := installer.runner
+	if runner == nil {
+		runner = ssh.NewRunner(installer.sshCommand)
+	}
+	return &StatusChecker{
+		config:    installer.config,
+		logger:    installer.logger,
+		runner:    runner,
+		installer: installer,
+	}
+}
+
+// SetRunner 设置执行远程命令的 Runner
+func (s *StatusChecker) SetRunner(runner ssh.Runner) {
+	s.runner = runner
+}
+
+// SetConcurrency 设置同时检查的主机数，小于1时使用全局 --concurrency
+func (s *StatusChecker) SetConcurrency(n int) {
+	s.concurrency = n
+}
+
+// SetKubeClient 设置查询节点就绪状态的Kubernetes客户端
+func (s *StatusChecker) SetKubeClient(client kubernetes.Interface) {
+	s.kubeClient = client
+}
+
+// Check 并行检查所有主机的RKE2状态，ctx 取消时终止正在执行的远程命令并返回已收集的结果和 ctx.Err()
+// 单个主机检查失败记录在该主机的 Status/Error 中，不作为返回错误
+func (s *StatusChecker) Check(ctx context.Context) (map[string]*RKE2Status, error) {
+	results := make(map[string]*RKE2Status, len(s.config.Hosts))
+	for _, host := range s.config.Hosts {
+		results[host.IP] = newHostStatus(host)
+	}
+
+	errs := ssh.ForEachHostContext(ctx, s.config.Hosts, s.concurrency, func(ctx context.Context, host config.Host) error {
+		return s.checkHost(ctx, host, results[host.IP])
+	})
+	for i, host := range s.config.Hosts {
+		if errs[i] != nil && results[host.IP].Error == "" {
+			results[host.IP].Status = "检查失败"
+			results[host.IP].Error = errs[i].Error()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return results, err
+	}
+
+	s.checkNodesReady(ctx, results)
+	return results, ctx.Err()
+}
+
+// newHostStatus 在RKE2中，有etcd或master角色的节点是server节点，只有纯worker节点是agent节点
+func newHostStatus(host config.Host) *RKE2Status {
+	isServer, isAgent := false, false
+	for _, role := range host.Role {
+		switch strings.TrimSpace(strings.ToLower(role)) {
+		case "etcd", "master":
+			isServer = true
+		case "worker":
+			isAgent = true
+		}
+	}
+	return &RKE2Status{
+		IP:       host.IP,
+		Role:     host.Role,
+		IsServer: isServer,
+		IsAgent:  isAgent && !isServer,
+		Status:   "未知",
+	}
+}
+
+// checkHost 检查单个主机的安装状态、残留和服务状态，节点就绪状态在所有主机检查完成后统一查询
+func (s *StatusChecker) checkHost(ctx context.Context, host config.Host, status *RKE2Status) error {
+	output, _, code, err := s.runner.Run(ctx, host, rke2InstalledScript(s.config.RKE2DataDir()))
+	if err != nil {
+		return fmt.Errorf("检查RKE2状态失败: %w", err)
+	}
+	if s.logger != nil && output != "" {
+		s.logger.Debug("主机 %s RKE2状态检查:\n%s", host.IP, output)
+	}
+
+	switch code {
+	case 0:
+	case 1:
+		status.Status = "未安装"
+		output, stderr, code, err := s.runner.Run(ctx, host, rke2ResidueScript(s.config.RKE2DataDir()))
+		switch {
+		case err != nil:
+			status.Error = fmt.Sprintf("检查RKE2残留文件失败: %v", err)
+		case code != 0:
+			status.Error = fmt.Sprintf("检查RKE2残留文件失败: 退出码 %d: %s", code, stderr)
+		default:
+			if residue := parseResidue(output); len(residue) > 0 {
+				status.Status = StatusResidual
+				status.Residue = residue
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("检查RKE2状态失败: 退出码 %d", code)
+	}
+
+	serviceName := "rke2-agent"
+	if status.IsServer {
+		serviceName = "rke2-server"
+	}
+	_, _, code, err = s.runner.Run(ctx, host, fmt.Sprintf("systemctl is-active %s", serviceName))
+	if err != nil {
+		return fmt.Errorf("检查 %s 服务状态失败: %w", serviceName, err)
+	}
+	status.Running = code == 0
+	if status.Running {
+		status.Status = "服务运行中但节点未就绪"
+	} else {
+		status.Status = "已安装未运行"
+	}
+	return nil
+}
+
+// checkNodesReady 查询一次节点列表，将服务运行中且对应节点Ready的主机标记为运行中
+func (s *StatusChecker) checkNodesReady(ctx context.Context, results map[string]*RKE2Status) {
+	running := false
+	for _, status := range results {
+		running = running || status.Running
+	}
+	if !running {
+		return
+	}
+
+	client, err := s.client()
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Debug("创建Kubernetes客户端失败: %v", err)
+		}
+		return
+	}
+	nodes, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		if s.logger != nil {
+			s.logger.Debug("获取节点列表失败: %v", err)
+		}
+		return
+	}
+
+	for _, host := range s.config.Hosts {
+		status := results[host.IP]
+		if !status.Running {
+			continue
+		}
+		if nodeReadyByIP(nodes.Items, host.IP) {
+			status.Status = "运行中"
+		}
+	}
+}
+
+func (s *StatusChecker) client() (kubernetes.Interface, error) {
+	if s.kubeClient != nil {
+		return s.kubeClient, nil
+	}
+	if err := s.installer.ensureKubernetesClient(); err != nil {
+		return nil, err
+	}
+	s.kubeClient = s.installer.kubeClient
+	return s.kubeClient, nil
+}
+
+// nodeReadyByIP 按内网或外网地址查找节点并返回其是否Ready
+func nodeReadyByIP(nodes []corev1.Node, ip string) bool {
+	for _, node := range nodes {
+		for _, addr := range node.Status.Addresses {
+			if (addr.Type != corev1.NodeInternalIP && addr.Type != corev1.NodeExternalIP) || addr.Address != ip {
+				continue
+			}
+			for _, condition := range node.Status.Conditions {
+				if condition.Type == corev1.NodeReady {
+					return condition.Status == corev1.ConditionTrue
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
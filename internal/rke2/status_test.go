@@ -0,0 +1,257 @@
+package rke2
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// fakeResult 假 Runner 对一条远程命令的返回
+type fakeResult struct {
+	stdout, stderr string
+	code           int
+	err            error
+}
+
+// fakeHost 单个主机上安装状态检查、残留检查和服务状态检查的返回
+type fakeHost struct {
+	installed fakeResult
+	residue   fakeResult
+	service   fakeResult
+}
+
+// fakeRunner 按主机和命令类型返回固定结果，记录执行过的命令
+type fakeRunner struct {
+	hosts map[string]fakeHost
+
+	mu       sync.Mutex
+	commands map[string][]string
+}
+
+func (f *fakeRunner) Run(ctx context.Context, host config.Host, command string) (string, string, int, error) {
+	f.mu.Lock()
+	if f.commands == nil {
+		f.commands = make(map[string][]string)
+	}
+	f.commands[host.IP] = append(f.commands[host.IP], command)
+	f.mu.Unlock()
+
+	h := f.hosts[host.IP]
+	var result fakeResult
+	switch {
+	case strings.Contains(command, "RKE2安装状态检查"):
+		result = h.installed
+	case strings.Contains(command, "for p in"):
+		result = h.residue
+	case strings.HasPrefix(command, "systemctl is-active"):
+		result = h.service
+	default:
+		return "", "", -1, errors.New("unexpected command: " + command)
+	}
+	return result.stdout, result.stderr, result.code, result.err
+}
+
+func readyNode(name, ip string, ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Addresses:  []corev1.NodeAddress{{Type: corev1.NodeInternalIP, Address: ip}},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: ready}},
+		},
+	}
+}
+
+func TestStatusCheckerCheck(t *testing.T) {
+	cfg := &config.Config{Hosts: []config.Host{
+		{IP: "10.0.0.1", Role: []string{"etcd", "master"}},
+		{IP: "10.0.0.2", Role: []string{"worker"}},
+		{IP: "10.0.0.3", Role: []string{"worker"}},
+		{IP: "10.0.0.4", Role: []string{"worker"}},
+		{IP: "10.0.0.5", Role: []string{"worker"}},
+		{IP: "10.0.0.6", Role: []string{"worker"}},
+		{IP: "10.0.0.7", Role: []string{"worker"}},
+		{IP: "10.0.0.8", Role: []string{"worker"}},
+	}}
+	runner := &fakeRunner{hosts: map[string]fakeHost{
+		// 已安装、服务运行、节点Ready
+		"10.0.0.1": {installed: fakeResult{code: 0}, service: fakeResult{code: 0}},
+		// 已安装、服务运行、节点NotReady
+		"10.0.0.2": {installed: fakeResult{code: 0}, service: fakeResult{code: 0}},
+		// 已安装、服务未运行
+		"10.0.0.3": {installed: fakeResult{code: 0}, service: fakeResult{code: 3}},
+		// 未安装、无残留
+		"10.0.0.4": {installed: fakeResult{code: 1}, residue: fakeResult{stdout: "\n"}},
+		// 未安装、存在残留
+		"10.0.0.5": {installed: fakeResult{code: 1}, residue: fakeResult{
+			stdout: "文件: /usr/local/bin/rke2\n服务: rke2-agent (failed)\n",
+		}},
+		// 未安装、残留检查失败
+		"10.0.0.6": {installed: fakeResult{code: 1}, residue: fakeResult{code: 2, stderr: "permission denied"}},
+		// 检查脚本以其他退出码结束
+		"10.0.0.7": {installed: fakeResult{code: 2}},
+		// 命令无法执行
+		"10.0.0.8": {installed: fakeResult{code: -1, err: errors.New("connection refused")}},
+	}}
+
+	checker := NewStatusChecker(cfg)
+	checker.SetRunner(runner)
+	checker.SetConcurrency(3)
+	checker.SetKubeClient(fake.NewSimpleClientset(
+		readyNode("node-1", "10.0.0.1", corev1.ConditionTrue),
+		readyNode("node-2", "10.0.0.2", corev1.ConditionFalse),
+	))
+
+	results, err := checker.Check(context.Background())
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	tests := []struct {
+		ip       string
+		status   string
+		running  bool
+		residue  []string
+		errorHas string
+	}{
+		{ip: "10.0.0.1", status: "运行中", running: true},
+		{ip: "10.0.0.2", status: "服务运行中但节点未就绪", running: true},
+		{ip: "10.0.0.3", status: "已安装未运行"},
+		{ip: "10.0.0.4", status: "未安装"},
+		{ip: "10.0.0.5", status: StatusResidual, residue: []string{"文件: /usr/local/bin/rke2", "服务: rke2-agent (failed)"}},
+		{ip: "10.0.0.6", status: "未安装", errorHas: "退出码 2: permission denied"},
+		{ip: "10.0.0.7", status: "检查失败", errorHas: "退出码 2"},
+		{ip: "10.0.0.8", status: "检查失败", errorHas: "connection refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ip, func(t *testing.T) {
+			got := results[tt.ip]
+			if got == nil {
+				t.Fatalf("no result for %s", tt.ip)
+			}
+			if got.Status != tt.status {
+				t.Errorf("Status = %q, want %q", got.Status, tt.status)
+			}
+			if got.Running != tt.running {
+				t.Errorf("Running = %v, want %v", got.Running, tt.running)
+			}
+			if !reflect.DeepEqual(got.Residue, tt.residue) {
+				t.Errorf("Residue = %q, want %q", got.Residue, tt.residue)
+			}
+			if tt.errorHas == "" && got.Error != "" {
+				t.Errorf("Error = %q, want empty", got.Error)
+			}
+			if tt.errorHas != "" && !strings.Contains(got.Error, tt.errorHas) {
+				t.Errorf("Error = %q, want it to contain %q", got.Error, tt.errorHas)
+			}
+		})
+	}
+
+	// server节点检查rke2-server服务，agent节点检查rke2-agent服务
+	for ip, want := range map[string]string{"10.0.0.1": "systemctl is-active rke2-server", "10.0.0.3": "systemctl is-active rke2-agent"} {
+		commands := runner.commands[ip]
+		if last := commands[len(commands)-1]; last != want {
+			t.Errorf("%s: last command = %q, want %q", ip, last, want)
+		}
+	}
+}
+
+func TestStatusCheckerCheckUsesDataDir(t *testing.T) {
+	cfg := &config.Config{
+		Hosts: []config.Host{{IP: "10.0.0.1", Role: []string{"worker"}}},
+		RKE2:  config.RKE2Config{DataDir: "/data/rke2"},
+	}
+	runner := &fakeRunner{hosts: map[string]fakeHost{
+		"10.0.0.1": {installed: fakeResult{code: 1}},
+	}}
+	checker := NewStatusChecker(cfg)
+	checker.SetRunner(runner)
+
+	if _, err := checker.Check(context.Background()); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	for _, command := range runner.commands["10.0.0.1"] {
+		if !strings.Contains(command, "/data/rke2") {
+			t.Errorf("command does not use rke2.data_dir:\n%s", command)
+		}
+	}
+}
+
+// blockingRunner 阻塞到上下文取消，记录同时执行的命令数
+type blockingRunner struct {
+	started  chan struct{}
+	inflight atomic.Int32
+	peak     atomic.Int32
+}
+
+func (b *blockingRunner) Run(ctx context.Context, host config.Host, command string) (string, string, int, error) {
+	n := b.inflight.Add(1)
+	defer b.inflight.Add(-1)
+	for {
+		peak := b.peak.Load()
+		if n <= peak || b.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+	b.started <- struct{}{}
+	<-ctx.Done()
+	return "", "", -1, ctx.Err()
+}
+
+func TestStatusCheckerCheckCancel(t *testing.T) {
+	cfg := &config.Config{}
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3", "10.0.0.4", "10.0.0.5"} {
+		cfg.Hosts = append(cfg.Hosts, config.Host{IP: ip, Role: []string{"worker"}})
+	}
+	runner := &blockingRunner{started: make(chan struct{}, len(cfg.Hosts))}
+	checker := NewStatusChecker(cfg)
+	checker.SetRunner(runner)
+	checker.SetConcurrency(2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// 两个主机的检查都已开始后取消
+		<-runner.started
+		<-runner.started
+		cancel()
+	}()
+
+	done := make(chan struct{})
+	var results map[string]*RKE2Status
+	var err error
+	go func() {
+		results, err = checker.Check(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Check() did not return after the context was canceled")
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Check() error = %v, want context.Canceled", err)
+	}
+	if peak := runner.peak.Load(); peak != 2 {
+		t.Errorf("peak concurrent checks = %d, want 2", peak)
+	}
+	if len(results) != len(cfg.Hosts) {
+		t.Fatalf("got %d results, want %d", len(results), len(cfg.Hosts))
+	}
+	for _, host := range cfg.Hosts {
+		got := results[host.IP]
+		if got.Status != "检查失败" || !strings.Contains(got.Error, context.Canceled.Error()) {
+			t.Errorf("%s: Status = %q, Error = %q, want a canceled check failure", host.IP, got.Status, got.Error)
+		}
+	}
+}
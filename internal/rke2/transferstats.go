@@ -0,0 +1,120 @@
+package rke2
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// TransferStats 单个节点的离线资源传输统计
+type TransferStats struct {
+	Host             string        // 主机IP
+	Files            int           // 实际传输的文件数
+	Bytes            int64         // 实际传输的文件大小
+	SkippedFiles     int           // 远程已存在且校验一致而跳过的文件数
+	SkippedBytes     int64         // 跳过的文件大小
+	Elapsed          time.Duration // 节点传输阶段的总耗时，包括校验和跳过检查
+	TransferDuration time.Duration // 实际传输文件的耗时，用于计算平均速率
+}
+
+// MBps 返回实际传输期间的平均速率（MB/s），没有传输时为0
+func (s TransferStats) MBps() float64 {
+	if s.Bytes == 0 || s.TransferDuration <= 0 {
+		return 0
+	}
+	return float64(s.Bytes) / 1024 / 1024 / s.TransferDuration.Seconds()
+}
+
+// TransferStats 按配置中的主机顺序返回最近一次离线资源传输的统计
+func (r *RKE2Installer) TransferStats() []TransferStats {
+	r.transferMu.Lock()
+	defer r.transferMu.Unlock()
+	var stats []TransferStats
+	for _, host := range r.config.Hosts {
+		if s, ok := r.transferStats[host.IP]; ok {
+			stats = append(stats, *s)
+		}
+	}
+	return stats
+}
+
+// resetTransferStats 开始新的传输阶段前清空统计
+func (r *RKE2Installer) resetTransferStats() {
+	r.transferMu.Lock()
+	defer r.transferMu.Unlock()
+	r.transferStats = make(map[string]*TransferStats)
+}
+
+// updateTransferStats 在锁内更新主机的传输统计，可在并行传输时调用
+func (r *RKE2Installer) updateTransferStats(host config.Host, update func(s *TransferStats)) {
+	r.transferMu.Lock()
+	defer r.transferMu.Unlock()
+	if r.transferStats == nil {
+		r.transferStats = make(map[string]*TransferStats)
+	}
+	s, ok := r.transferStats[host.IP]
+	if !ok {
+		s = &TransferStats{Host: host.IP}
+		r.transferStats[host.IP] = s
+	}
+	update(s)
+}
+
+// recordTransferredFile 记录一次成功的文件传输
+func (r *RKE2Installer) recordTransferredFile(host config.Host, size int64, duration time.Duration) {
+	r.updateTransferStats(host, func(s *TransferStats) {
+		s.Files++
+		s.Bytes += size
+		s.TransferDuration += duration
+	})
+}
+
+// recordSkippedFile 记录远程已存在而跳过的文件
+func (r *RKE2Installer) recordSkippedFile(host config.Host, size int64) {
+	r.updateTransferStats(host, func(s *TransferStats) {
+		s.SkippedFiles++
+		s.SkippedBytes += size
+	})
+}
+
+// logTransferSummary 输出各节点和总计的传输量、跳过量、耗时和平均速率，便于排查慢速链路
+func (r *RKE2Installer) logTransferSummary(elapsed time.Duration) {
+	if r.logger == nil {
+		return
+	}
+	stats := r.TransferStats()
+	if len(stats) == 0 {
+		return
+	}
+
+	total := TransferStats{Elapsed: elapsed}
+	r.logger.Info("离线资源传输统计:")
+	for _, s := range stats {
+		r.logger.Info("  %s: 传输 %d 个文件 %s，跳过 %d 个文件 %s（已存在），耗时 %s，平均 %.1f MB/s",
+			s.Host, s.Files, formatBytes(s.Bytes), s.SkippedFiles, formatBytes(s.SkippedBytes),
+			s.Elapsed.Round(time.Second), s.MBps())
+		total.Files += s.Files
+		total.Bytes += s.Bytes
+		total.SkippedFiles += s.SkippedFiles
+		total.SkippedBytes += s.SkippedBytes
+		total.TransferDuration += s.TransferDuration
+	}
+	r.logger.Info("  总计: %d 个节点，传输 %d 个文件 %s，跳过 %d 个文件 %s，耗时 %s，平均 %.1f MB/s",
+		len(stats), total.Files, formatBytes(total.Bytes), total.SkippedFiles, formatBytes(total.SkippedBytes),
+		total.Elapsed.Round(time.Second), total.MBps())
+}
+
+// formatBytes 将字节数格式化为易读的大小
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
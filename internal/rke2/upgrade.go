@@ -0,0 +1,382 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/cluster"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	upgradeNodeTimeout    = 10 * time.Minute // 单个节点升级后等待就绪的超时时间
+	upgradeHealthTimeout  = 10 * time.Minute // 节点之间等待集群健康的超时时间
+	upgradePollInterval   = 10 * time.Second
+	upgradeDrainTimeout   = "300s"
+	upgradeArtifactTarget = "/tmp/rke2-artifacts/rke2.linux*.tar.gz"
+)
+
+// upgradeArtifacts 升级需要替换的RKE2离线资源，不包含Rainbond镜像
+func (r *RKE2Installer) upgradeArtifacts() []FileArtifact {
+	return []FileArtifact{
+		{"rke2-install.sh", "/tmp/rke2-artifacts/rke2-install.sh", true},
+		{"rke2.linux*.tar.gz", upgradeArtifactTarget, true},
+		{"sha256sum*.txt", "/tmp/rke2-artifacts/sha256sum*.txt", true},
+		{"rke2-images-linux.tar", r.config.RKE2ImagesDir() + "/rke2-images-linux.tar", true},
+	}
+}
+
+// upgradeKubectl 在server节点上执行kubectl的命令前缀
+func (r *RKE2Installer) upgradeKubectl() string {
+	return r.config.RKE2BinDir() + "/kubectl --kubeconfig /etc/rancher/rke2/rke2.yaml"
+}
+
+// Upgrade 使用当前目录下的新版本离线资源原地升级RKE2集群
+// 按第一个server、其余server、agent的顺序逐个节点升级：驱逐、替换二进制和镜像、重启、等待就绪、恢复调度，
+// 每个节点完成后确认集群健康再继续，保证同一时间最多只有一个etcd成员离线
+func (r *RKE2Installer) Upgrade() error {
+	if r.logger != nil {
+		r.logger.Info("开始RKE2集群升级...")
+	}
+
+	servers := r.getServerHosts()
+	if len(servers) == 0 {
+		return fmt.Errorf("至少需要配置一个etcd或master节点")
+	}
+	agents := r.getAgentHosts()
+
+	// 校验本地新版本离线资源
+	localFileInfos := make(map[string]*FileInfo)
+	for _, artifact := range r.upgradeArtifacts() {
+		if err := r.addLocalFileInfos(artifact, localFileInfos); err != nil {
+			return fmt.Errorf("获取本地文件 %s 信息失败: %w", artifact.localPath, err)
+		}
+	}
+
+	// 升级前所有节点必须已安装并运行，避免在不健康的集群上滚动重启
+	status := r.checkRKE2Status()
+	r.printRKE2Status(status)
+	for _, host := range r.config.Hosts {
+		if s := status[host.IP]; s == nil || s.Status != "运行中" {
+			current := "未知"
+			if s != nil {
+				current = s.Status
+			}
+			return fmt.Errorf("节点 %s 当前状态为 %s，升级前所有节点必须处于运行中，请先执行 roi up --rke2", host.IP, current)
+		}
+	}
+
+	if len(servers) < 3 && r.logger != nil {
+		r.logger.Warn("etcd节点数为 %d，升级server节点期间etcd将失去多数派，Kubernetes API会短暂不可用", len(servers))
+	}
+
+	// 先将离线资源传输到第一个server，解压新二进制获取目标版本
+	if err := r.transferUpgradeArtifacts(servers[0], localFileInfos); err != nil {
+		return fmt.Errorf("节点 %s 传输升级资源失败: %w", servers[0].IP, err)
+	}
+	targetVersion, err := r.getArtifactVersion(servers[0])
+	if err != nil {
+		return err
+	}
+	if r.logger != nil {
+		r.logger.Info("目标RKE2版本: %s", targetVersion)
+	}
+
+	type upgradeTarget struct {
+		host     config.Host
+		nodeType string
+	}
+	var targets []upgradeTarget
+	for _, host := range servers {
+		targets = append(targets, upgradeTarget{host, "server"})
+	}
+	for _, host := range agents {
+		targets = append(targets, upgradeTarget{host, "agent"})
+	}
+
+	upgraded := 0
+	for i, target := range targets {
+		if r.logger != nil {
+			r.logger.Info("=== 升级节点 %d/%d: %s (%s) ===", i+1, len(targets), target.host.IP, target.nodeType)
+		}
+		if r.stepProgress != nil {
+			r.stepProgress.StartNodeProcessing(target.host.IP)
+		}
+
+		changed, err := r.upgradeNode(target.host, target.nodeType, targetVersion, localFileInfos)
+		if err != nil {
+			return fmt.Errorf("节点 %s 升级失败: %w", target.host.IP, err)
+		}
+		if changed {
+			upgraded++
+		}
+
+		if r.stepProgress != nil {
+			r.stepProgress.CompleteNodeStep(target.host.IP)
+		}
+	}
+
+	if r.logger != nil {
+		r.logger.Info("RKE2集群升级完成: %d 个节点已升级到 %s，%d 个节点已是目标版本", upgraded, targetVersion, len(targets)-upgraded)
+	}
+	return nil
+}
+
+// upgradeNode 升级单个节点，节点已是目标版本时跳过并返回false
+func (r *RKE2Installer) upgradeNode(host config.Host, nodeType, targetVersion string, localFileInfos map[string]*FileInfo) (bool, error) {
+	controlHost := r.upgradeControlHost(host)
+	client, err := r.createKubernetesClient(controlHost)
+	if err != nil {
+		return false, err
+	}
+
+	node, err := r.findKubernetesNode(client, host)
+	if err != nil {
+		return false, err
+	}
+	if node.Status.NodeInfo.KubeletVersion == targetVersion {
+		if r.logger != nil {
+			r.logger.Info("主机 %s: 节点 %s 已是目标版本 %s，跳过", host.IP, node.Name, targetVersion)
+		}
+		return false, nil
+	}
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 节点 %s 当前版本 %s，升级到 %s", host.IP, node.Name, node.Status.NodeInfo.KubeletVersion, targetVersion)
+	}
+
+	// 传输离线资源在驱逐之前完成，缩短节点不可调度的时间
+	if err := r.transferUpgradeArtifacts(host, localFileInfos); err != nil {
+		return false, fmt.Errorf("传输升级资源失败: %w", err)
+	}
+
+	if err := r.drainNode(controlHost, node.Name); err != nil {
+		r.uncordonNode(client, node.Name)
+		return false, err
+	}
+
+	if err := r.executeRKE2Install(host, nodeType); err != nil {
+		return false, fmt.Errorf("执行RKE2安装失败，节点 %s 保持不可调度状态: %w", node.Name, err)
+	}
+
+	if err := r.restartRKE2Service(host, nodeType); err != nil {
+		return false, err
+	}
+
+	if err := r.waitForNodeVersion(client, host, node.Name, targetVersion); err != nil {
+		return false, err
+	}
+
+	r.uncordonNode(client, node.Name)
+
+	if err := r.waitForClusterHealthy(client); err != nil {
+		return false, err
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 节点 %s 升级完成", host.IP, node.Name)
+	}
+	return true, nil
+}
+
+// upgradeControlHost 选择执行kubectl和访问API的server节点，优先避开正在升级的节点
+func (r *RKE2Installer) upgradeControlHost(upgrading config.Host) config.Host {
+	servers := r.getServerHosts()
+	for _, server := range servers {
+		if server.IP != upgrading.IP {
+			return server
+		}
+	}
+	return servers[0]
+}
+
+// transferUpgradeArtifacts 传输并校验升级所需的离线资源
+func (r *RKE2Installer) transferUpgradeArtifacts(host config.Host, localFileInfos map[string]*FileInfo) error {
+	mkdirCmd := "mkdir -p /tmp/rke2-artifacts " + r.config.RKE2ImagesDir()
+	if output, err := r.buildSSHCommand(host, mkdirCmd).CombinedOutput(); err != nil {
+		return fmt.Errorf("创建RKE2离线资源目录失败: %w, 输出: %s", err, string(output))
+	}
+	for _, artifact := range r.upgradeArtifacts() {
+		if err := r.transferArtifact(host, artifact); err != nil {
+			return fmt.Errorf("传输文件 %s 失败: %w", artifact.localPath, err)
+		}
+	}
+	if err := r.buildSSHCommand(host, "chmod +x /tmp/rke2-artifacts/rke2-install.sh").Run(); err != nil {
+		return fmt.Errorf("设置RKE2安装脚本执行权限失败: %w", err)
+	}
+	return r.validateFilesOnHost(host, r.upgradeArtifacts(), localFileInfos)
+}
+
+// getArtifactVersion 在节点上解压新版本rke2二进制并读取版本号，格式与kubelet版本一致（如 v1.28.9+rke2r1）
+func (r *RKE2Installer) getArtifactVersion(host config.Host) (string, error) {
+	versionCmd := fmt.Sprintf(`
+		tmp=$(mktemp -d)
+		trap 'rm -rf "$tmp"' EXIT
+		tar -xzf $(ls %s | head -1) -C "$tmp" bin/rke2 >/dev/null 2>&1 || exit 1
+		"$tmp/bin/rke2" --version | head -1
+	`, upgradeArtifactTarget)
+
+	output, err := r.buildSSHCommand(host, versionCmd).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("读取新版本RKE2版本号失败: %w, 输出: %s", err, string(output))
+	}
+
+	// 输出格式: rke2 version v1.28.9+rke2r1 (commit)
+	fields := strings.Fields(string(output))
+	if len(fields) < 3 || fields[0] != "rke2" || fields[1] != "version" {
+		return "", fmt.Errorf("无法解析RKE2版本号: %s", strings.TrimSpace(string(output)))
+	}
+	return fields[2], nil
+}
+
+// findKubernetesNode 按IP查找主机对应的Kubernetes节点
+func (r *RKE2Installer) findKubernetesNode(client kubernetes.Interface, host config.Host) (*corev1.Node, error) {
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("获取节点列表失败: %w", err)
+	}
+
+	hostIPs := map[string]bool{r.getNodeIP(host): true, r.getNodeInternalIP(host): true}
+	for i := range nodes.Items {
+		for _, addr := range nodes.Items[i].Status.Addresses {
+			if (addr.Type == corev1.NodeInternalIP || addr.Type == corev1.NodeExternalIP) && hostIPs[addr.Address] {
+				return &nodes.Items[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("未找到IP为 %s 的Kubernetes节点", host.IP)
+}
+
+// drainNode 通过server节点上的kubectl驱逐节点上的Pod并标记为不可调度
+func (r *RKE2Installer) drainNode(controlHost config.Host, nodeName string) error {
+	if r.logger != nil {
+		r.logger.Info("驱逐节点 %s 上的工作负载", nodeName)
+	}
+
+	drainCmd := fmt.Sprintf("%s drain %s --ignore-daemonsets --delete-emptydir-data --force --timeout=%s",
+		r.upgradeKubectl(), nodeName, upgradeDrainTimeout)
+	output, err := r.buildSSHCommand(controlHost, drainCmd).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("驱逐节点 %s 失败: %w, 输出: %s", nodeName, err, string(output))
+	}
+	if r.logger != nil {
+		r.logger.Debug("驱逐节点 %s 输出: %s", nodeName, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// uncordonNode 恢复节点调度，失败时仅记录警告，由用户手动处理
+func (r *RKE2Installer) uncordonNode(client kubernetes.Interface, nodeName string) {
+	patch := []byte(`{"spec":{"unschedulable":false}}`)
+	if _, err := client.CoreV1().Nodes().Patch(context.TODO(), nodeName, types.StrategicMergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		if r.logger != nil {
+			r.logger.Warn("恢复节点 %s 调度失败: %v，请手动执行 kubectl uncordon %s", nodeName, err, nodeName)
+		}
+		return
+	}
+	if r.logger != nil {
+		r.logger.Info("节点 %s 已恢复调度", nodeName)
+	}
+}
+
+// restartRKE2Service 重启RKE2服务以加载新版本二进制
+func (r *RKE2Installer) restartRKE2Service(host config.Host, nodeType string) error {
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 重启RKE2服务", host.IP)
+	}
+
+	restartCmd := fmt.Sprintf("systemctl restart --no-block rke2-%s", nodeType)
+	output, err := r.buildSSHCommand(host, restartCmd).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("重启RKE2服务失败: %w, 输出: %s", err, string(output))
+	}
+	return nil
+}
+
+// waitForNodeVersion 等待节点以目标版本重新注册并就绪
+func (r *RKE2Installer) waitForNodeVersion(client kubernetes.Interface, host config.Host, nodeName, targetVersion string) error {
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 等待节点 %s 以版本 %s 就绪", host.IP, nodeName, targetVersion)
+	}
+
+	err := cluster.WaitFor(context.Background(), r.logger, upgradePollInterval, upgradeNodeTimeout,
+		fmt.Sprintf("节点 %s 以版本 %s 就绪", nodeName, targetVersion),
+		func(ctx context.Context) (bool, error) {
+			node, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+			if err != nil {
+				return false, fmt.Errorf("暂时无法获取节点信息: %w", err)
+			}
+			if version := node.Status.NodeInfo.KubeletVersion; version != targetVersion {
+				return false, fmt.Errorf("当前版本 %s", version)
+			}
+			if !isNodeReady(node) {
+				return false, fmt.Errorf("节点未就绪")
+			}
+			return true, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if r.logger != nil {
+		r.logger.Info("主机 %s: 节点 %s 已就绪", host.IP, nodeName)
+	}
+	return nil
+}
+
+// waitForClusterHealthy 等待所有节点就绪且API Server健康检查（包含etcd）通过
+func (r *RKE2Installer) waitForClusterHealthy(client kubernetes.Interface) error {
+	err := cluster.WaitFor(context.Background(), r.logger, upgradePollInterval, upgradeHealthTimeout, "集群恢复健康",
+		func(ctx context.Context) (bool, error) {
+			if err := r.checkClusterHealth(client); err != nil {
+				return false, err
+			}
+			return true, nil
+		})
+	if err != nil {
+		return err
+	}
+
+	if r.logger != nil {
+		r.logger.Info("集群健康检查通过")
+	}
+	return nil
+}
+
+// checkClusterHealth 检查一次集群健康状态
+func (r *RKE2Installer) checkClusterHealth(client kubernetes.Interface) error {
+	body, err := client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(context.TODO())
+	if err != nil {
+		return fmt.Errorf("API Server未就绪: %w, 输出: %s", err, string(body))
+	}
+
+	nodes, err := client.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("获取节点列表失败: %w", err)
+	}
+	var notReady []string
+	for i := range nodes.Items {
+		if !isNodeReady(&nodes.Items[i]) {
+			notReady = append(notReady, nodes.Items[i].Name)
+		}
+	}
+	if len(notReady) > 0 {
+		return fmt.Errorf("节点未就绪: %s", strings.Join(notReady, ", "))
+	}
+	return nil
+}
+
+// isNodeReady 判断节点Ready条件是否为True
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package rke2
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VerifyClusterReady 严格模式下的阶段验证：API Server健康检查通过，且配置中的每个主机都已注册为节点并处于Ready状态
+// Run在部分节点未就绪时也可能返回成功，后续阶段依赖完整可用的集群，因此在继续前单独确认
+func (r *RKE2Installer) VerifyClusterReady() error {
+	if err := r.ensureKubernetesClient(); err != nil {
+		return fmt.Errorf("连接Kubernetes集群失败: %w", err)
+	}
+
+	body, err := r.kubeClient.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(context.TODO())
+	if err != nil {
+		return fmt.Errorf("API Server未就绪: %w, 输出: %s", err, string(body))
+	}
+
+	nodes, err := r.kubeClient.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("获取节点列表失败: %w", err)
+	}
+
+	var missing, notReady []string
+	for _, host := range r.config.Hosts {
+		node := findNodeByIP(nodes.Items, r.getNodeIP(host), r.getNodeInternalIP(host))
+		switch {
+		case node == nil:
+			missing = append(missing, host.IP)
+		case !isNodeReady(node):
+			notReady = append(notReady, fmt.Sprintf("%s(%s)", node.Name, host.IP))
+		}
+	}
+
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, fmt.Sprintf("未注册到集群的主机: %s", strings.Join(missing, ", ")))
+	}
+	if len(notReady) > 0 {
+		problems = append(problems, fmt.Sprintf("未就绪的节点: %s", strings.Join(notReady, ", ")))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%d 个主机中 %d 个节点未就绪，%s", len(r.config.Hosts), len(missing)+len(notReady), strings.Join(problems, "; "))
+	}
+
+	if r.logger != nil {
+		r.logger.Info("集群验证通过: %d 个节点全部就绪", len(r.config.Hosts))
+	}
+	return nil
+}
+
+// findNodeByIP 在节点列表中查找地址匹配任一IP的节点
+func findNodeByIP(nodes []corev1.Node, ips ...string) *corev1.Node {
+	for i := range nodes {
+		for _, addr := range nodes[i].Status.Addresses {
+			if addr.Type != corev1.NodeInternalIP && addr.Type != corev1.NodeExternalIP {
+				continue
+			}
+			for _, ip := range ips {
+				if addr.Address == ip {
+					return &nodes[i]
+				}
+			}
+		}
+	}
+	return nil
+}
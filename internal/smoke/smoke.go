@@ -0,0 +1,472 @@
+package smoke
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const (
+	// Namespace 冒烟测试使用的独立命名空间，测试结束后整体删除
+	Namespace = "roi-smoke-test"
+	// DefaultImage 测试工作负载使用的镜像，离线环境需提前导入或通过 --image 指定仓库中已有的HTTP服务镜像
+	DefaultImage = "registry.cn-hangzhou.aliyuncs.com/goodrain/nginx:alpine"
+	// DefaultIngressClass Rainbond网关监听的IngressClass
+	DefaultIngressClass = "apisix"
+	// DefaultTimeout 等待工作负载就绪和访问成功的超时时间
+	DefaultTimeout = 5 * time.Minute
+
+	workloadName   = "roi-smoke"
+	ingressHost    = "roi-smoke-test.local"
+	probeInterval  = 3 * time.Second
+	requestTimeout = 5 * time.Second
+)
+
+// Logger 定义日志接口
+type Logger interface {
+	Debug(format string, v ...interface{})
+	Info(format string, v ...interface{})
+	Warn(format string, v ...interface{})
+	Error(format string, v ...interface{})
+}
+
+// StepResult 冒烟测试单个步骤的结果
+type StepResult struct {
+	Name     string
+	Passed   bool
+	Duration time.Duration
+	Detail   string
+}
+
+// Tester 在集群中部署测试工作负载，确认其可以运行并被访问，结束后清理
+type Tester struct {
+	config       *config.Config
+	logger       Logger
+	kubeClient   kubernetes.Interface
+	image        string
+	ingress      bool
+	ingressClass string
+	timeout      time.Duration
+	keep         bool
+	results      []StepResult
+}
+
+func NewTester(cfg *config.Config) *Tester {
+	return NewTesterWithLogger(cfg, nil)
+}
+
+func NewTesterWithLogger(cfg *config.Config, logger Logger) *Tester {
+	return &Tester{
+		config:       cfg,
+		logger:       logger,
+		image:        DefaultImage,
+		ingressClass: DefaultIngressClass,
+		timeout:      DefaultTimeout,
+	}
+}
+
+// SetImage 设置测试工作负载的镜像，镜像需在80端口提供HTTP服务
+func (t *Tester) SetImage(image string) {
+	if image != "" {
+		t.image = image
+	}
+}
+
+// SetIngress 设置是否额外创建Ingress并通过Rainbond网关访问
+func (t *Tester) SetIngress(enabled bool, ingressClass string) {
+	t.ingress = enabled
+	if ingressClass != "" {
+		t.ingressClass = ingressClass
+	}
+}
+
+// SetTimeout 设置等待工作负载就绪和访问成功的超时时间
+func (t *Tester) SetTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		t.timeout = timeout
+	}
+}
+
+// SetKeep 设置测试结束后是否保留测试资源，便于排查失败原因
+func (t *Tester) SetKeep(keep bool) {
+	t.keep = keep
+}
+
+// Run 依次执行部署、等待就绪、访问和清理，返回各步骤结果；任一步骤失败时返回错误，清理始终执行
+func (t *Tester) Run() ([]StepResult, error) {
+	t.results = nil
+
+	if err := t.step("连接Kubernetes API", t.initializeKubeClient); err != nil {
+		return t.results, err
+	}
+
+	err := t.runWorkload()
+
+	if t.keep {
+		if t.logger != nil {
+			t.logger.Info("保留测试资源，可执行 kubectl delete namespace %s 手动清理", Namespace)
+		}
+	} else if cleanupErr := t.step("清理测试资源", t.cleanup); cleanupErr != nil && err == nil {
+		err = cleanupErr
+	}
+	return t.results, err
+}
+
+func (t *Tester) runWorkload() error {
+	if err := t.step("部署测试工作负载", t.deploy); err != nil {
+		return err
+	}
+	if err := t.step("等待工作负载就绪", t.waitForReady); err != nil {
+		return err
+	}
+	if err := t.step("集群内访问Service", t.probeClusterIP); err != nil {
+		return err
+	}
+	if err := t.step("通过NodePort访问", t.probeNodePort); err != nil {
+		return err
+	}
+	if t.ingress {
+		if err := t.step("通过Rainbond网关访问Ingress", t.probeIngress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// step 执行单个步骤并记录结果和耗时
+func (t *Tester) step(name string, fn func() (string, error)) error {
+	if t.logger != nil {
+		t.logger.Info("冒烟测试: %s...", name)
+	}
+	start := time.Now()
+	detail, err := fn()
+	result := StepResult{Name: name, Passed: err == nil, Duration: time.Since(start), Detail: detail}
+	if err != nil {
+		result.Detail = err.Error()
+	}
+	t.results = append(t.results, result)
+
+	if t.logger != nil {
+		if err != nil {
+			t.logger.Error("冒烟测试: %s失败: %v", name, err)
+		} else {
+			t.logger.Info("冒烟测试: %s完成（%s）%s", name, result.Duration.Round(time.Millisecond), detail)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s失败: %w", name, err)
+	}
+	return nil
+}
+
+// initializeKubeClient 使用RKE2安装时保存的本地kubeconfig（或已有集群的kubeconfig）创建客户端
+func (t *Tester) initializeKubeClient() (string, error) {
+	kubeConfigPath := t.config.KubeconfigPath()
+	if err := t.config.CheckKubeconfig(); err != nil {
+		return "", err
+	}
+
+	restConfig, err := clientcmd.BuildConfigFromFlags("", kubeConfigPath)
+	if err != nil {
+		return "", fmt.Errorf("构建Kubernetes配置失败: %w", err)
+	}
+	restConfig.Timeout = 10 * time.Second
+
+	clientset, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return "", fmt.Errorf("创建Kubernetes客户端失败: %w", err)
+	}
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("连接Kubernetes API失败: %w", err)
+	}
+
+	t.kubeClient = clientset
+	return version.GitVersion, nil
+}
+
+// deploy 创建测试命名空间、Deployment、NodePort Service，以及可选的Ingress
+func (t *Tester) deploy() (string, error) {
+	ctx := context.TODO()
+	labels := map[string]string{"app": workloadName}
+
+	if err := t.waitForNamespaceGone(); err != nil {
+		return "", err
+	}
+	namespace := &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: Namespace, Labels: t.config.ClusterLabels()},
+	}
+	if _, err := t.kubeClient.CoreV1().Namespaces().Create(ctx, namespace, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("创建命名空间 %s 失败: %w", Namespace, err)
+	}
+
+	replicas := int32(1)
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: workloadName, Namespace: Namespace, Labels: labels},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: labels},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name:            workloadName,
+						Image:           t.image,
+						ImagePullPolicy: corev1.PullIfNotPresent,
+						Ports:           []corev1.ContainerPort{{ContainerPort: 80}},
+						ReadinessProbe: &corev1.Probe{
+							ProbeHandler: corev1.ProbeHandler{
+								HTTPGet: &corev1.HTTPGetAction{Path: "/", Port: intstr.FromInt(80)},
+							},
+							PeriodSeconds: 2,
+						},
+					}},
+				},
+			},
+		},
+	}
+	if _, err := t.kubeClient.AppsV1().Deployments(Namespace).Create(ctx, deployment, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("创建Deployment失败: %w", err)
+	}
+
+	service := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: workloadName, Namespace: Namespace, Labels: labels},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeNodePort,
+			Selector: labels,
+			Ports:    []corev1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromInt(80)}},
+		},
+	}
+	if _, err := t.kubeClient.CoreV1().Services(Namespace).Create(ctx, service, metav1.CreateOptions{}); err != nil {
+		return "", fmt.Errorf("创建Service失败: %w", err)
+	}
+
+	if t.ingress {
+		pathType := networkingv1.PathTypePrefix
+		ingress := &networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: workloadName, Namespace: Namespace, Labels: labels},
+			Spec: networkingv1.IngressSpec{
+				IngressClassName: &t.ingressClass,
+				Rules: []networkingv1.IngressRule{{
+					Host: ingressHost,
+					IngressRuleValue: networkingv1.IngressRuleValue{
+						HTTP: &networkingv1.HTTPIngressRuleValue{
+							Paths: []networkingv1.HTTPIngressPath{{
+								Path:     "/",
+								PathType: &pathType,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: workloadName,
+										Port: networkingv1.ServiceBackendPort{Number: 80},
+									},
+								},
+							}},
+						},
+					},
+				}},
+			},
+		}
+		if _, err := t.kubeClient.NetworkingV1().Ingresses(Namespace).Create(ctx, ingress, metav1.CreateOptions{}); err != nil {
+			return "", fmt.Errorf("创建Ingress失败: %w", err)
+		}
+	}
+
+	return fmt.Sprintf("镜像 %s", t.image), nil
+}
+
+// waitForNamespaceGone 上次测试的命名空间仍在删除时等待其删除完成
+func (t *Tester) waitForNamespaceGone() error {
+	deadline := time.Now().Add(t.timeout)
+	for {
+		_, err := t.kubeClient.CoreV1().Namespaces().Get(context.TODO(), Namespace, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("查询命名空间 %s 失败: %w", Namespace, err)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("命名空间 %s 已存在且未能删除，请手动清理后重试", Namespace)
+		}
+		_ = t.kubeClient.CoreV1().Namespaces().Delete(context.TODO(), Namespace, metav1.DeleteOptions{})
+		time.Sleep(probeInterval)
+	}
+}
+
+// waitForReady 等待测试Pod就绪，超时时返回Pod当前的状态原因
+func (t *Tester) waitForReady() (string, error) {
+	deadline := time.Now().Add(t.timeout)
+	for {
+		pods, err := t.kubeClient.CoreV1().Pods(Namespace).List(context.TODO(), metav1.ListOptions{
+			LabelSelector: "app=" + workloadName,
+		})
+		if err == nil {
+			for _, pod := range pods.Items {
+				if ready, _ := podReady(pod); ready {
+					return fmt.Sprintf("Pod %s 运行在节点 %s", pod.Name, pod.Spec.NodeName), nil
+				}
+			}
+		}
+
+		if time.Now().After(deadline) {
+			reason := "未创建Pod"
+			if err != nil {
+				reason = err.Error()
+			} else if len(pods.Items) > 0 {
+				_, reason = podReady(pods.Items[0])
+			}
+			return "", fmt.Errorf("等待 %s 后测试Pod仍未就绪: %s", t.timeout, reason)
+		}
+		time.Sleep(probeInterval)
+	}
+}
+
+// podReady 判断Pod是否就绪，未就绪时返回原因
+func podReady(pod corev1.Pod) (bool, string) {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Waiting != nil && status.State.Waiting.Reason != "" {
+			return false, fmt.Sprintf("%s: %s", status.State.Waiting.Reason, status.State.Waiting.Message)
+		}
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled && condition.Status == corev1.ConditionFalse {
+			return false, fmt.Sprintf("无法调度: %s", condition.Message)
+		}
+		if condition.Type == corev1.PodReady {
+			if condition.Status == corev1.ConditionTrue {
+				return true, ""
+			}
+			return false, fmt.Sprintf("Pod状态 %s", pod.Status.Phase)
+		}
+	}
+	return false, fmt.Sprintf("Pod状态 %s", pod.Status.Phase)
+}
+
+// probeClusterIP 从控制节点访问Service的ClusterIP，验证kube-proxy和容器网络
+func (t *Tester) probeClusterIP() (string, error) {
+	service, err := t.kubeClient.CoreV1().Services(Namespace).Get(context.TODO(), workloadName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("获取Service失败: %w", err)
+	}
+	hosts := t.config.GetControlHosts()
+	if len(hosts) == 0 {
+		hosts = t.config.Hosts
+	}
+	if len(hosts) == 0 {
+		return "", fmt.Errorf("配置中没有可用的主机")
+	}
+	host := hosts[0]
+
+	// 使用bash的/dev/tcp发送HTTP请求，不依赖节点上安装curl
+	probeCmd := fmt.Sprintf(`timeout 5 bash -c 'exec 3<>/dev/tcp/%s/80; printf "GET / HTTP/1.0\r\nHost: %s\r\n\r\n" >&3; head -1 <&3'`,
+		service.Spec.ClusterIP, workloadName)
+	var lastErr error
+	deadline := time.Now().Add(t.timeout)
+	for {
+		output, err := ssh.NewHostCommand(host, probeCmd).CombinedOutput()
+		statusLine := strings.TrimSpace(string(output))
+		if err == nil && strings.HasPrefix(statusLine, "HTTP/") {
+			return fmt.Sprintf("%s -> %s:80 %s", host.IP, service.Spec.ClusterIP, statusLine), nil
+		}
+		lastErr = fmt.Errorf("%v, 输出: %s", err, statusLine)
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("主机 %s 无法访问 %s:80: %w", host.IP, service.Spec.ClusterIP, lastErr)
+		}
+		time.Sleep(probeInterval)
+	}
+}
+
+// probeNodePort 从运行roi的机器访问网关节点上的NodePort，验证集群外部到工作负载的转发
+func (t *Tester) probeNodePort() (string, error) {
+	service, err := t.kubeClient.CoreV1().Services(Namespace).Get(context.TODO(), workloadName, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("获取Service失败: %w", err)
+	}
+	if len(service.Spec.Ports) == 0 || service.Spec.Ports[0].NodePort == 0 {
+		return "", fmt.Errorf("Service未分配NodePort")
+	}
+	nodePort := service.Spec.Ports[0].NodePort
+
+	ip := t.gatewayIP()
+	if ip == "" {
+		return "", fmt.Errorf("配置中没有可用的主机")
+	}
+	return t.probeHTTP(fmt.Sprintf("http://%s:%d/", config.URLHost(ip), nodePort), "")
+}
+
+// probeIngress 通过Rainbond网关的80端口以Ingress域名访问测试工作负载
+func (t *Tester) probeIngress() (string, error) {
+	ip := t.gatewayIP()
+	if ip == "" {
+		return "", fmt.Errorf("配置中没有可用的网关IP")
+	}
+	return t.probeHTTP(fmt.Sprintf("http://%s/", config.URLHost(ip)), ingressHost)
+}
+
+// gatewayIP 返回访问测试工作负载使用的IP：优先网关入口IP，其次rbd-gateway节点，最后第一个主机
+func (t *Tester) gatewayIP() string {
+	if ips := t.config.GetGatewayIngressIPs(); len(ips) > 0 {
+		return ips[0]
+	}
+	if hosts := t.config.GetRbdGatewayHosts(); len(hosts) > 0 {
+		return hosts[0].IP
+	}
+	if len(t.config.Hosts) > 0 {
+		return t.config.Hosts[0].IP
+	}
+	return ""
+}
+
+// probeHTTP 在超时时间内重试HTTP请求，直到返回2xx/3xx
+func (t *Tester) probeHTTP(url, host string) (string, error) {
+	client := &http.Client{Timeout: requestTimeout}
+	var lastErr error
+	deadline := time.Now().Add(t.timeout)
+	for {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			return "", err
+		}
+		if host != "" {
+			req.Host = host
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 400 {
+				if host != "" {
+					return fmt.Sprintf("%s (Host: %s) 返回 %d", url, host, resp.StatusCode), nil
+				}
+				return fmt.Sprintf("%s 返回 %d", url, resp.StatusCode), nil
+			}
+			err = fmt.Errorf("返回 %d", resp.StatusCode)
+		}
+		lastErr = err
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("访问 %s 失败: %w", url, lastErr)
+		}
+		time.Sleep(probeInterval)
+	}
+}
+
+// cleanup 删除测试命名空间及其中的所有资源
+func (t *Tester) cleanup() (string, error) {
+	err := t.kubeClient.CoreV1().Namespaces().Delete(context.TODO(), Namespace, metav1.DeleteOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("删除命名空间 %s 失败: %w", Namespace, err)
+	}
+	return fmt.Sprintf("已删除命名空间 %s", Namespace), nil
+}
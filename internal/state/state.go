@@ -0,0 +1,104 @@
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// DefaultPath 本地状态文件，与RKE2安装保存的 ./kubeconfig 位于同一目录
+const DefaultPath = "./roi-state.json"
+
+// 记录主机验证状态的阶段
+const (
+	StageCheck    = "check"
+	StageOptimize = "optimize"
+)
+
+// HostRecord 主机在某个阶段最近一次成功时的配置摘要
+type HostRecord struct {
+	ConfigHash string    `json:"config_hash"`
+	VerifiedAt time.Time `json:"verified_at"`
+}
+
+// State 各阶段已成功处理的主机记录，用于再次运行时跳过配置未变更的主机
+type State struct {
+	path   string
+	Stages map[string]map[string]HostRecord `json:"stages"` // 阶段 -> 主机IP -> 记录
+}
+
+// Load 读取状态文件，文件不存在时返回空状态
+func Load(path string) (*State, error) {
+	s := &State{path: path, Stages: make(map[string]map[string]HostRecord)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取状态文件 %s 失败: %w", path, err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("解析状态文件 %s 失败: %w，可删除该文件后重试", path, err)
+	}
+	if s.Stages == nil {
+		s.Stages = make(map[string]map[string]HostRecord)
+	}
+	return s, nil
+}
+
+// Save 写回状态文件，先写临时文件再重命名，避免中断时留下不完整的文件
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化状态失败: %w", err)
+	}
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("写入状态文件 %s 失败: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("写入状态文件 %s 失败: %w", s.path, err)
+	}
+	return nil
+}
+
+// HostHash 计算主机配置及阶段相关全局配置的摘要，任一字段变化都会使摘要改变
+func HostHash(host config.Host, extra interface{}) string {
+	data, _ := json.Marshal(struct {
+		Host  config.Host `json:"host"`
+		Extra interface{} `json:"extra,omitempty"`
+	}{host, extra})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Partition 将主机分为需要处理的（新增、配置变更或从未成功）和可以跳过的（配置未变更且上次成功）
+func (s *State) Partition(stage string, hosts []config.Host, hash func(config.Host) string) (changed, unchanged []config.Host) {
+	records := s.Stages[stage]
+	for _, host := range hosts {
+		if record, ok := records[host.IP]; ok && record.ConfigHash == hash(host) {
+			unchanged = append(unchanged, host)
+		} else {
+			changed = append(changed, host)
+		}
+	}
+	return changed, unchanged
+}
+
+// MarkVerified 记录主机在阶段中成功处理时的配置摘要
+func (s *State) MarkVerified(stage string, hosts []config.Host, hash func(config.Host) string) {
+	records := s.Stages[stage]
+	if records == nil {
+		records = make(map[string]HostRecord)
+		s.Stages[stage] = records
+	}
+	now := time.Now()
+	for _, host := range hosts {
+		records[host.IP] = HostRecord{ConfigHash: hash(host), VerifiedAt: now}
+	}
+}
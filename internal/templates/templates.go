@@ -0,0 +1,74 @@
+package templates
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// 内置模板文件，随二进制一起发布
+//
+//go:embed files/*.tmpl
+var builtinFiles embed.FS
+
+// 模板名称
+const (
+	RKE2Config       = "rke2-config.yaml.tmpl"       // RKE2主配置 /etc/rancher/rke2/config.yaml
+	RKE2CustomConfig = "rke2-rainbond.yaml.tmpl"     // RKE2 Rainbond定制配置 config.yaml.d/00-rbd.yaml
+	RKE2CanalConfig  = "rke2-canal-config.yaml.tmpl" // canal网卡配置 server/manifests/rke2-canal-config.yaml
+	MySQLMaster      = "mysql-master.yaml.tmpl"      // MySQL Master Service + StatefulSet
+	MySQLSlave       = "mysql-slave.yaml.tmpl"       // MySQL Slave Service + StatefulSet
+	MySQLInit        = "mysql-init.yaml.tmpl"        // MySQL数据库初始化Job
+	Sysctl           = "sysctl.conf.tmpl"            // 系统优化内核参数 /etc/sysctl.conf
+	Limits           = "limits.conf.tmpl"            // 系统优化资源限制 /etc/security/limits.conf
+)
+
+// funcs 模板中可用的辅助函数
+var funcs = template.FuncMap{
+	"join": strings.Join,
+}
+
+// Render 渲染内置模板，overridePath 不为空时使用该路径下的用户自定义模板替代内置模板
+func Render(name, overridePath string, data interface{}) (string, error) {
+	var (
+		content []byte
+		err     error
+	)
+	if overridePath != "" {
+		content, err = os.ReadFile(overridePath)
+		if err != nil {
+			return "", fmt.Errorf("读取自定义模板 %s 失败: %w", overridePath, err)
+		}
+	} else {
+		content, err = builtinFiles.ReadFile("files/" + name)
+		if err != nil {
+			return "", fmt.Errorf("内置模板 %s 不存在: %w", name, err)
+		}
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(string(content))
+	if err != nil {
+		return "", fmt.Errorf("解析模板 %s 失败: %w", name, err)
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("渲染模板 %s 失败: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// Validate 校验用户自定义模板能否被解析，用于配置加载阶段提前发现语法错误
+func Validate(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("读取自定义模板 %s 失败: %w", path, err)
+	}
+	if _, err := template.New(path).Funcs(funcs).Parse(string(content)); err != nil {
+		return fmt.Errorf("解析自定义模板 %s 失败: %w", path, err)
+	}
+	return nil
+}
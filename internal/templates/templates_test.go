@@ -0,0 +1,223 @@
+package templates_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rainbond/rainbond-offline-installer/internal/optimize"
+	"github.com/rainbond/rainbond-offline-installer/internal/templates"
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// 模板修改后执行 go test ./internal/templates -update 重新生成golden文件，再检查diff
+var update = flag.Bool("update", false, "用渲染结果更新 testdata 中的golden文件")
+
+// rke2ConfigData 与 rke2 包中主配置模板参数的字段一致
+type rke2ConfigData struct {
+	Description           string
+	ServerURL             string
+	Token                 string
+	NodeName              string
+	NodeIP                string
+	NodeExternalIP        string
+	ClusterCIDR           string
+	ServiceCIDR           string
+	Taints                []string
+	NodeLabels            []string
+	DisableControlPlane   bool
+	DisableEtcd           bool
+	EtcdSnapshotCron      string
+	EtcdSnapshotRetention int
+	DataDir               string
+}
+
+// mysqlManifestData 与 mysql 包中清单模板参数的字段一致
+type mysqlManifestData struct {
+	NodeName          string
+	NodeSelectorKey   string
+	NodeSelectorValue string
+	RootPassword      string
+	ReplUser          string
+	ReplPassword      string
+	DataPath          string
+	StorageClass      string
+	StorageSize       string
+	InitSQL           string
+	SkipSyncTest      bool
+	ImagePullPolicy   string
+	ImagePullSecret   string
+}
+
+// goldenCase 用给定参数渲染模板 name，结果与 testdata/<golden>.golden 比较
+type goldenCase struct {
+	golden string
+	name   string
+	data   interface{}
+}
+
+func TestRenderGolden(t *testing.T) {
+	mysqlHostPath := mysqlManifestData{
+		NodeName:     "node-1",
+		RootPassword: "root-pass",
+		ReplUser:     "repl",
+		ReplPassword: "repl-pass",
+		DataPath:     "/opt/rainbond/mysql",
+		StorageSize:  "20Gi",
+	}
+	mysqlPVC := mysqlManifestData{
+		NodeSelectorKey:   "rainbond.io/mysql",
+		NodeSelectorValue: "master",
+		RootPassword:      "root-pass",
+		ReplUser:          "repl",
+		ReplPassword:      "repl-pass",
+		StorageClass:      "local-path",
+		StorageSize:       "50Gi",
+		ImagePullPolicy:   "IfNotPresent",
+		ImagePullSecret:   "registry-secret",
+	}
+	mysqlSlave := mysqlHostPath
+	mysqlSlave.NodeName = "node-2"
+
+	tests := []goldenCase{
+		{
+			golden: "rke2-config-first-server",
+			name:   templates.RKE2Config,
+			data: rke2ConfigData{
+				Description: "Server节点",
+				Token:       "test-token",
+				NodeName:    "node-1",
+				NodeIP:      "10.0.0.1",
+				ClusterCIDR: "10.42.0.0/16",
+				ServiceCIDR: "10.43.0.0/16",
+				NodeLabels:  []string{"rainbond.io/cluster=prod"},
+			},
+		},
+		{
+			golden: "rke2-config-etcd",
+			name:   templates.RKE2Config,
+			data: rke2ConfigData{
+				Description:           "Etcd节点",
+				ServerURL:             "10.0.0.1",
+				Token:                 "test-token",
+				NodeName:              "etcd-2",
+				NodeIP:                "192.168.0.2",
+				NodeExternalIP:        "10.0.0.2",
+				Taints:                []string{"node-role.kubernetes.io/etcd=true:NoExecute"},
+				DisableControlPlane:   true,
+				EtcdSnapshotCron:      "0 */6 * * *",
+				EtcdSnapshotRetention: 10,
+				DataDir:               "/data/rke2",
+			},
+		},
+		{
+			golden: "rke2-config-agent",
+			name:   templates.RKE2Config,
+			data: rke2ConfigData{
+				Description: "Agent节点",
+				ServerURL:   "[fd00::1]",
+				Token:       "test-token",
+				NodeName:    "worker-1",
+				NodeIP:      "10.0.0.3,fd00::3",
+				NodeLabels:  []string{"rainbond.io/cluster=prod", "pool=build"},
+			},
+		},
+		{
+			golden: "rke2-rainbond",
+			name:   templates.RKE2CustomConfig,
+			data:   map[string]string{"SystemDefaultRegistry": "registry.example.com"},
+		},
+		{
+			golden: "rke2-canal-config-iface",
+			name:   templates.RKE2CanalConfig,
+			data:   map[string]string{"Iface": "eth1", "IfaceRegex": ""},
+		},
+		{
+			golden: "rke2-canal-config-regex",
+			name:   templates.RKE2CanalConfig,
+			data:   map[string]string{"Iface": "", "IfaceRegex": "^(eth1|ens224)$"},
+		},
+		{golden: "mysql-master-hostpath", name: templates.MySQLMaster, data: mysqlHostPath},
+		{golden: "mysql-master-pvc", name: templates.MySQLMaster, data: mysqlPVC},
+		{golden: "mysql-slave-hostpath", name: templates.MySQLSlave, data: mysqlSlave},
+		{golden: "mysql-init", name: templates.MySQLInit, data: mysqlManifestData{RootPassword: "root-pass"}},
+		{
+			golden: "mysql-init-skip-sync",
+			name:   templates.MySQLInit,
+			data: mysqlManifestData{
+				RootPassword:    "root-pass",
+				InitSQL:         "Q1JFQVRFIERBVEFCQVNFIGFwcDs=",
+				SkipSyncTest:    true,
+				ImagePullPolicy: "Always",
+				ImagePullSecret: "registry-secret",
+			},
+		},
+	}
+
+	for _, name := range config.OptimizeProfiles {
+		profile, err := optimize.LookupProfile(name)
+		if err != nil {
+			t.Fatalf("LookupProfile(%q) error = %v", name, err)
+		}
+		ipv6 := profile
+		ipv6.KeepIPv6 = true
+		tests = append(tests,
+			goldenCase{golden: "sysctl-" + name, name: templates.Sysctl, data: profile},
+			goldenCase{golden: "sysctl-" + name + "-ipv6", name: templates.Sysctl, data: ipv6},
+			goldenCase{golden: "limits-" + name, name: templates.Limits, data: profile},
+		)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			got, err := templates.Render(tt.name, "", tt.data)
+			if err != nil {
+				t.Fatalf("Render(%s) error = %v", tt.name, err)
+			}
+			assertGolden(t, tt.golden, got)
+		})
+	}
+}
+
+func TestRenderOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "limits.conf.tmpl")
+	if err := os.WriteFile(path, []byte("* soft nofile {{ .NoFile }}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := templates.Render(templates.Limits, path, optimize.Profile{NoFile: 4096})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if want := "* soft nofile 4096\n"; got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderMissingKey(t *testing.T) {
+	if _, err := templates.Render(templates.RKE2CustomConfig, "", map[string]string{}); err == nil {
+		t.Error("Render() with a missing key succeeded, want error")
+	}
+}
+
+// assertGolden 比较渲染结果与 testdata/<name>.golden，指定 -update 时改为写入golden文件
+func assertGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取golden文件失败（新增用例时使用 -update 生成）: %v", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s 渲染结果与 %s 不一致\n--- got ---\n%s\n--- want ---\n%s", name, path, got, want)
+	}
+}
@@ -0,0 +1,38 @@
+package config
+
+import "fmt"
+
+// DefaultMinFreeInodesPercent 未配置 check.min_free_inodes_percent 时的最低空闲inode比例
+const DefaultMinFreeInodesPercent = 10
+
+// DefaultGatewayPorts 未配置 check.gateway_ports 时网关绑定的主机端口
+var DefaultGatewayPorts = []int{80, 443, 7070}
+
+// MinFreeInodesPercent 返回最低空闲inode比例，未配置时使用默认值
+func (c CheckConfig) MinFreeInodesPercent() int {
+	if c.MinFreeInodesPct == 0 {
+		return DefaultMinFreeInodesPercent
+	}
+	return c.MinFreeInodesPct
+}
+
+// GatewayPortList 返回需要在rbd-gateway节点上检查的端口，未配置时使用默认值
+func (c CheckConfig) GatewayPortList() []int {
+	if len(c.GatewayPorts) == 0 {
+		return DefaultGatewayPorts
+	}
+	return c.GatewayPorts
+}
+
+// validateCheck 验证系统检查阈值
+func validateCheck(check CheckConfig) error {
+	if check.MinFreeInodesPct < 0 || check.MinFreeInodesPct > 100 {
+		return fmt.Errorf("invalid check.min_free_inodes_percent %d, must be between 0 and 100", check.MinFreeInodesPct)
+	}
+	for _, port := range check.GatewayPorts {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid check.gateway_ports entry %d, must be between 1 and 65535", port)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultKubeconfigPath RKE2安装完成后保存到本地的kubeconfig
+const DefaultKubeconfigPath = "./kubeconfig"
+
+// rke2LocalAPIServer RKE2在server节点生成的kubeconfig中的API地址
+const rke2LocalAPIServer = "https://127.0.0.1:6443"
+
+// KubeconfigForServer 将RKE2生成的kubeconfig中的本地API地址改写为 server，使其可在节点外使用
+func KubeconfigForServer(data []byte, server string) []byte {
+	return []byte(strings.ReplaceAll(string(data), rke2LocalAPIServer, fmt.Sprintf("https://%s:6443", URLHost(server))))
+}
+
+// IsExistingCluster 是否安装到已有的Kubernetes集群（指定了 existing_cluster.kubeconfig），此时跳过RKE2安装
+func (c *Config) IsExistingCluster() bool {
+	return c.ExistingCluster.Kubeconfig != ""
+}
+
+// KubeconfigPath 返回访问集群使用的kubeconfig：已有集群使用指定的kubeconfig，否则使用RKE2安装时保存的本地文件
+func (c *Config) KubeconfigPath() string {
+	if c.IsExistingCluster() {
+		return c.ExistingCluster.Kubeconfig
+	}
+	return DefaultKubeconfigPath
+}
+
+// CheckKubeconfig 检查kubeconfig文件是否存在，不存在时提示先安装RKE2或指定已有集群
+func (c *Config) CheckKubeconfig() error {
+	path := c.KubeconfigPath()
+	if _, err := os.Stat(path); err != nil {
+		if c.IsExistingCluster() {
+			return fmt.Errorf("已有集群的kubeconfig文件不可访问: %s: %w", path, err)
+		}
+		return fmt.Errorf("本地kubeconfig文件不存在: %s，请先运行RKE2安装，或通过 --existing-cluster 指定已有集群的kubeconfig", path)
+	}
+	return nil
+}
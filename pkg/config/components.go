@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// 组件反亲和策略
+const (
+	AntiAffinityNone      = "none"      // 不设置反亲和（默认）
+	AntiAffinityPreferred = "preferred" // 尽量将副本分散到不同节点
+	AntiAffinityRequired  = "required"  // 强制每个节点最多一个副本
+)
+
+// componentValueName 将组件简称转换为Rainbond chart中的组件名，如 api -> rbd_api
+func componentValueName(name string) string {
+	name = strings.ReplaceAll(strings.TrimSpace(strings.ToLower(name)), "-", "_")
+	if !strings.HasPrefix(name, "rbd_") {
+		name = "rbd_" + name
+	}
+	return name
+}
+
+// SchedulableNodeCount 获取可调度业务负载的节点数量
+// 存在worker节点时控制平面节点带有NoSchedule污点，否则所有节点均可调度
+func (c *Config) SchedulableNodeCount() int {
+	workers := 0
+	for _, host := range c.Hosts {
+		for _, role := range host.Role {
+			if strings.TrimSpace(strings.ToLower(role)) == "worker" {
+				workers++
+				break
+			}
+		}
+	}
+	if workers > 0 {
+		return workers
+	}
+	return len(c.Hosts)
+}
+
+// validateComponents 验证组件副本数和反亲和配置
+func validateComponents(config *Config) error {
+	schedulable := config.SchedulableNodeCount()
+	for name, component := range config.Rainbond.Components {
+		if component.Replicas < 0 {
+			return fmt.Errorf("rainbond.components.%s: replicas must not be negative", name)
+		}
+		switch component.AntiAffinity {
+		case "", AntiAffinityNone, AntiAffinityPreferred, AntiAffinityRequired:
+		default:
+			return fmt.Errorf("rainbond.components.%s: invalid anti_affinity '%s', must be one of: none, preferred, required", name, component.AntiAffinity)
+		}
+		if component.Replicas > schedulable {
+			return fmt.Errorf("rainbond.components.%s: replicas %d exceeds schedulable node count %d", name, component.Replicas, schedulable)
+		}
+	}
+	return nil
+}
+
+// ApplyComponentScheduling 将 rainbond.components 中的副本数和反亲和配置合并到 values.Component
+func (c *Config) ApplyComponentScheduling() {
+	if len(c.Rainbond.Components) == 0 {
+		return
+	}
+	if c.Rainbond.Values == nil {
+		c.Rainbond.Values = make(map[string]interface{})
+	}
+	componentMap, ok := c.Rainbond.Values["Component"].(map[string]interface{})
+	if !ok {
+		componentMap = make(map[string]interface{})
+		c.Rainbond.Values["Component"] = componentMap
+	}
+
+	for name, component := range c.Rainbond.Components {
+		valueName := componentValueName(name)
+		values, ok := componentMap[valueName].(map[string]interface{})
+		if !ok {
+			values = make(map[string]interface{})
+			componentMap[valueName] = values
+		}
+
+		if component.Replicas > 0 {
+			values["replicas"] = component.Replicas
+		}
+		if affinity := podAntiAffinity(strings.ReplaceAll(valueName, "_", "-"), component.AntiAffinity); affinity != nil {
+			values["affinity"] = affinity
+		}
+	}
+}
+
+// podAntiAffinity 生成按节点分散组件副本的反亲和配置
+func podAntiAffinity(podName, mode string) map[string]interface{} {
+	term := map[string]interface{}{
+		"labelSelector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{"name": podName},
+		},
+		"topologyKey": "kubernetes.io/hostname",
+	}
+
+	switch mode {
+	case AntiAffinityPreferred:
+		return map[string]interface{}{
+			"podAntiAffinity": map[string]interface{}{
+				"preferredDuringSchedulingIgnoredDuringExecution": []interface{}{
+					map[string]interface{}{"weight": 100, "podAffinityTerm": term},
+				},
+			},
+		}
+	case AntiAffinityRequired:
+		return map[string]interface{}{
+			"podAntiAffinity": map[string]interface{}{
+				"requiredDuringSchedulingIgnoredDuringExecution": []interface{}{term},
+			},
+		}
+	}
+	return nil
+}
@@ -1,19 +1,79 @@
 package config
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	"net"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
+// StdinConfigPath 作为配置路径时表示从标准输入读取配置
+const StdinConfigPath = "-"
 
+const (
+	NodeNameStrategyIP       = "ip"       // 使用主机IP作为节点名称（默认）
+	NodeNameStrategyHostname = "hostname" // 使用主机 hostname -f 作为节点名称
+)
+
+const (
+	OptimizeProfileBalanced       = "balanced"        // 通用调优（默认）
+	OptimizeProfileHighThroughput = "high-throughput" // 高并发网络吞吐
+	OptimizeProfileLowMemory      = "low-memory"      // 小内存节点
+)
+
+// OptimizeProfiles 支持的系统调优档位
+var OptimizeProfiles = []string{OptimizeProfileBalanced, OptimizeProfileHighThroughput, OptimizeProfileLowMemory}
+
+// ValidateOptimizeProfile 验证系统调优档位名称，空值表示使用默认档位
+func ValidateOptimizeProfile(profile string) error {
+	if profile == "" {
+		return nil
+	}
+	for _, p := range OptimizeProfiles {
+		if p == profile {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid optimize profile '%s', must be one of: %s", profile, strings.Join(OptimizeProfiles, ", "))
+}
+
+const (
+	ContainerdLVName              = "lv_containerd"       // 容器存储专用逻辑卷名称
+	DefaultContainerdLVMountPoint = "/var/lib/containerd" // lv_containerd 未配置mount_point时的挂载点
+)
+
+// LVMountPoint 获取逻辑卷的挂载点，未配置mount_point时按卷名使用默认挂载点
+// lv_containerd 保持原有的默认挂载点 /var/lib/containerd，避免已有主机重新执行时改写fstab；
+// 安装RKE2前再绑定挂载到RKE2的containerd数据目录，使容器存储实际落在专用磁盘上
+func LVMountPoint(lv LogicalVolume) string {
+	if lv.MountPoint != "" {
+		return lv.MountPoint
+	}
+	switch lv.LVName {
+	case "lv_docker":
+		return "/var/lib/docker"
+	case ContainerdLVName:
+		return DefaultContainerdLVMountPoint
+	default:
+		return fmt.Sprintf("/mnt/%s", lv.LVName)
+	}
+}
+
+// LoadConfig 加载并校验配置文件，路径为 "-" 时从标准输入读取
 func LoadConfig(configPath string) (*Config, error) {
 	if configPath == "" {
 		return nil, fmt.Errorf("config path is required")
 	}
+	if configPath == StdinConfigPath {
+		return LoadConfigFromReader(os.Stdin)
+	}
 
 	absPath, err := filepath.Abs(configPath)
 	if err != nil {
@@ -25,13 +85,49 @@ func LoadConfig(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
+	return parseConfig(data)
+}
+
+// LoadConfigFromReader 从 io.Reader 读取YAML配置，校验和后处理与配置文件相同
+func LoadConfigFromReader(r io.Reader) (*Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, fmt.Errorf("config is empty")
+	}
+	return parseConfig(data)
+}
+
+// parseConfig 解析YAML配置并校验、填充默认值
+func parseConfig(data []byte) (*Config, error) {
 	var config Config
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
-	if err := validateConfig(&config); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+	if err := FinalizeConfig(&config); err != nil {
+		return nil, err
+	}
+
+	return &config, nil
+}
+
+// FinalizeConfig 校验配置并填充默认值，用于不经配置文件在内存中构造的配置
+func FinalizeConfig(config *Config) error {
+	// 未配置SSH用户、认证方式和端口的主机继承全局默认值，之后各模块看到的主机配置都是完整的
+	if err := applyHostDefaults(config); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+
+	// 先合并主机组的默认配置，合并后的标签、污点和LVM配置与主机自身的配置一起校验
+	if err := applyHostGroups(config); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
+	}
+
+	if err := validateConfig(config); err != nil {
+		return fmt.Errorf("config validation failed: %w", err)
 	}
 
 	// 后处理配置：自动从hosts中设置gateway和chaos节点
@@ -39,6 +135,9 @@ func LoadConfig(configPath string) (*Config, error) {
 	
 	// 设置默认的Component配置
 	config.SetDefaultComponentConfig()
+
+	// 合并组件副本数和反亲和配置
+	config.ApplyComponentScheduling()
 	
 	// 设置默认的Rainbond配置
 	config.SetDefaultRainbondConfig()
@@ -46,7 +145,7 @@ func LoadConfig(configPath string) (*Config, error) {
 	// 设置默认的MySQL配置
 	config.SetDefaultMySQLConfig()
 
-	return &config, nil
+	return nil
 }
 
 // SaveConfig 保存配置到文件
@@ -132,12 +231,27 @@ func validateConfig(config *Config) error {
 		return fmt.Errorf("at least one host must be specified")
 	}
 
+	if config.ClusterName != "" {
+		if errs := validation.IsDNS1123Label(config.ClusterName); len(errs) > 0 {
+			return fmt.Errorf("invalid cluster_name '%s': %s", config.ClusterName, strings.Join(errs, "; "))
+		}
+	}
+
+	// 各阶段按主机IP索引并行处理的结果，IP重复会使多个并行任务写入同一条结果
+	seenIPs := make(map[string]int)
 	for i, host := range config.Hosts {
 		if host.IP == "" {
 			return fmt.Errorf("host[%d]: IP is required", i)
 		}
+		if j, ok := seenIPs[host.IP]; ok {
+			return fmt.Errorf("host[%d]: duplicate ip '%s', already used by host[%d]", i, host.IP, j)
+		}
+		seenIPs[host.IP] = i
+		if err := validateHostAddresses(host); err != nil {
+			return fmt.Errorf("host[%d]: %w", i, err)
+		}
 		if host.User == "" {
-			return fmt.Errorf("host[%d]: user is required", i)
+			return fmt.Errorf("host[%d]: user is required (set user or default_user)", i)
 		}
 		// 验证角色数组
 		if err := validateRoles(host.Role); err != nil {
@@ -147,11 +261,250 @@ func validateConfig(config *Config) error {
 		if err := validateRbdRoles(host.RbdRole); err != nil {
 			return fmt.Errorf("host[%d]: %w", i, err)
 		}
+		if err := validateRbdRoleHost(host); err != nil {
+			return fmt.Errorf("host[%d] %s: %w", i, host.IP, err)
+		}
 		if host.Password == "" && host.SSHKey == "" {
-			return fmt.Errorf("host[%d]: either password or ssh_key must be specified", i)
+			return fmt.Errorf("host[%d]: either password or ssh_key must be specified (or set default_ssh_key)", i)
+		}
+		if host.NodeName != "" {
+			if err := ValidateNodeName(host.NodeName); err != nil {
+				return fmt.Errorf("host[%d]: %w", i, err)
+			}
+		}
+		// 主从部署在同一节点会使两个StatefulSet绑定到同一节点，失去主从复制的意义
+		if host.MySQLMaster && host.MySQLSlave {
+			return fmt.Errorf("host[%d] %s: mysql_master and mysql_slave cannot both be set on the same host", i, host.IP)
+		}
+		if err := validateNodeMetadata(host); err != nil {
+			return fmt.Errorf("host[%d] %s: %w", i, host.IP, err)
+		}
+		if host.LVMConfig != nil {
+			if err := validateLVMConfig(host.LVMConfig); err != nil {
+				return fmt.Errorf("host[%d] %s: lvm_config: %w", i, host.IP, err)
+			}
+		}
+		if host.FlannelIface != "" && host.FlannelIface != FlannelIfaceAuto && !ifaceNamePattern.MatchString(host.FlannelIface) {
+			return fmt.Errorf("host[%d] %s: invalid flannel_iface '%s', must be an interface name or '%s'", i, host.IP, host.FlannelIface, FlannelIfaceAuto)
+		}
+	}
+
+	if err := validateNetwork(config); err != nil {
+		return err
+	}
+
+	if config.MySQL.DataPath != "" {
+		if err := ValidateMySQLDataPath(config.MySQL.DataPath); err != nil {
+			return err
+		}
+	}
+
+	if err := validateMySQLInitSQL(config.MySQL); err != nil {
+		return err
+	}
+	if err := validateMySQLScheduling(config.MySQL); err != nil {
+		return err
+	}
+	if err := validateMySQLStorage(config.MySQL); err != nil {
+		return err
+	}
+
+	switch config.RKE2.NodeNameStrategy {
+	case "", NodeNameStrategyIP, NodeNameStrategyHostname:
+	default:
+		return fmt.Errorf("invalid rke2.node_name_strategy '%s', must be one of: ip, hostname", config.RKE2.NodeNameStrategy)
+	}
+
+	if err := validateComponents(config); err != nil {
+		return err
+	}
+
+	if config.RKE2.ConfigTemplate != "" {
+		if _, err := os.Stat(config.RKE2.ConfigTemplate); err != nil {
+			return fmt.Errorf("rke2.config_template '%s' is not accessible: %w", config.RKE2.ConfigTemplate, err)
+		}
+	}
+
+	if err := validateEtcdSnapshot(config.RKE2.EtcdSnapshot); err != nil {
+		return err
+	}
+
+	if err := validateRKE2DataDir(config.RKE2.DataDir); err != nil {
+		return err
+	}
+
+	if err := validateServerJoin(config.RKE2); err != nil {
+		return err
+	}
+
+	if err := validateConfigDropIns(config.RKE2.ConfigDropIns); err != nil {
+		return err
+	}
+
+	if err := validateNodeTimezone(config.NodeTimezone); err != nil {
+		return err
+	}
+
+	if err := validateCheck(config.Check); err != nil {
+		return err
+	}
+
+	if err := validateRegistries(config.RKE2.Registries); err != nil {
+		return err
+	}
+
+	if err := validateDNS(config.DNS); err != nil {
+		return err
+	}
+
+	if err := validateImagePull(config.ImagePull); err != nil {
+		return err
+	}
+
+	if err := validateHooks(config.Hooks); err != nil {
+		return err
+	}
+
+	if config.ExistingCluster.Kubeconfig != "" {
+		if _, err := os.Stat(config.ExistingCluster.Kubeconfig); err != nil {
+			return fmt.Errorf("existing_cluster.kubeconfig '%s' is not accessible: %w", config.ExistingCluster.Kubeconfig, err)
+		}
+	}
+
+	if err := ValidateOptimizeProfile(config.Optimize.Profile); err != nil {
+		return err
+	}
+	for key, path := range map[string]string{
+		"optimize.sysctl_template": config.Optimize.SysctlTemplate,
+		"optimize.limits_template": config.Optimize.LimitsTemplate,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%s '%s' is not accessible: %w", key, path, err)
+		}
+	}
+
+	if err := validateImageRegistry(config.Rainbond.ImageRegistry); err != nil {
+		return err
+	}
+
+	for _, ip := range config.Rainbond.GatewayIngressIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("invalid rainbond.gateway_ingress_ips entry '%s': must be an IP address", ip)
 		}
 	}
 
+	if config.SSH.ConnectTimeout < 0 {
+		return fmt.Errorf("invalid ssh.connect_timeout %d, must not be negative", config.SSH.ConnectTimeout)
+	}
+	if config.SSH.CommandTimeout < 0 {
+		return fmt.Errorf("invalid ssh.command_timeout %d, must not be negative", config.SSH.CommandTimeout)
+	}
+
+	return nil
+}
+
+// validateImageRegistry 验证镜像仓库格式为 host[:port][/namespace]，不包含协议前缀
+func validateImageRegistry(registry string) error {
+	if registry == "" {
+		return nil
+	}
+	if strings.Contains(registry, "://") {
+		return fmt.Errorf("invalid rainbond.image_registry '%s': must not include a scheme, use host[:port][/namespace]", registry)
+	}
+	if strings.ContainsAny(registry, " \t") || strings.HasPrefix(registry, "/") || strings.HasSuffix(registry, "/") {
+		return fmt.Errorf("invalid rainbond.image_registry '%s': must be host[:port][/namespace]", registry)
+	}
+	return nil
+}
+
+// RegistryAddress 从镜像仓库地址中解析主机和端口，未指定端口时使用443
+func RegistryAddress(registry string) (string, string) {
+	address := strings.SplitN(registry, "/", 2)[0]
+	if host, port, err := net.SplitHostPort(address); err == nil {
+		return host, port
+	}
+	return address, "443"
+}
+
+// protectedDataPaths 不允许作为MySQL数据目录的系统路径
+var protectedDataPaths = map[string]bool{
+	"/": true, "/root": true, "/home": true, "/etc": true, "/usr": true, "/bin": true,
+	"/sbin": true, "/lib": true, "/lib64": true, "/boot": true, "/dev": true, "/proc": true,
+	"/sys": true, "/run": true, "/tmp": true, "/var": true, "/var/lib": true, "/opt": true,
+	"/mnt": true, "/srv": true,
+}
+
+// ValidateMySQLDataPath 验证MySQL数据目录为安全的绝对路径，防止误删系统目录
+func ValidateMySQLDataPath(path string) error {
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("mysql.data_path '%s' must be an absolute path", path)
+	}
+	cleaned := filepath.Clean(path)
+	if protectedDataPaths[cleaned] {
+		return fmt.Errorf("mysql.data_path '%s' is a protected system directory, use a dedicated directory such as /opt/rainbond/mysql", path)
+	}
+	if strings.ContainsAny(cleaned, " '\"$`;&|*?") {
+		return fmt.Errorf("mysql.data_path '%s' contains unsupported characters", path)
+	}
+	return nil
+}
+
+// ValidateNodeName 验证节点名称是否符合DNS-1123规范
+func ValidateNodeName(name string) error {
+	if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return fmt.Errorf("invalid node_name '%s': %s", name, strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ClusterNameLabel 标记节点和命名空间所属集群的标签
+const ClusterNameLabel = "rainbond.io/cluster-name"
+
+// ClusterLabels 返回标记集群名称的标签，未配置cluster_name时返回nil
+func (c *Config) ClusterLabels() map[string]string {
+	if c.ClusterName == "" {
+		return nil
+	}
+	return map[string]string{ClusterNameLabel: c.ClusterName}
+}
+
+// GetNodeName 获取主机对应的Kubernetes节点名称，未指定node_name时使用IP
+func (c *Config) GetNodeName(host Host) string {
+	if host.NodeName != "" {
+		return host.NodeName
+	}
+	return host.IP
+}
+
+// ApplyNodeNames 按IP设置主机的节点名称，并重新生成gateway/chaos节点引用
+// 设置后的节点名称（包括显式配置的node_name和作为默认名称的IP）不能重复，重复时不修改任何主机
+func (c *Config) ApplyNodeNames(nodeNames map[string]string) error {
+	names := make([]string, len(c.Hosts))
+	owners := make(map[string]int)
+	for i, host := range c.Hosts {
+		names[i] = c.GetNodeName(host)
+		if name, exists := nodeNames[host.IP]; exists && host.NodeName == "" {
+			if err := ValidateNodeName(name); err != nil {
+				return fmt.Errorf("host[%d]: %w", i, err)
+			}
+			names[i] = name
+		}
+		if j, ok := owners[names[i]]; ok {
+			return fmt.Errorf("host[%d] (%s): duplicate node name '%s', already used by host[%d] (%s)",
+				i, host.IP, names[i], j, c.Hosts[j].IP)
+		}
+		owners[names[i]] = i
+	}
+
+	for i := range c.Hosts {
+		if _, exists := nodeNames[c.Hosts[i].IP]; exists && c.Hosts[i].NodeName == "" {
+			c.Hosts[i].NodeName = names[i]
+		}
+	}
+	c.PostProcessConfig()
 	return nil
 }
 
@@ -249,7 +602,7 @@ func (c *Config) PostProcessConfig() {
 			}
 			
 			nodeInfo := map[string]interface{}{
-				"name":       host.IP, // 使用外网IP作为节点名称
+				"name":       c.GetNodeName(host), // 与RKE2节点名称保持一致
 				"externalIP": host.IP,
 				"internalIP": internalIP,
 			}
@@ -266,7 +619,7 @@ func (c *Config) PostProcessConfig() {
 		var nodesForChaos []map[string]interface{}
 		for _, host := range chaosHosts {
 			chaosNode := map[string]interface{}{
-				"name": host.IP, // 使用外网IP作为节点名称
+				"name": c.GetNodeName(host), // 与RKE2节点名称保持一致
 			}
 			nodesForChaos = append(nodesForChaos, chaosNode)
 		}
@@ -275,11 +628,37 @@ func (c *Config) PostProcessConfig() {
 		clusterMap["nodesForChaos"] = nodesForChaos
 	}
 	
-	// 设置gatewayIngressIPs（自动从第一个rbd-gateway节点获取）
-	if len(gatewayHosts) > 0 {
-		// 使用第一个gateway节点的IP作为gatewayIngressIPs
-		clusterMap["gatewayIngressIPs"] = gatewayHosts[0].IP
+	// 设置gatewayIngressIPs：rainbond.gateway_ingress_ips 优先，其次保留values中的显式配置，
+	// 否则使用所有rbd-gateway节点的IP，多个IP以逗号分隔
+	existingIngressIPs, _ := clusterMap["gatewayIngressIPs"].(string)
+	switch {
+	case len(c.Rainbond.GatewayIngressIPs) > 0:
+		clusterMap["gatewayIngressIPs"] = strings.Join(c.Rainbond.GatewayIngressIPs, ",")
+	case existingIngressIPs != "":
+		// 保留用户在values中的显式配置
+	case len(gatewayHosts) > 0:
+		var ingressIPs []string
+		for _, host := range gatewayHosts {
+			ingressIPs = append(ingressIPs, host.IP)
+		}
+		clusterMap["gatewayIngressIPs"] = strings.Join(ingressIPs, ",")
+	}
+}
+
+// GetGatewayIngressIPs 获取最终生效的网关入口IP列表，需在 PostProcessConfig 之后调用
+func (c *Config) GetGatewayIngressIPs() []string {
+	cluster, ok := c.Rainbond.Values["Cluster"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	value, _ := cluster["gatewayIngressIPs"].(string)
+	var ips []string
+	for _, ip := range strings.Split(value, ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			ips = append(ips, ip)
+		}
 	}
+	return ips
 }
 
 // SetDefaultComponentConfig 设置默认的组件配置
@@ -376,6 +755,84 @@ func (c *Config) IsMySQLEnabled() bool {
 	return false
 }
 
+// FlannelIfaceAuto flannel_iface取该值时使用internal_ip所在的网卡
+const FlannelIfaceAuto = "auto"
+
+// ifaceNamePattern Linux网卡名称，最长15个字符
+var ifaceNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.:@-]{1,15}$`)
+
+// HasWorkerNodes 检查是否存在纯worker节点（不包含master/etcd角色），没有时所有业务负载都运行在控制平面节点上
+func (c *Config) HasWorkerNodes() bool {
+	for _, host := range c.Hosts {
+		isWorker, isControlPlane := false, false
+		for _, role := range host.Role {
+			switch strings.ToLower(strings.TrimSpace(role)) {
+			case "worker":
+				isWorker = true
+			case "master", "etcd":
+				isControlPlane = true
+			}
+		}
+		if isWorker && !isControlPlane {
+			return true
+		}
+	}
+	return false
+}
+
+// GetEtcdMemberHosts 获取运行etcd的主机：etcd角色的节点，以及未配置etcd角色时作为第一个server的master节点
+func (c *Config) GetEtcdMemberHosts() []Host {
+	var members []Host
+	firstServer := true
+	for _, host := range c.Hosts {
+		hasEtcd, hasMaster := false, false
+		for _, role := range host.Role {
+			switch strings.TrimSpace(strings.ToLower(role)) {
+			case "etcd":
+				hasEtcd = true
+			case "master":
+				hasMaster = true
+			}
+		}
+		if !hasEtcd && !hasMaster {
+			continue
+		}
+		// 第一个server节点总是运行etcd，其余纯master节点禁用etcd
+		if hasEtcd || firstServer {
+			members = append(members, host)
+		}
+		firstServer = false
+	}
+	return members
+}
+
+// EtcdTopologyWarnings 检查etcd节点数量，偶数个或超过7个时返回警告（单节点开发集群是合法的）
+func (c *Config) EtcdTopologyWarnings() []string {
+	count := len(c.GetEtcdMemberHosts())
+	var warnings []string
+	if count > 0 && count%2 == 0 {
+		warnings = append(warnings, fmt.Sprintf("etcd节点数量为 %d（偶数），相比 %d 个节点不能提高容错能力且增加脑裂风险，建议使用奇数个etcd节点（1、3、5）", count, count-1))
+	}
+	if count > 7 {
+		warnings = append(warnings, fmt.Sprintf("etcd节点数量为 %d，超过7个会降低写入性能，建议使用3或5个etcd节点", count))
+	}
+	return warnings
+}
+
+// MySQLTopologyWarnings 检查MySQL主从是否会落在同一节点（IP相同或节点名称相同）
+func (c *Config) MySQLTopologyWarnings() []string {
+	var warnings []string
+	for _, master := range c.GetMySQLMasterHosts() {
+		for _, slave := range c.GetMySQLSlaveHosts() {
+			masterName, slaveName := c.GetNodeName(master), c.GetNodeName(slave)
+			if master.IP == slave.IP || masterName == slaveName {
+				warnings = append(warnings, fmt.Sprintf("MySQL主节点 %s 和从节点 %s 将部署到同一节点 %s，主从复制无法提供容灾能力", master.IP, slave.IP, masterName))
+			}
+		}
+	}
+	return warnings
+}
+
 // GetMySQLMasterHosts 获取MySQL主节点
 func (c *Config) GetMySQLMasterHosts() []Host {
 	var masters []Host
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// DefaultRKE2DataDir RKE2默认的数据目录
+const DefaultRKE2DataDir = "/var/lib/rancher/rke2"
+
+// RKE2DataDir 返回RKE2数据目录（etcd、镜像、containerd等），未配置rke2.data_dir时为默认目录
+func (c *Config) RKE2DataDir() string {
+	if c.RKE2.DataDir != "" {
+		return path.Clean(c.RKE2.DataDir)
+	}
+	return DefaultRKE2DataDir
+}
+
+// RKE2ImagesDir 返回RKE2启动时自动导入离线镜像的目录
+func (c *Config) RKE2ImagesDir() string {
+	return path.Join(c.RKE2DataDir(), "agent/images")
+}
+
+// RKE2BinDir 返回RKE2解压kubectl、ctr、crictl等工具的目录
+func (c *Config) RKE2BinDir() string {
+	return path.Join(c.RKE2DataDir(), "bin")
+}
+
+// RKE2ContainerdPath 返回RKE2内置containerd的实际数据目录，配置rke2.data_dir时位于其下
+func (c *Config) RKE2ContainerdPath() string {
+	return path.Join(c.RKE2DataDir(), "agent/containerd")
+}
+
+// ContainerdLV 返回主机上用于容器存储的逻辑卷（lv_containerd，或直接挂载到containerd数据目录的逻辑卷），未配置时返回nil
+func (c *Config) ContainerdLV(host Host) *LogicalVolume {
+	if host.LVMConfig == nil {
+		return nil
+	}
+	containerdDir := c.RKE2ContainerdPath()
+	for i, lv := range host.LVMConfig.LVs {
+		if lv.LVName == ContainerdLVName || path.Clean(LVMountPoint(lv)) == containerdDir {
+			return &host.LVMConfig.LVs[i]
+		}
+	}
+	return nil
+}
+
+// DataDirLV 返回主机上承载RKE2数据目录的逻辑卷（挂载点为数据目录或其上级目录中最近的一个），没有时返回nil
+func (c *Config) DataDirLV(host Host) *LogicalVolume {
+	if host.LVMConfig == nil {
+		return nil
+	}
+	dataDir := c.RKE2DataDir()
+	var found *LogicalVolume
+	longest := -1
+	for i, lv := range host.LVMConfig.LVs {
+		mountPoint := path.Clean(LVMountPoint(lv))
+		if mountPoint != dataDir && !strings.HasPrefix(dataDir, mountPoint+"/") {
+			continue
+		}
+		if len(mountPoint) > longest {
+			found, longest = &host.LVMConfig.LVs[i], len(mountPoint)
+		}
+	}
+	return found
+}
+
+// validateRKE2DataDir 验证rke2.data_dir为绝对路径，且不含会破坏远程命令和配置文件的字符
+func validateRKE2DataDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	if !path.IsAbs(dir) {
+		return fmt.Errorf("invalid rke2.data_dir '%s': must be an absolute path", dir)
+	}
+	if path.Clean(dir) == "/" {
+		return fmt.Errorf("invalid rke2.data_dir '%s': must not be the root directory", dir)
+	}
+	if strings.ContainsAny(dir, " \t\n'\"$`;&|") {
+		return fmt.Errorf("invalid rke2.data_dir '%s': must not contain whitespace, quotes or shell metacharacters", dir)
+	}
+	return nil
+}
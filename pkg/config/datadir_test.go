@@ -0,0 +1,58 @@
+package config
+
+import "testing"
+
+func TestContainerdLV(t *testing.T) {
+	tests := []struct {
+		name    string
+		dataDir string
+		lvs     []LogicalVolume
+		want    string
+	}{
+		{
+			name: "lv_containerd",
+			lvs:  []LogicalVolume{{LVName: "lv_data", MountPoint: "/data"}, {LVName: ContainerdLVName}},
+			want: ContainerdLVName,
+		},
+		{
+			name: "mounted on the default containerd directory",
+			lvs:  []LogicalVolume{{LVName: "lv_images", MountPoint: "/var/lib/rancher/rke2/agent/containerd"}},
+			want: "lv_images",
+		},
+		{
+			name:    "mounted on the containerd directory under data_dir",
+			dataDir: "/data/rke2",
+			lvs:     []LogicalVolume{{LVName: "lv_images", MountPoint: "/data/rke2/agent/containerd/"}},
+			want:    "lv_images",
+		},
+		{
+			name:    "default containerd directory with a custom data_dir",
+			dataDir: "/data/rke2",
+			lvs:     []LogicalVolume{{LVName: "lv_images", MountPoint: "/var/lib/rancher/rke2/agent/containerd"}},
+		},
+		{
+			name: "no containerd volume",
+			lvs:  []LogicalVolume{{LVName: "lv_data", MountPoint: "/data"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{RKE2: RKE2Config{DataDir: tt.dataDir}}
+			host := Host{IP: "10.0.0.1", LVMConfig: &LVMConfig{LVs: tt.lvs}}
+			got := cfg.ContainerdLV(host)
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("ContainerdLV() = %s, want nil", got.LVName)
+				}
+				return
+			}
+			if got == nil || got.LVName != tt.want {
+				t.Errorf("ContainerdLV() = %v, want %s", got, tt.want)
+			}
+		})
+	}
+
+	if lv := (&Config{}).ContainerdLV(Host{IP: "10.0.0.1"}); lv != nil {
+		t.Errorf("ContainerdLV() without lvm_config = %v, want nil", lv)
+	}
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"net"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxNameservers glibc只使用resolv.conf中的前3个nameserver
+const maxNameservers = 3
+
+// HasDNSConfig 是否配置了需要写入节点的hosts映射或DNS服务器
+func (c *Config) HasDNSConfig() bool {
+	return len(c.DNS.Hosts) > 0 || len(c.DNS.Nameservers) > 0
+}
+
+// validateDNS 验证dns配置：IP格式正确，主机名符合RFC 1123，nameserver不超过3个
+func validateDNS(dns DNSConfig) error {
+	for i, entry := range dns.Hosts {
+		if net.ParseIP(entry.IP) == nil {
+			return fmt.Errorf("invalid dns.hosts[%d].ip '%s': must be an IP address", i, entry.IP)
+		}
+		if len(entry.Hostnames) == 0 {
+			return fmt.Errorf("dns.hosts[%d] %s: hostnames is required", i, entry.IP)
+		}
+		for _, hostname := range entry.Hostnames {
+			if errs := validation.IsDNS1123Subdomain(hostname); len(errs) > 0 {
+				return fmt.Errorf("invalid dns.hosts[%d] hostname '%s': %s", i, hostname, errs[0])
+			}
+		}
+	}
+
+	if len(dns.Nameservers) > maxNameservers {
+		return fmt.Errorf("dns.nameservers has %d entries, at most %d are used by the resolver", len(dns.Nameservers), maxNameservers)
+	}
+	for _, nameserver := range dns.Nameservers {
+		if net.ParseIP(nameserver) == nil {
+			return fmt.Errorf("invalid dns.nameservers entry '%s': must be an IP address", nameserver)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DropInPrefix 用户配置片段在 config.yaml.d 中的文件名前缀，排在 00-rbd.yaml 之后
+const DropInPrefix = "50-user-"
+
+var dropInNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
+// reservedDropInKeys 由roi生成且集群正常运行所依赖的RKE2配置项，用户配置片段不能覆盖
+var reservedDropInKeys = map[string]string{
+	"server":                     "",
+	"token":                      "",
+	"node-name":                  "use node_name or rke2.node_name_strategy",
+	"node-ip":                    "use internal_ip",
+	"node-external-ip":           "use ip",
+	"data-dir":                   "use rke2.data_dir",
+	"cluster-cidr":               "use rke2.cluster_cidr",
+	"service-cidr":               "use rke2.service_cidr",
+	"node-label":                 "use node_label on the host or host group",
+	"node-taint":                 "use node_taint on the host or host group",
+	"disable":                    "use disable+ to append to the list",
+	"disable-etcd":               "controlled by host roles",
+	"disable-apiserver":          "controlled by host roles",
+	"disable-controller-manager": "controlled by host roles",
+	"disable-scheduler":          "controlled by host roles",
+}
+
+// FileName 返回配置片段在节点 config.yaml.d 目录中的文件名
+func (d ConfigDropIn) FileName() string {
+	return DropInPrefix + d.Name + ".yaml"
+}
+
+// Load 读取配置片段内容，file优先于content
+func (d ConfigDropIn) Load() ([]byte, error) {
+	if d.File != "" {
+		data, err := os.ReadFile(d.File)
+		if err != nil {
+			return nil, fmt.Errorf("读取RKE2配置片段 %s 失败: %w", d.File, err)
+		}
+		return data, nil
+	}
+	return []byte(d.Content), nil
+}
+
+// validateConfigDropIns 验证用户配置片段：名称唯一且可作为文件名，file和content二选一，内容为YAML映射且不覆盖roi管理的配置项
+func validateConfigDropIns(dropIns []ConfigDropIn) error {
+	names := make(map[string]bool)
+	for i, dropIn := range dropIns {
+		field := fmt.Sprintf("rke2.config_drop_ins[%d]", i)
+		if !dropInNamePattern.MatchString(dropIn.Name) {
+			return fmt.Errorf("invalid %s name '%s': must consist of lowercase letters, digits and '-'", field, dropIn.Name)
+		}
+		if names[dropIn.Name] {
+			return fmt.Errorf("invalid %s name '%s': duplicate name", field, dropIn.Name)
+		}
+		names[dropIn.Name] = true
+
+		if (dropIn.File == "") == (dropIn.Content == "") {
+			return fmt.Errorf("invalid %s '%s': exactly one of file or content must be set", field, dropIn.Name)
+		}
+		data, err := dropIn.Load()
+		if err != nil {
+			return fmt.Errorf("%s '%s' is not accessible: %w", field, dropIn.Name, err)
+		}
+
+		var values map[string]interface{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return fmt.Errorf("invalid %s '%s': must be a YAML mapping of RKE2 options: %w", field, dropIn.Name, err)
+		}
+		if len(values) == 0 {
+			return fmt.Errorf("invalid %s '%s': must be a non-empty YAML mapping of RKE2 options", field, dropIn.Name)
+		}
+		for key := range values {
+			// disable+ 追加到roi禁用的组件列表，不会覆盖
+			if key == "disable+" {
+				continue
+			}
+			hint, reserved := reservedDropInKeys[strings.TrimSuffix(key, "+")]
+			if !reserved {
+				continue
+			}
+			if hint != "" {
+				return fmt.Errorf("invalid %s '%s': option '%s' is managed by roi (%s)", field, dropIn.Name, key, hint)
+			}
+			return fmt.Errorf("invalid %s '%s': option '%s' is managed by roi", field, dropIn.Name, key)
+		}
+	}
+	return nil
+}
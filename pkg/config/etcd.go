@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cronDescriptors RKE2（robfig/cron）支持的预定义调度表达式
+var cronDescriptors = map[string]bool{
+	"@yearly": true, "@annually": true, "@monthly": true, "@weekly": true,
+	"@daily": true, "@midnight": true, "@hourly": true,
+}
+
+// validateEtcdSnapshot 验证etcd定时快照配置，cron表达式需为5个字段或预定义表达式
+func validateEtcdSnapshot(snapshot EtcdSnapshotConfig) error {
+	if cron := strings.TrimSpace(snapshot.ScheduleCron); cron != "" {
+		if strings.HasPrefix(cron, "@") {
+			if !cronDescriptors[cron] && !strings.HasPrefix(cron, "@every ") {
+				return fmt.Errorf("invalid rke2.etcd_snapshot.schedule_cron '%s': unknown descriptor", snapshot.ScheduleCron)
+			}
+		} else if fields := strings.Fields(cron); len(fields) != 5 {
+			return fmt.Errorf("invalid rke2.etcd_snapshot.schedule_cron '%s': expected 5 fields (minute hour day month weekday), got %d",
+				snapshot.ScheduleCron, len(fields))
+		}
+		if strings.ContainsAny(cron, "\"\n") {
+			return fmt.Errorf("invalid rke2.etcd_snapshot.schedule_cron '%s': must not contain quotes or newlines", snapshot.ScheduleCron)
+		}
+	}
+	if snapshot.Retention < 0 {
+		return fmt.Errorf("invalid rke2.etcd_snapshot.retention %d: must not be negative", snapshot.Retention)
+	}
+	return nil
+}
+
+// DefaultEtcdHealthTimeout 等待etcd集群健康的默认超时时间（秒）
+const DefaultEtcdHealthTimeout = 300
+
+// EtcdHealthTimeout 获取等待etcd集群健康的超时时间
+func (c *Config) EtcdHealthTimeout() time.Duration {
+	if c.RKE2.EtcdHealthTimeout > 0 {
+		return time.Duration(c.RKE2.EtcdHealthTimeout) * time.Second
+	}
+	return DefaultEtcdHealthTimeout * time.Second
+}
+
+// ServerJoinDelay 获取server节点之间加入的稳定等待时间
+func (c *Config) ServerJoinDelay() time.Duration {
+	return time.Duration(c.RKE2.ServerJoinDelay) * time.Second
+}
+
+// validateServerJoin 验证server节点加入的等待时间配置
+func validateServerJoin(rke2 RKE2Config) error {
+	if rke2.ServerJoinDelay < 0 {
+		return fmt.Errorf("invalid rke2.server_join_delay %d: must not be negative", rke2.ServerJoinDelay)
+	}
+	if rke2.EtcdHealthTimeout < 0 {
+		return fmt.Errorf("invalid rke2.etcd_health_timeout %d: must not be negative", rke2.EtcdHealthTimeout)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultHookTimeout 未配置timeout时单个钩子命令或Webhook请求的超时
+const DefaultHookTimeout = 5 * time.Minute
+
+// IsEmpty 未配置任何命令和Webhook
+func (h HookConfig) IsEmpty() bool {
+	return len(h.Commands) == 0 && h.Webhook == ""
+}
+
+// TimeoutDuration 返回单个钩子的超时，未配置时使用默认值
+func (h HookConfig) TimeoutDuration() time.Duration {
+	if d, err := time.ParseDuration(h.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return DefaultHookTimeout
+}
+
+// validateHook 验证钩子的命令、Webhook地址和超时
+func validateHook(name string, hook HookConfig) error {
+	for i, command := range hook.Commands {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("hooks.%s.commands[%d] must not be empty", name, i)
+		}
+	}
+	if hook.Webhook != "" {
+		u, err := url.Parse(hook.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid hooks.%s.webhook '%s': must be an http:// or https:// URL", name, hook.Webhook)
+		}
+	}
+	if hook.Timeout != "" {
+		d, err := time.ParseDuration(hook.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid hooks.%s.timeout '%s': %w", name, hook.Timeout, err)
+		}
+		if d <= 0 {
+			return fmt.Errorf("invalid hooks.%s.timeout '%s': must be positive", name, hook.Timeout)
+		}
+	}
+	return nil
+}
+
+// validateHooks 验证所有钩子配置
+func validateHooks(hooks HooksConfig) error {
+	if err := validateHook("post_install", hooks.PostInstall); err != nil {
+		return err
+	}
+	return validateHook("on_failure", hooks.OnFailure)
+}
@@ -0,0 +1,43 @@
+package config
+
+import "fmt"
+
+// DefaultSSHPort 未配置port和default_port时使用的SSH端口
+const DefaultSSHPort = 22
+
+// SSHPort 获取主机的SSH端口，未配置时为22
+func (h Host) SSHPort() int {
+	if h.Port == 0 {
+		return DefaultSSHPort
+	}
+	return h.Port
+}
+
+// applyHostDefaults 将 default_user/default_ssh_key/default_port 填充到未配置对应字段的主机，主机上的配置优先
+// 主机配置了password或ssh_key时视为已指定认证方式，不再使用default_ssh_key
+func applyHostDefaults(config *Config) error {
+	if config.DefaultPort < 0 || config.DefaultPort > 65535 {
+		return fmt.Errorf("invalid default_port %d, must be between 1 and 65535", config.DefaultPort)
+	}
+	port := config.DefaultPort
+	if port == 0 {
+		port = DefaultSSHPort
+	}
+
+	for i := range config.Hosts {
+		host := &config.Hosts[i]
+		if host.User == "" {
+			host.User = config.DefaultUser
+		}
+		if host.Password == "" && host.SSHKey == "" {
+			host.SSHKey = config.DefaultSSHKey
+		}
+		if host.Port < 0 || host.Port > 65535 {
+			return fmt.Errorf("host[%d] %s: invalid port %d, must be between 1 and 65535", i, host.IP, host.Port)
+		}
+		if host.Port == 0 {
+			host.Port = port
+		}
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// applyHostGroups 校验主机引用的组并将组的默认配置合并到主机：
+// 标签按键合并、污点按 key:effect 合并，主机上的配置优先；主机未配置lvm_config时使用组的配置
+func applyHostGroups(config *Config) error {
+	for name, group := range config.HostGroups {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("host_groups: group name is required")
+		}
+		if err := validateNodeMetadata(Host{NodeLabel: group.NodeLabel, NodeTaint: group.NodeTaint}); err != nil {
+			return fmt.Errorf("host_groups.%s: %w", name, err)
+		}
+	}
+
+	for i := range config.Hosts {
+		host := &config.Hosts[i]
+		if host.Group == "" {
+			continue
+		}
+		group, ok := config.HostGroups[host.Group]
+		if !ok {
+			return fmt.Errorf("host[%d] %s: unknown group '%s', must be defined in host_groups", i, host.IP, host.Group)
+		}
+		host.NodeLabel = mergeNodeLabels(group.NodeLabel, host.NodeLabel)
+		host.NodeTaint = mergeNodeTaints(group.NodeTaint, host.NodeTaint)
+		if host.LVMConfig == nil && group.LVMConfig != nil {
+			lvmConfig := *group.LVMConfig
+			lvmConfig.PVDevices = append([]string(nil), group.LVMConfig.PVDevices...)
+			lvmConfig.LVs = append([]LogicalVolume(nil), group.LVMConfig.LVs...)
+			host.LVMConfig = &lvmConfig
+		}
+	}
+	return nil
+}
+
+// mergeNodeLabels 合并组和主机的标签，相同键时主机的值覆盖组的值
+func mergeNodeLabels(groupLabels, hostLabels []string) []string {
+	return mergeByKey(groupLabels, hostLabels, func(label string) string {
+		key, _, _ := strings.Cut(strings.TrimSpace(label), "=")
+		return key
+	})
+}
+
+// mergeNodeTaints 合并组和主机的污点，相同 key:effect 时主机的污点覆盖组的污点
+func mergeNodeTaints(groupTaints, hostTaints []string) []string {
+	return mergeByKey(groupTaints, hostTaints, func(taint string) string {
+		key, _, effect, err := ParseNodeTaint(taint)
+		if err != nil {
+			return taint
+		}
+		return key + ":" + effect
+	})
+}
+
+// mergeByKey 按键合并两个列表，保持组条目在前的顺序，overrides 中的条目替换相同键的条目
+func mergeByKey(base, overrides []string, keyOf func(string) string) []string {
+	if len(base) == 0 {
+		return overrides
+	}
+	var merged []string
+	index := make(map[string]int)
+	for _, list := range [][]string{base, overrides} {
+		for _, item := range list {
+			key := keyOf(item)
+			if i, ok := index[key]; ok {
+				merged[i] = item
+				continue
+			}
+			index[key] = len(merged)
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
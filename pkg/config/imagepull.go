@@ -0,0 +1,56 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// 镜像拉取策略
+const (
+	PullPolicyAlways       = "Always"
+	PullPolicyIfNotPresent = "IfNotPresent"
+	PullPolicyNever        = "Never"
+)
+
+// HasPullSecret 是否为MySQL和Rainbond配置了 imagePullSecrets
+func (p ImagePullConfig) HasPullSecret() bool {
+	return p.SecretName != ""
+}
+
+// CreatesPullSecret 提供了仓库凭据时由roi创建或更新Secret，否则要求Secret已存在
+func (p ImagePullConfig) CreatesPullSecret() bool {
+	return p.SecretName != "" && p.Username != ""
+}
+
+// validateImagePull 验证镜像拉取策略和imagePullSecret配置
+func validateImagePull(pull ImagePullConfig) error {
+	switch pull.Policy {
+	case "", PullPolicyAlways, PullPolicyIfNotPresent, PullPolicyNever:
+	default:
+		return fmt.Errorf("invalid image_pull.policy '%s', must be one of: %s, %s, %s",
+			pull.Policy, PullPolicyAlways, PullPolicyIfNotPresent, PullPolicyNever)
+	}
+
+	if pull.SecretName != "" {
+		if errs := validation.IsDNS1123Subdomain(pull.SecretName); len(errs) > 0 {
+			return fmt.Errorf("invalid image_pull.secret_name '%s': %s", pull.SecretName, strings.Join(errs, "; "))
+		}
+	}
+	if (pull.Username == "") != (pull.Password == "") {
+		return fmt.Errorf("image_pull: username and password must be set together")
+	}
+	if pull.Username != "" {
+		if pull.SecretName == "" {
+			return fmt.Errorf("image_pull: secret_name is required when username and password are set")
+		}
+		if pull.Registry == "" {
+			return fmt.Errorf("image_pull: registry is required when username and password are set")
+		}
+		if strings.Contains(pull.Registry, "://") || strings.ContainsAny(pull.Registry, "/ \t") {
+			return fmt.Errorf("invalid image_pull.registry '%s': must be host[:port] without scheme or path", pull.Registry)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	// lintLargeClusterHosts 节点数达到该值且没有专用worker节点时提示分离控制平面和业务负载
+	lintLargeClusterHosts = 5
+	// lintGatewayAllHosts 节点数达到该值且所有节点都分配了rbd-gateway时提示收敛网关节点
+	lintGatewayAllHosts = 3
+)
+
+// LintWarnings 检查合法但不推荐的配置（角色和rbd_role的常见误用），只返回附带原因的提示信息，不影响安装
+func (c *Config) LintWarnings() []string {
+	var warnings []string
+
+	if len(c.Hosts) >= lintLargeClusterHosts && !c.HasWorkerNodes() {
+		warnings = append(warnings, fmt.Sprintf("集群共 %d 个节点且全部为etcd/master节点，没有专用worker节点：业务负载与etcd、kube-apiserver争用资源，etcd写延迟升高时整个集群都会受影响，建议将多出的节点配置为worker",
+			len(c.Hosts)))
+	}
+
+	serverCount := 0
+	for _, host := range c.Hosts {
+		for _, role := range normalizedRoleSet(host.Role) {
+			if role == "etcd" || role == "master" {
+				serverCount++
+				break
+			}
+		}
+	}
+	if etcdMembers := c.GetEtcdMemberHosts(); len(etcdMembers) == 1 && serverCount > 1 {
+		warnings = append(warnings, fmt.Sprintf("配置了 %d 个server节点但只有1个etcd成员（%s）：该节点故障时整个控制平面不可用，多个master并不能提供高可用，建议使用3个etcd节点",
+			serverCount, etcdMembers[0].IP))
+	}
+
+	if gateways := c.GetRbdGatewayHosts(); len(c.Hosts) >= lintGatewayAllHosts && len(gateways) == len(c.Hosts) {
+		warnings = append(warnings, fmt.Sprintf("所有 %d 个节点都分配了rbd-gateway：每个节点都占用网关端口并对外暴露入口，控制平面节点也会承接网关流量，建议只在2-3个专用节点上部署网关",
+			len(c.Hosts)))
+	}
+
+	for i, host := range c.Hosts {
+		if host.MySQLMaster && isEtcdOnlyHost(host) {
+			warnings = append(warnings, fmt.Sprintf("host[%d] %s: MySQL主节点部署在纯etcd节点上，数据库写入与etcd争用磁盘IO，会放大etcd的fsync延迟，建议将mysql_master分配到worker节点",
+				i, host.IP))
+		}
+	}
+
+	warnings = append(warnings, c.lvmConsistencyWarnings()...)
+	return warnings
+}
+
+// lvmConsistencyWarnings 检查相同角色的主机是否都配置了LVM，部分配置通常是遗漏，会导致同类节点的存储布局不一致
+func (c *Config) lvmConsistencyWarnings() []string {
+	groups := make(map[string][]Host)
+	var keys []string
+	for _, host := range c.Hosts {
+		key := strings.Join(normalizedRoleSet(host.Role), ",")
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], host)
+	}
+
+	var warnings []string
+	for _, key := range keys {
+		var with, without []string
+		for _, host := range groups[key] {
+			if host.LVMConfig != nil && len(host.LVMConfig.PVDevices) > 0 {
+				with = append(with, host.IP)
+			} else {
+				without = append(without, host.IP)
+			}
+		}
+		if len(with) > 0 && len(without) > 0 {
+			warnings = append(warnings, fmt.Sprintf("角色为 %s 的节点中 %s 配置了LVM，而 %s 没有配置：同类节点的容器和数据目录将位于不同的存储上，容量和IO表现不一致，建议统一配置（可使用host_groups共享）",
+				key, strings.Join(with, ", "), strings.Join(without, ", ")))
+		}
+	}
+	return warnings
+}
+
+// normalizedRoleSet 返回去重、排序后的小写角色列表
+func normalizedRoleSet(roles []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, role := range roles {
+		role = strings.ToLower(strings.TrimSpace(role))
+		if role == "" || seen[role] {
+			continue
+		}
+		seen[role] = true
+		result = append(result, role)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// isEtcdOnlyHost 检查主机是否只有etcd角色
+func isEtcdOnlyHost(host Host) bool {
+	roles := normalizedRoleSet(host.Role)
+	return len(roles) == 1 && roles[0] == "etcd"
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lvmNamePattern LVM卷组和逻辑卷名称允许的字符
+var lvmNamePattern = regexp.MustCompile(`^[a-zA-Z0-9+_.-]+$`)
+
+// lvSizePattern lvcreate -L 接受的大小格式：数字加可选单位，单位后允许附带 B/iB（如 20GB、20GiB）
+var lvSizePattern = regexp.MustCompile(`^(\d+(?:\.\d+)?)\s*(?:([bBsSkKmMgGtTpPeE])(?:i?[bB])?)?$`)
+
+// lvReservedPrefixes/lvReservedSubstrings LVM内部使用、不允许出现在逻辑卷名称中的前缀和片段
+var (
+	lvReservedPrefixes   = []string{"snapshot", "pvmove"}
+	lvReservedSubstrings = []string{"_cdata", "_cmeta", "_corig", "_mlog", "_mimage", "_pmspare", "_rimage", "_rmeta", "_tdata", "_tmeta", "_vorigin", "_vdata"}
+)
+
+// DefaultLVMountOptions 逻辑卷默认挂载选项：nofail 避免磁盘缺失时主机启动卡在挂载阶段，noatime 减少容器存储的元数据写入
+const DefaultLVMountOptions = "defaults,nofail,noatime"
+
+// xfsMountOptions 逻辑卷（XFS）允许的挂载选项：通用选项和XFS专用选项，值为true的选项需要带 =值
+var xfsMountOptions = map[string]bool{
+	"defaults": false, "rw": false, "ro": false, "auto": false, "noauto": false, "nofail": false, "_netdev": false,
+	"atime": false, "noatime": false, "relatime": false, "norelatime": false, "strictatime": false, "lazytime": false,
+	"diratime": false, "nodiratime": false, "exec": false, "noexec": false, "suid": false, "nosuid": false,
+	"dev": false, "nodev": false, "sync": false, "async": false, "context": true,
+	"allocsize": true, "attr2": false, "noattr2": false, "discard": false, "nodiscard": false,
+	"grpid": false, "bsdgroups": false, "nogrpid": false, "sysvgroups": false, "filestreams": false,
+	"ikeep": false, "noikeep": false, "inode32": false, "inode64": false, "largeio": false, "nolargeio": false,
+	"logbufs": true, "logbsize": true, "logdev": true, "rtdev": true, "noalign": false, "norecovery": false, "nouuid": false,
+	"noquota": false, "uquota": false, "usrquota": false, "quota": false, "uqnoenforce": false, "qnoenforce": false,
+	"gquota": false, "grpquota": false, "gqnoenforce": false, "pquota": false, "prjquota": false, "pqnoenforce": false,
+	"sunit": true, "swidth": true, "swalloc": false, "wsync": false,
+}
+
+// LVMountOptions 获取逻辑卷的挂载选项，未配置mount_options时使用默认选项
+func LVMountOptions(lv LogicalVolume) string {
+	if lv.MountOptions != "" {
+		return lv.MountOptions
+	}
+	return DefaultLVMountOptions
+}
+
+// normalizeMountOptions 去除挂载选项中的空格并验证每个选项适用于XFS，x-systemd.* 等 x- 前缀选项直接放行
+func normalizeMountOptions(options string) (string, error) {
+	var result []string
+	for _, option := range strings.Split(options, ",") {
+		option = strings.TrimSpace(option)
+		if option == "" {
+			return "", fmt.Errorf("invalid mount_options '%s': empty option", options)
+		}
+		if strings.HasPrefix(option, "x-") {
+			result = append(result, option)
+			continue
+		}
+		name, value, hasValue := strings.Cut(option, "=")
+		needsValue, known := xfsMountOptions[name]
+		switch {
+		case !known:
+			return "", fmt.Errorf("invalid mount_options '%s': '%s' is not a valid option for xfs", options, name)
+		case needsValue && (!hasValue || value == ""):
+			return "", fmt.Errorf("invalid mount_options '%s': '%s' requires a value (%s=...)", options, name, name)
+		case !needsValue && hasValue:
+			return "", fmt.Errorf("invalid mount_options '%s': '%s' does not take a value", options, name)
+		}
+		result = append(result, option)
+	}
+	return strings.Join(result, ","), nil
+}
+
+// validateLVMConfig 验证主机的LVM配置并规范化逻辑卷大小，在加载配置时提前发现会导致lvcreate失败的问题
+func validateLVMConfig(lvm *LVMConfig) error {
+	if len(lvm.PVDevices) == 0 && len(lvm.LVs) == 0 {
+		return nil
+	}
+	if err := validateLVMName("vg_name", lvm.VGName); err != nil {
+		return err
+	}
+
+	lvNames := make(map[string]bool)
+	mountPoints := make(map[string]string)
+	for i := range lvm.LVs {
+		lv := &lvm.LVs[i]
+		if err := validateLVMName("lv_name", lv.LVName); err != nil {
+			return fmt.Errorf("lvs[%d]: %w", i, err)
+		}
+		for _, prefix := range lvReservedPrefixes {
+			if strings.HasPrefix(lv.LVName, prefix) {
+				return fmt.Errorf("lvs[%d]: lv_name '%s' must not start with reserved prefix '%s'", i, lv.LVName, prefix)
+			}
+		}
+		for _, reserved := range lvReservedSubstrings {
+			if strings.Contains(lv.LVName, reserved) {
+				return fmt.Errorf("lvs[%d]: lv_name '%s' must not contain reserved name '%s'", i, lv.LVName, reserved)
+			}
+		}
+		if lvNames[lv.LVName] {
+			return fmt.Errorf("lvs[%d]: duplicate lv_name '%s' in volume group '%s'", i, lv.LVName, lvm.VGName)
+		}
+		lvNames[lv.LVName] = true
+
+		size, err := NormalizeLVSize(lv.Size)
+		if err != nil {
+			return fmt.Errorf("lvs[%d] %s: %w", i, lv.LVName, err)
+		}
+		lv.Size = size
+
+		if lv.MountOptions != "" {
+			options, err := normalizeMountOptions(lv.MountOptions)
+			if err != nil {
+				return fmt.Errorf("lvs[%d] %s: %w", i, lv.LVName, err)
+			}
+			lv.MountOptions = options
+		}
+
+		mountPoint := LVMountPoint(*lv)
+		if other, exists := mountPoints[mountPoint]; exists {
+			return fmt.Errorf("lvs[%d] %s: mount point '%s' is already used by logical volume '%s'", i, lv.LVName, mountPoint, other)
+		}
+		mountPoints[mountPoint] = lv.LVName
+	}
+	return nil
+}
+
+// validateLVMName 验证卷组或逻辑卷名称符合LVM命名规则
+func validateLVMName(field, name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%s is required", field)
+	case len(name) > 127:
+		return fmt.Errorf("%s '%s' is too long, must be at most 127 characters", field, name)
+	case name == "." || name == "..":
+		return fmt.Errorf("%s '%s' is not allowed", field, name)
+	case strings.HasPrefix(name, "-"):
+		return fmt.Errorf("%s '%s' must not start with '-'", field, name)
+	case !lvmNamePattern.MatchString(name):
+		return fmt.Errorf("%s '%s' contains invalid characters, only a-z A-Z 0-9 + _ . - are allowed", field, name)
+	}
+	return nil
+}
+
+// NormalizeLVSize 将逻辑卷大小规范化为 lvcreate -L 接受的格式，如 20GB、20GiB、20gb 均转换为 20G
+// 未带单位时按lvcreate的默认单位MiB处理
+func NormalizeLVSize(size string) (string, error) {
+	trimmed := strings.TrimSpace(size)
+	if trimmed == "" {
+		return "", fmt.Errorf("size is required")
+	}
+	if strings.Contains(trimmed, "%") {
+		return "", fmt.Errorf("invalid size '%s': percentage sizes are not supported, use an absolute size such as 20G", size)
+	}
+
+	matches := lvSizePattern.FindStringSubmatch(trimmed)
+	if matches == nil {
+		return "", fmt.Errorf("invalid size '%s': expected a number with an optional unit B/S/K/M/G/T/P/E, such as 20G", size)
+	}
+	if value, err := strconv.ParseFloat(matches[1], 64); err != nil || value <= 0 {
+		return "", fmt.Errorf("invalid size '%s': must be greater than 0", size)
+	}
+	if matches[2] == "" {
+		return matches[1], nil
+	}
+	return matches[1] + strings.ToUpper(matches[2]), nil
+}
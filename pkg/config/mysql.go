@@ -0,0 +1,108 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// MySQL Pod调度方式
+const (
+	MySQLSchedulingNodeName     = "node_name"     // 通过nodeName直接绑定节点，绕过调度器
+	MySQLSchedulingNodeSelector = "node_selector" // 为目标节点添加标签，通过nodeSelector由调度器调度
+)
+
+// createStatementPattern 需要 IF NOT EXISTS 才能重复执行的建库、建用户、建表语句
+var createStatementPattern = regexp.MustCompile(`(?i)\bCREATE\s+(DATABASE|SCHEMA|USER|TABLE)\s+`)
+
+// ifNotExistsPattern 匹配紧跟在CREATE语句对象类型后的 IF NOT EXISTS
+var ifNotExistsPattern = regexp.MustCompile(`(?i)^IF\s+NOT\s+EXISTS\b`)
+
+// InitSQLScript 合并 mysql.init_sql 和 mysql.init_sql_file 中的额外初始化SQL，未配置时返回空字符串
+// 这些SQL在内置的console/region数据库创建之后执行，初始化Job重试或重复部署时会再次执行
+func (m MySQLConfig) InitSQLScript() (string, error) {
+	var parts []string
+	for i, statement := range m.InitSQL {
+		statement = strings.TrimSpace(statement)
+		if statement == "" || statement == ";" {
+			return "", fmt.Errorf("mysql.init_sql[%d] must not be empty", i)
+		}
+		if !strings.HasSuffix(statement, ";") {
+			statement += ";"
+		}
+		parts = append(parts, statement)
+	}
+
+	if m.InitSQLFile != "" {
+		content, err := os.ReadFile(m.InitSQLFile)
+		if err != nil {
+			return "", fmt.Errorf("mysql.init_sql_file '%s' is not readable: %w", m.InitSQLFile, err)
+		}
+		script := strings.TrimSpace(string(content))
+		if script == "" {
+			return "", fmt.Errorf("mysql.init_sql_file '%s' is empty", m.InitSQLFile)
+		}
+		if !strings.HasSuffix(script, ";") {
+			script += ";"
+		}
+		parts = append(parts, script)
+	}
+
+	return strings.Join(parts, "\n"), nil
+}
+
+// validateMySQLInitSQL 验证额外初始化SQL非空且建库、建用户、建表语句可重复执行
+func validateMySQLInitSQL(m MySQLConfig) error {
+	script, err := m.InitSQLScript()
+	if err != nil {
+		return err
+	}
+
+	for _, loc := range createStatementPattern.FindAllStringSubmatchIndex(script, -1) {
+		if !ifNotExistsPattern.MatchString(script[loc[1]:]) {
+			statement := strings.SplitN(script[loc[0]:], ";", 2)[0]
+			return fmt.Errorf("mysql init SQL statement '%s' is not idempotent: use CREATE %s IF NOT EXISTS",
+				strings.TrimSpace(statement), strings.ToUpper(script[loc[2]:loc[3]]))
+		}
+	}
+	return nil
+}
+
+// validateMySQLScheduling 验证MySQL Pod调度方式
+func validateMySQLScheduling(m MySQLConfig) error {
+	switch m.Scheduling {
+	case "", MySQLSchedulingNodeName, MySQLSchedulingNodeSelector:
+	default:
+		return fmt.Errorf("invalid mysql.scheduling '%s', must be one of: %s, %s",
+			m.Scheduling, MySQLSchedulingNodeName, MySQLSchedulingNodeSelector)
+	}
+	// nodeName绕过调度器，WaitForFirstConsumer模式的StorageClass无法为其绑定PVC
+	if m.StorageClass != "" && m.Scheduling == MySQLSchedulingNodeName {
+		return fmt.Errorf("mysql.storage_class requires mysql.scheduling '%s' (or leave it empty)", MySQLSchedulingNodeSelector)
+	}
+	return nil
+}
+
+// validateMySQLStorage 验证MySQL存储配置
+func validateMySQLStorage(m MySQLConfig) error {
+	if m.StorageClass != "" {
+		if errs := validation.IsDNS1123Subdomain(m.StorageClass); len(errs) > 0 {
+			return fmt.Errorf("invalid mysql.storage_class '%s': %s", m.StorageClass, strings.Join(errs, "; "))
+		}
+	}
+	if m.StorageSize != "" {
+		if _, err := resource.ParseQuantity(m.StorageSize); err != nil {
+			return fmt.Errorf("invalid mysql.storage_size '%s': %w", m.StorageSize, err)
+		}
+	}
+	return nil
+}
+
+// UsesNodeSelector MySQL Pod是否通过节点标签和nodeSelector调度，使用StorageClass时默认启用
+func (m MySQLConfig) UsesNodeSelector() bool {
+	return m.Scheduling == MySQLSchedulingNodeSelector || (m.Scheduling == "" && m.StorageClass != "")
+}
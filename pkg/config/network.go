@@ -0,0 +1,140 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// IsIPv6 判断地址是否为IPv6地址
+func IsIPv6(ip string) bool {
+	parsed := net.ParseIP(ip)
+	return parsed != nil && parsed.To4() == nil
+}
+
+// URLHost 返回URL、scp/rsync目标中使用的主机部分，IPv6地址加方括号
+func URLHost(host string) string {
+	if IsIPv6(host) {
+		return "[" + host + "]"
+	}
+	return host
+}
+
+// PrimaryNodeIP 返回主机写入node-ip的主地址：配置了internal_ip时使用internal_ip，否则使用ip
+func (h Host) PrimaryNodeIP() string {
+	if h.InternalIP != "" {
+		return h.InternalIP
+	}
+	return h.IP
+}
+
+// cidrFamilies 解析逗号分隔的CIDR列表（RKE2 cluster-cidr/service-cidr格式），返回按顺序出现的地址族
+func cidrFamilies(field, value string) ([]string, error) {
+	var families []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		ip, _, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s '%s': '%s' is not a CIDR", field, value, part)
+		}
+		family := "IPv4"
+		if ip.To4() == nil {
+			family = "IPv6"
+		}
+		for _, seen := range families {
+			if seen == family {
+				return nil, fmt.Errorf("invalid %s '%s': at most one %s range is allowed", field, value, family)
+			}
+		}
+		families = append(families, family)
+	}
+	return families, nil
+}
+
+// UsesIPv6 集群是否使用IPv6：任一主机地址为IPv6或集群网段包含IPv6，此时系统优化不禁用IPv6
+func (c *Config) UsesIPv6() bool {
+	for _, host := range c.Hosts {
+		if IsIPv6(host.IP) || IsIPv6(host.InternalIP) || host.DualStackIP != "" {
+			return true
+		}
+	}
+	for _, value := range []string{c.RKE2.ClusterCIDR, c.RKE2.ServiceCIDR} {
+		families, _ := cidrFamilies("", value)
+		for _, family := range families {
+			if family == "IPv6" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// validateHostAddresses 验证主机的ip、internal_ip和dual_stack_ip为合法的IPv4或IPv6地址
+func validateHostAddresses(host Host) error {
+	fields := []struct{ name, value string }{{"ip", host.IP}, {"internal_ip", host.InternalIP}, {"dual_stack_ip", host.DualStackIP}}
+	for _, field := range fields {
+		if field.value != "" && net.ParseIP(field.value) == nil {
+			return fmt.Errorf("invalid %s '%s': must be an IPv4 or IPv6 address", field.name, field.value)
+		}
+	}
+	if host.DualStackIP != "" && IsIPv6(host.DualStackIP) == IsIPv6(host.PrimaryNodeIP()) {
+		return fmt.Errorf("dual_stack_ip '%s' must be of the other address family than node IP '%s'", host.DualStackIP, host.PrimaryNodeIP())
+	}
+	return nil
+}
+
+// validateNetwork 验证集群网段与各主机地址族一致：
+// 双栈集群要求cluster_cidr和service_cidr都包含IPv4和IPv6网段、每个主机都配置dual_stack_ip，且主地址族与网段的第一个一致；
+// 纯IPv6集群要求显式配置IPv6网段（RKE2默认网段为IPv4）
+func validateNetwork(c *Config) error {
+	clusterFamilies, serviceFamilies := []string(nil), []string(nil)
+	var err error
+	if c.RKE2.ClusterCIDR != "" {
+		if clusterFamilies, err = cidrFamilies("rke2.cluster_cidr", c.RKE2.ClusterCIDR); err != nil {
+			return err
+		}
+	}
+	if c.RKE2.ServiceCIDR != "" {
+		if serviceFamilies, err = cidrFamilies("rke2.service_cidr", c.RKE2.ServiceCIDR); err != nil {
+			return err
+		}
+	}
+	if (len(clusterFamilies) == 2) != (len(serviceFamilies) == 2) {
+		return fmt.Errorf("rke2.cluster_cidr and rke2.service_cidr must both contain an IPv4 and an IPv6 range for dual-stack")
+	}
+	if len(clusterFamilies) > 0 && len(serviceFamilies) > 0 && clusterFamilies[0] != serviceFamilies[0] {
+		return fmt.Errorf("rke2.cluster_cidr and rke2.service_cidr must list the same address family first")
+	}
+	dualStack := len(clusterFamilies) == 2
+
+	primaryFamily := ""
+	for i, host := range c.Hosts {
+		family := "IPv4"
+		if IsIPv6(host.PrimaryNodeIP()) {
+			family = "IPv6"
+		}
+		if primaryFamily == "" {
+			primaryFamily = family
+		} else if family != primaryFamily {
+			return fmt.Errorf("host[%d] %s: node IP '%s' is %s but other hosts use %s, all hosts must use the same primary address family",
+				i, host.IP, host.PrimaryNodeIP(), family, primaryFamily)
+		}
+
+		if dualStack && host.DualStackIP == "" {
+			return fmt.Errorf("host[%d] %s: dual_stack_ip is required for dual-stack rke2.cluster_cidr", i, host.IP)
+		}
+		if !dualStack && host.DualStackIP != "" {
+			return fmt.Errorf("host[%d] %s: dual_stack_ip requires dual-stack rke2.cluster_cidr and rke2.service_cidr (IPv4 and IPv6 ranges)", i, host.IP)
+		}
+	}
+
+	if dualStack && clusterFamilies[0] != primaryFamily {
+		return fmt.Errorf("rke2.cluster_cidr lists %s first but node IPs are %s, the first range must match the node IP family", clusterFamilies[0], primaryFamily)
+	}
+	if !dualStack && primaryFamily == "IPv6" {
+		if len(clusterFamilies) == 0 || clusterFamilies[0] != "IPv6" || len(serviceFamilies) == 0 || serviceFamilies[0] != "IPv6" {
+			return fmt.Errorf("IPv6 node IPs require IPv6 rke2.cluster_cidr and rke2.service_cidr (e.g. fd42::/56 and fd43::/112)")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// taintEffects Kubernetes支持的污点效果
+var taintEffects = map[string]bool{
+	"NoSchedule":       true,
+	"PreferNoSchedule": true,
+	"NoExecute":        true,
+}
+
+// ParseNodeLabel 解析 node-label 条目 key=value
+func ParseNodeLabel(label string) (string, string, error) {
+	key, value, ok := strings.Cut(strings.TrimSpace(label), "=")
+	if !ok || key == "" {
+		return "", "", fmt.Errorf("invalid node-label '%s': must be key=value", label)
+	}
+	return key, value, nil
+}
+
+// ParseNodeTaint 解析 node-taint 条目 key=value:effect 或 key:effect
+func ParseNodeTaint(taint string) (key, value, effect string, err error) {
+	taint = strings.TrimSpace(taint)
+	idx := strings.LastIndex(taint, ":")
+	if idx < 0 {
+		return "", "", "", fmt.Errorf("invalid node-taint '%s': must be key=value:effect or key:effect", taint)
+	}
+	key, value, _ = strings.Cut(taint[:idx], "=")
+	effect = taint[idx+1:]
+	if key == "" {
+		return "", "", "", fmt.Errorf("invalid node-taint '%s': key is required", taint)
+	}
+	if !taintEffects[effect] {
+		return "", "", "", fmt.Errorf("invalid node-taint '%s': effect must be NoSchedule, PreferNoSchedule or NoExecute", taint)
+	}
+	return key, value, effect, nil
+}
+
+// validateNodeMetadata 检查主机的 node-label 和 node-taint 格式，安装后按这些配置校正节点的标签和污点
+func validateNodeMetadata(host Host) error {
+	for _, label := range host.NodeLabel {
+		if _, _, err := ParseNodeLabel(label); err != nil {
+			return err
+		}
+	}
+	for _, taint := range host.NodeTaint {
+		if _, _, _, err := ParseNodeTaint(taint); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// controlPlaneTaintKeys RKE2控制平面节点的标准污点，Rainbond网关和chaos组件可容忍
+var controlPlaneTaintKeys = map[string]bool{
+	"node-role.kubernetes.io/control-plane": true,
+	"node-role.kubernetes.io/master":        true,
+	"node-role.kubernetes.io/etcd":          true,
+}
+
+// validateRbdRoleHost 检查带有rbd_role的主机能否调度网关或chaos组件：
+// 用户配置的 NoSchedule/NoExecute 污点会使生成的 nodesForGateway/nodesForChaos 指向无法运行组件的节点
+func validateRbdRoleHost(host Host) error {
+	rbdRoles := normalizedRbdRoles(host)
+	if len(rbdRoles) == 0 {
+		return nil
+	}
+	for _, taint := range host.NodeTaint {
+		key, effect := splitTaint(taint)
+		if (effect == "NoSchedule" || effect == "NoExecute") && !controlPlaneTaintKeys[key] {
+			return fmt.Errorf("rbd_role %s requires a schedulable node, but node-taint '%s' prevents scheduling; remove the taint or assign rbd_role to another host",
+				strings.Join(rbdRoles, ", "), taint)
+		}
+	}
+	return nil
+}
+
+// RbdRoleWarnings 检查rbd-gateway/rbd-chaos的分配：分配在纯etcd节点上时与etcd争用资源，
+// 集群有worker节点时建议将网关和chaos放在worker节点上
+func (c *Config) RbdRoleWarnings() []string {
+	var warnings []string
+	for i, host := range c.Hosts {
+		rbdRoles := normalizedRbdRoles(host)
+		if len(rbdRoles) == 0 {
+			continue
+		}
+		isWorker, isMaster, isEtcd := false, false, false
+		for _, role := range host.Role {
+			switch strings.ToLower(strings.TrimSpace(role)) {
+			case "worker":
+				isWorker = true
+			case "master", "control":
+				isMaster = true
+			case "etcd":
+				isEtcd = true
+			}
+		}
+		if isEtcd && !isMaster && !isWorker {
+			warnings = append(warnings, fmt.Sprintf("host[%d] %s: %s 分配在纯etcd节点上，网关流量和构建任务会与etcd争用CPU和磁盘IO，建议分配到worker节点",
+				i, host.IP, strings.Join(rbdRoles, ", ")))
+		}
+	}
+	return warnings
+}
+
+// normalizedRbdRoles 返回主机上去除空项并转为小写的Rainbond角色
+func normalizedRbdRoles(host Host) []string {
+	var roles []string
+	for _, role := range host.RbdRole {
+		role = strings.TrimSpace(strings.ToLower(role))
+		if role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// splitTaint 将 key=value:effect 格式的污点拆分为键和效果
+func splitTaint(taint string) (string, string) {
+	taint = strings.TrimSpace(taint)
+	idx := strings.LastIndex(taint, ":")
+	if idx < 0 {
+		return taint, ""
+	}
+	key := taint[:idx]
+	if eq := strings.Index(key, "="); eq >= 0 {
+		key = key[:eq]
+	}
+	return key, taint[idx+1:]
+}
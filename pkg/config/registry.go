@@ -0,0 +1,90 @@
+package config
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// RegistryEndpoint 返回镜像仓库的实际访问地址，未配置endpoint时使用 https://<name>
+func (r RegistryConfig) RegistryEndpoint() string {
+	if r.Endpoint != "" {
+		return r.Endpoint
+	}
+	return "https://" + r.Name
+}
+
+// RegistryHost 返回访问地址中的 host[:port]，即registries.yaml中configs的键
+func (r RegistryConfig) RegistryHost() string {
+	if u, err := url.Parse(r.RegistryEndpoint()); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return r.Name
+}
+
+// validateRegistries 验证 rke2.registries 中每个镜像仓库的名称、访问地址、认证和CA证书配置
+func validateRegistries(registries []RegistryConfig) error {
+	seen := make(map[string]bool)
+	for i, registry := range registries {
+		if registry.Name == "" {
+			return fmt.Errorf("rke2.registries[%d]: name is required", i)
+		}
+		if strings.Contains(registry.Name, "://") || strings.ContainsAny(registry.Name, "/ \t") {
+			return fmt.Errorf("invalid rke2.registries[%d].name '%s': must be host[:port] without scheme or path", i, registry.Name)
+		}
+		if seen[registry.Name] {
+			return fmt.Errorf("duplicate rke2.registries name '%s'", registry.Name)
+		}
+		seen[registry.Name] = true
+
+		if registry.Endpoint != "" {
+			u, err := url.Parse(registry.Endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid rke2.registries[%d].endpoint '%s': must be http://host[:port] or https://host[:port]", i, registry.Endpoint)
+			}
+		}
+		if (registry.Username == "") != (registry.Password == "") {
+			return fmt.Errorf("rke2.registries[%d] %s: username and password must be set together", i, registry.Name)
+		}
+		if registry.CAFile != "" {
+			if err := validateCABundle(registry.CAFile); err != nil {
+				return fmt.Errorf("invalid rke2.registries[%d].ca_file '%s': %w", i, registry.CAFile, err)
+			}
+			if registry.Insecure {
+				return fmt.Errorf("rke2.registries[%d] %s: ca_file and insecure cannot be set together", i, registry.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateCABundle 验证CA证书文件可读，且包含至少一个可解析的PEM格式证书
+func validateCABundle(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("not accessible: %w", err)
+	}
+
+	count := 0
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return fmt.Errorf("certificate %d cannot be parsed: %w", count+1, err)
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("no PEM encoded certificate found")
+	}
+	return nil
+}
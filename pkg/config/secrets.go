@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// sensitiveValueKey rainbond.values 中按键名识别的敏感值
+var sensitiveValueKey = regexp.MustCompile(`(?i)(password|passwd|token|secret|accesskey|secretkey)`)
+
+// SecretValues 返回配置中所有敏感字段的非空值，用于在日志中脱敏：
+//   - hosts[].password、hosts[].become_password
+//   - image_pull.password
+//   - rke2.registries[].password
+//   - mysql.root_password、mysql.repl_password
+//   - rainbond.values 中键名包含 password/token/secret 等的值
+//
+// 新增包含凭据的配置字段时需要同步加入此列表
+func (c *Config) SecretValues() []string {
+	var values []string
+	for _, host := range c.Hosts {
+		values = append(values, host.Password, host.BecomePassword)
+	}
+	values = append(values, c.ImagePull.Password)
+	for _, registry := range c.RKE2.Registries {
+		values = append(values, registry.Password)
+	}
+	values = append(values, c.MySQL.RootPassword, c.MySQL.ReplPassword)
+	values = append(values, sensitiveMapValues(c.Rainbond.Values)...)
+
+	var result []string
+	for _, value := range values {
+		if value != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// sensitiveMapValues 递归收集键名属于敏感字段的标量值
+func sensitiveMapValues(values map[string]interface{}) []string {
+	var result []string
+	for key, value := range values {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			result = append(result, sensitiveMapValues(v)...)
+		case []interface{}:
+			for _, item := range v {
+				if m, ok := item.(map[string]interface{}); ok {
+					result = append(result, sensitiveMapValues(m)...)
+				} else if item != nil && sensitiveValueKey.MatchString(key) {
+					result = append(result, fmt.Sprint(item))
+				}
+			}
+		case nil:
+		default:
+			if sensitiveValueKey.MatchString(key) {
+				result = append(result, fmt.Sprint(v))
+			}
+		}
+	}
+	return result
+}
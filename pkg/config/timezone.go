@@ -0,0 +1,20 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// timezonePattern IANA时区名称，如 Asia/Shanghai、UTC、America/Argentina/Buenos_Aires
+var timezonePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_+-]*(/[A-Za-z0-9_+-]+)*$`)
+
+// validateNodeTimezone 校验node_timezone为IANA时区名称，是否存在由节点上的timedatectl确认
+func validateNodeTimezone(timezone string) error {
+	if timezone == "" {
+		return nil
+	}
+	if !timezonePattern.MatchString(timezone) {
+		return fmt.Errorf("invalid node_timezone '%s', must be an IANA time zone name such as Asia/Shanghai", timezone)
+	}
+	return nil
+}
@@ -1,25 +1,101 @@
 package config
 
+
 type Config struct {
-	Hosts    []Host         `yaml:"hosts"`
-	RKE2     RKE2Config     `yaml:"rke2,omitempty"`
-	Rainbond RainbondConfig `yaml:"rainbond,omitempty"`
-	MySQL    MySQLConfig    `yaml:"mysql,omitempty"`
+	ClusterName     string                `yaml:"cluster_name,omitempty"` // 集群名称，写入日志文件名、节点标签和命名空间标签，便于区分多个集群
+	Hosts           []Host                `yaml:"hosts"`
+	HostGroups      map[string]HostGroup  `yaml:"host_groups,omitempty"` // 主机组，组内的标签、污点和LVM配置合并到引用该组的主机
+	DefaultUser     string                `yaml:"default_user,omitempty"`    // 未配置user的主机使用的SSH用户
+	DefaultSSHKey   string                `yaml:"default_ssh_key,omitempty"` // 未配置password和ssh_key的主机使用的SSH私钥
+	DefaultPort     int                   `yaml:"default_port,omitempty"`    // 未配置port的主机使用的SSH端口，默认22
+	RKE2            RKE2Config            `yaml:"rke2,omitempty"`
+	Rainbond        RainbondConfig        `yaml:"rainbond,omitempty"`
+	MySQL           MySQLConfig           `yaml:"mysql,omitempty"`
+	LVM             LVMSettings           `yaml:"lvm,omitempty"`
+	SSH             SSHSettings           `yaml:"ssh,omitempty"`
+	Optimize        OptimizeConfig        `yaml:"optimize,omitempty"`
+	DNS             DNSConfig             `yaml:"dns,omitempty"`
+	ExistingCluster ExistingClusterConfig `yaml:"existing_cluster,omitempty"` // 安装到已有的Kubernetes集群，跳过RKE2安装
+	ImagePull       ImagePullConfig       `yaml:"image_pull,omitempty"`       // MySQL和Rainbond组件的镜像拉取策略和imagePullSecrets
+	Hooks           HooksConfig           `yaml:"hooks,omitempty"`            // 完整安装结束后执行的本地命令和Webhook
+	NodeTimezone    string                `yaml:"node_timezone,omitempty"`    // 统一设置的节点时区（IANA名称，如Asia/Shanghai），为空时只检查各节点时区是否一致
+	Check           CheckConfig           `yaml:"check,omitempty"`            // 系统检查的阈值
+}
+
+// CheckConfig 系统检查的可调阈值
+type CheckConfig struct {
+	MinFreeInodesPct int   `yaml:"min_free_inodes_percent,omitempty"` // 根分区和容器存储所在文件系统的最低空闲inode比例（%），默认10
+	GatewayPorts     []int `yaml:"gateway_ports,omitempty"`           // rbd-gateway节点上网关绑定的主机端口，需与chart一致，默认80、443、7070
+}
+
+// HooksConfig 完整安装（roi up）成功或失败时执行的钩子
+type HooksConfig struct {
+	PostInstall HookConfig `yaml:"post_install,omitempty"` // 安装成功、输出安装总结后执行
+	OnFailure   HookConfig `yaml:"on_failure,omitempty"`   // 任一阶段失败时执行，摘要中包含失败的阶段、主机和错误信息
+}
+
+// HookConfig 一组钩子：依次执行本地命令，再将安装摘要JSON POST到Webhook
+type HookConfig struct {
+	Commands    []string `yaml:"commands,omitempty"`      // 本地执行的命令（sh -c），标准输入为安装摘要JSON
+	Webhook     string   `yaml:"webhook,omitempty"`       // 接收安装摘要JSON的URL（http/https）
+	Timeout     string   `yaml:"timeout,omitempty"`       // 单个命令或Webhook请求的超时，默认5m
+	FailOnError bool     `yaml:"fail_on_error,omitempty"` // 钩子失败时roi以非零退出码结束，默认只报告失败
+}
+
+// ImagePullConfig MySQL和Rainbond组件的镜像拉取设置，离线环境中避免访问不可达的仓库或使用私有仓库凭据
+type ImagePullConfig struct {
+	Policy     string `yaml:"policy,omitempty"`      // imagePullPolicy：Always、IfNotPresent、Never，为空时使用Kubernetes默认值
+	SecretName string `yaml:"secret_name,omitempty"` // imagePullSecrets引用的Secret，未提供凭据时必须已存在于MySQL和Rainbond的命名空间
+	Registry   string `yaml:"registry,omitempty"`    // 创建Secret使用的仓库地址 host[:port]
+	Username   string `yaml:"username,omitempty"`    // 仓库用户名，与password同时设置时创建或更新Secret
+	Password   string `yaml:"password,omitempty"`
+}
+
+// ExistingClusterConfig 已有Kubernetes集群的连接配置，MySQL和Rainbond阶段使用该kubeconfig
+type ExistingClusterConfig struct {
+	Kubeconfig string `yaml:"kubeconfig,omitempty"` // 已有集群的kubeconfig路径，可通过 roi up --existing-cluster 指定
+}
+
+// DNSConfig 写入各节点的名称解析配置，用于离线环境中解析镜像仓库、网关等内部域名
+type DNSConfig struct {
+	Hosts       []HostsEntry `yaml:"hosts,omitempty"`       // 写入 /etc/hosts 的主机名映射
+	Nameservers []string     `yaml:"nameservers,omitempty"` // 写入 /etc/resolv.conf 的DNS服务器，优先于已有的nameserver
+}
+
+// HostsEntry /etc/hosts 中的一行：IP及其对应的主机名
+type HostsEntry struct {
+	IP        string   `yaml:"ip"`
+	Hostnames []string `yaml:"hostnames"`
 }
 
 type Host struct {
 	IP          string     `yaml:"ip"`                     // 外网IP，用于SSH连接和节点间通信
 	InternalIP  string     `yaml:"internal_ip,omitempty"`  // 内网IP（备用IP）
+	DualStackIP string     `yaml:"dual_stack_ip,omitempty"` // 双栈集群中另一地址族的节点地址，与internal_ip（或ip）一起写入node-ip
 	NodeName    string     `yaml:"node_name,omitempty"`    // 节点名称，如果不指定则自动生成
+	Group       string     `yaml:"group,omitempty"`        // 主机组名称（可选），引用 host_groups 中的组
 	User        string     `yaml:"user"`
 	Password    string     `yaml:"password,omitempty"`
 	SSHKey      string     `yaml:"ssh_key,omitempty"`
+	Port        int        `yaml:"port,omitempty"`         // SSH端口，未配置时使用default_port或22
 	Role        []string   `yaml:"role"`                   // Kubernetes角色：master, etcd, worker
 	RbdRole     []string   `yaml:"rbd_role,omitempty"`     // Rainbond角色：rbd-gateway, rbd-chaos
 	NodeTaint   []string   `yaml:"node-taint,omitempty"`   // 节点污点：key=value:effect
+	NodeLabel   []string   `yaml:"node-label,omitempty"`   // 节点标签：key=value，安装和加入节点后会重新应用到已注册的节点
 	MySQLMaster bool       `yaml:"mysql_master,omitempty"` // 是否为MySQL Master节点
 	MySQLSlave  bool       `yaml:"mysql_slave,omitempty"`  // 是否为MySQL Slave节点
 	LVMConfig   *LVMConfig `yaml:"lvm_config,omitempty"`
+	Become         bool   `yaml:"become,omitempty"`          // 是否通过sudo提权执行远程命令（非root用户登录时使用）
+	BecomeUser     string `yaml:"become_user,omitempty"`     // 提权目标用户，默认root
+	BecomePassword string `yaml:"become_password,omitempty"` // sudo密码，未设置时使用password，均为空时要求免密sudo
+	FlannelIface   string `yaml:"flannel_iface,omitempty"`   // 容器网络（canal/flannel）使用的网卡，auto表示使用internal_ip所在网卡
+}
+
+// HostGroup 主机组的默认配置，用于区分用途不同的worker节点池（如构建节点、运行节点）
+type HostGroup struct {
+	NodeLabel []string   `yaml:"node-label,omitempty"` // 组内节点的标签，主机上相同键的标签优先
+	NodeTaint []string   `yaml:"node-taint,omitempty"` // 组内节点的污点，与主机的污点合并，相同key:effect时主机优先
+	LVMConfig *LVMConfig `yaml:"lvm_config,omitempty"` // 组内主机未配置lvm_config时使用
 }
 
 type LVMConfig struct {
@@ -28,28 +104,94 @@ type LVMConfig struct {
 	LVs       []LogicalVolume `yaml:"lvs"`
 }
 
+// LVMSettings LVM阶段的全局设置，各主机的卷配置见 lvm_config
+type LVMSettings struct {
+	AutoInstallTools bool `yaml:"auto_install_tools,omitempty"` // 缺少lvm2时通过包管理器自动安装（需要软件源访问）
+}
+
+// SSHSettings 远程执行的全局设置，同时作用于exec和native两种SSH后端
+type SSHSettings struct {
+	ConnectTimeout int `yaml:"connect_timeout,omitempty"` // SSH连接超时（秒），默认10
+	CommandTimeout int `yaml:"command_timeout,omitempty"` // 单条远程命令超时（秒），不含文件传输，默认0表示不限制
+}
+
+// OptimizeConfig 系统优化配置
+type OptimizeConfig struct {
+	Profile        string `yaml:"profile,omitempty"`         // 调优档位：balanced（默认）、high-throughput、low-memory
+	SysctlTemplate string `yaml:"sysctl_template,omitempty"` // 自定义内核参数模板路径（Go text/template），替代内置模板
+	LimitsTemplate string `yaml:"limits_template,omitempty"` // 自定义资源限制模板路径（Go text/template），替代内置模板
+}
+
 type LogicalVolume struct {
-	LVName     string `yaml:"lv_name"`
-	Size       string `yaml:"size"`
-	MountPoint string `yaml:"mount_point"`
+	LVName       string `yaml:"lv_name"`
+	Size         string `yaml:"size"`
+	MountPoint   string `yaml:"mount_point"`
+	MountOptions string `yaml:"mount_options,omitempty"` // 挂载选项，写入fstab并用于挂载，默认 defaults,nofail,noatime
 }
 
 type RKE2Config struct {
-	RegistryConfig string `yaml:"registry_config,omitempty"` // containerd镜像仓库配置
+	RegistryConfig    string             `yaml:"registry_config,omitempty"`     // containerd镜像仓库配置
+	NodeNameStrategy  string             `yaml:"node_name_strategy,omitempty"`  // 节点名称策略：ip（默认）、hostname，主机的node_name优先
+	ConfigTemplate    string             `yaml:"config_template,omitempty"`     // 自定义RKE2主配置模板路径（Go text/template），替代内置模板
+	EtcdSnapshot      EtcdSnapshotConfig `yaml:"etcd_snapshot,omitempty"`       // etcd定时快照配置
+	Registries        []RegistryConfig   `yaml:"registries,omitempty"`          // 私有镜像仓库列表，与registry_config合并生成registries.yaml
+	ClusterCIDR       string             `yaml:"cluster_cidr,omitempty"`        // Pod网段，双栈时为 IPv4网段,IPv6网段，为空时使用RKE2默认值
+	ServiceCIDR       string             `yaml:"service_cidr,omitempty"`        // Service网段，格式同cluster_cidr
+	DataDir           string             `yaml:"data_dir,omitempty"`            // RKE2数据目录（etcd、镜像、containerd），为空时使用 /var/lib/rancher/rke2，建议使用独立挂载的逻辑卷
+	ServerJoinDelay   int                `yaml:"server_join_delay,omitempty"`   // 每个server节点通过etcd健康检查后，加入下一个server节点前的稳定等待时间（秒），默认0
+	EtcdHealthTimeout int                `yaml:"etcd_health_timeout,omitempty"` // 等待etcd集群健康且成员全部启动的超时时间（秒），默认300
+	ConfigDropIns     []ConfigDropIn     `yaml:"config_drop_ins,omitempty"`     // 用户自定义的RKE2配置片段，上传为 config.yaml.d/50-user-<name>.yaml
+}
+
+// RegistryConfig 单个私有镜像仓库的containerd配置
+type RegistryConfig struct {
+	Name     string `yaml:"name"`               // 镜像名称中的仓库地址，如 registry.example.com:5000
+	Endpoint string `yaml:"endpoint,omitempty"` // 实际访问地址，如 https://10.10.152.29:5000，默认 https://<name>
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	CAFile   string `yaml:"ca_file,omitempty"`  // 本地CA证书路径，安装时上传到各节点
+	Insecure bool   `yaml:"insecure,omitempty"` // 跳过TLS证书校验
+}
+
+// EtcdSnapshotConfig RKE2 etcd定时快照配置，写入etcd节点的 etcd-snapshot-schedule-cron/etcd-snapshot-retention
+type EtcdSnapshotConfig struct {
+	ScheduleCron string `yaml:"schedule_cron,omitempty"` // 定时快照的cron表达式，如 "0 */6 * * *"，为空时使用RKE2默认值（每12小时）
+	Retention    int    `yaml:"retention,omitempty"`     // 每个etcd节点保留的定时快照数量，为0时使用RKE2默认值（5）
 }
 
+// ConfigDropIn 用户自定义的RKE2配置片段（审计策略、额外参数等），file和content二选一
+type ConfigDropIn struct {
+	Name    string `yaml:"name"`              // 片段名称，决定节点上的文件名 50-user-<name>.yaml，只能包含小写字母、数字和-
+	File    string `yaml:"file,omitempty"`    // 本地YAML文件路径
+	Content string `yaml:"content,omitempty"` // 内联YAML内容
+}
 
 type RainbondConfig struct {
-	Version   string                 `yaml:"version,omitempty"`
-	Namespace string                 `yaml:"namespace,omitempty"`
-	Values    map[string]interface{} `yaml:"values,omitempty"`
+	Version           string                     `yaml:"version,omitempty"`
+	Namespace         string                     `yaml:"namespace,omitempty"`
+	ImageRegistry     string                     `yaml:"image_registry,omitempty"`      // 组件镜像仓库，如 registry.example.com:5000/rainbond，写入 Cluster.rainbondImageRepository
+	GatewayIngressIPs []string                   `yaml:"gateway_ingress_ips,omitempty"` // 网关入口IP（如keepalived VIP），默认使用所有rbd-gateway节点IP
+	Values            map[string]interface{}     `yaml:"values,omitempty"`
+	Components        map[string]ComponentConfig `yaml:"components,omitempty"` // 组件副本数和调度配置，键为组件名（api、gateway、worker等）
 }
 
+type ComponentConfig struct {
+	Replicas     int    `yaml:"replicas,omitempty"`      // 副本数，不能超过可调度节点数
+	AntiAffinity string `yaml:"anti_affinity,omitempty"` // 反亲和策略：none（默认）、preferred、required
+}
+
+
+
 type MySQLConfig struct {
-	Enabled      bool   `yaml:"enabled,omitempty"`       // 是否启用MySQL部署
-	RootPassword string `yaml:"root_password,omitempty"` // MySQL root密码
-	ReplUser     string `yaml:"repl_user,omitempty"`     // 复制用户
-	ReplPassword string `yaml:"repl_password,omitempty"` // 复制密码
-	StorageSize  string `yaml:"storage_size,omitempty"`  // 存储大小
-	DataPath     string `yaml:"data_path,omitempty"`     // 数据存储路径
+	Enabled      bool     `yaml:"enabled,omitempty"`        // 是否启用MySQL部署
+	RootPassword string   `yaml:"root_password,omitempty"`  // MySQL root密码
+	ReplUser     string   `yaml:"repl_user,omitempty"`      // 复制用户
+	ReplPassword string   `yaml:"repl_password,omitempty"`  // 复制密码
+	StorageSize  string   `yaml:"storage_size,omitempty"`   // 存储大小
+	DataPath     string   `yaml:"data_path,omitempty"`      // 数据存储路径
+	InitSQL      []string `yaml:"init_sql,omitempty"`       // 额外初始化SQL语句，在创建console/region数据库后执行
+	InitSQLFile  string   `yaml:"init_sql_file,omitempty"`  // 额外初始化SQL文件，在init_sql之后执行
+	Scheduling   string   `yaml:"scheduling,omitempty"`     // Pod调度方式：node_name（默认，直接绑定节点）、node_selector（按节点标签由调度器调度）
+	StorageClass string   `yaml:"storage_class,omitempty"`  // 使用该StorageClass动态创建PVC存储数据，为空时使用hostPath（data_path）
+	SkipSyncTest bool     `yaml:"skip_sync_test,omitempty"` // 初始化Job跳过主从同步验证，未配置Slave节点时自动跳过
 }
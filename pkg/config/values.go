@@ -0,0 +1,127 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ApplySetValues 将 --set 形式的覆盖项合并到values中，语法与 helm --set 一致：
+//   Cluster.gatewayIngressIPs=1.2.3.4
+//   a.b=1,c.d=true        多个赋值以逗号分隔
+//   a.list={x,y,z}        列表
+//   a.key\.with\.dot=v    使用反斜杠转义 . , = 等特殊字符
+// 整数、布尔值和null会自动转换类型
+func ApplySetValues(values map[string]interface{}, sets []string) error {
+	for _, set := range sets {
+		for _, assignment := range splitUnescaped(set, ',') {
+			if strings.TrimSpace(assignment) == "" {
+				continue
+			}
+			if err := applySetValue(values, assignment); err != nil {
+				return fmt.Errorf("invalid --set '%s': %w", set, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applySetValue 解析单个 key=value 赋值并写入values
+func applySetValue(values map[string]interface{}, assignment string) error {
+	parts := splitUnescaped(assignment, '=')
+	if len(parts) < 2 {
+		return fmt.Errorf("missing '=' in '%s'", assignment)
+	}
+	key := parts[0]
+	rawValue := strings.Join(parts[1:], "=")
+
+	var path []string
+	for _, segment := range splitUnescaped(key, '.') {
+		segment = unescape(strings.TrimSpace(segment))
+		if segment == "" {
+			return fmt.Errorf("empty key segment in '%s'", key)
+		}
+		path = append(path, segment)
+	}
+
+	var value interface{}
+	if strings.HasPrefix(rawValue, "{") && strings.HasSuffix(rawValue, "}") {
+		var list []interface{}
+		inner := rawValue[1 : len(rawValue)-1]
+		if inner != "" {
+			for _, item := range splitUnescaped(inner, ',') {
+				list = append(list, coerceValue(item))
+			}
+		}
+		value = list
+	} else {
+		value = coerceValue(rawValue)
+	}
+
+	current := values
+	for _, segment := range path[:len(path)-1] {
+		next, ok := current[segment].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			current[segment] = next
+		}
+		current = next
+	}
+	current[path[len(path)-1]] = value
+	return nil
+}
+
+// coerceValue 将字符串转换为整数、布尔值或null，其他情况保持字符串
+func coerceValue(raw string) interface{} {
+	switch raw {
+	case "true":
+		return true
+	case "false":
+		return false
+	case "null":
+		return nil
+	}
+	if i, err := strconv.ParseInt(raw, 10, 64); err == nil && (raw == "0" || !strings.HasPrefix(raw, "0")) {
+		return i
+	}
+	return unescape(raw)
+}
+
+// splitUnescaped 按未转义且不在花括号内的分隔符切分字符串
+func splitUnescaped(s string, sep byte) []string {
+	var parts []string
+	var current strings.Builder
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s):
+			current.WriteByte(c)
+			current.WriteByte(s[i+1])
+			i++
+			continue
+		case c == '{':
+			depth++
+		case c == '}' && depth > 0:
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, current.String())
+			current.Reset()
+			continue
+		}
+		current.WriteByte(c)
+	}
+	return append(parts, current.String())
+}
+
+// unescape 去除反斜杠转义
+func unescape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
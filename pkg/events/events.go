@@ -0,0 +1,204 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Type 事件类型
+type Type string
+
+const (
+	StageStarted      Type = "stage_started"      // 阶段开始
+	StageProgress     Type = "stage_progress"     // 阶段进度信息更新
+	StageCompleted    Type = "stage_completed"    // 阶段完成
+	StageSkipped      Type = "stage_skipped"      // 阶段跳过
+	StageFailed       Type = "stage_failed"       // 阶段失败
+	SubStepsStarted   Type = "substeps_started"   // 子步骤组开始
+	SubStepStarted    Type = "substep_started"    // 子步骤开始
+	SubStepCompleted  Type = "substep_completed"  // 子步骤完成
+	SubStepsCompleted Type = "substeps_completed" // 子步骤组完成
+	NodeStarted       Type = "node_started"       // 节点开始处理
+	NodeCompleted     Type = "node_completed"     // 节点处理完成
+	Finished          Type = "finished"           // 所有阶段完成
+)
+
+// Event 安装过程中的结构化事件
+type Event struct {
+	Type    Type      `json:"type"`
+	Time    time.Time `json:"time"`
+	Stage   string    `json:"stage,omitempty"`
+	Node    string    `json:"node,omitempty"`
+	SubStep string    `json:"sub_step,omitempty"`
+	Count   int       `json:"count,omitempty"`   // 子步骤组的子步骤数
+	Message string    `json:"message,omitempty"` // 进度信息或跳过原因
+	Error   string    `json:"error,omitempty"`   // 失败原因
+}
+
+// Subscriber 事件订阅者
+type Subscriber interface {
+	Handle(event Event)
+}
+
+// SubscriberFunc 将函数适配为订阅者
+type SubscriberFunc func(event Event)
+
+// Handle 处理事件
+func (f SubscriberFunc) Handle(event Event) {
+	f(event)
+}
+
+// Bus 事件总线，按发布顺序同步分发给所有订阅者
+// 同时实现各安装模块的 StepProgress 接口，安装模块无需感知具体的订阅者
+type Bus struct {
+	mu          sync.Mutex
+	subscribers []Subscriber
+	stage       string // 当前阶段，附加到阶段内的所有事件
+}
+
+// NewBus 创建事件总线
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe 添加订阅者
+func (b *Bus) Subscribe(s Subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscribers = append(b.subscribers, s)
+}
+
+// Publish 发布事件，未设置时间和阶段时自动补全
+func (b *Bus) Publish(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	if event.Type == StageStarted {
+		b.stage = event.Stage
+	}
+	if event.Stage == "" {
+		event.Stage = b.stage
+	}
+	for _, s := range b.subscribers {
+		s.Handle(event)
+	}
+}
+
+// StartStep 发布阶段开始事件
+func (b *Bus) StartStep(stage string) {
+	b.Publish(Event{Type: StageStarted, Stage: stage})
+}
+
+// UpdateStepProgress 发布阶段进度事件
+func (b *Bus) UpdateStepProgress(message string) {
+	b.Publish(Event{Type: StageProgress, Message: message})
+}
+
+// CompleteStep 发布阶段完成事件
+func (b *Bus) CompleteStep() {
+	b.Publish(Event{Type: StageCompleted})
+}
+
+// SkipStep 发布阶段跳过事件
+func (b *Bus) SkipStep(reason string) {
+	b.Publish(Event{Type: StageSkipped, Message: reason})
+}
+
+// FailStep 发布阶段失败事件
+func (b *Bus) FailStep(errorMsg string) {
+	b.Publish(Event{Type: StageFailed, Error: errorMsg})
+}
+
+// Finish 发布所有阶段完成事件
+func (b *Bus) Finish() {
+	b.Publish(Event{Type: Finished})
+}
+
+// StartSubSteps 发布子步骤组开始事件
+func (b *Bus) StartSubSteps(totalSubSteps int) {
+	b.Publish(Event{Type: SubStepsStarted, Count: totalSubSteps})
+}
+
+// StartSubStep 发布子步骤开始事件
+func (b *Bus) StartSubStep(subStepName string) {
+	b.Publish(Event{Type: SubStepStarted, SubStep: subStepName})
+}
+
+// CompleteSubStep 发布子步骤完成事件
+func (b *Bus) CompleteSubStep() {
+	b.Publish(Event{Type: SubStepCompleted})
+}
+
+// CompleteSubSteps 发布子步骤组完成事件
+func (b *Bus) CompleteSubSteps() {
+	b.Publish(Event{Type: SubStepsCompleted})
+}
+
+// StartNodeProcessing 发布节点开始处理事件
+func (b *Bus) StartNodeProcessing(nodeIP string) {
+	b.Publish(Event{Type: NodeStarted, Node: nodeIP})
+}
+
+// CompleteNodeStep 发布节点处理完成事件
+func (b *Bus) CompleteNodeStep(nodeIP string) {
+	b.Publish(Event{Type: NodeCompleted, Node: nodeIP})
+}
+
+// Logger 日志订阅者使用的日志接口
+type Logger interface {
+	InfoToFileOnly(format string, v ...interface{})
+}
+
+// NewLogSubscriber 创建将事件记录到日志文件的订阅者
+func NewLogSubscriber(logger Logger) Subscriber {
+	return SubscriberFunc(func(e Event) {
+		switch e.Type {
+		case StageStarted:
+			logger.InfoToFileOnly("开始步骤: %s", e.Stage)
+		case StageProgress:
+			logger.InfoToFileOnly("步骤进度更新: %s", e.Message)
+		case StageCompleted:
+			logger.InfoToFileOnly("步骤完成: %s", e.Stage)
+		case StageSkipped:
+			logger.InfoToFileOnly("步骤跳过: %s - %s", e.Stage, e.Message)
+		case StageFailed:
+			logger.InfoToFileOnly("步骤失败: %s - %s", e.Stage, e.Error)
+		case SubStepsStarted:
+			logger.InfoToFileOnly("开始子步骤组，共 %d 个子步骤", e.Count)
+		case SubStepStarted:
+			logger.InfoToFileOnly("执行子步骤: %s", e.SubStep)
+		case SubStepsCompleted:
+			logger.InfoToFileOnly("所有子步骤完成")
+		case NodeStarted:
+			logger.InfoToFileOnly("开始处理节点: %s", e.Node)
+		case NodeCompleted:
+			logger.InfoToFileOnly("节点处理完成: %s - %s", e.Node, e.Stage)
+		case Finished:
+			logger.InfoToFileOnly("所有步骤完成")
+		}
+	})
+}
+
+// NewJSONSubscriber 创建以JSON Lines格式输出事件的订阅者，供外部程序消费
+func NewJSONSubscriber(w io.Writer) Subscriber {
+	encoder := json.NewEncoder(w)
+	return SubscriberFunc(func(e Event) {
+		// 输出端写入失败时忽略，不影响安装流程
+		_ = encoder.Encode(e)
+	})
+}
+
+// NewChannelSubscriber 创建将事件转发到通道的订阅者，通道已满时丢弃事件，避免阻塞安装流程
+func NewChannelSubscriber(buffer int) (Subscriber, <-chan Event) {
+	ch := make(chan Event, buffer)
+	return SubscriberFunc(func(e Event) {
+		select {
+		case ch <- e:
+		default:
+		}
+	}), ch
+}
@@ -26,6 +26,14 @@ type Logger struct {
 	suppressConsole bool     // 是否抑制控制台输出（进度条模式）
 }
 
+// clusterName 集群名称，设置后写入日志文件名
+var clusterName string
+
+// SetClusterName 设置集群名称，之后创建的日志文件名包含该名称，如 roi-install-prod-2006-01-02-15-04.log
+func SetClusterName(name string) {
+	clusterName = name
+}
+
 // NewLogger 创建新的日志记录器
 // consoleLevel: 控制台输出级别 (ERROR表示只显示错误，INFO表示显示所有)
 // fileLevel: 文件输出级别 (通常为DEBUG，记录所有详细信息)
@@ -33,6 +41,9 @@ func NewLogger(consoleLevel, fileLevel LogLevel) (*Logger, error) {
 	// 创建日志文件名（按日期-小时分钟命名）
 	now := time.Now()
 	logFileName := fmt.Sprintf("roi-install-%s.log", now.Format("2006-01-02-15-04"))
+	if clusterName != "" {
+		logFileName = fmt.Sprintf("roi-install-%s-%s.log", clusterName, now.Format("2006-01-02-15-04"))
+	}
 	
 	// 创建日志文件
 	logFile, err := os.OpenFile(logFileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0666)
@@ -52,6 +63,9 @@ func NewLogger(consoleLevel, fileLevel LogLevel) (*Logger, error) {
 
 	// 只在文件中记录启动信息
 	logger.fileLogger.Printf("[INFO] 日志记录已启动，详细日志保存到: %s", logFileName)
+	if clusterName != "" {
+		logger.fileLogger.Printf("[INFO] 集群名称: %s", clusterName)
+	}
 	return logger, nil
 }
 
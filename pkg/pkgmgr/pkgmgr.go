@@ -0,0 +1,153 @@
+package pkgmgr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"github.com/rainbond/rainbond-offline-installer/pkg/ssh"
+)
+
+// PackageManager 操作系统包管理器类型
+type PackageManager string
+
+const (
+	Apt    PackageManager = "apt"
+	Dnf    PackageManager = "dnf"
+	Yum    PackageManager = "yum"
+	Zypper PackageManager = "zypper"
+)
+
+// Runner 在远程主机上执行命令并返回合并输出，由各安装模块基于自己的SSH命令构建方式实现
+type Runner interface {
+	Run(host config.Host, command string) ([]byte, error)
+}
+
+// RunnerFunc 允许普通函数作为 Runner 使用
+type RunnerFunc func(host config.Host, command string) ([]byte, error)
+
+func (f RunnerFunc) Run(host config.Host, command string) ([]byte, error) {
+	return f(host, command)
+}
+
+// Manager 按主机检测包管理器并安装软件包，检测结果按主机缓存
+type Manager struct {
+	runner Runner
+	mu     sync.Mutex
+	cache  map[string]PackageManager
+}
+
+// New 创建包管理器助手
+func New(runner Runner) *Manager {
+	return &Manager{
+		runner: runner,
+		cache:  make(map[string]PackageManager),
+	}
+}
+
+// Detect 根据 /etc/os-release 检测主机的包管理器
+func (m *Manager) Detect(host config.Host) (PackageManager, error) {
+	m.mu.Lock()
+	if pm, ok := m.cache[host.IP]; ok {
+		m.mu.Unlock()
+		return pm, nil
+	}
+	m.mu.Unlock()
+
+	output, err := m.runner.Run(host, "cat /etc/os-release; command -v dnf >/dev/null 2>&1 && echo ROI_HAS_DNF=1")
+	if err != nil {
+		return "", fmt.Errorf("读取/etc/os-release失败: %w, 输出: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	pm, err := ParseOSRelease(string(output))
+	if err != nil {
+		return "", fmt.Errorf("主机 %s: %w", host.IP, err)
+	}
+
+	m.mu.Lock()
+	m.cache[host.IP] = pm
+	m.mu.Unlock()
+	return pm, nil
+}
+
+// ParseOSRelease 根据os-release的ID和ID_LIKE判断包管理器，RHEL系在存在dnf时优先使用dnf
+func ParseOSRelease(osRelease string) (PackageManager, error) {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(osRelease, "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if !ok {
+			continue
+		}
+		fields[key] = strings.ToLower(strings.Trim(value, `"'`))
+	}
+
+	ids := append([]string{fields["ID"]}, strings.Fields(fields["ID_LIKE"])...)
+	for _, id := range ids {
+		switch id {
+		case "debian", "ubuntu", "linuxmint", "uos", "deepin":
+			return Apt, nil
+		case "rhel", "centos", "fedora", "rocky", "almalinux", "ol", "openeuler", "kylin", "anolis", "amzn":
+			if fields["ROI_HAS_DNF"] == "1" {
+				return Dnf, nil
+			}
+			return Yum, nil
+		case "suse", "sles", "opensuse", "opensuse-leap", "opensuse-tumbleweed":
+			return Zypper, nil
+		}
+	}
+	return "", fmt.Errorf("无法识别的发行版 (ID=%s, ID_LIKE=%s)", fields["ID"], fields["ID_LIKE"])
+}
+
+// IsInstalled 检查软件包是否已安装
+func (m *Manager) IsInstalled(host config.Host, pkg string) (bool, error) {
+	pm, err := m.Detect(host)
+	if err != nil {
+		return false, err
+	}
+
+	var command string
+	if pm == Apt {
+		command = fmt.Sprintf("dpkg-query -W -f='${Status}' %s 2>/dev/null | grep -q 'install ok installed'", pkg)
+	} else {
+		command = fmt.Sprintf("rpm -q %s >/dev/null 2>&1", pkg)
+	}
+
+	// 退出码1表示未安装，连接、认证或超时等其他错误需要上报，不能当作未安装处理
+	if _, err := m.runner.Run(host, command); err != nil {
+		if ssh.HasExitCode(err, 1) {
+			return false, nil
+		}
+		return false, fmt.Errorf("检查软件包 %s 是否安装失败: %w", pkg, err)
+	}
+	return true, nil
+}
+
+// InstallPackages 安装软件包，apt在首次安装失败时更新索引后重试
+func (m *Manager) InstallPackages(host config.Host, pkgs ...string) error {
+	if len(pkgs) == 0 {
+		return nil
+	}
+	pm, err := m.Detect(host)
+	if err != nil {
+		return err
+	}
+
+	list := strings.Join(pkgs, " ")
+	var command string
+	switch pm {
+	case Apt:
+		command = fmt.Sprintf("DEBIAN_FRONTEND=noninteractive apt-get install -y %[1]s || (apt-get update && DEBIAN_FRONTEND=noninteractive apt-get install -y %[1]s)", list)
+	case Dnf:
+		command = fmt.Sprintf("dnf install -y %s", list)
+	case Yum:
+		command = fmt.Sprintf("yum install -y %s", list)
+	case Zypper:
+		command = fmt.Sprintf("zypper --non-interactive install %s", list)
+	}
+
+	if output, err := m.runner.Run(host, command); err != nil {
+		return fmt.Errorf("使用%s安装 %s 失败: %w, 输出: %s", pm, list, err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
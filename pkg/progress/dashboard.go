@@ -0,0 +1,275 @@
+package progress
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// 节点在某个阶段的状态
+const (
+	cellPending = iota
+	cellRunning
+	cellDone
+	cellFailed
+	cellSkipped
+)
+
+// dashboardRefresh 仪表盘重绘间隔
+const dashboardRefresh = 200 * time.Millisecond
+
+// dashboard 多节点安装进度仪表盘，按 节点 × 阶段 网格实时显示状态
+// 由 StepProgress 的阶段事件和节点事件（StartNodeProcessing/CompleteNodeStep）驱动
+type dashboard struct {
+	mu         sync.Mutex
+	stages     []string
+	hosts      []string
+	cells      map[string]map[string]int // stage -> host -> 状态
+	stage      string                    // 当前阶段
+	node       string                    // 当前处理的节点
+	subStep    string                    // 当前子步骤
+	message    string                    // 当前阶段的进度信息
+	spinner    []string
+	frame      int
+	drawnLines int
+	stop       chan struct{}
+	stopped    sync.WaitGroup
+}
+
+// IsTerminal 判断标准输出是否为终端，非终端环境（重定向、CI）不启用仪表盘
+func IsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+func newDashboard(stages, hosts []string) *dashboard {
+	cells := make(map[string]map[string]int, len(stages))
+	for _, stage := range stages {
+		cells[stage] = make(map[string]int, len(hosts))
+	}
+	return &dashboard{
+		stages:  stages,
+		hosts:   hosts,
+		cells:   cells,
+		spinner: []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+		stop:    make(chan struct{}),
+	}
+}
+
+// start 启动后台重绘
+func (d *dashboard) start() {
+	d.stopped.Add(1)
+	go func() {
+		defer d.stopped.Done()
+		ticker := time.NewTicker(dashboardRefresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.stop:
+				return
+			case <-ticker.C:
+				d.mu.Lock()
+				d.frame++
+				d.renderLocked()
+				d.mu.Unlock()
+			}
+		}
+	}()
+}
+
+// close 停止重绘并输出最终状态，之后的控制台输出不会被覆盖
+func (d *dashboard) close() {
+	select {
+	case <-d.stop:
+		return
+	default:
+		close(d.stop)
+	}
+	d.stopped.Wait()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.renderLocked()
+	d.drawnLines = 0
+}
+
+// startStage 开始阶段，阶段内所有节点进入等待状态
+func (d *dashboard) startStage(stage string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if _, ok := d.cells[stage]; !ok {
+		d.stages = append(d.stages, stage)
+		d.cells[stage] = make(map[string]int, len(d.hosts))
+	}
+	d.stage = stage
+	d.node = ""
+	d.subStep = ""
+	d.message = ""
+}
+
+// finishStage 结束当前阶段，未单独上报节点事件的节点统一标记为 status
+func (d *dashboard) finishStage(status int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	row := d.cells[d.stage]
+	if row == nil {
+		return
+	}
+	if status == cellFailed && d.node != "" {
+		// 失败归属到正在处理的节点，其余未开始的节点保持等待
+		row[d.node] = cellFailed
+	} else {
+		for _, host := range d.hosts {
+			if s := row[host]; s == cellPending || s == cellRunning {
+				row[host] = status
+			}
+		}
+	}
+	d.node = ""
+	d.subStep = ""
+	d.renderLocked()
+}
+
+// setNode 更新节点在当前阶段的状态
+func (d *dashboard) setNode(nodeIP string, status int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if row := d.cells[d.stage]; row != nil {
+		row[nodeIP] = status
+	}
+	if status == cellRunning {
+		d.node = nodeIP
+		d.subStep = ""
+	} else if d.node == nodeIP {
+		d.node = ""
+	}
+	d.renderLocked()
+}
+
+// setSubStep 更新当前子步骤
+func (d *dashboard) setSubStep(subStep string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.subStep = subStep
+}
+
+// setMessage 更新当前阶段的进度信息
+func (d *dashboard) setMessage(message string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.message = message
+}
+
+// renderLocked 原地重绘仪表盘，调用方需持有锁
+func (d *dashboard) renderLocked() {
+	var b strings.Builder
+	if d.drawnLines > 0 {
+		fmt.Fprintf(&b, "\033[%dA", d.drawnLines)
+	}
+
+	hostWidth := displayWidth("节点")
+	for _, host := range d.hosts {
+		if w := displayWidth(host); w > hostWidth {
+			hostWidth = w
+		}
+	}
+
+	lines := 0
+	writeLine := func(line string) {
+		b.WriteString("\r\033[K")
+		b.WriteString(line)
+		b.WriteString("\n")
+		lines++
+	}
+
+	header := padRight("节点", hostWidth)
+	for _, stage := range d.stages {
+		header += "  " + stage
+	}
+	writeLine("\033[1m" + header + "\033[0m")
+
+	for _, host := range d.hosts {
+		line := padRight(host, hostWidth)
+		for _, stage := range d.stages {
+			symbol := d.cellSymbol(d.cells[stage][host])
+			line += "  " + symbol + strings.Repeat(" ", displayWidth(stage)-displayWidth(stripANSI(symbol)))
+		}
+		writeLine(line)
+	}
+
+	status := ""
+	if d.stage != "" {
+		status = fmt.Sprintf("\033[36m[%s]\033[0m", d.stage)
+		if d.node != "" {
+			status += fmt.Sprintf(" 节点 \033[35m%s\033[0m", d.node)
+		}
+		if d.subStep != "" {
+			status += " " + d.subStep
+		} else if d.message != "" {
+			status += " " + d.message
+		}
+	}
+	writeLine(status)
+
+	d.drawnLines = lines
+	fmt.Print(b.String())
+}
+
+// cellSymbol 节点状态对应的显示符号
+func (d *dashboard) cellSymbol(status int) string {
+	switch status {
+	case cellRunning:
+		return "\033[33m" + d.spinner[d.frame%len(d.spinner)] + "\033[0m"
+	case cellDone:
+		return "\033[32m✓\033[0m"
+	case cellFailed:
+		return "\033[31m✗\033[0m"
+	case cellSkipped:
+		return "\033[90m-\033[0m"
+	default:
+		return "\033[90m·\033[0m"
+	}
+}
+
+// displayWidth 估算字符串在终端中的显示宽度，中文字符按2列计算
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		if r >= 0x2E80 {
+			width += 2
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// padRight 按显示宽度右侧补齐空格
+func padRight(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// stripANSI 去除ANSI颜色控制序列
+func stripANSI(s string) string {
+	var b strings.Builder
+	inEscape := false
+	for _, r := range s {
+		switch {
+		case r == '\033':
+			inEscape = true
+		case inEscape:
+			if r == 'm' {
+				inEscape = false
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,33 @@
+package progress
+
+import "github.com/rainbond/rainbond-offline-installer/pkg/events"
+
+// Handle 作为事件总线的控制台订阅者，将结构化事件渲染为spinner或仪表盘输出
+func (sp *StepProgress) Handle(e events.Event) {
+	switch e.Type {
+	case events.StageStarted:
+		sp.StartStep(e.Stage)
+	case events.StageProgress:
+		sp.UpdateStepProgress(e.Message)
+	case events.StageCompleted:
+		sp.CompleteStep()
+	case events.StageSkipped:
+		sp.SkipStep(e.Message)
+	case events.StageFailed:
+		sp.FailStep(e.Error)
+	case events.SubStepsStarted:
+		sp.StartSubSteps(e.Count)
+	case events.SubStepStarted:
+		sp.StartSubStep(e.SubStep)
+	case events.SubStepCompleted:
+		sp.CompleteSubStep()
+	case events.SubStepsCompleted:
+		sp.CompleteSubSteps()
+	case events.NodeStarted:
+		sp.StartNodeProcessing(e.Node)
+	case events.NodeCompleted:
+		sp.CompleteNodeStep(e.Node)
+	case events.Finished:
+		sp.Finish()
+	}
+}
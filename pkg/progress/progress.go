@@ -109,11 +109,11 @@ func (s *Spinner) UpdateSubInfo(subInfo string) {
 	s.subInfo = subInfo
 }
 
-// Logger接口，用于与日志系统集成
+// Logger接口，用于在显示进度期间控制日志的控制台输出
+// 步骤和节点事件的文件记录由 events.NewLogSubscriber 负责
 type Logger interface {
 	SuppressConsole()
 	EnableConsole()
-	InfoToFileOnly(format string, v ...interface{})
 }
 
 type StepProgress struct {
@@ -130,6 +130,9 @@ type StepProgress struct {
 	
 	// 主机信息
 	hostIPs        []string
+
+	// 多节点仪表盘，启用后替代逐行进度输出
+	dashboard      *dashboard
 }
 
 func NewStepProgress(totalSteps int) *StepProgress {
@@ -153,6 +156,17 @@ func (sp *StepProgress) SetHostIPs(hostIPs []string) {
 	sp.hostIPs = hostIPs
 }
 
+// EnableDashboard 启用按 节点 × 阶段 实时刷新的仪表盘，需在 SetHostIPs 之后调用
+// 标准输出不是终端时不启用并返回false，继续使用逐行进度输出
+func (sp *StepProgress) EnableDashboard(stageNames []string) bool {
+	if !IsTerminal() {
+		return false
+	}
+	sp.dashboard = newDashboard(stageNames, sp.hostIPs)
+	sp.dashboard.start()
+	return true
+}
+
 func (sp *StepProgress) StartStep(stepName string) {
 	sp.currentStep++
 	sp.stepName = stepName
@@ -162,7 +176,11 @@ func (sp *StepProgress) StartStep(stepName string) {
 	// 如果有logger，抑制其控制台输出
 	if sp.logger != nil {
 		sp.logger.SuppressConsole()
-		sp.logger.InfoToFileOnly("开始步骤 %d/%d: %s", sp.currentStep, sp.totalSteps, stepName)
+	}
+
+	if sp.dashboard != nil {
+		sp.dashboard.startStage(stepName)
+		return
 	}
 	
 	// 先打印一行"开始检查"信息，保留在历史记录中
@@ -237,15 +255,14 @@ func (sp *StepProgress) getProcessingMessage() string {
 }
 
 func (sp *StepProgress) UpdateStepProgress(message string) {
-	// 记录详细进度到文件
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("步骤进度更新: %s", message)
+	if sp.dashboard != nil {
+		sp.dashboard.setMessage(message)
 	}
 }
 
 // StartSpinnerIfNeeded 如果还没有启动spinner，现在启动它（用于没有子步骤的阶段）
 func (sp *StepProgress) StartSpinnerIfNeeded() {
-	if sp.spinner == nil {
+	if sp.spinner == nil && sp.dashboard == nil {
 		sp.spinner = NewSpinner(fmt.Sprintf("Step %d/%d: \033[33m[进行中]\033[0m %s", sp.currentStep, sp.totalSteps, sp.stepName))
 		sp.spinner.Start()
 	}
@@ -256,6 +273,12 @@ func (sp *StepProgress) CompleteStep() {
 	if sp.isSkipped {
 		return
 	}
+
+	if sp.dashboard != nil {
+		sp.isRunning = false
+		sp.dashboard.finishStage(cellDone)
+		return
+	}
 	
 	if sp.spinner != nil {
 		sp.spinner.Stop() // 停止spinner并清除当前行
@@ -265,15 +288,18 @@ func (sp *StepProgress) CompleteStep() {
 	// 清除当前行并显示完成信息（覆盖进行中的信息）
 	hostInfo := sp.getHostInfo()
 	fmt.Printf("\r\033[K\033[36m[INFO]\033[0m [\033[32m%s %d/%d\033[0m] 节点 \033[35m%s\033[0m %s。\n", sp.getStagePrefix(), sp.currentStep, sp.totalSteps, hostInfo, sp.getCompleteMessage())
-	
-	// 记录完成信息到文件
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("步骤完成: %s", sp.stepName)
-	}
 }
 
 // SkipStep 跳过步骤
 func (sp *StepProgress) SkipStep(reason string) {
+	if sp.dashboard != nil {
+		sp.isRunning = false
+		sp.isSkipped = true
+		sp.dashboard.setMessage(reason)
+		sp.dashboard.finishStage(cellSkipped)
+		return
+	}
+
 	if sp.spinner != nil {
 		sp.spinner.Stop() // 停止spinner并清除当前行
 	}
@@ -282,11 +308,6 @@ func (sp *StepProgress) SkipStep(reason string) {
 	
 	// 清除当前行并显示跳过信息
 	fmt.Printf("\r\033[K\033[36m[INFO]\033[0m [\033[33m%s %d/%d\033[0m] %s，跳过。\n", sp.getStagePrefix(), sp.currentStep, sp.totalSteps, reason)
-	
-	// 记录跳过信息到文件
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("步骤跳过: %s - %s", sp.stepName, reason)
-	}
 }
 
 // getHostInfo 获取主机信息显示文本
@@ -321,6 +342,12 @@ func (sp *StepProgress) getCompleteMessage() string {
 }
 
 func (sp *StepProgress) FailStep(errorMsg string) {
+	// 失败时关闭仪表盘，保留最终网格后再输出失败原因
+	if sp.dashboard != nil {
+		sp.dashboard.finishStage(cellFailed)
+		sp.dashboard.close()
+	}
+
 	if sp.spinner != nil {
 		sp.spinner.Fail() // 停止spinner并清除当前行
 	}
@@ -330,51 +357,49 @@ func (sp *StepProgress) FailStep(errorMsg string) {
 	hostInfo := sp.getHostInfo()
 	fmt.Printf("\r\033[K\033[36m[INFO]\033[0m [\033[31m%s %d/%d\033[0m] 节点 \033[35m%s\033[0m %s失败。原因：\033[31m%s\033[0m\n", sp.getStagePrefix(), sp.currentStep, sp.totalSteps, hostInfo, sp.getCompleteMessage(), errorMsg)
 	
-	// 记录失败信息到文件，并重新启用控制台输出显示错误
+	// 重新启用控制台输出显示错误
 	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("步骤失败: %s - %s", sp.stepName, errorMsg)
 		sp.logger.EnableConsole() // 失败时重新启用控制台输出
 	}
 }
 
 // Finish 完成所有步骤，重新启用控制台输出
 func (sp *StepProgress) Finish() {
+	if sp.dashboard != nil {
+		sp.dashboard.close()
+	}
 	if sp.logger != nil {
 		sp.logger.EnableConsole()
-		sp.logger.InfoToFileOnly("所有步骤完成")
 	}
 }
 
-// StartSubSteps 开始子步骤（仅记录到文件）
+// StartSubSteps 开始子步骤（控制台不显示，由事件日志订阅者记录到文件）
 func (sp *StepProgress) StartSubSteps(totalSubSteps int) {
-	// 子步骤信息只记录到文件，控制台保持简洁
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("开始子步骤组，共 %d 个子步骤", totalSubSteps)
-	}
 }
 
-// StartSubStep 开始具体的子步骤（仅记录到文件）
+// StartSubStep 开始具体的子步骤（控制台不显示，由事件日志订阅者记录到文件）
 func (sp *StepProgress) StartSubStep(subStepName string) {
-	// 子步骤不在控制台显示，仅记录到文件
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("执行子步骤: %s", subStepName)
+	if sp.dashboard != nil {
+		sp.dashboard.setSubStep(subStepName)
 	}
 }
 
-// CompleteSubStep 完成子步骤（仅记录到文件）
+// CompleteSubStep 完成子步骤（控制台不显示，由事件日志订阅者记录到文件）
 func (sp *StepProgress) CompleteSubStep() {
 	// 子步骤完成信息只记录到文件
 }
 
-// CompleteSubSteps 完成所有子步骤（仅记录到文件）
+// CompleteSubSteps 完成所有子步骤（控制台不显示，由事件日志订阅者记录到文件）
 func (sp *StepProgress) CompleteSubSteps() {
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("所有子步骤完成")
-	}
 }
 
 // StartNodeProcessing 开始处理特定节点
 func (sp *StepProgress) StartNodeProcessing(nodeIP string) {
+	if sp.dashboard != nil {
+		sp.dashboard.setNode(nodeIP, cellRunning)
+		return
+	}
+
 	if sp.spinner != nil {
 		sp.spinner.Stop() // 停止当前spinner
 	}
@@ -385,24 +410,19 @@ func (sp *StepProgress) StartNodeProcessing(nodeIP string) {
 	// 启动新的spinner，显示正在处理节点
 	sp.spinner = NewSpinner(fmt.Sprintf("\033[36m[INFO]\033[0m [\033[33m%s %d/%d\033[0m] 正在部署 \033[35m%s\033[0m 节点", sp.getStagePrefix(), sp.currentStep, sp.totalSteps, nodeIP))
 	sp.spinner.Start()
-	
-	// 记录到文件
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("开始处理节点: %s", nodeIP)
-	}
 }
 
 // CompleteNodeStep 完成特定节点的处理
 func (sp *StepProgress) CompleteNodeStep(nodeIP string) {
+	if sp.dashboard != nil {
+		sp.dashboard.setNode(nodeIP, cellDone)
+		return
+	}
+
 	if sp.spinner != nil {
 		sp.spinner.Stop() // 停止spinner并清除当前行
 	}
 	
 	// 清除当前行并显示节点完成信息
 	fmt.Printf("\r\033[K\033[36m[INFO]\033[0m [\033[32m%s %d/%d\033[0m] 节点 \033[35m%s\033[0m %s。\n", sp.getStagePrefix(), sp.currentStep, sp.totalSteps, nodeIP, sp.getCompleteMessage())
-	
-	// 记录到文件
-	if sp.logger != nil {
-		sp.logger.InfoToFileOnly("节点处理完成: %s - %s", nodeIP, sp.stepName)
-	}
 }
\ No newline at end of file
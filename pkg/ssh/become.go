@@ -0,0 +1,56 @@
+package ssh
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// WrapBecome 为配置了become的主机将命令包装为sudo执行
+// 整个命令作为 sh -c 的参数传入，保证heredoc、管道、重定向等都在提权后的shell中执行
+func WrapBecome(host config.Host, command string) string {
+	if !host.Become {
+		return command
+	}
+
+	becomeUser := host.BecomeUser
+	if becomeUser == "" {
+		becomeUser = "root"
+	}
+
+	if becomePassword(host) == "" {
+		return fmt.Sprintf("sudo -n -u %s sh -c %s", ShellQuote(becomeUser), ShellQuote(command))
+	}
+	// 标准输入的第一行是密码，先由外层shell读走，只在 sudo -n true 失败（没有缓存凭据）时才通过 -S 交给sudo，
+	// 避免sudo已有缓存凭据时密码留在标准输入中被远程命令读取；-p '' 避免提示符混入命令输出
+	return fmt.Sprintf("sh -c %s roi-become %s %s", ShellQuote(becomeScript), ShellQuote(becomeUser), ShellQuote(command))
+}
+
+// becomeScript 带密码的become包装脚本，$1 为目标用户，$2 为要执行的命令
+const becomeScript = `IFS= read -r password
+if sudo -n true 2>/dev/null; then
+	exec sudo -n -u "$1" sh -c "$2"
+fi
+printf '%s\n' "$password" | sudo -S -p '' -u "$1" sh -c "$2"`
+
+// becomePassword 获取sudo密码，未设置become_password时使用SSH密码
+func becomePassword(host config.Host) string {
+	if !host.Become {
+		return ""
+	}
+	if host.BecomePassword != "" {
+		return host.BecomePassword
+	}
+	return host.Password
+}
+
+// becomeStdin 返回向sudo -S输入密码的标准输入，未配置密码时返回nil
+func becomeStdin(host config.Host) io.Reader {
+	password := becomePassword(host)
+	if password == "" {
+		return nil
+	}
+	return strings.NewReader(password + "\n")
+}
@@ -0,0 +1,90 @@
+package ssh
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedValue 命令日志中代替敏感值的占位符
+const redactedValue = "****"
+
+// CommandLogger 记录远程命令的日志接口
+type CommandLogger interface {
+	Debug(format string, v ...interface{})
+}
+
+var (
+	commandLogMu  sync.RWMutex
+	commandLogger CommandLogger
+	secretValues  []string
+)
+
+// secretPatterns 未登记的敏感值的兜底匹配：password=xxx、token: xxx、IDENTIFIED BY 'xxx' 等形式只保留键名
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)((?:password|passwd|token|secret)["']?\s*[:=]\s*["']?)([^\s"',;]+)`),
+	regexp.MustCompile(`(?i)(identified\s+by\s+')([^']*)`),
+}
+
+// SetCommandLogger 设置记录远程命令的日志记录器，nil 表示不记录
+// 每条命令执行前以Debug级别记录主机和完整命令，已登记的敏感值替换为 ****
+func SetCommandLogger(logger CommandLogger) {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	commandLogger = logger
+}
+
+// RegisterSecrets 登记需要在命令日志中脱敏的值（密码、token等），空值忽略
+func RegisterSecrets(values ...string) {
+	commandLogMu.Lock()
+	defer commandLogMu.Unlock()
+	for _, value := range values {
+		if value == "" || containsString(secretValues, value) {
+			continue
+		}
+		secretValues = append(secretValues, value)
+	}
+	// 先替换较长的值，避免一个敏感值是另一个的子串时残留部分内容
+	sort.Slice(secretValues, func(i, j int) bool { return len(secretValues[i]) > len(secretValues[j]) })
+}
+
+// Redact 将文本中已登记的敏感值和常见的密码参数替换为 ****
+func Redact(text string) string {
+	commandLogMu.RLock()
+	defer commandLogMu.RUnlock()
+	return redact(text)
+}
+
+func redact(text string) string {
+	for _, value := range secretValues {
+		text = strings.ReplaceAll(text, value, redactedValue)
+	}
+	for _, pattern := range secretPatterns {
+		text = pattern.ReplaceAllString(text, "${1}"+redactedValue)
+	}
+	return text
+}
+
+// logCommand 设置了命令日志时记录即将执行的命令
+func (c *Command) logCommand() {
+	commandLogMu.RLock()
+	defer commandLogMu.RUnlock()
+	if commandLogger == nil {
+		return
+	}
+	if c.isCopy {
+		commandLogger.Debug("[%s] 传输文件: %s -> %s", c.host.IP, c.source, c.dest)
+		return
+	}
+	commandLogger.Debug("[%s] 执行命令: %s", c.host.IP, redact(strings.TrimSpace(c.command)))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// Backend 远程执行后端
+type Backend string
+
+const (
+	BackendExec   Backend = "exec"   // 调用系统 ssh/scp/sshpass（默认）
+	BackendNative Backend = "native" // 使用Go原生SSH客户端，不依赖外部二进制
+)
+
+// currentBackend 当前使用的远程执行后端，由 --ssh-backend 设置
+var currentBackend = BackendExec
+
+// SetBackend 设置远程执行后端
+func SetBackend(name string) error {
+	switch Backend(strings.ToLower(strings.TrimSpace(name))) {
+	case "", BackendExec:
+		currentBackend = BackendExec
+	case BackendNative:
+		currentBackend = BackendNative
+	default:
+		return fmt.Errorf("不支持的SSH后端 '%s'，可选值: native, exec", name)
+	}
+	return nil
+}
+
+// GetBackend 获取当前远程执行后端
+func GetBackend() Backend {
+	return currentBackend
+}
+
+// Command 远程命令，根据当前后端通过系统ssh或Go原生SSH客户端执行
+// 提供与 exec.Cmd 一致的 Run/Output/CombinedOutput 方法，便于各安装模块直接替换
+type Command struct {
+	host    config.Host
+	command string
+	source  string // 文件传输时的本地路径
+	dest    string // 文件传输时的远程路径
+	isCopy  bool
+	execCmd *exec.Cmd       // exec后端使用的系统命令
+	ctx     context.Context // 取消时终止正在执行的命令，nil表示不可取消
+	runner  Runner          // 非nil时通过 Runner 执行，不使用SSH后端
+}
+
+// NewCommand 创建远程命令，execCmd 为exec后端下实际执行的ssh命令
+func NewCommand(host config.Host, command string, execCmd *exec.Cmd) *Command {
+	// become主机通过标准输入向 sudo -S 提供密码
+	if stdin := becomeStdin(host); stdin != nil && execCmd.Stdin == nil {
+		execCmd.Stdin = stdin
+	}
+	return &Command{
+		host:    host,
+		command: command,
+		execCmd: execCmd,
+	}
+}
+
+// NewHostCommand 按主机的认证方式构建远程命令：配置了密码时通过sshpass调用ssh，否则使用密钥或ssh-agent，
+// become主机的命令包装为sudo执行。没有自定义ssh参数需求的模块直接使用该方法，不再各自拼装ssh命令
+func NewHostCommand(host config.Host, command string) *Command {
+	command = WrapBecome(host, command)
+
+	args := []string{
+		"-o", "StrictHostKeyChecking=no",
+		"-o", "UserKnownHostsFile=/dev/null",
+	}
+	if host.Password == "" {
+		// 密钥认证失败时直接报错，不等待交互输入密码
+		args = append(args, "-o", "BatchMode=yes")
+		if host.SSHKey != "" {
+			args = append([]string{"-i", host.SSHKey}, args...)
+		}
+	}
+	args = append(args,
+		"-o", ConnectTimeoutOption(),
+		"-o", PortOption(host),
+		fmt.Sprintf("%s@%s", host.User, host.IP),
+		command)
+
+	var sshCmd *exec.Cmd
+	if host.Password != "" {
+		// sshpass 已在启动时检查
+		sshCmd = exec.Command("sshpass", append([]string{"-p", host.Password, "ssh"}, args...)...)
+	} else {
+		sshCmd = exec.Command("ssh", args...)
+	}
+	return NewCommand(host, command, sshCmd)
+}
+
+// NewCopyCommand 创建文件传输命令，execCmd 为exec后端下实际执行的scp命令
+func NewCopyCommand(host config.Host, source, dest string, execCmd *exec.Cmd) *Command {
+	return &Command{
+		host:    host,
+		source:  source,
+		dest:    dest,
+		isCopy:  true,
+		execCmd: execCmd,
+	}
+}
+
+// WithContext 设置命令的上下文，上下文取消时终止正在执行的远程命令
+func (c *Command) WithContext(ctx context.Context) *Command {
+	c.ctx = ctx
+	return c
+}
+
+// context 获取命令的上下文，未设置时不可取消
+func (c *Command) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// Run 执行命令并等待完成，失败时返回 *RemoteCommandError
+func (c *Command) Run() error {
+	c.logCommand()
+	if c.runner != nil {
+		stdout, stderr, err := c.runWithRunner()
+		return c.wrapError(err, stdout, stderr)
+	}
+	if currentBackend != BackendNative {
+		// 调用方未接管标准错误时收集起来，放入错误信息
+		var stderr bytes.Buffer
+		if c.execCmd.Stderr == nil {
+			c.execCmd.Stderr = &stderr
+		}
+		_, err := c.runExec(func(cmd *exec.Cmd) ([]byte, error) { return nil, cmd.Run() })
+		return c.wrapError(err, nil, stderr.Bytes())
+	}
+	stdout, stderr, err := c.runNative(false)
+	return c.wrapError(err, stdout, stderr)
+}
+
+// Output 执行命令并返回标准输出，失败时返回 *RemoteCommandError
+func (c *Command) Output() ([]byte, error) {
+	c.logCommand()
+	if c.runner != nil {
+		stdout, stderr, err := c.runWithRunner()
+		return stdout, c.wrapError(err, stdout, stderr)
+	}
+	if currentBackend != BackendNative {
+		stdout, err := c.runExec((*exec.Cmd).Output)
+		var stderr []byte
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			stderr = exitErr.Stderr
+		}
+		return stdout, c.wrapError(err, stdout, stderr)
+	}
+	stdout, stderr, err := c.runNative(false)
+	return stdout, c.wrapError(err, stdout, stderr)
+}
+
+// CombinedOutput 执行命令并返回标准输出和标准错误的合并内容，失败时返回 *RemoteCommandError
+func (c *Command) CombinedOutput() ([]byte, error) {
+	c.logCommand()
+	if c.runner != nil {
+		stdout, stderr, err := c.runWithRunner()
+		output := append(stdout, stderr...)
+		return output, c.wrapError(err, output, nil)
+	}
+	if currentBackend != BackendNative {
+		output, err := c.runExec((*exec.Cmd).CombinedOutput)
+		return output, c.wrapError(err, output, nil)
+	}
+	output, _, err := c.runNative(true)
+	return output, c.wrapError(err, output, nil)
+}
+
+// Streams 执行命令并分别返回标准输出和标准错误，失败时返回 *RemoteCommandError
+func (c *Command) Streams() ([]byte, []byte, error) {
+	c.logCommand()
+	if c.runner != nil {
+		stdout, stderr, err := c.runWithRunner()
+		return stdout, stderr, c.wrapError(err, stdout, stderr)
+	}
+	var stdout, stderr bytes.Buffer
+	if currentBackend != BackendNative {
+		c.execCmd.Stdout = &stdout
+		c.execCmd.Stderr = &stderr
+		_, err := c.runExec(func(cmd *exec.Cmd) ([]byte, error) { return nil, cmd.Run() })
+		return stdout.Bytes(), stderr.Bytes(), c.wrapError(err, stdout.Bytes(), stderr.Bytes())
+	}
+	if c.isCopy {
+		return nil, nil, copyFileNative(c.host, c.source, c.dest)
+	}
+	err := runSessionNative(c.context(), c.host, c.command, &stdout, &stderr)
+	return stdout.Bytes(), stderr.Bytes(), c.wrapError(err, stdout.Bytes(), stderr.Bytes())
+}
+
+// runNative 通过Go原生SSH客户端执行命令或传输文件，combined 时标准错误合并到第一个返回值
+func (c *Command) runNative(combined bool) ([]byte, []byte, error) {
+	if c.isCopy {
+		return nil, nil, copyFileNative(c.host, c.source, c.dest)
+	}
+	return runCommandNative(c.context(), c.host, c.command, combined)
+}
+
+// ExitCode 获取远程命令的退出码，兼容exec和native两种后端以及通过 Runner 执行的命令
+func ExitCode(err error) (int, bool) {
+	var execErr *exec.ExitError
+	if errors.As(err, &execErr) {
+		return execErr.ExitCode(), true
+	}
+	var sshErr *ssh.ExitError
+	if errors.As(err, &sshErr) {
+		return sshErr.ExitStatus(), true
+	}
+	var runnerErr *runnerExitError
+	if errors.As(err, &runnerErr) {
+		return runnerErr.code, true
+	}
+	return 0, false
+}
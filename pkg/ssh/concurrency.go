@@ -0,0 +1,142 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// DefaultConcurrency 未指定 --concurrency 时并行阶段同时处理的主机数
+const DefaultConcurrency = 4
+
+// hostConnectInterval 同一主机相邻两次新建SSH连接的最小间隔
+// exec后端每条命令都会新建连接，短时间内的大量未认证连接会触发sshd的MaxStartups限制被拒绝
+const hostConnectInterval = 100 * time.Millisecond
+
+var (
+	concurrency = DefaultConcurrency
+	dialSlots   = make(chan struct{}, DefaultConcurrency) // native后端同时建立中的连接数
+	connLimiter = &hostRateLimiter{next: make(map[string]time.Time)}
+)
+
+// SetConcurrency 设置并行阶段同时处理的主机数和native后端同时建立的连接数，需在执行任何远程命令前调用
+func SetConcurrency(n int) error {
+	if n < 1 {
+		return fmt.Errorf("并发数必须大于0，当前为 %d", n)
+	}
+	concurrency = n
+	dialSlots = make(chan struct{}, n)
+	return nil
+}
+
+// Concurrency 获取当前并发数
+func Concurrency() int {
+	return concurrency
+}
+
+// ForEachHost 以全局并发数并行对每个主机执行 fn，等待全部完成
+// 返回的错误切片与 hosts 一一对应，成功的主机对应位置为nil
+func ForEachHost(hosts []config.Host, fn func(host config.Host) error) []error {
+	return ForEachHostContext(context.Background(), hosts, concurrency, func(_ context.Context, host config.Host) error {
+		return fn(host)
+	})
+}
+
+// ForEachHostContext 以 n 个并发（n<1时使用全局并发数）并行对每个主机执行 fn，ctx 取消后不再开始处理新的主机，未处理的主机对应位置为 ctx.Err()
+// 正在处理的主机需要 fn 自行响应取消，例如通过 Command.WithContext 执行远程命令
+func ForEachHostContext(ctx context.Context, hosts []config.Host, n int, fn func(ctx context.Context, host config.Host) error) []error {
+	if n < 1 {
+		n = concurrency
+	}
+	errs := make([]error, len(hosts))
+
+	workers := n
+	if workers > len(hosts) {
+		workers = len(hosts)
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					errs[i] = err
+					continue
+				}
+				errs[i] = fn(ctx, hosts[i])
+			}
+		}()
+	}
+	for i := range hosts {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return errs
+}
+
+// HostError 单个主机的处理失败
+type HostError struct {
+	Host string
+	Err  error
+}
+
+// HostErrors 处理全部主机后汇总的失败，用于 --continue-on-error 模式下一次报告所有失败的主机
+type HostErrors []HostError
+
+func (e HostErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, hostErr := range e {
+		parts[i] = fmt.Sprintf("%s: %v", hostErr.Host, hostErr.Err)
+	}
+	return fmt.Sprintf("%d 个主机失败: %s", len(e), strings.Join(parts, "; "))
+}
+
+// Failed 判断主机是否在失败列表中
+func (e HostErrors) Failed(ip string) bool {
+	for _, hostErr := range e {
+		if hostErr.Host == ip {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectHostErrors 将 ForEachHost 返回的错误切片整理为失败主机列表，全部成功时返回nil
+func CollectHostErrors(hosts []config.Host, errs []error) HostErrors {
+	var failures HostErrors
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, HostError{Host: hosts[i].IP, Err: err})
+		}
+	}
+	return failures
+}
+
+// hostRateLimiter 按主机限制新建连接的速率
+type hostRateLimiter struct {
+	mu   sync.Mutex
+	next map[string]time.Time // 主机下一次允许建立连接的时间
+}
+
+// wait 预约主机的下一个连接时间并等待到达，并发调用按预约顺序依次放行
+func (l *hostRateLimiter) wait(host config.Host) {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next[host.IP]
+	if start.Before(now) {
+		start = now
+	}
+	l.next[host.IP] = start.Add(hostConnectInterval)
+	l.mu.Unlock()
+
+	time.Sleep(time.Until(start))
+}
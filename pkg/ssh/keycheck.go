@@ -0,0 +1,59 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// CheckSSHKeys 在任何远程操作之前检查主机配置的ssh_key：文件存在且可读，native后端下还需能解析为私钥，
+// 避免路径错误在安装中途表现为难以理解的SSH认证失败；返回私钥权限过宽等不影响继续执行的警告
+func CheckSSHKeys(hosts []config.Host) ([]string, error) {
+	checked := make(map[string]bool)
+	var warnings []string
+	for i, host := range hosts {
+		if host.Password != "" || host.SSHKey == "" {
+			continue
+		}
+		keyPath := expandHome(host.SSHKey)
+		if checked[keyPath] {
+			continue
+		}
+		checked[keyPath] = true
+
+		info, err := os.Stat(keyPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return warnings, fmt.Errorf("host[%d] %s: ssh_key %s 不存在", i, host.IP, host.SSHKey)
+			}
+			return warnings, fmt.Errorf("host[%d] %s: 无法访问ssh_key %s: %w", i, host.IP, host.SSHKey, err)
+		}
+		if info.IsDir() {
+			return warnings, fmt.Errorf("host[%d] %s: ssh_key %s 是目录，应为私钥文件", i, host.IP, host.SSHKey)
+		}
+		keyData, err := os.ReadFile(keyPath)
+		if err != nil {
+			return warnings, fmt.Errorf("host[%d] %s: 无法读取ssh_key %s: %w", i, host.IP, host.SSHKey, err)
+		}
+
+		if perm := info.Mode().Perm(); perm&0o077 != 0 {
+			warnings = append(warnings, fmt.Sprintf("host[%d] %s: 私钥文件 %s 的权限为 %04o，其他用户可读，OpenSSH（exec后端）会拒绝使用权限过宽的私钥，建议执行 chmod 600 %s",
+				i, host.IP, host.SSHKey, perm, host.SSHKey))
+		}
+
+		// native后端直接解析私钥，解析失败时在连接前报告
+		if currentBackend == BackendNative {
+			if _, err := ssh.ParsePrivateKey(keyData); err != nil {
+				var passphraseErr *ssh.PassphraseMissingError
+				if errors.As(err, &passphraseErr) {
+					return warnings, fmt.Errorf("host[%d] %s: ssh_key %s 设置了密码，native后端不支持带密码的私钥，请使用exec后端并通过ssh-agent加载", i, host.IP, host.SSHKey)
+				}
+				return warnings, fmt.Errorf("host[%d] %s: 解析ssh_key %s 失败: %w", i, host.IP, host.SSHKey, err)
+			}
+		}
+	}
+	return warnings, nil
+}
@@ -0,0 +1,110 @@
+package ssh
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// managedBlockUpdated 受管理区块脚本在文件发生变化时输出的结果
+const managedBlockUpdated = "updated"
+
+// ManagedBlock 节点文件中由roi管理的区块，以 "# BEGIN roi <Name>" 和 "# END roi <Name>" 标记
+// 再次写入时替换同名区块而不是追加，区块外的内容保持不变
+type ManagedBlock struct {
+	Path    string
+	Name    string
+	Content string
+	Prepend bool // 区块写在文件开头（如resolv.conf中的nameserver需要优先），默认追加到文件末尾
+	Dedupe  bool // 删除区块外与区块内容完全相同的行，用于迁移旧版本直接追加或覆盖写入的内容
+}
+
+// WriteManagedBlock 通过build构建的SSH命令在主机上写入受管理区块，返回文件是否发生变化
+// 内容未变化时不修改文件；变化时先备份为 <Path>.roi-bak，再通过临时文件和mv原子替换
+func WriteManagedBlock(host config.Host, build func(config.Host, string) *Command, block ManagedBlock) (bool, error) {
+	output, err := build(host, block.script()).CombinedOutput()
+	result := strings.TrimSpace(string(output))
+	if err != nil {
+		return false, fmt.Errorf("写入 %s 失败: %w, 输出: %s", block.Path, err, result)
+	}
+	return strings.HasSuffix(result, managedBlockUpdated), nil
+}
+
+// CheckManagedBlock 只检查写入区块是否会改变文件，不修改主机上的任何文件，用于只读的合规检查
+func CheckManagedBlock(host config.Host, build func(config.Host, string) *Command, block ManagedBlock) (bool, error) {
+	output, err := build(host, block.render(true)).CombinedOutput()
+	result := strings.TrimSpace(string(output))
+	if err != nil {
+		return false, fmt.Errorf("检查 %s 失败: %w, 输出: %s", block.Path, err, result)
+	}
+	return strings.HasSuffix(result, managedBlockUpdated), nil
+}
+
+// script 生成写入区块的脚本：先生成完整区块，再删除文件中已有的同名区块后写入
+// 文件为符号链接时（如systemd-resolved管理的resolv.conf）拒绝替换，避免破坏链接
+func (b ManagedBlock) script() string {
+	return b.render(false)
+}
+
+// render 生成区块脚本，checkOnly为true时只比较目标内容与现有文件并输出结果：
+// 临时文件放在 /tmp，文件不存在时按空文件比较，不创建、备份或替换文件
+func (b ManagedBlock) render(checkOnly bool) string {
+	begin := fmt.Sprintf("# BEGIN roi %s", b.Name)
+	end := fmt.Sprintf("# END roi %s", b.Name)
+
+	before, after := "", `cat "$blk"`
+	if b.Prepend {
+		before, after = after, before
+	}
+	dedupe := 0
+	if b.Dedupe {
+		dedupe = 1
+	}
+
+	prepare := `[ -e "$f" ] || touch "$f"
+src="$f"
+tmp=$(mktemp "$f.roi.XXXXXX")`
+	apply := fmt.Sprintf(`cp -p "$f" "$f.roi-bak"
+chmod --reference="$f" "$tmp" 2>/dev/null || chmod 644 "$tmp"
+mv -f "$tmp" "$f"
+echo %s`, managedBlockUpdated)
+	if checkOnly {
+		prepare = `src="$f"
+[ -e "$f" ] || src=/dev/null
+tmp=$(mktemp)`
+		apply = "echo " + managedBlockUpdated
+	}
+
+	return fmt.Sprintf(`set -e
+f=%[1]s
+if [ -L "$f" ]; then
+	echo "$f 是符号链接（$(readlink -f "$f")），请通过管理该文件的服务配置" >&2
+	exit 1
+fi
+%[8]s
+blk=$(mktemp)
+trap 'rm -f "$tmp" "$blk"' EXIT
+{
+printf '%%s\n' '%[2]s'
+cat <<'ROI_BLOCK_EOF'
+%[4]s
+ROI_BLOCK_EOF
+printf '%%s\n' '%[3]s'
+} > "$blk"
+{
+%[5]s
+awk -v b='%[2]s' -v e='%[3]s' -v dedupe=%[6]d '
+NR == FNR { if (dedupe && $0 != "") seen[$0] = 1; next }
+$0 == b { skip = 1; next }
+$0 == e { skip = 0; next }
+!skip && !seen[$0]' "$blk" "$src"
+%[7]s
+} > "$tmp"
+if cmp -s "$tmp" "$src"; then
+	echo unchanged
+	exit 0
+fi
+%[9]s
+`, b.Path, begin, end, strings.TrimRight(b.Content, "\n"), before, dedupe, after, prepare, apply)
+}
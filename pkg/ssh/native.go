@@ -0,0 +1,196 @@
+package ssh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// defaultPrivateKeys 未指定ssh_key时尝试的默认私钥
+var defaultPrivateKeys = []string{"id_rsa", "id_ed25519", "id_ecdsa"}
+
+// dialNative 使用Go原生SSH客户端连接主机
+func dialNative(host config.Host) (*ssh.Client, error) {
+	authMethods, err := buildAuthMethods(host)
+	if err != nil {
+		return nil, err
+	}
+
+	clientConfig := &ssh.ClientConfig{
+		User:            host.User,
+		Auth:            authMethods,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 与exec后端的 StrictHostKeyChecking=no 保持一致
+		Timeout:         connectTimeout,
+	}
+
+	addr := net.JoinHostPort(host.IP, strconv.Itoa(host.SSHPort()))
+	client, err := ssh.Dial("tcp", addr, clientConfig)
+	if err != nil {
+		return nil, fmt.Errorf("SSH连接主机 %s 失败: %w", host.IP, err)
+	}
+	return client, nil
+}
+
+// buildAuthMethods 根据主机配置构建认证方式：密码、指定私钥或默认私钥
+func buildAuthMethods(host config.Host) ([]ssh.AuthMethod, error) {
+	if host.Password != "" {
+		password := host.Password
+		return []ssh.AuthMethod{
+			ssh.Password(password),
+			ssh.KeyboardInteractive(func(user, instruction string, questions []string, echos []bool) ([]string, error) {
+				answers := make([]string, len(questions))
+				for i := range answers {
+					answers[i] = password
+				}
+				return answers, nil
+			}),
+		}, nil
+	}
+
+	var keyPaths []string
+	if host.SSHKey != "" {
+		keyPaths = append(keyPaths, expandHome(host.SSHKey))
+	} else if homeDir, err := os.UserHomeDir(); err == nil {
+		for _, name := range defaultPrivateKeys {
+			keyPaths = append(keyPaths, filepath.Join(homeDir, ".ssh", name))
+		}
+	}
+
+	var signers []ssh.Signer
+	for _, keyPath := range keyPaths {
+		keyData, err := os.ReadFile(keyPath)
+		if err != nil {
+			if host.SSHKey != "" {
+				return nil, fmt.Errorf("读取私钥文件 %s 失败: %w", keyPath, err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(keyData)
+		if err != nil {
+			if host.SSHKey != "" {
+				return nil, fmt.Errorf("解析私钥文件 %s 失败: %w", keyPath, err)
+			}
+			continue
+		}
+		signers = append(signers, signer)
+	}
+
+	if len(signers) == 0 {
+		return nil, fmt.Errorf("主机 %s 未配置密码，且未找到可用的SSH私钥", host.IP)
+	}
+	return []ssh.AuthMethod{ssh.PublicKeys(signers...)}, nil
+}
+
+// runCommandNative 在远程主机执行命令，combined为true时合并标准输出和标准错误
+func runCommandNative(ctx context.Context, host config.Host, command string, combined bool) ([]byte, []byte, error) {
+	var stdout, stderr bytes.Buffer
+	if combined {
+		// 与 session.CombinedOutput 相同，两个输出流可能并发写入同一缓冲区
+		writer := &lockedWriter{w: &stdout}
+		err := runSessionNative(ctx, host, command, writer, writer)
+		return stdout.Bytes(), nil, err
+	}
+
+	err := runSessionNative(ctx, host, command, &stdout, &stderr)
+	return stdout.Bytes(), stderr.Bytes(), err
+}
+
+// runSessionNative 在远程主机执行命令并将输出写入 stdout/stderr，命令超时或上下文取消时关闭会话
+func runSessionNative(ctx context.Context, host config.Host, command string, stdout, stderr io.Writer) error {
+	session, release, err := defaultPool.newSession(host)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if stdin := becomeStdin(host); stdin != nil {
+		session.Stdin = stdin
+	}
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	// 超时或取消后关闭会话，使阻塞中的 Run 返回
+	var timedOut atomic.Bool
+	if commandTimeout > 0 {
+		timer := time.AfterFunc(commandTimeout, func() {
+			timedOut.Store(true)
+			session.Close()
+		})
+		defer timer.Stop()
+	}
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	err = session.Run(command)
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() != nil:
+		return fmt.Errorf("主机 %s 远程命令已取消: %w", host.IP, ctx.Err())
+	case timedOut.Load():
+		return fmt.Errorf("主机 %s 远程命令执行超时（%s）: %w", host.IP, commandTimeout, err)
+	}
+	return err
+}
+
+// lockedWriter 串行化并发写入
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
+
+// copyFileNative 通过SSH会话将本地文件流式写入远程路径
+func copyFileNative(host config.Host, localPath, remotePath string) error {
+	file, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("打开本地文件 %s 失败: %w", localPath, err)
+	}
+	defer file.Close()
+
+	session, release, err := defaultPool.newSession(host)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	var stderr bytes.Buffer
+	session.Stdin = file
+	session.Stderr = &stderr
+	if err := session.Run(fmt.Sprintf("cat > %s", ShellQuote(remotePath))); err != nil {
+		return fmt.Errorf("传输文件到主机 %s 失败: %w, 输出: %s", host.IP, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// expandHome 展开路径中的 ~ 为用户主目录
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, strings.TrimPrefix(path, "~"))
+		}
+	}
+	return path
+}
+
+// ShellQuote 使用单引号转义shell参数，用于在远程命令中拼接用户提供的路径等参数
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'"'"'`) + "'"
+}
@@ -0,0 +1,156 @@
+package ssh
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+	"golang.org/x/crypto/ssh"
+)
+
+// poolIdleTimeout 连接空闲超过该时间后被关闭
+const poolIdleTimeout = 5 * time.Minute
+
+// pooledClient 连接池中的SSH客户端
+type pooledClient struct {
+	client   *ssh.Client
+	lastUsed time.Time
+	inUse    int // 正在使用该连接的会话数，使用中的连接不会被空闲回收
+}
+
+// clientPool native后端的SSH连接池，每个主机复用一个 *ssh.Client，每条命令创建独立会话
+type clientPool struct {
+	mu      sync.Mutex
+	clients map[string]*pooledClient
+	closed  bool
+}
+
+var defaultPool = &clientPool{clients: make(map[string]*pooledClient)}
+
+// poolKey 连接池的键：user@ip
+func poolKey(host config.Host) string {
+	return fmt.Sprintf("%s@%s", host.User, host.IP)
+}
+
+// get 获取主机的SSH客户端并标记为使用中，不存在时新建连接
+func (p *clientPool) get(host config.Host) (*pooledClient, error) {
+	key := poolKey(host)
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil, fmt.Errorf("SSH连接池已关闭")
+	}
+	p.evictIdleLocked()
+	if pc, exists := p.clients[key]; exists {
+		pc.inUse++
+		p.mu.Unlock()
+		return pc, nil
+	}
+	p.mu.Unlock()
+
+	// 建立连接时不持有锁，避免慢速主机阻塞其他主机
+	// 同时建立的连接数受全局并发数限制，避免压垮跳板机或本机
+	slots := dialSlots
+	slots <- struct{}{}
+	connLimiter.wait(host)
+	client, err := dialNative(host)
+	<-slots
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		client.Close()
+		return nil, fmt.Errorf("SSH连接池已关闭")
+	}
+	// 并发情况下其他协程可能已建立连接，复用已有连接
+	if pc, exists := p.clients[key]; exists {
+		client.Close()
+		pc.inUse++
+		return pc, nil
+	}
+	pc := &pooledClient{client: client, lastUsed: time.Now(), inUse: 1}
+	p.clients[key] = pc
+	return pc, nil
+}
+
+// release 会话结束后释放连接的使用标记
+func (p *clientPool) release(pc *pooledClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pc.inUse--
+	pc.lastUsed = time.Now()
+}
+
+// newSession 从连接池获取客户端并创建会话，连接失效时重新建立一次
+// 返回的 release 函数需在会话结束后调用
+func (p *clientPool) newSession(host config.Host) (*ssh.Session, func(), error) {
+	pc, err := p.get(host)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	session, err := pc.client.NewSession()
+	if err != nil {
+		// 连接可能已被服务端断开，移除后重试
+		p.release(pc)
+		p.remove(host, pc)
+		if pc, err = p.get(host); err != nil {
+			return nil, nil, err
+		}
+		if session, err = pc.client.NewSession(); err != nil {
+			p.release(pc)
+			return nil, nil, fmt.Errorf("创建SSH会话失败: %w", err)
+		}
+	}
+
+	release := func() {
+		session.Close()
+		p.release(pc)
+	}
+	return session, release, nil
+}
+
+// remove 关闭并移除指定主机的失效连接
+func (p *clientPool) remove(host config.Host, pc *pooledClient) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key := poolKey(host)
+	if current, exists := p.clients[key]; exists && current == pc {
+		delete(p.clients, key)
+	}
+	pc.client.Close()
+}
+
+// evictIdleLocked 关闭空闲超时的连接，调用方需持有锁
+func (p *clientPool) evictIdleLocked() {
+	for key, pc := range p.clients {
+		if pc.inUse == 0 && time.Since(pc.lastUsed) > poolIdleTimeout {
+			pc.client.Close()
+			delete(p.clients, key)
+		}
+	}
+}
+
+// closeAll 关闭连接池中的所有连接
+func (p *clientPool) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for key, pc := range p.clients {
+		pc.client.Close()
+		delete(p.clients, key)
+	}
+	p.closed = true
+}
+
+// CloseAll 关闭native后端的所有SSH连接，在程序退出或中断时调用
+func CloseAll() {
+	defaultPool.closeAll()
+}
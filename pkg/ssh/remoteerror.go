@@ -0,0 +1,69 @@
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RemoteCommandError 远程命令执行失败，分别保留主机、命令、退出码、标准输出和标准错误，
+// 便于按退出码分支处理和输出诊断信息。通过 errors.As 获取，Unwrap 返回底层错误，ExitCode 仍然可用
+type RemoteCommandError struct {
+	Host     string
+	Command  string
+	ExitCode int    // 远程命令的退出码，命令未执行完成（连接或认证失败、超时、取消）时为-1
+	Stdout   string // 标准输出，CombinedOutput 时为合并后的输出
+	Stderr   string // 标准错误，CombinedOutput 时为空
+	Err      error
+}
+
+func (e *RemoteCommandError) Error() string {
+	if stderr := strings.TrimSpace(e.Stderr); stderr != "" {
+		return fmt.Sprintf("%v: %s", e.Err, stderr)
+	}
+	return e.Err.Error()
+}
+
+func (e *RemoteCommandError) Unwrap() error {
+	return e.Err
+}
+
+// Exited 远程命令是否执行完成并以非零退出码结束，为false时表示命令未能执行
+func (e *RemoteCommandError) Exited() bool {
+	return e.ExitCode >= 0
+}
+
+// HasExitCode 检查错误是否为远程命令以指定退出码结束
+func HasExitCode(err error, code int) bool {
+	var remoteErr *RemoteCommandError
+	return errors.As(err, &remoteErr) && remoteErr.Exited() && remoteErr.ExitCode == code
+}
+
+// wrapError 将命令执行错误包装为 RemoteCommandError，文件传输和已包装的错误原样返回
+func (c *Command) wrapError(err error, stdout, stderr []byte) error {
+	if err == nil || c.isCopy {
+		return err
+	}
+	var remoteErr *RemoteCommandError
+	if errors.As(err, &remoteErr) {
+		return err
+	}
+	return &RemoteCommandError{
+		Host:     c.host.IP,
+		Command:  c.command,
+		ExitCode: remoteExitCode(err),
+		Stdout:   string(stdout),
+		Stderr:   string(stderr),
+		Err:      err,
+	}
+}
+
+// remoteExitCode 获取远程命令的退出码：被终止的进程退出码为-1；
+// 系统ssh以255退出表示连接或认证失败，而不是远程命令的退出码
+func remoteExitCode(err error) int {
+	code, exited := ExitCode(err)
+	if !exited || code < 0 || (code == 255 && currentBackend != BackendNative) {
+		return -1
+	}
+	return code
+}
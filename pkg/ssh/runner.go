@@ -0,0 +1,69 @@
+package ssh
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// Runner 执行远程命令并返回标准输出、标准错误和退出码，便于在测试中替换为返回固定结果的假实现
+// 远程命令以非零退出码结束时 err 为nil，只有命令无法执行（连接失败、超时、取消）时返回错误
+type Runner interface {
+	Run(ctx context.Context, host config.Host, command string) (stdout, stderr string, exitCode int, err error)
+}
+
+// CommandBuilder 按主机的认证和提权方式构造远程命令，即各模块的 buildSSHCommand
+type CommandBuilder func(host config.Host, command string) *Command
+
+// NewRunner 返回通过 build 构造的SSH命令执行的 Runner
+func NewRunner(build CommandBuilder) Runner {
+	return commandRunner{build: build}
+}
+
+type commandRunner struct {
+	build CommandBuilder
+}
+
+func (r commandRunner) Run(ctx context.Context, host config.Host, command string) (string, string, int, error) {
+	stdout, stderr, err := r.build(host, command).WithContext(ctx).Streams()
+	if err == nil {
+		return string(stdout), string(stderr), 0, nil
+	}
+
+	// 远程命令以非零退出码结束时返回退出码，命令未能执行时返回错误（错误信息中包含标准错误）
+	var remoteErr *RemoteCommandError
+	if errors.As(err, &remoteErr) && remoteErr.Exited() {
+		return string(stdout), string(stderr), remoteErr.ExitCode, nil
+	}
+	return string(stdout), string(stderr), -1, err
+}
+
+// NewRunnerCommand 创建通过 runner 执行的远程命令，Run/Output/CombinedOutput 的行为与SSH命令一致，
+// 非零退出码以 ExitCode 可识别的错误返回。各模块设置 Runner 后，已有的命令调用无需修改
+func NewRunnerCommand(runner Runner, host config.Host, command string) *Command {
+	return &Command{
+		host:    host,
+		command: command,
+		runner:  runner,
+	}
+}
+
+// runWithRunner 通过 Runner 执行命令，将非零退出码转换为错误
+func (c *Command) runWithRunner() ([]byte, []byte, error) {
+	stdout, stderr, code, err := c.runner.Run(c.context(), c.host, c.command)
+	if err == nil && code != 0 {
+		err = &runnerExitError{code: code}
+	}
+	return []byte(stdout), []byte(stderr), err
+}
+
+// runnerExitError 通过 Runner 执行的命令以非零退出码结束
+type runnerExitError struct {
+	code int
+}
+
+func (e *runnerExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
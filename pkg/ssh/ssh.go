@@ -7,9 +7,9 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
-	"time"
 
 	"golang.org/x/crypto/ssh"
 	"golang.org/x/term"
@@ -76,7 +76,7 @@ func CopySSHKeyWithSSHCopyID(keyPair *SSHKeyPair, host config.Host) error {
 	fmt.Printf("正在为主机 %s 配置SSH免密登录...\n", host.IP)
 	
 	// 构建 ssh-copy-id 命令
-	args := []string{"-i", keyPair.PublicKeyPath}
+	args := []string{"-i", keyPair.PublicKeyPath, "-p", strconv.Itoa(host.SSHPort())}
 	args = append(args, fmt.Sprintf("%s@%s", host.User, host.IP))
 
 	cmd := exec.Command("ssh-copy-id", args...)
@@ -97,7 +97,7 @@ func CopySSHKeyWithExpect(keyPair *SSHKeyPair, host config.Host, password string
 	// 创建临时expect脚本
 	expectScript := fmt.Sprintf(`#!/usr/bin/expect -f
 set timeout 30
-spawn ssh-copy-id -i %s %s@%s
+spawn ssh-copy-id -i %s -p %d %s@%s
 expect {
     "Are you sure you want to continue connecting" {
         send "yes\r"
@@ -111,7 +111,7 @@ expect {
     }
 }
 expect eof
-`, keyPair.PublicKeyPath, host.User, host.IP, password, password)
+`, keyPair.PublicKeyPath, host.SSHPort(), host.User, host.IP, password, password)
 
 	// 写入临时文件
 	tmpFile, err := ioutil.TempFile("", "ssh-copy-expect-*.exp")
@@ -143,7 +143,7 @@ expect eof
 func TestSSHConnection(host config.Host) error {
 	fmt.Printf("测试到主机 %s 的SSH连接...\n", host.IP)
 	
-	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=5"}
+	args := []string{"-o", "BatchMode=yes", "-o", ConnectTimeoutOption(), "-o", PortOption(host)}
 	args = append(args, fmt.Sprintf("%s@%s", host.User, host.IP), "echo", "SSH连接成功")
 
 	cmd := exec.Command("ssh", args...)
@@ -303,11 +303,11 @@ func CopySSHKeyWithNativeGo(keyPair *SSHKeyPair, host config.Host, password stri
 			ssh.Password(password),
 		},
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // 注意：生产环境中应该验证主机密钥
-		Timeout:         10 * time.Second,
+		Timeout:         connectTimeout,
 	}
 
 	// 连接SSH
-	addr := net.JoinHostPort(host.IP, "22")
+	addr := net.JoinHostPort(host.IP, strconv.Itoa(host.SSHPort()))
 	client, err := ssh.Dial("tcp", addr, config)
 	if err != nil {
 		return fmt.Errorf("SSH连接失败: %w", err)
@@ -366,11 +366,11 @@ func TestSSHConnectionWithKey(host config.Host, keyPair *SSHKeyPair) error {
 			ssh.PublicKeys(signer),
 		},
 		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         5 * time.Second,
+		Timeout:         connectTimeout,
 	}
 
 	// 连接SSH
-	addr := net.JoinHostPort(host.IP, "22")
+	addr := net.JoinHostPort(host.IP, strconv.Itoa(host.SSHPort()))
 	client, err := ssh.Dial("tcp", addr, config)
 	if err != nil {
 		return fmt.Errorf("SSH密钥认证失败: %w", err)
@@ -402,7 +402,8 @@ func isSSHPasswordlessWorking(host config.Host) bool {
 	// 构建SSH测试命令，使用BatchMode禁止交互式输入
 	args := []string{
 		"-o", "BatchMode=yes",           // 禁止交互式输入
-		"-o", "ConnectTimeout=5",        // 连接超时5秒
+		"-o", ConnectTimeoutOption(),    // 连接超时，见 ssh.connect_timeout
+		"-o", PortOption(host),          // 主机的SSH端口，见 port/default_port
 		"-o", "StrictHostKeyChecking=no", // 跳过主机密钥检查
 		"-o", "UserKnownHostsFile=/dev/null", // 不使用known_hosts文件
 		fmt.Sprintf("%s@%s", host.User, host.IP),
@@ -420,6 +421,29 @@ func isSSHPasswordlessWorking(host config.Host) bool {
 	return false
 }
 
+// CheckSSHPassAvailable 检查配置了密码认证的主机是否具备sshpass，缺失时立即返回错误
+func CheckSSHPassAvailable(hosts []config.Host) error {
+	var passwordHosts []string
+	for _, host := range hosts {
+		if host.Password != "" {
+			passwordHosts = append(passwordHosts, host.IP)
+		}
+	}
+	// native后端直接使用密码认证，不依赖sshpass
+	if len(passwordHosts) == 0 || currentBackend == BackendNative {
+		return nil
+	}
+
+	if _, err := exec.LookPath("sshpass"); err != nil {
+		return fmt.Errorf("主机 %s 配置了密码认证，但本机未找到sshpass。"+
+			"请安装sshpass（macOS: brew install hudochenkov/sshpass/sshpass，Ubuntu: apt-get install sshpass，CentOS: yum install sshpass），"+
+			"或改用ssh_key认证（可执行 roi ssh-setup --method expect 配置免密登录），也可以使用 --ssh-backend=native",
+			strings.Join(passwordHosts, ", "))
+	}
+
+	return nil
+}
+
 // DetectBestSSHMethod 检测最佳SSH设置方法
 func DetectBestSSHMethod() SetupSSHMethod {
 	// 检查expect是否可用
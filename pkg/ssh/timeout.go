@@ -0,0 +1,92 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"github.com/rainbond/rainbond-offline-installer/pkg/config"
+)
+
+// DefaultConnectTimeout 未配置 ssh.connect_timeout 时的SSH连接超时
+const DefaultConnectTimeout = 10 * time.Second
+
+// execWaitDelay 命令超时被终止后等待输出管道关闭的时间，避免ssh子进程遗留时阻塞
+const execWaitDelay = 5 * time.Second
+
+var (
+	connectTimeout = DefaultConnectTimeout
+	commandTimeout time.Duration // 0表示不限制
+)
+
+// SetTimeouts 设置全局SSH连接超时和远程命令超时，connect<=0时使用默认值，command<=0表示不限制
+func SetTimeouts(connect, command time.Duration) {
+	if connect <= 0 {
+		connect = DefaultConnectTimeout
+	}
+	if command < 0 {
+		command = 0
+	}
+	connectTimeout = connect
+	commandTimeout = command
+}
+
+// ConnectTimeout 获取当前SSH连接超时
+func ConnectTimeout() time.Duration {
+	return connectTimeout
+}
+
+// CommandTimeout 获取当前远程命令超时，0表示不限制
+func CommandTimeout() time.Duration {
+	return commandTimeout
+}
+
+// ConnectTimeoutOption 返回系统ssh/scp/rsync使用的 ConnectTimeout 选项值，配合 -o 使用
+func ConnectTimeoutOption() string {
+	seconds := int(connectTimeout / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return fmt.Sprintf("ConnectTimeout=%d", seconds)
+}
+
+// runExec 执行exec后端命令，配置了命令超时或设置了可取消的上下文时通过 exec.CommandContext 终止ssh进程
+// 文件传输不受命令超时限制，大文件传输的耗时由网络带宽决定
+func (c *Command) runExec(run func(cmd *exec.Cmd) ([]byte, error)) ([]byte, error) {
+	// 每条exec命令都会新建SSH连接，按主机限速
+	connLimiter.wait(c.host)
+
+	parent := c.context()
+	ctx := parent
+	if commandTimeout > 0 && !c.isCopy {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, commandTimeout)
+		defer cancel()
+	}
+	if ctx.Done() == nil {
+		return run(c.execCmd)
+	}
+
+	cmd := exec.CommandContext(ctx, c.execCmd.Path, c.execCmd.Args[1:]...)
+	cmd.Env = c.execCmd.Env
+	cmd.Dir = c.execCmd.Dir
+	cmd.Stdin = c.execCmd.Stdin
+	cmd.Stdout = c.execCmd.Stdout
+	cmd.Stderr = c.execCmd.Stderr
+	cmd.WaitDelay = execWaitDelay
+
+	output, err := run(cmd)
+	if err != nil && parent.Err() != nil {
+		return output, fmt.Errorf("主机 %s 远程命令已取消: %w", c.host.IP, parent.Err())
+	}
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return output, fmt.Errorf("主机 %s 远程命令执行超时（%s）: %w", c.host.IP, commandTimeout, err)
+	}
+	return output, err
+}
+
+// PortOption 返回系统ssh/scp/rsync连接主机使用的 Port 选项值，配合 -o 使用
+func PortOption(host config.Host) string {
+	return fmt.Sprintf("Port=%d", host.SSHPort())
+}
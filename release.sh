@@ -87,7 +87,7 @@ download_rainbond_chart() {
 build_roi() {
 docker run --rm -v "$(pwd)":/workspace -w /workspace -e GOPROXY=https://goproxy.cn,direct -e GOSUMDB=sum.golang.google.cn \
   registry.cn-hangzhou.aliyuncs.com/zqqq/golang:1.24 \
-  sh -c "go mod tidy && go build -o roi cmd/main.go"
+  sh -c "go mod tidy && go build -o roi ./cmd"
 
 }
 
@@ -115,7 +115,8 @@ main() {
   mv rke2.linux-"$ARCH".tar.gz "$offline_package_dir"
   mv rke2-install.sh "$offline_package_dir"
   mv sha256sum-"$ARCH".txt "$offline_package_dir"
-  mv helm "$offline_package_dir"
+  # helm is only needed to pull the chart; roi installs it through the Helm SDK
+  rm -f helm
 
   tar -zcvf rainbond-offline-installer-package-${VERSION%-release}-"$ARCH".tar.gz "$offline_package_dir"
   upload_tos